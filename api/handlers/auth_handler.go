@@ -9,6 +9,7 @@ import (
 	"hifzhun-api/pkg/utils"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 )
 
 type AuthHandler struct {
@@ -112,7 +113,7 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 
 // Login godoc
 // @Summary Login user
-// @Description Authenticate user and return JWT token
+// @Description Authenticate user and return JWT token. Students imported from a class roster may log in with their username instead of an email; must_change_password is true until they replace their one-time password.
 // @Tags Auth
 // @Accept json
 // @Produce json
@@ -123,6 +124,7 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 func (h *AuthHandler) Login(c *fiber.Ctx) error {
 	var req struct {
 		Email    string `json:"email"`
+		Username string `json:"username"`
 		Password string `json:"password"`
 	}
 
@@ -136,7 +138,12 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 		)
 	}
 
-	user, token, err := h.authUC.Login(req.Email, req.Password)
+	login := req.Email
+	if login == "" {
+		login = req.Username
+	}
+
+	user, token, err := h.authUC.Login(login, req.Password)
 	if err != nil {
 		return utils.Error(
 			c,
@@ -158,11 +165,40 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 			"role":       user.Role,
 			"is_premium": user.IsPremium,
 			"token":      token,
+
+			"must_change_password": user.MustChangePassword,
 		},
 		nil,
 	)
 }
 
+// ChangePassword godoc
+// @Summary Change password
+// @Description Replace the current password (at least 8 characters). Clears must_change_password of accounts created with a one-time password and returns a new token; while the flag is set every other authenticated route answers 403 PASSWORD_CHANGE_REQUIRED.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body ChangePasswordRequest true "Change password request"
+// @Success 200 {object} utils.SuccessResponse{data=ChangePasswordResponse}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /auth/change-password [post]
+func (h *AuthHandler) ChangePassword(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	var req ChangePasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, "invalid request body", "BAD_REQUEST", nil)
+	}
+
+	token, err := h.authUC.ChangePassword(userID.String(), req.OldPassword, req.NewPassword)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "CHANGE_PASSWORD_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "password changed successfully", fiber.Map{"token": token}, nil)
+}
+
 // ==================== REQUEST/RESPONSE MODELS ====================
 
 // RegisterRequest represents register request body
@@ -183,9 +219,21 @@ type RegisterResponse struct {
 // LoginRequest represents login request body
 type LoginRequest struct {
 	Email    string `json:"email" example:"user@example.com"`
+	Username string `json:"username,omitempty" example:"ahmad.fauzi"` // instead of email, for roster-imported students
 	Password string `json:"password" example:"password123"`
 }
 
+// ChangePasswordRequest represents a password change
+type ChangePasswordRequest struct {
+	OldPassword string `json:"old_password" example:"K7M2P9QX4T"`
+	NewPassword string `json:"new_password" example:"rahasia-baru-123"`
+}
+
+// ChangePasswordResponse carries the token to use after a password change
+type ChangePasswordResponse struct {
+	Token string `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+}
+
 // LoginResponse represents login response data
 type LoginResponse struct {
 	ID        string `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
@@ -194,4 +242,6 @@ type LoginResponse struct {
 	Role      string `json:"role" example:"student"`
 	IsPremium bool   `json:"is_premium" example:"false"`
 	Token     string `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+
+	MustChangePassword bool `json:"must_change_password" example:"false"`
 }
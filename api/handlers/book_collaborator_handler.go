@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"hifzhun-api/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// InviteCollaboratorRequest represents a co-author invitation
+type InviteCollaboratorRequest struct {
+	Email string `json:"email" example:"ustadz@example.com"`
+	Role  string `json:"role" example:"editor"` // editor | viewer, default editor
+}
+
+// AcceptBookInvitationRequest carries the token returned when the invitation
+// was created
+type AcceptBookInvitationRequest struct {
+	Token string `json:"token" example:"9f86d081884c7d65..."`
+}
+
+// UpdateCollaboratorRequest represents a collaborator role change
+type UpdateCollaboratorRequest struct {
+	Role string `json:"role" example:"viewer"`
+}
+
+// InviteCollaborator godoc
+// @Summary Invite a co-author (Owner)
+// @Description Invite an email address as editor (can change modules and items) or viewer (read-only, drafts included). The response carries the invitation token, shown only once; the owner shares it with the invitee, who needs it to accept. Inviting the same email again changes the role and, while the invitation is pending, issues a new token.
+// @Tags Book Collaborators
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Book ID"
+// @Param request body InviteCollaboratorRequest true "Invitation"
+// @Success 201 {object} utils.SuccessResponse{data=entities.BookCollaborator}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /books/{id}/collaborators [post]
+func (h *BookHandler) InviteCollaborator(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	var req InviteCollaboratorRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, "invalid request body", "BAD_REQUEST", nil)
+	}
+
+	collaborator, err := h.bookSvc.InviteCollaborator(c.Params("id"), userID, req.Email, req.Role)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "INVITE_COLLABORATOR_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusCreated, "collaborator invited successfully", collaborator, nil)
+}
+
+// GetCollaborators godoc
+// @Summary List co-authors of a book
+// @Description Invitations and collaborators of a book, for its owner and collaborators
+// @Tags Book Collaborators
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Book ID"
+// @Success 200 {object} utils.SuccessResponse{data=[]entities.BookCollaborator}
+// @Failure 403 {object} utils.ErrorResponse
+// @Router /books/{id}/collaborators [get]
+func (h *BookHandler) GetCollaborators(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	list, err := h.bookSvc.GetCollaborators(c.Params("id"), userID)
+	if err != nil {
+		return utils.Error(c, fiber.StatusForbidden, err.Error(), "GET_COLLABORATORS_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "collaborators fetched successfully", list, nil)
+}
+
+// UpdateCollaborator godoc
+// @Summary Change a co-author's role (Owner)
+// @Tags Book Collaborators
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Book ID"
+// @Param collaborator_id path string true "Collaborator ID"
+// @Param request body UpdateCollaboratorRequest true "Role"
+// @Success 200 {object} utils.SuccessResponse{data=entities.BookCollaborator}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /books/{id}/collaborators/{collaborator_id} [put]
+func (h *BookHandler) UpdateCollaborator(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	var req UpdateCollaboratorRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, "invalid request body", "BAD_REQUEST", nil)
+	}
+
+	collaborator, err := h.bookSvc.UpdateCollaboratorRole(c.Params("id"), userID, c.Params("collaborator_id"), req.Role)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "UPDATE_COLLABORATOR_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "collaborator updated successfully", collaborator, nil)
+}
+
+// RemoveCollaborator godoc
+// @Summary Remove a co-author or revoke an invitation
+// @Description The owner can remove anyone; a collaborator can remove themselves to leave the book
+// @Tags Book Collaborators
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Book ID"
+// @Param collaborator_id path string true "Collaborator ID"
+// @Success 200 {object} utils.SuccessResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /books/{id}/collaborators/{collaborator_id} [delete]
+func (h *BookHandler) RemoveCollaborator(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	if err := h.bookSvc.RemoveCollaborator(c.Params("id"), userID, c.Params("collaborator_id")); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "REMOVE_COLLABORATOR_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "collaborator removed successfully", nil, nil)
+}
+
+// GetMyBookInvitations godoc
+// @Summary My co-author invitations
+// @Description Open invitations sent to the current user's email
+// @Tags Book Collaborators
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.SuccessResponse{data=[]services.BookInvitation}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /books/invitations [get]
+func (h *BookHandler) GetMyBookInvitations(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	invitations, err := h.bookSvc.GetMyBookInvitations(userID)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "GET_INVITATIONS_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "invitations fetched successfully", invitations, nil)
+}
+
+// AcceptBookInvitation godoc
+// @Summary Accept a co-author invitation
+// @Description The invitation must be addressed to the current user's email and the token from the invitation must be given.
+// @Tags Book Collaborators
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Invitation ID"
+// @Param request body AcceptBookInvitationRequest true "Invitation token"
+// @Success 200 {object} utils.SuccessResponse{data=entities.BookCollaborator}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /books/invitations/{id}/accept [post]
+func (h *BookHandler) AcceptBookInvitation(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	var req AcceptBookInvitationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, "invalid request body", "BAD_REQUEST", nil)
+	}
+
+	collaborator, err := h.bookSvc.RespondToBookInvitation(c.Params("id"), userID, true, req.Token)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "ACCEPT_INVITATION_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "invitation accepted successfully", collaborator, nil)
+}
+
+// DeclineBookInvitation godoc
+// @Summary Decline a co-author invitation
+// @Tags Book Collaborators
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Invitation ID"
+// @Success 200 {object} utils.SuccessResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /books/invitations/{id}/decline [post]
+func (h *BookHandler) DeclineBookInvitation(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	if _, err := h.bookSvc.RespondToBookInvitation(c.Params("id"), userID, false, ""); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "DECLINE_INVITATION_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "invitation declined successfully", nil, nil)
+}
+
+// GetSharedBooks godoc
+// @Summary Books shared with me
+// @Description Books the current user co-authors, with their role
+// @Tags Book Collaborators
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.SuccessResponse{data=[]services.SharedBook}
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /books/shared [get]
+func (h *BookHandler) GetSharedBooks(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	books, err := h.bookSvc.GetSharedBooks(userID)
+	if err != nil {
+		return utils.Error(c, fiber.StatusInternalServerError, err.Error(), "GET_SHARED_BOOKS_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "shared books fetched successfully", books, nil)
+}
+
+// GetBookActivity godoc
+// @Summary Book activity log
+// @Description Who changed which module/item and collaborator changes, most recent first. Visible to the owner and collaborators.
+// @Tags Book Collaborators
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Book ID"
+// @Param page query int false "Page (default 1)"
+// @Param per_page query int false "Per page (default 20)"
+// @Success 200 {object} utils.SuccessResponse{data=[]entities.BookActivity}
+// @Failure 403 {object} utils.ErrorResponse
+// @Router /books/{id}/activity [get]
+func (h *BookHandler) GetBookActivity(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	page, perPage := pageParams(c)
+
+	activity, total, err := h.bookSvc.GetBookActivity(c.Params("id"), userID, page, perPage)
+	if err != nil {
+		return utils.Error(c, fiber.StatusForbidden, err.Error(), "GET_BOOK_ACTIVITY_FAILED", nil)
+	}
+
+	meta := &utils.Meta{Page: page, PerPage: perPage, Total: int(total)}
+	return utils.Success(c, fiber.StatusOK, "book activity fetched successfully", activity, meta)
+}
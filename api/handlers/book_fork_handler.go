@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"hifzhun-api/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// GetUpstreamStatus godoc
+// @Summary Get upstream changes of a forked book (Owner)
+// @Description For a book copied from the catalog (copy-to-draft), show the source book and the approved content versions the copy has not pulled yet
+// @Tags Book
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Book ID"
+// @Success 200 {object} utils.SuccessResponse{data=services.UpstreamStatus}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /books/{id}/upstream [get]
+func (h *BookHandler) GetUpstreamStatus(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	bookID := c.Params("id")
+
+	status, err := h.bookSvc.GetUpstreamStatus(bookID, userID)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "GET_UPSTREAM_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "upstream status fetched successfully", status, nil)
+}
+
+// PullUpstreamChanges godoc
+// @Summary Pull upstream changes into a forked book (Owner)
+// @Description Apply the source book's approved module/item changes since the copy was made (or last pulled). Draft copies are updated directly; published copies get the changes in their draft content revision.
+// @Tags Book
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Book ID"
+// @Success 200 {object} utils.SuccessResponse{data=services.UpstreamPullResult}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /books/{id}/upstream/pull [post]
+func (h *BookHandler) PullUpstreamChanges(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	bookID := c.Params("id")
+
+	result, err := h.bookSvc.PullUpstreamChanges(bookID, userID)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "PULL_UPSTREAM_FAILED", nil)
+	}
+
+	if !result.InDraftRevision {
+		h.cache.DeleteByPattern(c.Context(), "myitems:"+userID.String()+":*")
+	}
+
+	return utils.Success(c, fiber.StatusOK, "upstream changes pulled successfully", result, nil)
+}
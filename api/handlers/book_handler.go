@@ -1,12 +1,15 @@
 package handlers
 
 import (
+	"encoding/json"
 	"fmt"
 	"mime/multipart"
 	"strconv"
+	"strings"
 	"time"
 
 	"hifzhun-api/pkg/cache"
+	"hifzhun-api/pkg/entities"
 	"hifzhun-api/pkg/repositories"
 	"hifzhun-api/pkg/services"
 	"hifzhun-api/pkg/utils"
@@ -86,6 +89,26 @@ func (h *BookHandler) uploadItemImageIfPremium(c *fiber.Ctx, userID uuid.UUID) (
 	return imageURL, nil
 }
 
+// itemTypeFromForm reads the optional "type" and "distractors" form fields.
+// distractors is a JSON array of strings. Returns nil when neither is set.
+func itemTypeFromForm(c *fiber.Ctx) (*services.BookItemTypeInput, error) {
+	itemType := strings.TrimSpace(c.FormValue("type"))
+	raw := strings.TrimSpace(c.FormValue("distractors"))
+	if itemType == "" && raw == "" {
+		return nil, nil
+	}
+	in := &services.BookItemTypeInput{Type: itemType}
+	if raw != "" {
+		if err := json.Unmarshal([]byte(raw), &in.Distractors); err != nil {
+			return nil, fmt.Errorf("distractors must be a JSON array of strings")
+		}
+		if in.Distractors == nil {
+			in.Distractors = []string{}
+		}
+	}
+	return in, nil
+}
+
 // ==================== BOOK ENDPOINTS ====================
 
 // CreateBook godoc
@@ -138,29 +161,83 @@ func (h *BookHandler) GetMyBooks(c *fiber.Ctx) error {
 
 // GetPublishedBooks godoc
 // @Summary Get published books
-// @Description Get all published/approved books
+// @Description Search and browse published books. q matches book title/description and item title/content (Arabic harakat and hamza forms are ignored, words match as prefixes). Results are paged with a cursor: pass meta.next_cursor as cursor to get the next page.
 // @Tags Book
 // @Accept json
 // @Produce json
-// @Success 200 {object} utils.SuccessResponse{data=[]entities.Book}
-// @Failure 500 {object} utils.ErrorResponse
+// @Param q query string false "Search text"
+// @Param category query string false "Category"
+// @Param tags query string false "Comma-separated tags, books must have all of them"
+// @Param sort query string false "newest (default) | popular"
+// @Param cursor query string false "Cursor from meta.next_cursor"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Success 200 {object} utils.SuccessResponse{data=[]services.PublishedBookWithStats,meta=utils.Meta}
+// @Failure 400 {object} utils.ErrorResponse
 // @Router /books/published [get]
 func (h *BookHandler) GetPublishedBooks(c *fiber.Ctx) error {
-	// Try cache first
+	query := services.CatalogQuery{
+		Q:        c.Query("q"),
+		Category: c.Query("category"),
+		Sort:     c.Query("sort"),
+		Cursor:   c.Query("cursor"),
+		Limit:    c.QueryInt("limit", 0),
+	}
+	if tags := strings.TrimSpace(c.Query("tags")); tags != "" {
+		query.Tags = strings.Split(tags, ",")
+	}
+
+	// Only the unfiltered first page is cached
 	cacheKey := "books:published"
-	var cached []services.PublishedBookWithStats
-	if h.cache.Get(c.Context(), cacheKey, &cached) {
-		return utils.Success(c, fiber.StatusOK, "published books fetched successfully", cached, nil)
+	if query.IsDefault() {
+		var cached services.PublishedBookPage
+		if h.cache.Get(c.Context(), cacheKey, &cached) {
+			return utils.Success(c, fiber.StatusOK, "published books fetched successfully", cached.Books, &cached.Meta)
+		}
 	}
 
-	books, err := h.bookSvc.GetPublishedBooks()
+	page, err := h.bookSvc.GetPublishedBooks(query)
 	if err != nil {
-		return utils.Error(c, fiber.StatusInternalServerError, err.Error(), "GET_BOOKS_FAILED", nil)
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "GET_BOOKS_FAILED", nil)
 	}
 
-	h.cache.Set(c.Context(), cacheKey, books, 30*time.Minute)
+	if query.IsDefault() {
+		h.cache.Set(c.Context(), cacheKey, page, 30*time.Minute)
+	}
 
-	return utils.Success(c, fiber.StatusOK, "published books fetched successfully", books, nil)
+	return utils.Success(c, fiber.StatusOK, "published books fetched successfully", page.Books, &page.Meta)
+}
+
+// UpdateBookCatalogInfo godoc
+// @Summary Update book category and tags (Owner)
+// @Description Set the category and tags used to filter the published catalog. Applies directly, also for published books.
+// @Tags Book
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Book ID"
+// @Param request body UpdateBookCatalogRequest true "Catalog info"
+// @Success 200 {object} utils.SuccessResponse{data=entities.Book}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /books/{id}/catalog [put]
+func (h *BookHandler) UpdateBookCatalogInfo(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	bookID := c.Params("id")
+
+	var req UpdateBookCatalogRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, "invalid request body", "BAD_REQUEST", nil)
+	}
+
+	book, err := h.bookSvc.UpdateBookCatalogInfo(bookID, userID, req.Category, req.Tags)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "UPDATE_CATALOG_FAILED", nil)
+	}
+
+	if book.Status == entities.BookStatusPublished {
+		h.cache.Delete(c.Context(), "books:published")
+	}
+
+	return utils.Success(c, fiber.StatusOK, "book catalog info updated successfully", book, nil)
 }
 
 // GetBookDetail godoc
@@ -275,7 +352,7 @@ func (h *BookHandler) DeleteBook(c *fiber.Ctx) error {
 
 // RequestPublish godoc
 // @Summary Request book publish
-// @Description Submit book for admin approval. Set is_editable=true to allow users who import/copy this book to edit items and modules; false to make it read-only for them.
+// @Description Submit book for admin approval. Set is_editable=true to allow users who import/copy this book to edit items and modules; false to make it read-only for them. When resubmitting a rejected book, note can tell the reviewer what was fixed.
 // @Tags Book
 // @Accept json
 // @Produce json
@@ -289,16 +366,14 @@ func (h *BookHandler) RequestPublish(c *fiber.Ctx) error {
 	userID := c.Locals("user_id").(uuid.UUID)
 	bookID := c.Params("id")
 
-	var req struct {
-		IsEditable bool `json:"is_editable"`
-	}
+	var req RequestPublishRequest
 	// Default is_editable to true if body is empty or field not provided
 	req.IsEditable = true
 	if err := c.BodyParser(&req); err != nil {
 		// Body is optional; keep default
 	}
 
-	if err := h.bookSvc.RequestPublish(bookID, userID, req.IsEditable); err != nil {
+	if err := h.bookSvc.RequestPublish(bookID, userID, req.IsEditable, req.Note); err != nil {
 		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "REQUEST_PUBLISH_FAILED", nil)
 	}
 
@@ -370,6 +445,9 @@ func (h *BookHandler) CopyPublishedBookToDraft(c *fiber.Ctx) error {
 		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "COPY_PUBLISHED_BOOK_FAILED", nil)
 	}
 
+	// Catalog shows fork counts
+	h.cache.Delete(c.Context(), "books:published")
+
 	return utils.Success(c, fiber.StatusOK, "published book copied to draft successfully", book, nil)
 }
 
@@ -397,20 +475,25 @@ func (h *BookHandler) GetPendingBooks(c *fiber.Ctx) error {
 
 // ApproveBook godoc
 // @Summary Approve book (Admin)
-// @Description Approve a pending book for publishing
+// @Description Approve a pending book for publishing. An optional reason and inline comments are kept in the moderation history.
 // @Tags Book Admin
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param id path string true "Book ID"
+// @Param request body ModerateBookRequest false "Optional note and comments"
 // @Success 200 {object} utils.SuccessResponse
 // @Failure 400 {object} utils.ErrorResponse
 // @Failure 403 {object} utils.ErrorResponse
 // @Router /admin/books/{id}/approve [post]
 func (h *BookHandler) ApproveBook(c *fiber.Ctx) error {
+	adminID := c.Locals("user_id").(uuid.UUID)
 	bookID := c.Params("id")
 
-	if err := h.bookSvc.ApproveBook(bookID); err != nil {
+	var req ModerateBookRequest
+	_ = c.BodyParser(&req) // Body is optional
+
+	if err := h.bookSvc.ApproveBook(bookID, adminID, req.Reason, req.Comments); err != nil {
 		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "APPROVE_BOOK_FAILED", nil)
 	}
 
@@ -422,26 +505,56 @@ func (h *BookHandler) ApproveBook(c *fiber.Ctx) error {
 
 // RejectBook godoc
 // @Summary Reject book (Admin)
-// @Description Reject a pending book
+// @Description Reject a pending book. A reason or at least one inline comment (target_type module|item, target_id, comment) is required so the author knows what to fix.
 // @Tags Book Admin
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param id path string true "Book ID"
+// @Param request body ModerateBookRequest true "Reason and comments"
 // @Success 200 {object} utils.SuccessResponse
 // @Failure 400 {object} utils.ErrorResponse
 // @Failure 403 {object} utils.ErrorResponse
 // @Router /admin/books/{id}/reject [post]
 func (h *BookHandler) RejectBook(c *fiber.Ctx) error {
+	adminID := c.Locals("user_id").(uuid.UUID)
 	bookID := c.Params("id")
 
-	if err := h.bookSvc.RejectBook(bookID); err != nil {
+	var req ModerateBookRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, "invalid request body", "BAD_REQUEST", nil)
+	}
+
+	if err := h.bookSvc.RejectBook(bookID, adminID, req.Reason, req.Comments); err != nil {
 		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "REJECT_BOOK_FAILED", nil)
 	}
 
 	return utils.Success(c, fiber.StatusOK, "book rejected successfully", nil, nil)
 }
 
+// GetModerationTimeline godoc
+// @Summary Get book moderation history
+// @Description Get every publish submission and admin decision (with reasons and inline comments) of a book. Available to the book owner and admins.
+// @Tags Book
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Book ID"
+// @Success 200 {object} utils.SuccessResponse{data=services.BookModerationTimeline}
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /books/{id}/moderation [get]
+// @Router /admin/books/{id}/moderation [get]
+func (h *BookHandler) GetModerationTimeline(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	role, _ := c.Locals("role").(string)
+
+	timeline, err := h.bookSvc.GetModerationTimeline(c.Params("id"), userID, role)
+	if err != nil {
+		return utils.Error(c, fiber.StatusNotFound, err.Error(), "GET_MODERATION_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "moderation history fetched successfully", timeline, nil)
+}
+
 // GetPendingBookUpdates godoc
 // @Summary Get pending book updates (Admin)
 // @Description Get all pending book update requests
@@ -517,31 +630,6 @@ func (h *BookHandler) RejectBookUpdate(c *fiber.Ctx) error {
 	return utils.Success(c, fiber.StatusOK, "book update rejected successfully", nil, nil)
 }
 
-// DeletePublishedBook godoc
-// @Summary Delete published book (Admin)
-// @Description Delete a published book and all its modules/items
-// @Tags Book Admin
-// @Accept json
-// @Produce json
-// @Security BearerAuth
-// @Param id path string true "Book ID"
-// @Success 200 {object} utils.SuccessResponse
-// @Failure 400 {object} utils.ErrorResponse
-// @Failure 403 {object} utils.ErrorResponse
-// @Router /admin/books/{id} [delete]
-func (h *BookHandler) DeletePublishedBook(c *fiber.Ctx) error {
-	bookID := c.Params("id")
-
-	if err := h.bookSvc.DeletePublishedBook(bookID); err != nil {
-		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "DELETE_BOOK_FAILED", nil)
-	}
-
-	// Invalidate published books cache
-	h.cache.Delete(c.Context(), "books:published")
-
-	return utils.Success(c, fiber.StatusOK, "book deleted successfully", nil, nil)
-}
-
 // RequestBookUpdate godoc
 // @Summary Request book update (Owner)
 // @Description Request an update for a published book (requires admin approval)
@@ -765,6 +853,8 @@ func (h *BookHandler) DeleteModule(c *fiber.Ctx) error {
 // @Param title formData string false "Item title"
 // @Param content formData string true "Item content"
 // @Param answer formData string true "Item answer"
+// @Param type formData string false "Item type: basic (default), cloze or multiple_choice"
+// @Param distractors formData string false "Wrong options of a multiple_choice item, JSON array of strings"
 // @Param order formData int false "Item order"
 // @Param estimate_value formData int false "Estimate value"
 // @Param estimate_unit formData string false "Estimate unit (seconds or minutes)"
@@ -794,13 +884,18 @@ func (h *BookHandler) AddItemToBook(c *fiber.Ctx) error {
 		}
 	}
 
+	itemType, err := itemTypeFromForm(c)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "BAD_REQUEST", nil)
+	}
+
 	// Handle image upload (premium only)
 	imageURL, err := h.uploadItemImageIfPremium(c, userID)
 	if err != nil {
 		return utils.Error(c, fiber.StatusForbidden, err.Error(), "PREMIUM_REQUIRED", nil)
 	}
 
-	item, err := h.bookSvc.AddItem(bookID, nil, userID, title, content, answer, order, estimateValue, estimateUnit, imageURL)
+	item, err := h.bookSvc.AddItem(bookID, nil, userID, title, content, answer, order, estimateValue, estimateUnit, imageURL, itemType)
 	if err != nil {
 		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "ADD_ITEM_FAILED", nil)
 	}
@@ -820,6 +915,8 @@ func (h *BookHandler) AddItemToBook(c *fiber.Ctx) error {
 // @Param title formData string false "Item title"
 // @Param content formData string true "Item content"
 // @Param answer formData string true "Item answer"
+// @Param type formData string false "Item type: basic (default), cloze or multiple_choice"
+// @Param distractors formData string false "Wrong options of a multiple_choice item, JSON array of strings"
 // @Param order formData int false "Item order"
 // @Param estimate_value formData int false "Estimate value"
 // @Param estimate_unit formData string false "Estimate unit (seconds or minutes)"
@@ -855,13 +952,18 @@ func (h *BookHandler) AddItemToModule(c *fiber.Ctx) error {
 		}
 	}
 
+	itemType, err := itemTypeFromForm(c)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "BAD_REQUEST", nil)
+	}
+
 	// Handle image upload (premium only)
 	imageURL, err := h.uploadItemImageIfPremium(c, userID)
 	if err != nil {
 		return utils.Error(c, fiber.StatusForbidden, err.Error(), "PREMIUM_REQUIRED", nil)
 	}
 
-	item, err := h.bookSvc.AddItem(bookID, &moduleID, userID, title, content, answer, order, estimateValue, estimateUnit, imageURL)
+	item, err := h.bookSvc.AddItem(bookID, &moduleID, userID, title, content, answer, order, estimateValue, estimateUnit, imageURL, itemType)
 	if err != nil {
 		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "ADD_ITEM_FAILED", nil)
 	}
@@ -880,6 +982,8 @@ func (h *BookHandler) AddItemToModule(c *fiber.Ctx) error {
 // @Param title formData string false "Item title"
 // @Param content formData string false "Item content"
 // @Param answer formData string false "Item answer"
+// @Param type formData string false "Item type: basic, cloze or multiple_choice (empty keeps the current type)"
+// @Param distractors formData string false "Wrong options of a multiple_choice item, JSON array of strings"
 // @Param order formData int false "Item order"
 // @Param estimate_value formData int false "Estimate value"
 // @Param estimate_unit formData string false "Estimate unit (seconds or minutes)"
@@ -911,17 +1015,21 @@ func (h *BookHandler) UpdateItem(c *fiber.Ctx) error {
 		}
 	}
 
+	itemType, err := itemTypeFromForm(c)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "BAD_REQUEST", nil)
+	}
+
 	// Handle image upload (premium only); skip if remove_image is set
 	var imageURL string
 	if !removeImage {
-		var err error
 		imageURL, err = h.uploadItemImageIfPremium(c, userID)
 		if err != nil {
 			return utils.Error(c, fiber.StatusForbidden, err.Error(), "PREMIUM_REQUIRED", nil)
 		}
 	}
 
-	item, err := h.bookSvc.UpdateItem(itemID, userID, title, content, answer, order, estimateValue, estimateUnit, imageURL, removeImage)
+	item, err := h.bookSvc.UpdateItem(itemID, userID, title, content, answer, order, estimateValue, estimateUnit, imageURL, removeImage, itemType)
 	if err != nil {
 		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "UPDATE_ITEM_FAILED", nil)
 	}
@@ -955,7 +1063,14 @@ func (h *BookHandler) DeleteItem(c *fiber.Ctx) error {
 
 // RequestPublishRequest represents request publish options
 type RequestPublishRequest struct {
-	IsEditable bool `json:"is_editable" example:"true"`
+	IsEditable bool   `json:"is_editable" example:"true"`
+	Note       string `json:"note,omitempty" example:"Bab 2 sudah diperbaiki sesuai catatan"`
+}
+
+// ModerateBookRequest represents approve/reject book request
+type ModerateBookRequest struct {
+	Reason   string                       `json:"reason" example:"Beberapa jawaban belum lengkap"`
+	Comments []entities.ModerationComment `json:"comments,omitempty"`
 }
 
 // CreateBookRequest represents create book request
@@ -984,6 +1099,18 @@ type RejectBookUpdateRequest struct {
 	Reason string `json:"reason" example:"Content needs revision"`
 }
 
+// UpdateBookCatalogRequest represents update book catalog info request
+type UpdateBookCatalogRequest struct {
+	Category string   `json:"category" example:"bahasa arab"`
+	Tags     []string `json:"tags" example:"nahwu,pemula"`
+}
+
+// ResolveOverrideRequest represents resolve override request
+type ResolveOverrideRequest struct {
+	Strategy    string            `json:"strategy" example:"merge"` // keep_mine | take_theirs | merge
+	Resolutions map[string]string `json:"resolutions,omitempty"`    // field -> value, required for conflicts when merging
+}
+
 // AddModuleRequest represents add module request
 type AddModuleRequest struct {
 	Title       string `json:"title" example:"Bab 1: Pengenalan"`
@@ -1061,6 +1188,53 @@ func (h *BookHandler) StartMemorization(c *fiber.Ctx) error {
 	return utils.Success(c, fiber.StatusOK, "Item memorization started", result, nil)
 }
 
+// StartBulkMemorizationRequest represents bulk start request. Empty
+// module_id starts the whole book; per_day 0 starts everything today.
+type StartBulkMemorizationRequest struct {
+	ModuleID  string `json:"module_id,omitempty" example:""`
+	Recursive bool   `json:"recursive" example:"true"`
+	PerDay    int    `json:"per_day" example:"5"`
+}
+
+// StartBulkMemorization godoc
+// @Summary Start memorizing a module or book
+// @Description Start every item of a module (optionally with its child modules) or of the whole book in one call. Same access rules as starting one item; already started items are skipped and deactivated items stay inactive. With per_day > 0, start dates are staggered so only per_day new items enter the daily tasks each day.
+// @Tags Book
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Book ID"
+// @Param request body StartBulkMemorizationRequest false "Scope and pacing"
+// @Success 200 {object} utils.SuccessResponse{data=services.BulkStartResult}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /books/{id}/start [post]
+func (h *BookHandler) StartBulkMemorization(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	var req StartBulkMemorizationRequest
+	_ = c.BodyParser(&req) // Body is optional
+
+	in := services.BulkStartInput{Recursive: req.Recursive, PerDay: req.PerDay}
+	if req.ModuleID != "" {
+		moduleID, err := uuid.Parse(req.ModuleID)
+		if err != nil {
+			return utils.Error(c, fiber.StatusBadRequest, "invalid module_id", "BAD_REQUEST", nil)
+		}
+		in.ModuleID = &moduleID
+	}
+
+	result, err := h.bookSvc.StartBulkMemorization(userID, c.Params("id"), in)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "START_MEMORIZATION_FAILED", nil)
+	}
+
+	// New start items show up in my items and today's tasks
+	h.cache.DeleteByPattern(c.Context(), fmt.Sprintf("myitems:%s:*", userID.String()))
+	h.cache.DeleteByPattern(c.Context(), fmt.Sprintf("daily:%s:*", userID.String()))
+
+	return utils.Success(c, fiber.StatusOK, "memorization started", result, nil)
+}
+
 // ==================== MY BOOK COLLECTION ====================
 
 // GetMyBookCollection godoc
@@ -1154,3 +1328,77 @@ func (h *BookHandler) RemoveMyOverride(c *fiber.Ctx) error {
 
 	return utils.Success(c, fiber.StatusOK, "override removed successfully", nil, nil)
 }
+
+// GetStaleOverrides godoc
+// @Summary List my stale overrides
+// @Description List personal overrides whose canonical book item was changed by the book owner after the override was made
+// @Tags Book Item Override
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.SuccessResponse{data=[]services.StaleOverride}
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /books/my-overrides/stale [get]
+func (h *BookHandler) GetStaleOverrides(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	overrides, err := h.bookSvc.GetStaleOverrides(userID)
+	if err != nil {
+		return utils.Error(c, fiber.StatusInternalServerError, err.Error(), "GET_STALE_OVERRIDES_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "stale overrides fetched successfully", overrides, nil)
+}
+
+// GetOverrideDiff godoc
+// @Summary Three-way diff of my override
+// @Description Compare the override's base snapshot, my override and the current canonical item per field
+// @Tags Book Item Override
+// @Produce json
+// @Security BearerAuth
+// @Param item_id path string true "Book Item ID"
+// @Success 200 {object} utils.SuccessResponse{data=services.OverrideDiff}
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /books/items/{item_id}/my-override/diff [get]
+func (h *BookHandler) GetOverrideDiff(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	bookItemID := c.Params("item_id")
+
+	diff, err := h.bookSvc.GetOverrideDiff(userID, bookItemID)
+	if err != nil {
+		return utils.Error(c, fiber.StatusNotFound, err.Error(), "GET_OVERRIDE_DIFF_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "override diff fetched successfully", diff, nil)
+}
+
+// ResolveOverride godoc
+// @Summary Resolve my override
+// @Description Keep my override (keep_mine), drop it for the canonical item (take_theirs), or merge both (merge). For merge, every conflicting field needs a value in resolutions; image_url must be the base, mine or theirs value.
+// @Tags Book Item Override
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param item_id path string true "Book Item ID"
+// @Param request body ResolveOverrideRequest true "Resolve request"
+// @Success 200 {object} utils.SuccessResponse{data=services.ResolvedBookItem}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /books/items/{item_id}/my-override/resolve [post]
+func (h *BookHandler) ResolveOverride(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	bookItemID := c.Params("item_id")
+
+	var req ResolveOverrideRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, "invalid request body", "BAD_REQUEST", nil)
+	}
+
+	item, err := h.bookSvc.ResolveOverride(userID, bookItemID, req.Strategy, req.Resolutions)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "RESOLVE_OVERRIDE_FAILED", nil)
+	}
+
+	h.cache.Delete(c.Context(), "myitems:"+userID.String()+":book")
+	h.cache.Delete(c.Context(), "myitems:"+userID.String()+":all")
+
+	return utils.Success(c, fiber.StatusOK, "override resolved successfully", item, nil)
+}
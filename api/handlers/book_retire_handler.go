@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"hifzhun-api/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// RetireBookRequest represents retire book request
+type RetireBookRequest struct {
+	Reason string `json:"reason" example:"Digantikan edisi revisi"`
+}
+
+// PurgeBookRequest represents purge book request. ConfirmTitle must repeat
+// the book title exactly.
+type PurgeBookRequest struct {
+	ConfirmTitle string `json:"confirm_title" example:"Belajar Tajwid"`
+	Reason       string `json:"reason" example:"Permintaan penulis, hak cipta"`
+}
+
+// RetireBook godoc
+// @Summary Retire published book (Admin)
+// @Description Remove a published book from the catalog. Importers keep read-only access and their memorization progress; nothing is deleted.
+// @Tags Book Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Book ID"
+// @Param request body RetireBookRequest false "Reason"
+// @Success 200 {object} utils.SuccessResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 403 {object} utils.ErrorResponse
+// @Router /admin/books/{id} [delete]
+func (h *BookHandler) RetireBook(c *fiber.Ctx) error {
+	adminID := c.Locals("user_id").(uuid.UUID)
+
+	var req RetireBookRequest
+	_ = c.BodyParser(&req) // Body is optional
+
+	if err := h.bookSvc.RetireBook(c.Params("id"), adminID, req.Reason); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "RETIRE_BOOK_FAILED", nil)
+	}
+
+	// Invalidate published books cache
+	h.cache.Delete(c.Context(), "books:published")
+
+	return utils.Success(c, fiber.StatusOK, "book retired successfully", nil, nil)
+}
+
+// RestoreRetiredBook godoc
+// @Summary Restore retired book (Admin)
+// @Description Put a retired book back into the published catalog
+// @Tags Book Admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Book ID"
+// @Success 200 {object} utils.SuccessResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /admin/books/{id}/restore [post]
+func (h *BookHandler) RestoreRetiredBook(c *fiber.Ctx) error {
+	if err := h.bookSvc.RestoreRetiredBook(c.Params("id")); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "RESTORE_BOOK_FAILED", nil)
+	}
+
+	h.cache.Delete(c.Context(), "books:published")
+
+	return utils.Success(c, fiber.StatusOK, "book restored successfully", nil, nil)
+}
+
+// GetRetiredBooks godoc
+// @Summary Get retired books (Admin)
+// @Description List retired books, most recently retired first
+// @Tags Book Admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.SuccessResponse{data=[]entities.Book}
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /admin/books/retired [get]
+func (h *BookHandler) GetRetiredBooks(c *fiber.Ctx) error {
+	books, err := h.bookSvc.GetRetiredBooks()
+	if err != nil {
+		return utils.Error(c, fiber.StatusInternalServerError, err.Error(), "GET_RETIRED_BOOKS_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "retired books fetched successfully", books, nil)
+}
+
+// PurgeBook godoc
+// @Summary Purge retired book (Admin)
+// @Description Permanently delete a retired book including every user's memorization progress on it. Requires the book title as confirmation and a reason; the purge is recorded in the audit log.
+// @Tags Book Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Book ID"
+// @Param request body PurgeBookRequest true "Confirmation and reason"
+// @Success 200 {object} utils.SuccessResponse{data=entities.BookPurgeLog}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /admin/books/{id}/purge [post]
+func (h *BookHandler) PurgeBook(c *fiber.Ctx) error {
+	adminID := c.Locals("user_id").(uuid.UUID)
+
+	var req PurgeBookRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, "invalid request body", "BAD_REQUEST", nil)
+	}
+
+	log, err := h.bookSvc.PurgeBook(c.Params("id"), adminID, req.ConfirmTitle, req.Reason)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "PURGE_BOOK_FAILED", nil)
+	}
+
+	// Importers lost their Items from this book
+	h.cache.DeleteByPattern(c.Context(), "myitems:*")
+
+	return utils.Success(c, fiber.StatusOK, "book purged successfully", log, nil)
+}
+
+// GetPurgeLogs godoc
+// @Summary Get book purge audit log (Admin)
+// @Description List permanent book deletions, most recent first
+// @Tags Book Admin
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page (default 1)"
+// @Param per_page query int false "Entries per page (default 20, max 100)"
+// @Success 200 {object} utils.SuccessResponse{data=[]entities.BookPurgeLog,meta=utils.Meta}
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /admin/book-purges [get]
+func (h *BookHandler) GetPurgeLogs(c *fiber.Ctx) error {
+	page, perPage := pageParams(c)
+
+	logs, total, err := h.bookSvc.GetPurgeLogs(page, perPage)
+	if err != nil {
+		return utils.Error(c, fiber.StatusInternalServerError, err.Error(), "GET_PURGE_LOGS_FAILED", nil)
+	}
+
+	meta := &utils.Meta{Page: page, PerPage: perPage, Total: int(total)}
+	return utils.Success(c, fiber.StatusOK, "purge log fetched successfully", logs, meta)
+}
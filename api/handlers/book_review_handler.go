@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"hifzhun-api/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// SaveBookReviewRequest represents create/update review request
+type SaveBookReviewRequest struct {
+	Rating int    `json:"rating" example:"5"`
+	Body   string `json:"body" example:"Susunan babnya rapi, cocok untuk pemula"`
+}
+
+// HideBookReviewRequest represents hide review request
+type HideBookReviewRequest struct {
+	Reason string `json:"reason" example:"Bahasa kasar"`
+}
+
+// pageParams reads ?page= and ?per_page= with sane bounds.
+func pageParams(c *fiber.Ctx) (int, int) {
+	page := c.QueryInt("page", 1)
+	if page < 1 {
+		page = 1
+	}
+	perPage := c.QueryInt("per_page", 20)
+	if perPage < 1 || perPage > 100 {
+		perPage = 20
+	}
+	return page, perPage
+}
+
+// GetBookReviews godoc
+// @Summary Get book reviews
+// @Description Get the rating summary, my review and visible reviews of a published book
+// @Tags Book Review
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Book ID"
+// @Param page query int false "Page (default 1)"
+// @Param per_page query int false "Reviews per page (default 20, max 100)"
+// @Success 200 {object} utils.SuccessResponse{data=services.BookReviewList,meta=utils.Meta}
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /books/published/{id}/reviews [get]
+func (h *BookHandler) GetBookReviews(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	page, perPage := pageParams(c)
+
+	list, err := h.bookSvc.GetBookReviews(c.Params("id"), userID, page, perPage)
+	if err != nil {
+		return utils.Error(c, fiber.StatusNotFound, err.Error(), "GET_REVIEWS_FAILED", nil)
+	}
+
+	meta := &utils.Meta{Page: page, PerPage: perPage, Total: int(list.Summary.Count)}
+	return utils.Success(c, fiber.StatusOK, "reviews fetched successfully", list, meta)
+}
+
+// SaveMyReview godoc
+// @Summary Rate and review a book
+// @Description Create or update my rating (1-5) and review of a published book. Only users who imported or copied the book can review it.
+// @Tags Book Review
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Book ID"
+// @Param request body SaveBookReviewRequest true "Review"
+// @Success 200 {object} utils.SuccessResponse{data=entities.BookReview}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /books/published/{id}/reviews/mine [put]
+func (h *BookHandler) SaveMyReview(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	var req SaveBookReviewRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, "invalid request body", "BAD_REQUEST", nil)
+	}
+
+	review, err := h.bookSvc.SaveMyReview(c.Params("id"), userID, req.Rating, req.Body)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "SAVE_REVIEW_FAILED", nil)
+	}
+
+	h.cache.Delete(c.Context(), "books:published")
+
+	return utils.Success(c, fiber.StatusOK, "review saved successfully", review, nil)
+}
+
+// DeleteMyReview godoc
+// @Summary Delete my review
+// @Description Delete my rating and review of a book
+// @Tags Book Review
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Book ID"
+// @Success 200 {object} utils.SuccessResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /books/published/{id}/reviews/mine [delete]
+func (h *BookHandler) DeleteMyReview(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	if err := h.bookSvc.DeleteMyReview(c.Params("id"), userID); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "DELETE_REVIEW_FAILED", nil)
+	}
+
+	h.cache.Delete(c.Context(), "books:published")
+
+	return utils.Success(c, fiber.StatusOK, "review deleted successfully", nil, nil)
+}
+
+// GetReviewsForModeration godoc
+// @Summary Get book reviews for moderation (Admin)
+// @Description List all book reviews, newest first
+// @Tags Book Admin
+// @Produce json
+// @Security BearerAuth
+// @Param hidden query bool false "Only hidden reviews"
+// @Param page query int false "Page (default 1)"
+// @Param per_page query int false "Reviews per page (default 20, max 100)"
+// @Success 200 {object} utils.SuccessResponse{data=[]repositories.BookReviewView,meta=utils.Meta}
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /admin/book-reviews [get]
+func (h *BookHandler) GetReviewsForModeration(c *fiber.Ctx) error {
+	page, perPage := pageParams(c)
+
+	reviews, total, err := h.bookSvc.GetReviewsForModeration(c.QueryBool("hidden", false), page, perPage)
+	if err != nil {
+		return utils.Error(c, fiber.StatusInternalServerError, err.Error(), "GET_REVIEWS_FAILED", nil)
+	}
+
+	meta := &utils.Meta{Page: page, PerPage: perPage, Total: int(total)}
+	return utils.Success(c, fiber.StatusOK, "reviews fetched successfully", reviews, meta)
+}
+
+// HideReview godoc
+// @Summary Hide book review (Admin)
+// @Description Hide an abusive review; it is no longer shown or counted in the rating
+// @Tags Book Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Review ID"
+// @Param request body HideBookReviewRequest false "Reason"
+// @Success 200 {object} utils.SuccessResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /admin/book-reviews/{id}/hide [post]
+func (h *BookHandler) HideReview(c *fiber.Ctx) error {
+	adminID := c.Locals("user_id").(uuid.UUID)
+
+	var req HideBookReviewRequest
+	_ = c.BodyParser(&req) // Body is optional
+
+	if err := h.bookSvc.HideReview(c.Params("id"), adminID, req.Reason); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "HIDE_REVIEW_FAILED", nil)
+	}
+
+	h.cache.Delete(c.Context(), "books:published")
+
+	return utils.Success(c, fiber.StatusOK, "review hidden successfully", nil, nil)
+}
+
+// UnhideReview godoc
+// @Summary Unhide book review (Admin)
+// @Description Show a previously hidden review again
+// @Tags Book Admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Review ID"
+// @Success 200 {object} utils.SuccessResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /admin/book-reviews/{id}/unhide [post]
+func (h *BookHandler) UnhideReview(c *fiber.Ctx) error {
+	adminID := c.Locals("user_id").(uuid.UUID)
+
+	if err := h.bookSvc.UnhideReview(c.Params("id"), adminID); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "UNHIDE_REVIEW_FAILED", nil)
+	}
+
+	h.cache.Delete(c.Context(), "books:published")
+
+	return utils.Success(c, fiber.StatusOK, "review unhidden successfully", nil, nil)
+}
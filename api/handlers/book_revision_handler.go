@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"hifzhun-api/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// SubmitBookRevisionRequest represents submit content revision request
+type SubmitBookRevisionRequest struct {
+	Summary string `json:"summary" example:"Menambah bab 3 dan memperbaiki typo"`
+}
+
+// GetDraftRevision godoc
+// @Summary Get draft content revision (Owner)
+// @Description Get the open content revision of a published book with its diff against the live content. Module/item edits by the owner of a published book are collected here until an admin approves them.
+// @Tags Book
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Book ID"
+// @Success 200 {object} utils.SuccessResponse{data=services.BookRevisionDetail}
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /books/{id}/revisions/draft [get]
+func (h *BookHandler) GetDraftRevision(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	bookID := c.Params("id")
+
+	detail, err := h.bookSvc.GetDraftRevision(bookID, userID)
+	if err != nil {
+		return utils.Error(c, fiber.StatusNotFound, err.Error(), "GET_DRAFT_REVISION_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "draft revision fetched successfully", detail, nil)
+}
+
+// DiscardDraftRevision godoc
+// @Summary Discard draft content revision (Owner)
+// @Description Throw away all unapproved module/item changes of a published book
+// @Tags Book
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Book ID"
+// @Success 200 {object} utils.SuccessResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /books/{id}/revisions/draft [delete]
+func (h *BookHandler) DiscardDraftRevision(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	bookID := c.Params("id")
+
+	if err := h.bookSvc.DiscardDraftRevision(bookID, userID); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "DISCARD_REVISION_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "draft revision discarded successfully", nil, nil)
+}
+
+// SubmitRevision godoc
+// @Summary Submit content revision for review (Owner)
+// @Description Send the draft content revision to admin review
+// @Tags Book
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Book ID"
+// @Param request body SubmitBookRevisionRequest false "Change summary"
+// @Success 200 {object} utils.SuccessResponse{data=services.BookRevisionDetail}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /books/{id}/revisions/submit [post]
+func (h *BookHandler) SubmitRevision(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	bookID := c.Params("id")
+
+	var req SubmitBookRevisionRequest
+	_ = c.BodyParser(&req) // Body is optional
+
+	detail, err := h.bookSvc.SubmitRevision(bookID, userID, req.Summary)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "SUBMIT_REVISION_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "revision submitted for review", detail, nil)
+}
+
+// GetBookRevisions godoc
+// @Summary Get content revisions (Owner)
+// @Description Get all content revisions of a published book, newest first
+// @Tags Book
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Book ID"
+// @Success 200 {object} utils.SuccessResponse{data=[]entities.BookRevision}
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /books/{id}/revisions [get]
+func (h *BookHandler) GetBookRevisions(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	bookID := c.Params("id")
+
+	revs, err := h.bookSvc.GetBookRevisions(bookID, userID)
+	if err != nil {
+		return utils.Error(c, fiber.StatusNotFound, err.Error(), "GET_REVISIONS_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "revisions fetched successfully", revs, nil)
+}
+
+// GetBookChangelog godoc
+// @Summary Get book changelog
+// @Description Get approved content versions of a book with what changed in each. Versions after the caller's last seen version are counted in unseen_count.
+// @Tags Book
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Book ID"
+// @Success 200 {object} utils.SuccessResponse{data=services.BookChangelog}
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /books/{id}/changelog [get]
+func (h *BookHandler) GetBookChangelog(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	role, _ := c.Locals("role").(string)
+	bookID := c.Params("id")
+
+	changelog, err := h.bookSvc.GetBookChangelog(bookID, userID, role)
+	if err != nil {
+		return utils.Error(c, fiber.StatusNotFound, err.Error(), "GET_CHANGELOG_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "changelog fetched successfully", changelog, nil)
+}
+
+// MarkChangelogSeen godoc
+// @Summary Mark changelog as seen
+// @Description Mark all content versions of an imported book as seen
+// @Tags Book
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Book ID"
+// @Success 200 {object} utils.SuccessResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /books/{id}/changelog/seen [post]
+func (h *BookHandler) MarkChangelogSeen(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	bookID := c.Params("id")
+
+	if err := h.bookSvc.MarkChangelogSeen(bookID, userID); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "MARK_CHANGELOG_SEEN_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "changelog marked as seen", nil, nil)
+}
+
+// GetPendingRevisions godoc
+// @Summary Get pending content revisions (Admin)
+// @Description Get all content revisions waiting for review
+// @Tags Book Admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.SuccessResponse{data=[]entities.BookRevision}
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /admin/book-revisions/pending [get]
+func (h *BookHandler) GetPendingRevisions(c *fiber.Ctx) error {
+	revs, err := h.bookSvc.GetPendingRevisions()
+	if err != nil {
+		return utils.Error(c, fiber.StatusInternalServerError, err.Error(), "GET_PENDING_REVISIONS_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "pending revisions fetched successfully", revs, nil)
+}
+
+// GetRevisionDetail godoc
+// @Summary Get content revision detail (Admin)
+// @Description Get a content revision with its diff against the live book
+// @Tags Book Admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Revision ID"
+// @Success 200 {object} utils.SuccessResponse{data=services.BookRevisionDetail}
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /admin/book-revisions/{id} [get]
+func (h *BookHandler) GetRevisionDetail(c *fiber.Ctx) error {
+	detail, err := h.bookSvc.GetRevisionDetail(c.Params("id"))
+	if err != nil {
+		return utils.Error(c, fiber.StatusNotFound, err.Error(), "GET_REVISION_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "revision fetched successfully", detail, nil)
+}
+
+// ApproveRevision godoc
+// @Summary Approve content revision (Admin)
+// @Description Apply a pending content revision to the published book and bump its content version
+// @Tags Book Admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Revision ID"
+// @Success 200 {object} utils.SuccessResponse{data=services.BookRevisionDetail}
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 403 {object} utils.ErrorResponse
+// @Router /admin/book-revisions/{id}/approve [post]
+func (h *BookHandler) ApproveRevision(c *fiber.Ctx) error {
+	adminID := c.Locals("user_id").(uuid.UUID)
+
+	detail, err := h.bookSvc.ApproveRevision(c.Params("id"), adminID)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "APPROVE_REVISION_FAILED", nil)
+	}
+
+	// Content changed for every importer
+	h.cache.Delete(c.Context(), "books:published")
+	h.cache.DeleteByPattern(c.Context(), "myitems:*")
+
+	return utils.Success(c, fiber.StatusOK, "revision approved successfully", detail, nil)
+}
+
+// RejectRevision godoc
+// @Summary Reject content revision (Admin)
+// @Description Send a pending content revision back to the owner
+// @Tags Book Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Revision ID"
+// @Param request body RejectBookUpdateRequest true "Reject request"
+// @Success 200 {object} utils.SuccessResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 403 {object} utils.ErrorResponse
+// @Router /admin/book-revisions/{id}/reject [post]
+func (h *BookHandler) RejectRevision(c *fiber.Ctx) error {
+	adminID := c.Locals("user_id").(uuid.UUID)
+
+	var req RejectBookUpdateRequest
+	_ = c.BodyParser(&req) // Body is optional
+
+	if err := h.bookSvc.RejectRevision(c.Params("id"), adminID, req.Reason); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "REJECT_REVISION_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "revision rejected successfully", nil, nil)
+}
@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"hifzhun-api/pkg/anki"
+	"hifzhun-api/pkg/services"
+	"hifzhun-api/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// ImportBook godoc
+// @Summary Import a book from CSV or JSON
+// @Description Create a new draft book with nested modules and items in one transaction. Send multipart/form-data with field "file" (.csv or .json), or a raw JSON/CSV body. CSV columns: type,key,parent_key,title,description,content,answer,order,image_url,estimated_review_seconds. Text cells starting with = + - @ are exported with a leading apostrophe, which import removes. Image links (image_url, cover_image) are not imported; each dropped link is listed in "notes" so the images can be uploaded afterwards. Row-level validation errors are returned in "errors".
+// @Tags Book
+// @Accept multipart/form-data
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file false "CSV or JSON file"
+// @Param format query string false "csv | json (detected from file extension / content type when omitted)"
+// @Success 201 {object} utils.SuccessResponse{data=services.BookImportResult}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /books/import [post]
+func (h *BookHandler) ImportBook(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	format := strings.ToLower(c.Query("format"))
+
+	var reader io.Reader
+	if isMultipartForm(c) {
+		file, err := c.FormFile("file")
+		if err != nil {
+			return utils.Error(c, fiber.StatusBadRequest, "file is required", "BAD_REQUEST", nil)
+		}
+		if format == "" {
+			format = strings.ToLower(c.FormValue("format"))
+		}
+		if format == "" {
+			format = strings.TrimPrefix(strings.ToLower(filepath.Ext(file.Filename)), ".")
+		}
+
+		f, err := file.Open()
+		if err != nil {
+			return utils.Error(c, fiber.StatusBadRequest, "failed to read file", "BAD_REQUEST", nil)
+		}
+		defer f.Close()
+		reader = f
+	} else {
+		if format == "" {
+			if strings.Contains(strings.ToLower(c.Get(fiber.HeaderContentType)), "csv") {
+				format = "csv"
+			} else {
+				format = "json"
+			}
+		}
+		reader = bytes.NewReader(c.Body())
+	}
+
+	var doc *services.BookTransferDocument
+	var err error
+	switch format {
+	case "csv":
+		doc, err = services.ParseBookCSV(reader)
+	case "json":
+		doc = &services.BookTransferDocument{}
+		if decodeErr := json.NewDecoder(reader).Decode(doc); decodeErr != nil {
+			err = errors.New("invalid JSON: " + decodeErr.Error())
+		}
+	default:
+		return utils.Error(c, fiber.StatusBadRequest, "format must be csv or json", "BAD_REQUEST", nil)
+	}
+	if err != nil {
+		return importErrorResponse(c, err)
+	}
+
+	result, err := h.bookSvc.ImportBook(userID, doc)
+	if err != nil {
+		return importErrorResponse(c, err)
+	}
+
+	return utils.Success(c, fiber.StatusCreated, "book imported successfully", result, nil)
+}
+
+func importErrorResponse(c *fiber.Ctx, err error) error {
+	var importErr *services.BookImportError
+	if errors.As(err, &importErr) {
+		return utils.Error(c, fiber.StatusBadRequest, "validation error", "VALIDATION_ERROR", importErr.Errors)
+	}
+	return utils.Error(c, fiber.StatusBadRequest, err.Error(), "IMPORT_BOOK_FAILED", nil)
+}
+
+// ExportBook godoc
+// @Summary Export a book as CSV or JSON
+// @Description Download the canonical modules and items of any book the user can view. The file can be re-imported with POST /books/import.
+// @Tags Book
+// @Produce json
+// @Produce text/csv
+// @Security BearerAuth
+// @Param id path string true "Book ID"
+// @Param format query string false "json (default) | csv"
+// @Success 200 {object} services.BookTransferDocument
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /books/{id}/export [get]
+func (h *BookHandler) ExportBook(c *fiber.Ctx) error {
+	bookID := c.Params("id")
+	userID, role := optionalUserAndRole(c)
+
+	format := strings.ToLower(c.Query("format", "json"))
+	if format != "json" && format != "csv" {
+		return utils.Error(c, fiber.StatusBadRequest, "format must be csv or json", "BAD_REQUEST", nil)
+	}
+
+	doc, err := h.bookSvc.ExportBook(bookID, userID, role)
+	if err != nil {
+		return utils.Error(c, fiber.StatusNotFound, err.Error(), "BOOK_NOT_FOUND", nil)
+	}
+
+	c.Attachment(exportFileName(doc.Title, format))
+	if format == "csv" {
+		c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+		return services.WriteBookCSV(c.Response().BodyWriter(), doc)
+	}
+
+	return c.JSON(doc)
+}
+
+// optionalUserAndRole reads the user id and role set by JWTAuth, if any.
+func optionalUserAndRole(c *fiber.Ctx) (*uuid.UUID, string) {
+	var userID *uuid.UUID
+	if v := c.Locals("user_id"); v != nil {
+		id := v.(uuid.UUID)
+		userID = &id
+	}
+
+	var role string
+	if v := c.Locals("role"); v != nil {
+		role = v.(string)
+	}
+
+	return userID, role
+}
+
+// exportFileName builds an ASCII-safe download name from a book title.
+func exportFileName(title, ext string) string {
+	var b strings.Builder
+	lastDash := false
+	for _, r := range strings.ToLower(title) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			lastDash = false
+		} else if !lastDash && b.Len() > 0 {
+			b.WriteByte('-')
+			lastDash = true
+		}
+	}
+
+	name := strings.TrimSuffix(b.String(), "-")
+	if name == "" {
+		name = "book"
+	}
+	return name + "." + ext
+}
+
+// ImportAnkiPackage godoc
+// @Summary Import an Anki deck package (.apkg)
+// @Description Create a new draft book from an Anki .apkg file. Decks become (nested) modules and notes become items (Front/Back, Question/Answer, Text/Extra fields, otherwise first field = content, second = answer). Images are copied for premium users. With with_review_history=true, reviewed cards also create the user's memorization items with an FSRS state replayed from the Anki review log.
+// @Tags Book
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file true "Anki package (.apkg)"
+// @Param title formData string false "Book title (default: root deck name)"
+// @Param with_review_history formData bool false "Carry over Anki review history"
+// @Success 201 {object} utils.SuccessResponse{data=services.AnkiImportResult}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /books/import/anki [post]
+func (h *BookHandler) ImportAnkiPackage(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, "file is required", "BAD_REQUEST", nil)
+	}
+	if strings.ToLower(filepath.Ext(file.Filename)) != ".apkg" {
+		return utils.Error(c, fiber.StatusBadRequest, "only .apkg files are allowed", "BAD_REQUEST", nil)
+	}
+	if file.Size > anki.MaxPackageSize {
+		return utils.Error(c, fiber.StatusBadRequest, "package size must be 20MB or less", "BAD_REQUEST", nil)
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, "failed to read file", "BAD_REQUEST", nil)
+	}
+	defer f.Close()
+
+	pkg, err := anki.Open(f, file.Size)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "INVALID_ANKI_PACKAGE", nil)
+	}
+
+	withHistory, _ := strconv.ParseBool(c.FormValue("with_review_history"))
+	result, err := h.bookSvc.ImportAnkiPackage(userID, pkg, services.AnkiImportOptions{
+		Title:             c.FormValue("title"),
+		WithReviewHistory: withHistory,
+	})
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "IMPORT_BOOK_FAILED", nil)
+	}
+
+	if result.ProgressItemsCreated > 0 {
+		h.cache.DeleteByPattern(c.Context(), fmt.Sprintf("myitems:%s:*", userID.String()))
+	}
+
+	return utils.Success(c, fiber.StatusCreated, "anki package imported successfully", result, nil)
+}
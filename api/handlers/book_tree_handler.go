@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"hifzhun-api/pkg/services"
+	"hifzhun-api/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// MoveModuleRequest represents move module request. Empty parent_id moves
+// the module to the book level; position 0 puts it last.
+type MoveModuleRequest struct {
+	ParentID string `json:"parent_id,omitempty" example:""`
+	Position int    `json:"position" example:"1"`
+}
+
+// MoveItemRequest represents move item request. Empty module_id moves the
+// item to the book level; position 0 puts it last.
+type MoveItemRequest struct {
+	ModuleID string `json:"module_id,omitempty" example:""`
+	Position int    `json:"position" example:"1"`
+}
+
+// ReorderBookRequest represents bulk reorder request for the children of one
+// parent (empty parent_id = book level)
+type ReorderBookRequest struct {
+	ParentID  string   `json:"parent_id,omitempty" example:""`
+	ModuleIDs []string `json:"module_ids,omitempty"`
+	ItemIDs   []string `json:"item_ids,omitempty"`
+}
+
+// optionalUUID parses an optional UUID string; "" is nil
+func optionalUUID(s string) (*uuid.UUID, error) {
+	if s == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+func parseUUIDs(ids []string) ([]uuid.UUID, error) {
+	result := make([]uuid.UUID, 0, len(ids))
+	for _, s := range ids {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, id)
+	}
+	return result, nil
+}
+
+// MoveModule godoc
+// @Summary Move module
+// @Description Move a module under another module or to the book level. Moving a module into itself or one of its submodules is rejected. Siblings are renumbered; for published books the move goes into the draft content revision.
+// @Tags Book Module
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Module ID"
+// @Param request body MoveModuleRequest true "Target parent and position"
+// @Success 200 {object} utils.SuccessResponse{data=entities.BookModule}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /books/modules/{id}/move [post]
+func (h *BookHandler) MoveModule(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	var req MoveModuleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, "invalid request body", "BAD_REQUEST", nil)
+	}
+	parentID, err := optionalUUID(req.ParentID)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, "invalid parent_id", "BAD_REQUEST", nil)
+	}
+
+	module, err := h.bookSvc.MoveModule(c.Params("id"), userID, parentID, req.Position)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "MOVE_MODULE_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "module moved successfully", module, nil)
+}
+
+// MoveItem godoc
+// @Summary Move item
+// @Description Move a book item into another module or to the book level. Siblings are renumbered; for published books the move goes into the draft content revision.
+// @Tags Book Item
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param item_id path string true "Item ID"
+// @Param request body MoveItemRequest true "Target module and position"
+// @Success 200 {object} utils.SuccessResponse{data=entities.BookItem}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /books/items/{item_id}/move [post]
+func (h *BookHandler) MoveItem(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	var req MoveItemRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, "invalid request body", "BAD_REQUEST", nil)
+	}
+	moduleID, err := optionalUUID(req.ModuleID)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, "invalid module_id", "BAD_REQUEST", nil)
+	}
+
+	item, err := h.bookSvc.MoveItem(c.Params("item_id"), userID, moduleID, req.Position)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "MOVE_ITEM_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "item moved successfully", item, nil)
+}
+
+// ReorderBook godoc
+// @Summary Reorder modules and items
+// @Description Set the order of the modules and/or items directly under one parent in one call. Each list must contain every sibling exactly once; orders become 1..n.
+// @Tags Book
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Book ID"
+// @Param request body ReorderBookRequest true "Ordered IDs"
+// @Success 200 {object} utils.SuccessResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /books/{id}/order [put]
+func (h *BookHandler) ReorderBook(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	var req ReorderBookRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, "invalid request body", "BAD_REQUEST", nil)
+	}
+	parentID, err := optionalUUID(req.ParentID)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, "invalid parent_id", "BAD_REQUEST", nil)
+	}
+	moduleIDs, err := parseUUIDs(req.ModuleIDs)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, "invalid module_ids", "BAD_REQUEST", nil)
+	}
+	itemIDs, err := parseUUIDs(req.ItemIDs)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, "invalid item_ids", "BAD_REQUEST", nil)
+	}
+
+	in := services.BookReorderInput{ParentID: parentID, ModuleIDs: moduleIDs, ItemIDs: itemIDs}
+	if err := h.bookSvc.ReorderBookChildren(c.Params("id"), userID, in); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "REORDER_BOOK_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "book reordered successfully", nil, nil)
+}
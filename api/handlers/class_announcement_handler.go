@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"hifzhun-api/pkg/services"
+	"hifzhun-api/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// AnnouncementRequest represents a new announcement or an announcement
+// update; omitted fields stay. An empty group_id sends it to the whole class
+// and an empty attachment_url removes the attachment. With multipart/form-data
+// the same fields are form values and an "attachment" file may be uploaded.
+type AnnouncementRequest struct {
+	GroupID        *string `json:"group_id,omitempty" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Title          *string `json:"title,omitempty" example:"Libur setoran"`
+	Body           *string `json:"body,omitempty" example:"Setoran hari Jumat diliburkan."`
+	AttachmentURL  *string `json:"attachment_url,omitempty" example:"/uploads/attachments/2f1c9e4a-0d7b-4b8e-9a51-3c6d2e7f8a90_1760860800.pdf"`
+	AttachmentName *string `json:"attachment_name,omitempty" example:"jadwal.pdf"`
+	Pinned         *bool   `json:"pinned,omitempty"`
+	PublishAt      *string `json:"publish_at,omitempty" example:"2026-10-23T07:00:00+07:00"` // RFC3339; future schedules it
+}
+
+// parseAnnouncementRequest reads a JSON or multipart announcement and
+// uploads its attachment file
+func parseAnnouncementRequest(c *fiber.Ctx) (services.AnnouncementInput, error) {
+	var req AnnouncementRequest
+	if !isMultipartForm(c) {
+		if err := c.BodyParser(&req); err != nil {
+			return services.AnnouncementInput{}, errors.New("invalid request body")
+		}
+	} else {
+		form, err := c.MultipartForm()
+		if err != nil {
+			return services.AnnouncementInput{}, errors.New("invalid request body")
+		}
+		value := func(key string) *string {
+			if v, ok := form.Value[key]; ok && len(v) > 0 {
+				return &v[0]
+			}
+			return nil
+		}
+		req.GroupID = value("group_id")
+		req.Title = value("title")
+		req.Body = value("body")
+		req.AttachmentURL = value("attachment_url")
+		req.AttachmentName = value("attachment_name")
+		req.PublishAt = value("publish_at")
+		if pinned := value("pinned"); pinned != nil {
+			b, err := strconv.ParseBool(*pinned)
+			if err != nil {
+				return services.AnnouncementInput{}, errors.New("pinned must be true or false")
+			}
+			req.Pinned = &b
+		}
+
+		if files := form.File["attachment"]; len(files) > 0 {
+			link, err := utils.SaveAttachment(files[0])
+			if err != nil {
+				return services.AnnouncementInput{}, err
+			}
+			req.AttachmentURL = &link
+			if req.AttachmentName == nil {
+				req.AttachmentName = &files[0].Filename
+			}
+		}
+	}
+
+	in := services.AnnouncementInput{
+		GroupID:        req.GroupID,
+		Title:          req.Title,
+		Body:           req.Body,
+		AttachmentURL:  req.AttachmentURL,
+		AttachmentName: req.AttachmentName,
+		Pinned:         req.Pinned,
+	}
+	if req.PublishAt != nil && *req.PublishAt != "" {
+		t, err := time.Parse(time.RFC3339, *req.PublishAt)
+		if err != nil {
+			return services.AnnouncementInput{}, errors.New("publish_at must be RFC3339")
+		}
+		in.PublishAt = &t
+	}
+	return in, nil
+}
+
+// CreateAnnouncement godoc
+// @Summary Post a class announcement
+// @Description Teacher or staff posts an announcement to the class or one group (group_id), with an optional attachment (a pdf, image or audio file up to 10MB uploaded with the announcement or earlier). It can be pinned and scheduled with publish_at; students are notified in their inbox once it is published.
+// @Tags Class Announcements
+// @Accept json,mpfd
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Class ID"
+// @Param request body AnnouncementRequest true "Announcement"
+// @Success 201 {object} utils.SuccessResponse{data=entities.ClassAnnouncement}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /classes/{id}/announcements [post]
+func (h *ClassHandler) CreateAnnouncement(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	in, err := parseAnnouncementRequest(c)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "BAD_REQUEST", nil)
+	}
+
+	announcement, err := h.classSvc.CreateAnnouncement(c.Params("id"), userID, in)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "CREATE_ANNOUNCEMENT_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusCreated, "announcement created successfully", announcement, nil)
+}
+
+// GetAnnouncements godoc
+// @Summary List class announcements
+// @Description Pinned announcements first, then newest first. The teacher and staff also see scheduled announcements; students see published announcements for the class and their own group.
+// @Tags Class Announcements
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Class ID"
+// @Param page query int false "Page (default 1)"
+// @Param per_page query int false "Per page (default 20)"
+// @Success 200 {object} utils.SuccessResponse{data=[]entities.ClassAnnouncement}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /classes/{id}/announcements [get]
+func (h *ClassHandler) GetAnnouncements(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	page, perPage := pageParams(c)
+
+	list, total, err := h.classSvc.GetAnnouncements(c.Params("id"), userID, page, perPage)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "GET_ANNOUNCEMENTS_FAILED", nil)
+	}
+
+	meta := &utils.Meta{Page: page, PerPage: perPage, Total: int(total)}
+	return utils.Success(c, fiber.StatusOK, "announcements fetched successfully", list, meta)
+}
+
+// UpdateAnnouncement godoc
+// @Summary Update a class announcement
+// @Description The author, or a teacher who manages members, edits, pins or reschedules an announcement
+// @Tags Class Announcements
+// @Accept json,mpfd
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Class ID"
+// @Param announcement_id path string true "Announcement ID"
+// @Param request body AnnouncementRequest true "Announcement update"
+// @Success 200 {object} utils.SuccessResponse{data=entities.ClassAnnouncement}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /classes/{id}/announcements/{announcement_id} [put]
+func (h *ClassHandler) UpdateAnnouncement(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	in, err := parseAnnouncementRequest(c)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "BAD_REQUEST", nil)
+	}
+
+	announcement, err := h.classSvc.UpdateAnnouncement(c.Params("id"), c.Params("announcement_id"), userID, in)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "UPDATE_ANNOUNCEMENT_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "announcement updated successfully", announcement, nil)
+}
+
+// DeleteAnnouncement godoc
+// @Summary Delete a class announcement
+// @Description The author, or a teacher who manages members, removes an announcement
+// @Tags Class Announcements
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Class ID"
+// @Param announcement_id path string true "Announcement ID"
+// @Success 200 {object} utils.SuccessResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /classes/{id}/announcements/{announcement_id} [delete]
+func (h *ClassHandler) DeleteAnnouncement(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	if err := h.classSvc.DeleteAnnouncement(c.Params("id"), c.Params("announcement_id"), userID); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "DELETE_ANNOUNCEMENT_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "announcement deleted successfully", nil, nil)
+}
@@ -0,0 +1,274 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"hifzhun-api/pkg/config"
+	"hifzhun-api/pkg/services"
+	"hifzhun-api/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// CreateAssignmentRequest represents a new class assignment. Quran classes
+// set content_ref; book classes set book_id with module_ids and/or item_ids.
+// group_id gives the assignment to one group instead of the whole class.
+type CreateAssignmentRequest struct {
+	GroupID     *uuid.UUID  `json:"group_id,omitempty"`
+	Title       string      `json:"title" example:"Hafalan An-Naba 1-20"`
+	Description string      `json:"description"`
+	ContentRef  string      `json:"content_ref,omitempty" example:"surah:78:1-20"`
+	BookID      *uuid.UUID  `json:"book_id,omitempty"`
+	ModuleIDs   []uuid.UUID `json:"module_ids,omitempty"`
+	ItemIDs     []uuid.UUID `json:"item_ids,omitempty"`
+	DueAt       string      `json:"due_at" example:"2026-10-23"` // YYYY-MM-DD (end of day) or RFC3339
+}
+
+// UpdateAssignmentRequest represents an assignment update; omitted fields stay
+type UpdateAssignmentRequest struct {
+	Title       *string `json:"title,omitempty"`
+	Description *string `json:"description,omitempty"`
+	DueAt       *string `json:"due_at,omitempty"`
+}
+
+// AssignmentOverrideRequest represents a per-student due date or excuse
+type AssignmentOverrideRequest struct {
+	DueAt   *string `json:"due_at,omitempty" example:"2026-10-30"`
+	Excused bool    `json:"excused"`
+	Note    string  `json:"note,omitempty"`
+}
+
+// parseDueAt accepts a date (due at the end of that day) or an RFC3339 time
+func parseDueAt(value string) (time.Time, error) {
+	if d, err := time.ParseInLocation("2006-01-02", value, config.AppLocation); err == nil {
+		return d.Add(24*time.Hour - time.Second), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.In(config.AppLocation), nil
+	}
+	return time.Time{}, errors.New("due_at must be YYYY-MM-DD or RFC3339")
+}
+
+// CreateAssignment godoc
+// @Summary Create a class assignment
+// @Description Teacher assigns a Quran range (quran classes) or modules/items of a class book (book classes) with a due date in the future, to the whole class or to one group (group_id). The items are created in the account of every student it is for; students who join later (or are added to the group) get open assignments then.
+// @Tags Class Assignment
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Class ID"
+// @Param request body CreateAssignmentRequest true "Assignment"
+// @Success 201 {object} utils.SuccessResponse{data=entities.ClassAssignment}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /classes/{id}/assignments [post]
+func (h *ClassHandler) CreateAssignment(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	var req CreateAssignmentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, "invalid request body", "BAD_REQUEST", nil)
+	}
+	dueAt, err := parseDueAt(req.DueAt)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "BAD_REQUEST", nil)
+	}
+
+	assignment, err := h.classSvc.CreateAssignment(c.Params("id"), userID, services.AssignmentInput{
+		GroupID:     req.GroupID,
+		Title:       req.Title,
+		Description: req.Description,
+		ContentRef:  req.ContentRef,
+		BookID:      req.BookID,
+		ModuleIDs:   req.ModuleIDs,
+		BookItemIDs: req.ItemIDs,
+		DueAt:       dueAt,
+	})
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "CREATE_ASSIGNMENT_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusCreated, "assignment created successfully", assignment, nil)
+}
+
+// GetClassAssignments godoc
+// @Summary List class assignments
+// @Description Teacher lists the assignments of a class with completion and lateness counts
+// @Tags Class Assignment
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Class ID"
+// @Success 200 {object} utils.SuccessResponse{data=[]services.AssignmentProgress}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /classes/{id}/assignments [get]
+func (h *ClassHandler) GetClassAssignments(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	assignments, err := h.classSvc.GetClassAssignments(c.Params("id"), userID)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "GET_ASSIGNMENTS_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "assignments fetched successfully", assignments, nil)
+}
+
+// GetAssignmentProgress godoc
+// @Summary Assignment completion per student
+// @Description Teacher gets each student's due date, done items, status (not_started, in_progress, completed, late, overdue, excused) and days late
+// @Tags Class Assignment
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Class ID"
+// @Param assignment_id path string true "Assignment ID"
+// @Success 200 {object} utils.SuccessResponse{data=services.AssignmentProgress}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /classes/{id}/assignments/{assignment_id}/progress [get]
+func (h *ClassHandler) GetAssignmentProgress(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	progress, err := h.classSvc.GetAssignmentProgress(c.Params("id"), c.Params("assignment_id"), userID)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "GET_ASSIGNMENT_PROGRESS_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "assignment progress fetched successfully", progress, nil)
+}
+
+// UpdateAssignment godoc
+// @Summary Update a class assignment
+// @Description Change title, description or due date. The target range/items cannot change.
+// @Tags Class Assignment
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Class ID"
+// @Param assignment_id path string true "Assignment ID"
+// @Param request body UpdateAssignmentRequest true "Changes"
+// @Success 200 {object} utils.SuccessResponse{data=entities.ClassAssignment}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /classes/{id}/assignments/{assignment_id} [put]
+func (h *ClassHandler) UpdateAssignment(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	var req UpdateAssignmentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, "invalid request body", "BAD_REQUEST", nil)
+	}
+	var dueAt *time.Time
+	if req.DueAt != nil {
+		t, err := parseDueAt(*req.DueAt)
+		if err != nil {
+			return utils.Error(c, fiber.StatusBadRequest, err.Error(), "BAD_REQUEST", nil)
+		}
+		dueAt = &t
+	}
+
+	assignment, err := h.classSvc.UpdateAssignment(c.Params("id"), c.Params("assignment_id"), userID, req.Title, req.Description, dueAt)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "UPDATE_ASSIGNMENT_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "assignment updated successfully", assignment, nil)
+}
+
+// DeleteAssignment godoc
+// @Summary Delete a class assignment
+// @Description Students keep the items and progress created for the assignment
+// @Tags Class Assignment
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Class ID"
+// @Param assignment_id path string true "Assignment ID"
+// @Success 200 {object} utils.SuccessResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /classes/{id}/assignments/{assignment_id} [delete]
+func (h *ClassHandler) DeleteAssignment(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	if err := h.classSvc.DeleteAssignment(c.Params("id"), c.Params("assignment_id"), userID); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "DELETE_ASSIGNMENT_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "assignment deleted successfully", nil, nil)
+}
+
+// SetAssignmentOverride godoc
+// @Summary Set a per-student override
+// @Description Give one student another due date and/or excuse them from the assignment
+// @Tags Class Assignment
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Class ID"
+// @Param assignment_id path string true "Assignment ID"
+// @Param user_id path string true "Student ID"
+// @Param request body AssignmentOverrideRequest true "Override"
+// @Success 200 {object} utils.SuccessResponse{data=entities.ClassAssignmentOverride}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /classes/{id}/assignments/{assignment_id}/overrides/{user_id} [put]
+func (h *ClassHandler) SetAssignmentOverride(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	var req AssignmentOverrideRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, "invalid request body", "BAD_REQUEST", nil)
+	}
+	var dueAt *time.Time
+	if req.DueAt != nil && *req.DueAt != "" {
+		t, err := parseDueAt(*req.DueAt)
+		if err != nil {
+			return utils.Error(c, fiber.StatusBadRequest, err.Error(), "BAD_REQUEST", nil)
+		}
+		dueAt = &t
+	}
+
+	override, err := h.classSvc.SetAssignmentOverride(c.Params("id"), c.Params("assignment_id"), userID, c.Params("user_id"), dueAt, req.Excused, req.Note)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "SET_ASSIGNMENT_OVERRIDE_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "assignment override saved successfully", override, nil)
+}
+
+// RemoveAssignmentOverride godoc
+// @Summary Remove a per-student override
+// @Tags Class Assignment
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Class ID"
+// @Param assignment_id path string true "Assignment ID"
+// @Param user_id path string true "Student ID"
+// @Success 200 {object} utils.SuccessResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /classes/{id}/assignments/{assignment_id}/overrides/{user_id} [delete]
+func (h *ClassHandler) RemoveAssignmentOverride(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	if err := h.classSvc.RemoveAssignmentOverride(c.Params("id"), c.Params("assignment_id"), userID, c.Params("user_id")); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "REMOVE_ASSIGNMENT_OVERRIDE_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "assignment override removed successfully", nil, nil)
+}
+
+// GetMyAssignments godoc
+// @Summary My assignments in a class
+// @Description Student lists their assignments with their own due date and progress
+// @Tags Class Assignment
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Class ID"
+// @Success 200 {object} utils.SuccessResponse{data=[]services.MyAssignment}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /classes/{id}/my-assignments [get]
+func (h *ClassHandler) GetMyAssignments(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	assignments, err := h.classSvc.GetMyAssignments(c.Params("id"), userID)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "GET_MY_ASSIGNMENTS_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "assignments fetched successfully", assignments, nil)
+}
@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"sort"
 	"time"
@@ -30,8 +32,21 @@ type ClassDailyHandler struct {
 	bookRepo        repositories.BookRepository
 	bookItemRepo    repositories.BookItemRepository
 	cache           *cache.Cache
+	classSvc        services.ClassService
 }
 
+// ClassDailyStudent is one student's open class tasks in the group view
+type ClassDailyStudent struct {
+	UserID   uuid.UUID           `json:"user_id"`
+	Email    string              `json:"email"`
+	FullName string              `json:"full_name"`
+	Tasks    []DailyTaskResponse `json:"tasks"`
+}
+
+// classDailyTasks loads the open class tasks of one user for a day. The
+// message explains an empty result.
+type classDailyTasks func(ctx context.Context, userID uuid.UUID, classID string, now time.Time) ([]DailyTaskResponse, string, error)
+
 func NewClassDailyHandler(
 	dailyTaskSvc services.DailyTaskService,
 	dailyTaskRepo repositories.DailyTaskRepository,
@@ -44,6 +59,7 @@ func NewClassDailyHandler(
 	bookRepo repositories.BookRepository,
 	bookItemRepo repositories.BookItemRepository,
 	c *cache.Cache,
+	classSvc services.ClassService,
 ) *ClassDailyHandler {
 	return &ClassDailyHandler{
 		dailyTaskSvc:    dailyTaskSvc,
@@ -57,13 +73,49 @@ func NewClassDailyHandler(
 		bookRepo:        bookRepo,
 		bookItemRepo:    bookItemRepo,
 		cache:           c,
+		classSvc:        classSvc,
+	}
+}
+
+// classDailyDate resolves the date query (YYYY-MM-DD), defaulting to today
+func classDailyDate(c *fiber.Ctx) time.Time {
+	if dateStr := c.Query("date", ""); dateStr != "" {
+		if t, err := time.ParseInLocation("2006-01-02", dateStr, config.AppLocation); err == nil {
+			return t
+		}
+	}
+	return time.Now().In(config.AppLocation)
+}
+
+// listGroupDaily returns the open class tasks of every student of a group.
+// The class service checks that the user may view the class progress.
+func (h *ClassDailyHandler) listGroupDaily(c *fiber.Ctx, userID uuid.UUID, classID, groupID string, load classDailyTasks) error {
+	group, err := h.classSvc.GetClassGroup(classID, groupID, userID)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "BAD_REQUEST", nil)
+	}
+
+	now := classDailyDate(c)
+	students := make([]ClassDailyStudent, 0, len(group.Members))
+	for _, member := range group.Members {
+		tasks, _, err := load(c.Context(), member.UserID, classID, now)
+		if err != nil {
+			return utils.Error(c, fiber.StatusInternalServerError, err.Error(), "INTERNAL_ERROR", nil)
+		}
+		students = append(students, ClassDailyStudent{
+			UserID:   member.UserID,
+			Email:    member.Email,
+			FullName: member.FullName,
+			Tasks:    tasks,
+		})
 	}
+	return utils.Success(c, fiber.StatusOK, "success", students, nil)
 }
 
 // ListClassDaily godoc
 // @Summary List today's class-scoped daily tasks (Quran)
 // @Description Get all daily tasks for today that are scoped to the user's quran class.
-// @Description Requires the user to be a member of the class.
+// @Description Requires the user to be a member of the class. With group_id, class staff get the open tasks of every student of that group instead.
 // @Tags Daily Task
 // @Accept json
 // @Produce json
@@ -71,6 +123,7 @@ func NewClassDailyHandler(
 // @Param class_id query string true "Class UUID"
 // @Param date query string false "Date override (YYYY-MM-DD, defaults to today)"
 // @Param group query string false "Grouping mode: 'juz' to group by juz index"
+// @Param group_id query string false "Class group (halaqah) UUID: list the tasks of its students"
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} utils.ErrorResponse
 // @Failure 401 {object} utils.ErrorResponse
@@ -93,19 +146,23 @@ func (h *ClassDailyHandler) ListClassDaily(c *fiber.Ctx) error {
 	}
 
 	// ── 2. Verify user is a member of the class ───────────────────────────────
-	isMember, err := h.classMemberRepo.IsMember(classIDStr, userID.String())
-	if err != nil {
-		return utils.Error(c, fiber.StatusInternalServerError, "failed to verify membership", "INTERNAL_ERROR", nil)
-	}
-
-	// Teacher (guru) of the class is also allowed — check ownership as fallback
-	if !isMember {
-		class, err := h.classRepo.FindByID(classIDStr)
+	// (staff viewing a group are checked by the class service instead)
+	groupID := c.Query("group_id", "")
+	if groupID == "" {
+		isMember, err := h.classMemberRepo.IsMember(classIDStr, userID.String())
 		if err != nil {
-			return utils.Error(c, fiber.StatusNotFound, "class not found", "NOT_FOUND", nil)
+			return utils.Error(c, fiber.StatusInternalServerError, "failed to verify membership", "INTERNAL_ERROR", nil)
 		}
-		if class.GuruID != userID {
-			return utils.Error(c, fiber.StatusForbidden, "you are not a member of this class", "FORBIDDEN", nil)
+
+		// Teacher (guru) of the class is also allowed — check ownership as fallback
+		if !isMember {
+			class, err := h.classRepo.FindByID(classIDStr)
+			if err != nil {
+				return utils.Error(c, fiber.StatusNotFound, "class not found", "NOT_FOUND", nil)
+			}
+			if class.GuruID != userID {
+				return utils.Error(c, fiber.StatusForbidden, "you are not a member of this class", "FORBIDDEN", nil)
+			}
 		}
 	}
 
@@ -123,38 +180,45 @@ func (h *ClassDailyHandler) ListClassDaily(c *fiber.Ctx) error {
 			nil,
 		)
 	}
+	if groupID != "" {
+		return h.listGroupDaily(c, userID, classIDStr, groupID, h.quranClassTasks)
+	}
 
 	// ── 4. Resolve target date ────────────────────────────────────────────────
-	dateStr := c.Query("date", "")
-	var now time.Time
-	if dateStr != "" {
-		if t, parseErr := time.ParseInLocation("2006-01-02", dateStr, config.AppLocation); parseErr == nil {
-			now = t
-		} else {
-			now = time.Now().In(config.AppLocation)
-		}
-	} else {
-		now = time.Now().In(config.AppLocation)
+	now := classDailyDate(c)
+	group := c.Query("group", "")
+
+	resp, emptyMsg, err := h.quranClassTasks(c.Context(), userID, classIDStr, now)
+	if err != nil {
+		return utils.Error(c, fiber.StatusInternalServerError, err.Error(), "INTERNAL_ERROR", nil)
 	}
+	if len(resp) == 0 {
+		return utils.Success(c, fiber.StatusOK, emptyMsg, []DailyTaskResponse{}, nil)
+	}
+	return h.renderResponse(c, resp, group)
+}
+
+// quranClassTasks loads the open tasks of a user for the items of a quran
+// class (via the class juz) and caches them until midnight.
+func (h *ClassDailyHandler) quranClassTasks(ctx context.Context, userID uuid.UUID, classIDStr string, now time.Time) ([]DailyTaskResponse, string, error) {
 	date := now.Format("2006-01-02")
-	group := c.Query("group", "")
 
 	// ── 5. Try cache ─────────────────────────────────────────────────────────
-	cacheKey := fmt.Sprintf("class-daily:%s:%s:%s:%s", userID.String(), classIDStr, date, group)
+	cacheKey := fmt.Sprintf("class-daily:%s:%s:%s", userID.String(), classIDStr, date)
 	var cached []DailyTaskResponse
-	if h.cache.Get(c.Context(), cacheKey, &cached) {
-		return h.renderResponse(c, cached, group)
+	if h.cache.Get(ctx, cacheKey, &cached) {
+		return cached, "success", nil
 	}
 
 	// ── 6. Collect class-scoped item IDs (via juz → juz_items) ───────────────
 	juzs, err := h.juzRepo.FindByUserAndClass(userID.String(), classIDStr)
 	if err != nil {
-		return utils.Error(c, fiber.StatusInternalServerError, "failed to load class juz data", "INTERNAL_ERROR", nil)
+		return nil, "", errors.New("failed to load class juz data")
 	}
 
 	if len(juzs) == 0 {
 		// User has no juz entries for this class yet → return empty result
-		return utils.Success(c, fiber.StatusOK, "no class items found", []DailyTaskResponse{}, nil)
+		return []DailyTaskResponse{}, "no class items found", nil
 	}
 
 	juzIDStrings := make([]string, 0, len(juzs))
@@ -164,11 +228,11 @@ func (h *ClassDailyHandler) ListClassDaily(c *fiber.Ctx) error {
 
 	classItemIDStrings, err := h.juzItemRepo.FindItemIDsByJuzIDs(juzIDStrings)
 	if err != nil {
-		return utils.Error(c, fiber.StatusInternalServerError, "failed to load class item IDs", "INTERNAL_ERROR", nil)
+		return nil, "", errors.New("failed to load class item IDs")
 	}
 
 	if len(classItemIDStrings) == 0 {
-		return utils.Success(c, fiber.StatusOK, "no class items found", []DailyTaskResponse{}, nil)
+		return []DailyTaskResponse{}, "no class items found", nil
 	}
 
 	// Build a set for O(1) membership check
@@ -180,14 +244,14 @@ func (h *ClassDailyHandler) ListClassDaily(c *fiber.Ctx) error {
 	}
 
 	// ── 7. Load today's daily tasks then filter to class scope ────────────────
-	tasks, err := h.dailyTaskRepo.ListByUserAndDate(c.Context(), userID, now)
+	tasks, err := h.dailyTaskRepo.ListByUserAndDate(ctx, userID, now)
 	if err != nil {
-		return utils.Error(c, fiber.StatusInternalServerError, "failed to load daily tasks", "INTERNAL_ERROR", nil)
+		return nil, "", errors.New("failed to load daily tasks")
 	}
 
 	// Auto-generate if snapshot is empty (mirrors the existing daily handler behaviour)
 	if len(tasks) == 0 {
-		if gen, genErr := h.dailyTaskSvc.GenerateToday(c.Context(), userID, now, 0); genErr == nil {
+		if gen, genErr := h.dailyTaskSvc.GenerateToday(ctx, userID, now, 0); genErr == nil {
 			tasks = gen
 		}
 	}
@@ -205,7 +269,7 @@ func (h *ClassDailyHandler) ListClassDaily(c *fiber.Ctx) error {
 	}
 
 	if len(filtered) == 0 {
-		return utils.Success(c, fiber.StatusOK, "no class daily tasks for today", []DailyTaskResponse{}, nil)
+		return []DailyTaskResponse{}, "no class daily tasks for today", nil
 	}
 
 	// ── 8. Batch-enrich with item metadata ────────────────────────────────────
@@ -251,9 +315,9 @@ func (h *ClassDailyHandler) ListClassDaily(c *fiber.Ctx) error {
 
 	// Cache until midnight
 	midnight := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, config.AppLocation)
-	h.cache.Set(c.Context(), cacheKey, resp, time.Until(midnight))
+	h.cache.Set(ctx, cacheKey, resp, time.Until(midnight))
 
-	return h.renderResponse(c, resp, group)
+	return resp, "success", nil
 }
 
 // renderResponse returns the response grouped by juz or as a flat list.
@@ -294,13 +358,14 @@ func groupByJuzIndex(items []DailyTaskResponse) []DailyTaskGroup {
 // ListClassDailyBook godoc
 // @Summary List today's class-scoped daily tasks (Book)
 // @Description Get all daily tasks for today that are scoped to the user's book class.
-// @Description Requires the user to be a member of the class.
+// @Description Requires the user to be a member of the class. With group_id, class staff get the open tasks of every student of that group instead.
 // @Tags Daily Task
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param class_id query string true "Class UUID"
 // @Param date query string false "Date override (YYYY-MM-DD, defaults to today)"
+// @Param group_id query string false "Class group (halaqah) UUID: list the tasks of its students"
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} utils.ErrorResponse
 // @Failure 401 {object} utils.ErrorResponse
@@ -323,19 +388,23 @@ func (h *ClassDailyHandler) ListClassDailyBook(c *fiber.Ctx) error {
 	}
 
 	// ── 2. Verify user is a member of the class ───────────────────────────────
-	isMember, err := h.classMemberRepo.IsMember(classIDStr, userID.String())
-	if err != nil {
-		return utils.Error(c, fiber.StatusInternalServerError, "failed to verify membership", "INTERNAL_ERROR", nil)
-	}
-
-	// Teacher (guru) of the class is also allowed — check ownership as fallback
-	if !isMember {
-		class, err := h.classRepo.FindByID(classIDStr)
+	// (staff viewing a group are checked by the class service instead)
+	groupID := c.Query("group_id", "")
+	if groupID == "" {
+		isMember, err := h.classMemberRepo.IsMember(classIDStr, userID.String())
 		if err != nil {
-			return utils.Error(c, fiber.StatusNotFound, "class not found", "NOT_FOUND", nil)
+			return utils.Error(c, fiber.StatusInternalServerError, "failed to verify membership", "INTERNAL_ERROR", nil)
 		}
-		if class.GuruID != userID {
-			return utils.Error(c, fiber.StatusForbidden, "you are not a member of this class", "FORBIDDEN", nil)
+
+		// Teacher (guru) of the class is also allowed — check ownership as fallback
+		if !isMember {
+			class, err := h.classRepo.FindByID(classIDStr)
+			if err != nil {
+				return utils.Error(c, fiber.StatusNotFound, "class not found", "NOT_FOUND", nil)
+			}
+			if class.GuruID != userID {
+				return utils.Error(c, fiber.StatusForbidden, "you are not a member of this class", "FORBIDDEN", nil)
+			}
 		}
 	}
 
@@ -353,37 +422,42 @@ func (h *ClassDailyHandler) ListClassDailyBook(c *fiber.Ctx) error {
 			nil,
 		)
 	}
+	if groupID != "" {
+		return h.listGroupDaily(c, userID, classIDStr, groupID, h.bookClassTasks)
+	}
 
 	// ── 4. Resolve target date ────────────────────────────────────────────────
-	dateStr := c.Query("date", "")
-	var now time.Time
-	if dateStr != "" {
-		if t, parseErr := time.ParseInLocation("2006-01-02", dateStr, config.AppLocation); parseErr == nil {
-			now = t
-		} else {
-			now = time.Now().In(config.AppLocation)
-		}
-	} else {
-		now = time.Now().In(config.AppLocation)
+	resp, emptyMsg, err := h.bookClassTasks(c.Context(), userID, classIDStr, classDailyDate(c))
+	if err != nil {
+		return utils.Error(c, fiber.StatusInternalServerError, err.Error(), "INTERNAL_ERROR", nil)
 	}
+	if len(resp) == 0 {
+		return utils.Success(c, fiber.StatusOK, emptyMsg, []DailyTaskResponse{}, nil)
+	}
+	return utils.Success(c, fiber.StatusOK, "success", resp, nil)
+}
+
+// bookClassTasks loads the open tasks of a user for the items of the books of
+// a book class and caches them until midnight.
+func (h *ClassDailyHandler) bookClassTasks(ctx context.Context, userID uuid.UUID, classIDStr string, now time.Time) ([]DailyTaskResponse, string, error) {
 	date := now.Format("2006-01-02")
 
 	// ── 5. Try cache ─────────────────────────────────────────────────────────
 	cacheKey := fmt.Sprintf("class-daily-book:%s:%s:%s", userID.String(), classIDStr, date)
 	var cached []DailyTaskResponse
-	if h.cache.Get(c.Context(), cacheKey, &cached) {
-		return utils.Success(c, fiber.StatusOK, "success", cached, nil)
+	if h.cache.Get(ctx, cacheKey, &cached) {
+		return cached, "success", nil
 	}
 
 	// ── 6. Collect class-scoped book IDs (via class_books) ────────────────────
 	classBooks, err := h.classBookRepo.FindByClassID(classIDStr)
 	if err != nil {
-		return utils.Error(c, fiber.StatusInternalServerError, "failed to load class books", "INTERNAL_ERROR", nil)
+		return nil, "", errors.New("failed to load class books")
 	}
 
 	if len(classBooks) == 0 {
 		// No books in class yet → return empty result
-		return utils.Success(c, fiber.StatusOK, "no class books found", []DailyTaskResponse{}, nil)
+		return []DailyTaskResponse{}, "no class books found", nil
 	}
 
 	bookIDStrings := make([]string, 0, len(classBooks))
@@ -394,11 +468,11 @@ func (h *ClassDailyHandler) ListClassDailyBook(c *fiber.Ctx) error {
 	// ── 7. Fetch user's items for these books ─────────────────────────────────
 	classItems, err := h.itemRepo.FindByOwnerAndBookIDs(userID, bookIDStrings)
 	if err != nil {
-		return utils.Error(c, fiber.StatusInternalServerError, "failed to load class book items", "INTERNAL_ERROR", nil)
+		return nil, "", errors.New("failed to load class book items")
 	}
 
 	if len(classItems) == 0 {
-		return utils.Success(c, fiber.StatusOK, "no class book items found", []DailyTaskResponse{}, nil)
+		return []DailyTaskResponse{}, "no class book items found", nil
 	}
 
 	// Build a set for O(1) membership check
@@ -408,14 +482,14 @@ func (h *ClassDailyHandler) ListClassDailyBook(c *fiber.Ctx) error {
 	}
 
 	// ── 8. Load today's daily tasks then filter to class scope ────────────────
-	tasks, err := h.dailyTaskRepo.ListByUserAndDate(c.Context(), userID, now)
+	tasks, err := h.dailyTaskRepo.ListByUserAndDate(ctx, userID, now)
 	if err != nil {
-		return utils.Error(c, fiber.StatusInternalServerError, "failed to load daily tasks", "INTERNAL_ERROR", nil)
+		return nil, "", errors.New("failed to load daily tasks")
 	}
 
 	// Auto-generate if snapshot is empty
 	if len(tasks) == 0 {
-		if gen, genErr := h.dailyTaskSvc.GenerateToday(c.Context(), userID, now, 0); genErr == nil {
+		if gen, genErr := h.dailyTaskSvc.GenerateToday(ctx, userID, now, 0); genErr == nil {
 			tasks = gen
 		}
 	}
@@ -433,7 +507,7 @@ func (h *ClassDailyHandler) ListClassDailyBook(c *fiber.Ctx) error {
 	}
 
 	if len(filtered) == 0 {
-		return utils.Success(c, fiber.StatusOK, "no class daily tasks for today", []DailyTaskResponse{}, nil)
+		return []DailyTaskResponse{}, "no class daily tasks for today", nil
 	}
 
 	// ── 9. Batch-enrich with item metadata ────────────────────────────────────
@@ -445,6 +519,7 @@ func (h *ClassDailyHandler) ListClassDailyBook(c *fiber.Ctx) error {
 	itemContentMap := make(map[uuid.UUID]string)
 	itemEstimateMap := make(map[uuid.UUID]int)
 	itemStatusMap := make(map[uuid.UUID]string)
+	itemClozeMap := make(map[uuid.UUID]int)
 	bookIDSet := make(map[string]struct{})
 
 	if items, fetchErr := h.itemRepo.FindByIDs(itemIDs); fetchErr == nil {
@@ -452,6 +527,7 @@ func (h *ClassDailyHandler) ListClassDailyBook(c *fiber.Ctx) error {
 			itemContentMap[item.ID] = item.ContentRef
 			itemEstimateMap[item.ID] = item.EstimatedReviewSeconds
 			itemStatusMap[item.ID] = item.Status
+			itemClozeMap[item.ID] = item.ClozeIndex
 
 			// Extract book_id from content_ref: "book:{book_id}:item:{book_item_id}"
 			if len(item.ContentRef) > 5 && item.ContentRef[:5] == "book:" {
@@ -542,6 +618,7 @@ func (h *ClassDailyHandler) ListClassDailyBook(c *fiber.Ctx) error {
 			Status:                 itemStatusMap[t.ItemID],
 			TaskDate:               t.TaskDate.Format("2006-01-02"),
 			ContentRef:             itemContentMap[t.ItemID],
+			ClozeIndex:             itemClozeMap[t.ItemID],
 			JuzIndex:               0, // Book items don't have juz_index
 			EstimatedReviewSeconds: itemEstimateMap[t.ItemID],
 			BookTitle:              bookTitleByItem[t.ItemID],
@@ -551,7 +628,7 @@ func (h *ClassDailyHandler) ListClassDailyBook(c *fiber.Ctx) error {
 
 	// Cache until midnight
 	midnight := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, config.AppLocation)
-	h.cache.Set(c.Context(), cacheKey, resp, time.Until(midnight))
+	h.cache.Set(ctx, cacheKey, resp, time.Until(midnight))
 
-	return utils.Success(c, fiber.StatusOK, "success", resp, nil)
+	return resp, "success", nil
 }
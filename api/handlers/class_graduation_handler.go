@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"hifzhun-api/pkg/config"
+	"hifzhun-api/pkg/services"
+	"hifzhun-api/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// GraduationReviewRequest represents the optional body of a single
+// approve/reject. retest_at is only used when rejecting.
+type GraduationReviewRequest struct {
+	Comment  string `json:"comment,omitempty" example:"Ayat 5-7 masih tertukar"`
+	RetestAt string `json:"retest_at,omitempty" example:"2026-10-26"` // YYYY-MM-DD
+}
+
+// GraduationBatchRequest represents one decision for several pending items
+type GraduationBatchRequest struct {
+	Action   string   `json:"action" example:"approve"` // approve | reject
+	ItemIDs  []string `json:"item_ids"`
+	Comment  string   `json:"comment,omitempty"`
+	RetestAt string   `json:"retest_at,omitempty" example:"2026-10-26"` // YYYY-MM-DD, reject only
+}
+
+// GraduationPolicyRequest represents a graduation policy change; omitted
+// fields keep their current value
+type GraduationPolicyRequest struct {
+	MinReviews         *int     `json:"min_reviews,omitempty" example:"7"`
+	MinDays            *int     `json:"min_days,omitempty" example:"45"`
+	StabilityThreshold *float64 `json:"stability_threshold,omitempty" example:"40"`
+	RequireApproval    *bool    `json:"require_approval,omitempty" example:"true"`
+	GraduateReviewDays *int     `json:"graduate_review_days,omitempty" example:"14"`
+}
+
+// parseRetestAt parses an optional YYYY-MM-DD retest date
+func parseRetestAt(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, config.AppLocation)
+	if err != nil {
+		return nil, errors.New("retest_at must be YYYY-MM-DD")
+	}
+	return &t, nil
+}
+
+// DecideGraduations godoc
+// @Summary Batch approve or reject graduations
+// @Description Teacher approves or rejects several pending items at once with one comment (and a retest date when rejecting). Each item is decided on its own; items that cannot be decided are listed in failed.
+// @Tags Class
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Class ID"
+// @Param request body GraduationBatchRequest true "Decision"
+// @Success 200 {object} utils.SuccessResponse{data=services.GraduationBatchResult}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /classes/{id}/graduations/batch [post]
+func (h *ClassHandler) DecideGraduations(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	var req GraduationBatchRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, "invalid request body", "BAD_REQUEST", nil)
+	}
+	retestAt, err := parseRetestAt(req.RetestAt)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "BAD_REQUEST", nil)
+	}
+
+	result, err := h.classSvc.DecideGraduations(c.Params("id"), userID, services.GraduationBatchInput{
+		Action:   req.Action,
+		ItemIDs:  req.ItemIDs,
+		Comment:  req.Comment,
+		RetestAt: retestAt,
+	})
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "DECIDE_GRADUATIONS_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "graduation decisions applied", result, nil)
+}
+
+// GetGraduationHistory godoc
+// @Summary Graduation decision history
+// @Description Teacher lists every approve/reject decision of the class, newest first, optionally for one student
+// @Tags Class
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Class ID"
+// @Param student_id query string false "Student ID"
+// @Param page query int false "Page (default 1)"
+// @Param per_page query int false "Per page (default 20)"
+// @Success 200 {object} utils.SuccessResponse{data=[]services.GraduationDecision}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /classes/{id}/graduations/history [get]
+func (h *ClassHandler) GetGraduationHistory(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	return h.graduationHistory(c, userID, c.Query("student_id"))
+}
+
+// GetMyGraduations godoc
+// @Summary My graduation decisions
+// @Description Student lists the teacher's decisions on their items in a class, with comments and retest dates
+// @Tags Class
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Class ID"
+// @Param page query int false "Page (default 1)"
+// @Param per_page query int false "Per page (default 20)"
+// @Success 200 {object} utils.SuccessResponse{data=[]services.GraduationDecision}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /classes/{id}/my-graduations [get]
+func (h *ClassHandler) GetMyGraduations(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	return h.graduationHistory(c, userID, userID.String())
+}
+
+func (h *ClassHandler) graduationHistory(c *fiber.Ctx, userID uuid.UUID, studentID string) error {
+	page, perPage := pageParams(c)
+
+	decisions, total, err := h.classSvc.GetGraduationHistory(c.Params("id"), userID, studentID, page, perPage)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "GET_GRADUATION_HISTORY_FAILED", nil)
+	}
+
+	meta := &utils.Meta{Page: page, PerPage: perPage, Total: int(total)}
+	return utils.Success(c, fiber.StatusOK, "graduation history fetched successfully", decisions, meta)
+}
+
+// GetGraduationPolicy godoc
+// @Summary Get the class graduation policy
+// @Description Teacher and members get the graduation standard of the class: min reviews, min days in fsrs_active or stability threshold, whether teacher approval is required and the graduate review cadence. is_default is true when the class uses the default policy.
+// @Tags Class
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Class ID"
+// @Success 200 {object} utils.SuccessResponse{data=services.GraduationPolicy}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /classes/{id}/graduation-policy [get]
+func (h *ClassHandler) GetGraduationPolicy(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	policy, err := h.classSvc.GetGraduationPolicy(c.Params("id"), userID)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "GET_GRADUATION_POLICY_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "graduation policy fetched successfully", policy, nil)
+}
+
+// UpdateGraduationPolicy godoc
+// @Summary Update the class graduation policy
+// @Description Teacher changes the graduation standard of the class. Items are evaluated against it from their next review or daily task generation.
+// @Tags Class
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Class ID"
+// @Param request body GraduationPolicyRequest true "Policy"
+// @Success 200 {object} utils.SuccessResponse{data=services.GraduationPolicy}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /classes/{id}/graduation-policy [put]
+func (h *ClassHandler) UpdateGraduationPolicy(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	var req GraduationPolicyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, "invalid request body", "BAD_REQUEST", nil)
+	}
+
+	policy, err := h.classSvc.UpdateGraduationPolicy(c.Params("id"), userID, services.GraduationPolicyInput{
+		MinReviews:         req.MinReviews,
+		MinDays:            req.MinDays,
+		StabilityThreshold: req.StabilityThreshold,
+		RequireApproval:    req.RequireApproval,
+		GraduateReviewDays: req.GraduateReviewDays,
+	})
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "UPDATE_GRADUATION_POLICY_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "graduation policy updated successfully", policy, nil)
+}
+
+// ResetGraduationPolicy godoc
+// @Summary Reset the class graduation policy
+// @Description Teacher removes the class policy so the default one applies
+// @Tags Class
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Class ID"
+// @Success 200 {object} utils.SuccessResponse{data=services.GraduationPolicy}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /classes/{id}/graduation-policy [delete]
+func (h *ClassHandler) ResetGraduationPolicy(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	policy, err := h.classSvc.ResetGraduationPolicy(c.Params("id"), userID)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "RESET_GRADUATION_POLICY_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "graduation policy reset to default", policy, nil)
+}
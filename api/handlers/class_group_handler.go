@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"hifzhun-api/pkg/services"
+	"hifzhun-api/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// ClassGroupRequest represents a new group or a group update; omitted fields
+// stay and an empty staff_id unassigns the staff member
+type ClassGroupRequest struct {
+	Name        *string `json:"name,omitempty" example:"Halaqah Abu Bakar"`
+	Description *string `json:"description,omitempty"`
+	StaffID     *string `json:"staff_id,omitempty" example:"550e8400-e29b-41d4-a716-446655440000"`
+}
+
+// GroupMembersRequest represents students placed in a group
+type GroupMembersRequest struct {
+	UserIDs []string `json:"user_ids"`
+}
+
+func (r ClassGroupRequest) input() services.ClassGroupInput {
+	return services.ClassGroupInput{Name: r.Name, Description: r.Description, StaffID: r.StaffID}
+}
+
+// CreateClassGroup godoc
+// @Summary Create a class group
+// @Description Teacher adds a group (halaqah) to a class, optionally led by the owner or an active staff member
+// @Tags Class Groups
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Class ID"
+// @Param request body ClassGroupRequest true "Group"
+// @Success 201 {object} utils.SuccessResponse{data=services.ClassGroupInfo}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /classes/{id}/groups [post]
+func (h *ClassHandler) CreateClassGroup(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	var req ClassGroupRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, "invalid request body", "BAD_REQUEST", nil)
+	}
+
+	group, err := h.classSvc.CreateClassGroup(c.Params("id"), userID, req.input())
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "CREATE_GROUP_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusCreated, "group created successfully", group, nil)
+}
+
+// GetClassGroups godoc
+// @Summary List class groups
+// @Description Teacher or staff lists the groups of a class with their staff member and size
+// @Tags Class Groups
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Class ID"
+// @Success 200 {object} utils.SuccessResponse{data=[]services.ClassGroupInfo}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /classes/{id}/groups [get]
+func (h *ClassHandler) GetClassGroups(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	groups, err := h.classSvc.GetClassGroups(c.Params("id"), userID)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "GET_GROUPS_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "groups fetched successfully", groups, nil)
+}
+
+// GetClassGroup godoc
+// @Summary Get a class group
+// @Description Teacher or staff gets a group with its students
+// @Tags Class Groups
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Class ID"
+// @Param group_id path string true "Group ID"
+// @Success 200 {object} utils.SuccessResponse{data=services.ClassGroupInfo}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /classes/{id}/groups/{group_id} [get]
+func (h *ClassHandler) GetClassGroup(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	group, err := h.classSvc.GetClassGroup(c.Params("id"), c.Params("group_id"), userID)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "GET_GROUP_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "group fetched successfully", group, nil)
+}
+
+// UpdateClassGroup godoc
+// @Summary Update a class group
+// @Description Teacher renames a group or changes its description or staff member
+// @Tags Class Groups
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Class ID"
+// @Param group_id path string true "Group ID"
+// @Param request body ClassGroupRequest true "Group update"
+// @Success 200 {object} utils.SuccessResponse{data=services.ClassGroupInfo}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /classes/{id}/groups/{group_id} [put]
+func (h *ClassHandler) UpdateClassGroup(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	var req ClassGroupRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, "invalid request body", "BAD_REQUEST", nil)
+	}
+
+	group, err := h.classSvc.UpdateClassGroup(c.Params("id"), c.Params("group_id"), userID, req.input())
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "UPDATE_GROUP_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "group updated successfully", group, nil)
+}
+
+// DeleteClassGroup godoc
+// @Summary Delete a class group
+// @Description Teacher removes a group. Its students stay in the class and keep the assignments given to the group.
+// @Tags Class Groups
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Class ID"
+// @Param group_id path string true "Group ID"
+// @Success 200 {object} utils.SuccessResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /classes/{id}/groups/{group_id} [delete]
+func (h *ClassHandler) DeleteClassGroup(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	if err := h.classSvc.DeleteClassGroup(c.Params("id"), c.Params("group_id"), userID); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "DELETE_GROUP_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "group deleted successfully", nil, nil)
+}
+
+// AddGroupMembers godoc
+// @Summary Add students to a class group
+// @Description Teacher places class members in a group, moving them out of their previous group. They get the open assignments of the group.
+// @Tags Class Groups
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Class ID"
+// @Param group_id path string true "Group ID"
+// @Param request body GroupMembersRequest true "Students"
+// @Success 200 {object} utils.SuccessResponse{data=services.ClassGroupInfo}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /classes/{id}/groups/{group_id}/members [post]
+func (h *ClassHandler) AddGroupMembers(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	var req GroupMembersRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, "invalid request body", "BAD_REQUEST", nil)
+	}
+
+	group, err := h.classSvc.AddGroupMembers(c.Params("id"), c.Params("group_id"), userID, req.UserIDs)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "ADD_GROUP_MEMBERS_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "students added to group successfully", group, nil)
+}
+
+// RemoveGroupMember godoc
+// @Summary Remove a student from a class group
+// @Description Teacher takes a student out of a group; the student stays in the class
+// @Tags Class Groups
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Class ID"
+// @Param group_id path string true "Group ID"
+// @Param user_id path string true "Student user ID"
+// @Success 200 {object} utils.SuccessResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /classes/{id}/groups/{group_id}/members/{user_id} [delete]
+func (h *ClassHandler) RemoveGroupMember(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	if err := h.classSvc.RemoveGroupMember(c.Params("id"), c.Params("group_id"), userID, c.Params("user_id")); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "REMOVE_GROUP_MEMBER_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "student removed from group successfully", nil, nil)
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"hifzhun-api/pkg/cache"
 	"hifzhun-api/pkg/services"
 	"hifzhun-api/pkg/utils"
 
@@ -10,10 +11,11 @@ import (
 
 type ClassHandler struct {
 	classSvc services.ClassService
+	cache    *cache.Cache
 }
 
-func NewClassHandler(classSvc services.ClassService) *ClassHandler {
-	return &ClassHandler{classSvc}
+func NewClassHandler(classSvc services.ClassService, c *cache.Cache) *ClassHandler {
+	return &ClassHandler{classSvc, c}
 }
 
 // ==================== TEACHER ENDPOINTS ====================
@@ -262,6 +264,7 @@ func (h *ClassHandler) RemoveBookFromClass(c *fiber.Ctx) error {
 // @Produce json
 // @Security BearerAuth
 // @Param id path string true "Class ID"
+// @Param group_id query string false "Only the students of this group"
 // @Success 200 {object} utils.SuccessResponse{data=[]services.StudentProgress}
 // @Failure 400 {object} utils.ErrorResponse
 // @Failure 403 {object} utils.ErrorResponse
@@ -270,7 +273,7 @@ func (h *ClassHandler) GetStudentProgress(c *fiber.Ctx) error {
 	userID := c.Locals("user_id").(uuid.UUID)
 	classID := c.Params("id")
 
-	progress, err := h.classSvc.GetStudentProgress(classID, userID)
+	progress, err := h.classSvc.GetStudentProgress(classID, userID, c.Query("group_id"))
 	if err != nil {
 		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "GET_PROGRESS_FAILED", nil)
 	}
@@ -287,6 +290,7 @@ func (h *ClassHandler) GetStudentProgress(c *fiber.Ctx) error {
 // @Security BearerAuth
 // @Param id path string true "Class ID"
 // @Param book_id path string true "Book ID"
+// @Param group_id query string false "Only the students of this group"
 // @Success 200 {object} utils.SuccessResponse{data=services.ClassBookStudentProgress}
 // @Failure 400 {object} utils.ErrorResponse
 // @Failure 403 {object} utils.ErrorResponse
@@ -294,7 +298,7 @@ func (h *ClassHandler) GetStudentProgress(c *fiber.Ctx) error {
 func (h *ClassHandler) GetClassBookStudentProgress(c *fiber.Ctx) error {
 	userID := c.Locals("user_id").(uuid.UUID)
 
-	progress, err := h.classSvc.GetClassBookStudentProgress(c.Params("id"), c.Params("book_id"), userID)
+	progress, err := h.classSvc.GetClassBookStudentProgress(c.Params("id"), c.Params("book_id"), userID, c.Query("group_id"))
 	if err != nil {
 		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "GET_BOOK_PROGRESS_FAILED", nil)
 	}
@@ -330,13 +334,14 @@ func (h *ClassHandler) GetClassMembers(c *fiber.Ctx) error {
 
 // JoinClass godoc
 // @Summary Join class with code
-// @Description Student joins a class using class code
+// @Description Student joins a class using class code. Open classes join right away; approval classes queue a join request for the teacher (202, join_status pending); invite-only classes, expired codes, full classes and banned users are refused.
 // @Tags Class
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param request body JoinClassRequest true "Join class request"
 // @Success 200 {object} utils.SuccessResponse{data=entities.Class}
+// @Success 202 {object} utils.SuccessResponse{data=entities.Class}
 // @Failure 400 {object} utils.ErrorResponse
 // @Router /classes/join [post]
 func (h *ClassHandler) JoinClass(c *fiber.Ctx) error {
@@ -355,6 +360,9 @@ func (h *ClassHandler) JoinClass(c *fiber.Ctx) error {
 		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "JOIN_CLASS_FAILED", nil)
 	}
 
+	if class.JoinStatus == "pending" {
+		return utils.Success(c, fiber.StatusAccepted, "join request sent, waiting for teacher approval", class, nil)
+	}
 	return utils.Success(c, fiber.StatusOK, "joined class successfully", class, nil)
 }
 
@@ -462,6 +470,7 @@ type JoinClassRequest struct {
 // @Produce json
 // @Security BearerAuth
 // @Param id path string true "Class ID"
+// @Param group_id query string false "Only the students of this group"
 // @Success 200 {object} utils.SuccessResponse{data=[]services.PendingGraduation}
 // @Failure 400 {object} utils.ErrorResponse
 // @Failure 403 {object} utils.ErrorResponse
@@ -470,7 +479,7 @@ func (h *ClassHandler) GetPendingGraduations(c *fiber.Ctx) error {
 	userID := c.Locals("user_id").(uuid.UUID)
 	classID := c.Params("id")
 
-	pending, err := h.classSvc.GetPendingGraduations(classID, userID)
+	pending, err := h.classSvc.GetPendingGraduations(classID, userID, c.Query("group_id"))
 	if err != nil {
 		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "GET_PENDING_FAILED", nil)
 	}
@@ -480,13 +489,14 @@ func (h *ClassHandler) GetPendingGraduations(c *fiber.Ctx) error {
 
 // ApproveGraduation godoc
 // @Summary Approve graduation
-// @Description Teacher approves an item for graduation. The item must belong to a student in this Quran class and be created in a juz with this class_id.
+// @Description Teacher approves an item for graduation. The item must belong to a student in this Quran class and be created in a juz with this class_id. The optional comment is recorded in the graduation history.
 // @Tags Class
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param id path string true "Class ID"
 // @Param item_id path string true "Item ID"
+// @Param request body GraduationReviewRequest false "Comment"
 // @Success 200 {object} utils.SuccessResponse
 // @Failure 400 {object} utils.ErrorResponse
 // @Failure 403 {object} utils.ErrorResponse
@@ -496,7 +506,14 @@ func (h *ClassHandler) ApproveGraduation(c *fiber.Ctx) error {
 	classID := c.Params("id")
 	itemID := c.Params("item_id")
 
-	if err := h.classSvc.ApproveGraduation(classID, userID, itemID); err != nil {
+	var req GraduationReviewRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return utils.Error(c, fiber.StatusBadRequest, "invalid request body", "BAD_REQUEST", nil)
+		}
+	}
+
+	if err := h.classSvc.ApproveGraduation(classID, userID, itemID, req.Comment); err != nil {
 		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "APPROVE_FAILED", nil)
 	}
 
@@ -505,13 +522,14 @@ func (h *ClassHandler) ApproveGraduation(c *fiber.Ctx) error {
 
 // RejectGraduation godoc
 // @Summary Reject graduation
-// @Description Teacher rejects an item for graduation (returns to fsrs_active). The item must belong to this Quran class scope.
+// @Description Teacher rejects an item for graduation (returns to fsrs_active). The item must belong to this Quran class scope. The comment is shown to the student; with a retest_at date the item is forced into the student's daily tasks on that date and cannot graduate again before that review.
 // @Tags Class
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param id path string true "Class ID"
 // @Param item_id path string true "Item ID"
+// @Param request body GraduationReviewRequest false "Comment and retest date"
 // @Success 200 {object} utils.SuccessResponse
 // @Failure 400 {object} utils.ErrorResponse
 // @Failure 403 {object} utils.ErrorResponse
@@ -521,7 +539,18 @@ func (h *ClassHandler) RejectGraduation(c *fiber.Ctx) error {
 	classID := c.Params("id")
 	itemID := c.Params("item_id")
 
-	if err := h.classSvc.RejectGraduation(classID, userID, itemID); err != nil {
+	var req GraduationReviewRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return utils.Error(c, fiber.StatusBadRequest, "invalid request body", "BAD_REQUEST", nil)
+		}
+	}
+	retestAt, err := parseRetestAt(req.RetestAt)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "BAD_REQUEST", nil)
+	}
+
+	if err := h.classSvc.RejectGraduation(classID, userID, itemID, req.Comment, retestAt); err != nil {
 		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "REJECT_FAILED", nil)
 	}
 
@@ -0,0 +1,401 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"hifzhun-api/pkg/services"
+	"hifzhun-api/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// JoinSettingsRequest represents a join settings change; omitted fields keep
+// their current value and an empty code_expires_at removes the expiry
+type JoinSettingsRequest struct {
+	JoinPolicy    *string `json:"join_policy,omitempty" example:"approval"` // open | approval | invite_only
+	MaxMembers    *int    `json:"max_members,omitempty" example:"30"`       // 0 = unlimited
+	CodeExpiresAt *string `json:"code_expires_at,omitempty" example:"2026-11-01T00:00:00+07:00"`
+}
+
+// RegenerateCodeRequest represents the optional expiry of a new class code
+type RegenerateCodeRequest struct {
+	ExpiresAt string `json:"expires_at,omitempty" example:"2026-11-01T00:00:00+07:00"` // RFC3339
+}
+
+// InviteStudentRequest represents a student invited by the teacher
+type InviteStudentRequest struct {
+	Email string `json:"email" example:"student@example.com"`
+}
+
+// BanMemberRequest represents the optional reason of a ban
+type BanMemberRequest struct {
+	Reason string `json:"reason,omitempty" example:"Menyebarkan kode kelas"`
+}
+
+// parseCodeExpiry parses an optional RFC3339 code expiry
+func parseCodeExpiry(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, errors.New("code expiry must be RFC3339")
+	}
+	return &t, nil
+}
+
+// UpdateJoinSettings godoc
+// @Summary Update class join settings
+// @Description Teacher sets how students join: open (code joins right away), approval (code queues a join request) or invite_only (teacher adds students), the maximum member count (0 = unlimited) and the code expiry
+// @Tags Class Members
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Class ID"
+// @Param request body JoinSettingsRequest true "Join settings"
+// @Success 200 {object} utils.SuccessResponse{data=entities.Class}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /classes/{id}/join-settings [put]
+func (h *ClassHandler) UpdateJoinSettings(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	var req JoinSettingsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, "invalid request body", "BAD_REQUEST", nil)
+	}
+
+	in := services.JoinSettingsInput{JoinPolicy: req.JoinPolicy, MaxMembers: req.MaxMembers}
+	if req.CodeExpiresAt != nil {
+		expiresAt, err := parseCodeExpiry(*req.CodeExpiresAt)
+		if err != nil {
+			return utils.Error(c, fiber.StatusBadRequest, err.Error(), "BAD_REQUEST", nil)
+		}
+		in.CodeExpiresAt = expiresAt
+		in.ClearCodeExpiry = expiresAt == nil
+	}
+
+	class, err := h.classSvc.UpdateJoinSettings(c.Params("id"), userID, in)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "UPDATE_JOIN_SETTINGS_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "join settings updated successfully", class, nil)
+}
+
+// RegenerateClassCode godoc
+// @Summary Regenerate the class code
+// @Description Teacher replaces the class code so the old one stops working, optionally with an expiry
+// @Tags Class Members
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Class ID"
+// @Param request body RegenerateCodeRequest false "Expiry"
+// @Success 200 {object} utils.SuccessResponse{data=entities.Class}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /classes/{id}/code/regenerate [post]
+func (h *ClassHandler) RegenerateClassCode(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	var req RegenerateCodeRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return utils.Error(c, fiber.StatusBadRequest, "invalid request body", "BAD_REQUEST", nil)
+		}
+	}
+	expiresAt, err := parseCodeExpiry(req.ExpiresAt)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "BAD_REQUEST", nil)
+	}
+
+	class, err := h.classSvc.RegenerateClassCode(c.Params("id"), userID, expiresAt)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "REGENERATE_CODE_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "class code regenerated successfully", class, nil)
+}
+
+// GetJoinRequests godoc
+// @Summary List pending join requests
+// @Tags Class Members
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Class ID"
+// @Success 200 {object} utils.SuccessResponse{data=[]services.JoinRequestInfo}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /classes/{id}/join-requests [get]
+func (h *ClassHandler) GetJoinRequests(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	requests, err := h.classSvc.GetJoinRequests(c.Params("id"), userID)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "GET_JOIN_REQUESTS_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "join requests fetched successfully", requests, nil)
+}
+
+// ApproveJoinRequest godoc
+// @Summary Approve a join request
+// @Description Teacher adds the student to the class, within its maximum member count
+// @Tags Class Members
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Class ID"
+// @Param user_id path string true "Student ID"
+// @Success 200 {object} utils.SuccessResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /classes/{id}/join-requests/{user_id}/approve [post]
+func (h *ClassHandler) ApproveJoinRequest(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	if err := h.classSvc.DecideJoinRequest(c.Params("id"), userID, c.Params("user_id"), true); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "APPROVE_JOIN_REQUEST_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "join request approved", nil, nil)
+}
+
+// RejectJoinRequest godoc
+// @Summary Reject a join request
+// @Description The student can send a new request later; ban them to prevent that
+// @Tags Class Members
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Class ID"
+// @Param user_id path string true "Student ID"
+// @Success 200 {object} utils.SuccessResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /classes/{id}/join-requests/{user_id}/reject [post]
+func (h *ClassHandler) RejectJoinRequest(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	if err := h.classSvc.DecideJoinRequest(c.Params("id"), userID, c.Params("user_id"), false); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "REJECT_JOIN_REQUEST_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "join request rejected", nil, nil)
+}
+
+// InviteStudent godoc
+// @Summary Invite a student to the class
+// @Description Teacher invites a student account by email regardless of the join policy; this is how invite-only classes get their students. The student becomes a member once they accept. Inviting the same student again returns the open invitation.
+// @Tags Class Members
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Class ID"
+// @Param request body InviteStudentRequest true "Student"
+// @Success 201 {object} utils.SuccessResponse{data=entities.ClassInvitation}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /classes/{id}/invitations [post]
+func (h *ClassHandler) InviteStudent(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	var req InviteStudentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, "invalid request body", "BAD_REQUEST", nil)
+	}
+
+	invitation, err := h.classSvc.InviteStudent(c.Params("id"), userID, req.Email)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "INVITE_STUDENT_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusCreated, "student invited successfully", invitation, nil)
+}
+
+// GetClassInvitations godoc
+// @Summary Open student invitations of a class
+// @Tags Class Members
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Class ID"
+// @Success 200 {object} utils.SuccessResponse{data=[]services.ClassInvitationInfo}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /classes/{id}/invitations [get]
+func (h *ClassHandler) GetClassInvitations(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	invitations, err := h.classSvc.GetClassInvitations(c.Params("id"), userID)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "GET_INVITATIONS_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "invitations fetched successfully", invitations, nil)
+}
+
+// RevokeClassInvitation godoc
+// @Summary Revoke a student invitation
+// @Tags Class Members
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Class ID"
+// @Param invitation_id path string true "Invitation ID"
+// @Success 200 {object} utils.SuccessResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /classes/{id}/invitations/{invitation_id} [delete]
+func (h *ClassHandler) RevokeClassInvitation(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	if err := h.classSvc.RevokeClassInvitation(c.Params("id"), userID, c.Params("invitation_id")); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "REVOKE_INVITATION_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "invitation revoked successfully", nil, nil)
+}
+
+// GetMyClassInvitations godoc
+// @Summary My class invitations
+// @Description Open invitations to join a class as a student, sent to the current user
+// @Tags Class Members
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.SuccessResponse{data=[]services.ClassInvitationInfo}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /classes/invitations [get]
+func (h *ClassHandler) GetMyClassInvitations(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	invitations, err := h.classSvc.GetMyClassInvitations(userID)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "GET_INVITATIONS_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "invitations fetched successfully", invitations, nil)
+}
+
+// AcceptClassInvitation godoc
+// @Summary Accept a class invitation
+// @Description Joins the class, whatever its join policy; bans and max_members still apply
+// @Tags Class Members
+// @Produce json
+// @Security BearerAuth
+// @Param invitation_id path string true "Invitation ID"
+// @Success 200 {object} utils.SuccessResponse{data=services.MemberInfo}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /classes/invitations/{invitation_id}/accept [post]
+func (h *ClassHandler) AcceptClassInvitation(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	member, err := h.classSvc.RespondToClassInvitation(c.Params("invitation_id"), userID, true)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "ACCEPT_INVITATION_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "invitation accepted successfully", member, nil)
+}
+
+// DeclineClassInvitation godoc
+// @Summary Decline a class invitation
+// @Tags Class Members
+// @Produce json
+// @Security BearerAuth
+// @Param invitation_id path string true "Invitation ID"
+// @Success 200 {object} utils.SuccessResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /classes/invitations/{invitation_id}/decline [post]
+func (h *ClassHandler) DeclineClassInvitation(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	if _, err := h.classSvc.RespondToClassInvitation(c.Params("invitation_id"), userID, false); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "DECLINE_INVITATION_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "invitation declined successfully", nil, nil)
+}
+
+// RemoveMember godoc
+// @Summary Remove a student from the class
+// @Description The student can join again; ban them to prevent that
+// @Tags Class Members
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Class ID"
+// @Param user_id path string true "Student ID"
+// @Success 200 {object} utils.SuccessResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /classes/{id}/members/{user_id} [delete]
+func (h *ClassHandler) RemoveMember(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	if err := h.classSvc.RemoveMember(c.Params("id"), userID, c.Params("user_id")); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "REMOVE_MEMBER_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "member removed successfully", nil, nil)
+}
+
+// BanMember godoc
+// @Summary Ban a student from the class
+// @Description Removes the student (and any pending join request) and keeps them from joining again until unbanned
+// @Tags Class Members
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Class ID"
+// @Param user_id path string true "Student ID"
+// @Param request body BanMemberRequest false "Reason"
+// @Success 200 {object} utils.SuccessResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /classes/{id}/members/{user_id}/ban [post]
+func (h *ClassHandler) BanMember(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	var req BanMemberRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return utils.Error(c, fiber.StatusBadRequest, "invalid request body", "BAD_REQUEST", nil)
+		}
+	}
+
+	if err := h.classSvc.BanMember(c.Params("id"), userID, c.Params("user_id"), req.Reason); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "BAN_MEMBER_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "member banned successfully", nil, nil)
+}
+
+// GetClassBans godoc
+// @Summary List banned users of the class
+// @Tags Class Members
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Class ID"
+// @Success 200 {object} utils.SuccessResponse{data=[]services.ClassBanInfo}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /classes/{id}/bans [get]
+func (h *ClassHandler) GetClassBans(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	bans, err := h.classSvc.GetClassBans(c.Params("id"), userID)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "GET_CLASS_BANS_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "class bans fetched successfully", bans, nil)
+}
+
+// UnbanMember godoc
+// @Summary Lift a ban
+// @Description The user can join the class again; they are not re-added
+// @Tags Class Members
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Class ID"
+// @Param user_id path string true "Student ID"
+// @Success 200 {object} utils.SuccessResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /classes/{id}/bans/{user_id} [delete]
+func (h *ClassHandler) UnbanMember(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	if err := h.classSvc.UnbanMember(c.Params("id"), userID, c.Params("user_id")); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "UNBAN_MEMBER_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "ban lifted successfully", nil, nil)
+}
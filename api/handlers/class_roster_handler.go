@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"hifzhun-api/pkg/services"
+	"hifzhun-api/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// maxRosterFileSize caps an uploaded roster CSV (1MB)
+const maxRosterFileSize = 1 << 20
+
+// RosterStudentRequest is one student of a JSON roster
+type RosterStudentRequest struct {
+	FullName string `json:"full_name" example:"Ahmad Fauzi"`
+	Login    string `json:"login" example:"ahmad.fauzi"` // email or username
+	Guardian string `json:"guardian,omitempty" example:"Bapak Fauzi"`
+}
+
+// RosterImportRequest represents a JSON roster
+type RosterImportRequest struct {
+	Students []RosterStudentRequest `json:"students"`
+}
+
+// ImportRoster godoc
+// @Summary Import a class roster
+// @Description Teacher uploads a CSV roster (file field "file"; columns name, email or username, optional guardian; header row optional) or sends JSON students. New students get active accounts with one-time passwords and are enrolled; rows matching an existing account are reported as existing_account and not enrolled, the student joins with the class code. Rows are handled independently. With format=csv the response is a printable credentials sheet instead of JSON; passwords are only shown in this response.
+// @Tags Class Members
+// @Accept multipart/form-data
+// @Accept json
+// @Produce json
+// @Produce text/csv
+// @Security BearerAuth
+// @Param id path string true "Class ID"
+// @Param file formData file false "Roster CSV"
+// @Param request body RosterImportRequest false "JSON roster"
+// @Param format query string false "json (default) or csv"
+// @Success 200 {object} utils.SuccessResponse{data=services.RosterImportResult}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /classes/{id}/roster/import [post]
+func (h *ClassHandler) ImportRoster(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	var rows []services.RosterRow
+	if strings.HasPrefix(c.Get(fiber.HeaderContentType), fiber.MIMEMultipartForm) {
+		file, err := c.FormFile("file")
+		if err != nil {
+			return utils.Error(c, fiber.StatusBadRequest, "roster file is required", "BAD_REQUEST", nil)
+		}
+		if file.Size > maxRosterFileSize {
+			return utils.Error(c, fiber.StatusBadRequest, "roster file must be at most 1MB", "BAD_REQUEST", nil)
+		}
+		f, err := file.Open()
+		if err != nil {
+			return utils.Error(c, fiber.StatusBadRequest, "failed to read roster file", "BAD_REQUEST", nil)
+		}
+		defer f.Close()
+		rows, err = services.ParseRosterCSV(f)
+		if err != nil {
+			return utils.Error(c, fiber.StatusBadRequest, err.Error(), "IMPORT_ROSTER_FAILED", nil)
+		}
+	} else {
+		var req RosterImportRequest
+		if err := c.BodyParser(&req); err != nil {
+			return utils.Error(c, fiber.StatusBadRequest, "invalid request body", "BAD_REQUEST", nil)
+		}
+		for i, s := range req.Students {
+			rows = append(rows, services.RosterRow{Line: i + 1, FullName: s.FullName, Login: s.Login, Guardian: s.Guardian})
+		}
+	}
+
+	result, err := h.classSvc.ImportRoster(c.Params("id"), userID, rows)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "IMPORT_ROSTER_FAILED", nil)
+	}
+
+	if c.Query("format") == "csv" {
+		sheet, err := rosterCredentialsCSV(result)
+		if err != nil {
+			return utils.Error(c, fiber.StatusInternalServerError, "failed to build credentials sheet", "INTERNAL_ERROR", nil)
+		}
+		c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+		c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="roster-%s.csv"`, result.ClassCode))
+		return c.Send(sheet)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "roster imported successfully", result, nil)
+}
+
+// rosterCredentialsCSV renders the import result as a credentials sheet
+func rosterCredentialsCSV(result *services.RosterImportResult) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	records := [][]string{
+		{"Kelas", result.ClassName},
+		{"Kode Kelas", result.ClassCode},
+		{},
+		{"Baris", "Nama", "Login", "Password Sekali Pakai", "Wali", "Status", "Catatan"},
+	}
+	for _, row := range result.Rows {
+		login := row.Username
+		if login == "" {
+			login = row.Email
+		}
+		records = append(records, []string{
+			strconv.Itoa(row.Line), row.FullName, login, row.Password, row.Guardian, row.Status, row.Error,
+		})
+	}
+	if err := w.WriteAll(records); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
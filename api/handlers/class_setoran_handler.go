@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"fmt"
+	"time"
+
+	"hifzhun-api/pkg/config"
+	"hifzhun-api/pkg/services"
+	"hifzhun-api/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// RecordSetoranRequest represents a setoran heard by the class teacher
+type RecordSetoranRequest struct {
+	StudentID uuid.UUID `json:"student_id"`
+	ItemID    uuid.UUID `json:"item_id"`
+	Grade     int       `json:"grade" example:"3" minimum:"1" maximum:"4"` // 1=ulang, 2=kurang lancar, 3=lancar, 4=mutqin
+	Mistakes  int       `json:"mistakes" example:"2"`
+	Notes     string    `json:"notes,omitempty" example:"tertukar ayat 12 dan 13"`
+}
+
+// RecordSetoran godoc
+// @Summary Record a setoran
+// @Description The class teacher records a setoran (oral examination) of a student's class item: grade, mistakes and notes. The grade is applied to the item's FSRS state like a review (marked as teacher-assessed in the review log) and may be given before the item's next review date.
+// @Tags Setoran
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Class ID"
+// @Param request body RecordSetoranRequest true "Setoran"
+// @Success 201 {object} utils.SuccessResponse{data=entities.Setoran}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /classes/{id}/setoran [post]
+func (h *ClassHandler) RecordSetoran(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	var req RecordSetoranRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, "invalid request body", "BAD_REQUEST", nil)
+	}
+
+	now := time.Now().In(config.AppLocation)
+	setoran, err := h.classSvc.RecordSetoran(c.Params("id"), userID, services.SetoranInput{
+		StudentID: req.StudentID,
+		ItemID:    req.ItemID,
+		Grade:     req.Grade,
+		Mistakes:  req.Mistakes,
+		Notes:     req.Notes,
+	}, now)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "RECORD_SETORAN_FAILED", nil)
+	}
+
+	// The student's item changed: invalidate their caches
+	if h.cache != nil {
+		ctx := c.Context()
+		studentID := req.StudentID.String()
+		h.cache.Delete(ctx, fmt.Sprintf("juz:list:%s", studentID))
+		h.cache.DeleteByPattern(ctx, fmt.Sprintf("juz:list:%s:*", studentID))
+		h.cache.DeleteByPattern(ctx, fmt.Sprintf("myitems:%s:*", studentID))
+		h.cache.DeleteByPattern(ctx, fmt.Sprintf("daily:%s:%s:*", studentID, now.Format("2006-01-02")))
+		h.cache.DeleteByPattern(ctx, fmt.Sprintf("class-daily:%s:*", studentID))
+		h.cache.DeleteByPattern(ctx, fmt.Sprintf("class-daily-book:%s:*", studentID))
+	}
+
+	return utils.Success(c, fiber.StatusCreated, "setoran recorded successfully", setoran, nil)
+}
+
+// GetSetoranSheet godoc
+// @Summary Daily setoran sheet
+// @Description The class teacher gets every student with the setoran heard on one day
+// @Tags Setoran
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Class ID"
+// @Param date query string false "Date (YYYY-MM-DD, defaults to today)"
+// @Success 200 {object} utils.SuccessResponse{data=services.SetoranSheet}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /classes/{id}/setoran/sheet [get]
+func (h *ClassHandler) GetSetoranSheet(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	date := time.Now().In(config.AppLocation)
+	if dateStr := c.Query("date"); dateStr != "" {
+		t, err := time.ParseInLocation("2006-01-02", dateStr, config.AppLocation)
+		if err != nil {
+			return utils.Error(c, fiber.StatusBadRequest, "date must be YYYY-MM-DD", "BAD_REQUEST", nil)
+		}
+		date = t
+	}
+
+	sheet, err := h.classSvc.GetSetoranSheet(c.Params("id"), userID, date)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "GET_SETORAN_SHEET_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "setoran sheet fetched successfully", sheet, nil)
+}
+
+// GetStudentSetoran godoc
+// @Summary Setoran log of a student
+// @Description The class teacher gets a student's setoran log, most recent first
+// @Tags Setoran
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Class ID"
+// @Param user_id path string true "Student ID"
+// @Param page query int false "Page (default 1)"
+// @Param per_page query int false "Per page (default 20)"
+// @Success 200 {object} utils.SuccessResponse{data=[]entities.Setoran}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /classes/{id}/students/{user_id}/setoran [get]
+func (h *ClassHandler) GetStudentSetoran(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	return h.studentSetoran(c, userID, c.Params("user_id"))
+}
+
+// GetMySetoran godoc
+// @Summary My setoran log
+// @Description A student gets their own setoran log in a class, most recent first
+// @Tags Setoran
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Class ID"
+// @Param page query int false "Page (default 1)"
+// @Param per_page query int false "Per page (default 20)"
+// @Success 200 {object} utils.SuccessResponse{data=[]entities.Setoran}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /classes/{id}/my-setoran [get]
+func (h *ClassHandler) GetMySetoran(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	return h.studentSetoran(c, userID, userID.String())
+}
+
+func (h *ClassHandler) studentSetoran(c *fiber.Ctx, userID uuid.UUID, studentID string) error {
+	page, perPage := pageParams(c)
+
+	list, total, err := h.classSvc.GetStudentSetoran(c.Params("id"), userID, studentID, page, perPage)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "GET_SETORAN_FAILED", nil)
+	}
+
+	meta := &utils.Meta{Page: page, PerPage: perPage, Total: int(total)}
+	return utils.Success(c, fiber.StatusOK, "setoran log fetched successfully", list, meta)
+}
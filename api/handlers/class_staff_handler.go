@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"hifzhun-api/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// InviteStaffRequest represents a class staff invitation
+type InviteStaffRequest struct {
+	Email string `json:"email" example:"musyrif@example.com"`
+	Role  string `json:"role" example:"assistant"` // co_teacher | assistant, default assistant
+}
+
+// UpdateStaffRequest represents a staff role change
+type UpdateStaffRequest struct {
+	Role string `json:"role" example:"co_teacher"`
+}
+
+// TransferOwnershipRequest represents a class ownership transfer
+type TransferOwnershipRequest struct {
+	UserID string `json:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Leave  bool   `json:"leave"` // leave the class instead of staying as co-teacher
+}
+
+// InviteStaff godoc
+// @Summary Invite class staff (Owner)
+// @Description Owner invites a teacher by email as co_teacher (approve graduations, manage books, view progress, manage members, record setoran) or assistant (view progress, record setoran). Inviting the same teacher again changes the role.
+// @Tags Class Staff
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Class ID"
+// @Param request body InviteStaffRequest true "Invitation"
+// @Success 201 {object} utils.SuccessResponse{data=entities.ClassStaff}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /classes/{id}/staff [post]
+func (h *ClassHandler) InviteStaff(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	var req InviteStaffRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, "invalid request body", "BAD_REQUEST", nil)
+	}
+
+	staff, err := h.classSvc.InviteStaff(c.Params("id"), userID, req.Email, req.Role)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "INVITE_STAFF_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusCreated, "staff invited successfully", staff, nil)
+}
+
+// GetClassStaff godoc
+// @Summary List class staff
+// @Description Owner, staff and open invitations of a class with their permissions, for the owner and staff
+// @Tags Class Staff
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Class ID"
+// @Success 200 {object} utils.SuccessResponse{data=[]services.ClassStaffInfo}
+// @Failure 403 {object} utils.ErrorResponse
+// @Router /classes/{id}/staff [get]
+func (h *ClassHandler) GetClassStaff(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	list, err := h.classSvc.GetClassStaff(c.Params("id"), userID)
+	if err != nil {
+		return utils.Error(c, fiber.StatusForbidden, err.Error(), "GET_CLASS_STAFF_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "class staff fetched successfully", list, nil)
+}
+
+// UpdateStaff godoc
+// @Summary Change a staff role (Owner)
+// @Tags Class Staff
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Class ID"
+// @Param user_id path string true "Staff user ID"
+// @Param request body UpdateStaffRequest true "Role"
+// @Success 200 {object} utils.SuccessResponse{data=entities.ClassStaff}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /classes/{id}/staff/{user_id} [put]
+func (h *ClassHandler) UpdateStaff(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	var req UpdateStaffRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, "invalid request body", "BAD_REQUEST", nil)
+	}
+
+	staff, err := h.classSvc.UpdateStaffRole(c.Params("id"), userID, c.Params("user_id"), req.Role)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "UPDATE_STAFF_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "staff updated successfully", staff, nil)
+}
+
+// RemoveStaff godoc
+// @Summary Remove class staff or revoke an invitation (Owner)
+// @Tags Class Staff
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Class ID"
+// @Param user_id path string true "Staff user ID"
+// @Success 200 {object} utils.SuccessResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /classes/{id}/staff/{user_id} [delete]
+func (h *ClassHandler) RemoveStaff(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	if err := h.classSvc.RemoveStaff(c.Params("id"), userID, c.Params("user_id")); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "REMOVE_STAFF_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "staff removed successfully", nil, nil)
+}
+
+// LeaveClassStaff godoc
+// @Summary Leave the class staff
+// @Description Staff leave the class. When the owner leaves, ownership passes to the longest-serving co-teacher; without one the request fails.
+// @Tags Class Staff
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Class ID"
+// @Success 200 {object} utils.SuccessResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /classes/{id}/staff/leave [post]
+func (h *ClassHandler) LeaveClassStaff(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	if err := h.classSvc.LeaveClassStaff(c.Params("id"), userID); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "LEAVE_CLASS_STAFF_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "left class staff successfully", nil, nil)
+}
+
+// TransferClassOwnership godoc
+// @Summary Transfer class ownership (Owner)
+// @Description Owner hands the class over to an active staff member. The previous owner stays as co_teacher unless leave is true.
+// @Tags Class Staff
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Class ID"
+// @Param request body TransferOwnershipRequest true "New owner"
+// @Success 200 {object} utils.SuccessResponse{data=entities.Class}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /classes/{id}/transfer-ownership [post]
+func (h *ClassHandler) TransferClassOwnership(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	var req TransferOwnershipRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, "invalid request body", "BAD_REQUEST", nil)
+	}
+
+	class, err := h.classSvc.TransferClassOwnership(c.Params("id"), userID, req.UserID, req.Leave)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "TRANSFER_OWNERSHIP_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "class ownership transferred successfully", class, nil)
+}
+
+// GetMyStaffInvitations godoc
+// @Summary My class staff invitations
+// @Description Open invitations to teach a class, sent to the current user
+// @Tags Class Staff
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.SuccessResponse{data=[]services.ClassStaffInvitation}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /classes/staff-invitations [get]
+func (h *ClassHandler) GetMyStaffInvitations(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	invitations, err := h.classSvc.GetMyStaffInvitations(userID)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "GET_INVITATIONS_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "invitations fetched successfully", invitations, nil)
+}
+
+// AcceptStaffInvitation godoc
+// @Summary Accept a class staff invitation
+// @Tags Class Staff
+// @Produce json
+// @Security BearerAuth
+// @Param invitation_id path string true "Invitation ID"
+// @Success 200 {object} utils.SuccessResponse{data=entities.ClassStaff}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /classes/staff-invitations/{invitation_id}/accept [post]
+func (h *ClassHandler) AcceptStaffInvitation(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	staff, err := h.classSvc.RespondToStaffInvitation(c.Params("invitation_id"), userID, true)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "ACCEPT_INVITATION_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "invitation accepted successfully", staff, nil)
+}
+
+// DeclineStaffInvitation godoc
+// @Summary Decline a class staff invitation
+// @Tags Class Staff
+// @Produce json
+// @Security BearerAuth
+// @Param invitation_id path string true "Invitation ID"
+// @Success 200 {object} utils.SuccessResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /classes/staff-invitations/{invitation_id}/decline [post]
+func (h *ClassHandler) DeclineStaffInvitation(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	if _, err := h.classSvc.RespondToStaffInvitation(c.Params("invitation_id"), userID, false); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "DECLINE_INVITATION_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "invitation declined successfully", nil, nil)
+}
@@ -23,6 +23,7 @@ type DailyTaskResponse struct {
 	Status                 string    `json:"status" example:"fsrs_active"`
 	TaskDate               string    `json:"task_date" example:"2026-02-06"` // YYYY-MM-DD
 	ContentRef             string    `json:"content_ref" example:"surah:78:1-5"`
+	ClozeIndex             int       `json:"cloze_index,omitempty" example:"1"` // cloze being reviewed for cloze book items
 	JuzIndex               int       `json:"juz_index" example:"30"`
 	EstimatedReviewSeconds int       `json:"estimated_review_seconds" example:"120"`
 	BookTitle              string    `json:"book_title,omitempty" example:"Belajar Tajwid"`
@@ -43,7 +44,7 @@ type DailyTaskGroup struct {
 // isQuranSource returns true for sources that belong to the quran category
 func isQuranSource(source string) bool {
 	switch source {
-	case "quran", "interval", "interval_review", "graduate":
+	case "quran", "interval", "interval_review", "graduate", "retest":
 		return true
 	}
 	return false
@@ -243,6 +244,7 @@ func (h *DailyTaskHandler) ListToday(c *fiber.Ctx) error {
 	itemMap := make(map[uuid.UUID]string)
 	itemEstimateMap := make(map[uuid.UUID]int)
 	itemStatusMap := make(map[uuid.UUID]string)
+	itemClozeMap := make(map[uuid.UUID]int)
 	bookIDs := make(map[string]struct{})
 	if len(itemIDs) > 0 {
 		items, err := h.itemRepo.FindByIDs(itemIDs)
@@ -251,6 +253,7 @@ func (h *DailyTaskHandler) ListToday(c *fiber.Ctx) error {
 				itemMap[item.ID] = item.ContentRef
 				itemEstimateMap[item.ID] = item.EstimatedReviewSeconds
 				itemStatusMap[item.ID] = item.Status
+				itemClozeMap[item.ID] = item.ClozeIndex
 				// Collect book IDs from content_ref "book:{book_id}:item:{book_item_id}"
 				if len(item.ContentRef) > 5 && item.ContentRef[:5] == "book:" {
 					parts := make([]string, 0, 4)
@@ -370,6 +373,7 @@ func (h *DailyTaskHandler) ListToday(c *fiber.Ctx) error {
 			Status:                 itemStatusMap[t.ItemID],
 			TaskDate:               t.TaskDate.Format("2006-01-02"),
 			ContentRef:             itemMap[t.ItemID],
+			ClozeIndex:             itemClozeMap[t.ItemID],
 			JuzIndex:               juzMap[t.ItemID.String()],
 			EstimatedReviewSeconds: itemEstimateMap[t.ItemID],
 			BookTitle:              bookTitleByItem[t.ItemID],
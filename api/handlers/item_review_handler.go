@@ -3,6 +3,7 @@ package handlers
 import (
 	"fmt"
 	"time"
+	"unicode/utf8"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -27,7 +28,13 @@ func NewItemReviewHandler(s *services.ItemReviewService, juzItemRepo *repositori
 
 // ReviewItemRequest represents item review request
 type ReviewItemRequest struct {
-	Rating int `json:"rating" example:"3" minimum:"1" maximum:"4"` // 1=Again, 2=Hard, 3=Good, 4=Easy
+	Rating      int    `json:"rating" example:"3" minimum:"0" maximum:"4"` // 1=Again, 2=Hard, 3=Good, 4=Easy; 0 = use suggested rating of typed_answer
+	TypedAnswer string `json:"typed_answer,omitempty" example:"الحمد لله"` // optional, book items only, at most 2000 characters
+}
+
+// CheckAnswerRequest represents typed answer check request
+type CheckAnswerRequest struct {
+	TypedAnswer string `json:"typed_answer" example:"الحمد لله"`
 }
 
 // ReviewItemResponse represents item review response
@@ -42,11 +49,13 @@ type ReviewItemResponse struct {
 	ReviewCount  int        `json:"review_count" example:"5"`
 	ContentRef   string     `json:"content_ref" example:"surah:78:1-5"`
 	JuzIndex     int        `json:"juz_index" example:"30"`
+
+	AnswerCheck *services.AnswerGrade `json:"answer_check,omitempty"`
 }
 
 // ReviewItem godoc
 // @Summary Review an item
-// @Description Submit a review rating for a hafalan item. Class book items can only be reviewed by students who joined a class containing the book.
+// @Description Submit a review rating for a hafalan item. Class book items can only be reviewed by students who joined a class containing the book. Book items accept an optional typed_answer that is graded and stored in the review log; with rating 0 the suggested rating is used.
 // @Tags Item Review
 // @Accept json
 // @Produce json
@@ -68,14 +77,18 @@ func (h *ItemReviewHandler) ReviewItem(c *fiber.Ctx) error {
 		return utils.Error(c, fiber.StatusBadRequest, "Invalid request body", "INVALID_REQUEST_BODY", nil)
 	}
 
-	if req.Rating < 1 || req.Rating > 4 {
+	if (req.Rating < 1 || req.Rating > 4) && !(req.Rating == 0 && req.TypedAnswer != "") {
 		return utils.Error(c, fiber.StatusBadRequest, "Rating must be between 1 and 4", "INVALID_RATING", nil)
 	}
+	if utf8.RuneCountInString(req.TypedAnswer) > services.MaxTypedAnswerLength {
+		return utils.Error(c, fiber.StatusBadRequest, fmt.Sprintf("typed_answer must be at most %d characters", services.MaxTypedAnswerLength), "TYPED_ANSWER_TOO_LONG", nil)
+	}
 
-	result, err := h.service.ReviewItem(
+	result, err := h.service.ReviewItemWithAnswer(
 		userID,
 		itemID,
 		fsrs.Rating(req.Rating),
+		req.TypedAnswer,
 		time.Now().In(config.AppLocation),
 	)
 	if err != nil {
@@ -101,6 +114,7 @@ func (h *ItemReviewHandler) ReviewItem(c *fiber.Ctx) error {
 		ReviewCount:  result.ReviewCount,
 		ContentRef:   result.Item.ContentRef,
 		JuzIndex:     juzIndex,
+		AnswerCheck:  result.AnswerGrade,
 	}
 
 	// Invalidate caches
@@ -117,3 +131,38 @@ func (h *ItemReviewHandler) ReviewItem(c *fiber.Ctx) error {
 
 	return utils.Success(c, fiber.StatusOK, message, resp, nil)
 }
+
+// CheckAnswer godoc
+// @Summary Check typed answer
+// @Description Compare a typed answer with the answer of a book item (harakat, hamza forms and punctuation are ignored) and return a word diff and a suggested rating. Does not review the item.
+// @Tags Item Review
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param item_id path string true "Item ID"
+// @Param request body CheckAnswerRequest true "Typed answer"
+// @Success 200 {object} utils.SuccessResponse{data=services.AnswerGrade}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /items/{item_id}/check-answer [post]
+func (h *ItemReviewHandler) CheckAnswer(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	itemID, err := uuid.Parse(c.Params("item_id"))
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, "Invalid item_id", "INVALID_PARAMETER", nil)
+	}
+
+	var req CheckAnswerRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, "Invalid request body", "INVALID_REQUEST_BODY", nil)
+	}
+	if utf8.RuneCountInString(req.TypedAnswer) > services.MaxTypedAnswerLength {
+		return utils.Error(c, fiber.StatusBadRequest, fmt.Sprintf("typed_answer must be at most %d characters", services.MaxTypedAnswerLength), "TYPED_ANSWER_TOO_LONG", nil)
+	}
+
+	grade, err := h.service.CheckTypedAnswer(userID, itemID, req.TypedAnswer)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "CHECK_ANSWER_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "Answer checked", grade, nil)
+}
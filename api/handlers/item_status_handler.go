@@ -8,6 +8,7 @@ import (
 	"github.com/google/uuid"
 
 	"hifzhun-api/pkg/cache"
+	"hifzhun-api/pkg/entities"
 	"hifzhun-api/pkg/repositories"
 	"hifzhun-api/pkg/services"
 	"hifzhun-api/pkg/utils"
@@ -409,7 +410,7 @@ func (h *ItemStatusHandler) GetDeadlines(c *fiber.Ctx) error {
 
 // DeactivateItem godoc
 // @Summary Deactivate a book item
-// @Description Move book item from fsrs_active to inactive status. Only for non-quran items. Accepts item_id from items table or book_items table.
+// @Description Move book item from fsrs_active to inactive status. Only for non-quran items. Accepts item_id from items table or book_items table; a book item id changes every Item made from it (one per cloze) and data is the list of changed Items.
 // @Tags Item Status
 // @Accept json
 // @Produce json
@@ -432,21 +433,13 @@ func (h *ItemStatusHandler) DeactivateItem(c *fiber.Ctx) error {
 	if err != nil {
 		// If not found in items table, try book_items table
 		if err.Error() == "item not found" {
-			// Try to find in book_items table
-			bookItem, bookErr := h.bookItemRepo.FindByID(itemIDStr)
-			if bookErr == nil && bookItem != nil {
-				// Find corresponding Item entity using content_ref
-				contentRef := "book:" + bookItem.BookID.String() + ":item:" + bookItem.ID.String()
-				items, findErr := h.itemRepo.FindByOwnerAndContentRef(userID, contentRef)
-				if findErr == nil && len(items) > 0 {
-					// Deactivate the Item entity
-					item, err = h.service.DeactivateItem(items[0].ID, userID)
-					if err != nil {
-						return utils.Error(c, fiber.StatusBadRequest, err.Error(), "DEACTIVATE_FAILED", nil)
-					}
-					h.invalidateItemCaches(c, userID)
-					return utils.Success(c, fiber.StatusOK, "Item deactivated successfully", item, nil)
+			items, found, bookErr := h.changeBookItemStatus(userID, itemIDStr, h.service.DeactivateItem)
+			if found {
+				if bookErr != nil {
+					return utils.Error(c, fiber.StatusBadRequest, bookErr.Error(), "DEACTIVATE_FAILED", nil)
 				}
+				h.invalidateItemCaches(c, userID)
+				return utils.Success(c, fiber.StatusOK, "Item deactivated successfully", items, nil)
 			}
 		}
 		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "DEACTIVATE_FAILED", nil)
@@ -459,7 +452,7 @@ func (h *ItemStatusHandler) DeactivateItem(c *fiber.Ctx) error {
 
 // ReactivateItem godoc
 // @Summary Reactivate a book item
-// @Description Move book item from inactive back to fsrs_active status. Only for non-quran items. Accepts item_id from items table or book_items table.
+// @Description Move book item from inactive back to fsrs_active status. Only for non-quran items. Accepts item_id from items table or book_items table; a book item id changes every Item made from it (one per cloze) and data is the list of changed Items.
 // @Tags Item Status
 // @Accept json
 // @Produce json
@@ -482,21 +475,13 @@ func (h *ItemStatusHandler) ReactivateItem(c *fiber.Ctx) error {
 	if err != nil {
 		// If not found in items table, try book_items table
 		if err.Error() == "item not found" {
-			// Try to find in book_items table
-			bookItem, bookErr := h.bookItemRepo.FindByID(itemIDStr)
-			if bookErr == nil && bookItem != nil {
-				// Find corresponding Item entity using content_ref
-				contentRef := "book:" + bookItem.BookID.String() + ":item:" + bookItem.ID.String()
-				items, findErr := h.itemRepo.FindByOwnerAndContentRef(userID, contentRef)
-				if findErr == nil && len(items) > 0 {
-					// Reactivate the Item entity
-					item, err = h.service.ReactivateItem(items[0].ID, userID)
-					if err != nil {
-						return utils.Error(c, fiber.StatusBadRequest, err.Error(), "REACTIVATE_FAILED", nil)
-					}
-					h.invalidateItemCaches(c, userID)
-					return utils.Success(c, fiber.StatusOK, "Item reactivated successfully", item, nil)
+			items, found, bookErr := h.changeBookItemStatus(userID, itemIDStr, h.service.ReactivateItem)
+			if found {
+				if bookErr != nil {
+					return utils.Error(c, fiber.StatusBadRequest, bookErr.Error(), "REACTIVATE_FAILED", nil)
 				}
+				h.invalidateItemCaches(c, userID)
+				return utils.Success(c, fiber.StatusOK, "Item reactivated successfully", items, nil)
 			}
 		}
 		return utils.Error(c, fiber.StatusBadRequest, err.Error(), "REACTIVATE_FAILED", nil)
@@ -506,3 +491,36 @@ func (h *ItemStatusHandler) ReactivateItem(c *fiber.Ctx) error {
 
 	return utils.Success(c, fiber.StatusOK, "Item reactivated successfully", item, nil)
 }
+
+// changeBookItemStatus applies change to every Item the user made from the
+// book item bookItemID; a cloze item has one per cloze. Items already in the
+// target status are skipped. found is false when the user has no Item of
+// that book item.
+func (h *ItemStatusHandler) changeBookItemStatus(userID uuid.UUID, bookItemID string, change func(itemID, userID uuid.UUID) (*entities.Item, error)) (changed []entities.Item, found bool, err error) {
+	bookItem, err := h.bookItemRepo.FindByID(bookItemID)
+	if err != nil || bookItem == nil {
+		return nil, false, nil
+	}
+	contentRef := "book:" + bookItem.BookID.String() + ":item:" + bookItem.ID.String()
+	items, err := h.itemRepo.FindByOwnerAndContentRef(userID, contentRef)
+	if err != nil || len(items) == 0 {
+		return nil, false, nil
+	}
+
+	var firstErr error
+	changed = []entities.Item{}
+	for _, it := range items {
+		item, err := change(it.ID, userID)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		changed = append(changed, *item)
+	}
+	if len(changed) == 0 {
+		return nil, true, firstErr
+	}
+	return changed, true, nil
+}
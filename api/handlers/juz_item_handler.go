@@ -102,6 +102,9 @@ func (h *JuzItemHandler) Update(c *fiber.Ctx) error {
 	}
 
 	if req.ContentRef != "" {
+		if err := h.service.ValidateItemContentRef(item.ID, req.ContentRef); err != nil {
+			return utils.Error(c, fiber.StatusBadRequest, err.Error(), "INVALID_CONTENT_REF", nil)
+		}
 		item.ContentRef = req.ContentRef
 	}
 	if req.EstimateValue > 0 {
@@ -147,3 +150,21 @@ func (h *JuzItemHandler) Delete(c *fiber.Ctx) error {
 	h.cache.DeleteByPattern(ctx, fmt.Sprintf("juz:list:%s:*", userID.String()))
 	return utils.Success(c, fiber.StatusOK, "Hafalan deleted successfully", nil, nil)
 }
+
+// GetMisfiledItems godoc
+// @Summary List misfiled Quran items (Admin)
+// @Description List Quran items whose surah ref does not belong to the juz they are filed under, including refs that cross juz boundaries
+// @Tags Juz Admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.SuccessResponse{data=[]services.MisfiledJuzItem}
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /admin/juz-items/misfiled [get]
+func (h *JuzItemHandler) GetMisfiledItems(c *fiber.Ctx) error {
+	items, err := h.service.FindMisfiledItems()
+	if err != nil {
+		return utils.Error(c, fiber.StatusInternalServerError, err.Error(), "GET_MISFILED_ITEMS_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "misfiled items fetched successfully", items, nil)
+}
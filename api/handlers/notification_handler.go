@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"hifzhun-api/pkg/services"
+	"hifzhun-api/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type NotificationHandler struct {
+	notificationSvc *services.NotificationService
+}
+
+func NewNotificationHandler(notificationSvc *services.NotificationService) *NotificationHandler {
+	return &NotificationHandler{notificationSvc: notificationSvc}
+}
+
+// UnreadCountResponse is the number of unread notifications
+type UnreadCountResponse struct {
+	Unread int64 `json:"unread" example:"3"`
+}
+
+// GetNotifications godoc
+// @Summary List my notifications
+// @Description Inbox of the current user, newest first: class announcements, graduation decisions, teacher request and book publish decisions, and assignment due reminders
+// @Tags Notifications
+// @Produce json
+// @Security BearerAuth
+// @Param unread query bool false "Only unread notifications"
+// @Param page query int false "Page (default 1)"
+// @Param per_page query int false "Per page (default 20)"
+// @Success 200 {object} utils.SuccessResponse{data=[]entities.Notification}
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /notifications [get]
+func (h *NotificationHandler) GetNotifications(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	page, perPage := pageParams(c)
+
+	list, total, err := h.notificationSvc.GetNotifications(userID, c.QueryBool("unread"), page, perPage)
+	if err != nil {
+		return utils.Error(c, fiber.StatusInternalServerError, err.Error(), "GET_NOTIFICATIONS_FAILED", nil)
+	}
+
+	meta := &utils.Meta{Page: page, PerPage: perPage, Total: int(total)}
+	return utils.Success(c, fiber.StatusOK, "notifications fetched successfully", list, meta)
+}
+
+// GetUnreadCount godoc
+// @Summary Count my unread notifications
+// @Tags Notifications
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.SuccessResponse{data=UnreadCountResponse}
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /notifications/unread-count [get]
+func (h *NotificationHandler) GetUnreadCount(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	count, err := h.notificationSvc.UnreadCount(userID)
+	if err != nil {
+		return utils.Error(c, fiber.StatusInternalServerError, err.Error(), "GET_UNREAD_COUNT_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "unread count fetched successfully", UnreadCountResponse{Unread: count}, nil)
+}
+
+// MarkNotificationRead godoc
+// @Summary Mark a notification as read
+// @Tags Notifications
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Notification ID"
+// @Success 200 {object} utils.SuccessResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /notifications/{id}/read [patch]
+func (h *NotificationHandler) MarkNotificationRead(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	if err := h.notificationSvc.MarkRead(userID, c.Params("id")); err != nil {
+		return utils.Error(c, fiber.StatusNotFound, err.Error(), "MARK_READ_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "notification marked as read", nil, nil)
+}
+
+// MarkAllNotificationsRead godoc
+// @Summary Mark all my notifications as read
+// @Tags Notifications
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.SuccessResponse{data=UnreadCountResponse}
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /notifications/read-all [patch]
+func (h *NotificationHandler) MarkAllNotificationsRead(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	if _, err := h.notificationSvc.MarkAllRead(userID); err != nil {
+		return utils.Error(c, fiber.StatusInternalServerError, err.Error(), "MARK_ALL_READ_FAILED", nil)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "all notifications marked as read", UnreadCountResponse{Unread: 0}, nil)
+}
@@ -12,6 +12,7 @@ func AdminRoutes(
 	userHandler *handlers.UserHandler,
 	teacherReqHandler *handlers.TeacherRequestHandler,
 	bookHandler *handlers.BookHandler,
+	juzItemHandler *handlers.JuzItemHandler,
 ) {
 	admin := router.Group(
 		"/admin",
@@ -30,14 +31,33 @@ func AdminRoutes(
 
 	// Book approval endpoints
 	admin.Get("/books/pending", bookHandler.GetPendingBooks)
+	admin.Get("/books/retired", bookHandler.GetRetiredBooks)
 	admin.Get("/books/:id", bookHandler.GetBookDetailForAdmin)
 	admin.Post("/books/:id/approve", bookHandler.ApproveBook)
 	admin.Post("/books/:id/reject", bookHandler.RejectBook)
-	admin.Delete("/books/:id", bookHandler.DeletePublishedBook)
+	admin.Get("/books/:id/moderation", bookHandler.GetModerationTimeline)
+	admin.Delete("/books/:id", bookHandler.RetireBook)
+	admin.Post("/books/:id/restore", bookHandler.RestoreRetiredBook)
+	admin.Post("/books/:id/purge", bookHandler.PurgeBook)
+	admin.Get("/book-purges", bookHandler.GetPurgeLogs)
 
 	// Book update request endpoints
 	admin.Get("/book-updates/pending", bookHandler.GetPendingBookUpdates)
 	admin.Post("/book-updates/:id/approve", bookHandler.ApproveBookUpdate)
 	admin.Post("/book-updates/:id/reject", bookHandler.RejectBookUpdate)
+
+	// Book content revision endpoints
+	admin.Get("/book-revisions/pending", bookHandler.GetPendingRevisions)
+	admin.Get("/book-revisions/:id", bookHandler.GetRevisionDetail)
+	admin.Post("/book-revisions/:id/approve", bookHandler.ApproveRevision)
+	admin.Post("/book-revisions/:id/reject", bookHandler.RejectRevision)
+
+	// Book review moderation
+	admin.Get("/book-reviews", bookHandler.GetReviewsForModeration)
+	admin.Post("/book-reviews/:id/hide", bookHandler.HideReview)
+	admin.Post("/book-reviews/:id/unhide", bookHandler.UnhideReview)
+
+	// Quran item integrity report
+	admin.Get("/juz-items/misfiled", juzItemHandler.GetMisfiledItems)
 }
 
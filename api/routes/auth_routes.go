@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"hifzhun-api/api/handlers"
+	"hifzhun-api/pkg/middlewares"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/limiter"
@@ -46,4 +47,5 @@ func AuthRoutes(router fiber.Router, authHandler *handlers.AuthHandler) {
 
 	auth.Post("/register", registerLimiter, authHandler.Register)
 	auth.Post("/login", loginLimiter, authHandler.Login)
+	auth.Post("/change-password", middlewares.JWTAuthAllowingPasswordChange(), authHandler.ChangePassword)
 }
@@ -21,28 +21,69 @@ func RegisterBookRoutes(
 
 	// Book CRUD - specific paths first
 	books.Post("/", bookHandler.CreateBook)
+	books.Post("/import", bookHandler.ImportBook)
+	books.Post("/import/anki", bookHandler.ImportAnkiPackage)
 	books.Get("/", bookHandler.GetMyBooks)
 	books.Get("/published", bookHandler.GetPublishedBooks)
 	books.Get("/published/:id", bookHandler.GetPublishedBookDetail)
 	books.Post("/published/:id/add-to-my-books", bookHandler.AddPublishedBookToMyBook)
 	books.Post("/published/:id/copy-to-draft", bookHandler.CopyPublishedBookToDraft)
 
+	// Ratings & reviews
+	books.Get("/published/:id/reviews", bookHandler.GetBookReviews)
+	books.Put("/published/:id/reviews/mine", bookHandler.SaveMyReview)
+	books.Delete("/published/:id/reviews/mine", bookHandler.DeleteMyReview)
+
 	// My Book Collection
 	books.Get("/my-collection", bookHandler.GetMyBookCollection)
 	books.Delete("/my-collection/:id", bookHandler.RemoveFromMyBookCollection)
 
+	// Co-authors: books shared with me and my invitations
+	books.Get("/shared", bookHandler.GetSharedBooks)
+	books.Get("/invitations", bookHandler.GetMyBookInvitations)
+	books.Post("/invitations/:id/accept", bookHandler.AcceptBookInvitation)
+	books.Post("/invitations/:id/decline", bookHandler.DeclineBookInvitation)
+
+	// Stale overrides (canonical item changed after the override was made)
+	books.Get("/my-overrides/stale", bookHandler.GetStaleOverrides)
+
 	books.Get("/:id/tree", bookHandler.GetBookTree)
+	books.Put("/:id/order", bookHandler.ReorderBook)
+	books.Get("/:id/export", bookHandler.ExportBook)
+	books.Put("/:id/catalog", bookHandler.UpdateBookCatalogInfo)
+	books.Get("/:id/moderation", bookHandler.GetModerationTimeline)
 	books.Post("/:id/request-update", bookHandler.RequestBookUpdate)
 	books.Get("/:id/update-requests", bookHandler.GetBookUpdateRequests)
 
+	// Content revisions (module/item changes of published books)
+	books.Get("/:id/revisions", bookHandler.GetBookRevisions)
+	books.Get("/:id/revisions/draft", bookHandler.GetDraftRevision)
+	books.Delete("/:id/revisions/draft", bookHandler.DiscardDraftRevision)
+	books.Post("/:id/revisions/submit", bookHandler.SubmitRevision)
+	books.Get("/:id/changelog", bookHandler.GetBookChangelog)
+	books.Post("/:id/changelog/seen", bookHandler.MarkChangelogSeen)
+
+	// Forks (copy-to-draft): upstream changes of the source book
+	books.Get("/:id/upstream", bookHandler.GetUpstreamStatus)
+	books.Post("/:id/upstream/pull", bookHandler.PullUpstreamChanges)
+
+	// Collaborators and activity log
+	books.Get("/:id/collaborators", bookHandler.GetCollaborators)
+	books.Post("/:id/collaborators", bookHandler.InviteCollaborator)
+	books.Put("/:id/collaborators/:collaborator_id", bookHandler.UpdateCollaborator)
+	books.Delete("/:id/collaborators/:collaborator_id", bookHandler.RemoveCollaborator)
+	books.Get("/:id/activity", bookHandler.GetBookActivity)
+
 	// Module static paths (before dynamic /:id)
 	books.Put("/modules/:id", bookHandler.UpdateModule)
 	books.Delete("/modules/:id", bookHandler.DeleteModule)
+	books.Post("/modules/:id/move", bookHandler.MoveModule)
 	books.Post("/modules/:module_id/items", bookHandler.AddItemToModule)
 
 	// Item static paths (before dynamic /:id)
 	books.Put("/items/:item_id", bookHandler.UpdateItem)
 	books.Delete("/items/:item_id", bookHandler.DeleteItem)
+	books.Post("/items/:item_id/move", bookHandler.MoveItem)
 
 	// Dynamic book routes
 	books.Get("/:id", bookHandler.GetBookDetail)
@@ -54,8 +95,11 @@ func RegisterBookRoutes(
 
 	// Memorization - start memorizing a specific book item
 	books.Post("/:id/items/:item_id/start", bookHandler.StartMemorization)
+	books.Post("/:id/start", bookHandler.StartBulkMemorization)
 
 	// Book Item Overrides
 	books.Get("/items/:item_id/my-override", bookHandler.GetMyOverride)
 	books.Delete("/items/:item_id/my-override", bookHandler.RemoveMyOverride)
+	books.Get("/items/:item_id/my-override/diff", bookHandler.GetOverrideDiff)
+	books.Post("/items/:item_id/my-override/resolve", bookHandler.ResolveOverride)
 }
@@ -22,9 +22,23 @@ func RegisterClassRoutes(
 	classes.Get("/joined", classHandler.GetMyJoinedClasses)
 	classes.Delete("/:id/leave", classHandler.LeaveClass)
 
+	// ==================== STAFF AND STUDENT INVITATIONS ====================
+	// Invitations are addressed to a user, so any authenticated user can answer theirs
+	classes.Get("/staff-invitations", classHandler.GetMyStaffInvitations)
+	classes.Post("/staff-invitations/:invitation_id/accept", classHandler.AcceptStaffInvitation)
+	classes.Post("/staff-invitations/:invitation_id/decline", classHandler.DeclineStaffInvitation)
+	classes.Get("/invitations", classHandler.GetMyClassInvitations)
+	classes.Post("/invitations/:invitation_id/accept", classHandler.AcceptClassInvitation)
+	classes.Post("/invitations/:invitation_id/decline", classHandler.DeclineClassInvitation)
+
 	// ==================== SHARED ENDPOINTS ====================
 	// These can be accessed by members of the class (student/teacher)
 	classes.Get("/:id/books", classHandler.GetClassBooks)
+	classes.Get("/:id/my-assignments", classHandler.GetMyAssignments)
+	classes.Get("/:id/my-setoran", classHandler.GetMySetoran)
+	classes.Get("/:id/my-graduations", classHandler.GetMyGraduations)
+	classes.Get("/:id/graduation-policy", classHandler.GetGraduationPolicy)
+	classes.Get("/:id/announcements", classHandler.GetAnnouncements)
 	classes.Get("/:id", classHandler.GetClassDetail)
 
 	// ==================== TEACHER/ADMIN ENDPOINTS ====================
@@ -37,6 +51,14 @@ func RegisterClassRoutes(
 	teacher.Put("/:id", classHandler.UpdateClass)
 	teacher.Delete("/:id", classHandler.DeleteClass)
 
+	// Class staff (owner manages; staff can list and leave)
+	teacher.Get("/:id/staff", classHandler.GetClassStaff)
+	teacher.Post("/:id/staff", classHandler.InviteStaff)
+	teacher.Post("/:id/staff/leave", classHandler.LeaveClassStaff)
+	teacher.Put("/:id/staff/:user_id", classHandler.UpdateStaff)
+	teacher.Delete("/:id/staff/:user_id", classHandler.RemoveStaff)
+	teacher.Post("/:id/transfer-ownership", classHandler.TransferClassOwnership)
+
 	// Class books management (Teacher only)
 	teacher.Post("/:id/books/create", classHandler.CreateBookInClass)
 	teacher.Post("/:id/books", classHandler.AddBookToClass)
@@ -44,11 +66,61 @@ func RegisterClassRoutes(
 
 	// Class members & progress (Teacher only)
 	teacher.Get("/:id/members", classHandler.GetClassMembers)
+	teacher.Post("/:id/invitations", classHandler.InviteStudent)
+	teacher.Get("/:id/invitations", classHandler.GetClassInvitations)
+	teacher.Delete("/:id/invitations/:invitation_id", classHandler.RevokeClassInvitation)
+	teacher.Delete("/:id/members/:user_id", classHandler.RemoveMember)
+	teacher.Post("/:id/members/:user_id/ban", classHandler.BanMember)
+	teacher.Get("/:id/bans", classHandler.GetClassBans)
+	teacher.Delete("/:id/bans/:user_id", classHandler.UnbanMember)
+	teacher.Post("/:id/roster/import", classHandler.ImportRoster)
+
+	// Join controls (Teacher only)
+	teacher.Put("/:id/join-settings", classHandler.UpdateJoinSettings)
+	teacher.Post("/:id/code/regenerate", classHandler.RegenerateClassCode)
+	teacher.Get("/:id/join-requests", classHandler.GetJoinRequests)
+	teacher.Post("/:id/join-requests/:user_id/approve", classHandler.ApproveJoinRequest)
+	teacher.Post("/:id/join-requests/:user_id/reject", classHandler.RejectJoinRequest)
+
 	teacher.Get("/:id/progress", classHandler.GetStudentProgress)
 	teacher.Get("/:id/books/:book_id/progress", classHandler.GetClassBookStudentProgress)
+	teacher.Get("/:id/progress/export", classHandler.ExportStudentProgress)
+	teacher.Get("/:id/books/:book_id/progress/export", classHandler.ExportClassBookProgress)
+
+	// Groups / halaqah (Teacher only)
+	teacher.Get("/:id/groups", classHandler.GetClassGroups)
+	teacher.Post("/:id/groups", classHandler.CreateClassGroup)
+	teacher.Get("/:id/groups/:group_id", classHandler.GetClassGroup)
+	teacher.Put("/:id/groups/:group_id", classHandler.UpdateClassGroup)
+	teacher.Delete("/:id/groups/:group_id", classHandler.DeleteClassGroup)
+	teacher.Post("/:id/groups/:group_id/members", classHandler.AddGroupMembers)
+	teacher.Delete("/:id/groups/:group_id/members/:user_id", classHandler.RemoveGroupMember)
+
+	// Announcements (Teacher only)
+	teacher.Post("/:id/announcements", classHandler.CreateAnnouncement)
+	teacher.Put("/:id/announcements/:announcement_id", classHandler.UpdateAnnouncement)
+	teacher.Delete("/:id/announcements/:announcement_id", classHandler.DeleteAnnouncement)
+
+	// Assignments (Teacher only)
+	teacher.Post("/:id/assignments", classHandler.CreateAssignment)
+	teacher.Get("/:id/assignments", classHandler.GetClassAssignments)
+	teacher.Put("/:id/assignments/:assignment_id", classHandler.UpdateAssignment)
+	teacher.Delete("/:id/assignments/:assignment_id", classHandler.DeleteAssignment)
+	teacher.Get("/:id/assignments/:assignment_id/progress", classHandler.GetAssignmentProgress)
+	teacher.Put("/:id/assignments/:assignment_id/overrides/:user_id", classHandler.SetAssignmentOverride)
+	teacher.Delete("/:id/assignments/:assignment_id/overrides/:user_id", classHandler.RemoveAssignmentOverride)
+
+	// Setoran (Teacher only)
+	teacher.Post("/:id/setoran", classHandler.RecordSetoran)
+	teacher.Get("/:id/setoran/sheet", classHandler.GetSetoranSheet)
+	teacher.Get("/:id/students/:user_id/setoran", classHandler.GetStudentSetoran)
 
 	// Graduation approval (Teacher only - Quran classes)
 	teacher.Get("/:id/graduations/pending", classHandler.GetPendingGraduations)
+	teacher.Get("/:id/graduations/history", classHandler.GetGraduationHistory)
+	teacher.Post("/:id/graduations/batch", classHandler.DecideGraduations)
+	teacher.Put("/:id/graduation-policy", classHandler.UpdateGraduationPolicy)
+	teacher.Delete("/:id/graduation-policy", classHandler.ResetGraduationPolicy)
 	teacher.Post("/:id/graduations/:item_id/approve", classHandler.ApproveGraduation)
 	teacher.Post("/:id/graduations/:item_id/reject", classHandler.RejectGraduation)
 }
@@ -22,13 +22,14 @@ func SetupRoutes(
 	classHandler *handlers.ClassHandler,
 	myItemHandler *handlers.MyItemHandler,
 	classDailyHandler *handlers.ClassDailyHandler,
+	notificationHandler *handlers.NotificationHandler,
 ) {
 	api := app.Group("/api")
 	v1 := api.Group("/v1")
 
 	AuthRoutes(v1, authHandler)
 	UserRoutes(v1, teacherReqHandler)
-	AdminRoutes(v1, userHandler, teacherReqHandler, bookHandler)
+	AdminRoutes(v1, userHandler, teacherReqHandler, bookHandler, juzItemHandler)
 	RegisterLoadControlRoutes(v1, loadControlHandler)
 	RegisterDailyTaskRoutes(v1, dailyTaskHandler)
 	RegisterGraduationPreEngineRoutes(v1, graduationPreEngineHandler)
@@ -38,6 +39,7 @@ func SetupRoutes(
 	RegisterClassRoutes(v1, classHandler)
 	RegisterMyItemRoutes(v1, myItemHandler)
 	RegisterClassDailyRoutes(v1, classDailyHandler)
+	RegisterNotificationRoutes(v1, notificationHandler)
 	v1.Get("/health", handlers.Health)
 }
 
@@ -41,6 +41,9 @@ func RegisterItemStatusRoutes(
 	// Review item (FSRS) - auto graduate at 30 days for quran items
 	items.Post("/:item_id/review", reviewHandler.ReviewItem)
 
+	// Grade a typed answer for a book item without reviewing it
+	items.Post("/:item_id/check-answer", reviewHandler.CheckAnswer)
+
 	// Deactivate/Reactivate book items (non-quran only)
 	items.Post("/:item_id/deactivate", handler.DeactivateItem)
 	items.Post("/:item_id/reactivate", handler.ReactivateItem)
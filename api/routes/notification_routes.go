@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"hifzhun-api/api/handlers"
+	"hifzhun-api/pkg/middlewares"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func RegisterNotificationRoutes(
+	router fiber.Router,
+	notificationHandler *handlers.NotificationHandler,
+) {
+	notifications := router.Group(
+		"/notifications",
+		middlewares.JWTAuth(),
+	)
+
+	notifications.Get("/", notificationHandler.GetNotifications)
+	notifications.Get("/unread-count", notificationHandler.GetUnreadCount)
+	notifications.Patch("/read-all", notificationHandler.MarkAllNotificationsRead)
+	notifications.Patch("/:id/read", notificationHandler.MarkNotificationRead)
+}
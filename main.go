@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"time"
 
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	fiberSwagger "github.com/swaggo/fiber-swagger"
@@ -11,8 +12,10 @@ import (
 	"hifzhun-api/api/handlers"
 	"hifzhun-api/api/routes"
 	_ "hifzhun-api/docs" // swagger docs
+	"hifzhun-api/pkg/anki"
 	"hifzhun-api/pkg/cache"
 	"hifzhun-api/pkg/config"
+	"hifzhun-api/pkg/middlewares"
 	"hifzhun-api/pkg/repositories"
 	"hifzhun-api/pkg/services"
 	"hifzhun-api/pkg/usecases"
@@ -51,10 +54,14 @@ func main() {
 
 	app := fiber.New(fiber.Config{
 		BodyLimit: utils.MaxImageSize + 1024*1024,
+		// Bodies are read by middlewares.BodyLimit, which lets a few upload
+		// routes go past BodyLimit
+		StreamRequestBody:            true,
+		DisablePreParseMultipartForm: true,
 		ErrorHandler: func(c *fiber.Ctx, err error) error {
 			if fiberErr, ok := err.(*fiber.Error); ok {
 				if fiberErr.Code == fiber.StatusRequestEntityTooLarge {
-					return utils.Error(c, fiber.StatusBadRequest, "image size must be 3MB or less", "BAD_REQUEST", nil)
+					return utils.Error(c, fiber.StatusBadRequest, "request body is too large", "BAD_REQUEST", nil)
 				}
 				return utils.Error(c, fiberErr.Code, fiberErr.Message, "ERROR", nil)
 			}
@@ -63,6 +70,12 @@ func main() {
 		},
 	})
 
+	app.Use(middlewares.BodyLimit(utils.MaxImageSize+1024*1024, map[string]int{
+		"/api/v1/books/import/anki":                          anki.MaxPackageSize + 1024*1024,
+		"/api/v1/classes/:id/announcements":                  utils.MaxAttachmentSize + 1024*1024,
+		"/api/v1/classes/:id/announcements/:announcement_id": utils.MaxAttachmentSize + 1024*1024,
+	}))
+
 	app.Use(cors.New(cors.Config{
 		AllowOrigins:     "http://localhost:5173,http://localhost:5174,https://unlupa.id,https://www.unlupa.id,https://api.unlupa.id",
 		AllowMethods:     "GET,POST,PUT,PATCH,DELETE,OPTIONS",
@@ -86,6 +99,10 @@ func main() {
 	juzRepo := repositories.NewJuzRepository(config.DB)
 	bookRepo := repositories.NewBookRepository(config.DB)
 
+	// ================= NOTIFICATION =================
+	notificationSvc := services.NewNotificationService(repositories.NewNotificationRepository(config.DB))
+	notificationHandler := handlers.NewNotificationHandler(notificationSvc)
+
 	// ================= AUTH =================
 	authSvc := services.NewAuthService()
 	authUC := usecases.NewAuthUsecase(userRepo, authSvc)
@@ -96,7 +113,7 @@ func main() {
 	userHandler := handlers.NewUserHandler(userSvc)
 
 	// ================= TEACHER REQUEST =================
-	teacherReqSvc := services.NewTeacherRequestService(teacherReqRepo, userRepo)
+	teacherReqSvc := services.NewTeacherRequestService(teacherReqRepo, userRepo, notificationSvc)
 	teacherReqHandler := handlers.NewTeacherRequestHandler(teacherReqSvc)
 
 	// ================= LOAD CONTROL =================
@@ -109,6 +126,7 @@ func main() {
 	juzItemRepo := repositories.NewJuzItemRepository(config.DB)
 	dailyTaskRepo := repositories.NewDailyTaskRepository(config.DB)
 	classBookRepoForDaily := repositories.NewClassBookRepository(config.DB)
+	graduationPolicyRepo := repositories.NewClassGraduationPolicyRepository(config.DB)
 	dailyTaskSvc := services.NewDailyTaskService(
 		reviewStateRepo,
 		dailyTaskRepo,
@@ -117,6 +135,7 @@ func main() {
 		classRepo,
 		juzRepo,
 		juzItemRepo,
+		graduationPolicyRepo,
 	)
 	dailyTaskHandler := handlers.NewDailyTaskHandler(dailyTaskSvc, itemRepoForDaily, juzItemRepo, bookRepo, repositories.NewBookItemRepository(config.DB), classBookRepoForDaily, appCache)
 
@@ -143,22 +162,73 @@ func main() {
 	bookUpdateRequestRepo := repositories.NewBookUpdateRequestRepository(config.DB)
 	classBookRepo := repositories.NewClassBookRepository(config.DB)
 	bookItemOverrideRepo := repositories.NewBookItemOverrideRepository(config.DB)
-	bookSvc := services.NewBookService(bookRepo, bookModuleRepo, bookItemRepo, classBookRepo, itemRepo, userRepo, bookUpdateRequestRepo, bookItemOverrideRepo)
+	bookRevisionRepo := repositories.NewBookRevisionRepository(config.DB)
+	bookReviewRepo := repositories.NewBookReviewRepository(config.DB)
+	bookPublishRequestRepo := repositories.NewBookPublishRequestRepository(config.DB)
+	bookPurgeLogRepo := repositories.NewBookPurgeLogRepository(config.DB)
+	bookCollaboratorRepo := repositories.NewBookCollaboratorRepository(config.DB)
+	bookActivityRepo := repositories.NewBookActivityRepository(config.DB)
+	bookSvc := services.NewBookService(bookRepo, bookModuleRepo, bookItemRepo, classBookRepo, itemRepo, userRepo, bookUpdateRequestRepo, bookItemOverrideRepo, bookRevisionRepo, bookReviewRepo, bookPublishRequestRepo, bookPurgeLogRepo, bookCollaboratorRepo, bookActivityRepo, notificationSvc)
 	bookHandler := handlers.NewBookHandler(bookSvc, userRepo, appCache)
+	if n, err := bookSvc.BackfillSearchText(); err != nil {
+		log.Println("⚠️ Failed to backfill book search text:", err)
+	} else if n > 0 {
+		log.Printf("✅ Indexed %d published books for catalog search", n)
+	}
 
 	// ================= ITEM STATUS =================
 	intervalReviewLogRepo := repositories.NewIntervalReviewLogRepository(config.DB)
 	itemStatusSvc := services.NewItemStatusService(itemRepo, intervalReviewLogRepo, classBookRepo, dailyTaskActionRepo)
 	itemStatusHandler := handlers.NewItemStatusHandler(itemStatusSvc, juzItemRepo, bookRepo, bookItemRepo, itemRepo, bookItemOverrideRepo, appCache)
 
-	// ================= CLASS =================
-	classSvc := services.NewClassService(classRepo, classMemberRepo, classBookRepo, bookRepo, userRepo, itemRepo, juzRepo, juzItemRepo, dailyTaskRepo, dailyTaskSvc)
-	classHandler := handlers.NewClassHandler(classSvc)
-
 	// ================= ITEM REVIEW =================
-	itemReviewSvc := services.NewItemReviewService(itemRepo, fsrsWeightsRepo, dailyTaskActionRepo, classMemberRepo, classRepo, classBookRepo, juzItemRepo)
+	reviewLogRepo := repositories.NewReviewLogRepository(config.DB)
+	itemReviewSvc := services.NewItemReviewService(itemRepo, fsrsWeightsRepo, dailyTaskActionRepo, classMemberRepo, classRepo, classBookRepo, juzItemRepo, bookItemRepo, bookItemOverrideRepo, reviewLogRepo, graduationPolicyRepo)
 	itemReviewHandler := handlers.NewItemReviewHandler(itemReviewSvc, juzItemRepo, appCache)
 
+	// ================= CLASS =================
+	classAssignmentRepo := repositories.NewClassAssignmentRepository(config.DB)
+	setoranRepo := repositories.NewSetoranRepository(config.DB)
+	classStaffRepo := repositories.NewClassStaffRepository(config.DB)
+	classJoinRequestRepo := repositories.NewClassJoinRequestRepository(config.DB)
+	classInvitationRepo := repositories.NewClassInvitationRepository(config.DB)
+	classBanRepo := repositories.NewClassBanRepository(config.DB)
+	classGroupRepo := repositories.NewClassGroupRepository(config.DB)
+	classAnnouncementRepo := repositories.NewClassAnnouncementRepository(config.DB)
+	classSvc := services.NewClassService(services.ClassServiceDeps{
+		ClassRepo:        classRepo,
+		ClassMemberRepo:  classMemberRepo,
+		ClassBookRepo:    classBookRepo,
+		BookRepo:         bookRepo,
+		UserRepo:         userRepo,
+		ItemRepo:         itemRepo,
+		JuzRepo:          juzRepo,
+		JuzItemRepo:      juzItemRepo,
+		DailyTaskRepo:    dailyTaskRepo,
+		DailyTaskSvc:     dailyTaskSvc,
+		AssignmentRepo:   classAssignmentRepo,
+		BookModuleRepo:   bookModuleRepo,
+		BookItemRepo:     bookItemRepo,
+		QuranValidator:   quranValidator,
+		BookSvc:          bookSvc,
+		SetoranRepo:      setoranRepo,
+		ReviewSvc:        itemReviewSvc,
+		GraduationRepo:   graduationPreEngineRepo,
+		PolicyRepo:       graduationPolicyRepo,
+		StaffRepo:        classStaffRepo,
+		JoinRequestRepo:  classJoinRequestRepo,
+		InvitationRepo:   classInvitationRepo,
+		BanRepo:          classBanRepo,
+		AuthSvc:          authSvc,
+		GroupRepo:        classGroupRepo,
+		AnnouncementRepo: classAnnouncementRepo,
+		Notifier:         notificationSvc,
+		ReviewLogRepo:    reviewLogRepo,
+		IntervalLogRepo:  intervalReviewLogRepo,
+	})
+	classHandler := handlers.NewClassHandler(classSvc, appCache)
+	go runNotificationScheduler(classSvc)
+
 	// ================= MY ITEMS =================
 	myItemSvc := services.NewMyItemService(itemRepo, juzItemRepo, bookRepo, bookItemRepo, bookItemOverrideRepo)
 	myItemHandler := handlers.NewMyItemHandler(myItemSvc, appCache)
@@ -176,6 +246,7 @@ func main() {
 		bookRepo,
 		bookItemRepo,
 		appCache,
+		classSvc,
 	)
 
 	// ================= ROUTES =================
@@ -195,6 +266,7 @@ func main() {
 		classHandler,
 		myItemHandler,
 		classDailyHandler,
+		notificationHandler,
 	)
 
 	port := os.Getenv("APP_PORT")
@@ -205,3 +277,19 @@ func main() {
 	log.Printf("🚀 Server running on port %s...\n", port)
 	log.Fatal(app.Listen(fmt.Sprintf(":%s", port)))
 }
+
+// runNotificationScheduler delivers scheduled class announcements and
+// reminds students of assignments due within a day, once a minute
+func runNotificationScheduler(classSvc services.ClassService) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		now = now.In(config.AppLocation)
+		if _, err := classSvc.DeliverScheduledAnnouncements(now); err != nil {
+			log.Println("⚠️ Failed to deliver scheduled announcements:", err)
+		}
+		if _, err := classSvc.SendAssignmentDueReminders(now, 24*time.Hour); err != nil {
+			log.Println("⚠️ Failed to send assignment due reminders:", err)
+		}
+	}
+}
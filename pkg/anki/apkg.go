@@ -0,0 +1,385 @@
+// Package anki reads Anki deck packages (.apkg).
+//
+// An .apkg file is a zip archive containing a SQLite collection
+// (collection.anki21 or collection.anki2), a "media" JSON file mapping
+// numbered entries to their original file names, and the media files
+// themselves stored under those numbers.
+package anki
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// FieldSeparator separates note fields in notes.flds
+const FieldSeparator = "\x1f"
+
+// DeckSeparator separates parent and child deck names ("Fiqh::Thaharah")
+const DeckSeparator = "::"
+
+// MaxPackageSize is the largest .apkg file accepted for import
+const MaxPackageSize = 20 << 20
+
+// MaxMediaSize is the largest single media file read from a package
+const MaxMediaSize = 10 << 20
+
+// MaxCollectionSize is the largest uncompressed collection database
+// extracted from a package
+const MaxCollectionSize = 200 << 20
+
+// Card types in cards.type
+const (
+	CardTypeNew        = 0
+	CardTypeLearning   = 1
+	CardTypeReview     = 2
+	CardTypeRelearning = 3
+)
+
+// QueueSuspended is the cards.queue value of a suspended card
+const QueueSuspended = -1
+
+// Review log types in revlog.type. Manual entries (rescheduling, resets)
+// carry no answer and should be ignored when replaying history.
+const (
+	ReviewTypeLearn    = 0
+	ReviewTypeReview   = 1
+	ReviewTypeRelearn  = 2
+	ReviewTypeFiltered = 3
+	ReviewTypeManual   = 4
+)
+
+type Deck struct {
+	ID   int64
+	Name string // full name, e.g. "Arabic::Nouns"
+}
+
+// Path returns the deck name split into its hierarchy
+func (d Deck) Path() []string {
+	parts := strings.Split(d.Name, DeckSeparator)
+	result := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+type NoteType struct {
+	ID     int64
+	Name   string
+	Fields []string // field names ordered by ord
+	Cloze  bool
+}
+
+type Note struct {
+	ID       int64
+	ModelID  int64
+	Fields   []string
+	Tags     []string
+	Modified time.Time
+}
+
+type Card struct {
+	ID       int64
+	NoteID   int64
+	DeckID   int64
+	Ord      int
+	Type     int
+	Queue    int
+	Interval int // days when positive, seconds when negative
+	Factor   int // ease factor in permille (2500 = 250%)
+	Reps     int
+	Lapses   int
+}
+
+type ReviewEntry struct {
+	ID       int64 // epoch milliseconds of the review
+	CardID   int64
+	Ease     int // 1-4, 0 for manual entries
+	Interval int
+	Type     int
+}
+
+// ReviewedAt returns the time of the review
+func (r ReviewEntry) ReviewedAt() time.Time {
+	return time.UnixMilli(r.ID)
+}
+
+// Package is a parsed .apkg file
+type Package struct {
+	Decks     map[int64]Deck
+	NoteTypes map[int64]NoteType
+	Notes     []Note
+	Cards     []Card
+	Reviews   []ReviewEntry // ordered by time
+
+	media     map[string]*zip.File // original file name -> zip entry
+	mediaName map[string]string    // lower-case name -> original name
+}
+
+type colRow struct {
+	Models string
+	Decks  string
+}
+
+type noteRow struct {
+	ID   int64
+	Mid  int64
+	Mod  int64
+	Tags string
+	Flds string
+}
+
+type cardRow struct {
+	ID     int64
+	Nid    int64
+	Did    int64
+	Ord    int
+	Type   int
+	Queue  int
+	Ivl    int
+	Factor int
+	Reps   int
+	Lapses int
+}
+
+type revlogRow struct {
+	ID   int64
+	Cid  int64
+	Ease int
+	Ivl  int
+	Type int
+}
+
+// Open reads an .apkg package from r. The collection is extracted to a
+// temporary file because SQLite cannot be opened from memory directly.
+func Open(r io.ReaderAt, size int64) (*Package, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, errors.New("file is not a valid .apkg package")
+	}
+
+	entries := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		entries[f.Name] = f
+	}
+
+	collection := entries["collection.anki21"]
+	if collection == nil {
+		collection = entries["collection.anki2"]
+	}
+	if collection == nil {
+		if entries["collection.anki21b"] != nil {
+			return nil, errors.New("this package uses the newest Anki format, re-export it with 'Support older Anki versions' enabled")
+		}
+		return nil, errors.New("package does not contain an Anki collection")
+	}
+
+	if collection.UncompressedSize64 > MaxCollectionSize {
+		return nil, errors.New("Anki collection is too large")
+	}
+
+	pkg := &Package{
+		media:     make(map[string]*zip.File),
+		mediaName: make(map[string]string),
+	}
+	if err := pkg.readMediaIndex(entries); err != nil {
+		return nil, err
+	}
+
+	tmp, err := os.CreateTemp("", "apkg-*.anki2")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	src, err := collection.Open()
+	if err != nil {
+		tmp.Close()
+		return nil, errors.New("failed to read Anki collection")
+	}
+	// The size in the zip header is not trusted: copy at most one byte past
+	// the limit to notice a collection that expands beyond it
+	n, err := io.Copy(tmp, io.LimitReader(src, MaxCollectionSize+1))
+	src.Close()
+	tmp.Close()
+	if err != nil {
+		return nil, errors.New("failed to read Anki collection")
+	}
+	if n > MaxCollectionSize {
+		return nil, errors.New("Anki collection is too large")
+	}
+
+	db, err := gorm.Open(sqlite.Open(tmpPath), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		return nil, errors.New("failed to open Anki collection")
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		defer sqlDB.Close()
+	}
+
+	if err := pkg.readCollection(db); err != nil {
+		return nil, err
+	}
+
+	return pkg, nil
+}
+
+func (p *Package) readMediaIndex(entries map[string]*zip.File) error {
+	index := entries["media"]
+	if index == nil {
+		return nil
+	}
+
+	rc, err := index.Open()
+	if err != nil {
+		return errors.New("failed to read media index")
+	}
+	defer rc.Close()
+
+	var names map[string]string
+	if err := json.NewDecoder(rc).Decode(&names); err != nil {
+		// Newer packages store a protobuf index; media is skipped for those.
+		return nil
+	}
+
+	for key, name := range names {
+		if f := entries[key]; f != nil {
+			p.media[name] = f
+			p.mediaName[strings.ToLower(name)] = name
+		}
+	}
+	return nil
+}
+
+func (p *Package) readCollection(db *gorm.DB) error {
+	var col colRow
+	if err := db.Raw("SELECT models, decks FROM col LIMIT 1").Scan(&col).Error; err != nil {
+		return errors.New("unsupported Anki collection schema")
+	}
+	if strings.TrimSpace(col.Models) == "" || strings.TrimSpace(col.Decks) == "" {
+		return errors.New("unsupported Anki collection schema, re-export it with 'Support older Anki versions' enabled")
+	}
+
+	var rawDecks map[string]struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal([]byte(col.Decks), &rawDecks); err != nil {
+		return errors.New("invalid deck list in Anki collection")
+	}
+	p.Decks = make(map[int64]Deck, len(rawDecks))
+	for key, d := range rawDecks {
+		id, err := strconv.ParseInt(key, 10, 64)
+		if err != nil {
+			continue
+		}
+		p.Decks[id] = Deck{ID: id, Name: d.Name}
+	}
+
+	var rawModels map[string]struct {
+		Name string `json:"name"`
+		Type int    `json:"type"`
+		Flds []struct {
+			Name string `json:"name"`
+			Ord  int    `json:"ord"`
+		} `json:"flds"`
+	}
+	if err := json.Unmarshal([]byte(col.Models), &rawModels); err != nil {
+		return errors.New("invalid note types in Anki collection")
+	}
+	p.NoteTypes = make(map[int64]NoteType, len(rawModels))
+	for key, m := range rawModels {
+		id, err := strconv.ParseInt(key, 10, 64)
+		if err != nil {
+			continue
+		}
+		flds := m.Flds
+		sort.Slice(flds, func(i, j int) bool { return flds[i].Ord < flds[j].Ord })
+		names := make([]string, 0, len(flds))
+		for _, f := range flds {
+			names = append(names, f.Name)
+		}
+		p.NoteTypes[id] = NoteType{ID: id, Name: m.Name, Fields: names, Cloze: m.Type == 1}
+	}
+
+	var notes []noteRow
+	if err := db.Raw("SELECT id, mid, mod, tags, flds FROM notes ORDER BY id").Scan(&notes).Error; err != nil {
+		return errors.New("failed to read notes from Anki collection")
+	}
+	p.Notes = make([]Note, 0, len(notes))
+	for _, n := range notes {
+		p.Notes = append(p.Notes, Note{
+			ID:       n.ID,
+			ModelID:  n.Mid,
+			Fields:   strings.Split(n.Flds, FieldSeparator),
+			Tags:     strings.Fields(n.Tags),
+			Modified: time.Unix(n.Mod, 0),
+		})
+	}
+
+	var cards []cardRow
+	if err := db.Raw("SELECT id, nid, did, ord, type, queue, ivl, factor, reps, lapses FROM cards ORDER BY nid, ord").Scan(&cards).Error; err != nil {
+		return errors.New("failed to read cards from Anki collection")
+	}
+	p.Cards = make([]Card, 0, len(cards))
+	for _, c := range cards {
+		p.Cards = append(p.Cards, Card{
+			ID: c.ID, NoteID: c.Nid, DeckID: c.Did, Ord: c.Ord, Type: c.Type, Queue: c.Queue,
+			Interval: c.Ivl, Factor: c.Factor, Reps: c.Reps, Lapses: c.Lapses,
+		})
+	}
+
+	var reviews []revlogRow
+	if err := db.Raw("SELECT id, cid, ease, ivl, type FROM revlog ORDER BY id").Scan(&reviews).Error; err != nil {
+		return errors.New("failed to read review history from Anki collection")
+	}
+	p.Reviews = make([]ReviewEntry, 0, len(reviews))
+	for _, r := range reviews {
+		p.Reviews = append(p.Reviews, ReviewEntry{ID: r.ID, CardID: r.Cid, Ease: r.Ease, Interval: r.Ivl, Type: r.Type})
+	}
+
+	return nil
+}
+
+// HasMedia reports whether the package contains a media file with the given
+// name (case-insensitive, as referenced from note HTML)
+func (p *Package) HasMedia(name string) bool {
+	_, ok := p.mediaName[strings.ToLower(name)]
+	return ok
+}
+
+// Media returns the content of a media file referenced by name
+func (p *Package) Media(name string) ([]byte, error) {
+	original, ok := p.mediaName[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("media file %q not found in package", name)
+	}
+	f := p.media[original]
+	if f.UncompressedSize64 > MaxMediaSize {
+		return nil, fmt.Errorf("media file %q is too large", name)
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read media file %q", name)
+	}
+	defer rc.Close()
+
+	return io.ReadAll(io.LimitReader(rc, MaxMediaSize))
+}
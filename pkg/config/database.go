@@ -17,6 +17,57 @@ import (
 
 var DB *gorm.DB
 
+// Models lists every entity AutoMigrate creates
+var Models = []any{
+	&entities.User{},
+	&entities.Kitab{},
+	&entities.Class{},
+	&entities.ClassMember{},
+	&entities.Card{},
+	&entities.CardState{},
+	&entities.TeacherRequest{},
+	&entities.ItemState{},
+	&entities.Item{},
+	&entities.ItemGraduation{},
+	&entities.FSRSState{},
+	&entities.DailyTask{},
+	&entities.ReviewState{},
+	&entities.ReviewLog{},
+	&entities.EngineControl{},
+	&entities.FSRSWeights{},
+	&entities.Card{},
+	&entities.Juz{},
+	&entities.JuzItem{},
+	&entities.Book{},
+	&entities.BookModule{},
+	&entities.BookItem{},
+	&entities.BookItemOverride{},
+	&entities.ClassBook{},
+	&entities.IntervalReviewLog{},
+	&entities.BookUpdateRequest{},
+	&entities.ImportedBook{},
+	&entities.BookRevision{},
+	&entities.BookReview{},
+	&entities.BookPublishRequest{},
+	&entities.BookPurgeLog{},
+	&entities.BookCollaborator{},
+	&entities.BookActivity{},
+	&entities.ClassAssignment{},
+	&entities.ClassAssignmentOverride{},
+	&entities.ClassAssignmentItem{},
+	&entities.ClassAssignmentReminder{},
+	&entities.Setoran{},
+	&entities.ClassGraduationPolicy{},
+	&entities.ClassStaff{},
+	&entities.ClassJoinRequest{},
+	&entities.ClassInvitation{},
+	&entities.ClassBan{},
+	&entities.ClassGroup{},
+	&entities.ClassGroupMember{},
+	&entities.ClassAnnouncement{},
+	&entities.Notification{},
+}
+
 func ConnectDatabase() {
 
 	err := godotenv.Load()
@@ -45,39 +96,15 @@ func ConnectDatabase() {
 	DB = db
 
 	log.Println("🚀 Running AutoMigrate...")
-	err = db.AutoMigrate(
-		&entities.User{},
-		&entities.Kitab{},
-		&entities.Class{},
-		&entities.ClassMember{},
-		&entities.Card{},
-		&entities.CardState{},
-		&entities.TeacherRequest{},
-		&entities.ItemState{},
-		&entities.Item{},
-		&entities.ItemGraduation{},
-		&entities.FSRSState{},
-		&entities.DailyTask{},
-		&entities.ReviewState{},
-		&entities.ReviewLog{},
-		&entities.EngineControl{},
-		&entities.FSRSWeights{},
-		&entities.Card{},
-		&entities.Juz{},
-		&entities.JuzItem{},
-		&entities.Book{},
-		&entities.BookModule{},
-		&entities.BookItem{},
-		&entities.BookItemOverride{},
-		&entities.ClassBook{},
-		&entities.IntervalReviewLog{},
-		&entities.BookUpdateRequest{},
-		&entities.ImportedBook{},
-	)
+	err = db.AutoMigrate(Models...)
 	if err != nil {
 		log.Fatal("❌ Failed to migrate:", err)
 	}
 
+	// Catalog search: GIN indexes for full-text search and tag containment
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_books_search_text ON books USING GIN (to_tsvector('simple', COALESCE(search_text, '')))`)
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_books_tags ON books USING GIN (tags)`)
+
 	log.Println("✅ Database connected and migrated successfully!")
 	BackfillImportedBooks(db)
 }
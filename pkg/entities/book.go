@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 
@@ -13,6 +14,9 @@ const (
 	BookStatusPending   = "pending"
 	BookStatusPublished = "published"
 	BookStatusRejected  = "rejected"
+	// BookStatusRetired: ditarik dari katalog oleh admin. Importer lama tetap
+	// bisa membaca dan melanjutkan hafalannya, tapi tidak bisa diimport baru.
+	BookStatusRetired = "retired"
 )
 
 type Book struct {
@@ -29,9 +33,32 @@ type Book struct {
 	// false = importers cannot modify items/modules (read-only for them)
 	IsEditable bool `gorm:"not null;default:true" json:"is_editable"`
 
+	// Katalog: kategori dan tag untuk filter daftar buku published
+	Category string                      `gorm:"size:50;index" json:"category,omitempty"`
+	Tags     datatypes.JSONSlice[string] `gorm:"type:jsonb" json:"tags,omitempty"`
+
+	// SearchText: judul, deskripsi dan isi item yang sudah dinormalisasi
+	// (utils.NormalizeSearchText) untuk full-text search katalog
+	SearchText string `gorm:"type:text" json:"-"`
+
+	// SourceBookID: buku published asal jika buku ini hasil copy-to-draft
+	SourceBookID *uuid.UUID `gorm:"type:uuid;index" json:"source_book_id,omitempty"`
+	// SourceVersion: ContentVersion buku asal saat di-copy atau terakhir
+	// kali perubahan upstream ditarik. 0 untuk copy lama tanpa pelacakan.
+	SourceVersion int `gorm:"not null;default:0" json:"source_version,omitempty"`
+	// ForkedFrom: atribusi buku asal (diisi service, tidak disimpan)
+	ForkedFrom *BookForkOrigin `gorm:"-" json:"forked_from,omitempty"`
+
+	// ContentVersion naik setiap kali revisi konten (BookRevision) disetujui
+	ContentVersion int `gorm:"not null;default:1" json:"content_version"`
+
 	Status      string     `gorm:"size:20;not null;default:'draft'" json:"status"`
 	PublishedAt *time.Time `json:"published_at,omitempty"`
 
+	RetiredAt    *time.Time `json:"retired_at,omitempty"`
+	RetiredBy    *uuid.UUID `gorm:"type:uuid" json:"retired_by,omitempty"`
+	RetireReason string     `gorm:"type:text" json:"retire_reason,omitempty"`
+
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 
@@ -40,6 +67,21 @@ type Book struct {
 	Items   []BookItem   `gorm:"foreignKey:BookID" json:"items,omitempty"`
 }
 
+// BookForkOrigin menjelaskan buku asal sebuah fork (copy-to-draft)
+type BookForkOrigin struct {
+	BookID    uuid.UUID `json:"book_id"`
+	Title     string    `json:"title,omitempty"`
+	OwnerID   uuid.UUID `json:"owner_id,omitempty"`
+	OwnerName string    `json:"owner_name,omitempty"`
+	// Status buku asal; "unavailable" jika buku asal sudah dihapus
+	Status string `json:"status"`
+	// Version: versi buku asal yang dimiliki fork; CurrentVersion: versi
+	// buku asal sekarang; Behind: jumlah versi yang belum ditarik
+	Version        int `json:"version"`
+	CurrentVersion int `json:"current_version,omitempty"`
+	Behind         int `json:"behind"`
+}
+
 func (b *Book) BeforeCreate(tx *gorm.DB) error {
 	b.ID = uuid.New()
 	if b.Status == "" {
@@ -0,0 +1,53 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Book activity actions
+const (
+	BookActivityModuleAdded   = "module_added"
+	BookActivityModuleUpdated = "module_updated"
+	BookActivityModuleDeleted = "module_deleted"
+	BookActivityModuleMoved   = "module_moved"
+	BookActivityItemAdded     = "item_added"
+	BookActivityItemUpdated   = "item_updated"
+	BookActivityItemDeleted   = "item_deleted"
+	BookActivityItemMoved     = "item_moved"
+	BookActivityTreeReordered = "tree_reordered"
+	BookActivityUpstreamPull  = "upstream_pulled"
+
+	BookActivityCollaboratorInvited = "collaborator_invited"
+	BookActivityCollaboratorJoined  = "collaborator_joined"
+	BookActivityCollaboratorRole    = "collaborator_role_changed"
+	BookActivityCollaboratorRemoved = "collaborator_removed"
+)
+
+// BookActivity adalah log perubahan buku oleh pemilik dan kolaborator:
+// siapa mengubah module/item apa, dan perubahan daftar kolaborator.
+type BookActivity struct {
+	ID     uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	BookID uuid.UUID `gorm:"type:uuid;not null;index" json:"book_id"`
+	UserID uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+
+	Action      string     `gorm:"size:40;not null" json:"action"`
+	TargetID    *uuid.UUID `gorm:"type:uuid" json:"target_id,omitempty"`
+	TargetTitle string     `gorm:"size:255" json:"target_title,omitempty"`
+
+	// InDraft: perubahan masuk ke draft revisi konten (buku published),
+	// belum terlihat importer sampai disetujui admin
+	InDraft bool `gorm:"not null;default:false" json:"in_draft"`
+
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+
+	// UserName diisi service untuk tampilan
+	UserName string `gorm:"-" json:"user_name,omitempty"`
+}
+
+func (a *BookActivity) BeforeCreate(tx *gorm.DB) error {
+	a.ID = uuid.New()
+	return nil
+}
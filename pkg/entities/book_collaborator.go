@@ -0,0 +1,55 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Book collaborator roles and invitation statuses
+const (
+	BookCollaboratorRoleEditor = "editor" // boleh mengubah module & item
+	BookCollaboratorRoleViewer = "viewer" // hanya boleh melihat buku (termasuk draft)
+
+	BookCollaboratorStatusPending  = "pending"
+	BookCollaboratorStatusAccepted = "accepted"
+)
+
+// BookCollaborator adalah rekan penulis sebuah buku. Pemilik mengundang lewat
+// email; undangan berlaku setelah user dengan email tersebut menerimanya
+// dengan token undangan. Undangan yang ditolak dihapus sehingga bisa dikirim
+// ulang.
+type BookCollaborator struct {
+	ID     uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	BookID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_book_collaborator_email" json:"book_id"`
+	Email  string    `gorm:"size:255;not null;uniqueIndex:idx_book_collaborator_email;index" json:"email"`
+
+	// UserID terisi saat undangan diterima
+	UserID *uuid.UUID `gorm:"type:uuid;index" json:"user_id,omitempty"`
+
+	Role      string    `gorm:"size:20;not null" json:"role"`
+	Status    string    `gorm:"size:20;not null;default:'pending';index" json:"status"`
+	InvitedBy uuid.UUID `gorm:"type:uuid;not null" json:"invited_by"`
+
+	// TokenHash: sha256 token undangan. Token hanya dikembalikan sekali
+	// (Token) saat undangan dibuat atau dikirim ulang, lalu dibagikan
+	// pemilik ke pemegang email tersebut.
+	TokenHash string `gorm:"size:64" json:"-"`
+	Token     string `gorm:"-" json:"token,omitempty"`
+
+	AcceptedAt *time.Time `json:"accepted_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+
+	// Relations
+	Book *Book `gorm:"foreignKey:BookID" json:"book,omitempty"`
+}
+
+func (c *BookCollaborator) BeforeCreate(tx *gorm.DB) error {
+	c.ID = uuid.New()
+	if c.Status == "" {
+		c.Status = BookCollaboratorStatusPending
+	}
+	return nil
+}
@@ -4,9 +4,17 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 
+// Book item type constants
+const (
+	BookItemTypeBasic          = "basic"           // kartu bolak-balik: content → answer
+	BookItemTypeCloze          = "cloze"           // {{c1::...}} di content, satu unit hafalan per cloze
+	BookItemTypeMultipleChoice = "multiple_choice" // content = soal, answer = jawaban benar
+)
+
 type BookItem struct {
 	ID       uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
 	BookID   uuid.UUID  `gorm:"type:uuid;not null;index" json:"book_id"`
@@ -22,17 +30,42 @@ type BookItem struct {
 	Answer  string `gorm:"type:text" json:"answer"`  // jawaban
 	Order   int    `gorm:"not null;default:0" json:"order"`
 
+	// Type: basic | cloze | multiple_choice
+	Type string `gorm:"size:20;not null;default:'basic'" json:"type"`
+	// Distractors: pilihan jawaban yang salah untuk item multiple_choice
+	Distractors datatypes.JSONSlice[string] `gorm:"type:jsonb" json:"distractors,omitempty"`
+
 	// Gambar item (hanya untuk user premium)
 	ImageURL string `gorm:"size:500" json:"image_url,omitempty"`
 
 	// Estimasi waktu review (detik) opsional untuk item buku.
 	EstimatedReviewSeconds int `gorm:"default:0" json:"estimated_review_seconds"`
 
+	// Version naik setiap kali konten canonical (title/content/answer/gambar/
+	// estimasi) berubah. BookItemOverride mencatat versi yang menjadi dasarnya,
+	// sehingga override yang basisnya sudah berubah bisa ditandai stale.
+	Version int `gorm:"not null;default:1" json:"version"`
+
+	// SourceItemID: item asal di buku sumber untuk buku hasil copy-to-draft,
+	// dipakai saat menarik perubahan upstream
+	SourceItemID *uuid.UUID `gorm:"type:uuid;index" json:"source_item_id,omitempty"`
+	// SourceHash: sidik isi item sumber saat disalin atau terakhir ditarik,
+	// untuk mengenali item yang diubah di kedua sisi (konflik)
+	SourceHash string `gorm:"size:64" json:"source_hash,omitempty"`
+
+	// RemovedAt: diisi saat item canonical dihapus lewat revisi konten yang
+	// disetujui. Baris tetap disimpan agar progress (Item) importer masih bisa
+	// membaca kontennya, tetapi item tidak lagi tampil di buku.
+	RemovedAt *time.Time `gorm:"index" json:"removed_at,omitempty"`
+
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
 func (i *BookItem) BeforeCreate(tx *gorm.DB) error {
-	i.ID = uuid.New()
+	// Item dari revisi konten sudah punya ID (dibuat saat draft diedit)
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
 	return nil
 }
@@ -29,8 +29,23 @@ type BookItemOverride struct {
 	Answer   string `gorm:"type:text" json:"answer"`
 	ImageURL string `gorm:"size:500" json:"image_url,omitempty"`
 
+	// ImageUploaded: ImageURL diunggah user lewat override ini, bukan milik
+	// buku. Hanya gambar seperti ini yang dihapus dari storage.
+	ImageUploaded bool `gorm:"not null;default:false" json:"-"`
+
 	EstimatedReviewSeconds int `gorm:"default:0" json:"estimated_review_seconds"`
 
+	// Snapshot BookItem canonical yang menjadi dasar override ini. Jika
+	// BookItem.Version > BaseVersion, pemilik buku sudah mengubah item setelah
+	// override dibuat (stale) dan user perlu memilih: keep mine, take theirs,
+	// atau merge. Override lama (sebelum kolom ini ada) punya snapshot kosong.
+	BaseVersion                int    `gorm:"not null;default:1" json:"base_version"`
+	BaseTitle                  string `gorm:"size:200" json:"base_title"`
+	BaseContent                string `gorm:"type:text" json:"base_content"`
+	BaseAnswer                 string `gorm:"type:text" json:"base_answer"`
+	BaseImageURL               string `gorm:"size:500" json:"base_image_url,omitempty"`
+	BaseEstimatedReviewSeconds int    `gorm:"default:0" json:"base_estimated_review_seconds"`
+
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
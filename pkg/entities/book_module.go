@@ -16,6 +16,13 @@ type BookModule struct {
 	Description string `gorm:"type:text" json:"description"`
 	Order       int    `gorm:"not null;default:0" json:"order"`
 
+	// SourceModuleID: modul asal di buku sumber untuk buku hasil
+	// copy-to-draft, dipakai saat menarik perubahan upstream
+	SourceModuleID *uuid.UUID `gorm:"type:uuid;index" json:"source_module_id,omitempty"`
+	// SourceHash: sidik isi modul sumber saat disalin atau terakhir ditarik,
+	// untuk mengenali modul yang diubah di kedua sisi (konflik)
+	SourceHash string `gorm:"size:64" json:"source_hash,omitempty"`
+
 	CreatedAt time.Time `json:"created_at"`
 
 	// Relations
@@ -23,6 +30,9 @@ type BookModule struct {
 }
 
 func (m *BookModule) BeforeCreate(tx *gorm.DB) error {
-	m.ID = uuid.New()
+	// Module dari revisi konten sudah punya ID (dibuat saat draft diedit)
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
 	return nil
 }
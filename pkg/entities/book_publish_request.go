@@ -0,0 +1,65 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Publish request (moderation) status constants
+const (
+	BookPublishStatusPending  = "pending"
+	BookPublishStatusApproved = "approved"
+	BookPublishStatusRejected = "rejected"
+)
+
+// Target of an inline moderation comment
+const (
+	ModerationTargetModule = "module"
+	ModerationTargetItem   = "item"
+)
+
+// BookPublishRequest mencatat satu kali pengajuan publish beserta keputusan
+// admin. Setiap resubmit membuat record baru (Round bertambah) sehingga
+// riwayat moderasi tetap tersimpan.
+type BookPublishRequest struct {
+	ID      uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	BookID  uuid.UUID `gorm:"type:uuid;not null;index" json:"book_id"`
+	OwnerID uuid.UUID `gorm:"type:uuid;not null;index" json:"owner_id"`
+	Round   int       `gorm:"not null;default:1" json:"round"`
+	Status  string    `gorm:"size:20;not null;default:'pending';index" json:"status"`
+
+	// Catatan penulis saat (re)submit, mis. apa yang sudah diperbaiki
+	Note        string    `gorm:"type:text" json:"note,omitempty"`
+	SubmittedAt time.Time `json:"submitted_at"`
+
+	ReviewerID *uuid.UUID `gorm:"type:uuid" json:"reviewer_id,omitempty"`
+	DecidedAt  *time.Time `json:"decided_at,omitempty"`
+	Reason     string     `gorm:"type:text" json:"reason,omitempty"`
+
+	// Komentar inline admin pada modul/item tertentu
+	Comments datatypes.JSONSlice[ModerationComment] `gorm:"type:jsonb" json:"comments,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ModerationComment is a reviewer comment on one module or item of the book.
+// TargetTitle keeps the title at review time in case the target is renamed or
+// deleted before resubmission.
+type ModerationComment struct {
+	TargetType  string    `json:"target_type"` // module | item
+	TargetID    uuid.UUID `json:"target_id"`
+	TargetTitle string    `json:"target_title"`
+	Comment     string    `json:"comment"`
+}
+
+func (r *BookPublishRequest) BeforeCreate(tx *gorm.DB) error {
+	r.ID = uuid.New()
+	if r.Status == "" {
+		r.Status = BookPublishStatusPending
+	}
+	return nil
+}
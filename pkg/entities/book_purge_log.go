@@ -0,0 +1,32 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// BookPurgeLog adalah jejak audit penghapusan permanen (purge) sebuah buku
+// yang sudah di-retire. Data buku ikut terhapus, jadi judul dan pemilik
+// disalin ke sini.
+type BookPurgeLog struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	BookID    uuid.UUID `gorm:"type:uuid;not null;index" json:"book_id"`
+	BookTitle string    `gorm:"size:200;not null" json:"book_title"`
+	OwnerID   uuid.UUID `gorm:"type:uuid;not null" json:"owner_id"`
+	AdminID   uuid.UUID `gorm:"type:uuid;not null;index" json:"admin_id"`
+	Reason    string    `gorm:"type:text;not null" json:"reason"`
+
+	// Jumlah Item hafalan yang ikut terhapus dan jumlah user pemiliknya
+	ItemCount    int64 `gorm:"not null;default:0" json:"item_count"`
+	LearnerCount int64 `gorm:"not null;default:0" json:"learner_count"`
+
+	RetiredAt *time.Time `json:"retired_at,omitempty"`
+	PurgedAt  time.Time  `gorm:"not null" json:"purged_at"`
+}
+
+func (l *BookPurgeLog) BeforeCreate(tx *gorm.DB) error {
+	l.ID = uuid.New()
+	return nil
+}
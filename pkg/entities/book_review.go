@@ -0,0 +1,35 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// BookReview: rating bintang (1-5) dan ulasan singkat untuk buku published.
+// Hanya user yang meng-import atau meng-copy buku yang boleh mengulas, satu
+// ulasan per user (bisa diedit). Ulasan yang disembunyikan admin tidak tampil
+// dan tidak dihitung di rating agregat.
+type BookReview struct {
+	ID     uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	BookID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_book_review_user" json:"book_id"`
+	UserID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_book_review_user;index" json:"user_id"`
+
+	Rating int    `gorm:"not null" json:"rating"`
+	Body   string `gorm:"size:1000" json:"body"`
+
+	// Moderasi admin
+	Hidden       bool       `gorm:"not null;default:false;index" json:"hidden"`
+	HiddenReason string     `gorm:"type:text" json:"hidden_reason,omitempty"`
+	HiddenBy     *uuid.UUID `gorm:"type:uuid" json:"hidden_by,omitempty"`
+	HiddenAt     *time.Time `json:"hidden_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (r *BookReview) BeforeCreate(tx *gorm.DB) error {
+	r.ID = uuid.New()
+	return nil
+}
@@ -0,0 +1,89 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+const (
+	BookRevisionStatusDraft    = "draft"
+	BookRevisionStatusPending  = "pending"
+	BookRevisionStatusApproved = "approved"
+	BookRevisionStatusRejected = "rejected"
+)
+
+// Kinds and actions used in BookContentChange
+const (
+	BookChangeKindModule = "module"
+	BookChangeKindItem   = "item"
+
+	BookChangeAdded   = "added"
+	BookChangeUpdated = "updated"
+	BookChangeRemoved = "removed"
+)
+
+// BookRevision adalah revisi konten (module & item) untuk buku yang sudah
+// published. Pemilik buku mengedit revisi draft (snapshot seluruh konten
+// canonical), lalu mengajukannya ke admin. Saat disetujui, perubahan
+// diterapkan ke book_modules/book_items, Book.ContentVersion naik, dan
+// importer melihat changelog-nya.
+type BookRevision struct {
+	ID       uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	BookID   uuid.UUID `gorm:"type:uuid;not null;index" json:"book_id"`
+	AuthorID uuid.UUID `gorm:"type:uuid;not null;index" json:"author_id"`
+
+	// BaseVersion: Book.ContentVersion saat revisi dibuat.
+	// Version: versi baru buku, diisi saat revisi disetujui.
+	BaseVersion int `gorm:"not null;default:1" json:"base_version"`
+	Version     int `gorm:"not null;default:0" json:"version,omitempty"`
+	// SourceVersion: untuk fork, versi buku asal yang perubahannya sudah
+	// ditarik ke revisi ini. Disalin ke Book.SourceVersion saat disetujui.
+	SourceVersion int `gorm:"not null;default:0" json:"source_version,omitempty"`
+
+	Status  string `gorm:"size:20;not null;default:'draft';index" json:"status"`
+	Summary string `gorm:"type:text" json:"summary"` // catatan perubahan dari penulis
+
+	// Content: snapshot BookRevisionContent (JSON)
+	Content datatypes.JSON `gorm:"type:jsonb" json:"-"`
+	// Changelog: []BookContentChange, dihitung saat revisi disetujui
+	Changelog datatypes.JSON `gorm:"type:jsonb" json:"changelog,omitempty"`
+
+	SubmittedAt  *time.Time `json:"submitted_at,omitempty"`
+	ReviewedAt   *time.Time `json:"reviewed_at,omitempty"`
+	ReviewedBy   *uuid.UUID `gorm:"type:uuid" json:"reviewed_by,omitempty"`
+	RejectReason string     `gorm:"type:text" json:"reject_reason,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Relations
+	Book *Book `gorm:"foreignKey:BookID" json:"book,omitempty"`
+}
+
+func (r *BookRevision) BeforeCreate(tx *gorm.DB) error {
+	r.ID = uuid.New()
+	if r.Status == "" {
+		r.Status = BookRevisionStatusDraft
+	}
+	return nil
+}
+
+// BookRevisionContent is the canonical module/item tree of a revision,
+// stored flat like the book_modules/book_items tables. Existing rows keep
+// their IDs; new rows get their ID when they are added to the draft.
+type BookRevisionContent struct {
+	Modules []BookModule `json:"modules"`
+	Items   []BookItem   `json:"items"`
+}
+
+// BookContentChange is one line of a book changelog
+type BookContentChange struct {
+	Kind   string    `json:"kind"`   // module | item
+	Action string    `json:"action"` // added | updated | removed
+	ID     uuid.UUID `json:"id"`
+	Title  string    `json:"title"`
+	Fields []string  `json:"fields,omitempty"` // changed fields for updates
+}
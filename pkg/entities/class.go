@@ -13,6 +13,13 @@ const (
 	ClassTypeBook  = "book"
 )
 
+// Class join policies
+const (
+	ClassJoinOpen       = "open"        // kode kelas langsung bergabung
+	ClassJoinApproval   = "approval"    // kode kelas membuat permintaan yang disetujui guru
+	ClassJoinInviteOnly = "invite_only" // hanya guru yang menambahkan siswa
+)
+
 type Class struct {
 	ID uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
 
@@ -24,9 +31,16 @@ type Class struct {
 	Type        string    `gorm:"size:20;not null;default:'quran'" json:"type"` // quran | book
 	IsActive    bool      `gorm:"default:true" json:"is_active"`
 
+	// Pengaturan bergabung. MaxMembers 0 berarti tanpa batas.
+	JoinPolicy    string     `gorm:"size:20;not null;default:'open'" json:"join_policy"`
+	CodeExpiresAt *time.Time `json:"code_expires_at,omitempty"`
+	MaxMembers    int        `gorm:"not null;default:0" json:"max_members"`
+
 	OwnerName    string `gorm:"-" json:"owner_name"`
 	StudentCount int64  `gorm:"-" json:"student_count"`
 	BookCount    int64  `gorm:"-" json:"book_count"`
+	MyRole       string `gorm:"-" json:"my_role,omitempty"`     // owner | co_teacher | assistant
+	JoinStatus   string `gorm:"-" json:"join_status,omitempty"` // joined | pending, set by JoinClass
 
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
@@ -41,5 +55,8 @@ func (c *Class) BeforeCreate(tx *gorm.DB) error {
 	if c.Type == "" {
 		c.Type = ClassTypeQuran
 	}
+	if c.JoinPolicy == "" {
+		c.JoinPolicy = ClassJoinOpen
+	}
 	return nil
 }
@@ -0,0 +1,45 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ClassAnnouncement adalah pengumuman guru atau staf untuk seluruh siswa
+// kelas, atau untuk satu halaqah (GroupID). Pengumuman dengan PublishAt di
+// masa depan baru terlihat oleh siswa pada waktu tersebut.
+type ClassAnnouncement struct {
+	ID       uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	ClassID  uuid.UUID `gorm:"type:uuid;not null;index" json:"class_id"`
+	AuthorID uuid.UUID `gorm:"type:uuid;not null" json:"author_id"`
+
+	// GroupID: kosong berarti untuk seluruh siswa kelas
+	GroupID *uuid.UUID `gorm:"type:uuid;index" json:"group_id,omitempty"`
+
+	Title string `gorm:"size:200" json:"title,omitempty"`
+	Body  string `gorm:"type:text;not null" json:"body"`
+
+	// Lampiran opsional: file yang diunggah atau tautan
+	AttachmentURL  string `gorm:"size:500" json:"attachment_url,omitempty"`
+	AttachmentName string `gorm:"size:255" json:"attachment_name,omitempty"`
+
+	Pinned    bool      `gorm:"default:false" json:"pinned"`
+	PublishAt time.Time `gorm:"not null;index" json:"publish_at"`
+
+	// NotifiedAt: kapan notifikasi dikirim ke siswa; kosong selama
+	// pengumuman terjadwal belum terbit
+	NotifiedAt *time.Time `gorm:"index" json:"-"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Relations
+	Author *User `gorm:"foreignKey:AuthorID" json:"author,omitempty"`
+}
+
+func (a *ClassAnnouncement) BeforeCreate(tx *gorm.DB) error {
+	a.ID = uuid.New()
+	return nil
+}
@@ -0,0 +1,102 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Class assignment target types
+const (
+	AssignmentTargetQuran = "quran" // rentang ayat, mis. surah:78:1-20
+	AssignmentTargetBook  = "book"  // module dan/atau item dari buku kelas
+)
+
+// ClassAssignment adalah tugas hafalan dari guru untuk seluruh siswa kelas,
+// atau untuk satu halaqah (GroupID), dengan tenggat waktu. Item hafalan dibuat
+// otomatis di akun setiap siswa yang dituju.
+type ClassAssignment struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	ClassID   uuid.UUID `gorm:"type:uuid;not null;index" json:"class_id"`
+	TeacherID uuid.UUID `gorm:"type:uuid;not null" json:"teacher_id"`
+
+	// GroupID: kosong berarti untuk seluruh siswa kelas
+	GroupID *uuid.UUID `gorm:"type:uuid;index" json:"group_id,omitempty"`
+
+	Title       string `gorm:"size:200;not null" json:"title"`
+	Description string `gorm:"type:text" json:"description"`
+
+	TargetType string `gorm:"size:20;not null" json:"target_type"` // quran | book
+
+	// ContentRef: untuk target quran, mis. "surah:78:1-20". Rentang yang
+	// melewati batas juz dipecah per juz saat item siswa dibuat.
+	ContentRef string `gorm:"size:100" json:"content_ref,omitempty"`
+
+	// Target buku: module (beserta sub-module) dan/atau item tertentu
+	BookID      *uuid.UUID                     `gorm:"type:uuid;index" json:"book_id,omitempty"`
+	ModuleIDs   datatypes.JSONSlice[uuid.UUID] `gorm:"type:jsonb" json:"module_ids,omitempty"`
+	BookItemIDs datatypes.JSONSlice[uuid.UUID] `gorm:"type:jsonb" json:"book_item_ids,omitempty"`
+
+	DueAt time.Time `gorm:"not null;index" json:"due_at"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Relations
+	Overrides []ClassAssignmentOverride `gorm:"foreignKey:AssignmentID" json:"overrides,omitempty"`
+}
+
+func (a *ClassAssignment) BeforeCreate(tx *gorm.DB) error {
+	// ID sudah diisi saat link Item siswa disiapkan sebelum tugas disimpan
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
+
+// ClassAssignmentOverride mengganti tenggat tugas untuk satu siswa, atau
+// membebaskannya dari tugas (Excused).
+type ClassAssignmentOverride struct {
+	ID           uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	AssignmentID uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_assignment_override_user" json:"assignment_id"`
+	UserID       uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_assignment_override_user" json:"user_id"`
+	DueAt        *time.Time `json:"due_at,omitempty"`
+	Excused      bool       `gorm:"default:false" json:"excused"`
+	Note         string     `gorm:"type:text" json:"note,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (o *ClassAssignmentOverride) BeforeCreate(tx *gorm.DB) error {
+	o.ID = uuid.New()
+	return nil
+}
+
+// ClassAssignmentItem menghubungkan tugas dengan Item hafalan siswa yang
+// dibuat (atau sudah ada) untuk tugas tersebut. Satu Item ditautkan sekali
+// per tugas.
+type ClassAssignmentItem struct {
+	ID           uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	AssignmentID uuid.UUID  `gorm:"type:uuid;not null;index;uniqueIndex:idx_assignment_item" json:"assignment_id"`
+	UserID       uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	ItemID       uuid.UUID  `gorm:"type:uuid;not null;index;uniqueIndex:idx_assignment_item" json:"item_id"`
+	BookItemID   *uuid.UUID `gorm:"type:uuid" json:"book_item_id,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+func (i *ClassAssignmentItem) BeforeCreate(tx *gorm.DB) error {
+	i.ID = uuid.New()
+	return nil
+}
+
+// ClassAssignmentReminder menandai siswa yang sudah diingatkan tentang
+// tenggat tugas, sehingga pengingat dikirim sekali per siswa per tugas
+// walaupun scheduler berjalan bersamaan.
+type ClassAssignmentReminder struct {
+	AssignmentID uuid.UUID `gorm:"type:uuid;primaryKey" json:"assignment_id"`
+	UserID       uuid.UUID `gorm:"type:uuid;primaryKey" json:"user_id"`
+	RemindedAt   time.Time `gorm:"not null" json:"reminded_at"`
+}
@@ -0,0 +1,33 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ClassGraduationPolicy: standar kelulusan (graduate) item siswa di satu
+// kelas. Kelas tanpa policy memakai standar bawaan (MinimumReviews,
+// GraduationIntervalDays, GraduateStabilityThreshold, GraduateReviewDays).
+type ClassGraduationPolicy struct {
+	ID      uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	ClassID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex" json:"class_id"`
+
+	MinReviews         int     `gorm:"not null" json:"min_reviews"`          // jumlah review minimal
+	MinDays            int     `gorm:"not null" json:"min_days"`             // hari di fase fsrs_active
+	StabilityThreshold float64 `gorm:"not null" json:"stability_threshold"`  // atau stability (hari) minimal
+	RequireApproval    bool    `gorm:"not null" json:"require_approval"`     // pending_graduate menunggu guru
+	GraduateReviewDays int     `gorm:"not null" json:"graduate_review_days"` // jadwal murajaah setelah graduate
+
+	UpdatedBy uuid.UUID `gorm:"type:uuid" json:"updated_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (p *ClassGraduationPolicy) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ClassGroup adalah halaqah (kelompok kecil) di dalam kelas, opsional
+// dibimbing oleh satu staf kelas (musyrif).
+type ClassGroup struct {
+	ID      uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	ClassID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_class_group_name" json:"class_id"`
+
+	Name        string `gorm:"size:100;not null;uniqueIndex:idx_class_group_name" json:"name"`
+	Description string `gorm:"type:text" json:"description,omitempty"`
+
+	// StaffID: pemilik kelas atau staf aktif yang membimbing halaqah ini
+	StaffID *uuid.UUID `gorm:"type:uuid;index" json:"staff_id,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Relations
+	Staff *User `gorm:"foreignKey:StaffID" json:"staff,omitempty"`
+}
+
+func (g *ClassGroup) BeforeCreate(tx *gorm.DB) error {
+	g.ID = uuid.New()
+	return nil
+}
+
+// ClassGroupMember menempatkan siswa di satu halaqah. Satu siswa hanya
+// berada di satu halaqah per kelas.
+type ClassGroupMember struct {
+	ID      uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	ClassID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_class_group_member" json:"class_id"`
+	UserID  uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_class_group_member" json:"user_id"`
+	GroupID uuid.UUID `gorm:"type:uuid;not null;index" json:"group_id"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (m *ClassGroupMember) BeforeCreate(tx *gorm.DB) error {
+	m.ID = uuid.New()
+	return nil
+}
@@ -0,0 +1,83 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Class join request statuses
+const (
+	ClassJoinRequestPending  = "pending"
+	ClassJoinRequestRejected = "rejected"
+)
+
+// ClassJoinRequest adalah permintaan bergabung ke kelas dengan kebijakan
+// approval. Permintaan yang disetujui dihapus karena siswa menjadi member;
+// permintaan yang ditolak disimpan dan bisa diajukan ulang.
+type ClassJoinRequest struct {
+	ID      uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	ClassID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_class_join_request_user" json:"class_id"`
+	UserID  uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_class_join_request_user;index" json:"user_id"`
+
+	Status    string     `gorm:"size:20;not null;default:'pending';index" json:"status"`
+	DecidedBy *uuid.UUID `gorm:"type:uuid" json:"decided_by,omitempty"`
+	DecidedAt *time.Time `json:"decided_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Relations
+	User *User `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+func (r *ClassJoinRequest) BeforeCreate(tx *gorm.DB) error {
+	r.ID = uuid.New()
+	if r.Status == "" {
+		r.Status = ClassJoinRequestPending
+	}
+	return nil
+}
+
+// ClassInvitation adalah undangan guru kepada seorang siswa untuk bergabung
+// ke kelas, apa pun kebijakan join kelas. Siswa baru menjadi member setelah
+// menerimanya; undangan yang diterima atau ditolak dihapus.
+type ClassInvitation struct {
+	ID      uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	ClassID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_class_invitation_user" json:"class_id"`
+	UserID  uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_class_invitation_user;index" json:"user_id"`
+
+	InvitedBy uuid.UUID `gorm:"type:uuid;not null" json:"invited_by"`
+
+	CreatedAt time.Time `json:"created_at"`
+
+	// Relations
+	Class *Class `gorm:"foreignKey:ClassID" json:"class,omitempty"`
+	User  *User  `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+func (i *ClassInvitation) BeforeCreate(tx *gorm.DB) error {
+	i.ID = uuid.New()
+	return nil
+}
+
+// ClassBan mencegah user bergabung (lagi) ke kelas sampai ban dicabut
+type ClassBan struct {
+	ID      uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	ClassID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_class_ban_user" json:"class_id"`
+	UserID  uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_class_ban_user" json:"user_id"`
+
+	BannedBy uuid.UUID `gorm:"type:uuid;not null" json:"banned_by"`
+	Reason   string    `gorm:"type:text" json:"reason,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+
+	// Relations
+	User *User `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+func (b *ClassBan) BeforeCreate(tx *gorm.DB) error {
+	b.ID = uuid.New()
+	return nil
+}
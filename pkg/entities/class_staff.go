@@ -0,0 +1,48 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Class staff roles and invitation statuses. Pemilik kelas (owner) tetap
+// Class.GuruID dan tidak disimpan sebagai ClassStaff.
+const (
+	ClassStaffRoleOwner     = "owner"
+	ClassStaffRoleCoTeacher = "co_teacher" // ustadz pendamping: semua izin kecuali mengelola staff
+	ClassStaffRoleAssistant = "assistant"  // musyrif: melihat progres & mencatat setoran
+
+	ClassStaffStatusPending = "pending"
+	ClassStaffStatusActive  = "active"
+)
+
+// ClassStaff adalah pengajar tambahan sebuah kelas. Pemilik mengundang guru
+// lewat email; undangan berlaku setelah diterima. Undangan yang ditolak dan
+// staff yang keluar dihapus sehingga bisa diundang ulang.
+type ClassStaff struct {
+	ID      uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	ClassID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_class_staff_user" json:"class_id"`
+	UserID  uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_class_staff_user;index" json:"user_id"`
+
+	Role      string    `gorm:"size:20;not null" json:"role"`
+	Status    string    `gorm:"size:20;not null;default:'pending';index" json:"status"`
+	InvitedBy uuid.UUID `gorm:"type:uuid;not null" json:"invited_by"`
+
+	AcceptedAt *time.Time `json:"accepted_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+
+	// Relations
+	Class *Class `gorm:"foreignKey:ClassID" json:"class,omitempty"`
+	User  *User  `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+func (s *ClassStaff) BeforeCreate(tx *gorm.DB) error {
+	s.ID = uuid.New()
+	if s.Status == "" {
+		s.Status = ClassStaffStatusPending
+	}
+	return nil
+}
@@ -7,8 +7,11 @@ import (
 )
 
 type ImportedBook struct {
-	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
-	UserID    uuid.UUID `gorm:"type:uuid;not null;index:idx_user_book,unique" json:"user_id"`
-	BookID    uuid.UUID `gorm:"type:uuid;not null;index:idx_user_book,unique" json:"book_id"`
-	CreatedAt time.Time `json:"created_at"`
+	ID     uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID uuid.UUID `gorm:"type:uuid;not null;index:idx_user_book,unique" json:"user_id"`
+	BookID uuid.UUID `gorm:"type:uuid;not null;index:idx_user_book,unique" json:"book_id"`
+
+	// SeenVersion: Book.ContentVersion terakhir yang changelog-nya sudah dilihat importer
+	SeenVersion int       `gorm:"not null;default:0" json:"seen_version"`
+	CreatedAt   time.Time `json:"created_at"`
 }
@@ -35,6 +35,10 @@ type Item struct {
 	SourceType string    `gorm:"type:varchar(20);not null"` // quran | class | personal
 	ContentRef string    `gorm:"not null"`
 
+	// ClozeIndex: nomor cloze (c1, c2, ...) untuk item buku bertipe cloze,
+	// setiap cloze punya Item dan state FSRS sendiri. 0 = item utuh.
+	ClozeIndex int `gorm:"not null;default:0"`
+
 	// Status State Machine
 	Status               string     `gorm:"type:varchar(20);not null;default:'menghafal'"` // menghafal | interval | fsrs_active | pending_graduate | graduate
 	IntervalDays         int        `gorm:"default:0"`                                     // Custom interval days (for interval phase)
@@ -53,6 +57,11 @@ type Item struct {
 	ApprovedBy *uuid.UUID `gorm:"type:uuid;index"` // Teacher who approved graduation
 	ApprovedAt *time.Time `gorm:"type:timestamp"`  // When graduation was approved
 
+	// Retest date set when the teacher rejects a graduation: the item is due
+	// on that day regardless of its FSRS schedule, and cannot graduate again
+	// before the retest review.
+	RetestAt *time.Time `gorm:"type:timestamp;index"`
+
 	// FSRS start time: when item entered fsrs_active phase (day 1)
 	FSRSStartAt *time.Time `gorm:"type:timestamp"`
 
@@ -7,19 +7,31 @@ import (
 	"gorm.io/gorm"
 )
 
+// Keputusan guru kelas atas item pending_graduate
+const (
+	GraduationActionApprove = "approve"
+	GraduationActionReject  = "reject"
+)
+
 type ItemGraduation struct {
-	ID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	ID uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
 
-	UserID uuid.UUID `gorm:"type:uuid;not null;index"`
-	ItemID uuid.UUID `gorm:"type:uuid;not null;index"`
+	UserID uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	ItemID uuid.UUID `gorm:"type:uuid;not null;index" json:"item_id"`
 
 	// Keputusan akhir
-	Action string `gorm:"size:16;not null"` 
-	// graduate | freeze | reactivate
+	Action string `gorm:"size:16;not null" json:"action"`
+	// graduate | freeze | reactivate | approve | reject
+
+	// Komentar keputusan, terlihat oleh siswa
+	Reason string `gorm:"type:text" json:"reason,omitempty"`
 
-	Reason string `gorm:"size:255"`
+	// Keputusan guru kelas (approve | reject)
+	ClassID   *uuid.UUID `gorm:"type:uuid;index" json:"class_id,omitempty"`
+	DecidedBy *uuid.UUID `gorm:"type:uuid" json:"decided_by,omitempty"`
+	RetestAt  *time.Time `json:"retest_at,omitempty"` // tanggal ujian ulang setelah reject
 
-	CreatedAt time.Time
+	CreatedAt time.Time `json:"created_at"`
 }
 
 func (ig *ItemGraduation) BeforeCreate(tx *gorm.DB) error {
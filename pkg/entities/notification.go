@@ -0,0 +1,46 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Notification types
+const (
+	NotificationTypeAnnouncement           = "announcement"
+	NotificationTypeGraduationApproved     = "graduation_approved"
+	NotificationTypeGraduationRejected     = "graduation_rejected"
+	NotificationTypeTeacherRequestApproved = "teacher_request_approved"
+	NotificationTypeTeacherRequestRejected = "teacher_request_rejected"
+	NotificationTypeBookApproved           = "book_approved"
+	NotificationTypeBookRejected           = "book_rejected"
+	NotificationTypeAssignmentDue          = "assignment_due"
+)
+
+// Notification adalah satu pesan di kotak notifikasi pengguna, baik dari
+// pengumuman kelas maupun dari kejadian sistem (kelulusan, permintaan guru,
+// keputusan terbit buku, tenggat tugas).
+type Notification struct {
+	ID     uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID uuid.UUID `gorm:"type:uuid;not null;index:idx_notification_user_created" json:"user_id"`
+
+	Type  string `gorm:"size:50;not null" json:"type"`
+	Title string `gorm:"size:200;not null" json:"title"`
+	Body  string `gorm:"type:text" json:"body,omitempty"`
+
+	// ClassID dan RefID menunjuk objek terkait, mis. pengumuman, item,
+	// tugas, buku, atau permintaan guru
+	ClassID *uuid.UUID `gorm:"type:uuid" json:"class_id,omitempty"`
+	RefID   *uuid.UUID `gorm:"type:uuid;index" json:"ref_id,omitempty"`
+
+	ReadAt *time.Time `json:"read_at,omitempty"`
+
+	CreatedAt time.Time `gorm:"index:idx_notification_user_created" json:"created_at"`
+}
+
+func (n *Notification) BeforeCreate(tx *gorm.DB) error {
+	n.ID = uuid.New()
+	return nil
+}
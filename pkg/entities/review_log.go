@@ -27,5 +27,13 @@ type ReviewLog struct {
 
 	IntervalDays int `gorm:"not null"`
 
+	// JAWABAN KETIK (opsional, hanya item buku)
+	TypedAnswer      string  `gorm:"type:text"`
+	SuggestedRating  int     `gorm:"not null;default:0"` // 0 = tidak ada jawaban diketik
+	AnswerSimilarity float64 `gorm:"not null;default:0"`
+
+	// PENILAIAN GURU (setoran): guru yang menilai, nil = penilaian sendiri
+	AssessedBy *uuid.UUID `gorm:"type:uuid;index"`
+
 	CreatedAt time.Time
 }
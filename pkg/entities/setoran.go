@@ -0,0 +1,38 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Setoran adalah sesi setoran hafalan: guru kelas mendengarkan bacaan siswa
+// untuk satu item lalu memberi nilai. Nilai guru masuk ke state FSRS item
+// seperti review biasa (ReviewLog.AssessedBy = guru).
+type Setoran struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	ClassID   uuid.UUID `gorm:"type:uuid;not null;index" json:"class_id"`
+	TeacherID uuid.UUID `gorm:"type:uuid;not null" json:"teacher_id"`
+	StudentID uuid.UUID `gorm:"type:uuid;not null;index" json:"student_id"`
+	ItemID    uuid.UUID `gorm:"type:uuid;not null;index" json:"item_id"`
+
+	ReviewLogID *uuid.UUID `gorm:"type:uuid" json:"review_log_id,omitempty"`
+	ContentRef  string     `gorm:"not null" json:"content_ref"`
+
+	Grade    int    `gorm:"not null" json:"grade"`              // 1=ulang, 2=kurang lancar, 3=lancar, 4=mutqin (rating FSRS)
+	Mistakes int    `gorm:"not null;default:0" json:"mistakes"` // jumlah kesalahan
+	Notes    string `gorm:"type:text" json:"notes,omitempty"`
+
+	// State item setelah setoran
+	StatusAfter  string     `gorm:"size:20" json:"status_after"`
+	NextReviewAt *time.Time `json:"next_review_at,omitempty"`
+
+	RecordedAt time.Time `gorm:"not null;index" json:"recorded_at"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (s *Setoran) BeforeCreate(tx *gorm.DB) error {
+	s.ID = uuid.New()
+	return nil
+}
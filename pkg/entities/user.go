@@ -13,6 +13,11 @@ type User struct {
 	Email    string `gorm:"size:100;uniqueIndex;not null" json:"email"`
 	Password string `gorm:"size:255;not null" json:"-"`
 
+	// Username untuk santri tanpa email (dibuat lewat impor roster kelas)
+	Username *string `gorm:"size:50;uniqueIndex" json:"username,omitempty"`
+	// MustChangePassword aktif untuk akun dengan password sekali pakai
+	MustChangePassword bool `gorm:"not null;default:false" json:"must_change_password"`
+
 	Role     string `gorm:"size:10;not null" json:"role"`
 	IsActive bool   `gorm:"not null;default:false" json:"is_active"`
 
@@ -26,7 +31,8 @@ type User struct {
 	IsPremium bool `gorm:"not null;default:false" json:"is_premium"`
 
 	// ===== PROFILE =====
-	FullName  string `gorm:"size:100" json:"full_name"`
+	FullName     string `gorm:"size:100" json:"full_name"`
+	GuardianName string `gorm:"size:100" json:"guardian_name,omitempty"` // wali santri
 
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
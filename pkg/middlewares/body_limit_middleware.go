@@ -0,0 +1,74 @@
+package middlewares
+
+import (
+	"io"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// BodyLimit reads the request body into memory, up to routeLimits for the
+// request path or defaultLimit otherwise, and rejects larger bodies with
+// 413. The server must stream request bodies (fiber.Config
+// StreamRequestBody) so nothing is buffered before this runs.
+//
+// Keys of routeLimits are full route paths in which a ":param" segment
+// matches any single segment.
+func BodyLimit(defaultLimit int, routeLimits map[string]int) fiber.Handler {
+	type route struct {
+		segments []string
+		limit    int
+	}
+	routes := make([]route, 0, len(routeLimits))
+	for path, limit := range routeLimits {
+		routes = append(routes, route{segments: splitPath(path), limit: limit})
+	}
+
+	return func(c *fiber.Ctx) error {
+		req := c.Request()
+		if !req.IsBodyStream() {
+			if len(req.Body()) > defaultLimit {
+				return fiber.ErrRequestEntityTooLarge
+			}
+			return c.Next()
+		}
+
+		limit := defaultLimit
+		path := splitPath(c.Path())
+		for _, r := range routes {
+			if matchPath(r.segments, path) {
+				limit = r.limit
+				break
+			}
+		}
+
+		body, err := io.ReadAll(io.LimitReader(req.BodyStream(), int64(limit)+1))
+		if err != nil {
+			c.Context().SetConnectionClose()
+			return fiber.ErrBadRequest
+		}
+		if len(body) > limit {
+			// The rest of the body is not read, so the connection can't be reused
+			c.Context().SetConnectionClose()
+			return fiber.ErrRequestEntityTooLarge
+		}
+		req.SetBody(body)
+		return c.Next()
+	}
+}
+
+func splitPath(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
+
+func matchPath(pattern, path []string) bool {
+	if len(pattern) != len(path) {
+		return false
+	}
+	for i, segment := range pattern {
+		if !strings.HasPrefix(segment, ":") && segment != path[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -11,7 +11,19 @@ import (
 	"github.com/google/uuid"
 )
 
+// JWTAuth authenticates the Bearer token. Tokens of accounts that still have
+// to replace their one-time password are rejected with 403.
 func JWTAuth() fiber.Handler {
+	return jwtAuth(false)
+}
+
+// JWTAuthAllowingPasswordChange is JWTAuth that also accepts tokens carrying
+// must_change_password. Only the change-password route uses it.
+func JWTAuthAllowingPasswordChange() fiber.Handler {
+	return jwtAuth(true)
+}
+
+func jwtAuth(allowPasswordChange bool) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		authHeader := c.Get("Authorization")
 		if authHeader == "" {
@@ -83,6 +95,16 @@ func JWTAuth() fiber.Handler {
 			)
 		}
 
+		if mustChange, _ := claims["must_change_password"].(bool); mustChange && !allowPasswordChange {
+			return utils.Error(
+				c,
+				fiber.StatusForbidden,
+				"password must be changed before continuing",
+				"PASSWORD_CHANGE_REQUIRED",
+				nil,
+			)
+		}
+
 		// simpan ke context
 		c.Locals("user_id", userID)
 		c.Locals("email", claims["email"])
@@ -0,0 +1,39 @@
+package repositories
+
+import (
+	"hifzhun-api/pkg/entities"
+
+	"gorm.io/gorm"
+)
+
+type BookActivityRepository struct {
+	db *gorm.DB
+}
+
+func NewBookActivityRepository(db *gorm.DB) *BookActivityRepository {
+	return &BookActivityRepository{db}
+}
+
+func (r *BookActivityRepository) Create(a *entities.BookActivity) error {
+	return r.db.Create(a).Error
+}
+
+// FindByBookID lists the activity of a book, most recent first.
+func (r *BookActivityRepository) FindByBookID(bookID string, limit, offset int) ([]entities.BookActivity, int64, error) {
+	var total int64
+	if err := r.db.Model(&entities.BookActivity{}).Where("book_id = ?", bookID).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var list []entities.BookActivity
+	err := r.db.
+		Where("book_id = ?", bookID).
+		Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&list).Error
+	return list, total, err
+}
+
+func (r *BookActivityRepository) DeleteByBookID(bookID string) error {
+	return r.db.Where("book_id = ?", bookID).Delete(&entities.BookActivity{}).Error
+}
@@ -0,0 +1,93 @@
+package repositories
+
+import (
+	"hifzhun-api/pkg/entities"
+
+	"gorm.io/gorm"
+)
+
+type BookCollaboratorRepository struct {
+	db *gorm.DB
+}
+
+func NewBookCollaboratorRepository(db *gorm.DB) *BookCollaboratorRepository {
+	return &BookCollaboratorRepository{db}
+}
+
+func (r *BookCollaboratorRepository) Create(c *entities.BookCollaborator) error {
+	return r.db.Create(c).Error
+}
+
+func (r *BookCollaboratorRepository) Update(c *entities.BookCollaborator) error {
+	return r.db.Save(c).Error
+}
+
+func (r *BookCollaboratorRepository) Delete(id string) error {
+	return r.db.Where("id = ?", id).Delete(&entities.BookCollaborator{}).Error
+}
+
+func (r *BookCollaboratorRepository) DeleteByBookID(bookID string) error {
+	return r.db.Where("book_id = ?", bookID).Delete(&entities.BookCollaborator{}).Error
+}
+
+func (r *BookCollaboratorRepository) FindByID(id string) (*entities.BookCollaborator, error) {
+	var c entities.BookCollaborator
+	err := r.db.Where("id = ?", id).First(&c).Error
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// FindByBookID lists invitations and collaborators of a book, oldest first
+func (r *BookCollaboratorRepository) FindByBookID(bookID string) ([]entities.BookCollaborator, error) {
+	var list []entities.BookCollaborator
+	err := r.db.
+		Where("book_id = ?", bookID).
+		Order("created_at ASC").
+		Find(&list).Error
+	return list, err
+}
+
+func (r *BookCollaboratorRepository) FindByBookAndEmail(bookID, email string) (*entities.BookCollaborator, error) {
+	var c entities.BookCollaborator
+	err := r.db.Where("book_id = ? AND email = ?", bookID, email).First(&c).Error
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// FindAccepted returns the accepted collaboration of userID on a book
+func (r *BookCollaboratorRepository) FindAccepted(bookID, userID string) (*entities.BookCollaborator, error) {
+	var c entities.BookCollaborator
+	err := r.db.
+		Where("book_id = ? AND user_id = ? AND status = ?", bookID, userID, entities.BookCollaboratorStatusAccepted).
+		First(&c).Error
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// FindPendingByEmail lists open invitations for an email, with their book
+func (r *BookCollaboratorRepository) FindPendingByEmail(email string) ([]entities.BookCollaborator, error) {
+	var list []entities.BookCollaborator
+	err := r.db.
+		Preload("Book").
+		Where("email = ? AND status = ?", email, entities.BookCollaboratorStatusPending).
+		Order("created_at DESC").
+		Find(&list).Error
+	return list, err
+}
+
+// FindAcceptedByUser lists the collaborations of a user, with their book
+func (r *BookCollaboratorRepository) FindAcceptedByUser(userID string) ([]entities.BookCollaborator, error) {
+	var list []entities.BookCollaborator
+	err := r.db.
+		Preload("Book").
+		Where("user_id = ? AND status = ?", userID, entities.BookCollaboratorStatusAccepted).
+		Order("accepted_at DESC").
+		Find(&list).Error
+	return list, err
+}
@@ -19,6 +19,11 @@ type BookItemOverrideRepository interface {
 	// FindAllByUser returns every override that belongs to userID.
 	FindAllByUser(userID uuid.UUID) ([]entities.BookItemOverride, error)
 
+	// FindStaleByUser returns userID's overrides whose canonical BookItem has
+	// changed since the override was based on it (book_items.version >
+	// base_version). Items removed from their book are skipped.
+	FindStaleByUser(userID uuid.UUID) ([]entities.BookItemOverride, error)
+
 	// Upsert creates the override if it doesn't exist, updates it if it does.
 	Upsert(override *entities.BookItemOverride) error
 
@@ -73,6 +78,16 @@ func (r *bookItemOverrideRepository) FindAllByUser(userID uuid.UUID) ([]entities
 	return overrides, err
 }
 
+func (r *bookItemOverrideRepository) FindStaleByUser(userID uuid.UUID) ([]entities.BookItemOverride, error) {
+	var overrides []entities.BookItemOverride
+	err := r.db.
+		Joins("JOIN book_items ON book_items.id = book_item_overrides.book_item_id").
+		Where("book_item_overrides.user_id = ? AND book_items.version > book_item_overrides.base_version AND book_items.removed_at IS NULL", userID).
+		Order("book_item_overrides.updated_at DESC").
+		Find(&overrides).Error
+	return overrides, err
+}
+
 func (r *bookItemOverrideRepository) Upsert(override *entities.BookItemOverride) error {
 	existing, err := r.FindByUserAndBookItemID(override.UserID, override.BookItemID)
 	if err != nil {
@@ -12,6 +12,8 @@ type BookItemRepository interface {
 	FindByID(id string) (*entities.BookItem, error)
 	FindByIDs(ids []string) ([]entities.BookItem, error)
 	// FindByBookID returns only canonical items (importer_id IS NULL).
+	// Items removed by an approved content revision are excluded from all
+	// listings; FindByID/FindByIDs still return them.
 	FindByBookID(bookID string) ([]entities.BookItem, error)
 	// FindByBookIDForImporter returns canonical items + items created by importerID.
 	FindByBookIDForImporter(bookID string, importerID uuid.UUID) ([]entities.BookItem, error)
@@ -59,7 +61,7 @@ func (r *bookItemRepository) FindByIDs(ids []string) ([]entities.BookItem, error
 func (r *bookItemRepository) FindByBookID(bookID string) ([]entities.BookItem, error) {
 	var items []entities.BookItem
 	err := r.db.
-		Where("book_id = ? AND importer_id IS NULL", bookID).
+		Where("book_id = ? AND importer_id IS NULL AND removed_at IS NULL", bookID).
 		Order("\"order\" ASC").
 		Find(&items).Error
 	return items, err
@@ -71,7 +73,7 @@ func (r *bookItemRepository) FindByBookID(bookID string) ([]entities.BookItem, e
 func (r *bookItemRepository) FindByBookIDForImporter(bookID string, importerID uuid.UUID) ([]entities.BookItem, error) {
 	var items []entities.BookItem
 	err := r.db.
-		Where("book_id = ? AND (importer_id IS NULL OR importer_id = ?)", bookID, importerID).
+		Where("book_id = ? AND (importer_id IS NULL OR importer_id = ?) AND removed_at IS NULL", bookID, importerID).
 		Order("\"order\" ASC").
 		Find(&items).Error
 	return items, err
@@ -81,7 +83,7 @@ func (r *bookItemRepository) FindByBookIDForImporter(bookID string, importerID u
 func (r *bookItemRepository) FindByModuleID(moduleID string) ([]entities.BookItem, error) {
 	var items []entities.BookItem
 	err := r.db.
-		Where("module_id = ? AND importer_id IS NULL", moduleID).
+		Where("module_id = ? AND importer_id IS NULL AND removed_at IS NULL", moduleID).
 		Order("\"order\" ASC").
 		Find(&items).Error
 	return items, err
@@ -91,7 +93,7 @@ func (r *bookItemRepository) FindByModuleID(moduleID string) ([]entities.BookIte
 func (r *bookItemRepository) FindByModuleIDForImporter(moduleID string, importerID uuid.UUID) ([]entities.BookItem, error) {
 	var items []entities.BookItem
 	err := r.db.
-		Where("module_id = ? AND (importer_id IS NULL OR importer_id = ?)", moduleID, importerID).
+		Where("module_id = ? AND (importer_id IS NULL OR importer_id = ?) AND removed_at IS NULL", moduleID, importerID).
 		Order("\"order\" ASC").
 		Find(&items).Error
 	return items, err
@@ -0,0 +1,53 @@
+package repositories
+
+import (
+	"hifzhun-api/pkg/entities"
+
+	"gorm.io/gorm"
+)
+
+type BookPublishRequestRepository struct {
+	db *gorm.DB
+}
+
+func NewBookPublishRequestRepository(db *gorm.DB) *BookPublishRequestRepository {
+	return &BookPublishRequestRepository{db}
+}
+
+func (r *BookPublishRequestRepository) Create(req *entities.BookPublishRequest) error {
+	return r.db.Create(req).Error
+}
+
+func (r *BookPublishRequestRepository) Update(req *entities.BookPublishRequest) error {
+	return r.db.Save(req).Error
+}
+
+// FindPendingByBookID returns the open publish request of a book
+func (r *BookPublishRequestRepository) FindPendingByBookID(bookID string) (*entities.BookPublishRequest, error) {
+	var req entities.BookPublishRequest
+	err := r.db.
+		Where("book_id = ? AND status = ?", bookID, entities.BookPublishStatusPending).
+		Order("round DESC").
+		First(&req).Error
+	if err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// FindByBookID returns every publish request of a book, oldest round first
+func (r *BookPublishRequestRepository) FindByBookID(bookID string) ([]entities.BookPublishRequest, error) {
+	var reqs []entities.BookPublishRequest
+	err := r.db.Where("book_id = ?", bookID).Order("round ASC").Find(&reqs).Error
+	return reqs, err
+}
+
+func (r *BookPublishRequestRepository) CountByBookID(bookID string) (int64, error) {
+	var count int64
+	err := r.db.Model(&entities.BookPublishRequest{}).Where("book_id = ?", bookID).Count(&count).Error
+	return count, err
+}
+
+func (r *BookPublishRequestRepository) DeleteByBookID(bookID string) error {
+	return r.db.Where("book_id = ?", bookID).Delete(&entities.BookPublishRequest{}).Error
+}
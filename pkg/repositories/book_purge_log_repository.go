@@ -0,0 +1,109 @@
+package repositories
+
+import (
+	"hifzhun-api/pkg/entities"
+
+	"gorm.io/gorm"
+)
+
+type BookPurgeLogRepository struct {
+	db *gorm.DB
+}
+
+func NewBookPurgeLogRepository(db *gorm.DB) *BookPurgeLogRepository {
+	return &BookPurgeLogRepository{db}
+}
+
+// Purge permanently deletes the book of log with everything that belongs to
+// it and records log, all in one transaction, so a failed purge leaves the
+// book whole and a finished one always has its audit record. Classes lose
+// the book together with the assignments that target it.
+func (r *BookPurgeLogRepository) Purge(log *entities.BookPurgeLog) error {
+	bookID := log.BookID.String()
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		assignments := tx.Model(&entities.ClassAssignment{}).Select("id").Where("book_id = ?", bookID)
+		for _, model := range []any{
+			&entities.ClassAssignmentItem{},
+			&entities.ClassAssignmentOverride{},
+			&entities.ClassAssignmentReminder{},
+		} {
+			if err := tx.Where("assignment_id IN (?)", assignments).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("book_id = ?", bookID).Delete(&entities.ClassAssignment{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("book_id = ?", bookID).Delete(&entities.ClassBook{}).Error; err != nil {
+			return err
+		}
+
+		// Progress rows point at the memorization Items, delete them first
+		items := tx.Model(&entities.Item{}).Select("id").
+			Where("source_type = ? AND content_ref LIKE ?", "book", "book:"+bookID+":%")
+		for _, model := range []any{
+			&entities.ClassAssignmentItem{},
+			&entities.Setoran{},
+			&entities.ReviewLog{},
+			&entities.IntervalReviewLog{},
+			&entities.ItemGraduation{},
+			&entities.ReviewState{},
+			&entities.DailyTask{},
+			&entities.FSRSState{},
+			&entities.ItemState{},
+			&entities.EngineControl{},
+			&entities.Card{},
+			&entities.JuzItem{},
+		} {
+			if err := tx.Where("item_id IN (?)", items).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+
+		// Memorization Items and overrides point at book items, delete them next
+		if err := tx.
+			Where("source_type = ? AND content_ref LIKE ?", "book", "book:"+bookID+":%").
+			Delete(&entities.Item{}).Error; err != nil {
+			return err
+		}
+		if err := tx.
+			Where("book_item_id IN (?)", tx.Model(&entities.BookItem{}).Select("id").Where("book_id = ?", bookID)).
+			Delete(&entities.BookItemOverride{}).Error; err != nil {
+			return err
+		}
+		for _, model := range []any{
+			&entities.BookItem{},
+			&entities.BookModule{},
+			&entities.BookReview{},
+			&entities.BookPublishRequest{},
+			&entities.BookRevision{},
+			&entities.BookUpdateRequest{},
+			&entities.ImportedBook{},
+			&entities.BookCollaborator{},
+			&entities.BookActivity{},
+		} {
+			if err := tx.Where("book_id = ?", bookID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("id = ?", bookID).Delete(&entities.Book{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(log).Error
+	})
+}
+
+// FindAll lists purges, most recent first.
+func (r *BookPurgeLogRepository) FindAll(limit, offset int) ([]entities.BookPurgeLog, int64, error) {
+	var total int64
+	if err := r.db.Model(&entities.BookPurgeLog{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var logs []entities.BookPurgeLog
+	err := r.db.
+		Order("purged_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&logs).Error
+	return logs, total, err
+}
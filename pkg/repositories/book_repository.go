@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"encoding/json"
 	"time"
 
 	"hifzhun-api/pkg/entities"
@@ -15,10 +16,63 @@ type BookRepository interface {
 	FindByIDWithRelations(id string) (*entities.Book, error)
 	FindByOwner(ownerID string) ([]entities.Book, error)
 	FindPublished() ([]entities.Book, error)
+	// SearchPublished lists published books for the catalog, one page at a
+	// time, with the popularity count joined from a single aggregate query.
+	SearchPublished(filter PublishedBookFilter) ([]PublishedBookRow, int64, error)
+	UpdateSearchText(id uuid.UUID, searchText string) error
+	// HasCopy reports whether ownerID made a copy-to-draft of sourceBookID.
+	HasCopy(ownerID, sourceBookID string) (bool, error)
 	FindPendingPublish() ([]entities.Book, error)
+	FindRetired() ([]entities.Book, error)
 	Update(book *entities.Book) error
 	UpdateStatus(id, status string) error
 	Delete(id string) error
+	// CreateWithTree creates the book, its book-level items and its nested
+	// modules (with their items) in a single transaction.
+	CreateWithTree(book *entities.Book, items []entities.BookItem, modules []BookTreeModule) error
+	// UpdateTreePositions saves parent_id/order of modules and module_id/order
+	// of items in a single transaction. Other columns are left untouched.
+	UpdateTreePositions(modules []entities.BookModule, items []entities.BookItem) error
+}
+
+// Catalog sort orders
+const (
+	CatalogSortNewest  = "newest"
+	CatalogSortPopular = "popular"
+)
+
+// PublishedBookFilter filters and pages the published catalog. TSQuery is a
+// ready-made tsquery over the normalized search_text. The cursor fields are
+// the sort key of the last row of the previous page.
+type PublishedBookFilter struct {
+	TSQuery  string
+	Category string
+	Tags     []string
+	Sort     string
+	Limit    int
+
+	HasCursor        bool
+	CursorPublished  time.Time
+	CursorTotalAdded int64
+	CursorID         uuid.UUID
+}
+
+// PublishedBookRow is a published book with the number of distinct users
+// memorizing items from it and the number of copies (forks) made of it.
+type PublishedBookRow struct {
+	entities.Book `gorm:"embedded"`
+	TotalAdded    int64   `gorm:"column:total_added"`
+	RatingAverage float64 `gorm:"column:rating_average"`
+	RatingCount   int64   `gorm:"column:rating_count"`
+	ForkCount     int64   `gorm:"column:fork_count"`
+}
+
+// BookTreeModule is a module to be created together with its items and child modules.
+// ParentID/BookID on Module and BookID/ModuleID on Items are filled in by CreateWithTree.
+type BookTreeModule struct {
+	Module   entities.BookModule
+	Items    []entities.BookItem
+	Children []BookTreeModule
 }
 
 type bookRepository struct {
@@ -46,10 +100,10 @@ func (r *bookRepository) FindByIDWithRelations(id string) (*entities.Book, error
 			return db.Order("\"order\" ASC")
 		}).
 		Preload("Modules.Items", func(db *gorm.DB) *gorm.DB {
-			return db.Order("\"order\" ASC")
+			return db.Where("removed_at IS NULL").Order("\"order\" ASC")
 		}).
 		Preload("Items", func(db *gorm.DB) *gorm.DB {
-			return db.Where("module_id IS NULL").Order("\"order\" ASC")
+			return db.Where("module_id IS NULL AND removed_at IS NULL").Order("\"order\" ASC")
 		}).
 		Where("id = ?", id).
 		First(&book).Error
@@ -74,6 +128,89 @@ func (r *bookRepository) FindPublished() ([]entities.Book, error) {
 	return books, err
 }
 
+// bookPopularitySQL counts distinct users per book in one pass over items
+const bookPopularitySQL = `LEFT JOIN (
+	SELECT split_part(content_ref, ':', 2) AS book_id, COUNT(DISTINCT owner_id) AS total_added
+	FROM items
+	WHERE source_type = 'book' AND content_ref LIKE 'book:%'
+	GROUP BY 1
+) pop ON pop.book_id = books.id::text`
+
+// bookRatingSQL aggregates visible review ratings per book
+const bookRatingSQL = `LEFT JOIN (
+	SELECT book_id, AVG(rating) AS rating_average, COUNT(*) AS rating_count
+	FROM book_reviews
+	WHERE hidden = false
+	GROUP BY book_id
+) rating ON rating.book_id = books.id`
+
+// bookForkSQL counts copy-to-draft forks per source book
+const bookForkSQL = `LEFT JOIN (
+	SELECT source_book_id, COUNT(*) AS fork_count
+	FROM books
+	WHERE source_book_id IS NOT NULL
+	GROUP BY source_book_id
+) forks ON forks.source_book_id = books.id`
+
+func (r *bookRepository) SearchPublished(filter PublishedBookFilter) ([]PublishedBookRow, int64, error) {
+	base := r.db.Table("books").
+		Where("books.status = ?", entities.BookStatusPublished)
+	if filter.TSQuery != "" {
+		base = base.Where("to_tsvector('simple', COALESCE(books.search_text, '')) @@ to_tsquery('simple', ?)", filter.TSQuery)
+	}
+	if filter.Category != "" {
+		base = base.Where("books.category = ?", filter.Category)
+	}
+	if len(filter.Tags) > 0 {
+		tags, _ := json.Marshal(filter.Tags)
+		base = base.Where("books.tags @> ?::jsonb", string(tags))
+	}
+
+	var total int64
+	if err := base.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	query := base.Session(&gorm.Session{}).
+		Joins(bookPopularitySQL).
+		Joins(bookRatingSQL).
+		Joins(bookForkSQL).
+		Select("books.*, COALESCE(pop.total_added, 0) AS total_added, " +
+			"COALESCE(rating.rating_average, 0) AS rating_average, COALESCE(rating.rating_count, 0) AS rating_count, " +
+			"COALESCE(forks.fork_count, 0) AS fork_count")
+	publishedAt := "COALESCE(books.published_at, books.created_at)"
+	if filter.Sort == CatalogSortPopular {
+		if filter.HasCursor {
+			query = query.Where("(COALESCE(pop.total_added, 0), "+publishedAt+", books.id) < (?, ?, ?)",
+				filter.CursorTotalAdded, filter.CursorPublished, filter.CursorID)
+		}
+		query = query.Order("total_added DESC").Order(publishedAt + " DESC")
+	} else {
+		if filter.HasCursor {
+			query = query.Where("("+publishedAt+", books.id) < (?, ?)", filter.CursorPublished, filter.CursorID)
+		}
+		query = query.Order(publishedAt + " DESC")
+	}
+
+	var rows []PublishedBookRow
+	err := query.Order("books.id DESC").Limit(filter.Limit).Scan(&rows).Error
+	return rows, total, err
+}
+
+func (r *bookRepository) UpdateSearchText(id uuid.UUID, searchText string) error {
+	return r.db.Model(&entities.Book{}).
+		Where("id = ?", id).
+		UpdateColumn("search_text", searchText).Error
+}
+
+func (r *bookRepository) HasCopy(ownerID, sourceBookID string) (bool, error) {
+	var count int64
+	err := r.db.Model(&entities.Book{}).
+		Where("owner_id = ? AND source_book_id = ?", ownerID, sourceBookID).
+		Count(&count).Error
+	return count > 0, err
+}
+
 func (r *bookRepository) FindPendingPublish() ([]entities.Book, error) {
 	var books []entities.Book
 	err := r.db.
@@ -83,6 +220,15 @@ func (r *bookRepository) FindPendingPublish() ([]entities.Book, error) {
 	return books, err
 }
 
+func (r *bookRepository) FindRetired() ([]entities.Book, error) {
+	var books []entities.Book
+	err := r.db.
+		Where("status = ?", entities.BookStatusRetired).
+		Order("retired_at DESC").
+		Find(&books).Error
+	return books, err
+}
+
 func (r *bookRepository) Update(book *entities.Book) error {
 	return r.db.Save(book).Error
 }
@@ -105,3 +251,70 @@ func (r *bookRepository) UpdateStatus(id, status string) error {
 func (r *bookRepository) Delete(id string) error {
 	return r.db.Where("id = ?", id).Delete(&entities.Book{}).Error
 }
+
+func (r *bookRepository) CreateWithTree(book *entities.Book, items []entities.BookItem, modules []BookTreeModule) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(book).Error; err != nil {
+			return err
+		}
+
+		if err := createTreeItems(tx, book.ID, nil, items); err != nil {
+			return err
+		}
+
+		return createTreeModules(tx, book.ID, nil, modules)
+	})
+}
+
+func createTreeModules(tx *gorm.DB, bookID uuid.UUID, parentID *uuid.UUID, modules []BookTreeModule) error {
+	for i := range modules {
+		node := &modules[i]
+		node.Module.BookID = bookID
+		node.Module.ParentID = parentID
+		if err := tx.Create(&node.Module).Error; err != nil {
+			return err
+		}
+
+		moduleID := node.Module.ID
+		if err := createTreeItems(tx, bookID, &moduleID, node.Items); err != nil {
+			return err
+		}
+		if err := createTreeModules(tx, bookID, &moduleID, node.Children); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func createTreeItems(tx *gorm.DB, bookID uuid.UUID, moduleID *uuid.UUID, items []entities.BookItem) error {
+	for i := range items {
+		items[i].BookID = bookID
+		items[i].ModuleID = moduleID
+		if err := tx.Create(&items[i]).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *bookRepository) UpdateTreePositions(modules []entities.BookModule, items []entities.BookItem) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, m := range modules {
+			err := tx.Model(&entities.BookModule{}).
+				Where("id = ?", m.ID).
+				Updates(map[string]interface{}{"parent_id": m.ParentID, "order": m.Order}).Error
+			if err != nil {
+				return err
+			}
+		}
+		for _, it := range items {
+			err := tx.Model(&entities.BookItem{}).
+				Where("id = ?", it.ID).
+				Updates(map[string]interface{}{"module_id": it.ModuleID, "order": it.Order}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
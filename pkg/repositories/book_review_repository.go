@@ -0,0 +1,157 @@
+package repositories
+
+import (
+	"time"
+
+	"hifzhun-api/pkg/entities"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// BookReviewView is a review with the reviewer's display name.
+type BookReviewView struct {
+	entities.BookReview `gorm:"embedded"`
+	UserName            string `gorm:"column:user_name" json:"user_name"`
+}
+
+// BookRatingSummary aggregates the visible reviews of a book.
+type BookRatingSummary struct {
+	BookID       uuid.UUID `gorm:"column:book_id" json:"book_id"`
+	Average      float64   `gorm:"column:average" json:"average"`
+	Count        int64     `gorm:"column:count" json:"count"`
+	Distribution [5]int64  `gorm:"-" json:"distribution"` // index 0 = 1 star
+}
+
+type BookReviewRepository struct {
+	db *gorm.DB
+}
+
+func NewBookReviewRepository(db *gorm.DB) *BookReviewRepository {
+	return &BookReviewRepository{db}
+}
+
+func (r *BookReviewRepository) FindByID(id string) (*entities.BookReview, error) {
+	var review entities.BookReview
+	err := r.db.Where("id = ?", id).First(&review).Error
+	if err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+// FindByBookAndUser returns nil, nil when the user has not reviewed the book.
+func (r *BookReviewRepository) FindByBookAndUser(bookID, userID uuid.UUID) (*entities.BookReview, error) {
+	var review entities.BookReview
+	err := r.db.Where("book_id = ? AND user_id = ?", bookID, userID).First(&review).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+func (r *BookReviewRepository) Create(review *entities.BookReview) error {
+	return r.db.Create(review).Error
+}
+
+func (r *BookReviewRepository) Update(review *entities.BookReview) error {
+	return r.db.Save(review).Error
+}
+
+func (r *BookReviewRepository) Delete(id uuid.UUID) error {
+	return r.db.Where("id = ?", id).Delete(&entities.BookReview{}).Error
+}
+
+func (r *BookReviewRepository) withUserName() *gorm.DB {
+	return r.db.Table("book_reviews").
+		Select("book_reviews.*, COALESCE(users.full_name, '') AS user_name").
+		Joins("LEFT JOIN users ON users.id = book_reviews.user_id")
+}
+
+// FindVisibleByBookID lists the reviews shown on a book, newest first.
+func (r *BookReviewRepository) FindVisibleByBookID(bookID string, limit, offset int) ([]BookReviewView, error) {
+	var reviews []BookReviewView
+	err := r.withUserName().
+		Where("book_reviews.book_id = ? AND book_reviews.hidden = ?", bookID, false).
+		Order("book_reviews.updated_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Scan(&reviews).Error
+	return reviews, err
+}
+
+// FindForModeration lists reviews for admins, optionally only hidden ones.
+func (r *BookReviewRepository) FindForModeration(hiddenOnly bool, limit, offset int) ([]BookReviewView, int64, error) {
+	query := r.db.Table("book_reviews")
+	if hiddenOnly {
+		query = query.Where("hidden = ?", true)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	list := r.withUserName()
+	if hiddenOnly {
+		list = list.Where("book_reviews.hidden = ?", true)
+	}
+	var reviews []BookReviewView
+	err := list.
+		Order("book_reviews.created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Scan(&reviews).Error
+	return reviews, total, err
+}
+
+// GetSummary aggregates the visible ratings of a book in one query.
+func (r *BookReviewRepository) GetSummary(bookID uuid.UUID) (*BookRatingSummary, error) {
+	type row struct {
+		Rating int
+		Count  int64
+	}
+	var rows []row
+	err := r.db.Model(&entities.BookReview{}).
+		Select("rating, COUNT(*) AS count").
+		Where("book_id = ? AND hidden = ?", bookID, false).
+		Group("rating").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &BookRatingSummary{BookID: bookID}
+	var sum int64
+	for _, rw := range rows {
+		if rw.Rating < 1 || rw.Rating > 5 {
+			continue
+		}
+		summary.Distribution[rw.Rating-1] = rw.Count
+		summary.Count += rw.Count
+		sum += int64(rw.Rating) * rw.Count
+	}
+	if summary.Count > 0 {
+		summary.Average = float64(sum) / float64(summary.Count)
+	}
+	return summary, nil
+}
+
+// SetHidden hides or unhides a review for moderation.
+func (r *BookReviewRepository) SetHidden(review *entities.BookReview, hidden bool, adminID uuid.UUID, reason string, at time.Time) error {
+	review.Hidden = hidden
+	if hidden {
+		review.HiddenReason = reason
+		review.HiddenBy = &adminID
+		review.HiddenAt = &at
+	} else {
+		review.HiddenReason = ""
+		review.HiddenBy = nil
+		review.HiddenAt = nil
+	}
+	return r.db.Model(review).
+		Select("hidden", "hidden_reason", "hidden_by", "hidden_at").
+		Updates(review).Error
+}
@@ -0,0 +1,184 @@
+package repositories
+
+import (
+	"time"
+
+	"hifzhun-api/pkg/entities"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// BookRevisionPlan lists the row changes needed to apply an approved revision.
+// Modules in CreateModules must be ordered parents first.
+type BookRevisionPlan struct {
+	CreateModules   []entities.BookModule
+	UpdateModules   []entities.BookModule
+	DeleteModuleIDs []uuid.UUID
+	CreateItems     []entities.BookItem
+	UpdateItems     []entities.BookItem
+	RemoveItemIDs   []uuid.UUID
+}
+
+type BookRevisionRepository struct {
+	db *gorm.DB
+}
+
+func NewBookRevisionRepository(db *gorm.DB) *BookRevisionRepository {
+	return &BookRevisionRepository{db}
+}
+
+func (r *BookRevisionRepository) Create(rev *entities.BookRevision) error {
+	return r.db.Create(rev).Error
+}
+
+func (r *BookRevisionRepository) FindByID(id string) (*entities.BookRevision, error) {
+	var rev entities.BookRevision
+	err := r.db.Where("id = ?", id).First(&rev).Error
+	if err != nil {
+		return nil, err
+	}
+	return &rev, nil
+}
+
+func (r *BookRevisionRepository) FindByBookID(bookID string) ([]entities.BookRevision, error) {
+	var revs []entities.BookRevision
+	err := r.db.Where("book_id = ?", bookID).Order("created_at DESC").Find(&revs).Error
+	return revs, err
+}
+
+// FindOpenByBookID returns the revision the owner is still working on
+// (draft, pending or rejected). A book has at most one open revision.
+func (r *BookRevisionRepository) FindOpenByBookID(bookID string) (*entities.BookRevision, error) {
+	var rev entities.BookRevision
+	err := r.db.
+		Where("book_id = ? AND status IN ?", bookID, []string{
+			entities.BookRevisionStatusDraft,
+			entities.BookRevisionStatusPending,
+			entities.BookRevisionStatusRejected,
+		}).
+		Order("created_at DESC").
+		First(&rev).Error
+	if err != nil {
+		return nil, err
+	}
+	return &rev, nil
+}
+
+// FindApprovedByBookID returns approved revisions, newest version first
+func (r *BookRevisionRepository) FindApprovedByBookID(bookID string) ([]entities.BookRevision, error) {
+	var revs []entities.BookRevision
+	err := r.db.
+		Where("book_id = ? AND status = ?", bookID, entities.BookRevisionStatusApproved).
+		Order("version DESC").
+		Find(&revs).Error
+	return revs, err
+}
+
+func (r *BookRevisionRepository) FindAllPending() ([]entities.BookRevision, error) {
+	var revs []entities.BookRevision
+	err := r.db.
+		Preload("Book").
+		Where("status = ?", entities.BookRevisionStatusPending).
+		Order("submitted_at ASC").
+		Find(&revs).Error
+	return revs, err
+}
+
+func (r *BookRevisionRepository) Update(rev *entities.BookRevision) error {
+	return r.db.Save(rev).Error
+}
+
+func (r *BookRevisionRepository) Delete(id uuid.UUID) error {
+	return r.db.Where("id = ?", id).Delete(&entities.BookRevision{}).Error
+}
+
+// Apply writes an approved revision to the live book in one transaction.
+// Removed items are kept (removed_at set) so importers' memorization Items
+// can still resolve their content and keep being reviewed. Importer-only
+// items of deleted modules move to the book level.
+func (r *BookRevisionRepository) Apply(book *entities.Book, rev *entities.BookRevision, plan BookRevisionPlan) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := applyBookPlan(tx, plan); err != nil {
+			return err
+		}
+		if err := tx.Save(book).Error; err != nil {
+			return err
+		}
+		return tx.Save(rev).Error
+	})
+}
+
+// ApplyPlan applies row changes to a book that is not published (no
+// revision involved) and saves the book in the same transaction.
+func (r *BookRevisionRepository) ApplyPlan(book *entities.Book, plan BookRevisionPlan) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := applyBookPlan(tx, plan); err != nil {
+			return err
+		}
+		return tx.Save(book).Error
+	})
+}
+
+func applyBookPlan(tx *gorm.DB, plan BookRevisionPlan) error {
+	for i := range plan.CreateModules {
+		if err := tx.Create(&plan.CreateModules[i]).Error; err != nil {
+			return err
+		}
+	}
+	for i := range plan.UpdateModules {
+		if err := tx.Save(&plan.UpdateModules[i]).Error; err != nil {
+			return err
+		}
+	}
+	for i := range plan.CreateItems {
+		if err := tx.Create(&plan.CreateItems[i]).Error; err != nil {
+			return err
+		}
+	}
+	for i := range plan.UpdateItems {
+		if err := tx.Save(&plan.UpdateItems[i]).Error; err != nil {
+			return err
+		}
+	}
+
+	if len(plan.RemoveItemIDs) > 0 {
+		now := time.Now()
+		if err := tx.Model(&entities.BookItem{}).
+			Where("id IN ?", plan.RemoveItemIDs).
+			Updates(map[string]interface{}{"removed_at": now, "module_id": nil}).Error; err != nil {
+			return err
+		}
+	}
+
+	if len(plan.DeleteModuleIDs) > 0 {
+		if err := tx.Model(&entities.BookItem{}).
+			Where("module_id IN ?", plan.DeleteModuleIDs).
+			Update("module_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("id IN ?", plan.DeleteModuleIDs).Delete(&entities.BookModule{}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FindOpenContaining finds the open revision whose draft content holds the
+// module or item with the given ID (kind: "modules" | "items"). Used for rows
+// that only exist in a draft so far.
+func (r *BookRevisionRepository) FindOpenContaining(kind, id string) (*entities.BookRevision, error) {
+	var rev entities.BookRevision
+	err := r.db.
+		Where("status IN ?", []string{
+			entities.BookRevisionStatusDraft,
+			entities.BookRevisionStatusPending,
+			entities.BookRevisionStatusRejected,
+		}).
+		Where("content -> ? @> ?::jsonb", kind, `[{"id":"`+id+`"}]`).
+		First(&rev).Error
+	if err != nil {
+		return nil, err
+	}
+	return &rev, nil
+}
@@ -0,0 +1,106 @@
+package repositories
+
+import (
+	"time"
+
+	"hifzhun-api/pkg/entities"
+
+	"gorm.io/gorm"
+)
+
+type ClassAnnouncementRepository struct {
+	db *gorm.DB
+}
+
+func NewClassAnnouncementRepository(db *gorm.DB) *ClassAnnouncementRepository {
+	return &ClassAnnouncementRepository{db}
+}
+
+func (r *ClassAnnouncementRepository) Create(a *entities.ClassAnnouncement) error {
+	return r.db.Create(a).Error
+}
+
+// Update saves an announcement. NotifiedAt is left alone so an edit cannot
+// undo a delivery claimed in the meantime; see ClaimNotification and
+// ResetNotification.
+func (r *ClassAnnouncementRepository) Update(a *entities.ClassAnnouncement) error {
+	return r.db.Omit("Author", "NotifiedAt").Save(a).Error
+}
+
+func (r *ClassAnnouncementRepository) Delete(id string) error {
+	return r.db.Where("id = ?", id).Delete(&entities.ClassAnnouncement{}).Error
+}
+
+func (r *ClassAnnouncementRepository) DeleteByClassID(classID string) error {
+	return r.db.Where("class_id = ?", classID).Delete(&entities.ClassAnnouncement{}).Error
+}
+
+func (r *ClassAnnouncementRepository) FindByID(id string) (*entities.ClassAnnouncement, error) {
+	var a entities.ClassAnnouncement
+	err := r.db.Preload("Author").Where("id = ?", id).First(&a).Error
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// FindByClass lists the announcements of a class, pinned first and then
+// newest first. With publishedBefore set only announcements published by
+// then are returned; with groupIDs set only those for the whole class or
+// one of the groups.
+func (r *ClassAnnouncementRepository) FindByClass(classID string, publishedBefore *time.Time, groupIDs []string, limit, offset int) ([]entities.ClassAnnouncement, int64, error) {
+	q := r.db.Model(&entities.ClassAnnouncement{}).Where("class_id = ?", classID)
+	if publishedBefore != nil {
+		q = q.Where("publish_at <= ?", *publishedBefore)
+	}
+	if groupIDs != nil {
+		if len(groupIDs) == 0 {
+			q = q.Where("group_id IS NULL")
+		} else {
+			q = q.Where("group_id IS NULL OR group_id IN ?", groupIDs)
+		}
+	}
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var list []entities.ClassAnnouncement
+	err := q.Preload("Author").
+		Order("pinned DESC").
+		Order("publish_at DESC").
+		Limit(limit).Offset(offset).
+		Find(&list).Error
+	return list, total, err
+}
+
+// FindDueForNotification lists published announcements whose students have
+// not been notified yet.
+func (r *ClassAnnouncementRepository) FindDueForNotification(now time.Time) ([]entities.ClassAnnouncement, error) {
+	var list []entities.ClassAnnouncement
+	err := r.db.
+		Where("notified_at IS NULL AND publish_at <= ?", now).
+		Order("publish_at ASC").
+		Find(&list).Error
+	return list, err
+}
+
+// ClaimNotification marks an announcement as notified unless it already is,
+// and reports whether this call did. Only the caller that claims it sends
+// the notifications, so concurrent deliveries cannot notify twice.
+func (r *ClassAnnouncementRepository) ClaimNotification(id string, at time.Time) (bool, error) {
+	res := r.db.Model(&entities.ClassAnnouncement{}).
+		Where("id = ? AND notified_at IS NULL", id).
+		Update("notified_at", at)
+	return res.RowsAffected == 1, res.Error
+}
+
+// ResetNotification clears NotifiedAt of a rescheduled announcement so its
+// students are notified again at the new publish time.
+func (r *ClassAnnouncementRepository) ResetNotification(id string) error {
+	return r.db.Model(&entities.ClassAnnouncement{}).Where("id = ?", id).Update("notified_at", nil).Error
+}
+
+// DeleteByGroupID removes the announcements of a deleted group
+func (r *ClassAnnouncementRepository) DeleteByGroupID(groupID string) error {
+	return r.db.Where("group_id = ?", groupID).Delete(&entities.ClassAnnouncement{}).Error
+}
@@ -0,0 +1,173 @@
+package repositories
+
+import (
+	"time"
+
+	"hifzhun-api/pkg/entities"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ClassAssignmentRepository struct {
+	db *gorm.DB
+}
+
+func NewClassAssignmentRepository(db *gorm.DB) *ClassAssignmentRepository {
+	return &ClassAssignmentRepository{db}
+}
+
+// CreateWithItems creates an assignment together with the students' item
+// links in one transaction.
+func (r *ClassAssignmentRepository) CreateWithItems(a *entities.ClassAssignment, items []entities.ClassAssignmentItem) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(a).Error; err != nil {
+			return err
+		}
+		return createAssignmentItems(tx, items)
+	})
+}
+
+// DeleteProvisioned removes the Items and Juzs that were created for an
+// assignment that could not be saved, with the Items' juz links.
+func (r *ClassAssignmentRepository) DeleteProvisioned(itemIDs, juzIDs []uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if len(itemIDs) > 0 {
+			if err := tx.Where("item_id IN ?", itemIDs).Delete(&entities.JuzItem{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("id IN ?", itemIDs).Delete(&entities.Item{}).Error; err != nil {
+				return err
+			}
+		}
+		if len(juzIDs) > 0 {
+			return tx.Where("id IN ?", juzIDs).Delete(&entities.Juz{}).Error
+		}
+		return nil
+	})
+}
+
+func (r *ClassAssignmentRepository) Update(a *entities.ClassAssignment) error {
+	return r.db.Omit("Overrides").Save(a).Error
+}
+
+func (r *ClassAssignmentRepository) FindByID(id string) (*entities.ClassAssignment, error) {
+	var a entities.ClassAssignment
+	err := r.db.Preload("Overrides").Where("id = ?", id).First(&a).Error
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// FindByClassID lists the assignments of a class, earliest due first.
+func (r *ClassAssignmentRepository) FindByClassID(classID string) ([]entities.ClassAssignment, error) {
+	var list []entities.ClassAssignment
+	err := r.db.
+		Preload("Overrides").
+		Where("class_id = ?", classID).
+		Order("due_at ASC, created_at ASC").
+		Find(&list).Error
+	return list, err
+}
+
+// FindDueBetween lists assignments due in (from, to], for the class or
+// through a student's extended due date.
+func (r *ClassAssignmentRepository) FindDueBetween(from, to time.Time) ([]entities.ClassAssignment, error) {
+	var list []entities.ClassAssignment
+	extended := r.db.Model(&entities.ClassAssignmentOverride{}).
+		Select("assignment_id").
+		Where("due_at > ? AND due_at <= ?", from, to)
+	err := r.db.
+		Preload("Overrides").
+		Where("(due_at > ? AND due_at <= ?) OR id IN (?)", from, to, extended).
+		Order("due_at ASC").
+		Find(&list).Error
+	return list, err
+}
+
+// Delete removes an assignment with its overrides and item links. The
+// students' Items themselves are kept.
+func (r *ClassAssignmentRepository) Delete(id string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("assignment_id = ?", id).Delete(&entities.ClassAssignmentItem{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("assignment_id = ?", id).Delete(&entities.ClassAssignmentOverride{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("assignment_id = ?", id).Delete(&entities.ClassAssignmentReminder{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", id).Delete(&entities.ClassAssignment{}).Error
+	})
+}
+
+func (r *ClassAssignmentRepository) DeleteByClassID(classID string) error {
+	var ids []string
+	if err := r.db.Model(&entities.ClassAssignment{}).Where("class_id = ?", classID).Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := r.Delete(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *ClassAssignmentRepository) FindOverride(assignmentID, userID string) (*entities.ClassAssignmentOverride, error) {
+	var o entities.ClassAssignmentOverride
+	err := r.db.Where("assignment_id = ? AND user_id = ?", assignmentID, userID).First(&o).Error
+	if err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+func (r *ClassAssignmentRepository) SaveOverride(o *entities.ClassAssignmentOverride) error {
+	return r.db.Save(o).Error
+}
+
+func (r *ClassAssignmentRepository) DeleteOverride(assignmentID, userID string) error {
+	return r.db.Where("assignment_id = ? AND user_id = ?", assignmentID, userID).Delete(&entities.ClassAssignmentOverride{}).Error
+}
+
+// ClaimReminder records that a student is reminded of an assignment unless
+// they already were, and reports whether this call did. Only the caller
+// that claims it sends the reminder.
+func (r *ClassAssignmentRepository) ClaimReminder(assignmentID, userID uuid.UUID, at time.Time) (bool, error) {
+	res := r.db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&entities.ClassAssignmentReminder{AssignmentID: assignmentID, UserID: userID, RemindedAt: at})
+	return res.RowsAffected == 1, res.Error
+}
+
+// CreateItems adds item links in one transaction. Links that already exist
+// are left alone, so concurrent provisioning cannot duplicate them.
+func (r *ClassAssignmentRepository) CreateItems(items []entities.ClassAssignmentItem) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return createAssignmentItems(tx, items)
+	})
+}
+
+func createAssignmentItems(tx *gorm.DB, items []entities.ClassAssignmentItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(items, 500).Error
+}
+
+// FindItems lists the item links of an assignment for every student.
+func (r *ClassAssignmentRepository) FindItems(assignmentID string) ([]entities.ClassAssignmentItem, error) {
+	var list []entities.ClassAssignmentItem
+	err := r.db.Where("assignment_id = ?", assignmentID).Find(&list).Error
+	return list, err
+}
+
+// FindItemsByUser lists the item links of an assignment for one student.
+func (r *ClassAssignmentRepository) FindItemsByUser(assignmentID, userID string) ([]entities.ClassAssignmentItem, error) {
+	var list []entities.ClassAssignmentItem
+	err := r.db.Where("assignment_id = ? AND user_id = ?", assignmentID, userID).Find(&list).Error
+	return list, err
+}
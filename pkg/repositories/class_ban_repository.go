@@ -0,0 +1,46 @@
+package repositories
+
+import (
+	"hifzhun-api/pkg/entities"
+
+	"gorm.io/gorm"
+)
+
+type ClassBanRepository struct {
+	db *gorm.DB
+}
+
+func NewClassBanRepository(db *gorm.DB) *ClassBanRepository {
+	return &ClassBanRepository{db}
+}
+
+func (r *ClassBanRepository) Create(ban *entities.ClassBan) error {
+	return r.db.Create(ban).Error
+}
+
+func (r *ClassBanRepository) DeleteByClassAndUser(classID, userID string) error {
+	return r.db.Where("class_id = ? AND user_id = ?", classID, userID).Delete(&entities.ClassBan{}).Error
+}
+
+func (r *ClassBanRepository) DeleteByClassID(classID string) error {
+	return r.db.Where("class_id = ?", classID).Delete(&entities.ClassBan{}).Error
+}
+
+func (r *ClassBanRepository) IsBanned(classID, userID string) (bool, error) {
+	var count int64
+	err := r.db.Model(&entities.ClassBan{}).
+		Where("class_id = ? AND user_id = ?", classID, userID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// FindByClassID lists the bans of a class with the banned user, newest first
+func (r *ClassBanRepository) FindByClassID(classID string) ([]entities.ClassBan, error) {
+	var list []entities.ClassBan
+	err := r.db.
+		Preload("User").
+		Where("class_id = ?", classID).
+		Order("created_at DESC").
+		Find(&list).Error
+	return list, err
+}
@@ -14,7 +14,9 @@ type ClassBookRepository interface {
 	FindByClassAndBook(classID, bookID string) (*entities.ClassBook, error)
 	IsBookAssignedToClass(bookID string) (bool, error)
 	IsBookAccessibleByMember(bookID, userID string) (bool, error)
-	// IsBookAccessibleByTeacher returns true when userID is the guru of any class that contains bookID.
+	// FindClassIDsByBookAndMember lists the classes containing bookID that userID is a member of, oldest first.
+	FindClassIDsByBookAndMember(bookID, userID string) ([]string, error)
+	// IsBookAccessibleByTeacher returns true when userID is the guru or active staff of any class that contains bookID.
 	IsBookAccessibleByTeacher(bookID, userID string) (bool, error)
 	// IsBookOwner returns true when userID is the owner of the book.
 	IsBookOwner(bookID, userID string) (bool, error)
@@ -24,6 +26,9 @@ type ClassBookRepository interface {
 	IsBookImportedByUser(bookID, userID string) (bool, error)
 	FindImportedBooksByUserID(userID string) ([]entities.ImportedBook, error)
 	DeleteImportedBook(userID, bookID string) error
+	FindImportedBook(userID, bookID string) (*entities.ImportedBook, error)
+	// UpdateImportedBookSeenVersion records the book content version whose changelog the importer has seen.
+	UpdateImportedBookSeenVersion(userID, bookID string, version int) error
 	CountByClassID(classID string) (int64, error)
 	Delete(id string) error
 	DeleteByClassID(classID string) error
@@ -86,12 +91,25 @@ func (r *classBookRepository) IsBookAccessibleByMember(bookID, userID string) (b
 	return count > 0, err
 }
 
-// IsBookAccessibleByTeacher returns true when userID is the guru_id of any class that contains bookID.
+func (r *classBookRepository) FindClassIDsByBookAndMember(bookID, userID string) ([]string, error) {
+	var classIDs []string
+	err := r.db.Model(&entities.ClassBook{}).
+		Joins("JOIN class_members ON class_members.class_id = class_books.class_id").
+		Where("class_books.book_id = ? AND class_members.user_id = ?", bookID, userID).
+		Order("class_members.joined_at ASC").
+		Pluck("class_books.class_id", &classIDs).Error
+	return classIDs, err
+}
+
+// IsBookAccessibleByTeacher returns true when userID is the guru_id or an
+// active staff member of any class that contains bookID.
 func (r *classBookRepository) IsBookAccessibleByTeacher(bookID, userID string) (bool, error) {
 	var count int64
 	err := r.db.Model(&entities.ClassBook{}).
 		Joins("JOIN classes ON classes.id = class_books.class_id").
-		Where("class_books.book_id = ? AND classes.guru_id = ?", bookID, userID).
+		Where("class_books.book_id = ?", bookID).
+		Where("classes.guru_id = ? OR EXISTS (SELECT 1 FROM class_staffs WHERE class_staffs.class_id = classes.id AND class_staffs.user_id = ? AND class_staffs.status = ?)",
+			userID, userID, entities.ClassStaffStatusActive).
 		Count(&count).Error
 	return count > 0, err
 }
@@ -141,6 +159,21 @@ func (r *classBookRepository) DeleteImportedBook(userID, bookID string) error {
 	return r.db.Where("user_id = ? AND book_id = ?", userID, bookID).Delete(&entities.ImportedBook{}).Error
 }
 
+func (r *classBookRepository) FindImportedBook(userID, bookID string) (*entities.ImportedBook, error) {
+	var imported entities.ImportedBook
+	err := r.db.Where("user_id = ? AND book_id = ?", userID, bookID).First(&imported).Error
+	if err != nil {
+		return nil, err
+	}
+	return &imported, nil
+}
+
+func (r *classBookRepository) UpdateImportedBookSeenVersion(userID, bookID string, version int) error {
+	return r.db.Model(&entities.ImportedBook{}).
+		Where("user_id = ? AND book_id = ?", userID, bookID).
+		Update("seen_version", version).Error
+}
+
 func (r *classBookRepository) CountByClassID(classID string) (int64, error) {
 	var count int64
 	err := r.db.Model(&entities.ClassBook{}).
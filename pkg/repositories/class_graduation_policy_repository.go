@@ -0,0 +1,49 @@
+package repositories
+
+import (
+	"hifzhun-api/pkg/entities"
+
+	"gorm.io/gorm"
+)
+
+type ClassGraduationPolicyRepository struct {
+	db *gorm.DB
+}
+
+func NewClassGraduationPolicyRepository(db *gorm.DB) *ClassGraduationPolicyRepository {
+	return &ClassGraduationPolicyRepository{db}
+}
+
+func (r *ClassGraduationPolicyRepository) FindByClassID(classID string) (*entities.ClassGraduationPolicy, error) {
+	var policy entities.ClassGraduationPolicy
+	if err := r.db.Where("class_id = ?", classID).First(&policy).Error; err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// FindByClassIDs returns the policies of the given classes keyed by class ID.
+// Classes without a policy are absent from the map.
+func (r *ClassGraduationPolicyRepository) FindByClassIDs(classIDs []string) (map[string]entities.ClassGraduationPolicy, error) {
+	result := make(map[string]entities.ClassGraduationPolicy)
+	if len(classIDs) == 0 {
+		return result, nil
+	}
+	var policies []entities.ClassGraduationPolicy
+	if err := r.db.Where("class_id IN ?", classIDs).Find(&policies).Error; err != nil {
+		return nil, err
+	}
+	for _, p := range policies {
+		result[p.ClassID.String()] = p
+	}
+	return result, nil
+}
+
+// Save creates the policy or updates the existing one.
+func (r *ClassGraduationPolicyRepository) Save(policy *entities.ClassGraduationPolicy) error {
+	return r.db.Save(policy).Error
+}
+
+func (r *ClassGraduationPolicyRepository) DeleteByClassID(classID string) error {
+	return r.db.Where("class_id = ?", classID).Delete(&entities.ClassGraduationPolicy{}).Error
+}
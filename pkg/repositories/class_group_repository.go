@@ -0,0 +1,109 @@
+package repositories
+
+import (
+	"hifzhun-api/pkg/entities"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ClassGroupRepository struct {
+	db *gorm.DB
+}
+
+func NewClassGroupRepository(db *gorm.DB) *ClassGroupRepository {
+	return &ClassGroupRepository{db}
+}
+
+func (r *ClassGroupRepository) Create(group *entities.ClassGroup) error {
+	return r.db.Create(group).Error
+}
+
+func (r *ClassGroupRepository) Update(group *entities.ClassGroup) error {
+	return r.db.Omit("Staff").Save(group).Error
+}
+
+// Delete removes a group and its member placements
+func (r *ClassGroupRepository) Delete(id string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("group_id = ?", id).Delete(&entities.ClassGroupMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", id).Delete(&entities.ClassGroup{}).Error
+	})
+}
+
+func (r *ClassGroupRepository) DeleteByClassID(classID string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("class_id = ?", classID).Delete(&entities.ClassGroupMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("class_id = ?", classID).Delete(&entities.ClassGroup{}).Error
+	})
+}
+
+func (r *ClassGroupRepository) FindByID(id string) (*entities.ClassGroup, error) {
+	var group entities.ClassGroup
+	err := r.db.Preload("Staff").Where("id = ?", id).First(&group).Error
+	if err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+// FindByClassID lists the groups of a class with their staff, by name
+func (r *ClassGroupRepository) FindByClassID(classID string) ([]entities.ClassGroup, error) {
+	var list []entities.ClassGroup
+	err := r.db.
+		Preload("Staff").
+		Where("class_id = ?", classID).
+		Order("name ASC").
+		Find(&list).Error
+	return list, err
+}
+
+// ClearStaff unassigns a staff member from every group of a class
+func (r *ClassGroupRepository) ClearStaff(classID, staffID string) error {
+	return r.db.Model(&entities.ClassGroup{}).
+		Where("class_id = ? AND staff_id = ?", classID, staffID).
+		Update("staff_id", nil).Error
+}
+
+// SetMember places a student in a group, moving them out of their previous one
+func (r *ClassGroupRepository) SetMember(member *entities.ClassGroupMember) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("class_id = ? AND user_id = ?", member.ClassID, member.UserID).
+			Delete(&entities.ClassGroupMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(member).Error
+	})
+}
+
+func (r *ClassGroupRepository) DeleteMember(classID, userID string) error {
+	return r.db.Where("class_id = ? AND user_id = ?", classID, userID).Delete(&entities.ClassGroupMember{}).Error
+}
+
+// FindMember returns the group placement of a student in a class
+func (r *ClassGroupRepository) FindMember(classID, userID string) (*entities.ClassGroupMember, error) {
+	var member entities.ClassGroupMember
+	err := r.db.Where("class_id = ? AND user_id = ?", classID, userID).First(&member).Error
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+func (r *ClassGroupRepository) FindMembersByClassID(classID string) ([]entities.ClassGroupMember, error) {
+	var list []entities.ClassGroupMember
+	err := r.db.Where("class_id = ?", classID).Find(&list).Error
+	return list, err
+}
+
+func (r *ClassGroupRepository) FindMemberUserIDs(groupID string) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.Model(&entities.ClassGroupMember{}).
+		Where("group_id = ?", groupID).
+		Pluck("user_id", &ids).Error
+	return ids, err
+}
@@ -0,0 +1,71 @@
+package repositories
+
+import (
+	"hifzhun-api/pkg/entities"
+
+	"gorm.io/gorm"
+)
+
+type ClassInvitationRepository struct {
+	db *gorm.DB
+}
+
+func NewClassInvitationRepository(db *gorm.DB) *ClassInvitationRepository {
+	return &ClassInvitationRepository{db}
+}
+
+func (r *ClassInvitationRepository) Create(inv *entities.ClassInvitation) error {
+	return r.db.Create(inv).Error
+}
+
+func (r *ClassInvitationRepository) Delete(id string) error {
+	return r.db.Where("id = ?", id).Delete(&entities.ClassInvitation{}).Error
+}
+
+func (r *ClassInvitationRepository) DeleteByClassID(classID string) error {
+	return r.db.Where("class_id = ?", classID).Delete(&entities.ClassInvitation{}).Error
+}
+
+func (r *ClassInvitationRepository) DeleteByClassAndUser(classID, userID string) error {
+	return r.db.Where("class_id = ? AND user_id = ?", classID, userID).Delete(&entities.ClassInvitation{}).Error
+}
+
+func (r *ClassInvitationRepository) FindByID(id string) (*entities.ClassInvitation, error) {
+	var inv entities.ClassInvitation
+	err := r.db.Where("id = ?", id).First(&inv).Error
+	if err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
+
+func (r *ClassInvitationRepository) FindByClassAndUser(classID, userID string) (*entities.ClassInvitation, error) {
+	var inv entities.ClassInvitation
+	err := r.db.Where("class_id = ? AND user_id = ?", classID, userID).First(&inv).Error
+	if err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
+
+// FindByClassID lists the open invitations of a class with their user, oldest first
+func (r *ClassInvitationRepository) FindByClassID(classID string) ([]entities.ClassInvitation, error) {
+	var list []entities.ClassInvitation
+	err := r.db.
+		Preload("User").
+		Where("class_id = ?", classID).
+		Order("created_at ASC").
+		Find(&list).Error
+	return list, err
+}
+
+// FindByUserID lists the open invitations for userID with their class, newest first
+func (r *ClassInvitationRepository) FindByUserID(userID string) ([]entities.ClassInvitation, error) {
+	var list []entities.ClassInvitation
+	err := r.db.
+		Preload("Class").
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&list).Error
+	return list, err
+}
@@ -0,0 +1,51 @@
+package repositories
+
+import (
+	"hifzhun-api/pkg/entities"
+
+	"gorm.io/gorm"
+)
+
+type ClassJoinRequestRepository struct {
+	db *gorm.DB
+}
+
+func NewClassJoinRequestRepository(db *gorm.DB) *ClassJoinRequestRepository {
+	return &ClassJoinRequestRepository{db}
+}
+
+func (r *ClassJoinRequestRepository) Create(req *entities.ClassJoinRequest) error {
+	return r.db.Create(req).Error
+}
+
+func (r *ClassJoinRequestRepository) Update(req *entities.ClassJoinRequest) error {
+	return r.db.Save(req).Error
+}
+
+func (r *ClassJoinRequestRepository) Delete(id string) error {
+	return r.db.Where("id = ?", id).Delete(&entities.ClassJoinRequest{}).Error
+}
+
+func (r *ClassJoinRequestRepository) DeleteByClassID(classID string) error {
+	return r.db.Where("class_id = ?", classID).Delete(&entities.ClassJoinRequest{}).Error
+}
+
+func (r *ClassJoinRequestRepository) FindByClassAndUser(classID, userID string) (*entities.ClassJoinRequest, error) {
+	var req entities.ClassJoinRequest
+	err := r.db.Where("class_id = ? AND user_id = ?", classID, userID).First(&req).Error
+	if err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// FindPendingByClassID lists the pending requests of a class with their user, oldest first
+func (r *ClassJoinRequestRepository) FindPendingByClassID(classID string) ([]entities.ClassJoinRequest, error) {
+	var list []entities.ClassJoinRequest
+	err := r.db.
+		Preload("User").
+		Where("class_id = ? AND status = ?", classID, entities.ClassJoinRequestPending).
+		Order("created_at ASC").
+		Find(&list).Error
+	return list, err
+}
@@ -1,13 +1,21 @@
 package repositories
 
 import (
+	"errors"
+
 	"hifzhun-api/pkg/entities"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+var ErrClassFull = errors.New("class is full")
+
 type ClassMemberRepository interface {
 	Create(member *entities.ClassMember) error
+	// CreateWithinCapacity adds member unless the class already has
+	// maxMembers members (0 = no limit), returning ErrClassFull.
+	CreateWithinCapacity(member *entities.ClassMember, maxMembers int) error
 	FindByClassID(classID string) ([]entities.ClassMember, error)
 	FindByUserID(userID string) ([]entities.ClassMember, error)
 	FindByClassAndUser(classID, userID string) (*entities.ClassMember, error)
@@ -30,6 +38,32 @@ func (r *classMemberRepository) Create(member *entities.ClassMember) error {
 	return r.db.Create(member).Error
 }
 
+// CreateWithinCapacity locks the class row while it counts and inserts, so
+// concurrent joins cannot overfill the class.
+func (r *classMemberRepository) CreateWithinCapacity(member *entities.ClassMember, maxMembers int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var class entities.Class
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id").
+			Where("id = ?", member.ClassID).
+			First(&class).Error; err != nil {
+			return err
+		}
+		if maxMembers > 0 {
+			var count int64
+			if err := tx.Model(&entities.ClassMember{}).
+				Where("class_id = ?", member.ClassID).
+				Count(&count).Error; err != nil {
+				return err
+			}
+			if count >= int64(maxMembers) {
+				return ErrClassFull
+			}
+		}
+		return tx.Create(member).Error
+	})
+}
+
 func (r *classMemberRepository) FindByClassID(classID string) ([]entities.ClassMember, error) {
 	var members []entities.ClassMember
 	err := r.db.
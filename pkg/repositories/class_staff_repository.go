@@ -0,0 +1,102 @@
+package repositories
+
+import (
+	"hifzhun-api/pkg/entities"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ClassStaffRepository struct {
+	db *gorm.DB
+}
+
+func NewClassStaffRepository(db *gorm.DB) *ClassStaffRepository {
+	return &ClassStaffRepository{db}
+}
+
+func (r *ClassStaffRepository) Create(s *entities.ClassStaff) error {
+	return r.db.Create(s).Error
+}
+
+func (r *ClassStaffRepository) Update(s *entities.ClassStaff) error {
+	return r.db.Save(s).Error
+}
+
+func (r *ClassStaffRepository) Delete(id string) error {
+	return r.db.Where("id = ?", id).Delete(&entities.ClassStaff{}).Error
+}
+
+// TransferOwnership saves class with its new owner and removes the new
+// owner's staff row in one transaction. The former owner is added as staff
+// when formerOwnerStaff is given, and otherwise taken off the groups they
+// guided.
+func (r *ClassStaffRepository) TransferOwnership(class *entities.Class, newOwner *entities.ClassStaff, formerOwnerID uuid.UUID, formerOwnerStaff *entities.ClassStaff) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(class).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("id = ?", newOwner.ID).Delete(&entities.ClassStaff{}).Error; err != nil {
+			return err
+		}
+		if formerOwnerStaff != nil {
+			return tx.Create(formerOwnerStaff).Error
+		}
+		return tx.Model(&entities.ClassGroup{}).
+			Where("class_id = ? AND staff_id = ?", class.ID, formerOwnerID).
+			Update("staff_id", nil).Error
+	})
+}
+
+func (r *ClassStaffRepository) DeleteByClassID(classID string) error {
+	return r.db.Where("class_id = ?", classID).Delete(&entities.ClassStaff{}).Error
+}
+
+func (r *ClassStaffRepository) FindByID(id string) (*entities.ClassStaff, error) {
+	var s entities.ClassStaff
+	err := r.db.Where("id = ?", id).First(&s).Error
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *ClassStaffRepository) FindByClassAndUser(classID, userID string) (*entities.ClassStaff, error) {
+	var s entities.ClassStaff
+	err := r.db.Where("class_id = ? AND user_id = ?", classID, userID).First(&s).Error
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// FindByClassID lists invitations and staff of a class with their user, oldest first
+func (r *ClassStaffRepository) FindByClassID(classID string) ([]entities.ClassStaff, error) {
+	var list []entities.ClassStaff
+	err := r.db.
+		Preload("User").
+		Where("class_id = ?", classID).
+		Order("created_at ASC").
+		Find(&list).Error
+	return list, err
+}
+
+// FindActiveByUser lists the classes userID teaches as active staff
+func (r *ClassStaffRepository) FindActiveByUser(userID string) ([]entities.ClassStaff, error) {
+	var list []entities.ClassStaff
+	err := r.db.
+		Where("user_id = ? AND status = ?", userID, entities.ClassStaffStatusActive).
+		Find(&list).Error
+	return list, err
+}
+
+// FindPendingByUser lists open invitations for userID, with their class
+func (r *ClassStaffRepository) FindPendingByUser(userID string) ([]entities.ClassStaff, error) {
+	var list []entities.ClassStaff
+	err := r.db.
+		Preload("Class").
+		Where("user_id = ? AND status = ?", userID, entities.ClassStaffStatusPending).
+		Order("created_at DESC").
+		Find(&list).Error
+	return list, err
+}
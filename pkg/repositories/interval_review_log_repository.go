@@ -1,6 +1,8 @@
 package repositories
 
 import (
+	"time"
+
 	"hifzhun-api/pkg/entities"
 
 	"github.com/google/uuid"
@@ -39,3 +41,17 @@ func (r *IntervalReviewLogRepository) GetAverageRatingByItemID(itemID uuid.UUID)
 
 	return result.Avg, result.Count, err
 }
+
+// FindReviewTimes returns when a user reviewed any of itemIDs in the
+// interval phase in [from, to), oldest first.
+func (r *IntervalReviewLogRepository) FindReviewTimes(userID uuid.UUID, itemIDs []uuid.UUID, from, to time.Time) ([]time.Time, error) {
+	var times []time.Time
+	if len(itemIDs) == 0 {
+		return times, nil
+	}
+	err := r.db.Model(&entities.IntervalReviewLog{}).
+		Where("user_id = ? AND item_id IN ? AND reviewed_at >= ? AND reviewed_at < ?", userID, itemIDs, from, to).
+		Order("reviewed_at ASC").
+		Pluck("reviewed_at", &times).Error
+	return times, err
+}
@@ -13,6 +13,16 @@ type ItemGraduationRepository interface {
 		ctx context.Context,
 		grad *entities.ItemGraduation,
 	) error
+
+	// ListByClass lists the teacher decisions of a class, newest first.
+	// userID filters one student; empty lists every student.
+	ListByClass(
+		ctx context.Context,
+		classID string,
+		userID string,
+		limit int,
+		offset int,
+	) ([]entities.ItemGraduation, int64, error)
 }
 
 type itemGraduationRepository struct {
@@ -29,3 +39,25 @@ func (r *itemGraduationRepository) Create(
 ) error {
 	return r.db.WithContext(ctx).Create(grad).Error
 }
+
+func (r *itemGraduationRepository) ListByClass(
+	ctx context.Context,
+	classID string,
+	userID string,
+	limit int,
+	offset int,
+) ([]entities.ItemGraduation, int64, error) {
+	query := r.db.WithContext(ctx).Model(&entities.ItemGraduation{}).Where("class_id = ?", classID)
+	if userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var grads []entities.ItemGraduation
+	err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&grads).Error
+	return grads, total, err
+}
@@ -38,6 +38,20 @@ func (r *ItemRepository) DeleteBookItemsByBookID(bookID string) error {
 		Delete(&entities.Item{}).Error
 }
 
+// CountBookItemsByBookID counts the memorization Items created from a book
+// and the number of users owning them.
+func (r *ItemRepository) CountBookItemsByBookID(bookID string) (items int64, owners int64, err error) {
+	var row struct {
+		Items  int64
+		Owners int64
+	}
+	err = r.db.Model(&entities.Item{}).
+		Select("COUNT(*) AS items, COUNT(DISTINCT owner_id) AS owners").
+		Where("source_type = ? AND content_ref LIKE ?", "book", "book:"+bookID+":%").
+		Scan(&row).Error
+	return row.Items, row.Owners, err
+}
+
 func (r *ItemRepository) FindByOwnerAndStatus(ownerID uuid.UUID, status string) ([]entities.Item, error) {
 	var items []entities.Item
 	err := r.db.Where("owner_id = ? AND status = ?", ownerID, status).Find(&items).Error
@@ -144,20 +158,6 @@ func (r *ItemRepository) FindByIDs(ids []uuid.UUID) ([]entities.Item, error) {
 }
 
 // FindByOwnerAndSourceType finds items by owner and source_type
-// FindEligibleForGraduation finds items in fsrs_active that have been there for >= thresholdDays
-func (r *ItemRepository) FindEligibleForGraduation(ownerID uuid.UUID, thresholdDays int, now time.Time) ([]entities.Item, error) {
-	cutoff := now.AddDate(0, 0, -thresholdDays)
-	var items []entities.Item
-	// Use fsrs_start_at as the start of fsrs_active phase (day 1).
-	// Fallback to interval_end_at for backward compatibility if fsrs_start_at is NULL.
-	err := r.db.
-		Where("owner_id = ? AND status = ? AND source_type = 'quran'", ownerID, entities.ItemStatusFSRSActive).
-		Where("review_count >= ?", 5).
-		Where("(fsrs_start_at IS NOT NULL AND fsrs_start_at <= ?) OR (fsrs_start_at IS NULL AND interval_end_at IS NOT NULL AND interval_end_at <= ?)", cutoff, cutoff).
-		Find(&items).Error
-	return items, err
-}
-
 func (r *ItemRepository) FindByOwnerAndSourceType(ownerID uuid.UUID, sourceType string) ([]entities.Item, error) {
 	var items []entities.Item
 	err := r.db.Where("owner_id = ? AND source_type = ?", ownerID, sourceType).
@@ -166,16 +166,6 @@ func (r *ItemRepository) FindByOwnerAndSourceType(ownerID uuid.UUID, sourceType
 	return items, err
 }
 
-// CountDistinctOwnersByBookID counts how many unique users have added items from a specific book
-func (r *ItemRepository) CountDistinctOwnersByBookID(bookID string) (int64, error) {
-	var count int64
-	err := r.db.Model(&entities.Item{}).
-		Where("source_type = 'book' AND content_ref LIKE ?", "book:"+bookID+":%").
-		Distinct("owner_id").
-		Count(&count).Error
-	return count, err
-}
-
 // FindByOwnerAndBookIDs finds book items owned by user whose content_ref starts with any of the given book IDs.
 func (r *ItemRepository) FindByOwnerAndBookIDs(ownerID uuid.UUID, bookIDs []string) ([]entities.Item, error) {
 	if len(bookIDs) == 0 {
@@ -230,12 +220,24 @@ func (r *ItemRepository) FindClassBookItemIDsByUser(ownerID uuid.UUID) (map[uuid
 	return result, nil
 }
 
-// FindEligibleForGraduationByStability finds fsrs_active Quran items with stability >= threshold
-func (r *ItemRepository) FindEligibleForGraduationByStability(ownerID uuid.UUID, stabilityThreshold float64) ([]entities.Item, error) {
+// FindGraduationCandidates finds fsrs_active Quran items that have been
+// reviewed and are not waiting for a retest. Whether they graduate depends
+// on the graduation policy of their class.
+func (r *ItemRepository) FindGraduationCandidates(ownerID uuid.UUID) ([]entities.Item, error) {
+	var items []entities.Item
+	err := r.db.
+		Where("owner_id = ? AND status = ? AND source_type = 'quran'", ownerID, entities.ItemStatusFSRSActive).
+		Where("review_count > 0 AND retest_at IS NULL").
+		Find(&items).Error
+	return items, err
+}
+
+// FindRetestDue finds items whose teacher retest date is today or earlier.
+func (r *ItemRepository) FindRetestDue(ownerID uuid.UUID, now time.Time) ([]entities.Item, error) {
+	endOfDay := time.Date(now.Year(), now.Month(), now.Day(), 23, 59, 59, 0, now.Location())
 	var items []entities.Item
 	err := r.db.
-		Where("owner_id = ? AND status = ? AND source_type = 'quran' AND stability >= ? AND review_count >= ?",
-			ownerID, entities.ItemStatusFSRSActive, stabilityThreshold, 5).
+		Where("owner_id = ? AND retest_at IS NOT NULL AND retest_at <= ?", ownerID, endOfDay).
 		Find(&items).Error
 	return items, err
 }
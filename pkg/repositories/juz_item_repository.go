@@ -103,6 +103,32 @@ func (r *JuzItemRepository) FindJuzInfoByItemIDs(itemIDs []string) (map[string]J
 	return result, nil
 }
 
+// FindClassIDsByItemIDs returns item_id -> class_id for items in a class juz
+func (r *JuzItemRepository) FindClassIDsByItemIDs(itemIDs []string) (map[string]string, error) {
+	result := make(map[string]string)
+	if len(itemIDs) == 0 {
+		return result, nil
+	}
+	type row struct {
+		ItemID  string `gorm:"column:item_id"`
+		ClassID string `gorm:"column:class_id"`
+	}
+	var rows []row
+	err := r.db.
+		Table("juz_items").
+		Select("juz_items.item_id, juzs.class_id").
+		Joins("JOIN juzs ON juzs.id = juz_items.juz_id").
+		Where("juz_items.item_id IN ? AND juzs.class_id IS NOT NULL", itemIDs).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		result[r.ItemID] = r.ClassID
+	}
+	return result, nil
+}
+
 // JuzItemStatusCount holds per-status item counts for a juz
 type JuzItemStatusCount struct {
 	JuzID  string `gorm:"column:juz_id"`
@@ -122,3 +148,27 @@ func (r *JuzItemRepository) CountItemStatusByJuzIDs(juzIDs []string) ([]JuzItemS
 		Scan(&results).Error
 	return results, err
 }
+
+// JuzItemRef holds the juz placement of a single Quran item
+type JuzItemRef struct {
+	ItemID     string  `gorm:"column:item_id"`
+	OwnerID    string  `gorm:"column:owner_id"`
+	ContentRef string  `gorm:"column:content_ref"`
+	JuzID      string  `gorm:"column:juz_id"`
+	JuzIndex   int     `gorm:"column:juz_index"`
+	ClassID    *string `gorm:"column:class_id"`
+}
+
+// FindAllQuranItemRefs returns every Quran item together with the juz it is filed under
+func (r *JuzItemRepository) FindAllQuranItemRefs() ([]JuzItemRef, error) {
+	var rows []JuzItemRef
+	err := r.db.
+		Table("juz_items").
+		Select("juz_items.item_id, items.owner_id, items.content_ref, juz_items.juz_id, juzs.index AS juz_index, juzs.class_id").
+		Joins("JOIN items ON items.id = juz_items.item_id").
+		Joins("JOIN juzs ON juzs.id = juz_items.juz_id").
+		Where("items.source_type = ?", "quran").
+		Order("juzs.index ASC, items.created_at ASC").
+		Scan(&rows).Error
+	return rows, err
+}
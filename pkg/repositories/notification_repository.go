@@ -0,0 +1,71 @@
+package repositories
+
+import (
+	"time"
+
+	"hifzhun-api/pkg/entities"
+
+	"gorm.io/gorm"
+)
+
+type NotificationRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) *NotificationRepository {
+	return &NotificationRepository{db}
+}
+
+func (r *NotificationRepository) CreateBatch(list []entities.Notification) error {
+	if len(list) == 0 {
+		return nil
+	}
+	return r.db.CreateInBatches(list, 200).Error
+}
+
+// FindByUser lists a user's notifications, newest first, optionally only the
+// unread ones.
+func (r *NotificationRepository) FindByUser(userID string, unreadOnly bool, limit, offset int) ([]entities.Notification, int64, error) {
+	q := r.db.Model(&entities.Notification{}).Where("user_id = ?", userID)
+	if unreadOnly {
+		q = q.Where("read_at IS NULL")
+	}
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var list []entities.Notification
+	err := q.Order("created_at DESC").Limit(limit).Offset(offset).Find(&list).Error
+	return list, total, err
+}
+
+func (r *NotificationRepository) CountUnread(userID string) (int64, error) {
+	var count int64
+	err := r.db.Model(&entities.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// MarkRead marks one notification of a user as read; it reports whether the
+// notification exists.
+func (r *NotificationRepository) MarkRead(id, userID string, at time.Time) (bool, error) {
+	var n entities.Notification
+	if err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&n).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	if n.ReadAt != nil {
+		return true, nil
+	}
+	return true, r.db.Model(&n).Update("read_at", at).Error
+}
+
+func (r *NotificationRepository) MarkAllRead(userID string, at time.Time) (int64, error) {
+	res := r.db.Model(&entities.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", at)
+	return res.RowsAffected, res.Error
+}
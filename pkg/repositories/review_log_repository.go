@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -12,6 +13,7 @@ import (
 type ReviewLogRepository interface {
 	Create(ctx context.Context, log *entities.ReviewLog) error
 	ListByCardID(ctx context.Context, cardID uuid.UUID, limit int) ([]entities.ReviewLog, error)
+	ListReviewTimes(ctx context.Context, userID uuid.UUID, itemIDs []uuid.UUID, from, to time.Time) ([]time.Time, error)
 }
 
 type reviewLogRepository struct {
@@ -49,3 +51,23 @@ func (r *reviewLogRepository) ListByCardID(
 	}
 	return logs, nil
 }
+
+// ListReviewTimes returns when a user reviewed any of itemIDs in [from, to),
+// oldest first.
+func (r *reviewLogRepository) ListReviewTimes(
+	ctx context.Context,
+	userID uuid.UUID,
+	itemIDs []uuid.UUID,
+	from, to time.Time,
+) ([]time.Time, error) {
+	var times []time.Time
+	if len(itemIDs) == 0 {
+		return times, nil
+	}
+	err := r.db.WithContext(ctx).
+		Model(&entities.ReviewLog{}).
+		Where("user_id = ? AND item_id IN ? AND reviewed_at >= ? AND reviewed_at < ?", userID, itemIDs, from, to).
+		Order("reviewed_at ASC").
+		Pluck("reviewed_at", &times).Error
+	return times, err
+}
@@ -0,0 +1,61 @@
+package repositories
+
+import (
+	"time"
+
+	"hifzhun-api/pkg/entities"
+
+	"gorm.io/gorm"
+)
+
+type SetoranRepository struct {
+	db *gorm.DB
+}
+
+func NewSetoranRepository(db *gorm.DB) *SetoranRepository {
+	return &SetoranRepository{db}
+}
+
+func (r *SetoranRepository) Create(s *entities.Setoran) error {
+	return r.db.Create(s).Error
+}
+
+// CreateWithReview saves the reviewed item, its review log and the setoran
+// in one transaction.
+func (r *SetoranRepository) CreateWithReview(s *entities.Setoran, item *entities.Item, log *entities.ReviewLog) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(item).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(log).Error; err != nil {
+			return err
+		}
+		return tx.Create(s).Error
+	})
+}
+
+// FindByClassAndStudent lists a student's setoran in a class, most recent first.
+func (r *SetoranRepository) FindByClassAndStudent(classID, studentID string, limit, offset int) ([]entities.Setoran, int64, error) {
+	q := r.db.Model(&entities.Setoran{}).Where("class_id = ? AND student_id = ?", classID, studentID)
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var list []entities.Setoran
+	err := q.Order("recorded_at DESC").Limit(limit).Offset(offset).Find(&list).Error
+	return list, total, err
+}
+
+// FindByClassBetween lists the setoran of a class recorded in [from, to).
+func (r *SetoranRepository) FindByClassBetween(classID string, from, to time.Time) ([]entities.Setoran, error) {
+	var list []entities.Setoran
+	err := r.db.
+		Where("class_id = ? AND recorded_at >= ? AND recorded_at < ?", classID, from, to).
+		Order("recorded_at ASC").
+		Find(&list).Error
+	return list, err
+}
+
+func (r *SetoranRepository) DeleteByClassID(classID string) error {
+	return r.db.Where("class_id = ?", classID).Delete(&entities.Setoran{}).Error
+}
@@ -10,15 +10,18 @@ import (
 
 var ErrEmailAlreadyExists = errors.New("email already exists")
 var ErrUserNotFound = errors.New("user not found")
+var ErrUsernameAlreadyExists = errors.New("username already exists")
 
 type UserRepository interface {
 	Create(user *entities.User) error
 	FindByEmail(email string) (*entities.User, error)
+	FindByUsername(username string) (*entities.User, error)
 	FindByID(id string) (*entities.User, error)
 	GetAllUsers(role string) ([]entities.User, error)
 	UpdateRole(id string, role string) error
 	ActivateUser(id string) error
 	DeactivateUser(id string) error
+	UpdatePassword(id string, hashedPassword string, mustChange bool) error
 }
 
 type userRepository struct {
@@ -36,6 +39,9 @@ func (r *userRepository) Create(user *entities.User) error {
 		if strings.Contains(err.Error(), "idx_users_email") {
 			return ErrEmailAlreadyExists
 		}
+		if strings.Contains(err.Error(), "idx_users_username") {
+			return ErrUsernameAlreadyExists
+		}
 		return err
 	}
 	return nil
@@ -50,6 +56,15 @@ func (r *userRepository) FindByEmail(email string) (*entities.User, error) {
 	return &user, err
 }
 
+func (r *userRepository) FindByUsername(username string) (*entities.User, error) {
+	var user entities.User
+	err := r.db.Where("username = ?", username).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUserNotFound
+	}
+	return &user, err
+}
+
 func (r *userRepository) FindByID(id string) (*entities.User, error) {
 	var user entities.User
 	err := r.db.Where("id = ?", id).First(&user).Error
@@ -99,3 +114,17 @@ func (r *userRepository) DeactivateUser(id string) error {
 	}
 	return res.Error
 }
+
+func (r *userRepository) UpdatePassword(id string, hashedPassword string, mustChange bool) error {
+	res := r.db.Model(&entities.User{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"password":             hashedPassword,
+			"must_change_password": mustChange,
+		})
+
+	if res.RowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return res.Error
+}
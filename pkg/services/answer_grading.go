@@ -0,0 +1,172 @@
+package services
+
+import (
+	"strings"
+
+	"hifzhun-api/pkg/fsrs"
+	"hifzhun-api/pkg/utils"
+)
+
+// Answers at least this similar to the expected answer count as a slip
+// (Hard) rather than a miss (Again).
+const typedAnswerHardThreshold = 0.8
+
+// MaxTypedAnswerLength is the longest typed answer accepted, in characters.
+const MaxTypedAnswerLength = 2000
+
+// The word diff is quadratic in the number of words, so it is left out when
+// either answer has more words than this.
+const maxAnswerDiffWords = 400
+
+// Answer diff operations
+const (
+	AnswerDiffEqual   = "equal"
+	AnswerDiffMissing = "missing" // in the expected answer, not typed
+	AnswerDiffExtra   = "extra"   // typed, not in the expected answer
+)
+
+// AnswerDiffPart is one word of the word-level diff between the expected
+// and the typed answer.
+type AnswerDiffPart struct {
+	Op   string `json:"op" example:"equal"`
+	Text string `json:"text" example:"الحمد"`
+}
+
+// AnswerGrade is the result of comparing a typed answer with the expected
+// answer of a book item.
+type AnswerGrade struct {
+	Expected        string           `json:"expected"`
+	Typed           string           `json:"typed"`
+	Correct         bool             `json:"correct"`
+	Similarity      float64          `json:"similarity" example:"0.92"` // 0..1 on normalized text
+	SuggestedRating int              `json:"suggested_rating" example:"3"`
+	Diff            []AnswerDiffPart `json:"diff"` // empty when an answer is too long to diff
+}
+
+// normalizeAnswer ignores harakat, hamza forms, case, punctuation and
+// Arabic-Indic digits so only real mistakes count.
+func normalizeAnswer(s string) string {
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case r >= '٠' && r <= '٩':
+			return '0' + (r - '٠')
+		case r >= '۰' && r <= '۹':
+			return '0' + (r - '۰')
+		}
+		return r
+	}, s)
+	return utils.NormalizeSearchText(s)
+}
+
+// GradeTypedAnswer compares typed with expected and suggests an FSRS rating:
+// Easy for an exact match including harakat, Good for a match after
+// normalization, Hard for a close answer and Again otherwise.
+func GradeTypedAnswer(expected, typed string) *AnswerGrade {
+	grade := &AnswerGrade{Expected: expected, Typed: typed}
+
+	want, got := normalizeAnswer(expected), normalizeAnswer(typed)
+	grade.Similarity = answerSimilarity(want, got)
+	if len(strings.Fields(expected)) <= maxAnswerDiffWords && len(strings.Fields(typed)) <= maxAnswerDiffWords {
+		grade.Diff = diffAnswerWords(expected, typed)
+	} else {
+		grade.Diff = []AnswerDiffPart{}
+	}
+
+	switch {
+	case want == got && strings.Join(strings.Fields(expected), " ") == strings.Join(strings.Fields(typed), " "):
+		grade.Correct = true
+		grade.SuggestedRating = int(fsrs.Easy)
+	case want == got:
+		grade.Correct = true
+		grade.SuggestedRating = int(fsrs.Good)
+	case grade.Similarity >= typedAnswerHardThreshold:
+		grade.SuggestedRating = int(fsrs.Hard)
+	default:
+		grade.SuggestedRating = int(fsrs.Again)
+	}
+	return grade
+}
+
+// answerSimilarity is 1 minus the Levenshtein distance over the longer
+// length, computed on runes.
+func answerSimilarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 1
+	}
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return 1 - float64(prev[len(rb)])/float64(longest)
+}
+
+// diffAnswerWords aligns the words of expected and typed (longest common
+// subsequence on normalized words) and keeps the original spelling.
+func diffAnswerWords(expected, typed string) []AnswerDiffPart {
+	want, got := strings.Fields(expected), strings.Fields(typed)
+	wantKey := make([]string, len(want))
+	for i, w := range want {
+		wantKey[i] = normalizeAnswer(w)
+	}
+	gotKey := make([]string, len(got))
+	for i, w := range got {
+		gotKey[i] = normalizeAnswer(w)
+	}
+
+	// lcs[i][j] = LCS length of want[i:] and got[j:]
+	lcs := make([][]int, len(want)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(got)+1)
+	}
+	for i := len(want) - 1; i >= 0; i-- {
+		for j := len(got) - 1; j >= 0; j-- {
+			if wantKey[i] == gotKey[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	diff := make([]AnswerDiffPart, 0, len(want)+len(got))
+	i, j := 0, 0
+	for i < len(want) && j < len(got) {
+		switch {
+		case wantKey[i] == gotKey[j]:
+			diff = append(diff, AnswerDiffPart{Op: AnswerDiffEqual, Text: got[j]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			diff = append(diff, AnswerDiffPart{Op: AnswerDiffMissing, Text: want[i]})
+			i++
+		default:
+			diff = append(diff, AnswerDiffPart{Op: AnswerDiffExtra, Text: got[j]})
+			j++
+		}
+	}
+	for ; i < len(want); i++ {
+		diff = append(diff, AnswerDiffPart{Op: AnswerDiffMissing, Text: want[i]})
+	}
+	for ; j < len(got); j++ {
+		diff = append(diff, AnswerDiffPart{Op: AnswerDiffExtra, Text: got[j]})
+	}
+	return diff
+}
@@ -0,0 +1,110 @@
+package services_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"hifzhun-api/pkg/config"
+	"hifzhun-api/pkg/entities"
+	"hifzhun-api/pkg/services"
+)
+
+func TestGradeTypedAnswer(t *testing.T) {
+	cases := []struct {
+		expected, typed string
+		rating          int
+		correct         bool
+	}{
+		{"الْحَمْدُ لِلَّهِ", "الْحَمْدُ لِلَّهِ", 4, true},
+		{"الْحَمْدُ لِلَّهِ", "الحمد لله", 3, true},       // harakat ignored
+		{"أَحْمَدُ", "احمد", 3, true},                     // hamza forms folded
+		{"Al-Baqarah 2:255", "al baqarah ٢:٢٥٥", 3, true}, // case, punctuation, Arabic-Indic digits
+		{"الحمد لله رب العالمين", "الحمد لله رب العلمين", 2, false},
+		{"kitab", "buku", 1, false},
+	}
+	for _, tc := range cases {
+		grade := services.GradeTypedAnswer(tc.expected, tc.typed)
+		if grade.SuggestedRating != tc.rating || grade.Correct != tc.correct {
+			t.Errorf("grade(%q, %q) = rating %d correct %v (similarity %.2f)",
+				tc.expected, tc.typed, grade.SuggestedRating, grade.Correct, grade.Similarity)
+		}
+	}
+}
+
+func TestGradeTypedAnswerDiff(t *testing.T) {
+	grade := services.GradeTypedAnswer("rabbil alamin ar-rahman", "rabbil ar-rahim ar-rahman")
+	want := []services.AnswerDiffPart{
+		{Op: services.AnswerDiffEqual, Text: "rabbil"},
+		{Op: services.AnswerDiffMissing, Text: "alamin"},
+		{Op: services.AnswerDiffExtra, Text: "ar-rahim"},
+		{Op: services.AnswerDiffEqual, Text: "ar-rahman"},
+	}
+	if len(grade.Diff) != len(want) {
+		t.Fatalf("diff = %+v", grade.Diff)
+	}
+	for i := range want {
+		if grade.Diff[i] != want[i] {
+			t.Errorf("diff[%d] = %+v, want %+v", i, grade.Diff[i], want[i])
+		}
+	}
+}
+
+func TestGradeTypedAnswerSkipsDiffOfLongAnswers(t *testing.T) {
+	expected := strings.Repeat("alhamdu lillah ", 300)
+	grade := services.GradeTypedAnswer(expected, expected)
+	if !grade.Correct || len(grade.Diff) != 0 {
+		t.Errorf("long answer graded %v with %d diff parts", grade.Correct, len(grade.Diff))
+	}
+}
+
+func TestReviewWithTypedAnswerIsLogged(t *testing.T) {
+	env := newTestEnv(t)
+	db := env.db
+	itemRepo := env.itemRepo
+	bookItemRepo := env.bookItemRepo
+	reviewService := env.reviewSvc
+
+	userID, bookID := uuid.New(), uuid.New()
+	bookItem := &entities.BookItem{BookID: bookID, Title: "Kitab", Content: "كِتَابٌ", Answer: "buku"}
+	if err := bookItemRepo.Create(bookItem); err != nil {
+		t.Fatalf("create book item: %v", err)
+	}
+	item := &entities.Item{
+		OwnerID:    userID,
+		SourceType: "book",
+		ContentRef: "book:" + bookID.String() + ":item:" + bookItem.ID.String(),
+		Status:     entities.ItemStatusStart,
+	}
+	if err := itemRepo.Create(item); err != nil {
+		t.Fatalf("create item: %v", err)
+	}
+
+	grade, err := reviewService.CheckTypedAnswer(userID, item.ID, "Buku")
+	if err != nil || grade.SuggestedRating != 3 {
+		t.Fatalf("check = %+v err=%v", grade, err)
+	}
+	if _, err := reviewService.CheckTypedAnswer(uuid.New(), item.ID, "buku"); err == nil {
+		t.Error("another user checked the answer")
+	}
+	if _, err := reviewService.CheckTypedAnswer(userID, item.ID, strings.Repeat("ب", services.MaxTypedAnswerLength+1)); err == nil {
+		t.Error("accepted a typed answer over the length limit")
+	}
+
+	// Rating 0 takes the suggestion
+	result, err := reviewService.ReviewItemWithAnswer(userID, item.ID, 0, "bukan", time.Now().In(config.AppLocation))
+	if err != nil {
+		t.Fatalf("review: %v", err)
+	}
+	if result.AnswerGrade == nil || result.AnswerGrade.Correct {
+		t.Fatalf("unexpected grade %+v", result.AnswerGrade)
+	}
+
+	var logs []entities.ReviewLog
+	db.Find(&logs)
+	if len(logs) != 1 || logs[0].TypedAnswer != "bukan" || logs[0].Rating != result.AnswerGrade.SuggestedRating || logs[0].SuggestedRating == 0 {
+		t.Fatalf("review log %+v", logs)
+	}
+}
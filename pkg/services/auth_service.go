@@ -13,7 +13,7 @@ import (
 type AuthService interface {
 	HashPassword(password string) (string, error)
 	CheckPassword(hash, password string) error
-	GenerateToken(userID uuid.UUID, email, role string) (string, error)
+	GenerateToken(userID uuid.UUID, email, role string, mustChangePassword bool) (string, error)
 }
 
 type authService struct{}
@@ -31,7 +31,10 @@ func (s *authService) CheckPassword(hash, password string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 }
 
-func (s *authService) GenerateToken(userID uuid.UUID, email, role string) (string, error) {
+// GenerateToken signs a JWT for the user. Tokens of accounts that still have
+// to replace their one-time password carry must_change_password, which
+// JWTAuth rejects on every route but change-password.
+func (s *authService) GenerateToken(userID uuid.UUID, email, role string, mustChangePassword bool) (string, error) {
 	secret := os.Getenv("JWT_SECRET")
 	if secret == "" {
 		panic("JWT_SECRET environment variable is not set")
@@ -49,7 +52,10 @@ func (s *authService) GenerateToken(userID uuid.UUID, email, role string) (strin
 		"user_id": userID.String(),
 		"email":   email,
 		"role":    role,
-		"exp":     time.Now().Add(time.Hour * time.Duration(expireHours)).Unix(),
+
+		"must_change_password": mustChangePassword,
+
+		"exp": time.Now().Add(time.Hour * time.Duration(expireHours)).Unix(),
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -0,0 +1,440 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"hifzhun-api/pkg/anki"
+	"hifzhun-api/pkg/config"
+	"hifzhun-api/pkg/entities"
+	"hifzhun-api/pkg/fsrs"
+	"hifzhun-api/pkg/repositories"
+	"hifzhun-api/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+// AnkiImportOptions controls how an Anki package is turned into a book
+type AnkiImportOptions struct {
+	// Title overrides the book title (default: the common root deck name)
+	Title string
+	// WithReviewHistory creates the user's memorization Items with an FSRS
+	// state replayed from the Anki review log
+	WithReviewHistory bool
+}
+
+type AnkiImportResult struct {
+	Book                 *entities.Book `json:"book"`
+	ModulesCreated       int            `json:"modules_created"`
+	ItemsCreated         int            `json:"items_created"`
+	ImagesImported       int            `json:"images_imported"`
+	ProgressItemsCreated int            `json:"progress_items_created"`
+	Warnings             []string       `json:"warnings"`
+}
+
+// AnkiReviewState is the FSRS state reached by replaying a card's Anki reviews
+type AnkiReviewState struct {
+	Stability    float64
+	Difficulty   float64
+	ReviewCount  int
+	FirstReview  time.Time
+	LastReview   time.Time
+	NextReviewAt time.Time
+}
+
+var (
+	ankiImageSrcRe  = regexp.MustCompile(`(?i)<img[^>]*\ssrc\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s>]+))`)
+	ankiLineBreakRe = regexp.MustCompile(`(?i)<br\s*/?>|</(?:div|p|li|h[1-6])>`)
+	ankiTagRe       = regexp.MustCompile(`<[^>]*>`)
+	ankiSoundRe     = regexp.MustCompile(`\[sound:[^\]]*\]`)
+	ankiBlankLineRe = regexp.MustCompile(`\n{3,}`)
+)
+
+// Field names (lower-case) recognised when mapping a note to a book item.
+// Notes of unknown types fall back to field position.
+var (
+	ankiTitleFields   = []string{"title", "judul"}
+	ankiContentFields = []string{"front", "question", "pertanyaan", "soal", "text", "content", "konten"}
+	ankiAnswerFields  = []string{"back", "answer", "jawaban", "back extra", "extra"}
+)
+
+// CleanAnkiField converts an Anki field (HTML) to plain text and returns the
+// image file names it references, in order.
+func CleanAnkiField(field string) (string, []string) {
+	var images []string
+	for _, m := range ankiImageSrcRe.FindAllStringSubmatch(field, -1) {
+		src := m[1] + m[2] + m[3]
+		if src = strings.TrimSpace(html.UnescapeString(src)); src != "" {
+			images = append(images, src)
+		}
+	}
+
+	text := ankiLineBreakRe.ReplaceAllString(field, "\n")
+	text = ankiTagRe.ReplaceAllString(text, "")
+	text = ankiSoundRe.ReplaceAllString(text, "")
+	text = html.UnescapeString(text)
+	text = strings.ReplaceAll(text, "\u00a0", " ")
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	text = ankiBlankLineRe.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+
+	return strings.TrimSpace(text), images
+}
+
+// AnkiNoteToBookItem maps the fields of a note to a book item. Fields are
+// matched by name first (Front/Back, Question/Answer, Text/Extra, ...), then
+// by position: first field is the content, second the answer. The title
+// falls back to the first line of the content.
+func AnkiNoteToBookItem(fieldNames, fields []string) (BookTransferItem, []string) {
+	var item BookTransferItem
+	var images []string
+
+	cleaned := make([]string, len(fields))
+	for i, f := range fields {
+		text, imgs := CleanAnkiField(f)
+		cleaned[i] = text
+		images = append(images, imgs...)
+	}
+
+	used := make(map[int]bool)
+	find := func(candidates []string) int {
+		for _, candidate := range candidates {
+			for i, name := range fieldNames {
+				if i < len(cleaned) && !used[i] && strings.EqualFold(strings.TrimSpace(name), candidate) {
+					used[i] = true
+					return i
+				}
+			}
+		}
+		return -1
+	}
+	next := func() int {
+		for i := range cleaned {
+			if !used[i] {
+				used[i] = true
+				return i
+			}
+		}
+		return -1
+	}
+
+	titleIdx := find(ankiTitleFields)
+	contentIdx := find(ankiContentFields)
+	answerIdx := find(ankiAnswerFields)
+	if contentIdx < 0 {
+		contentIdx = next()
+	}
+	if answerIdx < 0 {
+		answerIdx = next()
+	}
+
+	if contentIdx >= 0 {
+		item.Content = cleaned[contentIdx]
+	}
+	if answerIdx >= 0 {
+		item.Answer = cleaned[answerIdx]
+	}
+	if titleIdx >= 0 {
+		item.Title = firstLine(cleaned[titleIdx])
+	}
+	if item.Title == "" {
+		item.Title = firstLine(item.Content)
+	}
+	if item.Title == "" {
+		item.Title = firstLine(item.Answer)
+	}
+
+	return item, images
+}
+
+func firstLine(text string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(text), "\n")
+	line = strings.TrimSpace(line)
+	if utf8.RuneCountInString(line) > maxBookTitleLength {
+		line = string([]rune(line)[:maxBookTitleLength-3]) + "..."
+	}
+	return line
+}
+
+// ReplayAnkiReviews runs a card's Anki answers through the same FSRS model
+// used by ItemReviewService.ReviewItem. Manual entries (reschedules, resets)
+// are skipped. ok is false when the card has no usable reviews.
+func ReplayAnkiReviews(reviews []anki.ReviewEntry, loc *time.Location) (AnkiReviewState, bool) {
+	entries := make([]anki.ReviewEntry, 0, len(reviews))
+	for _, r := range reviews {
+		if r.Ease < int(fsrs.Again) || r.Ease > int(fsrs.Easy) || r.Type == anki.ReviewTypeManual {
+			continue
+		}
+		entries = append(entries, r)
+	}
+	if len(entries) == 0 {
+		return AnkiReviewState{}, false
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+
+	weights := fsrs.DefaultWeights()
+	state := fsrs.CardState{Stability: 0.4, Difficulty: 5.0}
+	var interval time.Duration
+	for _, r := range entries {
+		result := fsrs.Review(state, fsrs.Rating(r.Ease), r.ReviewedAt().In(loc), weights)
+		state = result.NewState
+		interval = result.Interval
+	}
+
+	next := state.LastReview.Add(interval)
+	next = time.Date(next.Year(), next.Month(), next.Day(), 0, 0, 0, 0, next.Location())
+
+	return AnkiReviewState{
+		Stability:    state.Stability,
+		Difficulty:   state.Difficulty,
+		ReviewCount:  len(entries),
+		FirstReview:  entries[0].ReviewedAt().In(loc),
+		LastReview:   state.LastReview,
+		NextReviewAt: next,
+	}, true
+}
+
+// ankiDeckNode is a module being built from the deck hierarchy
+type ankiDeckNode struct {
+	title    string
+	items    []entities.BookItem
+	notes    []int64
+	children []*ankiDeckNode
+	byTitle  map[string]*ankiDeckNode
+}
+
+func (n *ankiDeckNode) child(title string) *ankiDeckNode {
+	if c, ok := n.byTitle[title]; ok {
+		return c
+	}
+	c := &ankiDeckNode{title: title, byTitle: make(map[string]*ankiDeckNode)}
+	n.byTitle[title] = c
+	n.children = append(n.children, c)
+	return c
+}
+
+// ankiItemRef points at a book item slot inside the tree passed to CreateWithTree
+type ankiItemRef struct {
+	items  []entities.BookItem
+	index  int
+	noteID int64
+}
+
+// ImportAnkiPackage creates a new draft book from an Anki package: decks
+// become (nested) modules and notes become items. Images are copied into
+// item image storage for premium users only.
+func (s *bookService) ImportAnkiPackage(ownerID uuid.UUID, pkg *anki.Package, opts AnkiImportOptions) (*AnkiImportResult, error) {
+	if len(pkg.Notes) == 0 {
+		return nil, errors.New("the Anki package does not contain any notes")
+	}
+
+	user, err := s.userRepo.FindByID(ownerID.String())
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	result := &AnkiImportResult{Warnings: []string{}}
+
+	// A note lives in the deck of its first card; the card with most
+	// repetitions carries its review history.
+	noteDeck := make(map[int64]int64)
+	noteCard := make(map[int64]anki.Card)
+	for _, card := range pkg.Cards {
+		if _, ok := noteDeck[card.NoteID]; !ok {
+			noteDeck[card.NoteID] = card.DeckID
+		}
+		if best, ok := noteCard[card.NoteID]; !ok || card.Reps > best.Reps {
+			noteCard[card.NoteID] = card
+		}
+	}
+
+	// Strip a root deck shared by every note, it becomes the book title.
+	paths := make(map[int64][]string)
+	commonRoot := ""
+	for i, note := range pkg.Notes {
+		path := pkg.Decks[noteDeck[note.ID]].Path()
+		paths[note.ID] = path
+		root := ""
+		if len(path) > 0 {
+			root = path[0]
+		}
+		if i == 0 {
+			commonRoot = root
+		} else if root != commonRoot {
+			commonRoot = ""
+		}
+	}
+
+	title := strings.TrimSpace(opts.Title)
+	if title == "" {
+		title = commonRoot
+	}
+	if title == "" {
+		title = "Anki Import"
+	}
+	title = firstLine(title)
+
+	root := &ankiDeckNode{byTitle: make(map[string]*ankiDeckNode)}
+	uploaded := make(map[string]string)
+	skippedImages := 0
+	for _, note := range pkg.Notes {
+		noteType := pkg.NoteTypes[note.ModelID]
+		transfer, images := AnkiNoteToBookItem(noteType.Fields, note.Fields)
+		if transfer.Title == "" && len(images) == 0 {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("note %d skipped: all fields are empty", note.ID))
+			continue
+		}
+
+		imageURL := ""
+		for _, name := range images {
+			if !pkg.HasMedia(name) {
+				if unescaped, err := url.PathUnescape(name); err == nil && pkg.HasMedia(unescaped) {
+					name = unescaped
+				} else {
+					continue
+				}
+			}
+			if !user.IsPremium {
+				skippedImages++
+				break
+			}
+			if u, ok := uploaded[strings.ToLower(name)]; ok {
+				imageURL = u
+				break
+			}
+			data, err := pkg.Media(name)
+			if err == nil {
+				imageURL, err = utils.SaveImageBytes(name, data)
+			}
+			if err != nil {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("note %d: image %s not imported: %s", note.ID, name, err.Error()))
+				continue
+			}
+			uploaded[strings.ToLower(name)] = imageURL
+			result.ImagesImported++
+			break
+		}
+
+		if transfer.Title == "" {
+			transfer.Title = fmt.Sprintf("Card %d", result.ItemsCreated+1)
+		}
+
+		node := root
+		path := paths[note.ID]
+		if commonRoot != "" && len(path) > 0 {
+			path = path[1:]
+		}
+		for _, part := range path {
+			node = node.child(firstLine(part))
+		}
+		node.items = append(node.items, entities.BookItem{
+			Title:    transfer.Title,
+			Content:  transfer.Content,
+			Answer:   transfer.Answer,
+			Order:    len(node.items) + 1,
+			ImageURL: imageURL,
+		})
+		node.notes = append(node.notes, note.ID)
+		result.ItemsCreated++
+	}
+	if skippedImages > 0 {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("%d images skipped: item images are only available for premium users", skippedImages))
+	}
+	if result.ItemsCreated == 0 {
+		return nil, errors.New("the Anki package does not contain any importable notes")
+	}
+
+	var refs []ankiItemRef
+	collect := func(n *ankiDeckNode) []entities.BookItem {
+		items := n.items
+		if items == nil {
+			items = []entities.BookItem{}
+		}
+		for i, noteID := range n.notes {
+			refs = append(refs, ankiItemRef{items: items, index: i, noteID: noteID})
+		}
+		return items
+	}
+	var toModules func(nodes []*ankiDeckNode) []repositories.BookTreeModule
+	toModules = func(nodes []*ankiDeckNode) []repositories.BookTreeModule {
+		// Anki lists sibling decks alphabetically
+		sort.SliceStable(nodes, func(i, j int) bool { return nodes[i].title < nodes[j].title })
+		modules := make([]repositories.BookTreeModule, 0, len(nodes))
+		for i, n := range nodes {
+			modules = append(modules, repositories.BookTreeModule{
+				Module:   entities.BookModule{Title: n.title, Order: i + 1},
+				Items:    collect(n),
+				Children: toModules(n.children),
+			})
+			result.ModulesCreated++
+		}
+		return modules
+	}
+	bookItems := collect(root)
+	modules := toModules(root.children)
+
+	book := &entities.Book{
+		OwnerID: ownerID,
+		Title:   title,
+		Status:  entities.BookStatusDraft,
+	}
+	if err := s.bookRepo.CreateWithTree(book, bookItems, modules); err != nil {
+		return nil, err
+	}
+	result.Book = book
+
+	if !opts.WithReviewHistory {
+		return result, nil
+	}
+
+	reviewsByCard := make(map[int64][]anki.ReviewEntry)
+	for _, r := range pkg.Reviews {
+		reviewsByCard[r.CardID] = append(reviewsByCard[r.CardID], r)
+	}
+
+	for _, ref := range refs {
+		card, ok := noteCard[ref.noteID]
+		if !ok || card.Type == anki.CardTypeNew {
+			continue
+		}
+		state, ok := ReplayAnkiReviews(reviewsByCard[card.ID], config.AppLocation)
+		if !ok {
+			continue
+		}
+
+		bookItem := ref.items[ref.index]
+		item := &entities.Item{
+			OwnerID:      ownerID,
+			SourceType:   "book",
+			ContentRef:   "book:" + book.ID.String() + ":item:" + bookItem.ID.String(),
+			Status:       entities.ItemStatusFSRSActive,
+			Stability:    state.Stability,
+			Difficulty:   state.Difficulty,
+			ReviewCount:  state.ReviewCount,
+			LastReviewAt: &state.LastReview,
+			NextReviewAt: &state.NextReviewAt,
+			FSRSStartAt:  &state.FirstReview,
+		}
+		// Suspended cards stay paused
+		if card.Queue == anki.QueueSuspended {
+			item.Status = entities.ItemStatusInactive
+		}
+		if err := s.itemRepo.Create(item); err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("review history of %q not imported: %s", bookItem.Title, err.Error()))
+			continue
+		}
+		result.ProgressItemsCreated++
+	}
+
+	return result, nil
+}
@@ -0,0 +1,133 @@
+package services_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"hifzhun-api/pkg/anki"
+	"hifzhun-api/pkg/services"
+)
+
+// buildTestApkg writes a minimal legacy-schema collection with one note
+// type, two nested decks, two notes and a short review history.
+func buildTestApkg(t *testing.T) []byte {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "collection.anki2")
+	db, err := gorm.Open(sqlite.Open(path), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+
+	models := `{"1001":{"name":"Basic","type":0,"flds":[{"name":"Back","ord":1},{"name":"Front","ord":0}]}}`
+	decks := `{"1":{"name":"Default"},"10":{"name":"Bahasa Arab::Isim"},"11":{"name":"Bahasa Arab::Fi'il"}}`
+	stmts := []string{
+		`CREATE TABLE col (id integer primary key, models text, decks text)`,
+		`CREATE TABLE notes (id integer primary key, mid integer, mod integer, tags text, flds text)`,
+		`CREATE TABLE cards (id integer primary key, nid integer, did integer, ord integer, type integer, queue integer, ivl integer, factor integer, reps integer, lapses integer)`,
+		`CREATE TABLE revlog (id integer primary key, cid integer, ease integer, ivl integer, type integer)`,
+	}
+	for _, stmt := range stmts {
+		if err := db.Exec(stmt).Error; err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+	db.Exec(`INSERT INTO col VALUES (1, ?, ?)`, models, decks)
+	db.Exec(`INSERT INTO notes VALUES (1, 1001, 0, ' vocab ', ?)`, "<b>كِتَابٌ</b><br>kata benda"+anki.FieldSeparator+"buku &amp; kitab <img src=\"kitab.png\">")
+	db.Exec(`INSERT INTO notes VALUES (2, 1001, 0, '', ?)`, "ذَهَبَ"+anki.FieldSeparator+"pergi [sound:dzahaba.mp3]")
+	db.Exec(`INSERT INTO cards VALUES (100, 1, 10, 0, 2, 2, 5, 2500, 3, 0)`)
+	db.Exec(`INSERT INTO cards VALUES (200, 2, 11, 0, 0, 0, 0, 0, 0, 0)`)
+	db.Exec(`INSERT INTO revlog VALUES (1700000000000, 100, 3, 1, 0)`)
+	db.Exec(`INSERT INTO revlog VALUES (1700086400000, 100, 0, 0, 4)`)
+	db.Exec(`INSERT INTO revlog VALUES (1700172800000, 100, 4, 5, 1)`)
+	if sqlDB, err := db.DB(); err == nil {
+		sqlDB.Close()
+	}
+
+	collection, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read collection: %v", err)
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	files := map[string][]byte{
+		"collection.anki2": collection,
+		"media":            []byte(`{"0":"kitab.png"}`),
+		"0":                []byte("not really a png"),
+	}
+	for name, data := range files {
+		w, _ := zw.Create(name)
+		w.Write(data)
+	}
+	zw.Close()
+	return buf.Bytes()
+}
+
+func TestAnkiPackageMapping(t *testing.T) {
+	data := buildTestApkg(t)
+	pkg, err := anki.Open(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	if len(pkg.Notes) != 2 || len(pkg.Cards) != 2 || len(pkg.Reviews) != 3 {
+		t.Fatalf("got %d notes, %d cards, %d reviews", len(pkg.Notes), len(pkg.Cards), len(pkg.Reviews))
+	}
+	if got := pkg.Decks[10].Path(); len(got) != 2 || got[1] != "Isim" {
+		t.Fatalf("deck path = %v", got)
+	}
+	if !pkg.HasMedia("KITAB.png") {
+		t.Fatal("expected media kitab.png")
+	}
+
+	note := pkg.Notes[0]
+	item, images := services.AnkiNoteToBookItem(pkg.NoteTypes[note.ModelID].Fields, note.Fields)
+	if item.Content != "كِتَابٌ\nkata benda" || item.Answer != "buku & kitab" || item.Title != "كِتَابٌ" {
+		t.Fatalf("unexpected item %+v", item)
+	}
+	if len(images) != 1 || images[0] != "kitab.png" {
+		t.Fatalf("images = %v", images)
+	}
+
+	// Unknown field names fall back to position
+	item, _ = services.AnkiNoteToBookItem([]string{"Kata", "Arti"}, pkg.Notes[1].Fields)
+	if item.Content != "ذَهَبَ" || item.Answer != "pergi" {
+		t.Fatalf("positional mapping %+v", item)
+	}
+}
+
+func TestReplayAnkiReviews(t *testing.T) {
+	if _, ok := services.ReplayAnkiReviews([]anki.ReviewEntry{{ID: 1700000000000, Ease: 0, Type: anki.ReviewTypeManual}}, time.UTC); ok {
+		t.Fatal("manual entries alone must not produce a state")
+	}
+
+	state, ok := services.ReplayAnkiReviews([]anki.ReviewEntry{
+		{ID: 1700172800000, Ease: 4, Type: anki.ReviewTypeReview},
+		{ID: 1700000000000, Ease: 3, Type: anki.ReviewTypeLearn},
+		{ID: 1700086400000, Ease: 0, Type: anki.ReviewTypeManual},
+	}, time.UTC)
+	if !ok {
+		t.Fatal("expected a replayed state")
+	}
+	if state.ReviewCount != 2 {
+		t.Fatalf("review count = %d", state.ReviewCount)
+	}
+	if !state.FirstReview.Equal(time.UnixMilli(1700000000000)) || !state.LastReview.Equal(time.UnixMilli(1700172800000)) {
+		t.Fatalf("first=%s last=%s", state.FirstReview, state.LastReview)
+	}
+	if state.Stability <= 0 || !state.NextReviewAt.After(state.LastReview) {
+		t.Fatalf("unexpected state %+v", state)
+	}
+	if h, m, s := state.NextReviewAt.Clock(); h != 0 || m != 0 || s != 0 {
+		t.Fatalf("next review not normalized to midnight: %s", state.NextReviewAt)
+	}
+}
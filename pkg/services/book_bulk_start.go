@@ -0,0 +1,149 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"hifzhun-api/pkg/config"
+	"hifzhun-api/pkg/entities"
+
+	"github.com/google/uuid"
+)
+
+// BulkStartInput selects the book items to start: a module (ModuleID, with
+// its child modules when Recursive) or the whole book (ModuleID nil).
+// PerDay > 0 staggers the start: the first PerDay items are due today, the
+// next PerDay tomorrow, and so on.
+type BulkStartInput struct {
+	ModuleID  *uuid.UUID
+	Recursive bool
+	PerDay    int
+}
+
+// BulkStartResult summarizes a bulk start. Items lists the newly started
+// book items in tree order.
+type BulkStartResult struct {
+	Started         int                       `json:"started"`
+	AlreadyStarted  int                       `json:"already_started"`
+	SkippedInactive int                       `json:"skipped_inactive"`
+	Items           []StartMemorizationResult `json:"items"`
+}
+
+// StartBulkMemorization starts every item of a module or book for userID in
+// tree order (items of a module before its child modules). Items the user
+// already started are left as they are, and deactivated items stay inactive.
+func (s *bookService) StartBulkMemorization(userID uuid.UUID, bookID string, in BulkStartInput) (*BulkStartResult, error) {
+	if in.PerDay < 0 {
+		return nil, errors.New("per_day cannot be negative")
+	}
+
+	book, err := s.memorizationBook(userID, bookID)
+	if err != nil {
+		return nil, err
+	}
+
+	modules, err := s.bookModuleRepo.FindByBookID(bookID)
+	if err != nil {
+		return nil, err
+	}
+	var items []entities.BookItem
+	if book.OwnerID == userID {
+		items, err = s.bookItemRepo.FindByBookID(bookID)
+	} else {
+		items, err = s.bookItemRepo.FindByBookIDForImporter(bookID, userID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Tree order: items of a module (by order), then its child modules
+	childrenByParent := make(map[string][]string)
+	moduleExists := make(map[string]bool, len(modules))
+	for _, m := range modules {
+		parentKey := ""
+		if m.ParentID != nil {
+			parentKey = m.ParentID.String()
+		}
+		childrenByParent[parentKey] = append(childrenByParent[parentKey], m.ID.String())
+		moduleExists[m.ID.String()] = true
+	}
+	itemsByModule := make(map[string][]entities.BookItem)
+	for _, it := range items {
+		key := ""
+		if it.ModuleID != nil {
+			key = it.ModuleID.String()
+		}
+		itemsByModule[key] = append(itemsByModule[key], it)
+	}
+
+	var selected []entities.BookItem
+	var collect func(key string, recursive bool)
+	collect = func(key string, recursive bool) {
+		selected = append(selected, itemsByModule[key]...)
+		if !recursive {
+			return
+		}
+		for _, child := range childrenByParent[key] {
+			collect(child, true)
+		}
+	}
+	if in.ModuleID == nil {
+		collect("", true)
+	} else {
+		if !moduleExists[in.ModuleID.String()] {
+			return nil, errors.New("module not found")
+		}
+		collect(in.ModuleID.String(), in.Recursive)
+	}
+
+	// The user's existing Items for this book, by content_ref
+	existing, err := s.itemRepo.FindByOwnerAndBookIDs(userID, []string{bookID})
+	if err != nil {
+		return nil, err
+	}
+	existingByRef := make(map[string][]entities.Item)
+	for _, it := range existing {
+		existingByRef[it.ContentRef] = append(existingByRef[it.ContentRef], it)
+	}
+
+	now := time.Now().In(config.AppLocation)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, config.AppLocation)
+
+	result := &BulkStartResult{Items: []StartMemorizationResult{}}
+	for i := range selected {
+		bookItem := &selected[i]
+		rows := existingByRef["book:"+bookID+":item:"+bookItem.ID.String()]
+
+		inactive := false
+		for _, row := range rows {
+			if row.Status == entities.ItemStatusInactive {
+				inactive = true
+			}
+		}
+		if inactive {
+			result.SkippedInactive++
+			continue
+		}
+
+		var startAt *time.Time
+		if in.PerDay > 0 {
+			if day := result.Started / in.PerDay; day > 0 {
+				t := today.AddDate(0, 0, day)
+				startAt = &t
+			}
+		}
+
+		started, changed, err := s.startBookItemUnits(userID, book, bookItem, rows, startAt)
+		if err != nil {
+			return nil, err
+		}
+		if !changed {
+			result.AlreadyStarted++
+			continue
+		}
+		result.Started++
+		result.Items = append(result.Items, *started)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,80 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+
+	"hifzhun-api/pkg/entities"
+	"hifzhun-api/pkg/services"
+)
+
+func TestStartBulkMemorization(t *testing.T) {
+	env := newTestEnv(t)
+	bookRepo := env.bookRepo
+	itemRepo := env.itemRepo
+	svc := env.bookSvc
+
+	ownerID := uuid.New()
+	book := &entities.Book{OwnerID: ownerID, Title: "Jurumiyah", Status: entities.BookStatusDraft}
+	if err := bookRepo.Create(book); err != nil {
+		t.Fatalf("create book: %v", err)
+	}
+	bookID := book.ID.String()
+
+	bab, _ := svc.AddModule(bookID, ownerID, "Bab Kalam", "", 1, nil)
+	fasl, _ := svc.AddModule(bookID, ownerID, "Fasl", "", 1, &bab.ID)
+	add := func(moduleID *uuid.UUID, title string, order int) *entities.BookItem {
+		it, err := svc.AddItem(bookID, moduleID, ownerID, title, title, "", order, 0, "", "", nil)
+		if err != nil {
+			t.Fatalf("add item: %v", err)
+		}
+		return it
+	}
+	add(nil, "Muqaddimah", 1)
+	a := add(&bab.ID, "A", 1)
+	b := add(&bab.ID, "B", 2)
+	add(&fasl.ID, "C", 1)
+	add(&fasl.ID, "D", 2)
+
+	if _, err := svc.StartBulkMemorization(uuid.New(), bookID, services.BulkStartInput{}); err == nil {
+		t.Error("stranger started a private book")
+	}
+
+	// A already started, B deactivated
+	if _, err := svc.StartItemMemorization(ownerID, bookID, a.ID.String()); err != nil {
+		t.Fatalf("start A: %v", err)
+	}
+	started, err := svc.StartItemMemorization(ownerID, bookID, b.ID.String())
+	if err != nil {
+		t.Fatalf("start B: %v", err)
+	}
+	inactive, _ := itemRepo.GetByID(started.ItemID)
+	inactive.Status = entities.ItemStatusInactive
+	if err := itemRepo.Update(inactive); err != nil {
+		t.Fatalf("deactivate B: %v", err)
+	}
+
+	result, err := svc.StartBulkMemorization(ownerID, bookID, services.BulkStartInput{ModuleID: &bab.ID})
+	if err != nil {
+		t.Fatalf("bulk start module: %v", err)
+	}
+	if result.Started != 0 || result.AlreadyStarted != 1 || result.SkippedInactive != 1 {
+		t.Fatalf("non-recursive module start %+v", result)
+	}
+
+	result, err = svc.StartBulkMemorization(ownerID, bookID, services.BulkStartInput{PerDay: 2})
+	if err != nil {
+		t.Fatalf("bulk start book: %v", err)
+	}
+	if result.Started != 3 || result.AlreadyStarted != 1 || result.SkippedInactive != 1 {
+		t.Fatalf("book start %+v", result)
+	}
+	// Muqaddimah and C today, D tomorrow
+	if result.Items[0].ItemTitle != "Muqaddimah" || result.Items[0].StartDate != "" || result.Items[2].ItemTitle != "D" || result.Items[2].StartDate == "" {
+		t.Fatalf("staggered items %+v", result.Items)
+	}
+	if reloaded, _ := itemRepo.GetByID(inactive.ID); reloaded.Status != entities.ItemStatusInactive {
+		t.Errorf("inactive item became %s", reloaded.Status)
+	}
+}
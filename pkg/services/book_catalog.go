@@ -0,0 +1,256 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"hifzhun-api/pkg/entities"
+	"hifzhun-api/pkg/repositories"
+	"hifzhun-api/pkg/utils"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+const (
+	catalogDefaultLimit = 20
+	catalogMaxLimit     = 100
+
+	maxBookTags      = 10
+	maxBookTagLength = 30
+	maxCategoryLen   = 50
+)
+
+// CatalogQuery is the published catalog request: free-text search over book
+// title/description and item title/content, category and tag filters, sort
+// (newest | popular) and cursor paging.
+type CatalogQuery struct {
+	Q        string
+	Category string
+	Tags     []string
+	Sort     string
+	Cursor   string
+	Limit    int
+}
+
+// IsDefault reports whether the query is the unfiltered first page, the only
+// page worth caching.
+func (q CatalogQuery) IsDefault() bool {
+	return strings.TrimSpace(q.Q) == "" && q.Category == "" && len(q.Tags) == 0 &&
+		(q.Sort == "" || q.Sort == repositories.CatalogSortNewest) && q.Cursor == "" &&
+		(q.Limit <= 0 || q.Limit == catalogDefaultLimit)
+}
+
+// PublishedBookPage is one page of the published catalog.
+type PublishedBookPage struct {
+	Books []PublishedBookWithStats `json:"books"`
+	Meta  utils.Meta               `json:"meta"`
+}
+
+// catalogCursor is the sort key of the last book on a page.
+type catalogCursor struct {
+	Sort        string    `json:"s"`
+	PublishedAt time.Time `json:"p"`
+	TotalAdded  int64     `json:"n,omitempty"`
+	ID          uuid.UUID `json:"id"`
+}
+
+func encodeCatalogCursor(c catalogCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCatalogCursor(s string) (*catalogCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	var c catalogCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == uuid.Nil {
+		return nil, errors.New("invalid cursor")
+	}
+	return &c, nil
+}
+
+// NormalizeCatalogTags lowercases, trims and de-duplicates tags.
+func NormalizeCatalogTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool, len(tags))
+	result := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.Join(strings.Fields(tag), " "))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if len([]rune(tag)) > maxBookTagLength {
+			return nil, errors.New("tags must be 30 characters or less")
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+	if len(result) > maxBookTags {
+		return nil, errors.New("a book can have at most 10 tags")
+	}
+	return result, nil
+}
+
+func normalizeCategory(category string) string {
+	return strings.ToLower(strings.Join(strings.Fields(category), " "))
+}
+
+// buildBookSearchText joins the searchable text of a book into one
+// normalized string for the search_text column.
+func buildBookSearchText(book *entities.Book, items []entities.BookItem) string {
+	parts := make([]string, 0, 2+len(items)*2)
+	parts = append(parts, book.Title, book.Description)
+	for _, it := range items {
+		parts = append(parts, it.Title, it.Content)
+	}
+	return utils.NormalizeSearchText(strings.Join(parts, "\n"))
+}
+
+// refreshSearchText rebuilds the catalog search text after the book or its
+// canonical items changed.
+func (s *bookService) refreshSearchText(book *entities.Book) error {
+	items, err := s.bookItemRepo.FindByBookID(book.ID.String())
+	if err != nil {
+		return err
+	}
+	book.SearchText = buildBookSearchText(book, items)
+	return s.bookRepo.UpdateSearchText(book.ID, book.SearchText)
+}
+
+// BackfillSearchText fills the search text of published books that were
+// published before catalog search existed.
+func (s *bookService) BackfillSearchText() (int, error) {
+	books, err := s.bookRepo.FindPublished()
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for i := range books {
+		if books[i].SearchText != "" {
+			continue
+		}
+		if err := s.refreshSearchText(&books[i]); err != nil {
+			log.Printf("⚠️ Failed to index book %s: %v", books[i].ID, err)
+			continue
+		}
+		count++
+	}
+	return count, nil
+}
+
+func (s *bookService) GetPublishedBooks(query CatalogQuery) (*PublishedBookPage, error) {
+	filter := repositories.PublishedBookFilter{
+		TSQuery:  utils.SearchPrefixQuery(query.Q),
+		Category: normalizeCategory(query.Category),
+		Sort:     query.Sort,
+		Limit:    query.Limit,
+	}
+	if filter.Sort == "" {
+		filter.Sort = repositories.CatalogSortNewest
+	}
+	if filter.Sort != repositories.CatalogSortNewest && filter.Sort != repositories.CatalogSortPopular {
+		return nil, errors.New("sort must be newest or popular")
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = catalogDefaultLimit
+	}
+	if filter.Limit > catalogMaxLimit {
+		filter.Limit = catalogMaxLimit
+	}
+	tags, err := NormalizeCatalogTags(query.Tags)
+	if err != nil {
+		return nil, err
+	}
+	filter.Tags = tags
+
+	if query.Cursor != "" {
+		cursor, err := decodeCatalogCursor(query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if cursor.Sort != filter.Sort {
+			return nil, errors.New("cursor belongs to a different sort order")
+		}
+		filter.HasCursor = true
+		filter.CursorPublished = cursor.PublishedAt
+		filter.CursorTotalAdded = cursor.TotalAdded
+		filter.CursorID = cursor.ID
+	}
+
+	// One extra row tells whether there is a next page
+	limit := filter.Limit
+	filter.Limit++
+	rows, total, err := s.bookRepo.SearchPublished(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &PublishedBookPage{
+		Books: make([]PublishedBookWithStats, 0, len(rows)),
+		Meta:  utils.Meta{PerPage: limit, Total: int(total)},
+	}
+	if len(rows) > limit {
+		rows = rows[:limit]
+		last := rows[len(rows)-1]
+		publishedAt := last.CreatedAt
+		if last.PublishedAt != nil {
+			publishedAt = *last.PublishedAt
+		}
+		page.Meta.HasMore = true
+		page.Meta.NextCursor = encodeCatalogCursor(catalogCursor{
+			Sort:        filter.Sort,
+			PublishedAt: publishedAt,
+			TotalAdded:  last.TotalAdded,
+			ID:          last.ID,
+		})
+	}
+	for _, row := range rows {
+		page.Books = append(page.Books, PublishedBookWithStats{
+			Book:          row.Book,
+			TotalAdded:    row.TotalAdded,
+			RatingAverage: math.Round(row.RatingAverage*10) / 10,
+			RatingCount:   row.RatingCount,
+			ForkCount:     row.ForkCount,
+		})
+	}
+	return page, nil
+}
+
+// UpdateBookCatalogInfo sets the category and tags used by catalog filters.
+// They only describe the book, so unlike content changes of a published book
+// they apply directly.
+func (s *bookService) UpdateBookCatalogInfo(bookID string, ownerID uuid.UUID, category string, tags []string) (*entities.Book, error) {
+	book, err := s.bookRepo.FindByID(bookID)
+	if err != nil {
+		return nil, errors.New("book not found")
+	}
+	if book.OwnerID != ownerID {
+		return nil, errors.New("you don't have permission to update this book")
+	}
+	if err := ensureNotRetired(book); err != nil {
+		return nil, err
+	}
+
+	category = normalizeCategory(category)
+	if len([]rune(category)) > maxCategoryLen {
+		return nil, errors.New("category must be 50 characters or less")
+	}
+	normalized, err := NormalizeCatalogTags(tags)
+	if err != nil {
+		return nil, err
+	}
+
+	book.Category = category
+	book.Tags = datatypes.NewJSONSlice(normalized)
+	if err := s.bookRepo.Update(book); err != nil {
+		return nil, err
+	}
+	return book, nil
+}
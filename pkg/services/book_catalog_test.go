@@ -0,0 +1,53 @@
+package services_test
+
+import (
+	"strings"
+	"testing"
+
+	"hifzhun-api/pkg/services"
+	"hifzhun-api/pkg/utils"
+)
+
+func TestNormalizeSearchText(t *testing.T) {
+	cases := map[string]string{
+		"كِتَابُ التَّوْحِيدِ": "كتاب التوحيد",
+		"أَحْمَد إِمَام آمَن":  "احمد امام امن",
+		"مُؤْمِن، شَيْئ":       "مومن شيي",
+		"مَدْرَسَة":            "مدرسه",
+		"الْعَرَبِيَّةُ ـــ":   "العربيه",
+		"Belajar  TAJWID (1)!": "belajar tajwid 1",
+	}
+	for in, want := range cases {
+		if got := utils.NormalizeSearchText(in); got != want {
+			t.Errorf("NormalizeSearchText(%q) = %q, want %q", in, got, want)
+		}
+	}
+
+	// Search input with different diacritics matches the indexed text
+	indexed := utils.NormalizeSearchText("بِسْمِ اللَّهِ الرَّحْمَٰنِ الرَّحِيمِ")
+	if !strings.Contains(indexed, utils.NormalizeSearchText("الرحمن")) {
+		t.Fatalf("indexed %q does not contain bare query", indexed)
+	}
+}
+
+func TestSearchPrefixQuery(t *testing.T) {
+	if got := utils.SearchPrefixQuery("  Kitab   tauḥ'  "); got != "kitab:* & tauḥ:*" {
+		t.Fatalf("got %q", got)
+	}
+	if got := utils.SearchPrefixQuery("&|!():*"); got != "" {
+		t.Fatalf("operators must not leak into tsquery, got %q", got)
+	}
+}
+
+func TestNormalizeCatalogTags(t *testing.T) {
+	tags, err := services.NormalizeCatalogTags([]string{" Nahwu ", "nahwu", "", "Bahasa  Arab"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(tags, "|") != "nahwu|bahasa arab" {
+		t.Fatalf("tags = %v", tags)
+	}
+	if _, err := services.NormalizeCatalogTags(strings.Split("a,b,c,d,e,f,g,h,i,j,k", ",")); err == nil {
+		t.Fatal("expected too many tags error")
+	}
+}
@@ -0,0 +1,382 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"hifzhun-api/pkg/config"
+	"hifzhun-api/pkg/entities"
+
+	"github.com/google/uuid"
+)
+
+// BookInvitation is an open collaborator invitation shown to the invitee
+type BookInvitation struct {
+	ID          uuid.UUID `json:"id"`
+	BookID      uuid.UUID `json:"book_id"`
+	BookTitle   string    `json:"book_title"`
+	Role        string    `json:"role"`
+	InvitedBy   uuid.UUID `json:"invited_by"`
+	InviterName string    `json:"inviter_name,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// SharedBook is a book the user collaborates on
+type SharedBook struct {
+	entities.Book
+	Role string `json:"role"`
+}
+
+func normalizeCollaboratorRole(role string) (string, error) {
+	role = strings.ToLower(strings.TrimSpace(role))
+	switch role {
+	case "":
+		return entities.BookCollaboratorRoleEditor, nil
+	case entities.BookCollaboratorRoleEditor, entities.BookCollaboratorRoleViewer:
+		return role, nil
+	}
+	return "", errors.New("role must be editor or viewer")
+}
+
+// collaboratorRole returns the accepted collaborator role of userID on the
+// book, or "" (also for the owner).
+func (s *bookService) collaboratorRole(book *entities.Book, userID uuid.UUID) string {
+	if s.collaboratorRepo == nil || book.OwnerID == userID {
+		return ""
+	}
+	c, err := s.collaboratorRepo.FindAccepted(book.ID.String(), userID.String())
+	if err != nil {
+		return ""
+	}
+	return c.Role
+}
+
+// isBookEditor reports whether userID may change the canonical modules and
+// items of the book: its owner or an editor collaborator.
+func (s *bookService) isBookEditor(book *entities.Book, userID uuid.UUID) bool {
+	return book.OwnerID == userID || s.collaboratorRole(book, userID) == entities.BookCollaboratorRoleEditor
+}
+
+// logBookActivity records a change by the owner or a collaborator. Logging
+// never fails the change itself.
+func (s *bookService) logBookActivity(book *entities.Book, userID uuid.UUID, action string, targetID *uuid.UUID, title string) {
+	if s.activityRepo == nil {
+		return
+	}
+	_ = s.activityRepo.Create(&entities.BookActivity{
+		BookID:      book.ID,
+		UserID:      userID,
+		Action:      action,
+		TargetID:    targetID,
+		TargetTitle: title,
+		InDraft:     book.Status == entities.BookStatusPublished && !strings.HasPrefix(action, "collaborator_"),
+		CreatedAt:   time.Now().In(config.AppLocation),
+	})
+}
+
+// issueInvitationToken sets a new random token on the invitation. Only its
+// hash is stored; the token itself is returned once in c.Token.
+func issueInvitationToken(c *entities.BookCollaborator) error {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return err
+	}
+	c.Token = hex.EncodeToString(raw)
+	c.TokenHash = invitationTokenHash(c.Token)
+	return nil
+}
+
+func invitationTokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// collaboratorBook loads a book and checks that ownerID owns it
+func (s *bookService) collaboratorBook(bookID string, ownerID uuid.UUID) (*entities.Book, error) {
+	if s.collaboratorRepo == nil {
+		return nil, errors.New("collaborator repository not available")
+	}
+	book, err := s.bookRepo.FindByID(bookID)
+	if err != nil {
+		return nil, errors.New("book not found")
+	}
+	if book.OwnerID != ownerID {
+		return nil, errors.New("only the book owner can manage collaborators")
+	}
+	return book, nil
+}
+
+// InviteCollaborator invites an email address to co-author the book. The
+// returned invitation carries the token the invitee needs to accept; an
+// account with the same email alone is not enough. Inviting an email again
+// updates the role and, while the invitation is pending, issues a new token.
+func (s *bookService) InviteCollaborator(bookID string, ownerID uuid.UUID, email, role string) (*entities.BookCollaborator, error) {
+	book, err := s.collaboratorBook(bookID, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureNotRetired(book); err != nil {
+		return nil, err
+	}
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" || !strings.Contains(email, "@") {
+		return nil, errors.New("a valid email is required")
+	}
+	role, err = normalizeCollaboratorRole(role)
+	if err != nil {
+		return nil, err
+	}
+	if owner, err := s.userRepo.FindByID(ownerID.String()); err == nil && strings.EqualFold(owner.Email, email) {
+		return nil, errors.New("you already own this book")
+	}
+
+	if existing, err := s.collaboratorRepo.FindByBookAndEmail(bookID, email); err == nil {
+		roleChanged := existing.Role != role
+		pending := existing.Status == entities.BookCollaboratorStatusPending
+		if !roleChanged && !pending {
+			return existing, nil
+		}
+		existing.Role = role
+		if pending {
+			if err := issueInvitationToken(existing); err != nil {
+				return nil, err
+			}
+		}
+		if err := s.collaboratorRepo.Update(existing); err != nil {
+			return nil, err
+		}
+		if roleChanged {
+			s.logBookActivity(book, ownerID, entities.BookActivityCollaboratorRole, &existing.ID, email)
+		}
+		return existing, nil
+	}
+
+	invitation := &entities.BookCollaborator{
+		BookID:    book.ID,
+		Email:     email,
+		Role:      role,
+		Status:    entities.BookCollaboratorStatusPending,
+		InvitedBy: ownerID,
+	}
+	if err := issueInvitationToken(invitation); err != nil {
+		return nil, err
+	}
+	if err := s.collaboratorRepo.Create(invitation); err != nil {
+		return nil, err
+	}
+	s.logBookActivity(book, ownerID, entities.BookActivityCollaboratorInvited, &invitation.ID, email)
+	return invitation, nil
+}
+
+// GetCollaborators lists invitations and collaborators of a book. The owner
+// and accepted collaborators can see the list.
+func (s *bookService) GetCollaborators(bookID string, userID uuid.UUID) ([]entities.BookCollaborator, error) {
+	if s.collaboratorRepo == nil {
+		return nil, errors.New("collaborator repository not available")
+	}
+	book, err := s.bookRepo.FindByID(bookID)
+	if err != nil {
+		return nil, errors.New("book not found")
+	}
+	if book.OwnerID != userID && s.collaboratorRole(book, userID) == "" {
+		return nil, errors.New("you don't have access to this book")
+	}
+	list, err := s.collaboratorRepo.FindByBookID(bookID)
+	if err != nil {
+		return nil, err
+	}
+	if list == nil {
+		list = []entities.BookCollaborator{}
+	}
+	return list, nil
+}
+
+// UpdateCollaboratorRole changes the role of an invitation or collaborator
+func (s *bookService) UpdateCollaboratorRole(bookID string, ownerID uuid.UUID, collaboratorID, role string) (*entities.BookCollaborator, error) {
+	book, err := s.collaboratorBook(bookID, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	role, err = normalizeCollaboratorRole(role)
+	if err != nil {
+		return nil, err
+	}
+	c, err := s.collaboratorRepo.FindByID(collaboratorID)
+	if err != nil || c.BookID != book.ID {
+		return nil, errors.New("collaborator not found")
+	}
+	if c.Role == role {
+		return c, nil
+	}
+	c.Role = role
+	if err := s.collaboratorRepo.Update(c); err != nil {
+		return nil, err
+	}
+	s.logBookActivity(book, ownerID, entities.BookActivityCollaboratorRole, &c.ID, c.Email)
+	return c, nil
+}
+
+// RemoveCollaborator revokes an invitation or removes a collaborator. The
+// owner can remove anyone; a collaborator can remove themselves (leave).
+func (s *bookService) RemoveCollaborator(bookID string, userID uuid.UUID, collaboratorID string) error {
+	if s.collaboratorRepo == nil {
+		return errors.New("collaborator repository not available")
+	}
+	book, err := s.bookRepo.FindByID(bookID)
+	if err != nil {
+		return errors.New("book not found")
+	}
+	c, err := s.collaboratorRepo.FindByID(collaboratorID)
+	if err != nil || c.BookID != book.ID {
+		return errors.New("collaborator not found")
+	}
+	isSelf := c.UserID != nil && *c.UserID == userID
+	if book.OwnerID != userID && !isSelf {
+		return errors.New("only the book owner can manage collaborators")
+	}
+	if err := s.collaboratorRepo.Delete(collaboratorID); err != nil {
+		return err
+	}
+	s.logBookActivity(book, userID, entities.BookActivityCollaboratorRemoved, &c.ID, c.Email)
+	return nil
+}
+
+// GetMyBookInvitations lists open invitations sent to the user's email
+func (s *bookService) GetMyBookInvitations(userID uuid.UUID) ([]BookInvitation, error) {
+	if s.collaboratorRepo == nil {
+		return nil, errors.New("collaborator repository not available")
+	}
+	user, err := s.userRepo.FindByID(userID.String())
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	pending, err := s.collaboratorRepo.FindPendingByEmail(strings.ToLower(user.Email))
+	if err != nil {
+		return nil, err
+	}
+
+	inviterNames := make(map[uuid.UUID]string)
+	invitations := make([]BookInvitation, 0, len(pending))
+	for _, c := range pending {
+		if c.Book == nil {
+			continue
+		}
+		name, ok := inviterNames[c.InvitedBy]
+		if !ok {
+			if inviter, err := s.userRepo.FindByID(c.InvitedBy.String()); err == nil {
+				name = inviter.FullName
+			}
+			inviterNames[c.InvitedBy] = name
+		}
+		invitations = append(invitations, BookInvitation{
+			ID:          c.ID,
+			BookID:      c.BookID,
+			BookTitle:   c.Book.Title,
+			Role:        c.Role,
+			InvitedBy:   c.InvitedBy,
+			InviterName: name,
+			CreatedAt:   c.CreatedAt,
+		})
+	}
+	return invitations, nil
+}
+
+// RespondToBookInvitation accepts or declines an invitation addressed to the
+// user's email. Accepting also needs the invitation token, since emails are
+// not verified. Declined invitations are deleted.
+func (s *bookService) RespondToBookInvitation(invitationID string, userID uuid.UUID, accept bool, token string) (*entities.BookCollaborator, error) {
+	if s.collaboratorRepo == nil {
+		return nil, errors.New("collaborator repository not available")
+	}
+	user, err := s.userRepo.FindByID(userID.String())
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	c, err := s.collaboratorRepo.FindByID(invitationID)
+	if err != nil || !strings.EqualFold(c.Email, user.Email) {
+		return nil, errors.New("invitation not found")
+	}
+	if c.Status != entities.BookCollaboratorStatusPending {
+		return nil, errors.New("invitation was already accepted")
+	}
+
+	if !accept {
+		return nil, s.collaboratorRepo.Delete(invitationID)
+	}
+	if c.TokenHash == "" || subtle.ConstantTimeCompare([]byte(invitationTokenHash(token)), []byte(c.TokenHash)) != 1 {
+		return nil, errors.New("invalid invitation token")
+	}
+
+	book, err := s.bookRepo.FindByID(c.BookID.String())
+	if err != nil {
+		return nil, errors.New("book not found")
+	}
+	now := time.Now().In(config.AppLocation)
+	c.UserID = &userID
+	c.Status = entities.BookCollaboratorStatusAccepted
+	c.AcceptedAt = &now
+	if err := s.collaboratorRepo.Update(c); err != nil {
+		return nil, err
+	}
+	s.logBookActivity(book, userID, entities.BookActivityCollaboratorJoined, &c.ID, c.Email)
+	return c, nil
+}
+
+// GetSharedBooks lists the books the user collaborates on
+func (s *bookService) GetSharedBooks(userID uuid.UUID) ([]SharedBook, error) {
+	if s.collaboratorRepo == nil {
+		return nil, errors.New("collaborator repository not available")
+	}
+	list, err := s.collaboratorRepo.FindAcceptedByUser(userID.String())
+	if err != nil {
+		return nil, err
+	}
+	books := make([]SharedBook, 0, len(list))
+	for _, c := range list {
+		if c.Book == nil {
+			continue
+		}
+		books = append(books, SharedBook{Book: *c.Book, Role: c.Role})
+	}
+	return books, nil
+}
+
+// GetBookActivity returns who changed what in a book, most recent first.
+// The owner and collaborators can read it.
+func (s *bookService) GetBookActivity(bookID string, userID uuid.UUID, page, perPage int) ([]entities.BookActivity, int64, error) {
+	if s.activityRepo == nil {
+		return nil, 0, errors.New("activity repository not available")
+	}
+	book, err := s.bookRepo.FindByID(bookID)
+	if err != nil {
+		return nil, 0, errors.New("book not found")
+	}
+	if book.OwnerID != userID && s.collaboratorRole(book, userID) == "" {
+		return nil, 0, errors.New("you don't have access to this book")
+	}
+
+	activity, total, err := s.activityRepo.FindByBookID(bookID, perPage, (page-1)*perPage)
+	if err != nil {
+		return nil, 0, err
+	}
+	if activity == nil {
+		activity = []entities.BookActivity{}
+	}
+	names := make(map[uuid.UUID]string)
+	for i := range activity {
+		name, ok := names[activity[i].UserID]
+		if !ok {
+			if user, err := s.userRepo.FindByID(activity[i].UserID.String()); err == nil {
+				name = user.FullName
+			}
+			names[activity[i].UserID] = name
+		}
+		activity[i].UserName = name
+	}
+	return activity, total, nil
+}
@@ -0,0 +1,139 @@
+package services_test
+
+import (
+	"testing"
+
+	"hifzhun-api/pkg/entities"
+)
+
+func TestBookCollaborators(t *testing.T) {
+	env := newTestEnv(t)
+	bookRepo := env.bookRepo
+	userRepo := env.userRepo
+	svc := env.bookSvc
+
+	owner := &entities.User{Email: "ustadz@example.com", FullName: "Ustadz Ahmad"}
+	editor := &entities.User{Email: "ustadzah@example.com", FullName: "Ustadzah Fatimah"}
+	viewer := &entities.User{Email: "santri@example.com", FullName: "Santri Umar"}
+	for _, u := range []*entities.User{owner, editor, viewer} {
+		if err := userRepo.Create(u); err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+	}
+	book := &entities.Book{OwnerID: owner.ID, Title: "Safinatun Najah", Status: entities.BookStatusDraft}
+	if err := bookRepo.Create(book); err != nil {
+		t.Fatalf("create book: %v", err)
+	}
+	bookID := book.ID.String()
+
+	if _, err := svc.InviteCollaborator(bookID, editor.ID, "x@example.com", ""); err == nil {
+		t.Error("non-owner invited a collaborator")
+	}
+	editorInv, err := svc.InviteCollaborator(bookID, owner.ID, " UstadzaH@example.com ", "")
+	if err != nil || editorInv.Role != entities.BookCollaboratorRoleEditor {
+		t.Fatalf("invite editor %+v, %v", editorInv, err)
+	}
+	viewerInv, err := svc.InviteCollaborator(bookID, owner.ID, viewer.Email, entities.BookCollaboratorRoleViewer)
+	if err != nil || viewerInv.Token == "" {
+		t.Fatalf("invite viewer %+v, %v", viewerInv, err)
+	}
+
+	// Pending invitations grant nothing
+	if _, err := svc.AddModule(bookID, editor.ID, "Bab Thaharah", "", 1, nil); err == nil {
+		t.Error("pending invitee edited the book")
+	}
+	invitations, err := svc.GetMyBookInvitations(editor.ID)
+	if err != nil || len(invitations) != 1 || invitations[0].BookTitle != "Safinatun Najah" || invitations[0].InviterName != "Ustadz Ahmad" {
+		t.Fatalf("invitations %+v, %v", invitations, err)
+	}
+	if _, err := svc.RespondToBookInvitation(editorInv.ID.String(), viewer.ID, true, editorInv.Token); err == nil {
+		t.Error("accepted someone else's invitation")
+	}
+	if _, err := svc.RespondToBookInvitation(editorInv.ID.String(), editor.ID, true, "guess"); err == nil {
+		t.Error("accepted an invitation without its token")
+	}
+	if _, err := svc.RespondToBookInvitation(editorInv.ID.String(), editor.ID, true, editorInv.Token); err != nil {
+		t.Fatalf("accept editor: %v", err)
+	}
+	if _, err := svc.RespondToBookInvitation(viewerInv.ID.String(), viewer.ID, true, viewerInv.Token); err != nil {
+		t.Fatalf("accept viewer: %v", err)
+	}
+
+	bab, err := svc.AddModule(bookID, editor.ID, "Bab Thaharah", "", 1, nil)
+	if err != nil {
+		t.Fatalf("editor add module: %v", err)
+	}
+	item, err := svc.AddItem(bookID, &bab.ID, editor.ID, "Rukun wudhu", "sebutkan rukun wudhu", "enam", 1, 0, "", "", nil)
+	if err != nil {
+		t.Fatalf("editor add item: %v", err)
+	}
+	if _, err := svc.AddItem(bookID, &bab.ID, viewer.ID, "Sunnah wudhu", "", "", 2, 0, "", "", nil); err == nil {
+		t.Error("viewer added an item")
+	}
+	if _, err := svc.GetBookDetail(bookID, &viewer.ID, ""); err != nil {
+		t.Errorf("viewer cannot see the draft book: %v", err)
+	}
+	shared, err := svc.GetSharedBooks(viewer.ID)
+	if err != nil || len(shared) != 1 || shared[0].Role != entities.BookCollaboratorRoleViewer {
+		t.Fatalf("shared books %+v, %v", shared, err)
+	}
+
+	// On a published book an editor's change lands in the draft revision
+	book.Status = entities.BookStatusPublished
+	if err := bookRepo.Update(book); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	if _, err := svc.UpdateItem(item.ID.String(), editor.ID, "", "", "enam perkara", 0, 0, "", "", false, nil); err != nil {
+		t.Fatalf("editor update published item: %v", err)
+	}
+	draft, err := svc.GetDraftRevision(bookID, owner.ID)
+	if err != nil || len(draft.Changes) != 1 {
+		t.Fatalf("draft revision %+v, %v", draft, err)
+	}
+	if _, err := svc.SubmitRevision(bookID, editor.ID, "jawaban"); err == nil {
+		t.Error("editor submitted a revision")
+	}
+
+	// is_editable does not open the shared modules to everyone
+	book.IsEditable = true
+	if err := bookRepo.Update(book); err != nil {
+		t.Fatalf("mark editable: %v", err)
+	}
+	if _, err := svc.AddModule(bookID, viewer.ID, "Bab Shalat", "", 2, nil); err == nil {
+		t.Error("viewer added a module to an editable book")
+	}
+	if _, err := svc.UpdateModule(bab.ID.String(), viewer.ID, "Bab Najis", "", 1); err == nil {
+		t.Error("viewer renamed a module of an editable book")
+	}
+	if err := svc.DeleteModule(bab.ID.String(), viewer.ID); err == nil {
+		t.Error("viewer deleted a module of an editable book")
+	}
+	if m, err := env.bookModuleRepo.FindByID(bab.ID.String()); err != nil || m.Title != "Bab Thaharah" {
+		t.Errorf("module after rejected edits %+v, %v", m, err)
+	}
+	if items, err := env.bookItemRepo.FindByModuleID(bab.ID.String()); err != nil || len(items) != 1 {
+		t.Errorf("items after rejected delete %+v, %v", items, err)
+	}
+
+	activity, total, err := svc.GetBookActivity(bookID, viewer.ID, 1, 20)
+	if err != nil {
+		t.Fatalf("activity: %v", err)
+	}
+	// invited x2, joined x2, module added, item added, item updated
+	if total != 7 || activity[0].Action != entities.BookActivityItemUpdated || !activity[0].InDraft || activity[0].UserName != "Ustadzah Fatimah" {
+		t.Fatalf("activity %d %+v", total, activity)
+	}
+
+	// A collaborator can leave; afterwards they lose access
+	list, _ := svc.GetCollaborators(bookID, owner.ID)
+	for _, c := range list {
+		if c.Email == viewer.Email {
+			if err := svc.RemoveCollaborator(bookID, viewer.ID, c.ID.String()); err != nil {
+				t.Fatalf("viewer leave: %v", err)
+			}
+		}
+	}
+	if _, _, err := svc.GetBookActivity(bookID, viewer.ID, 1, 20); err == nil {
+		t.Error("removed viewer still reads the activity log")
+	}
+}
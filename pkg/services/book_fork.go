@@ -0,0 +1,542 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"hifzhun-api/pkg/config"
+	"hifzhun-api/pkg/entities"
+
+	"github.com/google/uuid"
+)
+
+// Fork origin status when the source book no longer exists
+const forkOriginUnavailable = "unavailable"
+
+// UpstreamStatus lists the approved content versions of the source book
+// that a fork has not pulled yet.
+type UpstreamStatus struct {
+	Origin *entities.BookForkOrigin `json:"origin"`
+	// DraftVersion: source version already pulled into the fork's open
+	// content revision (published forks), waiting for approval
+	DraftVersion int                  `json:"draft_version,omitempty"`
+	Entries      []BookChangelogEntry `json:"entries"`
+}
+
+// UpstreamPullResult summarizes a pull of upstream changes into a fork.
+// Changes lists the applied changes with the fork's own module/item IDs.
+type UpstreamPullResult struct {
+	FromVersion int `json:"from_version"`
+	ToVersion   int `json:"to_version"`
+	Added       int `json:"added"`
+	Updated     int `json:"updated"`
+	Removed     int `json:"removed"`
+	// Skipped: upstream changes to rows the fork owner already deleted
+	Skipped int                          `json:"skipped"`
+	Changes []entities.BookContentChange `json:"changes"`
+	// Conflicts: upstream changes to rows the fork also changed since it
+	// copied or last pulled them. The fork keeps its own version.
+	Conflicts []entities.BookContentChange `json:"conflicts"`
+	// InDraftRevision: the fork is published, so the changes went to its
+	// draft content revision and need approval like any other edit
+	InDraftRevision bool `json:"in_draft_revision"`
+}
+
+// forkOrigin describes the source book of a copy-to-draft fork, or nil when
+// the book is not a fork.
+func (s *bookService) forkOrigin(book *entities.Book) *entities.BookForkOrigin {
+	if book.SourceBookID == nil {
+		return nil
+	}
+	origin := &entities.BookForkOrigin{
+		BookID:  *book.SourceBookID,
+		Version: book.SourceVersion,
+	}
+	src, err := s.bookRepo.FindByID(book.SourceBookID.String())
+	if err != nil {
+		origin.Status = forkOriginUnavailable
+		return origin
+	}
+	origin.Title = src.Title
+	origin.OwnerID = src.OwnerID
+	origin.Status = src.Status
+	origin.CurrentVersion = src.ContentVersion
+	if book.SourceVersion > 0 && src.ContentVersion > book.SourceVersion {
+		origin.Behind = src.ContentVersion - book.SourceVersion
+	}
+	if s.userRepo != nil {
+		if owner, err := s.userRepo.FindByID(src.OwnerID.String()); err == nil {
+			origin.OwnerName = owner.FullName
+		}
+	}
+	return origin
+}
+
+// attachForkOrigin fills book.ForkedFrom for responses
+func (s *bookService) attachForkOrigin(book *entities.Book) {
+	book.ForkedFrom = s.forkOrigin(book)
+}
+
+// upstreamSource loads the source book of a fork owned by ownerID
+func (s *bookService) upstreamSource(bookID string, ownerID uuid.UUID) (*entities.Book, *entities.Book, error) {
+	book, err := s.bookRepo.FindByID(bookID)
+	if err != nil {
+		return nil, nil, errors.New("book not found")
+	}
+	if !s.isBookEditor(book, ownerID) {
+		return nil, nil, errors.New("only the book owner and editors can pull upstream changes")
+	}
+	if err := ensureNotRetired(book); err != nil {
+		return nil, nil, err
+	}
+	if book.SourceBookID == nil {
+		return nil, nil, errors.New("book is not a copy of a published book")
+	}
+	if book.SourceVersion == 0 {
+		return nil, nil, errors.New("this copy was made before upstream tracking and cannot pull changes")
+	}
+	src, err := s.bookRepo.FindByID(book.SourceBookID.String())
+	if err != nil {
+		return nil, nil, errors.New("source book no longer exists")
+	}
+	if src.Status != entities.BookStatusPublished && src.Status != entities.BookStatusRetired {
+		return nil, nil, errors.New("source book is not published")
+	}
+	return book, src, nil
+}
+
+// upstreamEntries returns the approved revisions of the source book after
+// version, oldest first.
+func (s *bookService) upstreamEntries(src *entities.Book, version int) ([]BookChangelogEntry, error) {
+	if s.revisionRepo == nil {
+		return nil, errors.New("revision repository not available")
+	}
+	revs, err := s.revisionRepo.FindApprovedByBookID(src.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]BookChangelogEntry, 0)
+	for i := range revs {
+		if revs[i].Version <= version {
+			continue
+		}
+		entries = append(entries, BookChangelogEntry{
+			Version:    revs[i].Version,
+			Summary:    revs[i].Summary,
+			ApprovedAt: revs[i].ReviewedAt,
+			Changes:    decodeChangelog(&revs[i]),
+			Unseen:     true,
+		})
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Version < entries[j].Version })
+	return entries, nil
+}
+
+// collapseUpstreamChanges merges the changelogs of several versions into one
+// change per module/item: added then removed cancels out, added then
+// updated stays added, otherwise the latest action wins.
+func collapseUpstreamChanges(entries []BookChangelogEntry) []entities.BookContentChange {
+	type key struct {
+		kind string
+		id   uuid.UUID
+	}
+	index := make(map[key]int)
+	var changes []entities.BookContentChange
+	dropped := make(map[int]bool)
+	for _, entry := range entries {
+		for _, c := range entry.Changes {
+			k := key{c.Kind, c.ID}
+			i, seen := index[k]
+			if !seen || dropped[i] {
+				index[k] = len(changes)
+				changes = append(changes, c)
+				continue
+			}
+			prev := &changes[i]
+			switch {
+			case prev.Action == entities.BookChangeAdded && c.Action == entities.BookChangeRemoved:
+				dropped[i] = true
+			case prev.Action == entities.BookChangeAdded:
+				prev.Title = c.Title
+			default:
+				prev.Action = c.Action
+				prev.Title = c.Title
+				prev.Fields = c.Fields
+			}
+		}
+	}
+	result := make([]entities.BookContentChange, 0, len(changes))
+	for i, c := range changes {
+		if !dropped[i] {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// upstreamModuleHash fingerprints the module fields a pull copies, so a
+// fork row can be compared with the upstream row it was last synced from.
+func upstreamModuleHash(m entities.BookModule) string {
+	return contentHash(m.Title, m.Description)
+}
+
+// upstreamItemHash fingerprints the item fields a pull copies. Order and
+// placement are left out: moving a row is not a conflicting edit.
+func upstreamItemHash(it entities.BookItem) string {
+	return contentHash(
+		it.Title, it.Content, it.Answer, bookItemType(it),
+		strings.Join(it.Distractors, "\x00"), strconv.Itoa(it.EstimatedReviewSeconds),
+	)
+}
+
+func contentHash(fields ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(fields, "\x1f")))
+	return hex.EncodeToString(sum[:])
+}
+
+// forkDiverged reports whether a fork row with fingerprint current was edited
+// since it was synced at base and does not already hold upstream. Rows
+// copied before fingerprints were kept have no base and never conflict.
+func forkDiverged(base, current, upstream string) bool {
+	return base != "" && current != base && current != upstream
+}
+
+// applyUpstreamChanges replays upstream changes on a fork's content, using
+// SourceModuleID/SourceItemID to find the fork's copy of each row. Rows the
+// changes touch take the upstream values unless the fork changed them too
+// since the last sync; those are kept and reported as conflicts. Everything
+// else in the fork stays as the owner left it. Upstream images are not
+// copied.
+func applyUpstreamChanges(content *entities.BookRevisionContent, forkBookID uuid.UUID, upModules []entities.BookModule, upItems []entities.BookItem, changes []entities.BookContentChange) *UpstreamPullResult {
+	result := &UpstreamPullResult{Changes: []entities.BookContentChange{}, Conflicts: []entities.BookContentChange{}}
+	now := time.Now().In(config.AppLocation)
+
+	upModByID := make(map[uuid.UUID]entities.BookModule, len(upModules))
+	for _, m := range upModules {
+		upModByID[m.ID] = m
+	}
+	upItemByID := make(map[uuid.UUID]entities.BookItem, len(upItems))
+	for _, it := range upItems {
+		upItemByID[it.ID] = it
+	}
+	forkModule := func(sourceID uuid.UUID) int {
+		for i := range content.Modules {
+			if m := content.Modules[i]; m.SourceModuleID != nil && *m.SourceModuleID == sourceID {
+				return i
+			}
+		}
+		return -1
+	}
+	forkItem := func(sourceID uuid.UUID) int {
+		for i := range content.Items {
+			if it := content.Items[i]; it.SourceItemID != nil && *it.SourceItemID == sourceID {
+				return i
+			}
+		}
+		return -1
+	}
+	// mappedModule resolves an upstream module reference to the fork's module
+	mappedModule := func(upID *uuid.UUID) (*uuid.UUID, bool) {
+		if upID == nil {
+			return nil, true
+		}
+		if i := forkModule(*upID); i >= 0 {
+			id := content.Modules[i].ID
+			return &id, true
+		}
+		return nil, false
+	}
+	applied := func(c entities.BookContentChange, id uuid.UUID) {
+		c.ID = id
+		result.Changes = append(result.Changes, c)
+		switch c.Action {
+		case entities.BookChangeAdded:
+			result.Added++
+		case entities.BookChangeUpdated:
+			result.Updated++
+		case entities.BookChangeRemoved:
+			result.Removed++
+		}
+	}
+	conflict := func(c entities.BookContentChange, id uuid.UUID) {
+		c.ID = id
+		result.Conflicts = append(result.Conflicts, c)
+	}
+
+	wanted := make(map[uuid.UUID]entities.BookContentChange)
+	for _, c := range changes {
+		if c.Kind == entities.BookChangeKindModule && c.Action != entities.BookChangeRemoved {
+			wanted[c.ID] = c
+		}
+	}
+	// Modules: added parents are created before their children
+	done := make(map[uuid.UUID]bool)
+	var syncModule func(upID uuid.UUID)
+	syncModule = func(upID uuid.UUID) {
+		c, ok := wanted[upID]
+		if !ok || done[upID] {
+			return
+		}
+		done[upID] = true
+		up, ok := upModByID[upID]
+		if !ok {
+			result.Skipped++
+			return
+		}
+		if up.ParentID != nil {
+			syncModule(*up.ParentID)
+		}
+		parentID, parentMapped := mappedModule(up.ParentID)
+
+		if i := forkModule(upID); i >= 0 {
+			m := &content.Modules[i]
+			upHash := upstreamModuleHash(up)
+			if forkDiverged(m.SourceHash, upstreamModuleHash(*m), upHash) {
+				conflict(c, m.ID)
+				return
+			}
+			m.Title = up.Title
+			m.Description = up.Description
+			m.Order = up.Order
+			m.SourceHash = upHash
+			if parentMapped {
+				m.ParentID = parentID
+			}
+			applied(c, m.ID)
+			return
+		}
+		if c.Action != entities.BookChangeAdded {
+			result.Skipped++
+			return
+		}
+		sourceID := up.ID
+		m := entities.BookModule{
+			ID:             uuid.New(),
+			BookID:         forkBookID,
+			ParentID:       parentID,
+			Title:          up.Title,
+			Description:    up.Description,
+			Order:          up.Order,
+			SourceModuleID: &sourceID,
+			SourceHash:     upstreamModuleHash(up),
+			CreatedAt:      now,
+		}
+		content.Modules = append(content.Modules, m)
+		applied(c, m.ID)
+	}
+	for _, c := range changes {
+		if c.Kind == entities.BookChangeKindModule && c.Action != entities.BookChangeRemoved {
+			syncModule(c.ID)
+		}
+	}
+
+	// Items added or updated upstream
+	for _, c := range changes {
+		if c.Kind != entities.BookChangeKindItem || c.Action == entities.BookChangeRemoved {
+			continue
+		}
+		up, ok := upItemByID[c.ID]
+		if !ok {
+			result.Skipped++
+			continue
+		}
+		moduleID, moduleMapped := mappedModule(up.ModuleID)
+
+		if i := forkItem(c.ID); i >= 0 {
+			it := &content.Items[i]
+			upHash := upstreamItemHash(up)
+			if forkDiverged(it.SourceHash, upstreamItemHash(*it), upHash) {
+				conflict(c, it.ID)
+				continue
+			}
+			it.Title = up.Title
+			it.Content = up.Content
+			it.Answer = up.Answer
+			it.Type = up.Type
+			it.Distractors = up.Distractors
+			it.EstimatedReviewSeconds = up.EstimatedReviewSeconds
+			it.Order = up.Order
+			it.SourceHash = upHash
+			if moduleMapped {
+				it.ModuleID = moduleID
+			}
+			it.UpdatedAt = now
+			applied(c, it.ID)
+			continue
+		}
+		if c.Action != entities.BookChangeAdded {
+			result.Skipped++
+			continue
+		}
+		sourceID := up.ID
+		it := entities.BookItem{
+			ID:                     uuid.New(),
+			BookID:                 forkBookID,
+			ModuleID:               moduleID,
+			Title:                  up.Title,
+			Content:                up.Content,
+			Answer:                 up.Answer,
+			Order:                  up.Order,
+			Type:                   up.Type,
+			Distractors:            up.Distractors,
+			EstimatedReviewSeconds: up.EstimatedReviewSeconds,
+			Version:                1,
+			SourceItemID:           &sourceID,
+			SourceHash:             upstreamItemHash(up),
+			CreatedAt:              now,
+			UpdatedAt:              now,
+		}
+		content.Items = append(content.Items, it)
+		applied(c, it.ID)
+	}
+
+	// Removals: items first, then modules. The fork's own items and child
+	// modules inside a removed module move to the book level.
+	for _, c := range changes {
+		if c.Kind != entities.BookChangeKindItem || c.Action != entities.BookChangeRemoved {
+			continue
+		}
+		i := forkItem(c.ID)
+		if i < 0 {
+			result.Skipped++
+			continue
+		}
+		if it := content.Items[i]; it.SourceHash != "" && upstreamItemHash(it) != it.SourceHash {
+			conflict(c, it.ID)
+			continue
+		}
+		applied(c, content.Items[i].ID)
+		content.Items = append(content.Items[:i], content.Items[i+1:]...)
+	}
+	for _, c := range changes {
+		if c.Kind != entities.BookChangeKindModule || c.Action != entities.BookChangeRemoved {
+			continue
+		}
+		i := forkModule(c.ID)
+		if i < 0 {
+			result.Skipped++
+			continue
+		}
+		if m := content.Modules[i]; m.SourceHash != "" && upstreamModuleHash(m) != m.SourceHash {
+			conflict(c, m.ID)
+			continue
+		}
+		removedID := content.Modules[i].ID
+		applied(c, removedID)
+		content.Modules = append(content.Modules[:i], content.Modules[i+1:]...)
+		for j := range content.Modules {
+			if content.Modules[j].ParentID != nil && *content.Modules[j].ParentID == removedID {
+				content.Modules[j].ParentID = nil
+			}
+		}
+		for j := range content.Items {
+			if content.Items[j].ModuleID != nil && *content.Items[j].ModuleID == removedID {
+				content.Items[j].ModuleID = nil
+			}
+		}
+	}
+
+	return result
+}
+
+// GetUpstreamStatus shows where a fork came from and which source versions
+// it has not pulled yet.
+func (s *bookService) GetUpstreamStatus(bookID string, ownerID uuid.UUID) (*UpstreamStatus, error) {
+	book, src, err := s.upstreamSource(bookID, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.upstreamEntries(src, book.SourceVersion)
+	if err != nil {
+		return nil, err
+	}
+	status := &UpstreamStatus{Origin: s.forkOrigin(book), Entries: entries}
+	if book.Status == entities.BookStatusPublished {
+		if rev, err := s.revisionRepo.FindOpenByBookID(bookID); err == nil && rev != nil && rev.SourceVersion > book.SourceVersion {
+			status.DraftVersion = rev.SourceVersion
+		}
+	}
+	return status, nil
+}
+
+// PullUpstreamChanges applies the source book's approved changes since the
+// fork's SourceVersion. Draft forks are updated directly; published forks
+// get the changes in their draft content revision, and SourceVersion moves
+// once that revision is approved. Pulling again is safe: rows the fork
+// already has are only overwritten with the same upstream values. Rows
+// changed on both sides are left as the fork has them and listed in
+// Conflicts; SourceVersion still moves, so the owner merges them by hand.
+func (s *bookService) PullUpstreamChanges(bookID string, ownerID uuid.UUID) (*UpstreamPullResult, error) {
+	book, src, err := s.upstreamSource(bookID, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.upstreamEntries(src, book.SourceVersion)
+	if err != nil {
+		return nil, err
+	}
+	upModules, upItems, err := s.liveCanonicalContent(src.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return &UpstreamPullResult{
+			FromVersion: book.SourceVersion,
+			ToVersion:   book.SourceVersion,
+			Changes:     []entities.BookContentChange{},
+			Conflicts:   []entities.BookContentChange{},
+		}, nil
+	}
+	changes := collapseUpstreamChanges(entries)
+
+	if book.Status == entities.BookStatusPublished {
+		var result *UpstreamPullResult
+		err := s.editDraftRevision(book, ownerID, func(content *entities.BookRevisionContent) error {
+			result = applyUpstreamChanges(content, book.ID, upModules, upItems, changes)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		rev, err := s.revisionRepo.FindOpenByBookID(bookID)
+		if err != nil {
+			return nil, err
+		}
+		rev.SourceVersion = src.ContentVersion
+		if err := s.revisionRepo.Update(rev); err != nil {
+			return nil, err
+		}
+		result.FromVersion = book.SourceVersion
+		result.ToVersion = src.ContentVersion
+		result.InDraftRevision = true
+		s.logBookActivity(book, ownerID, entities.BookActivityUpstreamPull, &src.ID, src.Title)
+		return result, nil
+	}
+
+	modules, items, err := s.liveCanonicalContent(bookID)
+	if err != nil {
+		return nil, err
+	}
+	content := &entities.BookRevisionContent{
+		Modules: append([]entities.BookModule(nil), modules...),
+		Items:   append([]entities.BookItem(nil), items...),
+	}
+	result := applyUpstreamChanges(content, book.ID, upModules, upItems, changes)
+	result.FromVersion = book.SourceVersion
+	result.ToVersion = src.ContentVersion
+
+	book.SourceVersion = src.ContentVersion
+	if err := s.revisionRepo.ApplyPlan(book, planBookRevision(modules, items, *content)); err != nil {
+		return nil, err
+	}
+	if len(result.Changes) > 0 {
+		_ = s.refreshSearchText(book)
+	}
+	s.logBookActivity(book, ownerID, entities.BookActivityUpstreamPull, &src.ID, src.Title)
+	return result, nil
+}
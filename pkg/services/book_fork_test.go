@@ -0,0 +1,166 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+
+	"hifzhun-api/pkg/entities"
+)
+
+func TestForkPullUpstreamChanges(t *testing.T) {
+	env := newTestEnv(t)
+	bookRepo := env.bookRepo
+	bookItemRepo := env.bookItemRepo
+	userRepo := env.userRepo
+	svc := env.bookSvc
+
+	author := &entities.User{Email: "ustadz@example.com", FullName: "Ustadz Ahmad"}
+	if err := userRepo.Create(author); err != nil {
+		t.Fatalf("create author: %v", err)
+	}
+	src := &entities.Book{OwnerID: author.ID, Title: "Hadits Arbain", Status: entities.BookStatusDraft}
+	if err := bookRepo.Create(src); err != nil {
+		t.Fatalf("create source: %v", err)
+	}
+	srcID := src.ID.String()
+	bab, _ := svc.AddModule(srcID, author.ID, "Bab Niat", "", 1, nil)
+	x, _ := svc.AddItem(srcID, &bab.ID, author.ID, "Hadits 1", "innamal a'malu", "bin niyyat", 1, 0, "", "", nil)
+	y, _ := svc.AddItem(srcID, &bab.ID, author.ID, "Hadits 2", "bainama nahnu", "jibril", 2, 0, "", "", nil)
+	src.Status = entities.BookStatusPublished
+	if err := bookRepo.Update(src); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+
+	forker := uuid.New()
+	fork, err := svc.CopyPublishedBookToDraft(forker, srcID, "", "", "")
+	if err != nil {
+		t.Fatalf("copy: %v", err)
+	}
+	forkID := fork.ID.String()
+	if fork.SourceVersion != 1 {
+		t.Fatalf("fork source version = %d", fork.SourceVersion)
+	}
+	detail, err := svc.GetBookDetail(forkID, &forker, "")
+	if err != nil {
+		t.Fatalf("fork detail: %v", err)
+	}
+	if detail.ForkedFrom == nil || detail.ForkedFrom.Title != "Hadits Arbain" || detail.ForkedFrom.OwnerName != "Ustadz Ahmad" {
+		t.Fatalf("forked_from %+v", detail.ForkedFrom)
+	}
+	forkModuleID := detail.Modules[0].ID
+	local, err := svc.AddItem(forkID, &forkModuleID, forker, "Catatan", "catatan pribadi", "", 3, 0, "", "", nil)
+	if err != nil {
+		t.Fatalf("local item: %v", err)
+	}
+
+	if _, err := svc.PullUpstreamChanges(forkID, uuid.New()); err == nil {
+		t.Error("stranger pulled into a fork")
+	}
+	if _, err := svc.PullUpstreamChanges(srcID, author.ID); err == nil {
+		t.Error("pulled into a book that is not a fork")
+	}
+
+	// Upstream version 2: X edited, Y removed, Z added
+	if _, err := svc.UpdateItem(x.ID.String(), author.ID, "", "", "bin niyyati", 0, 0, "", "", false, nil); err != nil {
+		t.Fatalf("edit source: %v", err)
+	}
+	if err := svc.DeleteItem(y.ID.String(), author.ID); err != nil {
+		t.Fatalf("delete source item: %v", err)
+	}
+	if _, err := svc.AddItem(srcID, &bab.ID, author.ID, "Hadits 3", "al-islamu", "khamsun", 3, 0, "", "", nil); err != nil {
+		t.Fatalf("add source item: %v", err)
+	}
+	rev, err := svc.SubmitRevision(srcID, author.ID, "Hadits 3")
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	if _, err := svc.ApproveRevision(rev.ID.String(), uuid.New()); err != nil {
+		t.Fatalf("approve: %v", err)
+	}
+
+	status, err := svc.GetUpstreamStatus(forkID, forker)
+	if err != nil {
+		t.Fatalf("upstream status: %v", err)
+	}
+	if status.Origin.Behind != 1 || len(status.Entries) != 1 {
+		t.Fatalf("upstream status %+v", status)
+	}
+
+	result, err := svc.PullUpstreamChanges(forkID, forker)
+	if err != nil {
+		t.Fatalf("pull: %v", err)
+	}
+	if result.Added != 1 || result.Updated != 1 || result.Removed != 1 || result.ToVersion != 2 {
+		t.Fatalf("pull result %+v", result)
+	}
+
+	items, _ := bookItemRepo.FindByBookID(forkID)
+	byTitle := make(map[string]entities.BookItem)
+	for _, it := range items {
+		byTitle[it.Title] = it
+	}
+	if len(items) != 3 || byTitle["Hadits 1"].Answer != "bin niyyati" || byTitle["Catatan"].ID != local.ID {
+		t.Fatalf("fork items after pull %+v", items)
+	}
+	if added := byTitle["Hadits 3"]; added.ModuleID == nil || *added.ModuleID != forkModuleID || added.SourceItemID == nil {
+		t.Fatalf("pulled item %+v", added)
+	}
+	if _, gone := byTitle["Hadits 2"]; gone {
+		t.Error("item removed upstream is still in the fork")
+	}
+
+	reloaded, _ := bookRepo.FindByID(forkID)
+	if reloaded.SourceVersion != 2 {
+		t.Errorf("fork source version = %d, want 2", reloaded.SourceVersion)
+	}
+	again, err := svc.PullUpstreamChanges(forkID, forker)
+	if err != nil || len(again.Changes) != 0 {
+		t.Fatalf("second pull %+v, %v", again, err)
+	}
+
+	// Upstream version 3 edits Hadits 1 and Hadits 3; the fork edited
+	// Hadits 1 as well, so that one is a conflict
+	if _, err := svc.UpdateItem(byTitle["Hadits 1"].ID.String(), forker, "", "", "bin niyyah", 0, 0, "", "", false, nil); err != nil {
+		t.Fatalf("edit fork: %v", err)
+	}
+	srcItems, _ := bookItemRepo.FindByBookID(srcID)
+	for _, it := range srcItems {
+		answer := ""
+		switch it.Title {
+		case "Hadits 1":
+			answer = "bin niyyaat"
+		case "Hadits 3":
+			answer = "khamsin"
+		default:
+			continue
+		}
+		if _, err := svc.UpdateItem(it.ID.String(), author.ID, "", "", answer, 0, 0, "", "", false, nil); err != nil {
+			t.Fatalf("edit source: %v", err)
+		}
+	}
+	rev, err = svc.SubmitRevision(srcID, author.ID, "Jawaban")
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	if _, err := svc.ApproveRevision(rev.ID.String(), uuid.New()); err != nil {
+		t.Fatalf("approve: %v", err)
+	}
+
+	result, err = svc.PullUpstreamChanges(forkID, forker)
+	if err != nil {
+		t.Fatalf("pull: %v", err)
+	}
+	if result.Updated != 1 || len(result.Conflicts) != 1 || result.Conflicts[0].ID != byTitle["Hadits 1"].ID {
+		t.Fatalf("pull with conflict %+v", result)
+	}
+	items, _ = bookItemRepo.FindByBookID(forkID)
+	for _, it := range items {
+		if it.Title == "Hadits 1" && it.Answer != "bin niyyah" {
+			t.Errorf("conflicting fork edit overwritten: %q", it.Answer)
+		}
+		if it.Title == "Hadits 3" && it.Answer != "khamsin" {
+			t.Errorf("upstream edit not pulled: %q", it.Answer)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package services
+
+import (
+	"errors"
+	"hash/fnv"
+	"math/rand"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"hifzhun-api/pkg/entities"
+
+	"gorm.io/datatypes"
+)
+
+const maxChoiceDistractors = 7
+
+// clozePattern matches {{c1::text}} and {{c1::text::hint}}
+var clozePattern = regexp.MustCompile(`(?s)\{\{c([1-9][0-9]*)::(.*?)(?:::(.*?))?\}\}`)
+
+// ClozeDeletion is one {{cN::text::hint}} span of a cloze item. Spans that
+// share N are hidden together.
+type ClozeDeletion struct {
+	Index int
+	Text  string
+	Hint  string
+}
+
+// ClozeCard is the rendering of one cloze of an item: the content with that
+// cloze hidden and the others shown.
+type ClozeCard struct {
+	Index     int      `json:"index"`
+	Prompt    string   `json:"prompt"` // hidden spans shown as [...] or [hint]
+	Hidden    []string `json:"hidden"`
+	Stability string   `json:"stability,omitempty"`
+}
+
+// BookItemRender carries what a client needs to show a non-basic item.
+type BookItemRender struct {
+	Text    string      `json:"text,omitempty"` // cloze content without markers
+	Clozes  []ClozeCard `json:"clozes,omitempty"`
+	Options []string    `json:"options,omitempty"` // multiple choice: answer and distractors, shuffled
+}
+
+// BookItemTypeInput sets the type of a book item on add/update. On update
+// an empty Type keeps the current type and nil Distractors keep the current
+// distractors.
+type BookItemTypeInput struct {
+	Type        string
+	Distractors []string
+}
+
+// ParseClozes returns the cloze spans of content in order of appearance.
+func ParseClozes(content string) []ClozeDeletion {
+	matches := clozePattern.FindAllStringSubmatch(content, -1)
+	result := make([]ClozeDeletion, 0, len(matches))
+	for _, m := range matches {
+		index, _ := strconv.Atoi(m[1])
+		result = append(result, ClozeDeletion{Index: index, Text: m[2], Hint: m[3]})
+	}
+	return result
+}
+
+// ClozeIndices returns the distinct cloze numbers of content, ascending.
+func ClozeIndices(content string) []int {
+	seen := make(map[int]bool)
+	var indices []int
+	for _, c := range ParseClozes(content) {
+		if !seen[c.Index] {
+			seen[c.Index] = true
+			indices = append(indices, c.Index)
+		}
+	}
+	sort.Ints(indices)
+	return indices
+}
+
+// RenderCloze hides cloze index of content and reveals every other cloze.
+// Index 0 reveals everything.
+func RenderCloze(content string, index int) (string, []string) {
+	var hidden []string
+	prompt := clozePattern.ReplaceAllStringFunc(content, func(span string) string {
+		m := clozePattern.FindStringSubmatch(span)
+		n, _ := strconv.Atoi(m[1])
+		if n != index {
+			return m[2]
+		}
+		hidden = append(hidden, m[2])
+		if m[3] != "" {
+			return "[" + m[3] + "]"
+		}
+		return "[...]"
+	})
+	return prompt, hidden
+}
+
+// ChoiceOptions returns the answer and distractors of a multiple-choice item
+// in a shuffled order that stays the same for the item.
+func ChoiceOptions(item *entities.BookItem) []string {
+	options := make([]string, 0, len(item.Distractors)+1)
+	options = append(options, item.Answer)
+	options = append(options, item.Distractors...)
+
+	h := fnv.New64a()
+	h.Write(item.ID[:])
+	r := rand.New(rand.NewSource(int64(h.Sum64())))
+	r.Shuffle(len(options), func(i, j int) { options[i], options[j] = options[j], options[i] })
+	return options
+}
+
+// RenderBookItem builds the rendering data of cloze and multiple-choice
+// items; basic items return nil.
+func RenderBookItem(item *entities.BookItem) *BookItemRender {
+	switch item.Type {
+	case entities.BookItemTypeCloze:
+		text, _ := RenderCloze(item.Content, 0)
+		render := &BookItemRender{Text: text}
+		for _, index := range ClozeIndices(item.Content) {
+			prompt, hidden := RenderCloze(item.Content, index)
+			render.Clozes = append(render.Clozes, ClozeCard{Index: index, Prompt: prompt, Hidden: hidden})
+		}
+		return render
+	case entities.BookItemTypeMultipleChoice:
+		return &BookItemRender{Options: ChoiceOptions(item)}
+	}
+	return nil
+}
+
+// bookItemType treats rows from before item types existed as basic.
+func bookItemType(item entities.BookItem) string {
+	if item.Type == "" {
+		return entities.BookItemTypeBasic
+	}
+	return item.Type
+}
+
+// applyBookItemType copies the requested type fields onto item.
+func applyBookItemType(item *entities.BookItem, in *BookItemTypeInput) {
+	if in == nil {
+		return
+	}
+	if in.Type != "" {
+		item.Type = in.Type
+	}
+	if in.Distractors != nil {
+		item.Distractors = datatypes.NewJSONSlice(in.Distractors)
+	}
+}
+
+// ValidateBookItemType normalizes the type fields of item and checks them
+// against its content and answer.
+func ValidateBookItemType(item *entities.BookItem) error {
+	if item.Type == "" {
+		item.Type = entities.BookItemTypeBasic
+	}
+
+	switch item.Type {
+	case entities.BookItemTypeBasic:
+		item.Distractors = nil
+	case entities.BookItemTypeCloze:
+		if len(ParseClozes(item.Content)) == 0 {
+			return errors.New("cloze item content must contain at least one {{c1::...}} deletion")
+		}
+		item.Distractors = nil
+	case entities.BookItemTypeMultipleChoice:
+		if strings.TrimSpace(item.Content) == "" {
+			return errors.New("multiple choice item requires a question in content")
+		}
+		answer := strings.TrimSpace(item.Answer)
+		if answer == "" {
+			return errors.New("multiple choice item requires the correct answer")
+		}
+		seen := map[string]bool{answer: true}
+		distractors := make([]string, 0, len(item.Distractors))
+		for _, d := range item.Distractors {
+			d = strings.TrimSpace(d)
+			if d == "" {
+				continue
+			}
+			if seen[d] {
+				return errors.New("distractors must be unique and differ from the answer")
+			}
+			seen[d] = true
+			distractors = append(distractors, d)
+		}
+		if len(distractors) == 0 {
+			return errors.New("multiple choice item requires at least one distractor")
+		}
+		if len(distractors) > maxChoiceDistractors {
+			return errors.New("multiple choice item can have at most 7 distractors")
+		}
+		item.Distractors = datatypes.NewJSONSlice(distractors)
+	default:
+		return errors.New("type must be basic, cloze or multiple_choice")
+	}
+	return nil
+}
+
+// memorizationUnits returns the cloze numbers of a cloze item, each studied
+// as its own Item, or [0] for items reviewed as a whole.
+func memorizationUnits(item *entities.BookItem) []int {
+	if item.Type == entities.BookItemTypeCloze {
+		if indices := ClozeIndices(item.Content); len(indices) > 0 {
+			return indices
+		}
+	}
+	return []int{0}
+}
+
+// newBookItemWithStability attaches the user's progress to a book item.
+// rows are the user's Items for it; for cloze items each cloze card gets the
+// stability of its own Item and the item itself that of its first cloze.
+func newBookItemWithStability(item entities.BookItem, rows []entities.Item) BookItemWithStability {
+	byCloze := make(map[int]*entities.Item, len(rows))
+	for i := range rows {
+		byCloze[rows[i].ClozeIndex] = &rows[i]
+	}
+
+	result := BookItemWithStability{BookItem: item, Render: RenderBookItem(&item)}
+	if result.Render == nil || len(result.Render.Clozes) == 0 {
+		var first *entities.Item
+		if len(rows) > 0 {
+			first = &rows[0]
+		}
+		result.Stability = calculateStability(first)
+		return result
+	}
+
+	for i := range result.Render.Clozes {
+		card := &result.Render.Clozes[i]
+		row := byCloze[card.Index]
+		if row == nil && i == 0 {
+			// Progress from before the item became a cloze item
+			row = byCloze[0]
+		}
+		card.Stability = calculateStability(row)
+	}
+	result.Stability = result.Render.Clozes[0].Stability
+	return result
+}
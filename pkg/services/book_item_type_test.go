@@ -0,0 +1,124 @@
+package services_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"hifzhun-api/pkg/entities"
+	"hifzhun-api/pkg/services"
+)
+
+func TestRenderCloze(t *testing.T) {
+	content := "{{c1::Alhamdulillah}} rabbil {{c2::'alamin::semesta}}, {{c1::ar-rahman}}"
+
+	if got := services.ClozeIndices(content); !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Fatalf("indices = %v", got)
+	}
+
+	prompt, hidden := services.RenderCloze(content, 1)
+	if prompt != "[...] rabbil 'alamin, [...]" || !reflect.DeepEqual(hidden, []string{"Alhamdulillah", "ar-rahman"}) {
+		t.Errorf("cloze 1 = %q %v", prompt, hidden)
+	}
+	prompt, hidden = services.RenderCloze(content, 2)
+	if prompt != "Alhamdulillah rabbil [semesta], ar-rahman" || !reflect.DeepEqual(hidden, []string{"'alamin"}) {
+		t.Errorf("cloze 2 = %q %v", prompt, hidden)
+	}
+	if text, _ := services.RenderCloze(content, 0); text != "Alhamdulillah rabbil 'alamin, ar-rahman" {
+		t.Errorf("text = %q", text)
+	}
+}
+
+func TestValidateBookItemType(t *testing.T) {
+	cases := []struct {
+		name string
+		item entities.BookItem
+		ok   bool
+	}{
+		{"basic default", entities.BookItem{Content: "a"}, true},
+		{"cloze", entities.BookItem{Type: "cloze", Content: "{{c1::a}} b"}, true},
+		{"cloze without deletion", entities.BookItem{Type: "cloze", Content: "a b"}, false},
+		{"choice", entities.BookItem{Type: "multiple_choice", Content: "q", Answer: "a", Distractors: []string{"b", "c"}}, true},
+		{"choice without distractor", entities.BookItem{Type: "multiple_choice", Content: "q", Answer: "a"}, false},
+		{"choice repeats answer", entities.BookItem{Type: "multiple_choice", Content: "q", Answer: "a", Distractors: []string{"a"}}, false},
+		{"choice too many", entities.BookItem{Type: "multiple_choice", Content: "q", Answer: "a", Distractors: []string{"1", "2", "3", "4", "5", "6", "7", "8"}}, false},
+		{"unknown", entities.BookItem{Type: "essay", Content: "q"}, false},
+	}
+	for _, tc := range cases {
+		item := tc.item
+		if err := services.ValidateBookItemType(&item); (err == nil) != tc.ok {
+			t.Errorf("%s: err = %v", tc.name, err)
+		}
+	}
+
+	basic := entities.BookItem{Content: "a", Distractors: []string{"b"}}
+	_ = services.ValidateBookItemType(&basic)
+	if basic.Type != entities.BookItemTypeBasic || basic.Distractors != nil {
+		t.Errorf("basic item not normalized: %+v", basic)
+	}
+}
+
+func TestChoiceOptionsStableForItem(t *testing.T) {
+	item := &entities.BookItem{ID: uuid.New(), Answer: "a", Distractors: []string{"b", "c", "d"}}
+	first := services.ChoiceOptions(item)
+	for i := 0; i < 5; i++ {
+		if got := services.ChoiceOptions(item); !reflect.DeepEqual(got, first) {
+			t.Fatalf("options changed: %v then %v", first, got)
+		}
+	}
+	if len(first) != 4 {
+		t.Fatalf("options = %v", first)
+	}
+}
+
+func TestStartClozeItemCreatesItemPerCloze(t *testing.T) {
+	env := newTestEnv(t)
+	bookRepo := env.bookRepo
+	itemRepo := env.itemRepo
+	svc := env.bookSvc
+
+	ownerID := uuid.New()
+	book := &entities.Book{OwnerID: ownerID, Title: "Matan", Status: entities.BookStatusDraft}
+	if err := bookRepo.Create(book); err != nil {
+		t.Fatalf("create book: %v", err)
+	}
+	bookID := book.ID.String()
+
+	if _, err := svc.AddItem(bookID, nil, ownerID, "", "no clozes", "", 0, 0, "", "",
+		&services.BookItemTypeInput{Type: entities.BookItemTypeCloze}); err == nil {
+		t.Error("cloze item without deletions was accepted")
+	}
+	bookItem, err := svc.AddItem(bookID, nil, ownerID, "Bait 1", "{{c1::Qala}} {{c2::Muhammadun}} huwa {{c3::ibnu Malik}}", "", 0, 0, "", "",
+		&services.BookItemTypeInput{Type: entities.BookItemTypeCloze})
+	if err != nil {
+		t.Fatalf("add cloze item: %v", err)
+	}
+
+	result, err := svc.StartItemMemorization(ownerID, bookID, bookItem.ID.String())
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	if len(result.Clozes) != 3 || result.ItemID != result.Clozes[0].ItemID {
+		t.Fatalf("unexpected start result %+v", result)
+	}
+	// Starting again reuses the rows
+	if _, err := svc.StartItemMemorization(ownerID, bookID, bookItem.ID.String()); err != nil {
+		t.Fatalf("start again: %v", err)
+	}
+	rows, _ := itemRepo.FindByOwnerAndContentRef(ownerID, "book:"+bookID+":item:"+bookItem.ID.String())
+	if len(rows) != 3 {
+		t.Fatalf("got %d items, want one per cloze", len(rows))
+	}
+
+	tree, err := svc.GetBookTree(bookID, &ownerID, "user")
+	if err != nil {
+		t.Fatalf("tree: %v", err)
+	}
+	if len(tree.Items) != 1 || tree.Items[0].Render == nil || len(tree.Items[0].Render.Clozes) != 3 {
+		t.Fatalf("tree item not rendered: %+v", tree.Items)
+	}
+	if card := tree.Items[0].Render.Clozes[1]; card.Prompt != "Qala [...] huwa ibnu Malik" || card.Stability == "" {
+		t.Errorf("cloze 2 card = %+v", card)
+	}
+}
@@ -0,0 +1,208 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"hifzhun-api/pkg/config"
+	"hifzhun-api/pkg/entities"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// Moderation timeline event types
+const (
+	ModerationEventSubmitted = "submitted"
+	ModerationEventApproved  = "approved"
+	ModerationEventRejected  = "rejected"
+)
+
+// BookModerationEvent is one step in the publish history of a book.
+type BookModerationEvent struct {
+	Type      string                       `json:"type"` // submitted | approved | rejected
+	At        time.Time                    `json:"at"`
+	Round     int                          `json:"round"`
+	RequestID uuid.UUID                    `json:"request_id"`
+	ActorID   *uuid.UUID                   `json:"actor_id,omitempty"`
+	ActorName string                       `json:"actor_name,omitempty"`
+	Note      string                       `json:"note,omitempty"`
+	Reason    string                       `json:"reason,omitempty"`
+	Comments  []entities.ModerationComment `json:"comments,omitempty"`
+}
+
+// BookModerationTimeline lists all publish submissions and decisions of a book.
+type BookModerationTimeline struct {
+	BookID uuid.UUID             `json:"book_id"`
+	Status string                `json:"status"`
+	Events []BookModerationEvent `json:"events"`
+}
+
+// openPublishRequest records a new submission round for book.
+func (s *bookService) openPublishRequest(book *entities.Book, note string) (*entities.BookPublishRequest, error) {
+	rounds, err := s.publishRepo.CountByBookID(book.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	req := &entities.BookPublishRequest{
+		BookID:      book.ID,
+		OwnerID:     book.OwnerID,
+		Round:       int(rounds) + 1,
+		Status:      entities.BookPublishStatusPending,
+		Note:        note,
+		SubmittedAt: time.Now().In(config.AppLocation),
+	}
+	if err := s.publishRepo.Create(req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// decidePublishRequest stores the admin decision on the open submission.
+// Books that went pending before moderation records existed get a record
+// on the fly.
+func (s *bookService) decidePublishRequest(book *entities.Book, adminID uuid.UUID, status, reason string, comments []entities.ModerationComment) error {
+	req, err := s.publishRepo.FindPendingByBookID(book.ID.String())
+	if err != nil {
+		req, err = s.openPublishRequest(book, "")
+		if err != nil {
+			return err
+		}
+		req.SubmittedAt = book.UpdatedAt
+	}
+
+	now := time.Now().In(config.AppLocation)
+	req.Status = status
+	req.ReviewerID = &adminID
+	req.DecidedAt = &now
+	req.Reason = strings.TrimSpace(reason)
+	req.Comments = datatypes.NewJSONSlice(comments)
+	if err := s.publishRepo.Update(req); err != nil {
+		return err
+	}
+
+	n := entities.Notification{
+		Type:  entities.NotificationTypeBookApproved,
+		Title: fmt.Sprintf("Buku \"%s\" diterbitkan", book.Title),
+		Body:  req.Reason,
+		RefID: &book.ID,
+	}
+	if status == entities.BookPublishStatusRejected {
+		n.Type = entities.NotificationTypeBookRejected
+		n.Title = fmt.Sprintf("Buku \"%s\" ditolak untuk diterbitkan", book.Title)
+	}
+	notify(s.notifier, []uuid.UUID{book.OwnerID}, n)
+	return nil
+}
+
+// validateModerationComments checks that every comment targets a module or
+// canonical item of book, and fills in the target title.
+func (s *bookService) validateModerationComments(book *entities.Book, comments []entities.ModerationComment) ([]entities.ModerationComment, error) {
+	result := make([]entities.ModerationComment, 0, len(comments))
+	for _, c := range comments {
+		c.Comment = strings.TrimSpace(c.Comment)
+		if c.Comment == "" {
+			return nil, errors.New("comment text is required")
+		}
+		switch c.TargetType {
+		case entities.ModerationTargetModule:
+			module, err := s.bookModuleRepo.FindByID(c.TargetID.String())
+			if err != nil || module.BookID != book.ID {
+				return nil, errors.New("comment target module not found in this book")
+			}
+			c.TargetTitle = module.Title
+		case entities.ModerationTargetItem:
+			item, err := s.bookItemRepo.FindByID(c.TargetID.String())
+			if err != nil || item.BookID != book.ID || item.ImporterID != nil {
+				return nil, errors.New("comment target item not found in this book")
+			}
+			c.TargetTitle = item.Title
+		default:
+			return nil, errors.New("comment target_type must be module or item")
+		}
+		result = append(result, c)
+	}
+	return result, nil
+}
+
+// BuildModerationEvents flattens publish requests into a timeline, oldest first.
+func BuildModerationEvents(reqs []entities.BookPublishRequest) []BookModerationEvent {
+	events := make([]BookModerationEvent, 0, len(reqs)*2)
+	for _, r := range reqs {
+		ownerID := r.OwnerID
+		events = append(events, BookModerationEvent{
+			Type:      ModerationEventSubmitted,
+			At:        r.SubmittedAt,
+			Round:     r.Round,
+			RequestID: r.ID,
+			ActorID:   &ownerID,
+			Note:      r.Note,
+		})
+		if r.DecidedAt == nil {
+			continue
+		}
+		eventType := ModerationEventApproved
+		if r.Status == entities.BookPublishStatusRejected {
+			eventType = ModerationEventRejected
+		}
+		events = append(events, BookModerationEvent{
+			Type:      eventType,
+			At:        *r.DecidedAt,
+			Round:     r.Round,
+			RequestID: r.ID,
+			ActorID:   r.ReviewerID,
+			Reason:    r.Reason,
+			Comments:  r.Comments,
+		})
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].Round != events[j].Round {
+			return events[i].Round < events[j].Round
+		}
+		return events[i].At.Before(events[j].At)
+	})
+	return events
+}
+
+// GetModerationTimeline returns the publish history of a book to its owner
+// or an admin.
+func (s *bookService) GetModerationTimeline(bookID string, userID uuid.UUID, role string) (*BookModerationTimeline, error) {
+	book, err := s.bookRepo.FindByID(bookID)
+	if err != nil {
+		return nil, errors.New("book not found")
+	}
+	if role != "admin" && book.OwnerID != userID {
+		return nil, errors.New("you don't have permission to view this book's moderation history")
+	}
+
+	reqs, err := s.publishRepo.FindByBookID(bookID)
+	if err != nil {
+		return nil, err
+	}
+	events := BuildModerationEvents(reqs)
+
+	names := make(map[uuid.UUID]string)
+	for i := range events {
+		id := events[i].ActorID
+		if id == nil {
+			continue
+		}
+		name, ok := names[*id]
+		if !ok {
+			if user, err := s.userRepo.FindByID(id.String()); err == nil {
+				name = user.FullName
+			}
+			names[*id] = name
+		}
+		events[i].ActorName = name
+	}
+
+	return &BookModerationTimeline{
+		BookID: book.ID,
+		Status: book.Status,
+		Events: events,
+	}, nil
+}
@@ -0,0 +1,57 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+
+	"hifzhun-api/pkg/entities"
+	"hifzhun-api/pkg/services"
+)
+
+func TestBuildModerationEvents(t *testing.T) {
+	owner, admin := uuid.New(), uuid.New()
+	start := time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC)
+	rejectedAt := start.Add(24 * time.Hour)
+	itemID := uuid.New()
+
+	reqs := []entities.BookPublishRequest{
+		{
+			ID: uuid.New(), OwnerID: owner, Round: 1,
+			Status:      entities.BookPublishStatusRejected,
+			SubmittedAt: start,
+			ReviewerID:  &admin,
+			DecidedAt:   &rejectedAt,
+			Reason:      "jawaban kosong",
+			Comments: datatypes.NewJSONSlice([]entities.ModerationComment{
+				{TargetType: entities.ModerationTargetItem, TargetID: itemID, Comment: "lengkapi jawaban"},
+			}),
+		},
+		{
+			ID: uuid.New(), OwnerID: owner, Round: 2,
+			Status:      entities.BookPublishStatusPending,
+			SubmittedAt: start.Add(48 * time.Hour),
+			Note:        "sudah diperbaiki",
+		},
+	}
+
+	events := services.BuildModerationEvents(reqs)
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %+v", events)
+	}
+	want := []string{services.ModerationEventSubmitted, services.ModerationEventRejected, services.ModerationEventSubmitted}
+	for i, typ := range want {
+		if events[i].Type != typ {
+			t.Fatalf("event %d = %s, want %s", i, events[i].Type, typ)
+		}
+	}
+	rejected := events[1]
+	if *rejected.ActorID != admin || rejected.Reason != "jawaban kosong" || len(rejected.Comments) != 1 || rejected.Comments[0].TargetID != itemID {
+		t.Fatalf("rejected event %+v", rejected)
+	}
+	if events[2].Round != 2 || events[2].Note != "sudah diperbaiki" || *events[2].ActorID != owner {
+		t.Fatalf("resubmission event %+v", events[2])
+	}
+}
@@ -0,0 +1,310 @@
+package services
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"hifzhun-api/pkg/config"
+	"hifzhun-api/pkg/entities"
+
+	"github.com/google/uuid"
+)
+
+// Strategies for resolving a stale BookItemOverride
+const (
+	OverrideKeepMine   = "keep_mine"   // keep the personal version, mark it as based on the current item
+	OverrideTakeTheirs = "take_theirs" // drop the override, use the canonical item
+	OverrideMerge      = "merge"       // three-way merge, conflicts resolved by the user
+)
+
+// OverrideFieldDiff is one field of a three-way diff between the override's
+// base snapshot, the user's override ("mine") and the current canonical item
+// ("theirs").
+type OverrideFieldDiff struct {
+	Field    string `json:"field"`
+	Base     string `json:"base"`
+	Mine     string `json:"mine"`
+	Theirs   string `json:"theirs"`
+	Merged   string `json:"merged"` // auto-merge result, empty on conflict
+	Conflict bool   `json:"conflict"`
+}
+
+// OverrideDiff lists the fields that differ between base, mine and theirs.
+type OverrideDiff struct {
+	BookItemID     uuid.UUID           `json:"book_item_id"`
+	BookID         uuid.UUID           `json:"book_id"`
+	Stale          bool                `json:"stale"`
+	BaseVersion    int                 `json:"base_version"`
+	CurrentVersion int                 `json:"current_version"`
+	HasConflicts   bool                `json:"has_conflicts"`
+	Fields         []OverrideFieldDiff `json:"fields"`
+}
+
+// StaleOverride is an override whose canonical item changed underneath it.
+type StaleOverride struct {
+	Override       entities.BookItemOverride `json:"override"`
+	BookID         uuid.UUID                 `json:"book_id"`
+	ItemTitle      string                    `json:"item_title"`
+	CurrentVersion int                       `json:"current_version"`
+}
+
+// MergeOverrideField merges one field three ways. A side that did not touch
+// the base loses to the side that did; both changing it differently is a
+// conflict.
+func MergeOverrideField(base, mine, theirs string) (string, bool) {
+	switch {
+	case mine == theirs:
+		return mine, false
+	case mine == base:
+		return theirs, false
+	case theirs == base:
+		return mine, false
+	}
+	return "", true
+}
+
+// overrideFields returns base/mine/theirs per content field. Empty override
+// fields fall back to the canonical value, same as ResolveBookItemContent.
+func overrideFields(o *entities.BookItemOverride, item *entities.BookItem) [][4]string {
+	mine := func(v, canonical string) string {
+		if v == "" {
+			return canonical
+		}
+		return v
+	}
+	mineEst := o.EstimatedReviewSeconds
+	if mineEst <= 0 {
+		mineEst = item.EstimatedReviewSeconds
+	}
+	return [][4]string{
+		{"title", o.BaseTitle, mine(o.Title, item.Title), item.Title},
+		{"content", o.BaseContent, mine(o.Content, item.Content), item.Content},
+		{"answer", o.BaseAnswer, mine(o.Answer, item.Answer), item.Answer},
+		{"image_url", o.BaseImageURL, mine(o.ImageURL, item.ImageURL), item.ImageURL},
+		{"estimated_review_seconds", strconv.Itoa(o.BaseEstimatedReviewSeconds), strconv.Itoa(mineEst), strconv.Itoa(item.EstimatedReviewSeconds)},
+	}
+}
+
+// DiffBookItemOverride builds the three-way diff of an override against its
+// canonical item. Fields where base, mine and theirs are all equal are left out.
+func DiffBookItemOverride(o *entities.BookItemOverride, item *entities.BookItem) OverrideDiff {
+	diff := OverrideDiff{
+		BookItemID:     item.ID,
+		BookID:         item.BookID,
+		Stale:          item.Version > o.BaseVersion,
+		BaseVersion:    o.BaseVersion,
+		CurrentVersion: item.Version,
+		Fields:         make([]OverrideFieldDiff, 0),
+	}
+	for _, f := range overrideFields(o, item) {
+		base, mine, theirs := f[1], f[2], f[3]
+		if base == mine && mine == theirs {
+			continue
+		}
+		merged, conflict := MergeOverrideField(base, mine, theirs)
+		diff.Fields = append(diff.Fields, OverrideFieldDiff{
+			Field:    f[0],
+			Base:     base,
+			Mine:     mine,
+			Theirs:   theirs,
+			Merged:   merged,
+			Conflict: conflict,
+		})
+		if conflict {
+			diff.HasConflicts = true
+		}
+	}
+	return diff
+}
+
+// bookItemContentChanged reports whether fields an override can shadow
+// differ. Order and module moves do not make overrides stale.
+func bookItemContentChanged(a, b entities.BookItem) bool {
+	return a.Title != b.Title ||
+		a.Content != b.Content ||
+		a.Answer != b.Answer ||
+		a.ImageURL != b.ImageURL ||
+		a.EstimatedReviewSeconds != b.EstimatedReviewSeconds
+}
+
+// rebaseOverride marks the override as based on the item's current version.
+func rebaseOverride(o *entities.BookItemOverride, item *entities.BookItem) {
+	o.BaseVersion = item.Version
+	o.BaseTitle = item.Title
+	o.BaseContent = item.Content
+	o.BaseAnswer = item.Answer
+	o.BaseImageURL = item.ImageURL
+	o.BaseEstimatedReviewSeconds = item.EstimatedReviewSeconds
+}
+
+func (s *bookService) findOverrideWithItem(userID uuid.UUID, bookItemID string) (*entities.BookItemOverride, *entities.BookItem, error) {
+	bookItemUUID, err := uuid.Parse(bookItemID)
+	if err != nil {
+		return nil, nil, errors.New("invalid book_item_id")
+	}
+	item, err := s.bookItemRepo.FindByID(bookItemID)
+	if err != nil {
+		return nil, nil, errors.New("book item not found")
+	}
+	if s.overrideRepo == nil {
+		return nil, nil, errors.New("override repository not available")
+	}
+	override, err := s.overrideRepo.FindByUserAndBookItemID(userID, bookItemUUID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if override == nil {
+		return nil, nil, errors.New("no override found for this item")
+	}
+	return override, item, nil
+}
+
+// GetStaleOverrides lists the user's overrides whose canonical item changed
+// after the override was made.
+func (s *bookService) GetStaleOverrides(userID uuid.UUID) ([]StaleOverride, error) {
+	if s.overrideRepo == nil {
+		return nil, errors.New("override repository not available")
+	}
+	overrides, err := s.overrideRepo.FindStaleByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(overrides))
+	for i, o := range overrides {
+		ids[i] = o.BookItemID.String()
+	}
+	items, err := s.bookItemRepo.FindByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	itemByID := make(map[uuid.UUID]entities.BookItem, len(items))
+	for _, it := range items {
+		itemByID[it.ID] = it
+	}
+
+	result := make([]StaleOverride, 0, len(overrides))
+	for _, o := range overrides {
+		item, ok := itemByID[o.BookItemID]
+		if !ok {
+			continue
+		}
+		result = append(result, StaleOverride{
+			Override:       o,
+			BookID:         item.BookID,
+			ItemTitle:      item.Title,
+			CurrentVersion: item.Version,
+		})
+	}
+	return result, nil
+}
+
+// GetOverrideDiff returns the three-way diff for the user's override.
+func (s *bookService) GetOverrideDiff(userID uuid.UUID, bookItemID string) (*OverrideDiff, error) {
+	override, item, err := s.findOverrideWithItem(userID, bookItemID)
+	if err != nil {
+		return nil, err
+	}
+	diff := DiffBookItemOverride(override, item)
+	return &diff, nil
+}
+
+// ResolveOverride settles a (stale) override. For OverrideMerge, every
+// conflicting field needs a value in resolutions; resolutions may also
+// replace auto-merged fields. When the result equals the canonical item the
+// override is dropped.
+func (s *bookService) ResolveOverride(userID uuid.UUID, bookItemID, strategy string, resolutions map[string]string) (*ResolvedBookItem, error) {
+	override, item, err := s.findOverrideWithItem(userID, bookItemID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strategy {
+	case OverrideTakeTheirs:
+		if err := s.overrideRepo.DeleteByUserAndBookItemID(userID, item.ID); err != nil {
+			return nil, err
+		}
+		return &ResolvedBookItem{BookItem: *item}, nil
+
+	case OverrideKeepMine:
+		rebaseOverride(override, item)
+
+	case OverrideMerge:
+		diff := DiffBookItemOverride(override, item)
+		values := make(map[string]string, len(diff.Fields))
+		fields := make(map[string]OverrideFieldDiff, len(diff.Fields))
+		for _, f := range diff.Fields {
+			values[f.Field] = f.Merged
+			fields[f.Field] = f
+		}
+		for field, value := range resolutions {
+			f, ok := fields[field]
+			if !ok {
+				return nil, errors.New("field " + field + " has no changes to resolve")
+			}
+			// An image is a stored object, not free text: pick one of the versions
+			if field == "image_url" && value != f.Base && value != f.Mine && value != f.Theirs {
+				return nil, errors.New("image_url must be the base, mine or theirs value")
+			}
+			values[field] = value
+		}
+		for _, f := range diff.Fields {
+			if _, ok := resolutions[f.Field]; f.Conflict && !ok {
+				return nil, errors.New("conflict in field " + f.Field + " must be resolved")
+			}
+		}
+
+		merged := *override
+		// Start from the current item so untouched fields follow it
+		merged.Title = item.Title
+		merged.Content = item.Content
+		merged.Answer = item.Answer
+		merged.ImageURL = item.ImageURL
+		merged.EstimatedReviewSeconds = item.EstimatedReviewSeconds
+		for field, value := range values {
+			switch field {
+			case "title":
+				merged.Title = value
+			case "content":
+				merged.Content = value
+			case "answer":
+				merged.Answer = value
+			case "image_url":
+				merged.ImageURL = value
+			case "estimated_review_seconds":
+				est, err := strconv.Atoi(value)
+				if err != nil || est < 0 {
+					return nil, errors.New("estimated_review_seconds must be a non-negative number")
+				}
+				merged.EstimatedReviewSeconds = est
+			}
+		}
+		if merged.Content == "" && merged.Answer == "" {
+			return nil, errors.New("either content or answer must be provided")
+		}
+
+		if merged.Title == item.Title && merged.Content == item.Content && merged.Answer == item.Answer &&
+			merged.ImageURL == item.ImageURL && merged.EstimatedReviewSeconds == item.EstimatedReviewSeconds {
+			// Nothing personal left
+			if err := s.overrideRepo.DeleteByUserAndBookItemID(userID, item.ID); err != nil {
+				return nil, err
+			}
+			return &ResolvedBookItem{BookItem: *item}, nil
+		}
+		// Another version's image is the book's, not the user's upload
+		merged.ImageUploaded = override.ImageUploaded && merged.ImageURL == override.ImageURL
+		rebaseOverride(&merged, item)
+		override = &merged
+
+	default:
+		return nil, errors.New("strategy must be keep_mine, take_theirs or merge")
+	}
+
+	override.UpdatedAt = time.Now().In(config.AppLocation)
+	if err := s.overrideRepo.Upsert(override); err != nil {
+		return nil, err
+	}
+	resolved := ResolveBookItemContent(item, &userID, s.overrideRepo)
+	return &resolved, nil
+}
@@ -0,0 +1,116 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+
+	"hifzhun-api/pkg/entities"
+	"hifzhun-api/pkg/services"
+)
+
+func TestMergeOverrideField(t *testing.T) {
+	cases := []struct {
+		base, mine, theirs string
+		want               string
+		conflict           bool
+	}{
+		{"a", "a", "a", "a", false},
+		{"a", "mine", "a", "mine", false},
+		{"a", "a", "theirs", "theirs", false},
+		{"a", "same", "same", "same", false},
+		{"a", "mine", "theirs", "", true},
+		{"", "mine", "theirs", "", true}, // override made before base snapshots existed
+	}
+	for _, tc := range cases {
+		got, conflict := services.MergeOverrideField(tc.base, tc.mine, tc.theirs)
+		if got != tc.want || conflict != tc.conflict {
+			t.Errorf("merge(%q, %q, %q) = %q, %v", tc.base, tc.mine, tc.theirs, got, conflict)
+		}
+	}
+}
+
+func TestDiffBookItemOverride(t *testing.T) {
+	item := &entities.BookItem{
+		ID:      uuid.New(),
+		BookID:  uuid.New(),
+		Title:   "Kitab",
+		Content: "كِتَابٌ",
+		Answer:  "buku",
+		Version: 3,
+	}
+	override := &entities.BookItemOverride{
+		Title:       "Kitab",
+		Content:     "كتاب",       // mine changed content
+		Answer:      "buku tulis", // both changed answer
+		BaseVersion: 2,
+		BaseTitle:   "Kitab",
+		BaseContent: "كِتَابٌ",
+		BaseAnswer:  "bku",
+	}
+
+	diff := services.DiffBookItemOverride(override, item)
+	if !diff.Stale || diff.BaseVersion != 2 || diff.CurrentVersion != 3 {
+		t.Fatalf("unexpected header %+v", diff)
+	}
+	if len(diff.Fields) != 2 || !diff.HasConflicts {
+		t.Fatalf("fields %+v", diff.Fields)
+	}
+	content, answer := diff.Fields[0], diff.Fields[1]
+	if content.Field != "content" || content.Conflict || content.Merged != "كتاب" {
+		t.Fatalf("content %+v", content)
+	}
+	if answer.Field != "answer" || !answer.Conflict || answer.Theirs != "buku" || answer.Mine != "buku tulis" {
+		t.Fatalf("answer %+v", answer)
+	}
+
+	override.BaseVersion = 3
+	if services.DiffBookItemOverride(override, item).Stale {
+		t.Fatal("override based on the current version must not be stale")
+	}
+}
+
+func TestResolveOverrideImage(t *testing.T) {
+	env := newTestEnv(t)
+	svc := env.bookSvc
+
+	userID := uuid.New()
+	item := &entities.BookItem{BookID: uuid.New(), Title: "Kitab", Content: "كِتَابٌ", ImageURL: "https://cdn.example.com/theirs.png", Version: 2}
+	if err := env.bookItemRepo.Create(item); err != nil {
+		t.Fatalf("create item: %v", err)
+	}
+	override := &entities.BookItemOverride{
+		UserID:        userID,
+		BookItemID:    item.ID,
+		Title:         "Kitab",
+		Content:       "كِتَابٌ",
+		ImageURL:      "https://cdn.example.com/mine.png",
+		ImageUploaded: true,
+		BaseVersion:   1,
+		BaseTitle:     "Kitab",
+		BaseContent:   "كِتَابٌ",
+		BaseImageURL:  "https://cdn.example.com/base.png",
+	}
+	if err := env.overrideRepo.Upsert(override); err != nil {
+		t.Fatalf("create override: %v", err)
+	}
+
+	outside := map[string]string{"image_url": "https://cdn.example.com/other-users-upload.png"}
+	if _, err := svc.ResolveOverride(userID, item.ID.String(), services.OverrideMerge, outside); err == nil {
+		t.Error("merged an image that is none of the versions")
+	}
+
+	base := map[string]string{"image_url": "https://cdn.example.com/base.png"}
+	resolved, err := svc.ResolveOverride(userID, item.ID.String(), services.OverrideMerge, base)
+	if err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+	if resolved.ImageURL != "https://cdn.example.com/base.png" {
+		t.Errorf("resolved image = %q", resolved.ImageURL)
+	}
+	// The base image is the book's, so removing it later must not delete it
+	saved, _ := env.overrideRepo.FindByUserAndBookItemID(userID, item.ID)
+	if saved == nil || saved.ImageUploaded {
+		t.Errorf("override after merge = %+v", saved)
+	}
+}
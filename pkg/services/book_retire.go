@@ -0,0 +1,132 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"hifzhun-api/pkg/config"
+	"hifzhun-api/pkg/entities"
+
+	"github.com/google/uuid"
+)
+
+// ensureNotRetired rejects content changes to a retired book. Owner and
+// importers keep reading it, but it is frozen until an admin restores it.
+func ensureNotRetired(book *entities.Book) error {
+	if book.Status == entities.BookStatusRetired {
+		return errors.New("book is retired and read-only")
+	}
+	return nil
+}
+
+// canReadRetiredBook reports whether userID owns the book or has it in their
+// collection.
+func (s *bookService) canReadRetiredBook(book *entities.Book, userID *uuid.UUID) bool {
+	if userID == nil || s.classBookRepo == nil {
+		return false
+	}
+	if book.OwnerID == *userID {
+		return true
+	}
+	imported, err := s.classBookRepo.IsBookImportedByUser(book.ID.String(), userID.String())
+	return err == nil && imported
+}
+
+// RetireBook takes a published book out of the catalog. Nothing is deleted:
+// importers keep read-only access and their memorization progress.
+func (s *bookService) RetireBook(bookID string, adminID uuid.UUID, reason string) error {
+	book, err := s.bookRepo.FindByID(bookID)
+	if err != nil {
+		return errors.New("book not found")
+	}
+
+	if book.Status != entities.BookStatusPublished {
+		return errors.New("book is not published")
+	}
+
+	now := time.Now().In(config.AppLocation)
+	book.Status = entities.BookStatusRetired
+	book.RetiredAt = &now
+	book.RetiredBy = &adminID
+	book.RetireReason = strings.TrimSpace(reason)
+	return s.bookRepo.Update(book)
+}
+
+// RestoreRetiredBook puts a retired book back into the catalog.
+func (s *bookService) RestoreRetiredBook(bookID string) error {
+	book, err := s.bookRepo.FindByID(bookID)
+	if err != nil {
+		return errors.New("book not found")
+	}
+
+	if book.Status != entities.BookStatusRetired {
+		return errors.New("book is not retired")
+	}
+
+	book.Status = entities.BookStatusPublished
+	book.RetiredAt = nil
+	book.RetiredBy = nil
+	book.RetireReason = ""
+	if err := s.bookRepo.Update(book); err != nil {
+		return err
+	}
+	return s.refreshSearchText(book)
+}
+
+func (s *bookService) GetRetiredBooks() ([]entities.Book, error) {
+	return s.bookRepo.FindRetired()
+}
+
+// PurgeBook permanently deletes a retired book together with every user's
+// memorization Items, overrides and reviews of it. The admin must repeat the
+// book title and give a reason; both are kept in a BookPurgeLog.
+func (s *bookService) PurgeBook(bookID string, adminID uuid.UUID, confirmTitle, reason string) (*entities.BookPurgeLog, error) {
+	book, err := s.bookRepo.FindByID(bookID)
+	if err != nil {
+		return nil, errors.New("book not found")
+	}
+
+	if book.Status != entities.BookStatusRetired {
+		return nil, errors.New("only retired books can be purged")
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, errors.New("a reason is required to purge a book")
+	}
+	if strings.TrimSpace(confirmTitle) != book.Title {
+		return nil, errors.New("confirm_title does not match the book title")
+	}
+
+	itemCount, learnerCount, err := s.itemRepo.CountBookItemsByBookID(bookID)
+	if err != nil {
+		return nil, err
+	}
+
+	log := &entities.BookPurgeLog{
+		BookID:       book.ID,
+		BookTitle:    book.Title,
+		OwnerID:      book.OwnerID,
+		AdminID:      adminID,
+		Reason:       reason,
+		ItemCount:    itemCount,
+		LearnerCount: learnerCount,
+		RetiredAt:    book.RetiredAt,
+		PurgedAt:     time.Now().In(config.AppLocation),
+	}
+	if err := s.purgeLogRepo.Purge(log); err != nil {
+		return nil, err
+	}
+	return log, nil
+}
+
+func (s *bookService) GetPurgeLogs(page, perPage int) ([]entities.BookPurgeLog, int64, error) {
+	logs, total, err := s.purgeLogRepo.FindAll(perPage, (page-1)*perPage)
+	if err != nil {
+		return nil, 0, err
+	}
+	if logs == nil {
+		logs = []entities.BookPurgeLog{}
+	}
+	return logs, total, nil
+}
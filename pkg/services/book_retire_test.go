@@ -0,0 +1,127 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"hifzhun-api/pkg/entities"
+)
+
+func TestRetiredBookKeepsImporterProgressUntilPurge(t *testing.T) {
+	env := newTestEnv(t)
+	bookRepo := env.bookRepo
+	bookItemRepo := env.bookItemRepo
+	svc := env.bookSvc
+
+	ownerID, importerID, strangerID, adminID := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	book := &entities.Book{OwnerID: ownerID, Title: "Matan Jazariyah", Status: entities.BookStatusPublished}
+	if err := bookRepo.Create(book); err != nil {
+		t.Fatalf("create book: %v", err)
+	}
+	bookID := book.ID.String()
+	bookItem := &entities.BookItem{BookID: book.ID, Title: "Bait 1", Content: "..."}
+	if err := bookItemRepo.Create(bookItem); err != nil {
+		t.Fatalf("create book item: %v", err)
+	}
+
+	if _, err := svc.AddPublishedBookToMyBook(importerID, bookID); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	started, err := svc.StartItemMemorization(importerID, bookID, bookItem.ID.String())
+	if err != nil {
+		t.Fatalf("start memorization: %v", err)
+	}
+
+	if _, err := svc.PurgeBook(bookID, adminID, book.Title, "test"); err == nil {
+		t.Fatal("purged a book that was not retired")
+	}
+	if err := svc.RetireBook(bookID, adminID, "replaced by new edition"); err != nil {
+		t.Fatalf("retire: %v", err)
+	}
+
+	if _, err := svc.AddPublishedBookToMyBook(strangerID, bookID); err == nil {
+		t.Error("retired book could still be imported")
+	}
+	if _, err := svc.GetBookDetail(bookID, &importerID, "user"); err != nil {
+		t.Errorf("importer lost access to retired book: %v", err)
+	}
+	if _, err := svc.GetBookDetail(bookID, &strangerID, "user"); err == nil {
+		t.Error("stranger can view retired book")
+	}
+	if _, err := svc.AddItem(bookID, nil, ownerID, "", "new", "", 0, 0, "", "", nil); err == nil {
+		t.Error("retired book is still editable")
+	}
+	if err := svc.DeleteBook(bookID, ownerID); err == nil {
+		t.Error("owner deleted a retired book")
+	}
+	collection, err := svc.GetMyBookCollection(importerID)
+	if err != nil || len(collection) != 1 || collection[0].Status != entities.BookStatusRetired || collection[0].ItemCount != 1 {
+		t.Fatalf("collection after retire %+v err=%v", collection, err)
+	}
+
+	if _, err := svc.PurgeBook(bookID, adminID, "Matan", "author request"); err == nil {
+		t.Fatal("purge accepted a wrong confirmation title")
+	}
+	if _, err := svc.PurgeBook(bookID, adminID, book.Title, " "); err == nil {
+		t.Fatal("purge accepted an empty reason")
+	}
+
+	// A class uses the book: it is a class book, an assignment targets it and
+	// the importer already recited the item
+	class := &entities.Class{GuruID: ownerID, Name: "Tahsin", ClassCode: "TAHSIN", IsActive: true}
+	if err := env.classRepo.Create(class); err != nil {
+		t.Fatalf("create class: %v", err)
+	}
+	if err := env.classBookRepo.Create(&entities.ClassBook{ClassID: class.ID, BookID: book.ID}); err != nil {
+		t.Fatalf("add class book: %v", err)
+	}
+	assignment := &entities.ClassAssignment{
+		ClassID: class.ID, TeacherID: ownerID, Title: "Bait 1", TargetType: entities.AssignmentTargetBook,
+		BookID: &book.ID, DueAt: time.Now().Add(24 * time.Hour),
+	}
+	link := entities.ClassAssignmentItem{UserID: importerID, ItemID: started.ItemID, BookItemID: &bookItem.ID}
+	if err := env.assignmentRepo.CreateWithItems(assignment, []entities.ClassAssignmentItem{link}); err != nil {
+		t.Fatalf("create assignment: %v", err)
+	}
+	if err := env.setoranRepo.Create(&entities.Setoran{
+		ClassID: class.ID, TeacherID: ownerID, StudentID: importerID, ItemID: started.ItemID,
+		ContentRef: "book", Grade: 3, RecordedAt: time.Now(),
+	}); err != nil {
+		t.Fatalf("create setoran: %v", err)
+	}
+	if err := env.db.Create(&entities.ReviewLog{ID: uuid.New(), UserID: importerID, ItemID: started.ItemID, ReviewedAt: time.Now(), Rating: 3}).Error; err != nil {
+		t.Fatalf("create review log: %v", err)
+	}
+
+	log, err := svc.PurgeBook(bookID, adminID, book.Title, "author request")
+	if err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+	if log.ItemCount != 1 || log.LearnerCount != 1 || log.AdminID != adminID || log.RetiredAt == nil {
+		t.Fatalf("unexpected purge log %+v", log)
+	}
+	if _, err := bookRepo.FindByID(bookID); err == nil {
+		t.Error("book still exists after purge")
+	}
+	for _, model := range []any{
+		&entities.ClassBook{},
+		&entities.ClassAssignment{},
+		&entities.ClassAssignmentItem{},
+		&entities.Setoran{},
+		&entities.ReviewLog{},
+	} {
+		var n int64
+		if err := env.db.Model(model).Count(&n).Error; err != nil || n != 0 {
+			t.Errorf("%T rows left after purge: %d, %v", model, n, err)
+		}
+	}
+	if collection, _ := svc.GetMyBookCollection(importerID); len(collection) != 0 {
+		t.Errorf("purged book still in collection: %+v", collection)
+	}
+	logs, total, err := svc.GetPurgeLogs(1, 20)
+	if err != nil || total != 1 || len(logs) != 1 || logs[0].BookTitle != book.Title {
+		t.Fatalf("purge logs %+v total=%d err=%v", logs, total, err)
+	}
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"hifzhun-api/pkg/config"
+	"hifzhun-api/pkg/entities"
+	"hifzhun-api/pkg/repositories"
+
+	"github.com/google/uuid"
+)
+
+const maxReviewBodyLength = 1000
+
+// BookReviewList is the review section of a published book.
+type BookReviewList struct {
+	Summary   repositories.BookRatingSummary `json:"summary"`
+	MyReview  *entities.BookReview           `json:"my_review,omitempty"`
+	CanReview bool                           `json:"can_review"`
+	Reviews   []repositories.BookReviewView  `json:"reviews"`
+}
+
+// canReviewBook: only users who imported or copied a published book may
+// review it, and never its owner.
+func (s *bookService) canReviewBook(book *entities.Book, userID uuid.UUID) bool {
+	if book.OwnerID == userID {
+		return false
+	}
+	if _, err := s.classBookRepo.FindImportedBook(userID.String(), book.ID.String()); err == nil {
+		return true
+	}
+	copied, err := s.bookRepo.HasCopy(userID.String(), book.ID.String())
+	return err == nil && copied
+}
+
+func (s *bookService) findPublishedBook(bookID string) (*entities.Book, error) {
+	book, err := s.bookRepo.FindByID(bookID)
+	if err != nil {
+		return nil, errors.New("book not found")
+	}
+	if book.Status != entities.BookStatusPublished {
+		return nil, errors.New("book is not published")
+	}
+	return book, nil
+}
+
+func (s *bookService) GetBookReviews(bookID string, userID uuid.UUID, page, perPage int) (*BookReviewList, error) {
+	book, err := s.findPublishedBook(bookID)
+	if err != nil {
+		return nil, err
+	}
+
+	summary, err := s.reviewRepo.GetSummary(book.ID)
+	if err != nil {
+		return nil, err
+	}
+	reviews, err := s.reviewRepo.FindVisibleByBookID(bookID, perPage, (page-1)*perPage)
+	if err != nil {
+		return nil, err
+	}
+	if reviews == nil {
+		reviews = []repositories.BookReviewView{}
+	}
+	mine, err := s.reviewRepo.FindByBookAndUser(book.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BookReviewList{
+		Summary:   *summary,
+		MyReview:  mine,
+		CanReview: s.canReviewBook(book, userID),
+		Reviews:   reviews,
+	}, nil
+}
+
+// SaveMyReview creates the user's review or updates it; one per user per book.
+func (s *bookService) SaveMyReview(bookID string, userID uuid.UUID, rating int, body string) (*entities.BookReview, error) {
+	book, err := s.findPublishedBook(bookID)
+	if err != nil {
+		return nil, err
+	}
+	if book.OwnerID == userID {
+		return nil, errors.New("you cannot review your own book")
+	}
+	if !s.canReviewBook(book, userID) {
+		return nil, errors.New("only users who imported or copied this book can review it")
+	}
+	if rating < 1 || rating > 5 {
+		return nil, errors.New("rating must be between 1 and 5")
+	}
+	body = strings.TrimSpace(body)
+	if utf8.RuneCountInString(body) > maxReviewBodyLength {
+		return nil, errors.New("review must be 1000 characters or less")
+	}
+
+	review, err := s.reviewRepo.FindByBookAndUser(book.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if review == nil {
+		review = &entities.BookReview{BookID: book.ID, UserID: userID, Rating: rating, Body: body}
+		if err := s.reviewRepo.Create(review); err != nil {
+			return nil, err
+		}
+		return review, nil
+	}
+
+	// Edits keep the moderation state; a hidden review stays hidden
+	review.Rating = rating
+	review.Body = body
+	review.UpdatedAt = time.Now().In(config.AppLocation)
+	if err := s.reviewRepo.Update(review); err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+func (s *bookService) DeleteMyReview(bookID string, userID uuid.UUID) error {
+	bookUUID, err := uuid.Parse(bookID)
+	if err != nil {
+		return errors.New("invalid book id")
+	}
+	review, err := s.reviewRepo.FindByBookAndUser(bookUUID, userID)
+	if err != nil {
+		return err
+	}
+	if review == nil {
+		return errors.New("you have not reviewed this book")
+	}
+	return s.reviewRepo.Delete(review.ID)
+}
+
+// ==================== ADMIN MODERATION ====================
+
+func (s *bookService) GetReviewsForModeration(hiddenOnly bool, page, perPage int) ([]repositories.BookReviewView, int64, error) {
+	reviews, total, err := s.reviewRepo.FindForModeration(hiddenOnly, perPage, (page-1)*perPage)
+	if err != nil {
+		return nil, 0, err
+	}
+	if reviews == nil {
+		reviews = []repositories.BookReviewView{}
+	}
+	return reviews, total, nil
+}
+
+func (s *bookService) HideReview(reviewID string, adminID uuid.UUID, reason string) error {
+	review, err := s.reviewRepo.FindByID(reviewID)
+	if err != nil {
+		return errors.New("review not found")
+	}
+	if review.Hidden {
+		return errors.New("review is already hidden")
+	}
+	return s.reviewRepo.SetHidden(review, true, adminID, strings.TrimSpace(reason), time.Now().In(config.AppLocation))
+}
+
+func (s *bookService) UnhideReview(reviewID string, adminID uuid.UUID) error {
+	review, err := s.reviewRepo.FindByID(reviewID)
+	if err != nil {
+		return errors.New("review not found")
+	}
+	if !review.Hidden {
+		return errors.New("review is not hidden")
+	}
+	return s.reviewRepo.SetHidden(review, false, adminID, "", time.Now().In(config.AppLocation))
+}
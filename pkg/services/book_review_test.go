@@ -0,0 +1,48 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"hifzhun-api/pkg/entities"
+	"hifzhun-api/pkg/repositories"
+)
+
+func TestBookRatingSummaryIgnoresHiddenReviews(t *testing.T) {
+	repo := repositories.NewBookReviewRepository(setupTestSQLiteDB(t))
+
+	bookID := uuid.New()
+	var abusive *entities.BookReview
+	for _, rating := range []int{5, 4, 4, 1} {
+		review := &entities.BookReview{BookID: bookID, UserID: uuid.New(), Rating: rating}
+		if err := repo.Create(review); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+		if rating == 1 {
+			abusive = review
+		}
+	}
+
+	summary, err := repo.GetSummary(bookID)
+	if err != nil {
+		t.Fatalf("summary: %v", err)
+	}
+	if summary.Count != 4 || summary.Average != 3.5 || summary.Distribution != [5]int64{1, 0, 0, 2, 1} {
+		t.Fatalf("unexpected summary %+v", summary)
+	}
+
+	if err := repo.SetHidden(abusive, true, uuid.New(), "spam", time.Now()); err != nil {
+		t.Fatalf("hide: %v", err)
+	}
+	summary, _ = repo.GetSummary(bookID)
+	if summary.Count != 3 || summary.Distribution[0] != 0 {
+		t.Fatalf("hidden review still counted: %+v", summary)
+	}
+
+	hidden, total, err := repo.FindForModeration(true, 10, 0)
+	if err != nil || total != 1 || len(hidden) != 1 || hidden[0].HiddenReason != "spam" {
+		t.Fatalf("moderation list %+v total=%d err=%v", hidden, total, err)
+	}
+}
@@ -0,0 +1,835 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"hifzhun-api/pkg/config"
+	"hifzhun-api/pkg/entities"
+	"hifzhun-api/pkg/repositories"
+	"hifzhun-api/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+// BookRevisionDetail is a content revision with its draft tree and the
+// changes it makes compared to the live book (or made, once approved).
+type BookRevisionDetail struct {
+	entities.BookRevision
+	Content entities.BookRevisionContent `json:"content"`
+	Changes []entities.BookContentChange `json:"changes"`
+}
+
+type BookChangelogEntry struct {
+	Version    int                          `json:"version"`
+	Summary    string                       `json:"summary"`
+	ApprovedAt *time.Time                   `json:"approved_at,omitempty"`
+	Changes    []entities.BookContentChange `json:"changes"`
+	Unseen     bool                         `json:"unseen"`
+}
+
+// BookChangelog lists approved content versions of a book. For importers,
+// versions newer than the last one they acknowledged are flagged unseen.
+type BookChangelog struct {
+	BookID         string               `json:"book_id"`
+	CurrentVersion int                  `json:"current_version"`
+	SeenVersion    int                  `json:"seen_version"`
+	UnseenCount    int                  `json:"unseen_count"`
+	Entries        []BookChangelogEntry `json:"entries"`
+}
+
+// ==================== DIFF ====================
+
+// DiffBookRevision compares the live canonical modules/items of a book with a
+// revision's content and returns the changelog lines, modules first.
+func DiffBookRevision(liveModules []entities.BookModule, liveItems []entities.BookItem, content entities.BookRevisionContent) []entities.BookContentChange {
+	changes := make([]entities.BookContentChange, 0)
+
+	liveModByID := make(map[uuid.UUID]entities.BookModule, len(liveModules))
+	for _, m := range liveModules {
+		liveModByID[m.ID] = m
+	}
+	revModIDs := make(map[uuid.UUID]bool, len(content.Modules))
+	for _, m := range content.Modules {
+		revModIDs[m.ID] = true
+		live, ok := liveModByID[m.ID]
+		if !ok {
+			changes = append(changes, entities.BookContentChange{Kind: entities.BookChangeKindModule, Action: entities.BookChangeAdded, ID: m.ID, Title: m.Title})
+			continue
+		}
+		var fields []string
+		if live.Title != m.Title {
+			fields = append(fields, "title")
+		}
+		if live.Description != m.Description {
+			fields = append(fields, "description")
+		}
+		if live.Order != m.Order {
+			fields = append(fields, "order")
+		}
+		if !sameUUIDPtr(live.ParentID, m.ParentID) {
+			fields = append(fields, "parent_id")
+		}
+		if len(fields) > 0 {
+			changes = append(changes, entities.BookContentChange{Kind: entities.BookChangeKindModule, Action: entities.BookChangeUpdated, ID: m.ID, Title: m.Title, Fields: fields})
+		}
+	}
+	for _, m := range liveModules {
+		if !revModIDs[m.ID] {
+			changes = append(changes, entities.BookContentChange{Kind: entities.BookChangeKindModule, Action: entities.BookChangeRemoved, ID: m.ID, Title: m.Title})
+		}
+	}
+
+	liveItemByID := make(map[uuid.UUID]entities.BookItem, len(liveItems))
+	for _, it := range liveItems {
+		liveItemByID[it.ID] = it
+	}
+	revItemIDs := make(map[uuid.UUID]bool, len(content.Items))
+	for _, it := range content.Items {
+		revItemIDs[it.ID] = true
+		live, ok := liveItemByID[it.ID]
+		if !ok {
+			changes = append(changes, entities.BookContentChange{Kind: entities.BookChangeKindItem, Action: entities.BookChangeAdded, ID: it.ID, Title: it.Title})
+			continue
+		}
+		if fields := changedBookItemFields(live, it); len(fields) > 0 {
+			changes = append(changes, entities.BookContentChange{Kind: entities.BookChangeKindItem, Action: entities.BookChangeUpdated, ID: it.ID, Title: it.Title, Fields: fields})
+		}
+	}
+	for _, it := range liveItems {
+		if !revItemIDs[it.ID] {
+			changes = append(changes, entities.BookContentChange{Kind: entities.BookChangeKindItem, Action: entities.BookChangeRemoved, ID: it.ID, Title: it.Title})
+		}
+	}
+
+	return changes
+}
+
+func changedBookItemFields(a, b entities.BookItem) []string {
+	var fields []string
+	if a.Title != b.Title {
+		fields = append(fields, "title")
+	}
+	if a.Content != b.Content {
+		fields = append(fields, "content")
+	}
+	if a.Answer != b.Answer {
+		fields = append(fields, "answer")
+	}
+	if a.Order != b.Order {
+		fields = append(fields, "order")
+	}
+	if bookItemType(a) != bookItemType(b) {
+		fields = append(fields, "type")
+	}
+	if strings.Join(a.Distractors, "\x00") != strings.Join(b.Distractors, "\x00") {
+		fields = append(fields, "distractors")
+	}
+	if !sameUUIDPtr(a.ModuleID, b.ModuleID) {
+		fields = append(fields, "module_id")
+	}
+	if a.ImageURL != b.ImageURL {
+		fields = append(fields, "image_url")
+	}
+	if a.EstimatedReviewSeconds != b.EstimatedReviewSeconds {
+		fields = append(fields, "estimated_review_seconds")
+	}
+	return fields
+}
+
+func sameUUIDPtr(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// planBookRevision turns a revision's content into the row changes for
+// BookRevisionRepository.Apply.
+func planBookRevision(liveModules []entities.BookModule, liveItems []entities.BookItem, content entities.BookRevisionContent) repositories.BookRevisionPlan {
+	var plan repositories.BookRevisionPlan
+
+	liveModByID := make(map[uuid.UUID]entities.BookModule, len(liveModules))
+	for _, m := range liveModules {
+		liveModByID[m.ID] = m
+	}
+	revModByID := make(map[uuid.UUID]entities.BookModule, len(content.Modules))
+	for _, m := range content.Modules {
+		revModByID[m.ID] = m
+	}
+
+	// New modules are created parents first
+	created := make(map[uuid.UUID]bool)
+	var create func(m entities.BookModule)
+	create = func(m entities.BookModule) {
+		if created[m.ID] {
+			return
+		}
+		created[m.ID] = true
+		if m.ParentID != nil {
+			if _, live := liveModByID[*m.ParentID]; !live {
+				if parent, ok := revModByID[*m.ParentID]; ok {
+					create(parent)
+				}
+			}
+		}
+		m.Items = nil
+		plan.CreateModules = append(plan.CreateModules, m)
+	}
+	for _, m := range content.Modules {
+		if live, ok := liveModByID[m.ID]; ok {
+			if live.Title != m.Title || live.Description != m.Description || live.Order != m.Order || !sameUUIDPtr(live.ParentID, m.ParentID) || live.SourceHash != m.SourceHash {
+				m.Items = nil
+				plan.UpdateModules = append(plan.UpdateModules, m)
+			}
+		} else {
+			create(m)
+		}
+	}
+	for _, m := range liveModules {
+		if _, ok := revModByID[m.ID]; !ok {
+			plan.DeleteModuleIDs = append(plan.DeleteModuleIDs, m.ID)
+		}
+	}
+
+	liveItemByID := make(map[uuid.UUID]entities.BookItem, len(liveItems))
+	for _, it := range liveItems {
+		liveItemByID[it.ID] = it
+	}
+	revItemIDs := make(map[uuid.UUID]bool, len(content.Items))
+	for _, it := range content.Items {
+		revItemIDs[it.ID] = true
+		live, ok := liveItemByID[it.ID]
+		if !ok {
+			plan.CreateItems = append(plan.CreateItems, it)
+			continue
+		}
+		if len(changedBookItemFields(live, it)) > 0 || live.SourceHash != it.SourceHash {
+			it.Version = live.Version
+			if bookItemContentChanged(live, it) {
+				it.Version++
+			}
+			plan.UpdateItems = append(plan.UpdateItems, it)
+		}
+	}
+	for _, it := range liveItems {
+		if !revItemIDs[it.ID] {
+			plan.RemoveItemIDs = append(plan.RemoveItemIDs, it.ID)
+		}
+	}
+
+	return plan
+}
+
+// ==================== DRAFT EDITING ====================
+
+func decodeRevisionContent(rev *entities.BookRevision) (*entities.BookRevisionContent, error) {
+	content := &entities.BookRevisionContent{}
+	if len(rev.Content) > 0 {
+		if err := json.Unmarshal(rev.Content, content); err != nil {
+			return nil, errors.New("failed to read revision content")
+		}
+	}
+	if content.Modules == nil {
+		content.Modules = []entities.BookModule{}
+	}
+	if content.Items == nil {
+		content.Items = []entities.BookItem{}
+	}
+	return content, nil
+}
+
+// liveCanonicalContent loads the current canonical modules and items of a book
+func (s *bookService) liveCanonicalContent(bookID string) ([]entities.BookModule, []entities.BookItem, error) {
+	modules, err := s.bookModuleRepo.FindByBookID(bookID)
+	if err != nil {
+		return nil, nil, err
+	}
+	items, err := s.bookItemRepo.FindByBookID(bookID)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range modules {
+		modules[i].Items = nil
+	}
+	return modules, items, nil
+}
+
+// editDraftRevision applies edit to the owner's draft content revision of a
+// published book, creating the draft from the live content when needed.
+// Owner edits of published books never touch the live rows directly.
+func (s *bookService) editDraftRevision(book *entities.Book, authorID uuid.UUID, edit func(content *entities.BookRevisionContent) error) error {
+	if s.revisionRepo == nil {
+		return errors.New("revision repository not available")
+	}
+
+	rev, err := s.revisionRepo.FindOpenByBookID(book.ID.String())
+	var content *entities.BookRevisionContent
+	if err != nil || rev == nil {
+		modules, items, err := s.liveCanonicalContent(book.ID.String())
+		if err != nil {
+			return err
+		}
+		rev = &entities.BookRevision{
+			BookID:      book.ID,
+			AuthorID:    authorID,
+			BaseVersion: book.ContentVersion,
+			Status:      entities.BookRevisionStatusDraft,
+		}
+		content = &entities.BookRevisionContent{Modules: modules, Items: items}
+	} else {
+		if rev.Status == entities.BookRevisionStatusPending {
+			return errors.New("a content revision for this book is pending review")
+		}
+		content, err = decodeRevisionContent(rev)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := edit(content); err != nil {
+		return err
+	}
+
+	raw, err := json.Marshal(content)
+	if err != nil {
+		return err
+	}
+	rev.Content = raw
+	// Editing a rejected revision turns it back into a draft
+	rev.Status = entities.BookRevisionStatusDraft
+
+	if rev.ID == uuid.Nil {
+		return s.revisionRepo.Create(rev)
+	}
+	return s.revisionRepo.Update(rev)
+}
+
+// findDraftOnlyBook resolves the book of a module/item that so far only
+// exists in an open content revision (kind: "modules" | "items").
+func (s *bookService) findDraftOnlyBook(kind, id string) (*entities.Book, error) {
+	if s.revisionRepo == nil {
+		return nil, errors.New("not found")
+	}
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return nil, errors.New("not found")
+	}
+	rev, err := s.revisionRepo.FindOpenContaining(kind, parsed.String())
+	if err != nil {
+		return nil, errors.New("not found")
+	}
+	return s.bookRepo.FindByID(rev.BookID.String())
+}
+
+func findRevisionModule(content *entities.BookRevisionContent, id uuid.UUID) int {
+	for i := range content.Modules {
+		if content.Modules[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func findRevisionItem(content *entities.BookRevisionContent, id uuid.UUID) int {
+	for i := range content.Items {
+		if content.Items[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *bookService) reviseAddModule(book *entities.Book, ownerID uuid.UUID, title, description string, order int, parentID *uuid.UUID) (*entities.BookModule, error) {
+	module := entities.BookModule{
+		ID:          uuid.New(),
+		BookID:      book.ID,
+		ParentID:    parentID,
+		Title:       title,
+		Description: description,
+		Order:       order,
+		CreatedAt:   time.Now().In(config.AppLocation),
+	}
+	err := s.editDraftRevision(book, ownerID, func(content *entities.BookRevisionContent) error {
+		if parentID != nil && findRevisionModule(content, *parentID) < 0 {
+			return errors.New("parent module not found")
+		}
+		content.Modules = append(content.Modules, module)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.logBookActivity(book, ownerID, entities.BookActivityModuleAdded, &module.ID, module.Title)
+	return &module, nil
+}
+
+func (s *bookService) reviseUpdateModule(book *entities.Book, ownerID uuid.UUID, moduleID uuid.UUID, title, description string, order int) (*entities.BookModule, error) {
+	var result entities.BookModule
+	err := s.editDraftRevision(book, ownerID, func(content *entities.BookRevisionContent) error {
+		idx := findRevisionModule(content, moduleID)
+		if idx < 0 {
+			return errors.New("module not found")
+		}
+		m := &content.Modules[idx]
+		if title != "" {
+			m.Title = title
+		}
+		if description != "" {
+			m.Description = description
+		}
+		if order > 0 {
+			m.Order = order
+		}
+		result = *m
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.logBookActivity(book, ownerID, entities.BookActivityModuleUpdated, &result.ID, result.Title)
+	return &result, nil
+}
+
+// reviseDeleteModule removes a module together with its child modules and
+// all their items from the draft.
+func (s *bookService) reviseDeleteModule(book *entities.Book, ownerID uuid.UUID, moduleID uuid.UUID) error {
+	var title string
+	err := s.editDraftRevision(book, ownerID, func(content *entities.BookRevisionContent) error {
+		idx := findRevisionModule(content, moduleID)
+		if idx < 0 {
+			return errors.New("module not found")
+		}
+		title = content.Modules[idx].Title
+
+		removed := map[uuid.UUID]bool{moduleID: true}
+		for changed := true; changed; {
+			changed = false
+			for _, m := range content.Modules {
+				if m.ParentID != nil && removed[*m.ParentID] && !removed[m.ID] {
+					removed[m.ID] = true
+					changed = true
+				}
+			}
+		}
+
+		modules := content.Modules[:0]
+		for _, m := range content.Modules {
+			if !removed[m.ID] {
+				modules = append(modules, m)
+			}
+		}
+		content.Modules = modules
+
+		items := content.Items[:0]
+		for _, it := range content.Items {
+			if it.ModuleID == nil || !removed[*it.ModuleID] {
+				items = append(items, it)
+			}
+		}
+		content.Items = items
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	s.logBookActivity(book, ownerID, entities.BookActivityModuleDeleted, &moduleID, title)
+	return nil
+}
+
+func (s *bookService) reviseAddItem(book *entities.Book, ownerID uuid.UUID, item entities.BookItem) (*entities.BookItem, error) {
+	now := time.Now().In(config.AppLocation)
+	item.ID = uuid.New()
+	item.BookID = book.ID
+	item.CreatedAt = now
+	item.UpdatedAt = now
+	err := s.editDraftRevision(book, ownerID, func(content *entities.BookRevisionContent) error {
+		if item.ModuleID != nil && findRevisionModule(content, *item.ModuleID) < 0 {
+			return errors.New("module not found")
+		}
+		content.Items = append(content.Items, item)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.logBookActivity(book, ownerID, entities.BookActivityItemAdded, &item.ID, item.Title)
+	return &item, nil
+}
+
+func (s *bookService) reviseUpdateItem(book *entities.Book, ownerID uuid.UUID, itemID uuid.UUID, title, content, answer string, order int, estimateVal int, estimateUnit string, imageURL string, removeImage bool, itemType *BookItemTypeInput) (*entities.BookItem, error) {
+	var result entities.BookItem
+	err := s.editDraftRevision(book, ownerID, func(rc *entities.BookRevisionContent) error {
+		idx := findRevisionItem(rc, itemID)
+		if idx < 0 {
+			return errors.New("item not found")
+		}
+		it := &rc.Items[idx]
+		if title != "" {
+			it.Title = title
+		}
+		if content != "" {
+			it.Content = content
+		}
+		if answer != "" {
+			it.Answer = answer
+		}
+		if order > 0 {
+			it.Order = order
+		}
+		if estimateVal > 0 {
+			it.EstimatedReviewSeconds = normalizeEstSeconds(estimateVal, estimateUnit)
+		}
+		applyBookItemType(it, itemType)
+		if err := ValidateBookItemType(it); err != nil {
+			return err
+		}
+		// The live image stays in storage until the revision is approved
+		if imageURL != "" {
+			it.ImageURL = imageURL
+		} else if removeImage {
+			it.ImageURL = ""
+		}
+		it.UpdatedAt = time.Now().In(config.AppLocation)
+		result = *it
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.logBookActivity(book, ownerID, entities.BookActivityItemUpdated, &result.ID, result.Title)
+	return &result, nil
+}
+
+func (s *bookService) reviseDeleteItem(book *entities.Book, ownerID uuid.UUID, itemID uuid.UUID) error {
+	var title string
+	err := s.editDraftRevision(book, ownerID, func(content *entities.BookRevisionContent) error {
+		idx := findRevisionItem(content, itemID)
+		if idx < 0 {
+			return errors.New("item not found")
+		}
+		title = content.Items[idx].Title
+		content.Items = append(content.Items[:idx], content.Items[idx+1:]...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	s.logBookActivity(book, ownerID, entities.BookActivityItemDeleted, &itemID, title)
+	return nil
+}
+
+// ==================== OWNER WORKFLOW ====================
+
+func (s *bookService) revisionDetail(rev *entities.BookRevision) (*BookRevisionDetail, error) {
+	content, err := decodeRevisionContent(rev)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []entities.BookContentChange
+	if rev.Status == entities.BookRevisionStatusApproved {
+		changes = decodeChangelog(rev)
+	} else {
+		modules, items, err := s.liveCanonicalContent(rev.BookID.String())
+		if err != nil {
+			return nil, err
+		}
+		changes = DiffBookRevision(modules, items, *content)
+	}
+
+	return &BookRevisionDetail{BookRevision: *rev, Content: *content, Changes: changes}, nil
+}
+
+func decodeChangelog(rev *entities.BookRevision) []entities.BookContentChange {
+	changes := make([]entities.BookContentChange, 0)
+	if len(rev.Changelog) > 0 {
+		_ = json.Unmarshal(rev.Changelog, &changes)
+	}
+	return changes
+}
+
+func (s *bookService) ownedPublishedBook(bookID string, ownerID uuid.UUID) (*entities.Book, error) {
+	book, err := s.bookRepo.FindByID(bookID)
+	if err != nil {
+		return nil, errors.New("book not found")
+	}
+	if book.OwnerID != ownerID {
+		return nil, errors.New("you don't have permission to manage revisions of this book")
+	}
+	if book.Status != entities.BookStatusPublished {
+		return nil, errors.New("content revisions are only used for published books")
+	}
+	return book, nil
+}
+
+// GetDraftRevision returns the open content revision of a published book
+// with the changes it would make. Editors can read the draft they edit;
+// discarding and submitting it stays with the owner.
+func (s *bookService) GetDraftRevision(bookID string, ownerID uuid.UUID) (*BookRevisionDetail, error) {
+	book, err := s.bookRepo.FindByID(bookID)
+	if err != nil {
+		return nil, errors.New("book not found")
+	}
+	if !s.isBookEditor(book, ownerID) {
+		return nil, errors.New("you don't have permission to manage revisions of this book")
+	}
+	if book.Status != entities.BookStatusPublished {
+		return nil, errors.New("content revisions are only used for published books")
+	}
+
+	rev, err := s.revisionRepo.FindOpenByBookID(bookID)
+	if err != nil {
+		return nil, errors.New("this book has no open content revision")
+	}
+
+	return s.revisionDetail(rev)
+}
+
+// DiscardDraftRevision drops the open (draft or rejected) content revision
+func (s *bookService) DiscardDraftRevision(bookID string, ownerID uuid.UUID) error {
+	if _, err := s.ownedPublishedBook(bookID, ownerID); err != nil {
+		return err
+	}
+
+	rev, err := s.revisionRepo.FindOpenByBookID(bookID)
+	if err != nil {
+		return errors.New("this book has no open content revision")
+	}
+	if rev.Status == entities.BookRevisionStatusPending {
+		return errors.New("a content revision for this book is pending review")
+	}
+
+	return s.revisionRepo.Delete(rev.ID)
+}
+
+// SubmitRevision sends the draft content revision to admin review
+func (s *bookService) SubmitRevision(bookID string, ownerID uuid.UUID, summary string) (*BookRevisionDetail, error) {
+	book, err := s.ownedPublishedBook(bookID, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	rev, err := s.revisionRepo.FindOpenByBookID(bookID)
+	if err != nil {
+		return nil, errors.New("this book has no open content revision")
+	}
+	if rev.Status == entities.BookRevisionStatusPending {
+		return nil, errors.New("this content revision is already pending review")
+	}
+	if err := checkRevisionBase(rev, book); err != nil {
+		return nil, err
+	}
+
+	detail, err := s.revisionDetail(rev)
+	if err != nil {
+		return nil, err
+	}
+	if len(detail.Changes) == 0 {
+		return nil, errors.New("the content revision has no changes")
+	}
+
+	now := time.Now().In(config.AppLocation)
+	rev.Status = entities.BookRevisionStatusPending
+	rev.Summary = summary
+	rev.SubmittedAt = &now
+	rev.RejectReason = ""
+	rev.ReviewedAt = nil
+	rev.ReviewedBy = nil
+	if err := s.revisionRepo.Update(rev); err != nil {
+		return nil, err
+	}
+
+	detail.BookRevision = *rev
+	return detail, nil
+}
+
+// GetBookRevisions returns all content revisions of a book (owner history)
+func (s *bookService) GetBookRevisions(bookID string, ownerID uuid.UUID) ([]entities.BookRevision, error) {
+	book, err := s.bookRepo.FindByID(bookID)
+	if err != nil {
+		return nil, errors.New("book not found")
+	}
+	if book.OwnerID != ownerID {
+		return nil, errors.New("you don't have permission to manage revisions of this book")
+	}
+
+	return s.revisionRepo.FindByBookID(bookID)
+}
+
+// ==================== ADMIN REVIEW ====================
+
+func (s *bookService) GetPendingRevisions() ([]entities.BookRevision, error) {
+	return s.revisionRepo.FindAllPending()
+}
+
+func (s *bookService) GetRevisionDetail(revisionID string) (*BookRevisionDetail, error) {
+	rev, err := s.revisionRepo.FindByID(revisionID)
+	if err != nil {
+		return nil, errors.New("revision not found")
+	}
+	return s.revisionDetail(rev)
+}
+
+// ApproveRevision applies a pending content revision to the live book,
+// bumps its content version and stores the changelog for importers.
+func (s *bookService) ApproveRevision(revisionID string, adminID uuid.UUID) (*BookRevisionDetail, error) {
+	rev, err := s.revisionRepo.FindByID(revisionID)
+	if err != nil {
+		return nil, errors.New("revision not found")
+	}
+	if rev.Status != entities.BookRevisionStatusPending {
+		return nil, errors.New("revision is not pending")
+	}
+
+	book, err := s.bookRepo.FindByID(rev.BookID.String())
+	if err != nil {
+		return nil, errors.New("book not found")
+	}
+	if book.Status != entities.BookStatusPublished {
+		return nil, errors.New("book is not published")
+	}
+	if err := checkRevisionBase(rev, book); err != nil {
+		return nil, err
+	}
+
+	content, err := decodeRevisionContent(rev)
+	if err != nil {
+		return nil, err
+	}
+	modules, items, err := s.liveCanonicalContent(book.ID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	changes := DiffBookRevision(modules, items, *content)
+	changelog, err := json.Marshal(changes)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().In(config.AppLocation)
+	book.ContentVersion++
+	rev.Version = book.ContentVersion
+	// Upstream changes pulled into a fork's revision are now live
+	if rev.SourceVersion > book.SourceVersion {
+		book.SourceVersion = rev.SourceVersion
+	}
+	rev.Status = entities.BookRevisionStatusApproved
+	rev.Changelog = changelog
+	rev.ReviewedAt = &now
+	rev.ReviewedBy = &adminID
+
+	if err := s.revisionRepo.Apply(book, rev, planBookRevision(modules, items, *content)); err != nil {
+		return nil, err
+	}
+
+	deleteClearedItemImages(items, *content)
+	_ = s.refreshSearchText(book)
+
+	return &BookRevisionDetail{BookRevision: *rev, Content: *content, Changes: changes}, nil
+}
+
+// checkRevisionBase rejects a revision drafted from an older content
+// version: applying it would overwrite every row changed since.
+func checkRevisionBase(rev *entities.BookRevision, book *entities.Book) error {
+	if rev.BaseVersion != book.ContentVersion {
+		return fmt.Errorf("this revision was drafted from content version %d but the book is at version %d; it has to be discarded and drafted again", rev.BaseVersion, book.ContentVersion)
+	}
+	return nil
+}
+
+// deleteClearedItemImages deletes images the revision removed from items
+// that stay in the book, like UpdateItem does for remove_image. Removed
+// items keep their image for importers that still have progress on them.
+func deleteClearedItemImages(liveItems []entities.BookItem, content entities.BookRevisionContent) {
+	revByID := make(map[uuid.UUID]entities.BookItem, len(content.Items))
+	for _, it := range content.Items {
+		revByID[it.ID] = it
+	}
+	for _, it := range liveItems {
+		if rit, ok := revByID[it.ID]; ok && it.ImageURL != "" && rit.ImageURL == "" {
+			_ = utils.DeleteFromSupabase(it.ImageURL)
+		}
+	}
+}
+
+func (s *bookService) RejectRevision(revisionID string, adminID uuid.UUID, reason string) error {
+	rev, err := s.revisionRepo.FindByID(revisionID)
+	if err != nil {
+		return errors.New("revision not found")
+	}
+	if rev.Status != entities.BookRevisionStatusPending {
+		return errors.New("revision is not pending")
+	}
+
+	now := time.Now().In(config.AppLocation)
+	rev.Status = entities.BookRevisionStatusRejected
+	rev.ReviewedAt = &now
+	rev.ReviewedBy = &adminID
+	rev.RejectReason = reason
+
+	return s.revisionRepo.Update(rev)
+}
+
+// ==================== IMPORTER CHANGELOG ====================
+
+// GetBookChangelog lists approved content versions of a book the user can view
+func (s *bookService) GetBookChangelog(bookID string, userID uuid.UUID, role string) (*BookChangelog, error) {
+	book, err := s.bookRepo.FindByID(bookID)
+	if err != nil {
+		return nil, errors.New("book not found")
+	}
+	if !s.canViewBook(book, &userID, role) {
+		return nil, errors.New("you don't have access to this book")
+	}
+
+	seen := book.ContentVersion
+	if imported, err := s.classBookRepo.FindImportedBook(userID.String(), bookID); err == nil {
+		seen = imported.SeenVersion
+	}
+
+	revs, err := s.revisionRepo.FindApprovedByBookID(bookID)
+	if err != nil {
+		return nil, err
+	}
+
+	changelog := &BookChangelog{
+		BookID:         bookID,
+		CurrentVersion: book.ContentVersion,
+		SeenVersion:    seen,
+		Entries:        make([]BookChangelogEntry, 0, len(revs)),
+	}
+	for i := range revs {
+		entry := BookChangelogEntry{
+			Version:    revs[i].Version,
+			Summary:    revs[i].Summary,
+			ApprovedAt: revs[i].ReviewedAt,
+			Changes:    decodeChangelog(&revs[i]),
+			Unseen:     revs[i].Version > seen,
+		}
+		if entry.Unseen {
+			changelog.UnseenCount++
+		}
+		changelog.Entries = append(changelog.Entries, entry)
+	}
+	sort.SliceStable(changelog.Entries, func(i, j int) bool { return changelog.Entries[i].Version > changelog.Entries[j].Version })
+
+	return changelog, nil
+}
+
+// MarkChangelogSeen acknowledges all content versions up to the current one
+func (s *bookService) MarkChangelogSeen(bookID string, userID uuid.UUID) error {
+	book, err := s.bookRepo.FindByID(bookID)
+	if err != nil {
+		return errors.New("book not found")
+	}
+	if _, err := s.classBookRepo.FindImportedBook(userID.String(), bookID); err != nil {
+		return errors.New("book is not in your collection")
+	}
+	return s.classBookRepo.UpdateImportedBookSeenVersion(userID.String(), bookID, book.ContentVersion)
+}
@@ -0,0 +1,123 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+
+	"hifzhun-api/pkg/entities"
+	"hifzhun-api/pkg/services"
+)
+
+func TestDiffBookRevision(t *testing.T) {
+	bab1 := entities.BookModule{ID: uuid.New(), Title: "Bab 1", Order: 1}
+	bab2 := entities.BookModule{ID: uuid.New(), Title: "Bab 2", Order: 2}
+	kept := entities.BookItem{ID: uuid.New(), ModuleID: &bab1.ID, Content: "أَ", Answer: "a"}
+	edited := entities.BookItem{ID: uuid.New(), ModuleID: &bab1.ID, Content: "بَ", Answer: "b"}
+	dropped := entities.BookItem{ID: uuid.New(), ModuleID: &bab2.ID, Content: "تَ", Answer: "t"}
+
+	liveModules := []entities.BookModule{bab1, bab2}
+	liveItems := []entities.BookItem{kept, edited, dropped}
+
+	if changes := services.DiffBookRevision(liveModules, liveItems, entities.BookRevisionContent{
+		Modules: liveModules,
+		Items:   liveItems,
+	}); len(changes) != 0 {
+		t.Fatalf("identical content produced changes: %+v", changes)
+	}
+
+	bab1Renamed := bab1
+	bab1Renamed.Title = "Bab 1: Huruf"
+	bab3 := entities.BookModule{ID: uuid.New(), Title: "Bab 3", Order: 3}
+	editedNew := edited
+	editedNew.Answer = "ba"
+	added := entities.BookItem{ID: uuid.New(), ModuleID: &bab3.ID, Content: "ثَ", Answer: "ts"}
+
+	changes := services.DiffBookRevision(liveModules, liveItems, entities.BookRevisionContent{
+		Modules: []entities.BookModule{bab1Renamed, bab3},
+		Items:   []entities.BookItem{kept, editedNew, added},
+	})
+
+	type key struct{ kind, action string }
+	got := make(map[key]entities.BookContentChange)
+	for _, ch := range changes {
+		got[key{ch.Kind, ch.Action}] = ch
+	}
+	if len(changes) != 6 {
+		t.Fatalf("expected 6 changes, got %d: %+v", len(changes), changes)
+	}
+	if ch := got[key{entities.BookChangeKindModule, entities.BookChangeUpdated}]; ch.ID != bab1.ID || len(ch.Fields) != 1 || ch.Fields[0] != "title" {
+		t.Fatalf("module update %+v", ch)
+	}
+	if ch := got[key{entities.BookChangeKindModule, entities.BookChangeAdded}]; ch.ID != bab3.ID {
+		t.Fatalf("module add %+v", ch)
+	}
+	if ch := got[key{entities.BookChangeKindModule, entities.BookChangeRemoved}]; ch.ID != bab2.ID {
+		t.Fatalf("module remove %+v", ch)
+	}
+	if ch := got[key{entities.BookChangeKindItem, entities.BookChangeUpdated}]; ch.ID != edited.ID || len(ch.Fields) != 1 || ch.Fields[0] != "answer" {
+		t.Fatalf("item update %+v", ch)
+	}
+	if ch := got[key{entities.BookChangeKindItem, entities.BookChangeAdded}]; ch.ID != added.ID {
+		t.Fatalf("item add %+v", ch)
+	}
+	if ch := got[key{entities.BookChangeKindItem, entities.BookChangeRemoved}]; ch.ID != dropped.ID {
+		t.Fatalf("item remove %+v", ch)
+	}
+}
+
+func TestApproveRevision(t *testing.T) {
+	env := newTestEnv(t)
+	db := env.db
+	bookRepo := env.bookRepo
+	itemRepo := env.itemRepo
+	svc := env.bookSvc
+
+	owner := uuid.New()
+	book := &entities.Book{OwnerID: owner, Title: "Hadits Arbain", Status: entities.BookStatusDraft}
+	if err := bookRepo.Create(book); err != nil {
+		t.Fatalf("create book: %v", err)
+	}
+	bookID := book.ID.String()
+	x, _ := svc.AddItem(bookID, nil, owner, "Hadits 1", "innamal a'malu", "bin niyyat", 1, 0, "", "", nil)
+	y, _ := svc.AddItem(bookID, nil, owner, "Hadits 2", "bainama nahnu", "jibril", 2, 0, "", "", nil)
+	book.Status = entities.BookStatusPublished
+	if err := bookRepo.Update(book); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+
+	// An importer memorizes the item the owner is about to remove
+	learned := &entities.Item{
+		OwnerID: uuid.New(), SourceType: "book", ContentRef: "book:" + bookID + ":item:" + y.ID.String(),
+		Status: entities.ItemStatusInterval,
+	}
+	if err := itemRepo.Create(learned); err != nil {
+		t.Fatalf("create learner item: %v", err)
+	}
+
+	if _, err := svc.UpdateItem(x.ID.String(), owner, "", "", "bin niyyati", 0, 0, "", "", false, nil); err != nil {
+		t.Fatalf("edit: %v", err)
+	}
+	if err := svc.DeleteItem(y.ID.String(), owner); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	rev, err := svc.SubmitRevision(bookID, owner, "")
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+
+	// A revision drafted from an older content version is not applied
+	db.Model(&entities.Book{}).Where("id = ?", book.ID).Update("content_version", 2)
+	if _, err := svc.ApproveRevision(rev.ID.String(), uuid.New()); err == nil {
+		t.Fatal("approved a revision drafted from an older version")
+	}
+	db.Model(&entities.Book{}).Where("id = ?", book.ID).Update("content_version", 1)
+	if _, err := svc.ApproveRevision(rev.ID.String(), uuid.New()); err != nil {
+		t.Fatalf("approve: %v", err)
+	}
+
+	reloaded, err := itemRepo.FindByIDs([]uuid.UUID{learned.ID})
+	if err != nil || len(reloaded) != 1 || reloaded[0].Status != entities.ItemStatusInterval {
+		t.Errorf("importer item after removal %+v (%v)", reloaded, err)
+	}
+}
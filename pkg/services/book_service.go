@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	"hifzhun-api/pkg/anki"
 	"hifzhun-api/pkg/config"
 	"hifzhun-api/pkg/entities"
 	"hifzhun-api/pkg/repositories"
@@ -18,7 +19,9 @@ type BookService interface {
 	// Book CRUD
 	CreateBook(ownerID uuid.UUID, title, description, coverImage string) (*entities.Book, error)
 	GetMyBooks(ownerID uuid.UUID) ([]entities.Book, error)
-	GetPublishedBooks() ([]PublishedBookWithStats, error)
+	GetPublishedBooks(query CatalogQuery) (*PublishedBookPage, error)
+	UpdateBookCatalogInfo(bookID string, ownerID uuid.UUID, category string, tags []string) (*entities.Book, error)
+	BackfillSearchText() (int, error)
 	GetPublishedBookDetail(bookID string) (*entities.Book, error)
 	GetBookDetail(bookID string, userID *uuid.UUID, role string) (*entities.Book, error)
 	GetBookDetailWithStability(bookID string, userID *uuid.UUID, role string) (*BookDetailWithStability, error)
@@ -28,11 +31,19 @@ type BookService interface {
 	DeleteBook(bookID string, ownerID uuid.UUID) error
 
 	// Publish workflow
-	RequestPublish(bookID string, ownerID uuid.UUID, isEditable bool) error
+	RequestPublish(bookID string, ownerID uuid.UUID, isEditable bool, note string) error
 	GetPendingBooks() ([]entities.Book, error)
-	ApproveBook(bookID string) error
-	RejectBook(bookID string) error
-	DeletePublishedBook(bookID string) error
+	ApproveBook(bookID string, adminID uuid.UUID, reason string, comments []entities.ModerationComment) error
+	RejectBook(bookID string, adminID uuid.UUID, reason string, comments []entities.ModerationComment) error
+	GetModerationTimeline(bookID string, userID uuid.UUID, role string) (*BookModerationTimeline, error)
+
+	// Retirement (admin): retired books leave the catalog, importers keep
+	// read-only access; purge is the audited hard delete
+	RetireBook(bookID string, adminID uuid.UUID, reason string) error
+	RestoreRetiredBook(bookID string) error
+	GetRetiredBooks() ([]entities.Book, error)
+	PurgeBook(bookID string, adminID uuid.UUID, confirmTitle, reason string) (*entities.BookPurgeLog, error)
+	GetPurgeLogs(page, perPage int) ([]entities.BookPurgeLog, int64, error)
 
 	// Book update requests (for published books)
 	RequestBookUpdate(bookID string, ownerID uuid.UUID, title, description, coverImage string) (*entities.BookUpdateRequest, error)
@@ -45,20 +56,36 @@ type BookService interface {
 	AddModule(bookID string, ownerID uuid.UUID, title, description string, order int, parentID *uuid.UUID) (*entities.BookModule, error)
 	UpdateModule(moduleID string, ownerID uuid.UUID, title, description string, order int) (*entities.BookModule, error)
 	DeleteModule(moduleID string, ownerID uuid.UUID) error
+	MoveModule(moduleID string, ownerID uuid.UUID, parentID *uuid.UUID, position int) (*entities.BookModule, error)
 
 	// Item CRUD
-	AddItem(bookID string, moduleID *uuid.UUID, ownerID uuid.UUID, title, content, answer string, order int, estimateVal int, estimateUnit string, imageURL string) (*entities.BookItem, error)
-	UpdateItem(itemID string, ownerID uuid.UUID, title, content, answer string, order int, estimateVal int, estimateUnit string, imageURL string, removeImage bool) (*entities.BookItem, error)
+	AddItem(bookID string, moduleID *uuid.UUID, ownerID uuid.UUID, title, content, answer string, order int, estimateVal int, estimateUnit string, imageURL string, itemType *BookItemTypeInput) (*entities.BookItem, error)
+	UpdateItem(itemID string, ownerID uuid.UUID, title, content, answer string, order int, estimateVal int, estimateUnit string, imageURL string, removeImage bool, itemType *BookItemTypeInput) (*entities.BookItem, error)
 	DeleteItem(itemID string, ownerID uuid.UUID) error
+	MoveItem(itemID string, ownerID uuid.UUID, moduleID *uuid.UUID, position int) (*entities.BookItem, error)
+	ReorderBookChildren(bookID string, ownerID uuid.UUID, in BookReorderInput) error
+
+	// Collaborators (co-authors): owner invites by email, roles editor/viewer
+	InviteCollaborator(bookID string, ownerID uuid.UUID, email, role string) (*entities.BookCollaborator, error)
+	GetCollaborators(bookID string, userID uuid.UUID) ([]entities.BookCollaborator, error)
+	UpdateCollaboratorRole(bookID string, ownerID uuid.UUID, collaboratorID, role string) (*entities.BookCollaborator, error)
+	RemoveCollaborator(bookID string, userID uuid.UUID, collaboratorID string) error
+	GetMyBookInvitations(userID uuid.UUID) ([]BookInvitation, error)
+	RespondToBookInvitation(invitationID string, userID uuid.UUID, accept bool, token string) (*entities.BookCollaborator, error)
+	GetSharedBooks(userID uuid.UUID) ([]SharedBook, error)
+	GetBookActivity(bookID string, userID uuid.UUID, page, perPage int) ([]entities.BookActivity, int64, error)
 
 	// Memorization
 	StartItemMemorization(userID uuid.UUID, bookID, bookItemID string) (*StartMemorizationResult, error)
+	StartBulkMemorization(userID uuid.UUID, bookID string, in BulkStartInput) (*BulkStartResult, error)
 
 	// Add published book into user's "my book items" (creates Item rows for each BookItem)
 	AddPublishedBookToMyBook(userID uuid.UUID, bookID string) (*AddPublishedBookToMyBookResult, error)
 
 	// Copy published book structure into a new draft owned by the user
 	CopyPublishedBookToDraft(userID uuid.UUID, publishedBookID string, title, description, coverImage string) (*entities.Book, error)
+	GetUpstreamStatus(bookID string, ownerID uuid.UUID) (*UpstreamStatus, error)
+	PullUpstreamChanges(bookID string, ownerID uuid.UUID) (*UpstreamPullResult, error)
 
 	// My Book Collection
 	GetMyBookCollection(userID uuid.UUID) ([]BookCollectionItem, error)
@@ -67,12 +94,41 @@ type BookService interface {
 	// Book Item Overrides
 	GetMyOverride(userID uuid.UUID, bookItemID string) (*entities.BookItemOverride, error)
 	RemoveMyOverride(userID uuid.UUID, bookItemID string) error
+	GetStaleOverrides(userID uuid.UUID) ([]StaleOverride, error)
+	GetOverrideDiff(userID uuid.UUID, bookItemID string) (*OverrideDiff, error)
+	ResolveOverride(userID uuid.UUID, bookItemID, strategy string, resolutions map[string]string) (*ResolvedBookItem, error)
+
+	// Bulk import / export (CSV & JSON)
+	ImportBook(ownerID uuid.UUID, doc *BookTransferDocument) (*BookImportResult, error)
+	ExportBook(bookID string, userID *uuid.UUID, role string) (*BookTransferDocument, error)
+	ImportAnkiPackage(ownerID uuid.UUID, pkg *anki.Package, opts AnkiImportOptions) (*AnkiImportResult, error)
+
+	// Content revisions (module/item changes of published books)
+	GetDraftRevision(bookID string, ownerID uuid.UUID) (*BookRevisionDetail, error)
+	DiscardDraftRevision(bookID string, ownerID uuid.UUID) error
+	SubmitRevision(bookID string, ownerID uuid.UUID, summary string) (*BookRevisionDetail, error)
+	GetBookRevisions(bookID string, ownerID uuid.UUID) ([]entities.BookRevision, error)
+	GetPendingRevisions() ([]entities.BookRevision, error)
+	GetRevisionDetail(revisionID string) (*BookRevisionDetail, error)
+	ApproveRevision(revisionID string, adminID uuid.UUID) (*BookRevisionDetail, error)
+	RejectRevision(revisionID string, adminID uuid.UUID, reason string) error
+	GetBookChangelog(bookID string, userID uuid.UUID, role string) (*BookChangelog, error)
+	MarkChangelogSeen(bookID string, userID uuid.UUID) error
+
+	// Ratings & reviews
+	GetBookReviews(bookID string, userID uuid.UUID, page, perPage int) (*BookReviewList, error)
+	SaveMyReview(bookID string, userID uuid.UUID, rating int, body string) (*entities.BookReview, error)
+	DeleteMyReview(bookID string, userID uuid.UUID) error
+	GetReviewsForModeration(hiddenOnly bool, page, perPage int) ([]repositories.BookReviewView, int64, error)
+	HideReview(reviewID string, adminID uuid.UUID, reason string) error
+	UnhideReview(reviewID string, adminID uuid.UUID) error
 }
 
 // BookItemWithStability represents a BookItem with stability information
 type BookItemWithStability struct {
 	entities.BookItem
-	Stability string `json:"stability"` // "item belum masuk ujian" or days until next review
+	Stability string          `json:"stability"` // "item belum masuk ujian" or days until next review
+	Render    *BookItemRender `json:"render,omitempty"`
 }
 
 // BookDetailWithStability represents book detail with stability information for items
@@ -139,13 +195,23 @@ type ModuleNodeWithItems struct {
 	Children    []ModuleNodeWithItems   `json:"children"`
 }
 
-// StartMemorizationResult represents the result of starting book item memorization
+// StartMemorizationResult represents the result of starting book item memorization.
+// For cloze items ItemID/Status belong to the first cloze and Clozes lists all.
 type StartMemorizationResult struct {
-	ItemID     uuid.UUID `json:"item_id"`
-	BookItemID uuid.UUID `json:"book_item_id"`
-	BookTitle  string    `json:"book_title"`
-	ItemTitle  string    `json:"item_title"`
-	Status     string    `json:"status"`
+	ItemID     uuid.UUID      `json:"item_id"`
+	BookItemID uuid.UUID      `json:"book_item_id"`
+	BookTitle  string         `json:"book_title"`
+	ItemTitle  string         `json:"item_title"`
+	Status     string         `json:"status"`
+	Clozes     []StartedCloze `json:"clozes,omitempty"`
+	StartDate  string         `json:"start_date,omitempty"` // bulk start with per_day: first day in daily tasks
+}
+
+// StartedCloze is the memorization Item of one cloze
+type StartedCloze struct {
+	Index  int       `json:"index"`
+	ItemID uuid.UUID `json:"item_id"`
+	Status string    `json:"status"`
 }
 
 type AddPublishedBookToMyBookResult struct {
@@ -164,12 +230,25 @@ type BookCollectionItem struct {
 	OwnerName   string `json:"owner_name,omitempty"`
 	ItemCount   int    `json:"item_count"`
 	AddedAt     string `json:"added_at"`
+	// Status "retired": no longer in the catalog, read-only but progress kept
+	Status string `json:"status"`
+
+	// Content versions approved since the user last read the changelog
+	ContentVersion int `json:"content_version"`
+	UnseenVersions int `json:"unseen_versions"`
 }
 
 // PublishedBookWithStats wraps a Book with additional stats for the published listing
 type PublishedBookWithStats struct {
 	entities.Book
 	TotalAdded int64 `json:"total_added"`
+
+	// Rating agregat dari ulasan yang tidak disembunyikan
+	RatingAverage float64 `json:"rating_average"`
+	RatingCount   int64   `json:"rating_count"`
+
+	// ForkCount: jumlah copy-to-draft yang dibuat dari buku ini
+	ForkCount int64 `json:"fork_count"`
 }
 
 type bookService struct {
@@ -181,6 +260,13 @@ type bookService struct {
 	userRepo          repositories.UserRepository
 	updateRequestRepo *repositories.BookUpdateRequestRepository
 	overrideRepo      repositories.BookItemOverrideRepository
+	revisionRepo      *repositories.BookRevisionRepository
+	reviewRepo        *repositories.BookReviewRepository
+	publishRepo       *repositories.BookPublishRequestRepository
+	purgeLogRepo      *repositories.BookPurgeLogRepository
+	collaboratorRepo  *repositories.BookCollaboratorRepository
+	activityRepo      *repositories.BookActivityRepository
+	notifier          *NotificationService
 }
 
 func NewBookService(
@@ -192,6 +278,13 @@ func NewBookService(
 	userRepo repositories.UserRepository,
 	updateRequestRepo *repositories.BookUpdateRequestRepository,
 	overrideRepo repositories.BookItemOverrideRepository,
+	revisionRepo *repositories.BookRevisionRepository,
+	reviewRepo *repositories.BookReviewRepository,
+	publishRepo *repositories.BookPublishRequestRepository,
+	purgeLogRepo *repositories.BookPurgeLogRepository,
+	collaboratorRepo *repositories.BookCollaboratorRepository,
+	activityRepo *repositories.BookActivityRepository,
+	notifier *NotificationService,
 ) BookService {
 	return &bookService{
 		bookRepo:          bookRepo,
@@ -202,6 +295,13 @@ func NewBookService(
 		userRepo:          userRepo,
 		updateRequestRepo: updateRequestRepo,
 		overrideRepo:      overrideRepo,
+		revisionRepo:      revisionRepo,
+		reviewRepo:        reviewRepo,
+		publishRepo:       publishRepo,
+		purgeLogRepo:      purgeLogRepo,
+		collaboratorRepo:  collaboratorRepo,
+		activityRepo:      activityRepo,
+		notifier:          notifier,
 	}
 }
 
@@ -235,6 +335,11 @@ func (s *bookService) canViewBook(book *entities.Book, userID *uuid.UUID, role s
 		return true
 	}
 
+	// Owner and collaborators (editor or viewer), whatever the status
+	if userID != nil && (book.OwnerID == *userID || s.collaboratorRole(book, *userID) != "") {
+		return true
+	}
+
 	if s.classBookRepo != nil {
 		isClassBook, err := s.classBookRepo.IsBookAssignedToClass(book.ID.String())
 		if err == nil && isClassBook {
@@ -242,6 +347,10 @@ func (s *bookService) canViewBook(book *entities.Book, userID *uuid.UUID, role s
 		}
 	}
 
+	if book.Status == entities.BookStatusRetired && s.canReadRetiredBook(book, userID) {
+		return true
+	}
+
 	return book.Status == entities.BookStatusPublished || s.canAccessClassBook(book.ID.String(), book.OwnerID, userID)
 }
 
@@ -271,23 +380,6 @@ func (s *bookService) GetMyBooks(ownerID uuid.UUID) ([]entities.Book, error) {
 	return s.bookRepo.FindByOwner(ownerID.String())
 }
 
-func (s *bookService) GetPublishedBooks() ([]PublishedBookWithStats, error) {
-	books, err := s.bookRepo.FindPublished()
-	if err != nil {
-		return nil, err
-	}
-
-	result := make([]PublishedBookWithStats, 0, len(books))
-	for _, book := range books {
-		count, _ := s.itemRepo.CountDistinctOwnersByBookID(book.ID.String())
-		result = append(result, PublishedBookWithStats{
-			Book:       book,
-			TotalAdded: count,
-		})
-	}
-	return result, nil
-}
-
 func (s *bookService) GetPublishedBookDetail(bookID string) (*entities.Book, error) {
 	book, err := s.bookRepo.FindByIDWithRelations(bookID)
 	if err != nil {
@@ -297,6 +389,7 @@ func (s *bookService) GetPublishedBookDetail(bookID string) (*entities.Book, err
 	if book.Status != entities.BookStatusPublished {
 		return nil, errors.New("book is not published")
 	}
+	s.attachForkOrigin(book)
 
 	return book, nil
 }
@@ -310,6 +403,7 @@ func (s *bookService) GetBookDetail(bookID string, userID *uuid.UUID, role strin
 	if !s.canViewBook(book, userID, role) {
 		return nil, errors.New("you don't have access to this book")
 	}
+	s.attachForkOrigin(book)
 
 	return book, nil
 }
@@ -348,12 +442,12 @@ func (s *bookService) GetBookDetailWithStability(bookID string, userID *uuid.UUI
 	}
 
 	// Fetch Item entities for stability calculation (if user is logged in)
-	itemByContentRef := make(map[string]*entities.Item)
+	itemsByContentRef := make(map[string][]entities.Item)
 	if userID != nil {
 		for _, ref := range contentRefs {
 			existingItems, err := s.itemRepo.FindByOwnerAndContentRef(*userID, ref)
 			if err == nil && len(existingItems) > 0 {
-				itemByContentRef[ref] = &existingItems[0]
+				itemsByContentRef[ref] = existingItems
 			}
 		}
 	}
@@ -377,11 +471,7 @@ func (s *bookService) GetBookDetailWithStability(bookID string, userID *uuid.UUI
 	itemsByModule := make(map[string][]BookItemWithStability)
 	for _, it := range items {
 		contentRef := "book:" + bookID + ":item:" + it.ID.String()
-		stability := calculateStability(itemByContentRef[contentRef])
-		itemWithStability := BookItemWithStability{
-			BookItem:  it,
-			Stability: stability,
-		}
+		itemWithStability := newBookItemWithStability(it, itemsByContentRef[contentRef])
 		if it.ModuleID == nil {
 			bookItems = append(bookItems, itemWithStability)
 			continue
@@ -407,6 +497,7 @@ func (s *bookService) GetBookDetailWithStability(bookID string, userID *uuid.UUI
 		return nodes
 	}
 
+	s.attachForkOrigin(book)
 	return &BookDetailWithStability{
 		Book:    *book,
 		Items:   bookItems,
@@ -420,7 +511,9 @@ func (s *bookService) GetBookDetailForAdmin(bookID string) (*entities.Book, erro
 		return nil, errors.New("book not found")
 	}
 
-	// Admin can view any book regardless of status
+	// Admin can view any book regardless of status; forked_from helps
+	// spot copies submitted as original work
+	s.attachForkOrigin(book)
 	return book, nil
 }
 
@@ -458,12 +551,12 @@ func (s *bookService) GetBookTree(bookID string, userID *uuid.UUID, role string)
 	}
 
 	// Fetch Item entities for stability calculation (if user is logged in)
-	itemByContentRef := make(map[string]*entities.Item)
+	itemsByContentRef := make(map[string][]entities.Item)
 	if userID != nil {
 		for _, ref := range contentRefs {
 			existingItems, err := s.itemRepo.FindByOwnerAndContentRef(*userID, ref)
 			if err == nil && len(existingItems) > 0 {
-				itemByContentRef[ref] = &existingItems[0]
+				itemsByContentRef[ref] = existingItems
 			}
 		}
 	}
@@ -473,12 +566,7 @@ func (s *bookService) GetBookTree(bookID string, userID *uuid.UUID, role string)
 	itemsByModule := make(map[string][]BookItemWithStability)
 	for _, it := range items {
 		contentRef := "book:" + bookID + ":item:" + it.ID.String()
-		stability := calculateStability(itemByContentRef[contentRef])
-
-		itemWithStability := BookItemWithStability{
-			BookItem:  it,
-			Stability: stability,
-		}
+		itemWithStability := newBookItemWithStability(it, itemsByContentRef[contentRef])
 
 		if it.ModuleID == nil {
 			bookItems = append(bookItems, itemWithStability)
@@ -566,6 +654,8 @@ func (s *bookService) AddPublishedBookToMyBook(userID uuid.UUID, bookID string)
 	if err := s.classBookRepo.CreateImportedBook(userID.String(), bookID); err != nil {
 		return nil, err
 	}
+	// Changes made before the import are not news to this user
+	_ = s.classBookRepo.UpdateImportedBookSeenVersion(userID.String(), bookID, book.ContentVersion)
 
 	return &AddPublishedBookToMyBookResult{
 		BookID:     bookID,
@@ -606,13 +696,15 @@ func (s *bookService) CopyPublishedBookToDraft(
 	}
 
 	draft := &entities.Book{
-		OwnerID:     userID,
-		Title:       finalTitle,
-		Description: finalDesc,
-		CoverImage:  finalCover,
-		IsEditable:  srcBook.IsEditable, // inherit editable flag from source book
-		Status:      entities.BookStatusDraft,
-		PublishedAt: nil,
+		OwnerID:       userID,
+		Title:         finalTitle,
+		Description:   finalDesc,
+		CoverImage:    finalCover,
+		IsEditable:    srcBook.IsEditable, // inherit editable flag from source book
+		SourceBookID:  &srcBook.ID,
+		SourceVersion: srcBook.ContentVersion,
+		Status:        entities.BookStatusDraft,
+		PublishedAt:   nil,
 	}
 	if err := s.bookRepo.Create(draft); err != nil {
 		return nil, err
@@ -621,12 +713,15 @@ func (s *bookService) CopyPublishedBookToDraft(
 	// 1) Copy modules first (without parent pointers), so we can map IDs.
 	newModulesByOldID := make(map[uuid.UUID]*entities.BookModule)
 	for _, m := range srcBook.Modules {
+		srcModuleID := m.ID
 		newMod := &entities.BookModule{
-			BookID:      draft.ID,
-			ParentID:    nil, // fix in second pass
-			Title:       m.Title,
-			Description: m.Description,
-			Order:       m.Order,
+			BookID:         draft.ID,
+			ParentID:       nil, // fix in second pass
+			Title:          m.Title,
+			Description:    m.Description,
+			Order:          m.Order,
+			SourceModuleID: &srcModuleID,
+			SourceHash:     upstreamModuleHash(m),
 		}
 		if err := s.bookModuleRepo.Create(newMod); err != nil {
 			return nil, err
@@ -656,6 +751,11 @@ func (s *bookService) CopyPublishedBookToDraft(
 
 	// 3) Copy book-level items (module_id IS NULL)
 	for _, it := range srcBook.Items {
+		// Importers' personal items are not part of the published book
+		if it.ImporterID != nil {
+			continue
+		}
+		srcItemID := it.ID
 		newItem := &entities.BookItem{
 			BookID:                 draft.ID,
 			ModuleID:               nil,
@@ -663,7 +763,11 @@ func (s *bookService) CopyPublishedBookToDraft(
 			Content:                it.Content,
 			Answer:                 it.Answer,
 			Order:                  it.Order,
+			Type:                   it.Type,
+			Distractors:            it.Distractors,
 			EstimatedReviewSeconds: it.EstimatedReviewSeconds,
+			SourceItemID:           &srcItemID,
+			SourceHash:             upstreamItemHash(it),
 		}
 		if err := s.bookItemRepo.Create(newItem); err != nil {
 			return nil, err
@@ -677,7 +781,11 @@ func (s *bookService) CopyPublishedBookToDraft(
 			return nil, errors.New("failed to copy module")
 		}
 		for _, it := range m.Items {
+			if it.ImporterID != nil {
+				continue
+			}
 			modID := newMod.ID
+			srcItemID := it.ID
 			newItem := &entities.BookItem{
 				BookID:                 draft.ID,
 				ModuleID:               &modID,
@@ -685,7 +793,11 @@ func (s *bookService) CopyPublishedBookToDraft(
 				Content:                it.Content,
 				Answer:                 it.Answer,
 				Order:                  it.Order,
+				Type:                   it.Type,
+				Distractors:            it.Distractors,
 				EstimatedReviewSeconds: it.EstimatedReviewSeconds,
+				SourceItemID:           &srcItemID,
+				SourceHash:             upstreamItemHash(it),
 			}
 			if err := s.bookItemRepo.Create(newItem); err != nil {
 				return nil, err
@@ -706,6 +818,10 @@ func (s *bookService) UpdateBook(bookID string, ownerID uuid.UUID, title, descri
 		return nil, errors.New("you don't have permission to update this book")
 	}
 
+	if err := ensureNotRetired(book); err != nil {
+		return nil, err
+	}
+
 	// For published books, create an update request instead of direct update
 	if book.Status == entities.BookStatusPublished {
 		// Check if there's already a pending update request
@@ -772,6 +888,10 @@ func (s *bookService) DeleteBook(bookID string, ownerID uuid.UUID) error {
 	if book.Status == entities.BookStatusPublished {
 		return errors.New("cannot delete published book")
 	}
+	// Importers still learn from a retired book; only an admin purge removes it
+	if book.Status == entities.BookStatusRetired {
+		return errors.New("cannot delete retired book")
+	}
 
 	// Delete memorization Item rows created from this book before deleting the
 	// book structure itself.
@@ -786,13 +906,26 @@ func (s *bookService) DeleteBook(bookID string, ownerID uuid.UUID) error {
 	if err := s.bookModuleRepo.DeleteByBookID(bookID); err != nil {
 		return err
 	}
+	if err := s.publishRepo.DeleteByBookID(bookID); err != nil {
+		return err
+	}
+	if s.collaboratorRepo != nil {
+		if err := s.collaboratorRepo.DeleteByBookID(bookID); err != nil {
+			return err
+		}
+	}
+	if s.activityRepo != nil {
+		if err := s.activityRepo.DeleteByBookID(bookID); err != nil {
+			return err
+		}
+	}
 
 	return s.bookRepo.Delete(bookID)
 }
 
 // ==================== PUBLISH WORKFLOW ====================
 
-func (s *bookService) RequestPublish(bookID string, ownerID uuid.UUID, isEditable bool) error {
+func (s *bookService) RequestPublish(bookID string, ownerID uuid.UUID, isEditable bool, note string) error {
 	book, err := s.bookRepo.FindByID(bookID)
 	if err != nil {
 		return errors.New("book not found")
@@ -812,14 +945,27 @@ func (s *bookService) RequestPublish(bookID string, ownerID uuid.UUID, isEditabl
 		return err
 	}
 
-	return s.bookRepo.UpdateStatus(bookID, entities.BookStatusPending)
+	if err := s.bookRepo.UpdateStatus(bookID, entities.BookStatusPending); err != nil {
+		return err
+	}
+
+	// Every (re)submission gets its own moderation record
+	_, err = s.openPublishRequest(book, strings.TrimSpace(note))
+	return err
 }
 
 func (s *bookService) GetPendingBooks() ([]entities.Book, error) {
-	return s.bookRepo.FindPendingPublish()
+	books, err := s.bookRepo.FindPendingPublish()
+	if err != nil {
+		return nil, err
+	}
+	for i := range books {
+		s.attachForkOrigin(&books[i])
+	}
+	return books, nil
 }
 
-func (s *bookService) ApproveBook(bookID string) error {
+func (s *bookService) ApproveBook(bookID string, adminID uuid.UUID, reason string, comments []entities.ModerationComment) error {
 	book, err := s.bookRepo.FindByID(bookID)
 	if err != nil {
 		return errors.New("book not found")
@@ -829,48 +975,45 @@ func (s *bookService) ApproveBook(bookID string) error {
 		return errors.New("book is not pending for approval")
 	}
 
-	return s.bookRepo.UpdateStatus(bookID, entities.BookStatusPublished)
-}
-
-func (s *bookService) RejectBook(bookID string) error {
-	book, err := s.bookRepo.FindByID(bookID)
+	comments, err = s.validateModerationComments(book, comments)
 	if err != nil {
-		return errors.New("book not found")
+		return err
 	}
 
-	if book.Status != entities.BookStatusPending {
-		return errors.New("book is not pending for approval")
+	if err := s.bookRepo.UpdateStatus(bookID, entities.BookStatusPublished); err != nil {
+		return err
 	}
-
-	return s.bookRepo.UpdateStatus(bookID, entities.BookStatusRejected)
+	if err := s.decidePublishRequest(book, adminID, entities.BookPublishStatusApproved, reason, comments); err != nil {
+		return err
+	}
+	// Index the book for catalog search
+	return s.refreshSearchText(book)
 }
 
-// DeletePublishedBook deletes a published book (Admin only)
-func (s *bookService) DeletePublishedBook(bookID string) error {
+func (s *bookService) RejectBook(bookID string, adminID uuid.UUID, reason string, comments []entities.ModerationComment) error {
 	book, err := s.bookRepo.FindByID(bookID)
 	if err != nil {
 		return errors.New("book not found")
 	}
 
-	if book.Status != entities.BookStatusPublished {
-		return errors.New("book is not published")
+	if book.Status != entities.BookStatusPending {
+		return errors.New("book is not pending for approval")
 	}
 
-	// Delete memorization Item rows created from this book before deleting the
-	// book structure itself.
-	if err := s.itemRepo.DeleteBookItemsByBookID(bookID); err != nil {
-		return err
+	// The author needs to know what to fix
+	reason = strings.TrimSpace(reason)
+	if reason == "" && len(comments) == 0 {
+		return errors.New("a reason or at least one comment is required to reject a book")
 	}
-
-	// Delete all related book items and modules
-	if err := s.bookItemRepo.DeleteByBookID(bookID); err != nil {
+	comments, err = s.validateModerationComments(book, comments)
+	if err != nil {
 		return err
 	}
-	if err := s.bookModuleRepo.DeleteByBookID(bookID); err != nil {
+
+	if err := s.bookRepo.UpdateStatus(bookID, entities.BookStatusRejected); err != nil {
 		return err
 	}
-
-	return s.bookRepo.Delete(bookID)
+	return s.decidePublishRequest(book, adminID, entities.BookPublishStatusRejected, reason, comments)
 }
 
 // ==================== BOOK UPDATE REQUESTS ====================
@@ -954,6 +1097,9 @@ func (s *bookService) ApproveBookUpdate(requestID string, adminID uuid.UUID) err
 	if err := s.bookRepo.Update(book); err != nil {
 		return err
 	}
+	if err := s.refreshSearchText(book); err != nil {
+		return err
+	}
 
 	return s.updateRequestRepo.Update(updateReq)
 }
@@ -991,22 +1137,26 @@ func (s *bookService) AddModule(bookID string, ownerID uuid.UUID, title, descrip
 		return nil, errors.New("book not found")
 	}
 
-	// For published books: allow anyone if is_editable=true, owner always can edit
-	// For non-published books: only owner can edit
-	if book.Status == entities.BookStatusPublished {
-		if !book.IsEditable && book.OwnerID != ownerID {
-			return nil, errors.New("this book is not editable")
-		}
-	} else {
-		if book.OwnerID != ownerID {
-			return nil, errors.New("you don't have permission to add module to this book")
-		}
+	if err := ensureNotRetired(book); err != nil {
+		return nil, err
+	}
+
+	// Modules are shared by every importer, so only the owner and editors
+	// change them, also on published books marked is_editable
+	if !s.isBookEditor(book, ownerID) {
+		return nil, errors.New("you don't have permission to add module to this book")
 	}
 
 	if title == "" {
 		return nil, errors.New("module title is required")
 	}
 
+	// Owner of a published book edits a draft content revision; importers
+	// see the change once an admin approves it.
+	if book.Status == entities.BookStatusPublished {
+		return s.reviseAddModule(book, ownerID, title, description, order, parentID)
+	}
+
 	module := &entities.BookModule{
 		BookID:      uuid.MustParse(bookID),
 		ParentID:    parentID,
@@ -1018,6 +1168,7 @@ func (s *bookService) AddModule(bookID string, ownerID uuid.UUID, title, descrip
 	if err := s.bookModuleRepo.Create(module); err != nil {
 		return nil, err
 	}
+	s.logBookActivity(book, ownerID, entities.BookActivityModuleAdded, &module.ID, module.Title)
 
 	return module, nil
 }
@@ -1025,7 +1176,12 @@ func (s *bookService) AddModule(bookID string, ownerID uuid.UUID, title, descrip
 func (s *bookService) UpdateModule(moduleID string, ownerID uuid.UUID, title, description string, order int) (*entities.BookModule, error) {
 	module, err := s.bookModuleRepo.FindByID(moduleID)
 	if err != nil {
-		return nil, errors.New("module not found")
+		// The module may so far only exist in a draft content revision
+		book, findErr := s.findDraftOnlyBook("modules", moduleID)
+		if findErr != nil || !s.isBookEditor(book, ownerID) {
+			return nil, errors.New("module not found")
+		}
+		return s.reviseUpdateModule(book, ownerID, uuid.MustParse(moduleID), title, description, order)
 	}
 
 	book, err := s.bookRepo.FindByID(module.BookID.String())
@@ -1033,16 +1189,18 @@ func (s *bookService) UpdateModule(moduleID string, ownerID uuid.UUID, title, de
 		return nil, errors.New("book not found")
 	}
 
-	// Published books: allow anyone if is_editable=true, owner always can edit
-	// Non-published books: only owner can edit
+	if err := ensureNotRetired(book); err != nil {
+		return nil, err
+	}
+
+	// Modules are shared by every importer, so only the owner and editors
+	// change them, also on published books marked is_editable
+	if !s.isBookEditor(book, ownerID) {
+		return nil, errors.New("you don't have permission to update this module")
+	}
+
 	if book.Status == entities.BookStatusPublished {
-		if !book.IsEditable && book.OwnerID != ownerID {
-			return nil, errors.New("this book is not editable")
-		}
-	} else {
-		if book.OwnerID != ownerID {
-			return nil, errors.New("you don't have permission to update this module")
-		}
+		return s.reviseUpdateModule(book, ownerID, module.ID, title, description, order)
 	}
 
 	if title != "" {
@@ -1058,6 +1216,7 @@ func (s *bookService) UpdateModule(moduleID string, ownerID uuid.UUID, title, de
 	if err := s.bookModuleRepo.Update(module); err != nil {
 		return nil, err
 	}
+	s.logBookActivity(book, ownerID, entities.BookActivityModuleUpdated, &module.ID, module.Title)
 
 	return module, nil
 }
@@ -1065,7 +1224,11 @@ func (s *bookService) UpdateModule(moduleID string, ownerID uuid.UUID, title, de
 func (s *bookService) DeleteModule(moduleID string, ownerID uuid.UUID) error {
 	module, err := s.bookModuleRepo.FindByID(moduleID)
 	if err != nil {
-		return errors.New("module not found")
+		book, findErr := s.findDraftOnlyBook("modules", moduleID)
+		if findErr != nil || !s.isBookEditor(book, ownerID) {
+			return errors.New("module not found")
+		}
+		return s.reviseDeleteModule(book, ownerID, uuid.MustParse(moduleID))
 	}
 
 	book, err := s.bookRepo.FindByID(module.BookID.String())
@@ -1073,16 +1236,18 @@ func (s *bookService) DeleteModule(moduleID string, ownerID uuid.UUID) error {
 		return errors.New("book not found")
 	}
 
-	// Published books: allow anyone if is_editable=true, owner always can delete
-	// Non-published books: only owner can delete
+	if err := ensureNotRetired(book); err != nil {
+		return err
+	}
+
+	// Deleting a module removes every importer's progress on its items, so
+	// only the owner and editors may, also on published books marked is_editable
+	if !s.isBookEditor(book, ownerID) {
+		return errors.New("you don't have permission to delete this module")
+	}
+
 	if book.Status == entities.BookStatusPublished {
-		if !book.IsEditable && book.OwnerID != ownerID {
-			return errors.New("this book is not editable")
-		}
-	} else {
-		if book.OwnerID != ownerID {
-			return errors.New("you don't have permission to delete this module")
-		}
+		return s.reviseDeleteModule(book, ownerID, module.ID)
 	}
 
 	// Get all BookItems in this module to find their Item entities
@@ -1105,7 +1270,11 @@ func (s *bookService) DeleteModule(moduleID string, ownerID uuid.UUID) error {
 		return err
 	}
 
-	return s.bookModuleRepo.Delete(moduleID)
+	if err := s.bookModuleRepo.Delete(moduleID); err != nil {
+		return err
+	}
+	s.logBookActivity(book, ownerID, entities.BookActivityModuleDeleted, &module.ID, module.Title)
+	return nil
 }
 
 // ==================== ITEM CRUD ====================
@@ -1114,6 +1283,8 @@ func (s *bookService) DeleteModule(moduleID string, ownerID uuid.UUID) error {
 type ResolvedBookItem struct {
 	entities.BookItem
 	HasOverride bool `json:"has_override"` // true if user has personal override for this item
+	// OverrideStale: the canonical item changed after the override was made
+	OverrideStale bool `json:"override_stale,omitempty"`
 }
 
 // ResolveBookItemContent resolves a BookItem's content for a specific user.
@@ -1143,6 +1314,7 @@ func ResolveBookItemContent(
 
 	// Apply override: non-empty fields from override replace canonical values.
 	resolved.HasOverride = true
+	resolved.OverrideStale = canonical.Version > override.BaseVersion
 	if override.Title != "" {
 		resolved.Title = override.Title
 	}
@@ -1198,6 +1370,7 @@ func ResolveBatchBookItemContent(
 
 		if override, exists := overrideMap[canonical.ID]; exists && override != nil {
 			resolved.HasOverride = true
+			resolved.OverrideStale = canonical.Version > override.BaseVersion
 			if override.Title != "" {
 				resolved.Title = override.Title
 			}
@@ -1234,20 +1407,26 @@ func normalizeEstSeconds(estimateVal int, estimateUnit string) int {
 	}
 }
 
-func (s *bookService) AddItem(bookID string, moduleID *uuid.UUID, ownerID uuid.UUID, title, content, answer string, order int, estimateVal int, estimateUnit string, imageURL string) (*entities.BookItem, error) {
+func (s *bookService) AddItem(bookID string, moduleID *uuid.UUID, ownerID uuid.UUID, title, content, answer string, order int, estimateVal int, estimateUnit string, imageURL string, itemType *BookItemTypeInput) (*entities.BookItem, error) {
 	book, err := s.bookRepo.FindByID(bookID)
 	if err != nil {
 		return nil, errors.New("book not found")
 	}
 
-	// Published books: allow anyone if is_editable=true, owner always can edit
-	// Non-published books: only owner can edit
+	if err := ensureNotRetired(book); err != nil {
+		return nil, err
+	}
+
+	isEditor := s.isBookEditor(book, ownerID)
+
+	// Published books: allow anyone if is_editable=true, owner and editors always can edit
+	// Non-published books: only owner and editors can edit
 	if book.Status == entities.BookStatusPublished {
-		if !book.IsEditable && book.OwnerID != ownerID {
+		if !book.IsEditable && !isEditor {
 			return nil, errors.New("this book is not editable")
 		}
 	} else {
-		if book.OwnerID != ownerID {
+		if !isEditor {
 			return nil, errors.New("you don't have permission to add item to this book")
 		}
 	}
@@ -1257,6 +1436,28 @@ func (s *bookService) AddItem(bookID string, moduleID *uuid.UUID, ownerID uuid.U
 		return nil, errors.New("either content or answer must be provided")
 	}
 
+	typed := entities.BookItem{Content: content, Answer: answer}
+	applyBookItemType(&typed, itemType)
+	if err := ValidateBookItemType(&typed); err != nil {
+		return nil, err
+	}
+
+	// Owner of a published book → draft content revision (module is
+	// validated against the draft, it may not be live yet)
+	if book.Status == entities.BookStatusPublished && isEditor {
+		return s.reviseAddItem(book, ownerID, entities.BookItem{
+			ModuleID:               moduleID,
+			Title:                  title,
+			Content:                content,
+			Answer:                 answer,
+			Order:                  order,
+			Type:                   typed.Type,
+			Distractors:            typed.Distractors,
+			EstimatedReviewSeconds: normalizeEstSeconds(estimateVal, estimateUnit),
+			ImageURL:               imageURL,
+		})
+	}
+
 	// Validate module belongs to book if provided
 	if moduleID != nil {
 		module, err := s.bookModuleRepo.FindByID(moduleID.String())
@@ -1273,7 +1474,7 @@ func (s *bookService) AddItem(bookID string, moduleID *uuid.UUID, ownerID uuid.U
 	// ── Non-owner of a published book → create personal BookItem with ImporterID ─
 	// BookItem dengan importer_id terisi TIDAK akan muncul di FindByBookID (canonical),
 	// sehingga pemilik buku dan importer lain tidak melihatnya sama sekali.
-	if book.Status == entities.BookStatusPublished && book.OwnerID != ownerID {
+	if book.Status == entities.BookStatusPublished && !isEditor {
 		importerItem := &entities.BookItem{
 			BookID:                 uuid.MustParse(bookID),
 			ModuleID:               moduleID,
@@ -1282,6 +1483,8 @@ func (s *bookService) AddItem(bookID string, moduleID *uuid.UUID, ownerID uuid.U
 			Content:                content,
 			Answer:                 answer,
 			Order:                  order,
+			Type:                   typed.Type,
+			Distractors:            typed.Distractors,
 			EstimatedReviewSeconds: estSeconds,
 			ImageURL:               imageURL,
 			CreatedAt:              time.Now().In(config.AppLocation),
@@ -1292,20 +1495,24 @@ func (s *bookService) AddItem(bookID string, moduleID *uuid.UUID, ownerID uuid.U
 		}
 
 		// Buat Item (memorization row) agar muncul di daily feed dan koleksi importer.
+		// Item cloze mendapat satu Item per cloze.
 		contentRef := "book:" + bookID + ":item:" + importerItem.ID.String()
-		memItem := &entities.Item{
-			OwnerID:                ownerID,
-			SourceType:             "book",
-			ContentRef:             contentRef,
-			Status:                 entities.ItemStatusMenghafal,
-			EstimatedReviewSeconds: estSeconds,
+		for _, unit := range memorizationUnits(importerItem) {
+			memItem := &entities.Item{
+				OwnerID:                ownerID,
+				SourceType:             "book",
+				ContentRef:             contentRef,
+				ClozeIndex:             unit,
+				Status:                 entities.ItemStatusMenghafal,
+				EstimatedReviewSeconds: estSeconds,
+			}
+			_ = s.itemRepo.Create(memItem)
 		}
-		_ = s.itemRepo.Create(memItem)
 
 		return importerItem, nil
 	}
 
-	// ── Owner (or draft book) → write directly to book_items ───────────────
+	// ── Owner/editor (or draft book) → write directly to book_items ────────
 	item := &entities.BookItem{
 		BookID:                 uuid.MustParse(bookID),
 		ModuleID:               moduleID,
@@ -1313,6 +1520,8 @@ func (s *bookService) AddItem(bookID string, moduleID *uuid.UUID, ownerID uuid.U
 		Content:                content,
 		Answer:                 answer,
 		Order:                  order,
+		Type:                   typed.Type,
+		Distractors:            typed.Distractors,
 		EstimatedReviewSeconds: estSeconds,
 		ImageURL:               imageURL,
 		CreatedAt:              time.Now().In(config.AppLocation),
@@ -1322,14 +1531,23 @@ func (s *bookService) AddItem(bookID string, moduleID *uuid.UUID, ownerID uuid.U
 	if err := s.bookItemRepo.Create(item); err != nil {
 		return nil, err
 	}
+	s.logBookActivity(book, ownerID, entities.BookActivityItemAdded, &item.ID, item.Title)
 
 	return item, nil
 }
 
-func (s *bookService) UpdateItem(itemID string, ownerID uuid.UUID, title, content, answer string, order int, estimateVal int, estimateUnit string, imageURL string, removeImage bool) (*entities.BookItem, error) {
+func (s *bookService) UpdateItem(itemID string, ownerID uuid.UUID, title, content, answer string, order int, estimateVal int, estimateUnit string, imageURL string, removeImage bool, itemType *BookItemTypeInput) (*entities.BookItem, error) {
 	item, err := s.bookItemRepo.FindByID(itemID)
 	if err != nil {
-		return nil, errors.New("item not found")
+		// The item may so far only exist in a draft content revision
+		book, findErr := s.findDraftOnlyBook("items", itemID)
+		if findErr != nil || !s.isBookEditor(book, ownerID) {
+			return nil, errors.New("item not found")
+		}
+		return s.reviseUpdateItem(book, ownerID, uuid.MustParse(itemID), title, content, answer, order, estimateVal, estimateUnit, imageURL, removeImage, itemType)
+	}
+	if item.RemovedAt != nil {
+		return nil, errors.New("item has been removed from this book")
 	}
 
 	book, err := s.bookRepo.FindByID(item.BookID.String())
@@ -1337,20 +1555,26 @@ func (s *bookService) UpdateItem(itemID string, ownerID uuid.UUID, title, conten
 		return nil, errors.New("book not found")
 	}
 
-	// Published books: allow anyone if is_editable=true, owner always can edit
-	// Non-published books: only owner can edit
+	if err := ensureNotRetired(book); err != nil {
+		return nil, err
+	}
+
+	isEditor := s.isBookEditor(book, ownerID)
+
+	// Published books: allow anyone if is_editable=true, owner and editors always can edit
+	// Non-published books: only owner and editors can edit
 	if book.Status == entities.BookStatusPublished {
-		if !book.IsEditable && book.OwnerID != ownerID {
+		if !book.IsEditable && !isEditor {
 			return nil, errors.New("this book is not editable")
 		}
 	} else {
-		if book.OwnerID != ownerID {
+		if !isEditor {
 			return nil, errors.New("you don't have permission to update this item")
 		}
 	}
 
 	// ── Non-owner of a published book ──────────────────────────────────────
-	if book.Status == entities.BookStatusPublished && book.OwnerID != ownerID {
+	if book.Status == entities.BookStatusPublished && !isEditor {
 		// Case A: item adalah milik importer ini sendiri (importer_id = ownerID)
 		//         → edit langsung BookItem mereka.
 		if item.ImporterID != nil && *item.ImporterID == ownerID {
@@ -1369,6 +1593,10 @@ func (s *bookService) UpdateItem(itemID string, ownerID uuid.UUID, title, conten
 			if estimateVal > 0 {
 				item.EstimatedReviewSeconds = normalizeEstSeconds(estimateVal, estimateUnit)
 			}
+			applyBookItemType(item, itemType)
+			if err := ValidateBookItemType(item); err != nil {
+				return nil, err
+			}
 			if imageURL != "" {
 				item.ImageURL = imageURL
 			} else if removeImage {
@@ -1391,6 +1619,9 @@ func (s *bookService) UpdateItem(itemID string, ownerID uuid.UUID, title, conten
 		if s.overrideRepo == nil {
 			return nil, errors.New("override repository not available")
 		}
+		if itemType != nil {
+			return nil, errors.New("only the book owner can change the item type")
+		}
 
 		existing, _ := s.overrideRepo.FindByUserAndBookItemID(ownerID, item.ID)
 		var base entities.BookItemOverride
@@ -1406,6 +1637,7 @@ func (s *bookService) UpdateItem(itemID string, ownerID uuid.UUID, title, conten
 				ImageURL:               item.ImageURL,
 				EstimatedReviewSeconds: item.EstimatedReviewSeconds,
 			}
+			rebaseOverride(&base, item)
 		}
 		if title != "" {
 			base.Title = title
@@ -1418,13 +1650,25 @@ func (s *bookService) UpdateItem(itemID string, ownerID uuid.UUID, title, conten
 		}
 		if imageURL != "" {
 			base.ImageURL = imageURL
+			base.ImageUploaded = true
 		} else if removeImage {
-			_ = utils.DeleteFromSupabase(base.ImageURL)
+			// The book's images stay in storage; only the user's own upload goes
+			if base.ImageUploaded {
+				_ = utils.DeleteFromSupabase(base.ImageURL)
+			}
 			base.ImageURL = ""
+			base.ImageUploaded = false
 		}
 		if estimateVal > 0 {
 			base.EstimatedReviewSeconds = normalizeEstSeconds(estimateVal, estimateUnit)
 		}
+		// A personal version must still fit the item type, e.g. keep its clozes
+		check := *item
+		check.Content = base.Content
+		check.Answer = base.Answer
+		if err := ValidateBookItemType(&check); err != nil {
+			return nil, err
+		}
 		base.UpdatedAt = time.Now().In(config.AppLocation)
 		if err := s.overrideRepo.Upsert(&base); err != nil {
 			return nil, err
@@ -1438,7 +1682,13 @@ func (s *bookService) UpdateItem(itemID string, ownerID uuid.UUID, title, conten
 		return &result, nil
 	}
 
-	// ── Owner (or draft book) → mutate the canonical BookItem ──────────────
+	// ── Owner/editor of a published book → draft content revision ────────
+	if book.Status == entities.BookStatusPublished && item.ImporterID == nil {
+		return s.reviseUpdateItem(book, ownerID, item.ID, title, content, answer, order, estimateVal, estimateUnit, imageURL, removeImage, itemType)
+	}
+
+	// ── Owner/editor (or draft book) → mutate the canonical BookItem ───────
+	before := *item
 	if title != "" {
 		item.Title = title
 	}
@@ -1454,6 +1704,10 @@ func (s *bookService) UpdateItem(itemID string, ownerID uuid.UUID, title, conten
 	if estimateVal > 0 {
 		item.EstimatedReviewSeconds = normalizeEstSeconds(estimateVal, estimateUnit)
 	}
+	applyBookItemType(item, itemType)
+	if err := ValidateBookItemType(item); err != nil {
+		return nil, err
+	}
 	if imageURL != "" {
 		item.ImageURL = imageURL
 	} else if removeImage {
@@ -1461,11 +1715,15 @@ func (s *bookService) UpdateItem(itemID string, ownerID uuid.UUID, title, conten
 		_ = utils.DeleteFromSupabase(item.ImageURL)
 		item.ImageURL = ""
 	}
+	if bookItemContentChanged(before, *item) {
+		item.Version++
+	}
 	item.UpdatedAt = time.Now().In(config.AppLocation)
 
 	if err := s.bookItemRepo.Update(item); err != nil {
 		return nil, err
 	}
+	s.logBookActivity(book, ownerID, entities.BookActivityItemUpdated, &item.ID, item.Title)
 
 	return item, nil
 }
@@ -1473,7 +1731,14 @@ func (s *bookService) UpdateItem(itemID string, ownerID uuid.UUID, title, conten
 func (s *bookService) DeleteItem(itemID string, ownerID uuid.UUID) error {
 	item, err := s.bookItemRepo.FindByID(itemID)
 	if err != nil {
-		return errors.New("item not found")
+		book, findErr := s.findDraftOnlyBook("items", itemID)
+		if findErr != nil || !s.isBookEditor(book, ownerID) {
+			return errors.New("item not found")
+		}
+		return s.reviseDeleteItem(book, ownerID, uuid.MustParse(itemID))
+	}
+	if item.RemovedAt != nil {
+		return errors.New("item has been removed from this book")
 	}
 
 	book, err := s.bookRepo.FindByID(item.BookID.String())
@@ -1481,14 +1746,20 @@ func (s *bookService) DeleteItem(itemID string, ownerID uuid.UUID) error {
 		return errors.New("book not found")
 	}
 
-	// Published books: allow anyone if is_editable=true, owner always can delete
-	// Non-published books: only owner can delete
+	if err := ensureNotRetired(book); err != nil {
+		return err
+	}
+
+	isEditor := s.isBookEditor(book, ownerID)
+
+	// Published books: allow anyone if is_editable=true, owner and editors always can delete
+	// Non-published books: only owner and editors can delete
 	if book.Status == entities.BookStatusPublished {
-		if !book.IsEditable && book.OwnerID != ownerID {
+		if !book.IsEditable && !isEditor {
 			return errors.New("this book is not editable")
 		}
 	} else {
-		if book.OwnerID != ownerID {
+		if !isEditor {
 			return errors.New("you don't have permission to delete this item")
 		}
 	}
@@ -1496,7 +1767,7 @@ func (s *bookService) DeleteItem(itemID string, ownerID uuid.UUID) error {
 	// ── Non-owner of a published book → only remove their personal BookItem
 	//    (importer_id = ownerID), their override, and their memorization Item row.
 	//    The canonical BookItem (importer_id IS NULL) stays intact.
-	if book.Status == entities.BookStatusPublished && book.OwnerID != ownerID {
+	if book.Status == entities.BookStatusPublished && !isEditor {
 		// Only allowed to delete items they personally created (importer_id = ownerID).
 		if item.ImporterID == nil || *item.ImporterID != ownerID {
 			return errors.New("you don't have permission to delete this item")
@@ -1520,7 +1791,13 @@ func (s *bookService) DeleteItem(itemID string, ownerID uuid.UUID) error {
 		return s.bookItemRepo.Delete(itemID)
 	}
 
-	// ── Owner → delete the canonical BookItem (and all memorization rows for
+	// ── Owner/editor of a published book → draft content revision. Importers keep
+	//    their progress; the item is retired when the revision is approved.
+	if book.Status == entities.BookStatusPublished && item.ImporterID == nil {
+		return s.reviseDeleteItem(book, ownerID, item.ID)
+	}
+
+	// ── Owner/editor → delete the canonical BookItem (and all memorization rows for
 	//    everyone pointing to it, plus any overrides).
 	contentRef := "book:" + item.BookID.String() + ":item:" + itemID
 	existingItems, err := s.itemRepo.FindByContentRef(contentRef)
@@ -1540,7 +1817,11 @@ func (s *bookService) DeleteItem(itemID string, ownerID uuid.UUID) error {
 		}
 	}
 
-	return s.bookItemRepo.Delete(itemID)
+	if err := s.bookItemRepo.Delete(itemID); err != nil {
+		return err
+	}
+	s.logBookActivity(book, ownerID, entities.BookActivityItemDeleted, &item.ID, item.Title)
+	return nil
 }
 
 // ==================== MEMORIZATION ====================
@@ -1549,12 +1830,36 @@ func (s *bookService) DeleteItem(itemID string, ownerID uuid.UUID) error {
 // If item already exists, returns the existing item instead of error
 func (s *bookService) StartItemMemorization(userID uuid.UUID, bookID, bookItemID string) (*StartMemorizationResult, error) {
 	// 1. Get book and validate access
+	book, err := s.memorizationBook(userID, bookID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Get book item and validate it belongs to book
+	bookItem, err := s.bookItemRepo.FindByID(bookItemID)
+	if err != nil {
+		return nil, errors.New("book item not found")
+	}
+
+	if bookItem.BookID.String() != bookID {
+		return nil, errors.New("book item does not belong to this book")
+	}
+
+	contentRef := "book:" + bookID + ":item:" + bookItemID
+	existingItems, _ := s.itemRepo.FindByOwnerAndContentRef(userID, contentRef)
+	result, _, err := s.startBookItemUnits(userID, book, bookItem, existingItems, nil)
+	return result, err
+}
+
+// memorizationBook returns the book if userID may memorize its items: the
+// owner, a member of a class the book is assigned to, or an importer.
+func (s *bookService) memorizationBook(userID uuid.UUID, bookID string) (*entities.Book, error) {
 	book, err := s.bookRepo.FindByID(bookID)
 	if err != nil {
 		return nil, errors.New("book not found")
 	}
 
-	isOwner := book.OwnerID == userID
+	isAuthor := book.OwnerID == userID || s.collaboratorRole(book, userID) != ""
 	var isClassroomMember bool
 	if s.classBookRepo != nil {
 		isClassBook, err := s.classBookRepo.IsBookAssignedToClass(bookID)
@@ -1565,79 +1870,90 @@ func (s *bookService) StartItemMemorization(userID uuid.UUID, bookID, bookItemID
 	var isImporter bool
 	if s.classBookRepo != nil {
 		isImported, err := s.classBookRepo.IsBookImportedByUser(bookID, userID.String())
-		if err == nil && isImported && book.Status == entities.BookStatusPublished {
+		if err == nil && isImported && (book.Status == entities.BookStatusPublished || book.Status == entities.BookStatusRetired) {
 			isImporter = true
 		}
 	}
 
-	if !isOwner && !isClassroomMember && !isImporter {
+	if !isAuthor && !isClassroomMember && !isImporter {
 		return nil, errors.New("you don't have access to this book")
 	}
+	return book, nil
+}
 
-	// 2. Get book item and validate it belongs to book
-	bookItem, err := s.bookItemRepo.FindByID(bookItemID)
-	if err != nil {
-		return nil, errors.New("book item not found")
+// startBookItemUnits creates or updates the user's Items for bookItem, one
+// per memorization unit (the whole item, or each cloze). existingItems are
+// the user's rows for the item; they are reused so starting again is
+// harmless. startAt, when set, holds back new units from daily tasks until
+// that day. changed reports whether any row was created or moved to start.
+func (s *bookService) startBookItemUnits(userID uuid.UUID, book *entities.Book, bookItem *entities.BookItem, existingItems []entities.Item, startAt *time.Time) (result *StartMemorizationResult, changed bool, err error) {
+	contentRef := "book:" + book.ID.String() + ":item:" + bookItem.ID.String()
+	byCloze := make(map[int]*entities.Item, len(existingItems))
+	for i := range existingItems {
+		byCloze[existingItems[i].ClozeIndex] = &existingItems[i]
 	}
 
-	if bookItem.BookID.String() != bookID {
-		return nil, errors.New("book item does not belong to this book")
+	units := memorizationUnits(bookItem)
+	result = &StartMemorizationResult{
+		BookItemID: bookItem.ID,
+		BookTitle:  book.Title,
+		ItemTitle:  bookItem.Title,
 	}
-
-	// 3. Check if user already has this item
-	contentRef := "book:" + bookID + ":item:" + bookItemID
-	existingItems, err := s.itemRepo.FindByOwnerAndContentRef(userID, contentRef)
-	if err == nil && len(existingItems) > 0 {
-		existingItem := &existingItems[0]
-
-		// If item exists but status is 'menghafal', update to 'start'
-		// This handles items created from AddPublishedBookToMyBook
-		if existingItem.Status == entities.ItemStatusMenghafal {
-			existingItem.Status = entities.ItemStatusStart
-			if err := s.itemRepo.Update(existingItem); err != nil {
-				return nil, err
+	if startAt != nil {
+		result.StartDate = startAt.Format("2006-01-02")
+	}
+	for _, unit := range units {
+		item, ok := byCloze[unit]
+		if !ok && unit > 0 && byCloze[0] != nil {
+			// The item became a cloze item after the user started it; the
+			// old progress carries over to the first cloze.
+			item = byCloze[0]
+			delete(byCloze, 0)
+			item.ClozeIndex = unit
+			if err := s.itemRepo.Update(item); err != nil {
+				return nil, false, err
 			}
-			return &StartMemorizationResult{
-				ItemID:     existingItem.ID,
-				BookItemID: bookItem.ID,
-				BookTitle:  book.Title,
-				ItemTitle:  bookItem.Title,
-				Status:     entities.ItemStatusStart,
-			}, nil
-		}
-
-		// Item already exists with other status, return as-is
-		return &StartMemorizationResult{
-			ItemID:     existingItem.ID,
-			BookItemID: bookItem.ID,
-			BookTitle:  book.Title,
-			ItemTitle:  bookItem.Title,
-			Status:     existingItem.Status,
-		}, nil
-	}
+		}
 
-	// 4. Create new Item with status "start" for book items
-	// Book items flow: START → FSRS_ACTIVE → GRADUATE
-	item := &entities.Item{
-		OwnerID:    userID,
-		SourceType: "book", // book items use "book" as source type
-		ContentRef: contentRef,
-		Status:     entities.ItemStatusStart, // Start phase for book items
-	}
-	// copy estimation from book item into Item for daily usage
-	item.EstimatedReviewSeconds = bookItem.EstimatedReviewSeconds
+		switch {
+		case item == nil:
+			// Create new Item with status "start" for book items
+			// Book items flow: START → FSRS_ACTIVE → GRADUATE
+			item = &entities.Item{
+				OwnerID:      userID,
+				SourceType:   "book", // book items use "book" as source type
+				ContentRef:   contentRef,
+				ClozeIndex:   unit,
+				Status:       entities.ItemStatusStart, // Start phase for book items
+				NextReviewAt: startAt,
+			}
+			// copy estimation from book item into Item for daily usage
+			item.EstimatedReviewSeconds = bookItem.EstimatedReviewSeconds
+			if err := s.itemRepo.Create(item); err != nil {
+				return nil, false, err
+			}
+			changed = true
+		case item.Status == entities.ItemStatusMenghafal:
+			// Items created for importer-only book items start as 'menghafal'
+			item.Status = entities.ItemStatusStart
+			item.NextReviewAt = startAt
+			if err := s.itemRepo.Update(item); err != nil {
+				return nil, false, err
+			}
+			changed = true
+		}
+		// Item already exists with other status, keep as-is
 
-	if err := s.itemRepo.Create(item); err != nil {
-		return nil, err
+		if unit > 0 {
+			result.Clozes = append(result.Clozes, StartedCloze{Index: unit, ItemID: item.ID, Status: item.Status})
+		}
+		if result.ItemID == uuid.Nil {
+			result.ItemID = item.ID
+			result.Status = item.Status
+		}
 	}
 
-	return &StartMemorizationResult{
-		ItemID:     item.ID,
-		BookItemID: bookItem.ID,
-		BookTitle:  book.Title,
-		ItemTitle:  bookItem.Title,
-		Status:     item.Status,
-	}, nil
+	return result, changed, nil
 }
 
 // ==================== MY BOOK COLLECTION ====================
@@ -1664,15 +1980,17 @@ func (s *bookService) GetMyBookCollection(userID uuid.UUID) ([]BookCollectionIte
 		}
 
 		items, err := s.itemRepo.FindByOwnerAndSourceType(userID, "book")
-		itemCount := 0
+		// Cloze items have one Item per cloze; count book items, not clozes
+		started := make(map[string]bool)
 		if err == nil {
 			for _, item := range items {
 				parts := strings.Split(item.ContentRef, ":")
 				if len(parts) == 4 && parts[0] == "book" && parts[1] == bookID {
-					itemCount++
+					started[item.ContentRef] = true
 				}
 			}
 		}
+		itemCount := len(started)
 
 		result = append(result, BookCollectionItem{
 			BookID:      bookID,
@@ -1682,12 +2000,28 @@ func (s *bookService) GetMyBookCollection(userID uuid.UUID) ([]BookCollectionIte
 			OwnerName:   ownerName,
 			ItemCount:   itemCount,
 			AddedAt:     ib.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+			Status:      book.Status,
+
+			ContentVersion: book.ContentVersion,
+			UnseenVersions: unseenVersions(book.ContentVersion, ib.SeenVersion),
 		})
 	}
 
 	return result, nil
 }
 
+// unseenVersions counts approved content versions after seen. Version 1 is
+// the originally published content, so it never counts as an update.
+func unseenVersions(current, seen int) int {
+	if seen < 1 {
+		seen = 1
+	}
+	if current <= seen {
+		return 0
+	}
+	return current - seen
+}
+
 func (s *bookService) RemoveFromMyBookCollection(userID uuid.UUID, bookID string) error {
 	_, err := s.bookRepo.FindByID(bookID)
 	if err != nil {
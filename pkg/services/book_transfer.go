@@ -0,0 +1,613 @@
+package services
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"hifzhun-api/pkg/entities"
+	"hifzhun-api/pkg/repositories"
+	"hifzhun-api/pkg/utils"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// BookTransferFormat identifies the JSON schema used for book import/export
+const BookTransferFormat = "hifzhun.book"
+
+// BookTransferVersion is the current version of the book import/export schema
+const BookTransferVersion = 1
+
+// BookCSVHeader is the column layout used for CSV import/export.
+//
+// Every row has a type:
+//   - book:   title, description, image_url (cover image). At most one row.
+//   - module: key (any unique string), parent_key (optional), title, description, order
+//   - item:   parent_key (module key, empty = directly under book), title, content,
+//     answer, order, image_url, estimated_review_seconds, item_type (basic, cloze
+//     or multiple_choice; empty = basic), distractors (one per line)
+//
+// Text cells a spreadsheet would read as a formula are written with a leading
+// apostrophe (utils.CSVSafeCell), which ParseBookCSV removes again. image_url
+// is exported for reference only; ImportBook drops it with a note.
+var BookCSVHeader = []string{
+	"type", "key", "parent_key", "title", "description", "content", "answer",
+	"order", "image_url", "estimated_review_seconds", "item_type", "distractors",
+}
+
+// BookTransferDocument is the portable representation of a book used for
+// bulk import and export (JSON schema, and the in-memory form of CSV files)
+type BookTransferDocument struct {
+	Format      string               `json:"format"`
+	Version     int                  `json:"version"`
+	Title       string               `json:"title"`
+	Description string               `json:"description"`
+	CoverImage  string               `json:"cover_image,omitempty"`
+	Items       []BookTransferItem   `json:"items"`
+	Modules     []BookTransferModule `json:"modules"`
+}
+
+type BookTransferModule struct {
+	Title       string               `json:"title"`
+	Description string               `json:"description"`
+	Order       int                  `json:"order"`
+	Items       []BookTransferItem   `json:"items"`
+	Children    []BookTransferModule `json:"children"`
+}
+
+type BookTransferItem struct {
+	Title                  string   `json:"title"`
+	Content                string   `json:"content"`
+	Answer                 string   `json:"answer"`
+	Order                  int      `json:"order"`
+	ImageURL               string   `json:"image_url,omitempty"`
+	EstimatedReviewSeconds int      `json:"estimated_review_seconds,omitempty"`
+	ItemType               string   `json:"item_type,omitempty"`
+	Distractors            []string `json:"distractors,omitempty"`
+}
+
+// BookImportError carries row-level validation errors for a bulk import
+type BookImportError struct {
+	Errors []utils.FieldError
+}
+
+func (e *BookImportError) Error() string {
+	return "book import validation failed"
+}
+
+func newFieldError(field, message string) utils.FieldError {
+	return utils.FieldError{Field: field, Messages: []string{message}, Message: message}
+}
+
+// maxBookTitleLength mirrors the size limit of book/module/item title columns
+const maxBookTitleLength = 200
+
+func transferItemErrors(prefix string, item BookTransferItem) []utils.FieldError {
+	var errs []utils.FieldError
+	if strings.TrimSpace(item.Content) == "" && strings.TrimSpace(item.Answer) == "" {
+		errs = append(errs, newFieldError(prefix+".content", "either content or answer must be provided"))
+	}
+	if utf8.RuneCountInString(item.Title) > maxBookTitleLength {
+		errs = append(errs, newFieldError(prefix+".title", fmt.Sprintf("title must be %d characters or less", maxBookTitleLength)))
+	}
+	if item.EstimatedReviewSeconds < 0 {
+		errs = append(errs, newFieldError(prefix+".estimated_review_seconds", "estimated_review_seconds cannot be negative"))
+	}
+	if utf8.RuneCountInString(item.ImageURL) > 500 {
+		errs = append(errs, newFieldError(prefix+".image_url", "image_url must be 500 characters or less"))
+	}
+	typed := item.toBookItem()
+	if err := ValidateBookItemType(&typed); err != nil {
+		errs = append(errs, newFieldError(prefix+".item_type", err.Error()))
+	}
+	return errs
+}
+
+// toBookItem maps the transfer item onto a BookItem without IDs
+func (item BookTransferItem) toBookItem() entities.BookItem {
+	bookItem := entities.BookItem{
+		Title:                  strings.TrimSpace(item.Title),
+		Content:                item.Content,
+		Answer:                 item.Answer,
+		Order:                  item.Order,
+		ImageURL:               item.ImageURL,
+		EstimatedReviewSeconds: item.EstimatedReviewSeconds,
+		Type:                   strings.TrimSpace(item.ItemType),
+	}
+	if len(item.Distractors) > 0 {
+		bookItem.Distractors = datatypes.NewJSONSlice(item.Distractors)
+	}
+	return bookItem
+}
+
+func transferModuleErrors(prefix string, module BookTransferModule) []utils.FieldError {
+	var errs []utils.FieldError
+	if strings.TrimSpace(module.Title) == "" {
+		errs = append(errs, newFieldError(prefix+".title", "module title is required"))
+	} else if utf8.RuneCountInString(module.Title) > maxBookTitleLength {
+		errs = append(errs, newFieldError(prefix+".title", fmt.Sprintf("title must be %d characters or less", maxBookTitleLength)))
+	}
+	return errs
+}
+
+// ValidateBookTransferDocument returns field errors keyed by JSON path,
+// e.g. "modules[0].items[2].content".
+func ValidateBookTransferDocument(doc *BookTransferDocument) []utils.FieldError {
+	var errs []utils.FieldError
+	if doc.Format != "" && doc.Format != BookTransferFormat {
+		errs = append(errs, newFieldError("format", "format must be "+BookTransferFormat))
+	}
+	if doc.Version > BookTransferVersion {
+		errs = append(errs, newFieldError("version", fmt.Sprintf("unsupported version %d", doc.Version)))
+	}
+	if strings.TrimSpace(doc.Title) == "" {
+		errs = append(errs, newFieldError("title", "title is required"))
+	} else if utf8.RuneCountInString(doc.Title) > maxBookTitleLength {
+		errs = append(errs, newFieldError("title", fmt.Sprintf("title must be %d characters or less", maxBookTitleLength)))
+	}
+
+	for i, item := range doc.Items {
+		errs = append(errs, transferItemErrors(fmt.Sprintf("items[%d]", i), item)...)
+	}
+
+	var walk func(prefix string, modules []BookTransferModule)
+	walk = func(prefix string, modules []BookTransferModule) {
+		for i, m := range modules {
+			p := fmt.Sprintf("%s[%d]", prefix, i)
+			errs = append(errs, transferModuleErrors(p, m)...)
+			for j, item := range m.Items {
+				errs = append(errs, transferItemErrors(fmt.Sprintf("%s.items[%d]", p, j), item)...)
+			}
+			walk(p+".children", m.Children)
+		}
+	}
+	walk("modules", doc.Modules)
+
+	return errs
+}
+
+// ParseBookCSV reads a CSV file laid out as BookCSVHeader into a document.
+// Field errors are keyed by spreadsheet row number (header is row 1),
+// e.g. "rows[5].content".
+func ParseBookCSV(r io.Reader) (*BookTransferDocument, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errors.New("csv file is empty or unreadable")
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.TrimPrefix(name, "\ufeff") // Excel UTF-8 BOM
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["type"]; !ok {
+		return nil, &BookImportError{Errors: []utils.FieldError{
+			newFieldError("header", "missing required column: type"),
+		}}
+	}
+
+	type moduleRow struct {
+		key       string
+		parentKey string
+		row       int
+		module    BookTransferModule
+	}
+	type itemRow struct {
+		parentKey string
+		row       int
+		item      BookTransferItem
+	}
+
+	doc := &BookTransferDocument{Format: BookTransferFormat, Version: BookTransferVersion}
+	var modules []*moduleRow
+	var items []itemRow
+	moduleByKey := make(map[string]*moduleRow)
+	var errs []utils.FieldError
+	seenBookRow := false
+
+	rowNum := 1
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		rowNum++
+		prefix := fmt.Sprintf("rows[%d]", rowNum)
+		if err != nil {
+			errs = append(errs, newFieldError(prefix, err.Error()))
+			continue
+		}
+
+		// raw keeps free-text cells untouched so content round-trips exactly
+		raw := func(name string) string {
+			idx, ok := columns[name]
+			if !ok || idx >= len(record) {
+				return ""
+			}
+			return utils.CSVUnescapeCell(record[idx])
+		}
+		get := func(name string) string {
+			return strings.TrimSpace(raw(name))
+		}
+		getInt := func(name string) int {
+			v := get(name)
+			if v == "" {
+				return 0
+			}
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				errs = append(errs, newFieldError(prefix+"."+name, name+" must be a number"))
+			}
+			return n
+		}
+
+		switch strings.ToLower(get("type")) {
+		case "":
+			// Allow blank spacer rows
+			if strings.TrimSpace(strings.Join(record, "")) != "" {
+				errs = append(errs, newFieldError(prefix+".type", "type is required (book, module or item)"))
+			}
+		case "book":
+			if seenBookRow {
+				errs = append(errs, newFieldError(prefix+".type", "only one book row is allowed"))
+				continue
+			}
+			seenBookRow = true
+			doc.Title = get("title")
+			doc.Description = raw("description")
+			doc.CoverImage = get("image_url")
+		case "module":
+			m := &moduleRow{
+				key:       get("key"),
+				parentKey: get("parent_key"),
+				row:       rowNum,
+				module: BookTransferModule{
+					Title:       get("title"),
+					Description: raw("description"),
+					Order:       getInt("order"),
+				},
+			}
+			errs = append(errs, transferModuleErrors(prefix, m.module)...)
+			if m.key == "" {
+				errs = append(errs, newFieldError(prefix+".key", "module key is required"))
+			} else if _, dup := moduleByKey[m.key]; dup {
+				errs = append(errs, newFieldError(prefix+".key", "duplicate module key "+m.key))
+			} else {
+				moduleByKey[m.key] = m
+			}
+			modules = append(modules, m)
+		case "item":
+			it := itemRow{
+				parentKey: get("parent_key"),
+				row:       rowNum,
+				item: BookTransferItem{
+					Title:                  get("title"),
+					Content:                raw("content"),
+					Answer:                 raw("answer"),
+					Order:                  getInt("order"),
+					ImageURL:               get("image_url"),
+					EstimatedReviewSeconds: getInt("estimated_review_seconds"),
+					ItemType:               strings.ToLower(get("item_type")),
+					Distractors:            splitDistractors(raw("distractors")),
+				},
+			}
+			errs = append(errs, transferItemErrors(prefix, it.item)...)
+			items = append(items, it)
+		default:
+			errs = append(errs, newFieldError(prefix+".type", "type must be book, module or item"))
+		}
+	}
+
+	// Resolve parent keys and reject cycles before building the tree
+	for _, m := range modules {
+		if m.parentKey == "" {
+			continue
+		}
+		if _, ok := moduleByKey[m.parentKey]; !ok {
+			errs = append(errs, newFieldError(fmt.Sprintf("rows[%d].parent_key", m.row), "unknown module key "+m.parentKey))
+			continue
+		}
+		seen := map[string]bool{m.key: true}
+		for p := m.parentKey; p != ""; {
+			if seen[p] {
+				errs = append(errs, newFieldError(fmt.Sprintf("rows[%d].parent_key", m.row), "module nesting contains a cycle"))
+				break
+			}
+			seen[p] = true
+			parent, ok := moduleByKey[p]
+			if !ok {
+				break
+			}
+			p = parent.parentKey
+		}
+	}
+	for _, it := range items {
+		if it.parentKey == "" {
+			continue
+		}
+		if _, ok := moduleByKey[it.parentKey]; !ok {
+			errs = append(errs, newFieldError(fmt.Sprintf("rows[%d].parent_key", it.row), "unknown module key "+it.parentKey))
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, &BookImportError{Errors: errs}
+	}
+
+	// Build nested modules; children and items keep their row order
+	childrenByKey := make(map[string][]*moduleRow)
+	for _, m := range modules {
+		childrenByKey[m.parentKey] = append(childrenByKey[m.parentKey], m)
+	}
+	itemsByKey := make(map[string][]BookTransferItem)
+	for _, it := range items {
+		itemsByKey[it.parentKey] = append(itemsByKey[it.parentKey], it.item)
+	}
+	itemsFor := func(key string) []BookTransferItem {
+		if list := itemsByKey[key]; list != nil {
+			return list
+		}
+		return []BookTransferItem{}
+	}
+
+	var build func(parentKey string) []BookTransferModule
+	build = func(parentKey string) []BookTransferModule {
+		nodes := make([]BookTransferModule, 0, len(childrenByKey[parentKey]))
+		for _, m := range childrenByKey[parentKey] {
+			node := m.module
+			node.Items = itemsFor(m.key)
+			node.Children = build(m.key)
+			nodes = append(nodes, node)
+		}
+		return nodes
+	}
+
+	doc.Items = itemsFor("")
+	doc.Modules = build("")
+	return doc, nil
+}
+
+// splitDistractors reads the distractors cell, one distractor per line
+func splitDistractors(cell string) []string {
+	var result []string
+	for _, line := range strings.Split(cell, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			result = append(result, line)
+		}
+	}
+	return result
+}
+
+// WriteBookCSV writes a document in the BookCSVHeader layout. Module keys are
+// generated as m1, m2, ... in depth-first order so the file re-imports as-is.
+func WriteBookCSV(w io.Writer, doc *BookTransferDocument) error {
+	// UTF-8 BOM so spreadsheet apps render Arabic text correctly
+	if _, err := io.WriteString(w, "\ufeff"); err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(BookCSVHeader); err != nil {
+		return err
+	}
+
+	safe := utils.CSVSafeCell
+	row := func(typ, key, parentKey, title, description, content, answer string, order int, imageURL string, estSeconds int) error {
+		return writer.Write([]string{
+			typ, key, parentKey, safe(title), safe(description), safe(content), safe(answer),
+			strconv.Itoa(order), safe(imageURL), strconv.Itoa(estSeconds), "", "",
+		})
+	}
+	itemRows := func(parentKey string, items []BookTransferItem) error {
+		for _, it := range items {
+			if err := writer.Write([]string{
+				"item", "", parentKey, safe(it.Title), "", safe(it.Content), safe(it.Answer),
+				strconv.Itoa(it.Order), safe(it.ImageURL), strconv.Itoa(it.EstimatedReviewSeconds),
+				safe(it.ItemType), safe(strings.Join(it.Distractors, "\n")),
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := writer.Write([]string{"book", "", "", safe(doc.Title), safe(doc.Description), "", "", "", safe(doc.CoverImage), "", "", ""}); err != nil {
+		return err
+	}
+	if err := itemRows("", doc.Items); err != nil {
+		return err
+	}
+
+	counter := 0
+	var walk func(parentKey string, modules []BookTransferModule) error
+	walk = func(parentKey string, modules []BookTransferModule) error {
+		for _, m := range modules {
+			counter++
+			key := fmt.Sprintf("m%d", counter)
+			if err := row("module", key, parentKey, m.Title, m.Description, "", "", m.Order, "", 0); err != nil {
+				return err
+			}
+			if err := itemRows(key, m.Items); err != nil {
+				return err
+			}
+			if err := walk(key, m.Children); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk("", doc.Modules); err != nil {
+		return err
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// ==================== BULK IMPORT / EXPORT ====================
+
+// BookImportResult is the imported book. Notes lists what was left out,
+// keyed by JSON path like validation errors, e.g. "modules[0].items[2].image_url".
+type BookImportResult struct {
+	*entities.Book
+	Notes []utils.FieldError `json:"notes,omitempty"`
+}
+
+// imageLinkNote explains why an image link was left out of an import
+const imageLinkNote = "image link not imported; upload the image again"
+
+// ImportBook creates a new draft book owned by ownerID with all modules and
+// items from the document, in one transaction. Image links (cover_image,
+// image_url) are not imported: images are only stored through the premium
+// upload, and an item's image is deleted from storage with the item. Each
+// dropped link is reported in the result notes.
+func (s *bookService) ImportBook(ownerID uuid.UUID, doc *BookTransferDocument) (*BookImportResult, error) {
+	if errs := ValidateBookTransferDocument(doc); len(errs) > 0 {
+		return nil, &BookImportError{Errors: errs}
+	}
+
+	var notes []utils.FieldError
+	if strings.TrimSpace(doc.CoverImage) != "" {
+		notes = append(notes, newFieldError("cover_image", imageLinkNote))
+	}
+	toItems := func(prefix string, items []BookTransferItem) []entities.BookItem {
+		result := make([]entities.BookItem, 0, len(items))
+		for i, it := range items {
+			bookItem := it.toBookItem()
+			if strings.TrimSpace(bookItem.ImageURL) != "" {
+				notes = append(notes, newFieldError(fmt.Sprintf("%s[%d].image_url", prefix, i), imageLinkNote))
+			}
+			bookItem.ImageURL = ""
+			_ = ValidateBookItemType(&bookItem) // already validated, normalizes type and distractors
+			result = append(result, bookItem)
+		}
+		return result
+	}
+
+	var toModules func(prefix string, modules []BookTransferModule) []repositories.BookTreeModule
+	toModules = func(prefix string, modules []BookTransferModule) []repositories.BookTreeModule {
+		result := make([]repositories.BookTreeModule, 0, len(modules))
+		for i, m := range modules {
+			p := fmt.Sprintf("%s[%d]", prefix, i)
+			result = append(result, repositories.BookTreeModule{
+				Module: entities.BookModule{
+					Title:       strings.TrimSpace(m.Title),
+					Description: m.Description,
+					Order:       m.Order,
+				},
+				Items:    toItems(p+".items", m.Items),
+				Children: toModules(p+".children", m.Children),
+			})
+		}
+		return result
+	}
+
+	book := &entities.Book{
+		OwnerID:     ownerID,
+		Title:       strings.TrimSpace(doc.Title),
+		Description: doc.Description,
+		Status:      entities.BookStatusDraft,
+	}
+
+	if err := s.bookRepo.CreateWithTree(book, toItems("items", doc.Items), toModules("modules", doc.Modules)); err != nil {
+		return nil, err
+	}
+
+	return &BookImportResult{Book: book, Notes: notes}, nil
+}
+
+// ExportBook returns the canonical content of a book the user can view.
+// Importer-only items and personal overrides are not included.
+func (s *bookService) ExportBook(bookID string, userID *uuid.UUID, role string) (*BookTransferDocument, error) {
+	book, err := s.bookRepo.FindByID(bookID)
+	if err != nil {
+		return nil, errors.New("book not found")
+	}
+
+	if !s.canViewBook(book, userID, role) {
+		return nil, errors.New("you don't have access to this book")
+	}
+
+	modules, err := s.bookModuleRepo.FindByBookID(bookID)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.bookItemRepo.FindByBookID(bookID)
+	if err != nil {
+		return nil, err
+	}
+
+	toTransfer := func(it entities.BookItem) BookTransferItem {
+		item := BookTransferItem{
+			Title:                  it.Title,
+			Content:                it.Content,
+			Answer:                 it.Answer,
+			Order:                  it.Order,
+			ImageURL:               it.ImageURL,
+			EstimatedReviewSeconds: it.EstimatedReviewSeconds,
+		}
+		if typ := bookItemType(it); typ != entities.BookItemTypeBasic {
+			item.ItemType = typ
+			item.Distractors = it.Distractors
+		}
+		return item
+	}
+
+	bookItems := make([]BookTransferItem, 0)
+	itemsByModule := make(map[string][]BookTransferItem)
+	for _, it := range items {
+		if it.ModuleID == nil {
+			bookItems = append(bookItems, toTransfer(it))
+			continue
+		}
+		key := it.ModuleID.String()
+		itemsByModule[key] = append(itemsByModule[key], toTransfer(it))
+	}
+
+	childrenByParent := make(map[string][]entities.BookModule)
+	for _, m := range modules {
+		parentKey := ""
+		if m.ParentID != nil {
+			parentKey = m.ParentID.String()
+		}
+		childrenByParent[parentKey] = append(childrenByParent[parentKey], m)
+	}
+
+	var build func(parentID string) []BookTransferModule
+	build = func(parentID string) []BookTransferModule {
+		nodes := make([]BookTransferModule, 0, len(childrenByParent[parentID]))
+		for _, m := range childrenByParent[parentID] {
+			id := m.ID.String()
+			moduleItems := itemsByModule[id]
+			if moduleItems == nil {
+				moduleItems = []BookTransferItem{}
+			}
+			nodes = append(nodes, BookTransferModule{
+				Title:       m.Title,
+				Description: m.Description,
+				Order:       m.Order,
+				Items:       moduleItems,
+				Children:    build(id),
+			})
+		}
+		return nodes
+	}
+
+	return &BookTransferDocument{
+		Format:      BookTransferFormat,
+		Version:     BookTransferVersion,
+		Title:       book.Title,
+		Description: book.Description,
+		CoverImage:  book.CoverImage,
+		Items:       bookItems,
+		Modules:     build(""),
+	}, nil
+}
@@ -0,0 +1,119 @@
+package services_test
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"hifzhun-api/pkg/services"
+)
+
+func TestBookCSVRoundTrip(t *testing.T) {
+	doc := &services.BookTransferDocument{
+		Format:      services.BookTransferFormat,
+		Version:     services.BookTransferVersion,
+		Title:       "Matan Al-Ajurrumiyyah",
+		Description: "Nahwu dasar,\n\"dengan\" contoh",
+		CoverImage:  "https://example.com/cover.jpg",
+		Items: []services.BookTransferItem{
+			{Title: "Muqaddimah", Content: "الكلام هو اللفظ المركب المفيد بالوضع", Answer: "", Order: 1},
+			{Title: "=HYPERLINK(\"https://example.com\")", Content: "-1 derajat", Answer: "'=kutipan", Order: 2},
+		},
+		Modules: []services.BookTransferModule{
+			{
+				Title: "Bab I'rab", Order: 1,
+				Items: []services.BookTransferItem{
+					{Title: "Definisi", Content: "الإعراب هو", Answer: "تغيير أواخر الكلم ", Order: 1, EstimatedReviewSeconds: 90},
+				},
+				Children: []services.BookTransferModule{
+					{Title: "Alamat Rafa'", Order: 1, Items: []services.BookTransferItem{
+						{Content: "للرفع أربع علامات", Answer: "الضمة والواو والألف والنون", Order: 2, ImageURL: "https://example.com/a.png"},
+					}, Children: []services.BookTransferModule{}},
+				},
+			},
+			{Title: "Bab Kosong", Order: 2, Items: []services.BookTransferItem{}, Children: []services.BookTransferModule{}},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := services.WriteBookCSV(&buf, doc); err != nil {
+		t.Fatalf("write csv: %v", err)
+	}
+	// Formula-like cells are exported as text
+	for _, cell := range []string{`"'=HYPERLINK(""https://example.com"")"`, "'-1 derajat", "''=kutipan"} {
+		if !strings.Contains(buf.String(), ","+cell+",") {
+			t.Errorf("csv does not contain escaped cell %s", cell)
+		}
+	}
+
+	got, err := services.ParseBookCSV(&buf)
+	if err != nil {
+		t.Fatalf("parse csv: %v", err)
+	}
+
+	if !reflect.DeepEqual(doc, got) {
+		t.Errorf("round trip mismatch\nwant %+v\ngot  %+v", doc, got)
+	}
+}
+
+func TestParseBookCSVRowErrors(t *testing.T) {
+	csv := strings.Join([]string{
+		"type,key,parent_key,title,content,answer",
+		"book,,,Fiqh,,",
+		"module,m1,,,,",
+		"item,,m1,Q1,,",
+		"item,,m9,Q2,isi,",
+	}, "\n")
+
+	_, err := services.ParseBookCSV(strings.NewReader(csv))
+	var importErr *services.BookImportError
+	if !errors.As(err, &importErr) {
+		t.Fatalf("expected BookImportError, got %v", err)
+	}
+
+	fields := make(map[string]bool)
+	for _, fe := range importErr.Errors {
+		fields[fe.Field] = true
+	}
+	for _, want := range []string{"rows[3].title", "rows[4].content", "rows[5].parent_key"} {
+		if !fields[want] {
+			t.Errorf("expected error for %s, got %+v", want, importErr.Errors)
+		}
+	}
+}
+
+func TestImportBookDropsImageLinks(t *testing.T) {
+	env := newTestEnv(t)
+	bookItemRepo := env.bookItemRepo
+	svc := env.bookSvc
+
+	book, err := svc.ImportBook(uuid.New(), &services.BookTransferDocument{
+		Title:      "Fiqh",
+		CoverImage: "https://storage.example.com/uploads/cover.jpg",
+		Items:      []services.BookTransferItem{{Content: "isi", ImageURL: "https://storage.example.com/uploads/other.png"}},
+		Modules: []services.BookTransferModule{{Title: "Bab", Children: []services.BookTransferModule{
+			{Title: "Fasl", Items: []services.BookTransferItem{{Content: "isi", ImageURL: "https://storage.example.com/uploads/x.png"}}},
+		}}},
+	})
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	var noted []string
+	for _, n := range book.Notes {
+		noted = append(noted, n.Field)
+	}
+	if want := []string{"cover_image", "items[0].image_url", "modules[0].children[0].items[0].image_url"}; !reflect.DeepEqual(noted, want) {
+		t.Errorf("notes %v, want %v", noted, want)
+	}
+	items, err := bookItemRepo.FindByBookID(book.ID.String())
+	if err != nil || len(items) != 2 {
+		t.Fatalf("items %+v (%v)", items, err)
+	}
+	if book.CoverImage != "" || items[0].ImageURL != "" || items[1].ImageURL != "" {
+		t.Errorf("imported image links: cover %q, items %q %q", book.CoverImage, items[0].ImageURL, items[1].ImageURL)
+	}
+}
@@ -0,0 +1,328 @@
+package services
+
+import (
+	"errors"
+	"sort"
+
+	"hifzhun-api/pkg/entities"
+
+	"github.com/google/uuid"
+)
+
+// BookReorderInput is the new order of the children of one parent: the
+// book itself (ParentID nil) or a module. Either list may be empty; a
+// non-empty list must name every canonical sibling exactly once.
+type BookReorderInput struct {
+	ParentID  *uuid.UUID
+	ModuleIDs []uuid.UUID
+	ItemIDs   []uuid.UUID
+}
+
+// ==================== TREE OPERATIONS ====================
+//
+// Moves and reorders work on a BookRevisionContent so the same code edits
+// the live tree of draft books and the draft revision of published books.
+// Siblings are always renumbered 1..n.
+
+func sortedSiblingModules(content *entities.BookRevisionContent, parentID *uuid.UUID, skip uuid.UUID) []int {
+	var idx []int
+	for i, m := range content.Modules {
+		if m.ID != skip && sameUUIDPtr(m.ParentID, parentID) {
+			idx = append(idx, i)
+		}
+	}
+	sort.SliceStable(idx, func(a, b int) bool { return content.Modules[idx[a]].Order < content.Modules[idx[b]].Order })
+	return idx
+}
+
+func sortedSiblingItems(content *entities.BookRevisionContent, moduleID *uuid.UUID, skip uuid.UUID) []int {
+	var idx []int
+	for i, it := range content.Items {
+		if it.ID != skip && sameUUIDPtr(it.ModuleID, moduleID) {
+			idx = append(idx, i)
+		}
+	}
+	sort.SliceStable(idx, func(a, b int) bool { return content.Items[idx[a]].Order < content.Items[idx[b]].Order })
+	return idx
+}
+
+// insertAt puts v at 1-based position of list; 0 or past the end appends.
+func insertAt(list []int, position int, v int) []int {
+	if position <= 0 || position > len(list) {
+		return append(list, v)
+	}
+	list = append(list, 0)
+	copy(list[position:], list[position-1:])
+	list[position-1] = v
+	return list
+}
+
+func copyUUIDPtr(id *uuid.UUID) *uuid.UUID {
+	if id == nil {
+		return nil
+	}
+	v := *id
+	return &v
+}
+
+// moveModuleInContent moves a module under parentID (nil = book level) at
+// position. A module cannot be moved into itself or its own submodules.
+func moveModuleInContent(content *entities.BookRevisionContent, moduleID uuid.UUID, parentID *uuid.UUID, position int) error {
+	idx := findRevisionModule(content, moduleID)
+	if idx < 0 {
+		return errors.New("module not found")
+	}
+	if parentID != nil {
+		for p := parentID; p != nil; {
+			if *p == moduleID {
+				return errors.New("cannot move a module into itself or one of its submodules")
+			}
+			parent := findRevisionModule(content, *p)
+			if parent < 0 {
+				return errors.New("parent module not found")
+			}
+			p = content.Modules[parent].ParentID
+		}
+	}
+
+	oldParent := content.Modules[idx].ParentID
+	content.Modules[idx].ParentID = copyUUIDPtr(parentID)
+
+	siblings := insertAt(sortedSiblingModules(content, parentID, moduleID), position, idx)
+	for n, i := range siblings {
+		content.Modules[i].Order = n + 1
+	}
+	if !sameUUIDPtr(oldParent, parentID) {
+		for n, i := range sortedSiblingModules(content, oldParent, moduleID) {
+			content.Modules[i].Order = n + 1
+		}
+	}
+	return nil
+}
+
+// moveItemInContent moves an item into moduleID (nil = book level) at position.
+func moveItemInContent(content *entities.BookRevisionContent, itemID uuid.UUID, moduleID *uuid.UUID, position int) error {
+	idx := findRevisionItem(content, itemID)
+	if idx < 0 {
+		return errors.New("item not found")
+	}
+	if moduleID != nil && findRevisionModule(content, *moduleID) < 0 {
+		return errors.New("module not found")
+	}
+
+	oldModule := content.Items[idx].ModuleID
+	content.Items[idx].ModuleID = copyUUIDPtr(moduleID)
+
+	siblings := insertAt(sortedSiblingItems(content, moduleID, itemID), position, idx)
+	for n, i := range siblings {
+		content.Items[i].Order = n + 1
+	}
+	if !sameUUIDPtr(oldModule, moduleID) {
+		for n, i := range sortedSiblingItems(content, oldModule, itemID) {
+			content.Items[i].Order = n + 1
+		}
+	}
+	return nil
+}
+
+// reorderInContent applies the order of in to the children of in.ParentID.
+func reorderInContent(content *entities.BookRevisionContent, in BookReorderInput) error {
+	if in.ParentID != nil && findRevisionModule(content, *in.ParentID) < 0 {
+		return errors.New("parent module not found")
+	}
+
+	if len(in.ModuleIDs) > 0 {
+		siblings := sortedSiblingModules(content, in.ParentID, uuid.Nil)
+		byID := make(map[uuid.UUID]int, len(siblings))
+		for _, i := range siblings {
+			byID[content.Modules[i].ID] = i
+		}
+		if len(in.ModuleIDs) != len(siblings) {
+			return errors.New("module_ids must list every module under the parent exactly once")
+		}
+		for n, id := range in.ModuleIDs {
+			i, ok := byID[id]
+			if !ok {
+				return errors.New("module_ids must list every module under the parent exactly once")
+			}
+			delete(byID, id)
+			content.Modules[i].Order = n + 1
+		}
+	}
+
+	if len(in.ItemIDs) > 0 {
+		siblings := sortedSiblingItems(content, in.ParentID, uuid.Nil)
+		byID := make(map[uuid.UUID]int, len(siblings))
+		for _, i := range siblings {
+			byID[content.Items[i].ID] = i
+		}
+		if len(in.ItemIDs) != len(siblings) {
+			return errors.New("item_ids must list every item under the parent exactly once")
+		}
+		for n, id := range in.ItemIDs {
+			i, ok := byID[id]
+			if !ok {
+				return errors.New("item_ids must list every item under the parent exactly once")
+			}
+			delete(byID, id)
+			content.Items[i].Order = n + 1
+		}
+	}
+	return nil
+}
+
+// ==================== SERVICE ====================
+
+// treeEditableBook returns the book if ownerID may rearrange it.
+func (s *bookService) treeEditableBook(bookID string, ownerID uuid.UUID) (*entities.Book, error) {
+	book, err := s.bookRepo.FindByID(bookID)
+	if err != nil {
+		return nil, errors.New("book not found")
+	}
+	if err := ensureNotRetired(book); err != nil {
+		return nil, err
+	}
+	if !s.isBookEditor(book, ownerID) {
+		return nil, errors.New("only the book owner and editors can rearrange the book")
+	}
+	return book, nil
+}
+
+// editBookTree runs edit on the canonical tree of the book. Published books
+// are edited through the draft revision; otherwise the changed positions
+// are saved in one transaction.
+func (s *bookService) editBookTree(book *entities.Book, ownerID uuid.UUID, edit func(content *entities.BookRevisionContent) error) error {
+	if book.Status == entities.BookStatusPublished {
+		return s.editDraftRevision(book, ownerID, edit)
+	}
+
+	modules, items, err := s.liveCanonicalContent(book.ID.String())
+	if err != nil {
+		return err
+	}
+	content := &entities.BookRevisionContent{Modules: modules, Items: items}
+
+	type position struct {
+		parent *uuid.UUID
+		order  int
+	}
+	before := make(map[uuid.UUID]position, len(modules)+len(items))
+	for _, m := range modules {
+		before[m.ID] = position{m.ParentID, m.Order}
+	}
+	for _, it := range items {
+		before[it.ID] = position{it.ModuleID, it.Order}
+	}
+
+	if err := edit(content); err != nil {
+		return err
+	}
+
+	var changedModules []entities.BookModule
+	for _, m := range content.Modules {
+		if p := before[m.ID]; p.order != m.Order || !sameUUIDPtr(p.parent, m.ParentID) {
+			changedModules = append(changedModules, m)
+		}
+	}
+	var changedItems []entities.BookItem
+	for _, it := range content.Items {
+		if p := before[it.ID]; p.order != it.Order || !sameUUIDPtr(p.parent, it.ModuleID) {
+			changedItems = append(changedItems, it)
+		}
+	}
+	if len(changedModules) == 0 && len(changedItems) == 0 {
+		return nil
+	}
+	return s.bookRepo.UpdateTreePositions(changedModules, changedItems)
+}
+
+// MoveModule moves a module under another module (parentID) or to the book
+// level (nil) at the 1-based position; 0 puts it last.
+func (s *bookService) MoveModule(moduleID string, ownerID uuid.UUID, parentID *uuid.UUID, position int) (*entities.BookModule, error) {
+	var bookID string
+	if module, err := s.bookModuleRepo.FindByID(moduleID); err == nil {
+		bookID = module.BookID.String()
+	} else if book, findErr := s.findDraftOnlyBook("modules", moduleID); findErr == nil {
+		bookID = book.ID.String()
+	} else {
+		return nil, errors.New("module not found")
+	}
+
+	book, err := s.treeEditableBook(bookID, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	id := uuid.MustParse(moduleID)
+	var result entities.BookModule
+	err = s.editBookTree(book, ownerID, func(content *entities.BookRevisionContent) error {
+		if err := moveModuleInContent(content, id, parentID, position); err != nil {
+			return err
+		}
+		result = content.Modules[findRevisionModule(content, id)]
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.logBookActivity(book, ownerID, entities.BookActivityModuleMoved, &result.ID, result.Title)
+	return &result, nil
+}
+
+// MoveItem moves a canonical item into a module (moduleID) or to the book
+// level (nil) at the 1-based position; 0 puts it last.
+func (s *bookService) MoveItem(itemID string, ownerID uuid.UUID, moduleID *uuid.UUID, position int) (*entities.BookItem, error) {
+	var bookID string
+	if item, err := s.bookItemRepo.FindByID(itemID); err == nil {
+		if item.ImporterID != nil || item.RemovedAt != nil {
+			return nil, errors.New("item not found")
+		}
+		bookID = item.BookID.String()
+	} else if book, findErr := s.findDraftOnlyBook("items", itemID); findErr == nil {
+		bookID = book.ID.String()
+	} else {
+		return nil, errors.New("item not found")
+	}
+
+	book, err := s.treeEditableBook(bookID, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	id := uuid.MustParse(itemID)
+	var result entities.BookItem
+	err = s.editBookTree(book, ownerID, func(content *entities.BookRevisionContent) error {
+		if err := moveItemInContent(content, id, moduleID, position); err != nil {
+			return err
+		}
+		result = content.Items[findRevisionItem(content, id)]
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.logBookActivity(book, ownerID, entities.BookActivityItemMoved, &result.ID, result.Title)
+	return &result, nil
+}
+
+// ReorderBookChildren sets the order of the modules and/or items under one
+// parent in a single call.
+func (s *bookService) ReorderBookChildren(bookID string, ownerID uuid.UUID, in BookReorderInput) error {
+	if len(in.ModuleIDs) == 0 && len(in.ItemIDs) == 0 {
+		return errors.New("module_ids or item_ids is required")
+	}
+
+	book, err := s.treeEditableBook(bookID, ownerID)
+	if err != nil {
+		return err
+	}
+
+	err = s.editBookTree(book, ownerID, func(content *entities.BookRevisionContent) error {
+		return reorderInContent(content, in)
+	})
+	if err != nil {
+		return err
+	}
+	s.logBookActivity(book, ownerID, entities.BookActivityTreeReordered, in.ParentID, "")
+	return nil
+}
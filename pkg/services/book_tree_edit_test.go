@@ -0,0 +1,101 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+
+	"hifzhun-api/pkg/entities"
+	"hifzhun-api/pkg/services"
+)
+
+func TestMoveAndReorderBookTree(t *testing.T) {
+	env := newTestEnv(t)
+	bookRepo := env.bookRepo
+	moduleRepo := env.bookModuleRepo
+	bookItemRepo := env.bookItemRepo
+	svc := env.bookSvc
+
+	ownerID := uuid.New()
+	book := &entities.Book{OwnerID: ownerID, Title: "Nahwu", Status: entities.BookStatusDraft}
+	if err := bookRepo.Create(book); err != nil {
+		t.Fatalf("create book: %v", err)
+	}
+	bookID := book.ID.String()
+
+	bab1, _ := svc.AddModule(bookID, ownerID, "Bab 1", "", 1, nil)
+	bab2, _ := svc.AddModule(bookID, ownerID, "Bab 2", "", 2, nil)
+	fasl, _ := svc.AddModule(bookID, ownerID, "Fasl", "", 1, &bab1.ID)
+	a, _ := svc.AddItem(bookID, &bab1.ID, ownerID, "A", "a", "", 1, 0, "", "", nil)
+	b, _ := svc.AddItem(bookID, &bab1.ID, ownerID, "B", "b", "", 2, 0, "", "", nil)
+	c, _ := svc.AddItem(bookID, &bab2.ID, ownerID, "C", "c", "", 1, 0, "", "", nil)
+
+	if _, err := svc.MoveModule(bab1.ID.String(), ownerID, &fasl.ID, 0); err == nil {
+		t.Error("moved a module into its own submodule")
+	}
+	if _, err := svc.MoveModule(bab1.ID.String(), ownerID, &bab1.ID, 0); err == nil {
+		t.Error("moved a module into itself")
+	}
+	if _, err := svc.MoveItem(a.ID.String(), uuid.New(), nil, 0); err == nil {
+		t.Error("non-owner moved an item")
+	}
+
+	// A goes to the front of Bab 2; Bab 1 is renumbered
+	if _, err := svc.MoveItem(a.ID.String(), ownerID, &bab2.ID, 1); err != nil {
+		t.Fatalf("move item: %v", err)
+	}
+	orderOf := func(id uuid.UUID) (int, *uuid.UUID) {
+		it, err := bookItemRepo.FindByID(id.String())
+		if err != nil {
+			t.Fatalf("find item: %v", err)
+		}
+		return it.Order, it.ModuleID
+	}
+	if order, module := orderOf(a.ID); order != 1 || module == nil || *module != bab2.ID {
+		t.Errorf("A at %d in %v", order, module)
+	}
+	if order, _ := orderOf(c.ID); order != 2 {
+		t.Errorf("C order = %d, want 2", order)
+	}
+	if order, _ := orderOf(b.ID); order != 1 {
+		t.Errorf("B order = %d, want 1", order)
+	}
+
+	// Fasl moves to the book level, first
+	if _, err := svc.MoveModule(fasl.ID.String(), ownerID, nil, 1); err != nil {
+		t.Fatalf("move module: %v", err)
+	}
+	modules, _ := moduleRepo.FindByBookID(bookID)
+	if len(modules) != 3 || modules[0].ID != fasl.ID || modules[0].ParentID != nil || modules[2].ID != bab2.ID {
+		t.Fatalf("modules after move %+v", modules)
+	}
+
+	if err := svc.ReorderBookChildren(bookID, ownerID, services.BookReorderInput{ModuleIDs: []uuid.UUID{bab2.ID, bab1.ID}}); err == nil {
+		t.Error("reorder accepted an incomplete list")
+	}
+	if err := svc.ReorderBookChildren(bookID, ownerID, services.BookReorderInput{ParentID: &bab2.ID, ItemIDs: []uuid.UUID{c.ID, a.ID}}); err != nil {
+		t.Fatalf("reorder: %v", err)
+	}
+	if order, _ := orderOf(a.ID); order != 2 {
+		t.Errorf("A order after reorder = %d", order)
+	}
+
+	// Published books: the move lands in the draft revision, live rows stay
+	book.Status = entities.BookStatusPublished
+	if err := bookRepo.Update(book); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	if _, err := svc.MoveItem(b.ID.String(), ownerID, nil, 0); err != nil {
+		t.Fatalf("move in published book: %v", err)
+	}
+	if _, module := orderOf(b.ID); module == nil || *module != bab1.ID {
+		t.Error("live item moved in a published book")
+	}
+	draft, err := svc.GetDraftRevision(bookID, ownerID)
+	if err != nil {
+		t.Fatalf("draft: %v", err)
+	}
+	if len(draft.Changes) != 1 || draft.Changes[0].ID != b.ID {
+		t.Fatalf("draft changes %+v", draft.Changes)
+	}
+}
@@ -0,0 +1,356 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"hifzhun-api/pkg/config"
+	"hifzhun-api/pkg/entities"
+	"hifzhun-api/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+const (
+	maxAnnouncementBody    = 5000
+	announcementPreviewLen = 200
+)
+
+// AnnouncementInput creates or updates an announcement. Nil fields are left
+// as they are; an empty GroupID sends it to the whole class and an empty
+// AttachmentURL removes the attachment. PublishAt in the future schedules
+// the announcement.
+type AnnouncementInput struct {
+	GroupID        *string
+	Title          *string
+	Body           *string
+	AttachmentURL  *string
+	AttachmentName *string
+	Pinned         *bool
+	PublishAt      *time.Time
+}
+
+// announcementClass loads a class where userID may post announcements
+func (s *classService) announcementClass(classID string, userID uuid.UUID) (*entities.Class, error) {
+	if s.announcementRepo == nil {
+		return nil, errors.New("class announcements not available")
+	}
+	class, err := s.classRepo.FindByID(classID)
+	if err != nil {
+		return nil, errors.New("class not found")
+	}
+	if !s.can(class, userID, ClassPermPostAnnouncements) {
+		return nil, errors.New("you don't have permission to post announcements in this class")
+	}
+	return class, nil
+}
+
+// classAnnouncement loads an announcement of a class that userID may change:
+// its author, or a teacher who manages the class members.
+func (s *classService) classAnnouncement(classID, announcementID string, userID uuid.UUID) (*entities.Class, *entities.ClassAnnouncement, error) {
+	class, err := s.announcementClass(classID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	announcement, err := s.announcementRepo.FindByID(announcementID)
+	if err != nil || announcement.ClassID != class.ID {
+		return nil, nil, errors.New("announcement not found")
+	}
+	if announcement.AuthorID != userID && !s.can(class, userID, ClassPermManageMembers) {
+		return nil, nil, errors.New("you can only change your own announcements")
+	}
+	return class, announcement, nil
+}
+
+// applyAnnouncementInput validates and copies the input onto the announcement
+func (s *classService) applyAnnouncementInput(class *entities.Class, a *entities.ClassAnnouncement, in AnnouncementInput) error {
+	if in.GroupID != nil {
+		if *in.GroupID == "" {
+			a.GroupID = nil
+		} else {
+			group, err := s.classGroup(class, *in.GroupID)
+			if err != nil {
+				return err
+			}
+			a.GroupID = &group.ID
+		}
+	}
+	if in.Title != nil {
+		title := strings.TrimSpace(*in.Title)
+		if utf8.RuneCountInString(title) > 200 {
+			return errors.New("title must be at most 200 characters")
+		}
+		a.Title = title
+	}
+	if in.Body != nil {
+		body := strings.TrimSpace(*in.Body)
+		if body == "" {
+			return errors.New("announcement text is required")
+		}
+		if utf8.RuneCountInString(body) > maxAnnouncementBody {
+			return fmt.Errorf("announcement text must be at most %d characters", maxAnnouncementBody)
+		}
+		a.Body = body
+	}
+	if in.AttachmentURL != nil {
+		link := strings.TrimSpace(*in.AttachmentURL)
+		if link != "" && !utils.IsAttachmentURL(link) {
+			return errors.New("attachment must be a file uploaded to this app")
+		}
+		a.AttachmentURL = link
+		if link == "" {
+			a.AttachmentName = ""
+		}
+	}
+	if in.AttachmentName != nil && a.AttachmentURL != "" {
+		a.AttachmentName = strings.TrimSpace(*in.AttachmentName)
+	}
+	if in.Pinned != nil {
+		a.Pinned = *in.Pinned
+	}
+	if in.PublishAt != nil {
+		a.PublishAt = in.PublishAt.In(config.AppLocation)
+	}
+	return nil
+}
+
+// CreateAnnouncement posts an announcement to a class or one of its groups.
+// Students are notified once it is published: right away, or at PublishAt
+// for a scheduled announcement.
+func (s *classService) CreateAnnouncement(classID string, authorID uuid.UUID, in AnnouncementInput) (*entities.ClassAnnouncement, error) {
+	class, err := s.announcementClass(classID, authorID)
+	if err != nil {
+		return nil, err
+	}
+	if in.Body == nil {
+		return nil, errors.New("announcement text is required")
+	}
+	now := time.Now().In(config.AppLocation)
+	announcement := &entities.ClassAnnouncement{ClassID: class.ID, AuthorID: authorID, PublishAt: now}
+	if err := s.applyAnnouncementInput(class, announcement, in); err != nil {
+		return nil, err
+	}
+	if err := s.announcementRepo.Create(announcement); err != nil {
+		return nil, err
+	}
+	if !announcement.PublishAt.After(now) {
+		if _, err := s.deliverAnnouncement(class, announcement, now); err != nil {
+			return nil, err
+		}
+	}
+	return s.announcementRepo.FindByID(announcement.ID.String())
+}
+
+// UpdateAnnouncement edits, pins or reschedules an announcement. Moving a
+// published announcement into the future hides it until then and notifies
+// the students again at the new time.
+func (s *classService) UpdateAnnouncement(classID, announcementID string, authorID uuid.UUID, in AnnouncementInput) (*entities.ClassAnnouncement, error) {
+	class, announcement, err := s.classAnnouncement(classID, announcementID, authorID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyAnnouncementInput(class, announcement, in); err != nil {
+		return nil, err
+	}
+	now := time.Now().In(config.AppLocation)
+	if err := s.announcementRepo.Update(announcement); err != nil {
+		return nil, err
+	}
+	if announcement.PublishAt.After(now) {
+		if err := s.announcementRepo.ResetNotification(announcementID); err != nil {
+			return nil, err
+		}
+	} else if announcement.NotifiedAt == nil {
+		if _, err := s.deliverAnnouncement(class, announcement, now); err != nil {
+			return nil, err
+		}
+	}
+	return s.announcementRepo.FindByID(announcementID)
+}
+
+// DeleteAnnouncement removes an announcement. Notifications already sent
+// stay in the students' inboxes.
+func (s *classService) DeleteAnnouncement(classID, announcementID string, authorID uuid.UUID) error {
+	_, announcement, err := s.classAnnouncement(classID, announcementID, authorID)
+	if err != nil {
+		return err
+	}
+	return s.announcementRepo.Delete(announcement.ID.String())
+}
+
+// GetAnnouncements lists the announcements of a class, pinned first. The
+// teacher and staff see scheduled ones too; a student only sees published
+// announcements for the whole class or their own group.
+func (s *classService) GetAnnouncements(classID string, userID uuid.UUID, page, perPage int) ([]entities.ClassAnnouncement, int64, error) {
+	if s.announcementRepo == nil {
+		return nil, 0, errors.New("class announcements not available")
+	}
+	class, err := s.classRepo.FindByID(classID)
+	if err != nil {
+		return nil, 0, errors.New("class not found")
+	}
+	offset := (page - 1) * perPage
+	if s.classRole(class, userID) != "" {
+		return s.announcementRepo.FindByClass(classID, nil, nil, perPage, offset)
+	}
+
+	if isMember, err := s.classMemberRepo.IsMember(classID, userID.String()); err != nil || !isMember {
+		return nil, 0, errors.New("you are not a member of this class")
+	}
+	groupIDs := []string{}
+	if groupID := s.studentGroupID(classID, userID); groupID != nil {
+		groupIDs = append(groupIDs, groupID.String())
+	}
+	now := time.Now().In(config.AppLocation)
+	return s.announcementRepo.FindByClass(classID, &now, groupIDs, perPage, offset)
+}
+
+// deliverAnnouncement claims an announcement for delivery and notifies the
+// students it is for. An announcement already claimed elsewhere, by the
+// scheduler or a concurrent edit, is skipped; it reports whether this call
+// delivered it.
+func (s *classService) deliverAnnouncement(class *entities.Class, a *entities.ClassAnnouncement, now time.Time) (bool, error) {
+	groupID := ""
+	if a.GroupID != nil {
+		groupID = a.GroupID.String()
+	}
+	members, err := s.classMembers(class, groupID)
+	if err != nil {
+		return false, err
+	}
+	userIDs := make([]uuid.UUID, 0, len(members))
+	for _, m := range members {
+		userIDs = append(userIDs, m.UserID)
+	}
+
+	claimed, err := s.announcementRepo.ClaimNotification(a.ID.String(), now)
+	if err != nil || !claimed {
+		return false, err
+	}
+	a.NotifiedAt = &now
+
+	title := a.Title
+	if title == "" {
+		title = "Pengumuman " + class.Name
+	}
+	body := a.Body
+	if utf8.RuneCountInString(body) > announcementPreviewLen {
+		body = string([]rune(body)[:announcementPreviewLen]) + "…"
+	}
+	notify(s.notifier, userIDs, entities.Notification{
+		Type:    entities.NotificationTypeAnnouncement,
+		Title:   title,
+		Body:    body,
+		ClassID: &class.ID,
+		RefID:   &a.ID,
+	})
+	return true, nil
+}
+
+// DeliverScheduledAnnouncements notifies the students of scheduled
+// announcements whose publish time has come, and returns how many were
+// delivered.
+func (s *classService) DeliverScheduledAnnouncements(now time.Time) (int, error) {
+	if s.announcementRepo == nil {
+		return 0, nil
+	}
+	due, err := s.announcementRepo.FindDueForNotification(now)
+	if err != nil {
+		return 0, err
+	}
+	delivered := 0
+	for i := range due {
+		class, err := s.classRepo.FindByID(due[i].ClassID.String())
+		if err != nil {
+			continue
+		}
+		ok, err := s.deliverAnnouncement(class, &due[i], now)
+		if err != nil {
+			return delivered, err
+		}
+		if ok {
+			delivered++
+		}
+	}
+	return delivered, nil
+}
+
+// SendAssignmentDueReminders notifies students whose assignment is due
+// within the given window and not completed yet. Each student is reminded
+// once per assignment; it returns how many reminders were sent.
+func (s *classService) SendAssignmentDueReminders(now time.Time, within time.Duration) (int, error) {
+	if s.assignmentRepo == nil || s.notifier == nil {
+		return 0, nil
+	}
+	assignments, err := s.assignmentRepo.FindDueBetween(now, now.Add(within))
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, a := range assignments {
+		links, err := s.assignmentRepo.FindItems(a.ID.String())
+		if err != nil {
+			return sent, err
+		}
+		linksByUser := make(map[uuid.UUID][]entities.ClassAssignmentItem)
+		itemIDs := make([]uuid.UUID, 0, len(links))
+		for _, l := range links {
+			linksByUser[l.UserID] = append(linksByUser[l.UserID], l)
+			itemIDs = append(itemIDs, l.ItemID)
+		}
+		items := make(map[uuid.UUID]*entities.Item, len(itemIDs))
+		if len(itemIDs) > 0 {
+			rows, err := s.itemRepo.FindByIDs(itemIDs)
+			if err != nil {
+				return sent, err
+			}
+			for i := range rows {
+				items[rows[i].ID] = &rows[i]
+			}
+		}
+		overrides := make(map[uuid.UUID]entities.ClassAssignmentOverride, len(a.Overrides))
+		for _, o := range a.Overrides {
+			overrides[o.UserID] = o
+		}
+
+		classID := a.ClassID.String()
+		for userID, userLinks := range linksByUser {
+			override := overrides[userID]
+			p := AssignmentStudentProgress{UserID: userID, DueAt: a.DueAt, Excused: override.Excused}
+			if override.DueAt != nil {
+				p.DueAt = *override.DueAt
+			}
+			if !p.DueAt.After(now) || p.DueAt.After(now.Add(within)) {
+				continue
+			}
+			evaluateAssignment(&p, userLinks, items, now)
+			if p.Status != AssignmentStatusNotStarted && p.Status != AssignmentStatusInProgress {
+				continue
+			}
+			if isMember, err := s.classMemberRepo.IsMember(classID, userID.String()); err != nil || !isMember {
+				continue
+			}
+			claimed, err := s.assignmentRepo.ClaimReminder(a.ID, userID, now)
+			if err != nil {
+				return sent, err
+			}
+			if !claimed {
+				continue
+			}
+
+			notify(s.notifier, []uuid.UUID{userID}, entities.Notification{
+				Type:    entities.NotificationTypeAssignmentDue,
+				Title:   fmt.Sprintf("Tugas \"%s\" jatuh tempo %s", a.Title, p.DueAt.In(config.AppLocation).Format("02 Jan 2006 15:04")),
+				Body:    fmt.Sprintf("%d dari %d item sudah dihafal.", p.DoneItems, p.TotalItems),
+				ClassID: &a.ClassID,
+				RefID:   &a.ID,
+			})
+			sent++
+		}
+	}
+	return sent, nil
+}
@@ -0,0 +1,171 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"hifzhun-api/pkg/config"
+	"hifzhun-api/pkg/entities"
+	"hifzhun-api/pkg/services"
+)
+
+func TestClassAnnouncementsAndNotifications(t *testing.T) {
+	env := newTestEnv(t)
+	userRepo := env.userRepo
+	classRepo := env.classRepo
+	notifications := env.notifications
+	svc := env.classSvc
+
+	teacher := &entities.User{Email: "ustadz@example.com", FullName: "Ustadz Ahmad", Role: "teacher"}
+	ali := &entities.User{Email: "ali@example.com", FullName: "Ali", Role: "student"}
+	umar := &entities.User{Email: "umar@example.com", FullName: "Umar", Role: "student"}
+	for _, u := range []*entities.User{teacher, ali, umar} {
+		if err := userRepo.Create(u); err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+	}
+	class := &entities.Class{GuruID: teacher.ID, Name: "Tahfidz Putra", ClassCode: "PUTRA", Type: entities.ClassTypeQuran, IsActive: true}
+	if err := classRepo.Create(class); err != nil {
+		t.Fatalf("create class: %v", err)
+	}
+	classID := class.ID.String()
+	for _, u := range []*entities.User{ali, umar} {
+		if _, err := svc.JoinClass(u.ID, "PUTRA"); err != nil {
+			t.Fatalf("join: %v", err)
+		}
+	}
+	str := func(s string) *string { return &s }
+	yes := true
+	group, err := svc.CreateClassGroup(classID, teacher.ID, services.ClassGroupInput{Name: str("Abu Bakar")})
+	if err != nil {
+		t.Fatalf("create group: %v", err)
+	}
+	if _, err := svc.AddGroupMembers(classID, group.ID.String(), teacher.ID, []string{ali.ID.String()}); err != nil {
+		t.Fatalf("add members: %v", err)
+	}
+
+	if _, err := svc.CreateAnnouncement(classID, ali.ID, services.AnnouncementInput{Body: str("halo")}); err == nil {
+		t.Error("a student posted an announcement")
+	}
+	for _, link := range []string{"https://example.com/jadwal.pdf", "javascript:alert(1)", "/uploads/attachments/../covers/x.png"} {
+		if _, err := svc.CreateAnnouncement(classID, teacher.ID, services.AnnouncementInput{Body: str("x"), AttachmentURL: str(link)}); err == nil {
+			t.Errorf("accepted attachment link %q outside the app's storage", link)
+		}
+	}
+
+	// A pinned class announcement reaches both students right away
+	now := time.Now().In(config.AppLocation)
+	pinned, err := svc.CreateAnnouncement(classID, teacher.ID, services.AnnouncementInput{
+		Title: str("Libur"), Body: str("Setoran hari Jumat diliburkan."), Pinned: &yes,
+		AttachmentURL: str("/uploads/attachments/jadwal.pdf"), AttachmentName: str("jadwal.pdf"),
+	})
+	if err != nil || !pinned.Pinned || pinned.Author == nil {
+		t.Fatalf("create announcement: %+v (%v)", pinned, err)
+	}
+	groupID := group.ID.String()
+	if _, err := svc.CreateAnnouncement(classID, teacher.ID, services.AnnouncementInput{GroupID: &groupID, Body: str("Halaqah Abu Bakar ke masjid.")}); err != nil {
+		t.Fatalf("group announcement: %v", err)
+	}
+	later := now.Add(2 * time.Hour)
+	scheduled, err := svc.CreateAnnouncement(classID, teacher.ID, services.AnnouncementInput{Body: str("Ujian pekan depan."), PublishAt: &later})
+	if err != nil {
+		t.Fatalf("scheduled announcement: %v", err)
+	}
+
+	unread := func(u *entities.User) int64 {
+		n, _ := notifications.UnreadCount(u.ID)
+		return n
+	}
+	if unread(ali) != 2 || unread(umar) != 1 || unread(teacher) != 0 {
+		t.Fatalf("unread ali=%d umar=%d teacher=%d", unread(ali), unread(umar), unread(teacher))
+	}
+
+	// Students only see published announcements for them; staff see all
+	list, total, err := svc.GetAnnouncements(classID, umar.ID, 1, 20)
+	if err != nil || total != 1 || list[0].ID != pinned.ID {
+		t.Fatalf("umar announcements = %+v (%v)", list, err)
+	}
+	if list, _, _ := svc.GetAnnouncements(classID, ali.ID, 1, 20); len(list) != 2 || list[0].ID != pinned.ID {
+		t.Fatalf("ali announcements = %+v", list)
+	}
+	if _, total, _ := svc.GetAnnouncements(classID, teacher.ID, 1, 20); total != 3 {
+		t.Errorf("teacher sees %d announcements", total)
+	}
+
+	// The scheduled announcement is delivered once its time comes
+	if n, err := svc.DeliverScheduledAnnouncements(now); err != nil || n != 0 {
+		t.Errorf("delivered %d early (%v)", n, err)
+	}
+	if n, err := svc.DeliverScheduledAnnouncements(later.Add(time.Minute)); err != nil || n != 1 {
+		t.Fatalf("delivered %d (%v)", n, err)
+	}
+	if n, _ := svc.DeliverScheduledAnnouncements(later.Add(2 * time.Minute)); n != 0 || unread(umar) != 2 {
+		t.Errorf("redelivered %d, umar unread %d", n, unread(umar))
+	}
+	// A delivery racing the scheduler cannot claim the announcement again
+	if claimed, err := env.announcementRepo.ClaimNotification(scheduled.ID.String(), later); err != nil || claimed {
+		t.Errorf("claimed a delivered announcement again (%v)", err)
+	}
+	if _, err := svc.UpdateAnnouncement(classID, scheduled.ID.String(), teacher.ID, services.AnnouncementInput{Body: str("")}); err == nil {
+		t.Error("cleared the announcement text")
+	}
+
+	// Assignment due reminders go out once, only inside the window
+	due := now.Add(12 * time.Hour)
+	if _, err := svc.CreateAssignment(classID, teacher.ID, services.AssignmentInput{Title: "An-Naba", ContentRef: "surah:78:1-20", DueAt: due}); err != nil {
+		t.Fatalf("assignment: %v", err)
+	}
+	if n, err := svc.SendAssignmentDueReminders(now, 6*time.Hour); err != nil || n != 0 {
+		t.Errorf("reminded %d too early (%v)", n, err)
+	}
+	if n, err := svc.SendAssignmentDueReminders(now, 24*time.Hour); err != nil || n != 2 {
+		t.Fatalf("reminded %d (%v)", n, err)
+	}
+	if n, _ := svc.SendAssignmentDueReminders(now.Add(time.Minute), 24*time.Hour); n != 0 {
+		t.Errorf("reminded %d twice", n)
+	}
+	// An extended due date is what counts for the student who has it
+	inThreeDays := now.Add(72 * time.Hour)
+	extended, err := svc.CreateAssignment(classID, teacher.ID, services.AssignmentInput{Title: "An-Nazi'at", ContentRef: "surah:79:1-20", DueAt: now.Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("second assignment: %v", err)
+	}
+	if err := env.db.Model(extended).Update("due_at", now.Add(-time.Hour)).Error; err != nil {
+		t.Fatalf("backdate: %v", err)
+	}
+	if _, err := svc.SetAssignmentOverride(classID, extended.ID.String(), teacher.ID, ali.ID.String(), &due, false, ""); err != nil {
+		t.Fatalf("extend: %v", err)
+	}
+	if _, err := svc.SetAssignmentOverride(classID, extended.ID.String(), teacher.ID, umar.ID.String(), &inThreeDays, false, ""); err != nil {
+		t.Fatalf("extend: %v", err)
+	}
+	if n, err := svc.SendAssignmentDueReminders(now, 24*time.Hour); err != nil || n != 1 {
+		t.Errorf("reminded %d of the extended assignment (%v)", n, err)
+	}
+
+	// Read state
+	inbox, total, err := notifications.GetNotifications(umar.ID, true, 1, 20)
+	if err != nil || total != 3 || inbox[0].Type != entities.NotificationTypeAssignmentDue {
+		t.Fatalf("umar inbox = %+v (%v)", inbox, err)
+	}
+	if err := notifications.MarkRead(ali.ID, inbox[0].ID.String()); err == nil {
+		t.Error("marked another user's notification")
+	}
+	if err := notifications.MarkRead(umar.ID, inbox[0].ID.String()); err != nil || unread(umar) != 2 {
+		t.Fatalf("mark read: %v, unread %d", err, unread(umar))
+	}
+	if n, err := notifications.MarkAllRead(umar.ID); err != nil || n != 2 || unread(umar) != 0 {
+		t.Fatalf("mark all read: %d (%v), unread %d", n, err, unread(umar))
+	}
+	if _, total, _ := notifications.GetNotifications(umar.ID, false, 1, 20); total != 3 {
+		t.Errorf("umar has %d notifications", total)
+	}
+
+	// Deleting the group removes its announcements
+	if err := svc.DeleteClassGroup(classID, groupID, teacher.ID); err != nil {
+		t.Fatalf("delete group: %v", err)
+	}
+	if _, total, _ := svc.GetAnnouncements(classID, teacher.ID, 1, 20); total != 2 {
+		t.Errorf("%d announcements after deleting the group", total)
+	}
+}
@@ -0,0 +1,769 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"hifzhun-api/pkg/config"
+	"hifzhun-api/pkg/entities"
+
+	"github.com/google/uuid"
+)
+
+// Per-student assignment statuses
+const (
+	AssignmentStatusNotStarted = "not_started"
+	AssignmentStatusInProgress = "in_progress"
+	AssignmentStatusCompleted  = "completed"
+	AssignmentStatusLate       = "late"    // completed after the due date
+	AssignmentStatusOverdue    = "overdue" // past the due date and not completed
+	AssignmentStatusExcused    = "excused"
+)
+
+// AssignmentInput describes an assignment: a Quran range (ContentRef) for
+// quran classes, or modules/items of a class book for book classes. GroupID
+// gives it to the students of one group instead of the whole class.
+type AssignmentInput struct {
+	GroupID     *uuid.UUID
+	Title       string
+	Description string
+	ContentRef  string
+	BookID      *uuid.UUID
+	ModuleIDs   []uuid.UUID
+	BookItemIDs []uuid.UUID
+	DueAt       time.Time
+}
+
+// AssignmentStudentProgress is one student's state on an assignment.
+// An item counts as done once it left the memorizing phase (start/menghafal).
+type AssignmentStudentProgress struct {
+	UserID      uuid.UUID    `json:"user_id"`
+	Email       string       `json:"email"`
+	FullName    string       `json:"full_name"`
+	DueAt       time.Time    `json:"due_at"`
+	Excused     bool         `json:"excused"`
+	TotalItems  int          `json:"total_items"`
+	DoneItems   int          `json:"done_items"`
+	Status      string       `json:"status"`
+	CompletedAt *time.Time   `json:"completed_at,omitempty"`
+	DaysLate    int          `json:"days_late,omitempty"`
+	Items       []ItemDetail `json:"items,omitempty"`
+}
+
+// AssignmentProgress is the teacher's completion and lateness view of an
+// assignment. Students is only filled for the single-assignment view.
+type AssignmentProgress struct {
+	Assignment entities.ClassAssignment    `json:"assignment"`
+	Students   []AssignmentStudentProgress `json:"students,omitempty"`
+	Total      int                         `json:"total"`
+	Completed  int                         `json:"completed"`
+	Late       int                         `json:"late"`
+	Overdue    int                         `json:"overdue"`
+	InProgress int                         `json:"in_progress"`
+	NotStarted int                         `json:"not_started"`
+	Excused    int                         `json:"excused"`
+}
+
+// MyAssignment is an assignment as seen by a student
+type MyAssignment struct {
+	entities.ClassAssignment
+	Progress AssignmentStudentProgress `json:"progress"`
+}
+
+// assignmentClass loads a class and checks that teacherID holds perm in it
+func (s *classService) assignmentClass(classID string, teacherID uuid.UUID, perm string) (*entities.Class, error) {
+	if s.assignmentRepo == nil {
+		return nil, errors.New("assignment repository not available")
+	}
+	class, err := s.classRepo.FindByID(classID)
+	if err != nil {
+		return nil, errors.New("class not found")
+	}
+	if !s.can(class, teacherID, perm) {
+		return nil, errors.New("you don't have permission to manage assignments of this class")
+	}
+	return class, nil
+}
+
+// classAssignment loads an assignment of a class where teacherID holds perm
+func (s *classService) classAssignment(classID, assignmentID string, teacherID uuid.UUID, perm string) (*entities.Class, *entities.ClassAssignment, error) {
+	class, err := s.assignmentClass(classID, teacherID, perm)
+	if err != nil {
+		return nil, nil, err
+	}
+	assignment, err := s.assignmentRepo.FindByID(assignmentID)
+	if err != nil || assignment.ClassID != class.ID {
+		return nil, nil, errors.New("assignment not found")
+	}
+	return class, assignment, nil
+}
+
+// assignmentBookItems resolves the book items an assignment targets: the
+// items of its modules (with their child modules) and its single items, in
+// book order.
+func (s *classService) assignmentBookItems(a *entities.ClassAssignment) ([]entities.BookItem, error) {
+	if a.BookID == nil {
+		return nil, errors.New("assignment has no book")
+	}
+	bookID := a.BookID.String()
+	modules, err := s.bookModuleRepo.FindByBookID(bookID)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.bookItemRepo.FindByBookID(bookID)
+	if err != nil {
+		return nil, err
+	}
+
+	children := make(map[uuid.UUID][]uuid.UUID)
+	moduleExists := make(map[uuid.UUID]bool, len(modules))
+	for _, m := range modules {
+		moduleExists[m.ID] = true
+		if m.ParentID != nil {
+			children[*m.ParentID] = append(children[*m.ParentID], m.ID)
+		}
+	}
+	selectedModules := make(map[uuid.UUID]bool)
+	var selectModule func(id uuid.UUID)
+	selectModule = func(id uuid.UUID) {
+		if selectedModules[id] {
+			return
+		}
+		selectedModules[id] = true
+		for _, child := range children[id] {
+			selectModule(child)
+		}
+	}
+	for _, id := range a.ModuleIDs {
+		if !moduleExists[id] {
+			return nil, errors.New("module not found in this book")
+		}
+		selectModule(id)
+	}
+
+	itemExists := make(map[uuid.UUID]bool, len(items))
+	for _, it := range items {
+		itemExists[it.ID] = true
+	}
+	selectedItems := make(map[uuid.UUID]bool, len(a.BookItemIDs))
+	for _, id := range a.BookItemIDs {
+		if !itemExists[id] {
+			return nil, errors.New("book item not found in this book")
+		}
+		selectedItems[id] = true
+	}
+
+	var result []entities.BookItem
+	for _, it := range items {
+		if selectedItems[it.ID] || (it.ModuleID != nil && selectedModules[*it.ModuleID]) {
+			result = append(result, it)
+		}
+	}
+	return result, nil
+}
+
+// provisionAssignment creates (or reuses) the student's Items for an
+// assignment and adds the links that are missing, so provisioning again
+// only fills gaps.
+func (s *classService) provisionAssignment(class *entities.Class, a *entities.ClassAssignment, userID uuid.UUID) error {
+	existing, err := s.assignmentRepo.FindItemsByUser(a.ID.String(), userID.String())
+	if err != nil {
+		return err
+	}
+	var created provisionedRows
+	links, err := s.assignmentLinks(class, a, userID, existing, &created)
+	if err == nil {
+		err = s.assignmentRepo.CreateItems(links)
+	}
+	if err != nil {
+		s.removeProvisioned(&created)
+		return err
+	}
+	return nil
+}
+
+// provisionedRows collects the Items and Juzs assignmentLinks created, so
+// they can be removed when the links are not saved.
+type provisionedRows struct {
+	itemIDs []uuid.UUID
+	juzIDs  []uuid.UUID
+}
+
+// removeProvisioned deletes the rows created for links that were not saved.
+// It is best effort: the caller already reports the original error.
+func (s *classService) removeProvisioned(created *provisionedRows) {
+	if len(created.itemIDs) == 0 && len(created.juzIDs) == 0 {
+		return
+	}
+	if err := s.assignmentRepo.DeleteProvisioned(created.itemIDs, created.juzIDs); err != nil {
+		log.Printf("⚠️ Failed to remove items of an unsaved assignment: %v", err)
+	}
+}
+
+// assignmentLinks creates (or reuses) the student's Items for an assignment
+// and returns the links to them that are not in existing yet. The links are
+// not saved; the rows it creates are added to created.
+func (s *classService) assignmentLinks(class *entities.Class, a *entities.ClassAssignment, userID uuid.UUID, existing []entities.ClassAssignmentItem, created *provisionedRows) ([]entities.ClassAssignmentItem, error) {
+	linked := make(map[uuid.UUID]bool, len(existing))
+	for _, l := range existing {
+		linked[l.ItemID] = true
+	}
+	var links []entities.ClassAssignmentItem
+	link := func(itemID uuid.UUID, bookItemID *uuid.UUID) {
+		if linked[itemID] {
+			return
+		}
+		linked[itemID] = true
+		links = append(links, entities.ClassAssignmentItem{
+			AssignmentID: a.ID,
+			UserID:       userID,
+			ItemID:       itemID,
+			BookItemID:   bookItemID,
+		})
+	}
+
+	if a.TargetType == entities.AssignmentTargetBook {
+		if s.bookSvc == nil {
+			return nil, errors.New("book service not available")
+		}
+		bookItems, err := s.assignmentBookItems(a)
+		if err != nil {
+			return nil, err
+		}
+		for _, bookItem := range bookItems {
+			contentRef := "book:" + a.BookID.String() + ":item:" + bookItem.ID.String()
+			before, _ := s.itemRepo.FindByOwnerAndContentRef(userID, contentRef)
+			started, err := s.bookSvc.StartItemMemorization(userID, a.BookID.String(), bookItem.ID.String())
+			if err != nil {
+				return nil, err
+			}
+			created.itemIDs = append(created.itemIDs, newItemIDs(before, started)...)
+			bookItemID := bookItem.ID
+			if len(started.Clozes) == 0 {
+				link(started.ItemID, &bookItemID)
+				continue
+			}
+			for _, cloze := range started.Clozes {
+				link(cloze.ItemID, &bookItemID)
+			}
+		}
+		return links, nil
+	}
+
+	// Quran range: one Item per juz segment, filed under the student's
+	// class juz (created when missing). An Item with the same range already
+	// in the class juz is reused.
+	segments, err := s.quranValidator.SplitSurahRefByJuz(a.ContentRef)
+	if err != nil {
+		return nil, err
+	}
+	classItemIDs, err := s.classQuranItemIDSet(userID, class.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	classJuzs, err := s.juzRepo.FindByUserAndClass(userID.String(), class.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	juzByIndex := make(map[int]*entities.Juz, len(classJuzs))
+	for i := range classJuzs {
+		juzByIndex[classJuzs[i].Index] = &classJuzs[i]
+	}
+	classID := class.ID
+	for _, seg := range segments {
+		juz := juzByIndex[seg.JuzIndex]
+		if juz == nil {
+			juz = &entities.Juz{UserID: userID, ClassID: &classID, Index: seg.JuzIndex}
+			if err := s.juzRepo.Create(juz); err != nil {
+				return nil, err
+			}
+			created.juzIDs = append(created.juzIDs, juz.ID)
+			juzByIndex[seg.JuzIndex] = juz
+		}
+
+		var itemID uuid.UUID
+		existing, _ := s.itemRepo.FindByOwnerAndContentRef(userID, seg.ContentRef)
+		for _, it := range existing {
+			if classItemIDs[it.ID] {
+				itemID = it.ID
+				break
+			}
+		}
+		if itemID == uuid.Nil {
+			item := &entities.Item{
+				OwnerID:    userID,
+				SourceType: "quran",
+				ContentRef: seg.ContentRef,
+			}
+			if err := s.itemRepo.Create(item); err != nil {
+				return nil, err
+			}
+			created.itemIDs = append(created.itemIDs, item.ID)
+			if err := s.juzItemRepo.Create(&entities.JuzItem{ID: uuid.New(), JuzID: juz.ID, ItemID: item.ID}); err != nil {
+				return nil, err
+			}
+			itemID = item.ID
+		}
+		link(itemID, nil)
+	}
+	return links, nil
+}
+
+// newItemIDs returns the Items of started that are not in before.
+func newItemIDs(before []entities.Item, started *StartMemorizationResult) []uuid.UUID {
+	had := make(map[uuid.UUID]bool, len(before))
+	for _, it := range before {
+		had[it.ID] = true
+	}
+	ids := []uuid.UUID{started.ItemID}
+	for _, cloze := range started.Clozes {
+		ids = append(ids, cloze.ItemID)
+	}
+	var out []uuid.UUID
+	for _, id := range ids {
+		if id != uuid.Nil && !had[id] {
+			had[id] = true
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// provisionOpenAssignments gives a new class (or group) member the Items of
+// every assignment for them that is not past its due date yet.
+func (s *classService) provisionOpenAssignments(class *entities.Class, userID uuid.UUID) error {
+	if s.assignmentRepo == nil {
+		return nil
+	}
+	assignments, err := s.assignmentRepo.FindByClassID(class.ID.String())
+	if err != nil {
+		return err
+	}
+	groupID := s.studentGroupID(class.ID.String(), userID)
+	now := time.Now().In(config.AppLocation)
+	for i := range assignments {
+		if assignments[i].DueAt.Before(now) {
+			continue
+		}
+		if target := assignments[i].GroupID; target != nil && (groupID == nil || *groupID != *target) {
+			continue
+		}
+		if err := s.provisionAssignment(class, &assignments[i], userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateAssignment creates an assignment and the Items of every student
+// in the class, or in the group it is given to.
+func (s *classService) CreateAssignment(classID string, teacherID uuid.UUID, in AssignmentInput) (*entities.ClassAssignment, error) {
+	class, err := s.assignmentClass(classID, teacherID, ClassPermManageBooks)
+	if err != nil {
+		return nil, err
+	}
+	title := strings.TrimSpace(in.Title)
+	if title == "" {
+		return nil, errors.New("title is required")
+	}
+	if in.DueAt.IsZero() {
+		return nil, errors.New("due_at is required")
+	}
+	if in.DueAt.Before(time.Now()) {
+		return nil, errors.New("due_at must be in the future")
+	}
+
+	if in.GroupID != nil {
+		if _, err := s.classGroup(class, in.GroupID.String()); err != nil {
+			return nil, err
+		}
+	}
+
+	assignment := &entities.ClassAssignment{
+		ClassID:     class.ID,
+		GroupID:     in.GroupID,
+		TeacherID:   teacherID,
+		Title:       title,
+		Description: in.Description,
+		DueAt:       in.DueAt,
+	}
+
+	switch class.Type {
+	case entities.ClassTypeQuran:
+		if s.quranValidator == nil {
+			return nil, errors.New("quran validator not available")
+		}
+		contentRef := strings.TrimSpace(in.ContentRef)
+		if contentRefMode(contentRef) != "surah" {
+			return nil, errors.New("content_ref must be a surah range, e.g. surah:78:1-20")
+		}
+		if err := s.quranValidator.ValidateContentRef("surah", contentRef); err != nil {
+			return nil, err
+		}
+		assignment.TargetType = entities.AssignmentTargetQuran
+		assignment.ContentRef = contentRef
+	case entities.ClassTypeBook:
+		if in.BookID == nil {
+			return nil, errors.New("book_id is required for book classes")
+		}
+		if _, err := s.classBookRepo.FindByClassAndBook(classID, in.BookID.String()); err != nil {
+			return nil, errors.New("book is not assigned to this class")
+		}
+		if len(in.ModuleIDs) == 0 && len(in.BookItemIDs) == 0 {
+			return nil, errors.New("select at least one module or item")
+		}
+		assignment.TargetType = entities.AssignmentTargetBook
+		assignment.BookID = in.BookID
+		assignment.ModuleIDs = in.ModuleIDs
+		assignment.BookItemIDs = in.BookItemIDs
+		bookItems, err := s.assignmentBookItems(assignment)
+		if err != nil {
+			return nil, err
+		}
+		if len(bookItems) == 0 {
+			return nil, errors.New("the selected modules have no items")
+		}
+	default:
+		return nil, errors.New("unsupported class type")
+	}
+
+	groupID := ""
+	if in.GroupID != nil {
+		groupID = in.GroupID.String()
+	}
+	members, err := s.classMembers(class, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The students' Items are created first; the assignment is only saved,
+	// with all its links, once every student has them. When that fails the
+	// Items created on the way are removed again.
+	assignment.ID = uuid.New()
+	var links []entities.ClassAssignmentItem
+	var created provisionedRows
+	for _, member := range members {
+		memberLinks, err := s.assignmentLinks(class, assignment, member.UserID, nil, &created)
+		if err != nil {
+			s.removeProvisioned(&created)
+			return nil, err
+		}
+		links = append(links, memberLinks...)
+	}
+	if err := s.assignmentRepo.CreateWithItems(assignment, links); err != nil {
+		s.removeProvisioned(&created)
+		return nil, err
+	}
+	return assignment, nil
+}
+
+// UpdateAssignment changes the title, description or due date. The target
+// cannot change once the students' Items exist.
+func (s *classService) UpdateAssignment(classID, assignmentID string, teacherID uuid.UUID, title, description *string, dueAt *time.Time) (*entities.ClassAssignment, error) {
+	_, assignment, err := s.classAssignment(classID, assignmentID, teacherID, ClassPermManageBooks)
+	if err != nil {
+		return nil, err
+	}
+	if title != nil {
+		t := strings.TrimSpace(*title)
+		if t == "" {
+			return nil, errors.New("title cannot be empty")
+		}
+		assignment.Title = t
+	}
+	if description != nil {
+		assignment.Description = *description
+	}
+	if dueAt != nil {
+		assignment.DueAt = *dueAt
+	}
+	if err := s.assignmentRepo.Update(assignment); err != nil {
+		return nil, err
+	}
+	return assignment, nil
+}
+
+// DeleteAssignment removes an assignment. The students keep their Items
+// and progress.
+func (s *classService) DeleteAssignment(classID, assignmentID string, teacherID uuid.UUID) error {
+	_, assignment, err := s.classAssignment(classID, assignmentID, teacherID, ClassPermManageBooks)
+	if err != nil {
+		return err
+	}
+	return s.assignmentRepo.Delete(assignment.ID.String())
+}
+
+// SetAssignmentOverride gives one student another due date or excuses them
+func (s *classService) SetAssignmentOverride(classID, assignmentID string, teacherID uuid.UUID, studentID string, dueAt *time.Time, excused bool, note string) (*entities.ClassAssignmentOverride, error) {
+	_, assignment, err := s.classAssignment(classID, assignmentID, teacherID, ClassPermManageMembers)
+	if err != nil {
+		return nil, err
+	}
+	studentUUID, err := uuid.Parse(studentID)
+	if err != nil {
+		return nil, errors.New("invalid student id")
+	}
+	if isMember, err := s.classMemberRepo.IsMember(classID, studentID); err != nil || !isMember {
+		return nil, errors.New("student is not a member of this class")
+	}
+	if dueAt == nil && !excused {
+		return nil, errors.New("set due_at or excused")
+	}
+
+	override, err := s.assignmentRepo.FindOverride(assignment.ID.String(), studentID)
+	if err != nil {
+		override = &entities.ClassAssignmentOverride{AssignmentID: assignment.ID, UserID: studentUUID}
+	}
+	override.DueAt = dueAt
+	override.Excused = excused
+	override.Note = note
+	if err := s.assignmentRepo.SaveOverride(override); err != nil {
+		return nil, err
+	}
+	return override, nil
+}
+
+// RemoveAssignmentOverride puts a student back on the assignment due date
+func (s *classService) RemoveAssignmentOverride(classID, assignmentID string, teacherID uuid.UUID, studentID string) error {
+	_, assignment, err := s.classAssignment(classID, assignmentID, teacherID, ClassPermManageMembers)
+	if err != nil {
+		return err
+	}
+	return s.assignmentRepo.DeleteOverride(assignment.ID.String(), studentID)
+}
+
+// assignmentItemDoneAt is when an item left the memorizing phase, as far as
+// the item records it.
+func assignmentItemDoneAt(item *entities.Item) *time.Time {
+	for _, t := range []*time.Time{item.IntervalStartAt, item.FSRSStartAt, item.ApprovedAt, item.LastReviewAt} {
+		if t != nil {
+			return t
+		}
+	}
+	return nil
+}
+
+// evaluateAssignment fills the item counts and status of one student from
+// the Items linked to the assignment.
+func evaluateAssignment(p *AssignmentStudentProgress, links []entities.ClassAssignmentItem, items map[uuid.UUID]*entities.Item, now time.Time) {
+	p.TotalItems = len(links)
+	var completedAt *time.Time
+	for _, l := range links {
+		item := items[l.ItemID]
+		if item == nil {
+			continue
+		}
+		switch item.Status {
+		case entities.ItemStatusStart, entities.ItemStatusMenghafal, entities.ItemStatusInactive:
+			continue
+		}
+		p.DoneItems++
+		if at := assignmentItemDoneAt(item); at != nil && (completedAt == nil || at.After(*completedAt)) {
+			completedAt = at
+		}
+	}
+
+	switch {
+	case p.Excused:
+		p.Status = AssignmentStatusExcused
+	case p.TotalItems > 0 && p.DoneItems == p.TotalItems:
+		p.CompletedAt = completedAt
+		p.Status = AssignmentStatusCompleted
+		if completedAt != nil && completedAt.After(p.DueAt) {
+			p.Status = AssignmentStatusLate
+			p.DaysLate = int(math.Ceil(completedAt.Sub(p.DueAt).Hours() / 24))
+		}
+	case now.After(p.DueAt):
+		p.Status = AssignmentStatusOverdue
+		p.DaysLate = int(math.Ceil(now.Sub(p.DueAt).Hours() / 24))
+	case p.DoneItems > 0:
+		p.Status = AssignmentStatusInProgress
+	default:
+		p.Status = AssignmentStatusNotStarted
+	}
+}
+
+func (p *AssignmentProgress) count(status string) {
+	p.Total++
+	switch status {
+	case AssignmentStatusCompleted:
+		p.Completed++
+	case AssignmentStatusLate:
+		p.Late++
+	case AssignmentStatusOverdue:
+		p.Overdue++
+	case AssignmentStatusInProgress:
+		p.InProgress++
+	case AssignmentStatusNotStarted:
+		p.NotStarted++
+	case AssignmentStatusExcused:
+		p.Excused++
+	}
+}
+
+// assignmentProgress builds the teacher view of one assignment on top of
+// the class progress (GetStudentProgress). Students without Items for the
+// assignment (joined after its due date) are left out.
+func (s *classService) assignmentProgress(a *entities.ClassAssignment, students []StudentProgress, withStudents bool, now time.Time) (*AssignmentProgress, error) {
+	links, err := s.assignmentRepo.FindItems(a.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	linksByUser := make(map[uuid.UUID][]entities.ClassAssignmentItem)
+	itemIDs := make([]uuid.UUID, 0, len(links))
+	for _, l := range links {
+		linksByUser[l.UserID] = append(linksByUser[l.UserID], l)
+		itemIDs = append(itemIDs, l.ItemID)
+	}
+	items := make(map[uuid.UUID]*entities.Item, len(itemIDs))
+	if len(itemIDs) > 0 {
+		rows, err := s.itemRepo.FindByIDs(itemIDs)
+		if err != nil {
+			return nil, err
+		}
+		for i := range rows {
+			items[rows[i].ID] = &rows[i]
+		}
+	}
+	overrides := make(map[uuid.UUID]entities.ClassAssignmentOverride, len(a.Overrides))
+	for _, o := range a.Overrides {
+		overrides[o.UserID] = o
+	}
+
+	result := &AssignmentProgress{Assignment: *a}
+	for _, student := range students {
+		userLinks := linksByUser[student.UserID]
+		override, hasOverride := overrides[student.UserID]
+		if len(userLinks) == 0 && !hasOverride {
+			continue
+		}
+
+		p := AssignmentStudentProgress{
+			UserID:   student.UserID,
+			Email:    student.Email,
+			FullName: student.FullName,
+			DueAt:    a.DueAt,
+			Excused:  override.Excused,
+		}
+		if override.DueAt != nil {
+			p.DueAt = *override.DueAt
+		}
+		evaluateAssignment(&p, userLinks, items, now)
+		result.count(p.Status)
+
+		if withStudents {
+			linked := make(map[uuid.UUID]bool, len(userLinks))
+			for _, l := range userLinks {
+				linked[l.ItemID] = true
+			}
+			for _, detail := range student.Items {
+				if linked[detail.ItemID] {
+					p.Items = append(p.Items, detail)
+				}
+			}
+			result.Students = append(result.Students, p)
+		}
+	}
+	return result, nil
+}
+
+// GetClassAssignments lists the assignments of a class with completion and
+// lateness counts.
+func (s *classService) GetClassAssignments(classID string, teacherID uuid.UUID) ([]AssignmentProgress, error) {
+	if _, err := s.assignmentClass(classID, teacherID, ClassPermViewProgress); err != nil {
+		return nil, err
+	}
+	assignments, err := s.assignmentRepo.FindByClassID(classID)
+	if err != nil {
+		return nil, err
+	}
+	students, err := s.GetStudentProgress(classID, teacherID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().In(config.AppLocation)
+	result := make([]AssignmentProgress, 0, len(assignments))
+	for i := range assignments {
+		p, err := s.assignmentProgress(&assignments[i], students, false, now)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *p)
+	}
+	return result, nil
+}
+
+// GetAssignmentProgress returns every student's completion of an
+// assignment, with their assignment items.
+func (s *classService) GetAssignmentProgress(classID, assignmentID string, teacherID uuid.UUID) (*AssignmentProgress, error) {
+	_, assignment, err := s.classAssignment(classID, assignmentID, teacherID, ClassPermViewProgress)
+	if err != nil {
+		return nil, err
+	}
+	students, err := s.GetStudentProgress(classID, teacherID, "")
+	if err != nil {
+		return nil, err
+	}
+	return s.assignmentProgress(assignment, students, true, time.Now().In(config.AppLocation))
+}
+
+// GetMyAssignments lists a student's assignments in a class with their own
+// due date and progress.
+func (s *classService) GetMyAssignments(classID string, userID uuid.UUID) ([]MyAssignment, error) {
+	if s.assignmentRepo == nil {
+		return nil, errors.New("assignment repository not available")
+	}
+	if isMember, err := s.classMemberRepo.IsMember(classID, userID.String()); err != nil || !isMember {
+		return nil, errors.New("you are not a member of this class")
+	}
+	assignments, err := s.assignmentRepo.FindByClassID(classID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().In(config.AppLocation)
+	result := make([]MyAssignment, 0, len(assignments))
+	for _, a := range assignments {
+		links, err := s.assignmentRepo.FindItemsByUser(a.ID.String(), userID.String())
+		if err != nil {
+			return nil, err
+		}
+		p := AssignmentStudentProgress{UserID: userID, DueAt: a.DueAt}
+		for _, o := range a.Overrides {
+			if o.UserID == userID {
+				p.Excused = o.Excused
+				if o.DueAt != nil {
+					p.DueAt = *o.DueAt
+				}
+			}
+		}
+		if len(links) == 0 && !p.Excused {
+			continue
+		}
+
+		itemIDs := make([]uuid.UUID, 0, len(links))
+		for _, l := range links {
+			itemIDs = append(itemIDs, l.ItemID)
+		}
+		items := make(map[uuid.UUID]*entities.Item, len(itemIDs))
+		if len(itemIDs) > 0 {
+			rows, err := s.itemRepo.FindByIDs(itemIDs)
+			if err != nil {
+				return nil, err
+			}
+			for i := range rows {
+				items[rows[i].ID] = &rows[i]
+			}
+		}
+		evaluateAssignment(&p, links, items, now)
+
+		// Other students' overrides are not shown
+		a.Overrides = nil
+		result = append(result, MyAssignment{ClassAssignment: a, Progress: p})
+	}
+	return result, nil
+}
@@ -0,0 +1,202 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"hifzhun-api/pkg/config"
+	"hifzhun-api/pkg/entities"
+	"hifzhun-api/pkg/services"
+)
+
+func TestClassAssignments(t *testing.T) {
+	env := newTestEnv(t)
+	db := env.db
+	userRepo := env.userRepo
+	classRepo := env.classRepo
+	itemRepo := env.itemRepo
+	svc := env.classSvc
+
+	teacher := &entities.User{Email: "ustadz@example.com", FullName: "Ustadz Ahmad"}
+	ali := &entities.User{Email: "ali@example.com", FullName: "Ali"}
+	umar := &entities.User{Email: "umar@example.com", FullName: "Umar"}
+	late := &entities.User{Email: "zaid@example.com", FullName: "Zaid"}
+	for _, u := range []*entities.User{teacher, ali, umar, late} {
+		if err := userRepo.Create(u); err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+	}
+	class := &entities.Class{GuruID: teacher.ID, Name: "Tahfidz A", ClassCode: "TAHFIDZ", IsActive: true}
+	if err := classRepo.Create(class); err != nil {
+		t.Fatalf("create class: %v", err)
+	}
+	classID := class.ID.String()
+	for _, u := range []*entities.User{ali, umar} {
+		if _, err := svc.JoinClass(u.ID, "TAHFIDZ"); err != nil {
+			t.Fatalf("join: %v", err)
+		}
+	}
+
+	now := time.Now().In(config.AppLocation)
+	if _, err := svc.CreateAssignment(classID, ali.ID, services.AssignmentInput{Title: "x", ContentRef: "surah:78:1-20", DueAt: now.Add(time.Hour)}); err == nil {
+		t.Error("student created an assignment")
+	}
+	if _, err := svc.CreateAssignment(classID, teacher.ID, services.AssignmentInput{Title: "x", ContentRef: "surah:78:1-99", DueAt: now.Add(time.Hour)}); err == nil {
+		t.Error("accepted a verse past the end of the surah")
+	}
+	if _, err := svc.CreateAssignment(classID, teacher.ID, services.AssignmentInput{Title: "x", ContentRef: "surah:78:1-20", DueAt: now.Add(-time.Minute)}); err == nil {
+		t.Error("accepted a due date in the past")
+	}
+
+	naba, err := svc.CreateAssignment(classID, teacher.ID, services.AssignmentInput{Title: "An-Naba 1-20", ContentRef: "surah:78:1-20", DueAt: now.Add(48 * time.Hour)})
+	if err != nil {
+		t.Fatalf("create naba: %v", err)
+	}
+	// 2:140-145 crosses from juz 1 into juz 2
+	baqarah, err := svc.CreateAssignment(classID, teacher.ID, services.AssignmentInput{Title: "Al-Baqarah", ContentRef: "surah:2:140-145", DueAt: now.Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("create baqarah: %v", err)
+	}
+	// Only an existing assignment can be overdue
+	baqarah.DueAt = now.Add(-24 * time.Hour)
+	if err := db.Model(baqarah).Update("due_at", baqarah.DueAt).Error; err != nil {
+		t.Fatalf("backdate baqarah: %v", err)
+	}
+
+	aliItems, _ := itemRepo.FindByOwner(ali.ID.String())
+	if len(aliItems) != 3 {
+		t.Fatalf("ali items %+v", aliItems)
+	}
+	var filed int64
+	db.Table("juz_items").
+		Joins("JOIN juzs ON juzs.id = juz_items.juz_id").
+		Where("juzs.user_id = ? AND juzs.class_id = ?", ali.ID, class.ID).
+		Count(&filed)
+	var juzCount int64
+	db.Model(&entities.Juz{}).Where("user_id = ?", ali.ID).Count(&juzCount)
+	if filed != 3 || juzCount != 3 {
+		t.Fatalf("ali has %d class juz items in %d juz", filed, juzCount)
+	}
+
+	// Ali memorized An-Naba; Umar is excused from Al-Baqarah
+	for _, it := range aliItems {
+		if it.ContentRef == "surah:78:1-20" {
+			it.Status = entities.ItemStatusInterval
+			it.IntervalStartAt = &now
+			if err := itemRepo.Update(&it); err != nil {
+				t.Fatalf("update item: %v", err)
+			}
+		}
+	}
+	if _, err := svc.SetAssignmentOverride(classID, baqarah.ID.String(), teacher.ID, umar.ID.String(), nil, true, "sakit"); err != nil {
+		t.Fatalf("override: %v", err)
+	}
+
+	progress, err := svc.GetAssignmentProgress(classID, naba.ID.String(), teacher.ID)
+	if err != nil {
+		t.Fatalf("naba progress: %v", err)
+	}
+	if progress.Completed != 1 || progress.NotStarted != 1 || len(progress.Students) != 2 {
+		t.Fatalf("naba progress %+v", progress)
+	}
+
+	list, err := svc.GetClassAssignments(classID, teacher.ID)
+	if err != nil || len(list) != 2 {
+		t.Fatalf("assignments %+v, %v", list, err)
+	}
+	// earliest due first
+	if list[0].Assignment.ID != baqarah.ID || list[0].Overdue != 1 || list[0].Excused != 1 {
+		t.Fatalf("baqarah summary %+v", list[0])
+	}
+
+	// A late joiner only gets the open assignment
+	if _, err := svc.JoinClass(late.ID, "TAHFIDZ"); err != nil {
+		t.Fatalf("late join: %v", err)
+	}
+	mine, err := svc.GetMyAssignments(classID, late.ID)
+	if err != nil || len(mine) != 1 || mine[0].ID != naba.ID || mine[0].Progress.Status != services.AssignmentStatusNotStarted {
+		t.Fatalf("late joiner assignments %+v, %v", mine, err)
+	}
+
+	// Provisioning again only adds the links a student is missing
+	var nabaLinks int64
+	db.Model(&entities.ClassAssignmentItem{}).Where("assignment_id = ?", naba.ID).Count(&nabaLinks)
+	db.Where("assignment_id = ? AND user_id = ?", naba.ID, umar.ID).Delete(&entities.ClassAssignmentItem{})
+	groupName := "Halaqah Umar"
+	group, err := svc.CreateClassGroup(classID, teacher.ID, services.ClassGroupInput{Name: &groupName})
+	if err != nil {
+		t.Fatalf("create group: %v", err)
+	}
+	if _, err := svc.AddGroupMembers(classID, group.ID.String(), teacher.ID, []string{umar.ID.String(), late.ID.String()}); err != nil {
+		t.Fatalf("add group members: %v", err)
+	}
+	var relinked int64
+	db.Model(&entities.ClassAssignmentItem{}).Where("assignment_id = ?", naba.ID).Count(&relinked)
+	if relinked != nabaLinks {
+		t.Errorf("naba has %d links after reprovisioning, want %d", relinked, nabaLinks)
+	}
+
+	// Extending the due date turns an overdue student into in time
+	future := now.Add(72 * time.Hour)
+	if _, err := svc.SetAssignmentOverride(classID, baqarah.ID.String(), teacher.ID, ali.ID.String(), &future, false, ""); err != nil {
+		t.Fatalf("extend: %v", err)
+	}
+	mine, _ = svc.GetMyAssignments(classID, ali.ID)
+	for _, a := range mine {
+		if a.ID == baqarah.ID && (a.Progress.Status != services.AssignmentStatusNotStarted || a.Progress.TotalItems != 2) {
+			t.Errorf("extended assignment %+v", a.Progress)
+		}
+		if a.ID == naba.ID && a.Progress.Status != services.AssignmentStatusCompleted {
+			t.Errorf("naba status %s", a.Progress.Status)
+		}
+	}
+}
+
+func TestCreateAssignmentFailureRemovesItems(t *testing.T) {
+	env := newTestEnv(t)
+	db := env.db
+	svc := env.classSvc
+
+	teacher := &entities.User{Email: "ustadz@example.com", FullName: "Ustadz Ahmad"}
+	ali := &entities.User{Email: "ali@example.com", FullName: "Ali"}
+	for _, u := range []*entities.User{teacher, ali} {
+		if err := env.userRepo.Create(u); err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+	}
+	class := &entities.Class{GuruID: teacher.ID, Name: "Tahfidz A", ClassCode: "TAHFIDZ", IsActive: true}
+	if err := env.classRepo.Create(class); err != nil {
+		t.Fatalf("create class: %v", err)
+	}
+	classID := class.ID.String()
+	if _, err := svc.JoinClass(ali.ID, "TAHFIDZ"); err != nil {
+		t.Fatalf("join: %v", err)
+	}
+	due := time.Now().Add(48 * time.Hour)
+	if _, err := svc.CreateAssignment(classID, teacher.ID, services.AssignmentInput{Title: "An-Naba", ContentRef: "surah:78:1-20", DueAt: due}); err != nil {
+		t.Fatalf("create naba: %v", err)
+	}
+	counts := func() (items, juzs, juzItems int64) {
+		db.Model(&entities.Item{}).Where("owner_id = ?", ali.ID).Count(&items)
+		db.Model(&entities.Juz{}).Where("user_id = ?", ali.ID).Count(&juzs)
+		db.Model(&entities.JuzItem{}).Count(&juzItems)
+		return
+	}
+	items, juzs, juzItems := counts()
+
+	// Saving the links fails after Ali's Items for juz 1 and 2 were created
+	if err := db.Migrator().DropTable(&entities.ClassAssignmentItem{}); err != nil {
+		t.Fatalf("drop links: %v", err)
+	}
+	if _, err := svc.CreateAssignment(classID, teacher.ID, services.AssignmentInput{Title: "Al-Baqarah", ContentRef: "surah:2:140-145", DueAt: due}); err == nil {
+		t.Fatal("created an assignment without its links")
+	}
+	if i, j, ji := counts(); i != items || j != juzs || ji != juzItems {
+		t.Errorf("after the failed assignment: %d items, %d juzs, %d juz items; want %d, %d, %d", i, j, ji, items, juzs, juzItems)
+	}
+	var assignments int64
+	db.Model(&entities.ClassAssignment{}).Count(&assignments)
+	if assignments != 1 {
+		t.Errorf("%d assignments saved", assignments)
+	}
+}
@@ -0,0 +1,349 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"hifzhun-api/pkg/config"
+	"hifzhun-api/pkg/entities"
+
+	"github.com/google/uuid"
+)
+
+// GraduationBatchInput is one teacher decision applied to several pending
+// items. RetestAt is only allowed with the reject action.
+type GraduationBatchInput struct {
+	Action   string // approve | reject
+	ItemIDs  []string
+	Comment  string
+	RetestAt *time.Time
+}
+
+// GraduationBatchFailure is an item the batch decision could not apply to
+type GraduationBatchFailure struct {
+	ItemID string `json:"item_id"`
+	Error  string `json:"error"`
+}
+
+// GraduationBatchResult reports a batch decision per item. Items are decided
+// independently: one stale item does not block the others.
+type GraduationBatchResult struct {
+	Action    string                   `json:"action"`
+	Succeeded []string                 `json:"succeeded"`
+	Failed    []GraduationBatchFailure `json:"failed"`
+}
+
+// GraduationDecision is a recorded teacher decision with the item and student
+type GraduationDecision struct {
+	entities.ItemGraduation
+	ContentRef  string `json:"content_ref"`
+	StudentName string `json:"student_name"`
+}
+
+// decideGraduation applies a teacher decision to a pending item of the class
+// and records it in the graduation history.
+func (s *classService) decideGraduation(class *entities.Class, teacherID uuid.UUID, itemID, action, comment string, retestAt *time.Time, now time.Time) error {
+	comment = strings.TrimSpace(comment)
+	if retestAt != nil {
+		if action != entities.GraduationActionReject {
+			return errors.New("retest date is only allowed when rejecting")
+		}
+		day := retestAt.In(config.AppLocation)
+		day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, config.AppLocation)
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		if day.Before(today) {
+			return errors.New("retest date cannot be in the past")
+		}
+		retestAt = &day
+	}
+
+	itemUUID, err := uuid.Parse(itemID)
+	if err != nil {
+		return errors.New("invalid item ID")
+	}
+
+	item, err := s.itemRepo.GetByID(itemUUID)
+	if err != nil {
+		return errors.New("item not found")
+	}
+
+	// Verify item is pending graduate
+	if item.Status != entities.ItemStatusPendingGraduate {
+		return errors.New("item is not pending graduation")
+	}
+
+	// Verify item owner is a member of this class
+	classID := class.ID.String()
+	isMember, err := s.classMemberRepo.IsMember(classID, item.OwnerID.String())
+	if err != nil || !isMember {
+		return errors.New("item owner is not a member of this class")
+	}
+
+	classQuranItemIDs, err := s.classQuranItemIDSet(item.OwnerID, classID)
+	if err != nil || !classQuranItemIDs[item.ID] {
+		return errors.New("item is not part of this class")
+	}
+
+	switch action {
+	case entities.GraduationActionApprove:
+		item.Status = entities.ItemStatusGraduate
+		item.ApprovedBy = &teacherID
+		item.ApprovedAt = &now
+		item.RetestAt = nil
+	case entities.GraduationActionReject:
+		// Back to fsrs_active; with a retest date the item waits for it
+		item.Status = entities.ItemStatusFSRSActive
+		item.RetestAt = retestAt
+	default:
+		return errors.New("action must be approve or reject")
+	}
+
+	if err := s.itemRepo.Update(item); err != nil {
+		return err
+	}
+	s.notifyGraduation(class, item, action, comment, retestAt)
+
+	if s.graduationRepo == nil {
+		return nil
+	}
+	return s.graduationRepo.Create(context.Background(), &entities.ItemGraduation{
+		UserID:    item.OwnerID,
+		ItemID:    item.ID,
+		Action:    action,
+		Reason:    comment,
+		ClassID:   &class.ID,
+		DecidedBy: &teacherID,
+		RetestAt:  retestAt,
+		CreatedAt: now,
+	})
+}
+
+// notifyGraduation tells the student about a graduation decision
+func (s *classService) notifyGraduation(class *entities.Class, item *entities.Item, action, comment string, retestAt *time.Time) {
+	n := entities.Notification{
+		Type:    entities.NotificationTypeGraduationApproved,
+		Title:   fmt.Sprintf("Hafalan %s lulus", item.ContentRef),
+		Body:    comment,
+		ClassID: &class.ID,
+		RefID:   &item.ID,
+	}
+	if action == entities.GraduationActionReject {
+		n.Type = entities.NotificationTypeGraduationRejected
+		n.Title = fmt.Sprintf("Hafalan %s belum lulus", item.ContentRef)
+		if retestAt != nil {
+			n.Body = strings.TrimSpace(fmt.Sprintf("Ujian ulang %s. %s", retestAt.Format("2006-01-02"), comment))
+		}
+	}
+	notify(s.notifier, []uuid.UUID{item.OwnerID}, n)
+}
+
+// DecideGraduations approves or rejects several pending items at once
+func (s *classService) DecideGraduations(classID string, teacherID uuid.UUID, in GraduationBatchInput) (*GraduationBatchResult, error) {
+	class, err := s.classRepo.FindByID(classID)
+	if err != nil {
+		return nil, errors.New("class not found")
+	}
+
+	if !s.can(class, teacherID, ClassPermApproveGraduations) {
+		return nil, errors.New("you don't have permission to decide graduations in this class")
+	}
+
+	if class.Type != entities.ClassTypeQuran {
+		return nil, errors.New("graduation approval only available for quran-type classes")
+	}
+
+	if in.Action != entities.GraduationActionApprove && in.Action != entities.GraduationActionReject {
+		return nil, errors.New("action must be approve or reject")
+	}
+	if len(in.ItemIDs) == 0 {
+		return nil, errors.New("item_ids is required")
+	}
+	if in.RetestAt != nil && in.Action != entities.GraduationActionReject {
+		return nil, errors.New("retest date is only allowed when rejecting")
+	}
+
+	now := time.Now().In(config.AppLocation)
+	result := &GraduationBatchResult{
+		Action:    in.Action,
+		Succeeded: []string{},
+		Failed:    []GraduationBatchFailure{},
+	}
+	seen := make(map[string]bool, len(in.ItemIDs))
+	for _, itemID := range in.ItemIDs {
+		if seen[itemID] {
+			continue
+		}
+		seen[itemID] = true
+
+		if err := s.decideGraduation(class, teacherID, itemID, in.Action, in.Comment, in.RetestAt, now); err != nil {
+			result.Failed = append(result.Failed, GraduationBatchFailure{ItemID: itemID, Error: err.Error()})
+			continue
+		}
+		result.Succeeded = append(result.Succeeded, itemID)
+	}
+	return result, nil
+}
+
+// GetGraduationHistory lists the graduation decisions of a class, newest
+// first. The teacher and staff see every student (or one with studentID); a
+// student only sees their own decisions.
+func (s *classService) GetGraduationHistory(classID string, userID uuid.UUID, studentID string, page, perPage int) ([]GraduationDecision, int64, error) {
+	if s.graduationRepo == nil {
+		return nil, 0, errors.New("graduation history not available")
+	}
+	class, err := s.classRepo.FindByID(classID)
+	if err != nil {
+		return nil, 0, errors.New("class not found")
+	}
+	if !s.can(class, userID, ClassPermViewProgress) {
+		if studentID != userID.String() {
+			return nil, 0, errors.New("you don't have access to this graduation history")
+		}
+		if isMember, err := s.classMemberRepo.IsMember(classID, studentID); err != nil || !isMember {
+			return nil, 0, errors.New("you are not a member of this class")
+		}
+	}
+
+	grads, total, err := s.graduationRepo.ListByClass(context.Background(), classID, studentID, perPage, (page-1)*perPage)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	names := make(map[uuid.UUID]string)
+	decisions := make([]GraduationDecision, 0, len(grads))
+	for _, grad := range grads {
+		decision := GraduationDecision{ItemGraduation: grad}
+		if item, err := s.itemRepo.GetByID(grad.ItemID); err == nil {
+			decision.ContentRef = item.ContentRef
+		}
+		name, ok := names[grad.UserID]
+		if !ok {
+			if user, err := s.userRepo.FindByID(grad.UserID.String()); err == nil {
+				name = user.FullName
+			}
+			names[grad.UserID] = name
+		}
+		decision.StudentName = name
+		decisions = append(decisions, decision)
+	}
+	return decisions, total, nil
+}
+
+// GraduationPolicyInput changes a class graduation policy; nil fields keep
+// their current value.
+type GraduationPolicyInput struct {
+	MinReviews         *int
+	MinDays            *int
+	StabilityThreshold *float64
+	RequireApproval    *bool
+	GraduateReviewDays *int
+}
+
+// GetGraduationPolicy returns the graduation policy of a class (the default
+// one when the teacher has not set it). Teacher, staff and members can read it.
+func (s *classService) GetGraduationPolicy(classID string, userID uuid.UUID) (*GraduationPolicy, error) {
+	class, err := s.classRepo.FindByID(classID)
+	if err != nil {
+		return nil, errors.New("class not found")
+	}
+	if s.classRole(class, userID) == "" {
+		if isMember, err := s.classMemberRepo.IsMember(classID, userID.String()); err != nil || !isMember {
+			return nil, errors.New("you don't have access to this class")
+		}
+	}
+	return s.classGraduationPolicy(classID), nil
+}
+
+func (s *classService) classGraduationPolicy(classID string) *GraduationPolicy {
+	policy := DefaultGraduationPolicy()
+	if s.policyRepo != nil {
+		if found, err := s.policyRepo.FindByClassID(classID); err == nil {
+			policy = graduationPolicyFromEntity(found)
+		}
+	}
+	return &policy
+}
+
+// UpdateGraduationPolicy sets the graduation policy of a class. Items are
+// evaluated against it from their next review or daily task generation;
+// items already pending or graduate keep their status.
+func (s *classService) UpdateGraduationPolicy(classID string, teacherID uuid.UUID, in GraduationPolicyInput) (*GraduationPolicy, error) {
+	class, err := s.classRepo.FindByID(classID)
+	if err != nil {
+		return nil, errors.New("class not found")
+	}
+	if !s.can(class, teacherID, ClassPermApproveGraduations) {
+		return nil, errors.New("you don't have permission to change the graduation policy of this class")
+	}
+	if s.policyRepo == nil {
+		return nil, errors.New("graduation policy not available")
+	}
+
+	current := s.classGraduationPolicy(classID)
+	if in.MinReviews != nil {
+		current.MinReviews = *in.MinReviews
+	}
+	if in.MinDays != nil {
+		current.MinDays = *in.MinDays
+	}
+	if in.StabilityThreshold != nil {
+		current.StabilityThreshold = *in.StabilityThreshold
+	}
+	if in.RequireApproval != nil {
+		current.RequireApproval = *in.RequireApproval
+	}
+	if in.GraduateReviewDays != nil {
+		current.GraduateReviewDays = *in.GraduateReviewDays
+	}
+
+	if current.MinReviews < 1 || current.MinReviews > 100 {
+		return nil, errors.New("min_reviews must be between 1 and 100")
+	}
+	if current.MinDays < 1 || current.MinDays > 365 {
+		return nil, errors.New("min_days must be between 1 and 365")
+	}
+	if current.StabilityThreshold < 1 || current.StabilityThreshold > 365 {
+		return nil, errors.New("stability_threshold must be between 1 and 365")
+	}
+	if current.GraduateReviewDays < 1 || current.GraduateReviewDays > 365 {
+		return nil, errors.New("graduate_review_days must be between 1 and 365")
+	}
+
+	policy, err := s.policyRepo.FindByClassID(classID)
+	if err != nil {
+		policy = &entities.ClassGraduationPolicy{ClassID: class.ID}
+	}
+	policy.MinReviews = current.MinReviews
+	policy.MinDays = current.MinDays
+	policy.StabilityThreshold = current.StabilityThreshold
+	policy.RequireApproval = current.RequireApproval
+	policy.GraduateReviewDays = current.GraduateReviewDays
+	policy.UpdatedBy = teacherID
+	if err := s.policyRepo.Save(policy); err != nil {
+		return nil, err
+	}
+
+	updated := graduationPolicyFromEntity(policy)
+	return &updated, nil
+}
+
+// ResetGraduationPolicy removes the class policy so the default applies
+func (s *classService) ResetGraduationPolicy(classID string, teacherID uuid.UUID) (*GraduationPolicy, error) {
+	class, err := s.classRepo.FindByID(classID)
+	if err != nil {
+		return nil, errors.New("class not found")
+	}
+	if !s.can(class, teacherID, ClassPermApproveGraduations) {
+		return nil, errors.New("you don't have permission to change the graduation policy of this class")
+	}
+	if s.policyRepo != nil {
+		if err := s.policyRepo.DeleteByClassID(classID); err != nil {
+			return nil, err
+		}
+	}
+	policy := DefaultGraduationPolicy()
+	return &policy, nil
+}
@@ -0,0 +1,262 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"hifzhun-api/pkg/config"
+	"hifzhun-api/pkg/entities"
+	"hifzhun-api/pkg/services"
+)
+
+func TestClassGraduationDecisions(t *testing.T) {
+	env := newTestEnv(t)
+	userRepo := env.userRepo
+	classRepo := env.classRepo
+	itemRepo := env.itemRepo
+	assignmentRepo := env.assignmentRepo
+	svc := env.classSvc
+
+	teacher := &entities.User{Email: "ustadz@example.com", FullName: "Ustadz Ahmad"}
+	ali := &entities.User{Email: "ali@example.com", FullName: "Ali"}
+	umar := &entities.User{Email: "umar@example.com", FullName: "Umar"}
+	for _, u := range []*entities.User{teacher, ali, umar} {
+		if err := userRepo.Create(u); err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+	}
+	class := &entities.Class{GuruID: teacher.ID, Name: "Tahfidz A", ClassCode: "TAHFIDZ", IsActive: true}
+	if err := classRepo.Create(class); err != nil {
+		t.Fatalf("create class: %v", err)
+	}
+	classID := class.ID.String()
+	for _, u := range []*entities.User{ali, umar} {
+		if _, err := svc.JoinClass(u.ID, "TAHFIDZ"); err != nil {
+			t.Fatalf("join: %v", err)
+		}
+	}
+
+	now := time.Now().In(config.AppLocation)
+	assignment, err := svc.CreateAssignment(classID, teacher.ID, services.AssignmentInput{Title: "An-Naba", ContentRef: "surah:78:1-20", DueAt: now.Add(48 * time.Hour)})
+	if err != nil {
+		t.Fatalf("create assignment: %v", err)
+	}
+
+	// Both students finished the exam phase and wait for approval
+	pending := func(user *entities.User) *entities.Item {
+		links, err := assignmentRepo.FindItemsByUser(assignment.ID.String(), user.ID.String())
+		if err != nil || len(links) != 1 {
+			t.Fatalf("assignment items of %s: %v (%d)", user.FullName, err, len(links))
+		}
+		item, _ := itemRepo.GetByID(links[0].ItemID)
+		start, last, next := now.AddDate(0, 0, -40), now.AddDate(0, 0, -2), now.AddDate(0, 0, 30)
+		item.Status = entities.ItemStatusPendingGraduate
+		item.FSRSStartAt, item.LastReviewAt, item.NextReviewAt = &start, &last, &next
+		item.Stability, item.Difficulty, item.ReviewCount = 35, 5, 8
+		if err := itemRepo.Update(item); err != nil {
+			t.Fatalf("update item: %v", err)
+		}
+		return item
+	}
+	aliItem, umarItem := pending(ali), pending(umar)
+
+	retest := now.AddDate(0, 0, 3)
+	if _, err := svc.DecideGraduations(classID, ali.ID, services.GraduationBatchInput{Action: "approve", ItemIDs: []string{aliItem.ID.String()}}); err == nil {
+		t.Error("a student decided a graduation")
+	}
+	if _, err := svc.DecideGraduations(classID, teacher.ID, services.GraduationBatchInput{Action: "approve", ItemIDs: []string{aliItem.ID.String()}, RetestAt: &retest}); err == nil {
+		t.Error("accepted a retest date on approval")
+	}
+	yesterday := now.AddDate(0, 0, -1)
+	if err := svc.RejectGraduation(classID, teacher.ID, aliItem.ID.String(), "", &yesterday); err == nil {
+		t.Error("accepted a retest date in the past")
+	}
+
+	result, err := svc.DecideGraduations(classID, teacher.ID, services.GraduationBatchInput{
+		Action:   "reject",
+		ItemIDs:  []string{aliItem.ID.String(), umarItem.ID.String(), "not-a-uuid", aliItem.ID.String()},
+		Comment:  " Ayat 5-7 masih tertukar ",
+		RetestAt: &retest,
+	})
+	if err != nil {
+		t.Fatalf("batch reject: %v", err)
+	}
+	if len(result.Succeeded) != 2 || len(result.Failed) != 1 || result.Failed[0].ItemID != "not-a-uuid" {
+		t.Errorf("batch result = %+v", result)
+	}
+
+	// Rejected items are back in fsrs_active and wait for the retest
+	retestDay := time.Date(retest.Year(), retest.Month(), retest.Day(), 0, 0, 0, 0, config.AppLocation)
+	rejected, _ := itemRepo.GetByID(aliItem.ID)
+	if rejected.Status != entities.ItemStatusFSRSActive || rejected.RetestAt == nil || !rejected.RetestAt.Equal(retestDay) {
+		t.Errorf("rejected item: status %s, retest %v", rejected.Status, rejected.RetestAt)
+	}
+	if due, _ := itemRepo.FindRetestDue(ali.ID, now); len(due) != 0 {
+		t.Errorf("retest due before its date: %d", len(due))
+	}
+	if due, _ := itemRepo.FindRetestDue(ali.ID, retest); len(due) != 1 {
+		t.Errorf("retest not due on its date: %d", len(due))
+	}
+	if err := svc.ApproveGraduation(classID, teacher.ID, aliItem.ID.String(), ""); err == nil {
+		t.Error("approved an item that is no longer pending")
+	}
+
+	// The retest review is allowed on its date despite next_review_at, and
+	// the item can graduate again only after it
+	reviewSvc := env.reviewSvc
+	if _, err := reviewSvc.ReviewItem(ali.ID, aliItem.ID, 3, now); err == nil {
+		t.Error("reviewed before the retest date")
+	}
+	if _, err := reviewSvc.ReviewItem(ali.ID, aliItem.ID, 3, retestDay.Add(8*time.Hour)); err != nil {
+		t.Fatalf("retest review: %v", err)
+	}
+	retested, _ := itemRepo.GetByID(aliItem.ID)
+	if retested.RetestAt != nil || retested.Status == entities.ItemStatusFSRSActive {
+		t.Errorf("after retest: status %s, retest %v", retested.Status, retested.RetestAt)
+	}
+
+	history, total, err := svc.GetGraduationHistory(classID, ali.ID, ali.ID.String(), 1, 20)
+	if err != nil || total != 1 || len(history) != 1 {
+		t.Fatalf("ali's history: %v (%d)", err, total)
+	}
+	if h := history[0]; h.Action != entities.GraduationActionReject || h.Reason != "Ayat 5-7 masih tertukar" || h.RetestAt == nil || h.ContentRef != "surah:78:1-20" || h.StudentName != "Ali" {
+		t.Errorf("history entry = %+v", h)
+	}
+	if _, _, err := svc.GetGraduationHistory(classID, umar.ID, ali.ID.String(), 1, 20); err == nil {
+		t.Error("a classmate read another student's graduation history")
+	}
+	if _, total, err := svc.GetGraduationHistory(classID, teacher.ID, "", 1, 20); err != nil || total != 2 {
+		t.Errorf("class history: %v (%d)", err, total)
+	}
+}
+
+func TestClassGraduationPolicy(t *testing.T) {
+	env := newTestEnv(t)
+	db := env.db
+	userRepo := env.userRepo
+	classRepo := env.classRepo
+	itemRepo := env.itemRepo
+	assignmentRepo := env.assignmentRepo
+	svc := env.classSvc
+	reviewSvc := env.reviewSvc
+	dailySvc := env.dailyTaskSvc
+
+	teacher := &entities.User{Email: "ustadz@example.com", FullName: "Ustadz Ahmad"}
+	ali := &entities.User{Email: "ali@example.com", FullName: "Ali"}
+	stranger := &entities.User{Email: "zaid@example.com", FullName: "Zaid"}
+	for _, u := range []*entities.User{teacher, ali, stranger} {
+		if err := userRepo.Create(u); err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+	}
+	class := &entities.Class{GuruID: teacher.ID, Name: "Tahfidz A", ClassCode: "TAHFIDZ", IsActive: true}
+	if err := classRepo.Create(class); err != nil {
+		t.Fatalf("create class: %v", err)
+	}
+	classID := class.ID.String()
+	if _, err := svc.JoinClass(ali.ID, "TAHFIDZ"); err != nil {
+		t.Fatalf("join: %v", err)
+	}
+
+	policy, err := svc.GetGraduationPolicy(classID, ali.ID)
+	if err != nil || !policy.IsDefault || policy.MinReviews != services.MinimumReviewsToGraduate || !policy.RequireApproval {
+		t.Fatalf("default policy = %+v (%v)", policy, err)
+	}
+	if _, err := svc.GetGraduationPolicy(classID, stranger.ID); err == nil {
+		t.Error("a non-member read the graduation policy")
+	}
+
+	three, seven, ten, zero, noApproval := 3, 7, 10, 0, false
+	if _, err := svc.UpdateGraduationPolicy(classID, ali.ID, services.GraduationPolicyInput{MinReviews: &three}); err == nil {
+		t.Error("a student changed the graduation policy")
+	}
+	if _, err := svc.UpdateGraduationPolicy(classID, teacher.ID, services.GraduationPolicyInput{MinReviews: &zero}); err == nil {
+		t.Error("accepted min_reviews 0")
+	}
+	policy, err = svc.UpdateGraduationPolicy(classID, teacher.ID, services.GraduationPolicyInput{
+		MinReviews: &three, MinDays: &seven, RequireApproval: &noApproval, GraduateReviewDays: &ten,
+	})
+	if err != nil {
+		t.Fatalf("update policy: %v", err)
+	}
+	if policy.IsDefault || policy.MinReviews != 3 || policy.MinDays != 7 || policy.RequireApproval || policy.GraduateReviewDays != 10 ||
+		policy.StabilityThreshold != entities.GraduateStabilityThreshold {
+		t.Errorf("updated policy = %+v", policy)
+	}
+
+	now := time.Now().In(config.AppLocation)
+	assignment, err := svc.CreateAssignment(classID, teacher.ID, services.AssignmentInput{Title: "Juz Amma", ContentRef: "surah:78:1-20", DueAt: now.Add(48 * time.Hour)})
+	if err != nil {
+		t.Fatalf("create assignment: %v", err)
+	}
+	if _, err := svc.CreateAssignment(classID, teacher.ID, services.AssignmentInput{Title: "An-Nazi'at", ContentRef: "surah:79:1-10", DueAt: now.Add(48 * time.Hour)}); err != nil {
+		t.Fatalf("create assignment: %v", err)
+	}
+
+	// Three reviews in ten days meet the class policy but not the default one
+	exam := func(item *entities.Item) {
+		start, last := now.AddDate(0, 0, -10), now.AddDate(0, 0, -1)
+		item.Status = entities.ItemStatusFSRSActive
+		item.FSRSStartAt, item.LastReviewAt, item.NextReviewAt = &start, &last, &last
+		item.Stability, item.Difficulty, item.ReviewCount = 5, 5, 2
+		if err := itemRepo.Update(item); err != nil {
+			t.Fatalf("update item: %v", err)
+		}
+	}
+	links, _ := assignmentRepo.FindItemsByUser(assignment.ID.String(), ali.ID.String())
+	if len(links) != 1 {
+		t.Fatalf("assignment items: %d", len(links))
+	}
+	classItem, _ := itemRepo.GetByID(links[0].ItemID)
+	exam(classItem)
+	personal := &entities.Item{OwnerID: ali.ID, SourceType: "quran", ContentRef: "surah:1:1-7"}
+	if err := itemRepo.Create(personal); err != nil {
+		t.Fatalf("create item: %v", err)
+	}
+	exam(personal)
+
+	res, err := reviewSvc.ReviewItem(ali.ID, classItem.ID, 3, now)
+	if err != nil {
+		t.Fatalf("review class item: %v", err)
+	}
+	wantNext := time.Date(now.Year(), now.Month(), now.Day()+10, 0, 0, 0, 0, config.AppLocation)
+	if !res.Graduated || res.PendingGraduate || res.Item.Status != entities.ItemStatusGraduate || !res.Item.NextReviewAt.Equal(wantNext) {
+		t.Errorf("class item after review: status %s, next %v", res.Item.Status, res.Item.NextReviewAt)
+	}
+	if res, err := reviewSvc.ReviewItem(ali.ID, personal.ID, 3, now); err != nil || res.Item.Status != entities.ItemStatusFSRSActive {
+		t.Errorf("personal item graduated under the class policy: %v", err)
+	}
+
+	// Daily task generation evaluates against the same policy; with approval
+	// required the item waits for the teacher
+	yes := true
+	if _, err := svc.UpdateGraduationPolicy(classID, teacher.ID, services.GraduationPolicyInput{RequireApproval: &yes}); err != nil {
+		t.Fatalf("update policy: %v", err)
+	}
+	var nazi entities.Item
+	if err := db.Where("owner_id = ? AND content_ref = ?", ali.ID, "surah:79:1-10").First(&nazi).Error; err != nil {
+		t.Fatalf("second class item: %v", err)
+	}
+	exam(&nazi)
+	nazi.ReviewCount = 3
+	if err := itemRepo.Update(&nazi); err != nil {
+		t.Fatalf("update item: %v", err)
+	}
+	// The monthly graduate step after graduation uses juzs.index, which
+	// SQLite cannot parse, so only the graduation outcome is checked.
+	_, _ = dailySvc.GenerateToday(context.Background(), ali.ID, now, 0)
+	if got, _ := itemRepo.GetByID(nazi.ID); got.Status != entities.ItemStatusPendingGraduate {
+		t.Errorf("class item after daily generation: %s", got.Status)
+	}
+	if got, _ := itemRepo.GetByID(personal.ID); got.Status != entities.ItemStatusFSRSActive {
+		t.Errorf("personal item after daily generation: %s", got.Status)
+	}
+
+	if policy, err := svc.ResetGraduationPolicy(classID, teacher.ID); err != nil || !policy.IsDefault {
+		t.Errorf("reset policy: %v", err)
+	}
+	if policy, _ := svc.GetGraduationPolicy(classID, teacher.ID); !policy.IsDefault {
+		t.Error("policy still set after reset")
+	}
+}
@@ -0,0 +1,368 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"hifzhun-api/pkg/config"
+	"hifzhun-api/pkg/entities"
+
+	"github.com/google/uuid"
+)
+
+// ClassGroupInput creates or updates a group. Nil fields are left as they
+// are; an empty StaffID unassigns the staff member.
+type ClassGroupInput struct {
+	Name        *string
+	Description *string
+	StaffID     *string
+}
+
+// ClassGroupInfo is a group (halaqah) of a class with its staff member and
+// size. Members is only filled for the single-group view.
+type ClassGroupInfo struct {
+	ID          uuid.UUID    `json:"id"`
+	ClassID     uuid.UUID    `json:"class_id"`
+	Name        string       `json:"name"`
+	Description string       `json:"description,omitempty"`
+	StaffID     *uuid.UUID   `json:"staff_id,omitempty"`
+	StaffName   string       `json:"staff_name,omitempty"`
+	MemberCount int          `json:"member_count"`
+	Members     []MemberInfo `json:"members,omitempty"`
+	CreatedAt   time.Time    `json:"created_at"`
+}
+
+func classGroupInfo(g *entities.ClassGroup, memberCount int) ClassGroupInfo {
+	info := ClassGroupInfo{
+		ID:          g.ID,
+		ClassID:     g.ClassID,
+		Name:        g.Name,
+		Description: g.Description,
+		StaffID:     g.StaffID,
+		MemberCount: memberCount,
+		CreatedAt:   g.CreatedAt,
+	}
+	if g.Staff != nil {
+		info.StaffName = g.Staff.FullName
+	}
+	return info
+}
+
+// classGroup loads a group of a class
+func (s *classService) classGroup(class *entities.Class, groupID string) (*entities.ClassGroup, error) {
+	if s.groupRepo == nil {
+		return nil, errors.New("class groups not available")
+	}
+	group, err := s.groupRepo.FindByID(groupID)
+	if err != nil || group.ClassID != class.ID {
+		return nil, errors.New("group not found")
+	}
+	return group, nil
+}
+
+// classMembers lists the members of a class, or only those of one of its
+// groups when groupID is set.
+func (s *classService) classMembers(class *entities.Class, groupID string) ([]entities.ClassMember, error) {
+	members, err := s.classMemberRepo.FindByClassID(class.ID.String())
+	if err != nil || groupID == "" {
+		return members, err
+	}
+	if _, err := s.classGroup(class, groupID); err != nil {
+		return nil, err
+	}
+	userIDs, err := s.groupRepo.FindMemberUserIDs(groupID)
+	if err != nil {
+		return nil, err
+	}
+	inGroup := make(map[uuid.UUID]bool, len(userIDs))
+	for _, id := range userIDs {
+		inGroup[id] = true
+	}
+	filtered := make([]entities.ClassMember, 0, len(userIDs))
+	for _, member := range members {
+		if inGroup[member.UserID] {
+			filtered = append(filtered, member)
+		}
+	}
+	return filtered, nil
+}
+
+// studentGroupID returns the group of a student in a class, or nil
+func (s *classService) studentGroupID(classID string, userID uuid.UUID) *uuid.UUID {
+	if s.groupRepo == nil {
+		return nil
+	}
+	member, err := s.groupRepo.FindMember(classID, userID.String())
+	if err != nil {
+		return nil
+	}
+	return &member.GroupID
+}
+
+// leaveGroups drops the group placement of a student leaving the class
+func (s *classService) leaveGroups(classID, userID string) error {
+	if s.groupRepo == nil {
+		return nil
+	}
+	return s.groupRepo.DeleteMember(classID, userID)
+}
+
+// unassignGroupStaff takes a staff member leaving the class off its groups
+func (s *classService) unassignGroupStaff(classID, userID string) error {
+	if s.groupRepo == nil {
+		return nil
+	}
+	return s.groupRepo.ClearStaff(classID, userID)
+}
+
+// applyGroupInput validates and copies the input onto the group
+func (s *classService) applyGroupInput(class *entities.Class, group *entities.ClassGroup, in ClassGroupInput) error {
+	if in.Name != nil {
+		name := strings.TrimSpace(*in.Name)
+		if name == "" {
+			return errors.New("group name is required")
+		}
+		if len(name) > 100 {
+			return errors.New("group name must be at most 100 characters")
+		}
+		group.Name = name
+	}
+	if in.Description != nil {
+		group.Description = strings.TrimSpace(*in.Description)
+	}
+	if in.StaffID != nil {
+		if *in.StaffID == "" {
+			group.StaffID = nil
+		} else {
+			staffID, err := uuid.Parse(*in.StaffID)
+			if err != nil {
+				return errors.New("invalid staff ID")
+			}
+			if s.classRole(class, staffID) == "" {
+				return errors.New("group staff must be the class owner or an active staff member")
+			}
+			group.StaffID = &staffID
+		}
+	}
+	return nil
+}
+
+// CreateClassGroup adds a group (halaqah) to a class
+func (s *classService) CreateClassGroup(classID string, teacherID uuid.UUID, in ClassGroupInput) (*ClassGroupInfo, error) {
+	if s.groupRepo == nil {
+		return nil, errors.New("class groups not available")
+	}
+	class, err := s.memberClass(classID, teacherID)
+	if err != nil {
+		return nil, err
+	}
+	if in.Name == nil {
+		return nil, errors.New("group name is required")
+	}
+	group := &entities.ClassGroup{ClassID: class.ID}
+	if err := s.applyGroupInput(class, group, in); err != nil {
+		return nil, err
+	}
+	if s.groupNameTaken(classID, group.Name, uuid.Nil) {
+		return nil, errors.New("a group with this name already exists in this class")
+	}
+	if err := s.groupRepo.Create(group); err != nil {
+		return nil, err
+	}
+	return s.GetClassGroup(classID, group.ID.String(), teacherID)
+}
+
+func (s *classService) groupNameTaken(classID, name string, except uuid.UUID) bool {
+	groups, err := s.groupRepo.FindByClassID(classID)
+	if err != nil {
+		return false
+	}
+	for _, g := range groups {
+		if g.ID != except && strings.EqualFold(g.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// UpdateClassGroup renames a group or changes its description or staff member
+func (s *classService) UpdateClassGroup(classID, groupID string, teacherID uuid.UUID, in ClassGroupInput) (*ClassGroupInfo, error) {
+	class, err := s.memberClass(classID, teacherID)
+	if err != nil {
+		return nil, err
+	}
+	group, err := s.classGroup(class, groupID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyGroupInput(class, group, in); err != nil {
+		return nil, err
+	}
+	if s.groupNameTaken(classID, group.Name, group.ID) {
+		return nil, errors.New("a group with this name already exists in this class")
+	}
+	group.Staff = nil
+	if err := s.groupRepo.Update(group); err != nil {
+		return nil, err
+	}
+	return s.GetClassGroup(classID, groupID, teacherID)
+}
+
+// DeleteClassGroup removes a group. Its students stay in the class, and
+// assignments given to the group stay with the students who have them.
+func (s *classService) DeleteClassGroup(classID, groupID string, teacherID uuid.UUID) error {
+	class, err := s.memberClass(classID, teacherID)
+	if err != nil {
+		return err
+	}
+	if _, err := s.classGroup(class, groupID); err != nil {
+		return err
+	}
+	// Announcements for the group have no one left to reach
+	if s.announcementRepo != nil {
+		if err := s.announcementRepo.DeleteByGroupID(groupID); err != nil {
+			return err
+		}
+	}
+	return s.groupRepo.Delete(groupID)
+}
+
+// GetClassGroups lists the groups of a class with their size
+func (s *classService) GetClassGroups(classID string, userID uuid.UUID) ([]ClassGroupInfo, error) {
+	if s.groupRepo == nil {
+		return nil, errors.New("class groups not available")
+	}
+	class, err := s.classRepo.FindByID(classID)
+	if err != nil {
+		return nil, errors.New("class not found")
+	}
+	if !s.can(class, userID, ClassPermViewProgress) {
+		return nil, errors.New("you don't have permission to view this class")
+	}
+	groups, err := s.groupRepo.FindByClassID(classID)
+	if err != nil {
+		return nil, err
+	}
+	members, err := s.classMembers(class, "")
+	if err != nil {
+		return nil, err
+	}
+	isMember := make(map[uuid.UUID]bool, len(members))
+	for _, m := range members {
+		isMember[m.UserID] = true
+	}
+	placements, err := s.groupRepo.FindMembersByClassID(classID)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[uuid.UUID]int, len(groups))
+	for _, p := range placements {
+		if isMember[p.UserID] {
+			counts[p.GroupID]++
+		}
+	}
+
+	result := make([]ClassGroupInfo, 0, len(groups))
+	for i := range groups {
+		result = append(result, classGroupInfo(&groups[i], counts[groups[i].ID]))
+	}
+	return result, nil
+}
+
+// GetClassGroup returns a group with its students
+func (s *classService) GetClassGroup(classID, groupID string, userID uuid.UUID) (*ClassGroupInfo, error) {
+	class, err := s.classRepo.FindByID(classID)
+	if err != nil {
+		return nil, errors.New("class not found")
+	}
+	if !s.can(class, userID, ClassPermViewProgress) {
+		return nil, errors.New("you don't have permission to view this class")
+	}
+	group, err := s.classGroup(class, groupID)
+	if err != nil {
+		return nil, err
+	}
+	members, err := s.classMembers(class, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	info := classGroupInfo(group, len(members))
+	info.Members = make([]MemberInfo, 0, len(members))
+	for _, member := range members {
+		user, err := s.userRepo.FindByID(member.UserID.String())
+		if err != nil {
+			continue
+		}
+		info.Members = append(info.Members, MemberInfo{
+			UserID:   member.UserID,
+			Email:    user.Email,
+			FullName: user.FullName,
+			JoinedAt: member.JoinedAt,
+		})
+	}
+	return &info, nil
+}
+
+// AddGroupMembers places class members in a group, moving them out of their
+// previous group, and gives them the open assignments of the group.
+func (s *classService) AddGroupMembers(classID, groupID string, teacherID uuid.UUID, studentIDs []string) (*ClassGroupInfo, error) {
+	class, err := s.memberClass(classID, teacherID)
+	if err != nil {
+		return nil, err
+	}
+	group, err := s.classGroup(class, groupID)
+	if err != nil {
+		return nil, err
+	}
+	if len(studentIDs) == 0 {
+		return nil, errors.New("select at least one student")
+	}
+
+	userIDs := make([]uuid.UUID, 0, len(studentIDs))
+	for _, id := range studentIDs {
+		userID, err := uuid.Parse(id)
+		if err != nil {
+			return nil, errors.New("invalid student ID")
+		}
+		if isMember, err := s.classMemberRepo.IsMember(classID, id); err != nil || !isMember {
+			return nil, errors.New("student is not a member of this class")
+		}
+		userIDs = append(userIDs, userID)
+	}
+	for _, userID := range userIDs {
+		if current := s.studentGroupID(classID, userID); current != nil && *current == group.ID {
+			continue
+		}
+		if err := s.groupRepo.SetMember(&entities.ClassGroupMember{
+			ClassID:   class.ID,
+			UserID:    userID,
+			GroupID:   group.ID,
+			CreatedAt: time.Now().In(config.AppLocation),
+		}); err != nil {
+			return nil, err
+		}
+		if err := s.provisionOpenAssignments(class, userID); err != nil {
+			return nil, err
+		}
+	}
+	return s.GetClassGroup(classID, groupID, teacherID)
+}
+
+// RemoveGroupMember takes a student out of a group; they stay in the class
+func (s *classService) RemoveGroupMember(classID, groupID string, teacherID uuid.UUID, studentID string) error {
+	class, err := s.memberClass(classID, teacherID)
+	if err != nil {
+		return err
+	}
+	group, err := s.classGroup(class, groupID)
+	if err != nil {
+		return err
+	}
+	member, err := s.groupRepo.FindMember(classID, studentID)
+	if err != nil || member.GroupID != group.ID {
+		return errors.New("student is not in this group")
+	}
+	return s.groupRepo.DeleteMember(classID, studentID)
+}
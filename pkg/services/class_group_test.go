@@ -0,0 +1,153 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"hifzhun-api/pkg/config"
+	"hifzhun-api/pkg/entities"
+	"hifzhun-api/pkg/services"
+)
+
+func TestClassGroups(t *testing.T) {
+	env := newTestEnv(t)
+	userRepo := env.userRepo
+	classRepo := env.classRepo
+	itemRepo := env.itemRepo
+	svc := env.classSvc
+
+	teacher := &entities.User{Email: "ustadz@example.com", FullName: "Ustadz Ahmad", Role: "teacher"}
+	musyrif := &entities.User{Email: "musyrif@example.com", FullName: "Musyrif Bilal", Role: "teacher"}
+	ali := &entities.User{Email: "ali@example.com", FullName: "Ali", Role: "student"}
+	umar := &entities.User{Email: "umar@example.com", FullName: "Umar", Role: "student"}
+	zaid := &entities.User{Email: "zaid@example.com", FullName: "Zaid", Role: "student"}
+	for _, u := range []*entities.User{teacher, musyrif, ali, umar, zaid} {
+		if err := userRepo.Create(u); err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+	}
+	class := &entities.Class{GuruID: teacher.ID, Name: "Tahfidz Putra", ClassCode: "PUTRA", Type: entities.ClassTypeQuran, IsActive: true}
+	if err := classRepo.Create(class); err != nil {
+		t.Fatalf("create class: %v", err)
+	}
+	classID := class.ID.String()
+	for _, u := range []*entities.User{ali, umar, zaid} {
+		if _, err := svc.JoinClass(u.ID, "PUTRA"); err != nil {
+			t.Fatalf("join: %v", err)
+		}
+	}
+	if _, err := svc.InviteStaff(classID, teacher.ID, musyrif.Email, entities.ClassStaffRoleAssistant); err != nil {
+		t.Fatalf("invite: %v", err)
+	}
+	invitations, _ := svc.GetMyStaffInvitations(musyrif.ID)
+	if len(invitations) != 1 {
+		t.Fatalf("invitations = %+v", invitations)
+	}
+	if _, err := svc.RespondToStaffInvitation(invitations[0].ID.String(), musyrif.ID, true); err != nil {
+		t.Fatalf("accept: %v", err)
+	}
+
+	name := func(s string) *string { return &s }
+	musyrifID := musyrif.ID.String()
+	aliID := ali.ID.String()
+	if _, err := svc.CreateClassGroup(classID, musyrif.ID, services.ClassGroupInput{Name: name("Abu Bakar")}); err == nil {
+		t.Error("an assistant created a group")
+	}
+	if _, err := svc.CreateClassGroup(classID, teacher.ID, services.ClassGroupInput{Name: name("Abu Bakar"), StaffID: &aliID}); err == nil {
+		t.Error("a student was made group staff")
+	}
+	abuBakar, err := svc.CreateClassGroup(classID, teacher.ID, services.ClassGroupInput{Name: name(" Abu Bakar "), StaffID: &musyrifID})
+	if err != nil || abuBakar.Name != "Abu Bakar" || abuBakar.StaffName != "Musyrif Bilal" {
+		t.Fatalf("create group: %+v (%v)", abuBakar, err)
+	}
+	if _, err := svc.CreateClassGroup(classID, teacher.ID, services.ClassGroupInput{Name: name("abu bakar")}); err == nil {
+		t.Error("created a group with a taken name")
+	}
+	umarGroup, err := svc.CreateClassGroup(classID, teacher.ID, services.ClassGroupInput{Name: name("Umar bin Khattab")})
+	if err != nil {
+		t.Fatalf("create group: %v", err)
+	}
+	abuBakarID, umarGroupID := abuBakar.ID.String(), umarGroup.ID.String()
+
+	if _, err := svc.AddGroupMembers(classID, abuBakarID, teacher.ID, []string{musyrifID}); err == nil {
+		t.Error("placed a non-member in a group")
+	}
+	if _, err := svc.AddGroupMembers(classID, abuBakarID, teacher.ID, []string{aliID, umar.ID.String()}); err != nil {
+		t.Fatalf("add members: %v", err)
+	}
+	if _, err := svc.AddGroupMembers(classID, umarGroupID, teacher.ID, []string{zaid.ID.String()}); err != nil {
+		t.Fatalf("add members: %v", err)
+	}
+
+	// Group assignments only reach the group's students
+	due := time.Now().In(config.AppLocation).Add(48 * time.Hour)
+	naba, err := svc.CreateAssignment(classID, teacher.ID, services.AssignmentInput{GroupID: &abuBakar.ID, Title: "An-Naba", ContentRef: "surah:78:1-20", DueAt: due})
+	if err != nil || naba.GroupID == nil {
+		t.Fatalf("group assignment: %v", err)
+	}
+	if _, err := svc.CreateAssignment(classID, teacher.ID, services.AssignmentInput{GroupID: &umarGroup.ID, Title: "An-Nazi'at", ContentRef: "surah:79:1-10", DueAt: due}); err != nil {
+		t.Fatalf("group assignment: %v", err)
+	}
+	countItems := func(u *entities.User) int {
+		items, _ := itemRepo.FindByOwner(u.ID.String())
+		return len(items)
+	}
+	if countItems(ali) != 1 || countItems(umar) != 1 || countItems(zaid) != 1 {
+		t.Fatalf("items ali=%d umar=%d zaid=%d", countItems(ali), countItems(umar), countItems(zaid))
+	}
+
+	// Progress and pending graduations filter by group; assistants may view
+	progress, err := svc.GetStudentProgress(classID, musyrif.ID, abuBakarID)
+	if err != nil || len(progress) != 2 {
+		t.Fatalf("group progress = %+v (%v)", progress, err)
+	}
+	if all, _ := svc.GetStudentProgress(classID, teacher.ID, ""); len(all) != 3 {
+		t.Errorf("class progress has %d students", len(all))
+	}
+	if _, err := svc.GetStudentProgress(classID, teacher.ID, class.ID.String()); err == nil {
+		t.Error("filtered by an unknown group")
+	}
+	if _, err := svc.GetPendingGraduations(classID, teacher.ID, umarGroupID); err != nil {
+		t.Errorf("group pending graduations: %v", err)
+	}
+
+	// Moving a student hands them the open assignments of the new group
+	if _, err := svc.AddGroupMembers(classID, umarGroupID, teacher.ID, []string{umar.ID.String()}); err != nil {
+		t.Fatalf("move: %v", err)
+	}
+	if countItems(umar) != 2 {
+		t.Errorf("umar has %d items after moving", countItems(umar))
+	}
+	groups, err := svc.GetClassGroups(classID, musyrif.ID)
+	if err != nil || len(groups) != 2 || groups[0].MemberCount != 1 || groups[1].MemberCount != 2 {
+		t.Fatalf("groups = %+v (%v)", groups, err)
+	}
+
+	// Leaving the class drops the placement; removing staff unassigns them
+	if err := svc.RemoveMember(classID, teacher.ID, zaid.ID.String()); err != nil {
+		t.Fatalf("remove member: %v", err)
+	}
+	if err := svc.RemoveStaff(classID, teacher.ID, musyrifID); err != nil {
+		t.Fatalf("remove staff: %v", err)
+	}
+	group, err := svc.GetClassGroup(classID, umarGroupID, teacher.ID)
+	if err != nil || group.MemberCount != 1 || group.Members[0].UserID != umar.ID {
+		t.Fatalf("group = %+v (%v)", group, err)
+	}
+	if group, _ := svc.GetClassGroup(classID, abuBakarID, teacher.ID); group.StaffID != nil {
+		t.Error("removed staff still leads the group")
+	}
+
+	if err := svc.RemoveGroupMember(classID, abuBakarID, teacher.ID, umar.ID.String()); err == nil {
+		t.Error("removed a student from a group they are not in")
+	}
+	if err := svc.DeleteClassGroup(classID, abuBakarID, teacher.ID); err != nil {
+		t.Fatalf("delete group: %v", err)
+	}
+	if _, err := svc.GetStudentProgress(classID, teacher.ID, abuBakarID); err == nil {
+		t.Error("filtered by a deleted group")
+	}
+	if countItems(ali) != 1 {
+		t.Error("deleting the group removed the student's items")
+	}
+}
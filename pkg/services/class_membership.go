@@ -0,0 +1,539 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"hifzhun-api/pkg/config"
+	"hifzhun-api/pkg/entities"
+
+	"github.com/google/uuid"
+)
+
+// MaxClassMembers caps the max_members setting of a class
+const MaxClassMembers = 1000
+
+// JoinSettingsInput changes how students join a class; nil fields keep their
+// current value. ClearCodeExpiry removes the code expiry date.
+type JoinSettingsInput struct {
+	JoinPolicy      *string
+	MaxMembers      *int
+	CodeExpiresAt   *time.Time
+	ClearCodeExpiry bool
+}
+
+// JoinRequestInfo is a pending join request with the student
+type JoinRequestInfo struct {
+	ID          uuid.UUID `json:"id"`
+	UserID      uuid.UUID `json:"user_id"`
+	Email       string    `json:"email"`
+	FullName    string    `json:"full_name"`
+	RequestedAt time.Time `json:"requested_at"`
+}
+
+// ClassInvitationInfo is an open student invitation. The class teacher sees
+// the invited student; the student sees who invited them.
+type ClassInvitationInfo struct {
+	ID          uuid.UUID `json:"id"`
+	ClassID     uuid.UUID `json:"class_id"`
+	ClassName   string    `json:"class_name"`
+	UserID      uuid.UUID `json:"user_id"`
+	Email       string    `json:"email,omitempty"`
+	FullName    string    `json:"full_name,omitempty"`
+	InvitedBy   uuid.UUID `json:"invited_by"`
+	InviterName string    `json:"inviter_name,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ClassBanInfo is a banned user of a class
+type ClassBanInfo struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Email    string    `json:"email"`
+	FullName string    `json:"full_name"`
+	Reason   string    `json:"reason,omitempty"`
+	BannedBy uuid.UUID `json:"banned_by"`
+	BannedAt time.Time `json:"banned_at"`
+}
+
+// memberClass loads a class and checks that teacherID can manage its members
+func (s *classService) memberClass(classID string, teacherID uuid.UUID) (*entities.Class, error) {
+	class, err := s.classRepo.FindByID(classID)
+	if err != nil {
+		return nil, errors.New("class not found")
+	}
+	if !s.can(class, teacherID, ClassPermManageMembers) {
+		return nil, errors.New("you don't have permission to manage members of this class")
+	}
+	return class, nil
+}
+
+func (s *classService) isBanned(classID, userID string) bool {
+	if s.banRepo == nil {
+		return false
+	}
+	banned, err := s.banRepo.IsBanned(classID, userID)
+	return err == nil && banned
+}
+
+// addMember adds a student to the class within its capacity and provisions
+// the open assignments for them. If provisioning fails the student is taken
+// out again, so a join either fully happens or can be retried.
+func (s *classService) addMember(class *entities.Class, userID uuid.UUID) error {
+	member := &entities.ClassMember{
+		ClassID:  class.ID,
+		UserID:   userID,
+		JoinedAt: time.Now().In(config.AppLocation),
+	}
+	if err := s.classMemberRepo.CreateWithinCapacity(member, class.MaxMembers); err != nil {
+		return err
+	}
+
+	// Open assignments of the class become the new student's items too
+	if err := s.provisionOpenAssignments(class, userID); err != nil {
+		_ = s.classMemberRepo.Delete(member.ID.String())
+		return err
+	}
+	return nil
+}
+
+// requestToJoin queues a join request. A rejected request can be sent again.
+func (s *classService) requestToJoin(class *entities.Class, userID uuid.UUID) error {
+	if s.joinRequestRepo == nil {
+		return errors.New("join requests not available")
+	}
+	existing, err := s.joinRequestRepo.FindByClassAndUser(class.ID.String(), userID.String())
+	if err != nil {
+		return s.joinRequestRepo.Create(&entities.ClassJoinRequest{
+			ClassID: class.ID,
+			UserID:  userID,
+			Status:  entities.ClassJoinRequestPending,
+		})
+	}
+	if existing.Status == entities.ClassJoinRequestPending {
+		return errors.New("your join request is already pending")
+	}
+	existing.Status = entities.ClassJoinRequestPending
+	existing.DecidedBy = nil
+	existing.DecidedAt = nil
+	existing.CreatedAt = time.Now().In(config.AppLocation)
+	return s.joinRequestRepo.Update(existing)
+}
+
+// UpdateJoinSettings changes the join policy, capacity and code expiry of a class
+func (s *classService) UpdateJoinSettings(classID string, teacherID uuid.UUID, in JoinSettingsInput) (*entities.Class, error) {
+	class, err := s.memberClass(classID, teacherID)
+	if err != nil {
+		return nil, err
+	}
+
+	if in.JoinPolicy != nil {
+		policy := strings.ToLower(strings.TrimSpace(*in.JoinPolicy))
+		switch policy {
+		case entities.ClassJoinOpen, entities.ClassJoinApproval, entities.ClassJoinInviteOnly:
+			class.JoinPolicy = policy
+		default:
+			return nil, errors.New("join_policy must be open, approval or invite_only")
+		}
+	}
+	if in.MaxMembers != nil {
+		if *in.MaxMembers < 0 || *in.MaxMembers > MaxClassMembers {
+			return nil, errors.New("max_members must be between 0 and 1000")
+		}
+		if *in.MaxMembers > 0 {
+			count, err := s.classMemberRepo.CountByClassID(classID)
+			if err != nil {
+				return nil, err
+			}
+			if int64(*in.MaxMembers) < count {
+				return nil, errors.New("max_members cannot be lower than the current member count")
+			}
+		}
+		class.MaxMembers = *in.MaxMembers
+	}
+	if in.ClearCodeExpiry {
+		class.CodeExpiresAt = nil
+	} else if in.CodeExpiresAt != nil {
+		if !in.CodeExpiresAt.After(time.Now().In(config.AppLocation)) {
+			return nil, errors.New("code expiry must be in the future")
+		}
+		class.CodeExpiresAt = in.CodeExpiresAt
+	}
+
+	if err := s.classRepo.Update(class); err != nil {
+		return nil, err
+	}
+	if err := s.enrichClassSummary(class); err != nil {
+		return nil, err
+	}
+	return class, nil
+}
+
+// RegenerateClassCode replaces the class code so the old one stops working.
+// The new code expires at expiresAt, or never when it is nil.
+func (s *classService) RegenerateClassCode(classID string, teacherID uuid.UUID, expiresAt *time.Time) (*entities.Class, error) {
+	class, err := s.memberClass(classID, teacherID)
+	if err != nil {
+		return nil, err
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now().In(config.AppLocation)) {
+		return nil, errors.New("code expiry must be in the future")
+	}
+
+	code, err := s.generateClassCode()
+	if err != nil {
+		return nil, err
+	}
+	class.ClassCode = code
+	class.CodeExpiresAt = expiresAt
+	if err := s.classRepo.Update(class); err != nil {
+		return nil, err
+	}
+	if err := s.enrichClassSummary(class); err != nil {
+		return nil, err
+	}
+	return class, nil
+}
+
+// GetJoinRequests lists the pending join requests of a class, oldest first
+func (s *classService) GetJoinRequests(classID string, teacherID uuid.UUID) ([]JoinRequestInfo, error) {
+	if s.joinRequestRepo == nil {
+		return nil, errors.New("join requests not available")
+	}
+	if _, err := s.memberClass(classID, teacherID); err != nil {
+		return nil, err
+	}
+	list, err := s.joinRequestRepo.FindPendingByClassID(classID)
+	if err != nil {
+		return nil, err
+	}
+
+	requests := make([]JoinRequestInfo, 0, len(list))
+	for _, req := range list {
+		info := JoinRequestInfo{ID: req.ID, UserID: req.UserID, RequestedAt: req.CreatedAt}
+		if req.User != nil {
+			info.Email = req.User.Email
+			info.FullName = req.User.FullName
+		}
+		requests = append(requests, info)
+	}
+	return requests, nil
+}
+
+// DecideJoinRequest approves (adds the student) or rejects a pending request
+func (s *classService) DecideJoinRequest(classID string, teacherID uuid.UUID, studentID string, approve bool) error {
+	if s.joinRequestRepo == nil {
+		return errors.New("join requests not available")
+	}
+	class, err := s.memberClass(classID, teacherID)
+	if err != nil {
+		return err
+	}
+	req, err := s.joinRequestRepo.FindByClassAndUser(classID, studentID)
+	if err != nil || req.Status != entities.ClassJoinRequestPending {
+		return errors.New("join request not found")
+	}
+
+	if !approve {
+		now := time.Now().In(config.AppLocation)
+		req.Status = entities.ClassJoinRequestRejected
+		req.DecidedBy = &teacherID
+		req.DecidedAt = &now
+		return s.joinRequestRepo.Update(req)
+	}
+
+	if s.isBanned(classID, studentID) {
+		return errors.New("user is banned from this class")
+	}
+	if isMember, err := s.classMemberRepo.IsMember(classID, studentID); err != nil || !isMember {
+		if err := s.addMember(class, req.UserID); err != nil {
+			return err
+		}
+	}
+	return s.joinRequestRepo.Delete(req.ID.String())
+}
+
+// InviteStudent invites a student by email, whatever the join policy of the
+// class. This is how invite-only classes get their students; the student
+// becomes a member once they accept. Inviting the same student again
+// returns the open invitation.
+func (s *classService) InviteStudent(classID string, teacherID uuid.UUID, email string) (*entities.ClassInvitation, error) {
+	if s.invitationRepo == nil {
+		return nil, errors.New("class invitations not available")
+	}
+	class, err := s.memberClass(classID, teacherID)
+	if err != nil {
+		return nil, err
+	}
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" || !strings.Contains(email, "@") {
+		return nil, errors.New("a valid email is required")
+	}
+	user, err := s.userRepo.FindByEmail(email)
+	if err != nil {
+		return nil, errors.New("no user with this email")
+	}
+	if user.Role != "student" {
+		return nil, errors.New("only students can be invited to a class")
+	}
+	if isMember, err := s.classMemberRepo.IsMember(classID, user.ID.String()); err == nil && isMember {
+		return nil, errors.New("user is already a member of this class")
+	}
+	if s.isBanned(classID, user.ID.String()) {
+		return nil, errors.New("user is banned from this class")
+	}
+
+	if existing, err := s.invitationRepo.FindByClassAndUser(classID, user.ID.String()); err == nil {
+		return existing, nil
+	}
+	invitation := &entities.ClassInvitation{
+		ClassID:   class.ID,
+		UserID:    user.ID,
+		InvitedBy: teacherID,
+	}
+	if err := s.invitationRepo.Create(invitation); err != nil {
+		return nil, err
+	}
+	return invitation, nil
+}
+
+// GetClassInvitations lists the open student invitations of a class, oldest first
+func (s *classService) GetClassInvitations(classID string, teacherID uuid.UUID) ([]ClassInvitationInfo, error) {
+	if s.invitationRepo == nil {
+		return nil, errors.New("class invitations not available")
+	}
+	class, err := s.memberClass(classID, teacherID)
+	if err != nil {
+		return nil, err
+	}
+	list, err := s.invitationRepo.FindByClassID(classID)
+	if err != nil {
+		return nil, err
+	}
+	invitations := make([]ClassInvitationInfo, 0, len(list))
+	for _, inv := range list {
+		info := ClassInvitationInfo{
+			ID:        inv.ID,
+			ClassID:   inv.ClassID,
+			ClassName: class.Name,
+			UserID:    inv.UserID,
+			InvitedBy: inv.InvitedBy,
+			CreatedAt: inv.CreatedAt,
+		}
+		if inv.User != nil {
+			info.Email = inv.User.Email
+			info.FullName = inv.User.FullName
+		}
+		invitations = append(invitations, info)
+	}
+	return invitations, nil
+}
+
+// RevokeClassInvitation withdraws an open student invitation
+func (s *classService) RevokeClassInvitation(classID string, teacherID uuid.UUID, invitationID string) error {
+	if s.invitationRepo == nil {
+		return errors.New("class invitations not available")
+	}
+	if _, err := s.memberClass(classID, teacherID); err != nil {
+		return err
+	}
+	invitation, err := s.invitationRepo.FindByID(invitationID)
+	if err != nil || invitation.ClassID.String() != classID {
+		return errors.New("invitation not found")
+	}
+	return s.invitationRepo.Delete(invitationID)
+}
+
+// GetMyClassInvitations lists the open class invitations sent to the user
+func (s *classService) GetMyClassInvitations(userID uuid.UUID) ([]ClassInvitationInfo, error) {
+	if s.invitationRepo == nil {
+		return nil, errors.New("class invitations not available")
+	}
+	list, err := s.invitationRepo.FindByUserID(userID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	inviterNames := make(map[uuid.UUID]string)
+	invitations := make([]ClassInvitationInfo, 0, len(list))
+	for _, inv := range list {
+		if inv.Class == nil {
+			continue
+		}
+		name, ok := inviterNames[inv.InvitedBy]
+		if !ok {
+			if inviter, err := s.userRepo.FindByID(inv.InvitedBy.String()); err == nil {
+				name = inviter.FullName
+			}
+			inviterNames[inv.InvitedBy] = name
+		}
+		invitations = append(invitations, ClassInvitationInfo{
+			ID:          inv.ID,
+			ClassID:     inv.ClassID,
+			ClassName:   inv.Class.Name,
+			UserID:      inv.UserID,
+			InvitedBy:   inv.InvitedBy,
+			InviterName: name,
+			CreatedAt:   inv.CreatedAt,
+		})
+	}
+	return invitations, nil
+}
+
+// RespondToClassInvitation accepts (joins the class) or declines a class
+// invitation addressed to the user. Either way the invitation is deleted.
+func (s *classService) RespondToClassInvitation(invitationID string, userID uuid.UUID, accept bool) (*MemberInfo, error) {
+	if s.invitationRepo == nil {
+		return nil, errors.New("class invitations not available")
+	}
+	invitation, err := s.invitationRepo.FindByID(invitationID)
+	if err != nil || invitation.UserID != userID {
+		return nil, errors.New("invitation not found")
+	}
+	if !accept {
+		return nil, s.invitationRepo.Delete(invitationID)
+	}
+
+	class, err := s.classRepo.FindByID(invitation.ClassID.String())
+	if err != nil {
+		return nil, errors.New("class not found")
+	}
+	classID := class.ID.String()
+	if s.isBanned(classID, userID.String()) {
+		return nil, errors.New("you are banned from this class")
+	}
+	if isMember, err := s.classMemberRepo.IsMember(classID, userID.String()); err != nil || !isMember {
+		if err := s.addMember(class, userID); err != nil {
+			return nil, err
+		}
+	}
+	// A pending request of the student is answered by the invitation
+	if s.joinRequestRepo != nil {
+		if req, err := s.joinRequestRepo.FindByClassAndUser(classID, userID.String()); err == nil {
+			_ = s.joinRequestRepo.Delete(req.ID.String())
+		}
+	}
+	if err := s.invitationRepo.Delete(invitationID); err != nil {
+		return nil, err
+	}
+
+	member, err := s.classMemberRepo.FindByClassAndUser(classID, userID.String())
+	if err != nil {
+		return nil, err
+	}
+	info := &MemberInfo{UserID: userID, JoinedAt: member.JoinedAt}
+	if user, err := s.userRepo.FindByID(userID.String()); err == nil {
+		info.Email = user.Email
+		info.FullName = user.FullName
+	}
+	return info, nil
+}
+
+// RemoveMember removes a student from the class. They can join again.
+func (s *classService) RemoveMember(classID string, teacherID uuid.UUID, studentID string) error {
+	if _, err := s.memberClass(classID, teacherID); err != nil {
+		return err
+	}
+	if isMember, err := s.classMemberRepo.IsMember(classID, studentID); err != nil || !isMember {
+		return errors.New("student is not a member of this class")
+	}
+	if err := s.leaveGroups(classID, studentID); err != nil {
+		return err
+	}
+	return s.classMemberRepo.DeleteByClassAndUser(classID, studentID)
+}
+
+// BanMember removes a student (or a requester) from the class and keeps them
+// from joining again until unbanned.
+func (s *classService) BanMember(classID string, teacherID uuid.UUID, studentID, reason string) error {
+	if s.banRepo == nil {
+		return errors.New("class bans not available")
+	}
+	class, err := s.memberClass(classID, teacherID)
+	if err != nil {
+		return err
+	}
+	studentUUID, err := uuid.Parse(studentID)
+	if err != nil {
+		return errors.New("invalid user ID")
+	}
+	if _, err := s.userRepo.FindByID(studentID); err != nil {
+		return errors.New("user not found")
+	}
+	if s.classRole(class, studentUUID) != "" {
+		return errors.New("class staff cannot be banned")
+	}
+	if s.isBanned(classID, studentID) {
+		return errors.New("user is already banned from this class")
+	}
+
+	if err := s.leaveGroups(classID, studentID); err != nil {
+		return err
+	}
+	if err := s.classMemberRepo.DeleteByClassAndUser(classID, studentID); err != nil {
+		return err
+	}
+	if s.joinRequestRepo != nil {
+		if req, err := s.joinRequestRepo.FindByClassAndUser(classID, studentID); err == nil {
+			if err := s.joinRequestRepo.Delete(req.ID.String()); err != nil {
+				return err
+			}
+		}
+	}
+	if s.invitationRepo != nil {
+		if err := s.invitationRepo.DeleteByClassAndUser(classID, studentID); err != nil {
+			return err
+		}
+	}
+	return s.banRepo.Create(&entities.ClassBan{
+		ClassID:   class.ID,
+		UserID:    studentUUID,
+		BannedBy:  teacherID,
+		Reason:    strings.TrimSpace(reason),
+		CreatedAt: time.Now().In(config.AppLocation),
+	})
+}
+
+// UnbanMember lifts a ban; the user can join again but is not re-added
+func (s *classService) UnbanMember(classID string, teacherID uuid.UUID, studentID string) error {
+	if s.banRepo == nil {
+		return errors.New("class bans not available")
+	}
+	if _, err := s.memberClass(classID, teacherID); err != nil {
+		return err
+	}
+	if !s.isBanned(classID, studentID) {
+		return errors.New("user is not banned from this class")
+	}
+	return s.banRepo.DeleteByClassAndUser(classID, studentID)
+}
+
+// GetClassBans lists the banned users of a class, newest first
+func (s *classService) GetClassBans(classID string, teacherID uuid.UUID) ([]ClassBanInfo, error) {
+	if s.banRepo == nil {
+		return nil, errors.New("class bans not available")
+	}
+	if _, err := s.memberClass(classID, teacherID); err != nil {
+		return nil, err
+	}
+	list, err := s.banRepo.FindByClassID(classID)
+	if err != nil {
+		return nil, err
+	}
+
+	bans := make([]ClassBanInfo, 0, len(list))
+	for _, ban := range list {
+		info := ClassBanInfo{
+			UserID:   ban.UserID,
+			Reason:   ban.Reason,
+			BannedBy: ban.BannedBy,
+			BannedAt: ban.CreatedAt,
+		}
+		if ban.User != nil {
+			info.Email = ban.User.Email
+			info.FullName = ban.User.FullName
+		}
+		bans = append(bans, info)
+	}
+	return bans, nil
+}
@@ -0,0 +1,196 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"hifzhun-api/pkg/config"
+	"hifzhun-api/pkg/entities"
+	"hifzhun-api/pkg/services"
+)
+
+func TestClassJoinControls(t *testing.T) {
+	env := newTestEnv(t)
+	db := env.db
+	userRepo := env.userRepo
+	memberRepo := env.memberRepo
+	svc := env.classSvc
+
+	teacher := &entities.User{Email: "ustadz@example.com", FullName: "Ustadz Ahmad", Role: "teacher"}
+	ali := &entities.User{Email: "ali@example.com", FullName: "Ali", Role: "student"}
+	umar := &entities.User{Email: "umar@example.com", FullName: "Umar", Role: "student"}
+	zaid := &entities.User{Email: "zaid@example.com", FullName: "Zaid", Role: "student"}
+	for _, u := range []*entities.User{teacher, ali, umar, zaid} {
+		if err := userRepo.Create(u); err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+	}
+	class, err := svc.CreateClass(teacher.ID, "Halaqah Ashar", "", entities.ClassTypeBook, "")
+	if err != nil {
+		t.Fatalf("create class: %v", err)
+	}
+	classID := class.ID.String()
+	if class.JoinPolicy != entities.ClassJoinOpen {
+		t.Errorf("default join policy = %q", class.JoinPolicy)
+	}
+
+	// Approval: the code queues a request for the teacher
+	approval := entities.ClassJoinApproval
+	if _, err := svc.UpdateJoinSettings(classID, ali.ID, services.JoinSettingsInput{JoinPolicy: &approval}); err == nil {
+		t.Error("a student changed the join settings")
+	}
+	if _, err := svc.UpdateJoinSettings(classID, teacher.ID, services.JoinSettingsInput{JoinPolicy: &approval}); err != nil {
+		t.Fatalf("join settings: %v", err)
+	}
+	joined, err := svc.JoinClass(ali.ID, class.ClassCode)
+	if err != nil || joined.JoinStatus != "pending" {
+		t.Fatalf("join with approval: %v (%+v)", err, joined)
+	}
+	if isMember, _ := memberRepo.IsMember(classID, ali.ID.String()); isMember {
+		t.Error("joined before approval")
+	}
+	if _, err := svc.JoinClass(ali.ID, class.ClassCode); err == nil {
+		t.Error("sent a second pending request")
+	}
+	if _, err := svc.JoinClass(umar.ID, class.ClassCode); err != nil {
+		t.Fatalf("umar join: %v", err)
+	}
+	requests, err := svc.GetJoinRequests(classID, teacher.ID)
+	if err != nil || len(requests) != 2 || requests[0].UserID != ali.ID || requests[0].FullName != "Ali" {
+		t.Fatalf("join requests = %+v (%v)", requests, err)
+	}
+
+	// Capacity: one seat, so umar's approval fails after ali's
+	one := 1
+	if _, err := svc.UpdateJoinSettings(classID, teacher.ID, services.JoinSettingsInput{MaxMembers: &one}); err != nil {
+		t.Fatalf("max members: %v", err)
+	}
+	if err := svc.DecideJoinRequest(classID, teacher.ID, ali.ID.String(), true); err != nil {
+		t.Fatalf("approve: %v", err)
+	}
+	if isMember, _ := memberRepo.IsMember(classID, ali.ID.String()); !isMember {
+		t.Error("approved student is not a member")
+	}
+	if err := svc.DecideJoinRequest(classID, teacher.ID, umar.ID.String(), true); err == nil {
+		t.Error("approved beyond max members")
+	}
+	if err := svc.DecideJoinRequest(classID, teacher.ID, umar.ID.String(), false); err != nil {
+		t.Fatalf("reject: %v", err)
+	}
+	if requests, _ := svc.GetJoinRequests(classID, teacher.ID); len(requests) != 0 {
+		t.Errorf("requests left = %d", len(requests))
+	}
+	zero := 0
+	if _, err := svc.UpdateJoinSettings(classID, teacher.ID, services.JoinSettingsInput{MaxMembers: &zero}); err != nil {
+		t.Fatalf("unlimited: %v", err)
+	}
+
+	// Invite-only refuses the code; the teacher invites students by email
+	inviteOnly := entities.ClassJoinInviteOnly
+	if _, err := svc.UpdateJoinSettings(classID, teacher.ID, services.JoinSettingsInput{JoinPolicy: &inviteOnly}); err != nil {
+		t.Fatalf("invite only: %v", err)
+	}
+	if _, err := svc.JoinClass(zaid.ID, class.ClassCode); err == nil {
+		t.Error("joined an invite-only class with the code")
+	}
+	if _, err := svc.InviteStudent(classID, teacher.ID, teacher.Email); err == nil {
+		t.Error("invited a teacher as a student")
+	}
+	invitation, err := svc.InviteStudent(classID, teacher.ID, " Zaid@Example.com ")
+	if err != nil || invitation.UserID != zaid.ID {
+		t.Fatalf("invite: %v", err)
+	}
+	if again, err := svc.InviteStudent(classID, teacher.ID, zaid.Email); err != nil || again.ID != invitation.ID {
+		t.Errorf("second invite = %+v (%v)", again, err)
+	}
+	// Nothing changes until the student accepts
+	if isMember, _ := memberRepo.IsMember(classID, zaid.ID.String()); isMember {
+		t.Error("invited student joined before accepting")
+	}
+	if pending, err := svc.GetClassInvitations(classID, teacher.ID); err != nil || len(pending) != 1 || pending[0].Email != zaid.Email {
+		t.Errorf("class invitations = %+v (%v)", pending, err)
+	}
+	mine, err := svc.GetMyClassInvitations(zaid.ID)
+	if err != nil || len(mine) != 1 || mine[0].ClassName != "Halaqah Ashar" || mine[0].InviterName != "Ustadz Ahmad" {
+		t.Fatalf("my invitations = %+v (%v)", mine, err)
+	}
+	if _, err := svc.RespondToClassInvitation(invitation.ID.String(), umar.ID, true); err == nil {
+		t.Error("accepted someone else's invitation")
+	}
+	if member, err := svc.RespondToClassInvitation(invitation.ID.String(), zaid.ID, true); err != nil || member.UserID != zaid.ID {
+		t.Fatalf("accept: %v", err)
+	}
+	if mine, _ := svc.GetMyClassInvitations(zaid.ID); len(mine) != 0 {
+		t.Errorf("invitations left = %+v", mine)
+	}
+
+	// A declined invitation is gone and adds nobody
+	declined, err := svc.InviteStudent(classID, teacher.ID, umar.Email)
+	if err != nil {
+		t.Fatalf("invite umar: %v", err)
+	}
+	if _, err := svc.RespondToClassInvitation(declined.ID.String(), umar.ID, false); err != nil {
+		t.Fatalf("decline: %v", err)
+	}
+	if isMember, _ := memberRepo.IsMember(classID, umar.ID.String()); isMember {
+		t.Error("declined invitation added the student")
+	}
+
+	// Regenerating the code retires the old one; an expired code is refused
+	open := entities.ClassJoinOpen
+	if _, err := svc.UpdateJoinSettings(classID, teacher.ID, services.JoinSettingsInput{JoinPolicy: &open}); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	oldCode := class.ClassCode
+	tomorrow := time.Now().In(config.AppLocation).AddDate(0, 0, 1)
+	regenerated, err := svc.RegenerateClassCode(classID, teacher.ID, &tomorrow)
+	if err != nil || regenerated.ClassCode == oldCode || regenerated.CodeExpiresAt == nil {
+		t.Fatalf("regenerate: %v", err)
+	}
+	if _, err := svc.JoinClass(umar.ID, oldCode); err == nil {
+		t.Error("joined with the old code")
+	}
+	past := time.Now().In(config.AppLocation).Add(-time.Hour)
+	if err := db.Model(&entities.Class{}).Where("id = ?", classID).Update("code_expires_at", past).Error; err != nil {
+		t.Fatalf("expire code: %v", err)
+	}
+	if _, err := svc.JoinClass(umar.ID, regenerated.ClassCode); err == nil {
+		t.Error("joined with an expired code")
+	}
+	if _, err := svc.UpdateJoinSettings(classID, teacher.ID, services.JoinSettingsInput{ClearCodeExpiry: true}); err != nil {
+		t.Fatalf("clear expiry: %v", err)
+	}
+
+	// Removed students can rejoin; banned ones cannot until unbanned
+	if err := svc.RemoveMember(classID, teacher.ID, zaid.ID.String()); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if _, err := svc.JoinClass(zaid.ID, regenerated.ClassCode); err != nil {
+		t.Errorf("rejoin after removal: %v", err)
+	}
+	if err := svc.BanMember(classID, teacher.ID, teacher.ID.String(), ""); err == nil {
+		t.Error("banned the class owner")
+	}
+	if err := svc.BanMember(classID, teacher.ID, ali.ID.String(), "menyebarkan kode kelas"); err != nil {
+		t.Fatalf("ban: %v", err)
+	}
+	if isMember, _ := memberRepo.IsMember(classID, ali.ID.String()); isMember {
+		t.Error("banned student is still a member")
+	}
+	if _, err := svc.JoinClass(ali.ID, regenerated.ClassCode); err == nil {
+		t.Error("banned student rejoined")
+	}
+	if _, err := svc.InviteStudent(classID, teacher.ID, ali.Email); err == nil {
+		t.Error("teacher invited a banned student")
+	}
+	bans, err := svc.GetClassBans(classID, teacher.ID)
+	if err != nil || len(bans) != 1 || bans[0].Reason != "menyebarkan kode kelas" || bans[0].Email != ali.Email {
+		t.Fatalf("bans = %+v (%v)", bans, err)
+	}
+	if err := svc.UnbanMember(classID, teacher.ID, ali.ID.String()); err != nil {
+		t.Fatalf("unban: %v", err)
+	}
+	if _, err := svc.JoinClass(ali.ID, regenerated.ClassCode); err != nil {
+		t.Errorf("join after unban: %v", err)
+	}
+}
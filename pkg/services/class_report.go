@@ -0,0 +1,322 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"hifzhun-api/pkg/config"
+	"hifzhun-api/pkg/entities"
+
+	"github.com/google/uuid"
+)
+
+// maxReportDays bounds the date range of a progress export
+const maxReportDays = 366
+
+// ProgressReportFilter narrows a progress export. BookID limits a book class
+// to one of its books and GroupID to the students of one group. Reviews,
+// streaks and assignments are measured over the whole days From to To.
+type ProgressReportFilter struct {
+	BookID  string
+	GroupID string
+	From    time.Time
+	To      time.Time
+}
+
+// ProgressReportRow is one student's line in a progress export. Status
+// counts and stability describe the student's Items now; reviews, streak
+// and assignments cover the report period.
+type ProgressReportRow struct {
+	FullName        string
+	Email           string
+	Username        string
+	GroupName       string
+	TotalItems      int
+	Start           int
+	Menghafal       int
+	Interval        int
+	FSRSActive      int
+	PendingGraduate int
+	Graduate        int
+	Inactive        int
+	ProgressPct     float64
+	// AverageStability is the mean review interval in days of the Items
+	// that have one
+	AverageStability float64
+	ReviewsDone      int
+	// Streak is the number of consecutive days with at least one review
+	// up to the end of the period (or the day before, when the last day has
+	// no review yet)
+	Streak                int
+	AssignmentsDue        int
+	AssignmentsCompleted  int
+	AssignmentsLate       int
+	AssignmentsIncomplete int
+}
+
+// ProgressReport is a prepared progress export. Rows are computed one
+// student at a time by Each, so exporting a large class keeps memory flat.
+type ProgressReport struct {
+	Class     *entities.Class
+	BookTitle string
+	From      time.Time
+	To        time.Time
+
+	svc         *classService
+	members     []entities.ClassMember
+	classBooks  []entities.ClassBook
+	assignments []entities.ClassAssignment
+	groupNames  map[uuid.UUID]string
+}
+
+// PrepareProgressReport checks access and the filter of a progress export
+// and loads what every row shares.
+func (s *classService) PrepareProgressReport(classID string, teacherID uuid.UUID, f ProgressReportFilter) (*ProgressReport, error) {
+	class, err := s.classRepo.FindByID(classID)
+	if err != nil {
+		return nil, errors.New("class not found")
+	}
+	if !s.can(class, teacherID, ClassPermViewProgress) {
+		return nil, errors.New("you don't have permission to view this class progress")
+	}
+
+	from := dayStart(f.From)
+	to := dayStart(f.To)
+	if to.Before(from) {
+		return nil, errors.New("from must not be after to")
+	}
+	if to.Sub(from) > maxReportDays*24*time.Hour {
+		return nil, errors.New("the report period can be at most a year")
+	}
+	report := &ProgressReport{Class: class, From: from, To: to, svc: s, groupNames: map[uuid.UUID]string{}}
+
+	if f.BookID != "" {
+		if class.Type != entities.ClassTypeBook {
+			return nil, errors.New("book reports are only available for book-type classes")
+		}
+		classBook, err := s.classBookRepo.FindByClassAndBook(classID, f.BookID)
+		if err != nil {
+			return nil, errors.New("book is not assigned to this class")
+		}
+		report.classBooks = []entities.ClassBook{*classBook}
+		if book, err := s.bookRepo.FindByID(f.BookID); err == nil {
+			report.BookTitle = book.Title
+		}
+	} else if class.Type == entities.ClassTypeBook {
+		if report.classBooks, err = s.classBookRepo.FindByClassID(classID); err != nil {
+			return nil, err
+		}
+	}
+
+	if report.members, err = s.classMembers(class, f.GroupID); err != nil {
+		return nil, err
+	}
+
+	if s.groupRepo != nil {
+		groups, err := s.groupRepo.FindByClassID(classID)
+		if err != nil {
+			return nil, err
+		}
+		names := make(map[uuid.UUID]string, len(groups))
+		for _, g := range groups {
+			names[g.ID] = g.Name
+		}
+		placements, err := s.groupRepo.FindMembersByClassID(classID)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range placements {
+			report.groupNames[p.UserID] = names[p.GroupID]
+		}
+	}
+
+	if s.assignmentRepo != nil {
+		assignments, err := s.assignmentRepo.FindByClassID(classID)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range assignments {
+			if f.BookID != "" && (a.BookID == nil || a.BookID.String() != f.BookID) {
+				continue
+			}
+			report.assignments = append(report.assignments, a)
+		}
+	}
+	return report, nil
+}
+
+func dayStart(t time.Time) time.Time {
+	t = t.In(config.AppLocation)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, config.AppLocation)
+}
+
+// Each computes the row of every student in turn and passes it to fn,
+// stopping at the first error fn returns.
+func (r *ProgressReport) Each(fn func(ProgressReportRow) error) error {
+	for _, member := range r.members {
+		row, err := r.row(member.UserID)
+		if err != nil {
+			return err
+		}
+		if row == nil {
+			continue
+		}
+		if err := fn(*row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *ProgressReport) row(userID uuid.UUID) (*ProgressReportRow, error) {
+	s := r.svc
+	user, err := s.userRepo.FindByID(userID.String())
+	if err != nil {
+		return nil, nil
+	}
+	inClass, err := s.classItemFilter(r.Class, r.classBooks, userID)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.itemRepo.FindByOwner(userID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	row := &ProgressReportRow{
+		FullName:  user.FullName,
+		Email:     user.Email,
+		GroupName: r.groupNames[userID],
+	}
+	if user.Username != nil {
+		row.Username = *user.Username
+	}
+	owned := make(map[uuid.UUID]*entities.Item, len(items))
+	itemIDs := make([]uuid.UUID, 0, len(items))
+	stabilityTotal, stabilityCount := 0.0, 0
+	for i := range items {
+		item := &items[i]
+		owned[item.ID] = item
+		if !inClass(*item) {
+			continue
+		}
+		itemIDs = append(itemIDs, item.ID)
+		row.TotalItems++
+		switch item.Status {
+		case entities.ItemStatusStart:
+			row.Start++
+		case entities.ItemStatusMenghafal:
+			row.Menghafal++
+		case entities.ItemStatusInterval:
+			row.Interval++
+		case entities.ItemStatusFSRSActive:
+			row.FSRSActive++
+		case entities.ItemStatusPendingGraduate:
+			row.PendingGraduate++
+		case entities.ItemStatusGraduate:
+			row.Graduate++
+		case entities.ItemStatusInactive:
+			row.Inactive++
+		}
+		if days, ok := itemStabilityDays(item); ok {
+			stabilityTotal += days
+			stabilityCount++
+		}
+	}
+	if row.TotalItems > 0 {
+		row.ProgressPct = float64(row.Graduate) / float64(row.TotalItems) * 100
+	}
+	if stabilityCount > 0 {
+		row.AverageStability = stabilityTotal / float64(stabilityCount)
+	}
+
+	if err := r.fillReviews(row, userID, itemIDs); err != nil {
+		return nil, err
+	}
+	r.fillAssignments(row, userID, owned)
+	return row, nil
+}
+
+// fillReviews counts the reviews of the period and the streak at its end.
+// The streak may reach back before the period, up to a year.
+func (r *ProgressReport) fillReviews(row *ProgressReportRow, userID uuid.UUID, itemIDs []uuid.UUID) error {
+	end := r.To.AddDate(0, 0, 1)
+	since := r.To.AddDate(0, 0, -maxReportDays)
+	if r.From.Before(since) {
+		since = r.From
+	}
+
+	var times []time.Time
+	if r.svc.reviewLogRepo != nil {
+		logged, err := r.svc.reviewLogRepo.ListReviewTimes(context.Background(), userID, itemIDs, since, end)
+		if err != nil {
+			return err
+		}
+		times = append(times, logged...)
+	}
+	if r.svc.intervalLogRepo != nil {
+		logged, err := r.svc.intervalLogRepo.FindReviewTimes(userID, itemIDs, since, end)
+		if err != nil {
+			return err
+		}
+		times = append(times, logged...)
+	}
+
+	reviewed := make(map[time.Time]bool)
+	for _, t := range times {
+		if !t.Before(r.From) {
+			row.ReviewsDone++
+		}
+		reviewed[dayStart(t)] = true
+	}
+	day := r.To
+	if !reviewed[day] {
+		day = day.AddDate(0, 0, -1)
+	}
+	for reviewed[day] {
+		row.Streak++
+		day = day.AddDate(0, 0, -1)
+	}
+	return nil
+}
+
+// fillAssignments counts the student's assignments due in the period by
+// completion. Excused assignments are left out.
+func (r *ProgressReport) fillAssignments(row *ProgressReportRow, userID uuid.UUID, items map[uuid.UUID]*entities.Item) {
+	if len(r.assignments) == 0 {
+		return
+	}
+	end := r.To.AddDate(0, 0, 1)
+	now := time.Now().In(config.AppLocation)
+	for _, a := range r.assignments {
+		p := AssignmentStudentProgress{UserID: userID, DueAt: a.DueAt}
+		for _, o := range a.Overrides {
+			if o.UserID == userID {
+				p.Excused = o.Excused
+				if o.DueAt != nil {
+					p.DueAt = *o.DueAt
+				}
+			}
+		}
+		if p.Excused || p.DueAt.Before(r.From) || !p.DueAt.Before(end) {
+			continue
+		}
+		links, err := r.svc.assignmentRepo.FindItemsByUser(a.ID.String(), userID.String())
+		if err != nil || len(links) == 0 {
+			continue
+		}
+
+		evaluateAssignment(&p, links, items, now)
+		row.AssignmentsDue++
+		switch p.Status {
+		case AssignmentStatusCompleted:
+			row.AssignmentsCompleted++
+		case AssignmentStatusLate:
+			row.AssignmentsCompleted++
+			row.AssignmentsLate++
+		default:
+			row.AssignmentsIncomplete++
+		}
+	}
+}
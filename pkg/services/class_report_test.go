@@ -0,0 +1,137 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"hifzhun-api/pkg/config"
+	"hifzhun-api/pkg/entities"
+	"hifzhun-api/pkg/services"
+)
+
+func TestClassProgressReport(t *testing.T) {
+	env := newTestEnv(t)
+	db := env.db
+	userRepo := env.userRepo
+	classRepo := env.classRepo
+	itemRepo := env.itemRepo
+	svc := env.classSvc
+
+	teacher := &entities.User{Email: "ustadz@example.com", FullName: "Ustadz Ahmad", Role: "teacher"}
+	ali := &entities.User{Email: "ali@example.com", FullName: "Ali", Role: "student"}
+	umar := &entities.User{Email: "umar@example.com", FullName: "Umar", Role: "student"}
+	for _, u := range []*entities.User{teacher, ali, umar} {
+		if err := userRepo.Create(u); err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+	}
+	class := &entities.Class{GuruID: teacher.ID, Name: "Tahfidz Putra", ClassCode: "PUTRA", Type: entities.ClassTypeQuran, IsActive: true}
+	if err := classRepo.Create(class); err != nil {
+		t.Fatalf("create class: %v", err)
+	}
+	classID := class.ID.String()
+	for _, u := range []*entities.User{ali, umar} {
+		if _, err := svc.JoinClass(u.ID, "PUTRA"); err != nil {
+			t.Fatalf("join: %v", err)
+		}
+	}
+	name := "Abu Bakar"
+	group, err := svc.CreateClassGroup(classID, teacher.ID, services.ClassGroupInput{Name: &name})
+	if err != nil {
+		t.Fatalf("create group: %v", err)
+	}
+	if _, err := svc.AddGroupMembers(classID, group.ID.String(), teacher.ID, []string{ali.ID.String()}); err != nil {
+		t.Fatalf("add members: %v", err)
+	}
+
+	now := time.Now().In(config.AppLocation)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 12, 0, 0, 0, config.AppLocation)
+	if _, err := svc.CreateAssignment(classID, teacher.ID, services.AssignmentInput{Title: "An-Naba", ContentRef: "surah:78:1-20", DueAt: now.Add(2 * time.Hour)}); err != nil {
+		t.Fatalf("assignment: %v", err)
+	}
+
+	// Ali memorized An-Naba and reviewed it on three days in a row, plus
+	// once before the period
+	aliItems, _ := itemRepo.FindByOwner(ali.ID.String())
+	if len(aliItems) != 1 {
+		t.Fatalf("ali items %+v", aliItems)
+	}
+	item := aliItems[0]
+	last, next := today.AddDate(0, 0, -2), today.AddDate(0, 0, 2)
+	item.Status = entities.ItemStatusInterval
+	item.IntervalStartAt = &now
+	item.LastReviewAt = &last
+	item.NextReviewAt = &next
+	if err := itemRepo.Update(&item); err != nil {
+		t.Fatalf("update item: %v", err)
+	}
+	for _, days := range []int{0, -1, -2, -10} {
+		review := &entities.ReviewLog{ID: uuid.New(), UserID: ali.ID, ItemID: item.ID, ReviewedAt: today.AddDate(0, 0, days), Rating: 3}
+		if err := db.Create(review).Error; err != nil {
+			t.Fatalf("review log: %v", err)
+		}
+	}
+	if err := db.Create(&entities.IntervalReviewLog{UserID: ali.ID, ItemID: item.ID, Rating: 2, ReviewedAt: today}).Error; err != nil {
+		t.Fatalf("interval log: %v", err)
+	}
+
+	filter := services.ProgressReportFilter{From: today.AddDate(0, 0, -6), To: today.AddDate(0, 0, 1)}
+	if _, err := svc.PrepareProgressReport(classID, ali.ID, filter); err == nil {
+		t.Error("a student exported the class progress")
+	}
+	if _, err := svc.PrepareProgressReport(classID, teacher.ID, services.ProgressReportFilter{From: today, To: today.AddDate(0, 0, -1)}); err == nil {
+		t.Error("accepted a period that ends before it starts")
+	}
+	if _, err := svc.PrepareProgressReport(classID, teacher.ID, services.ProgressReportFilter{BookID: uuid.NewString(), From: today, To: today}); err == nil {
+		t.Error("accepted a book filter on a Quran class")
+	}
+
+	collect := func(f services.ProgressReportFilter) map[string]services.ProgressReportRow {
+		report, err := svc.PrepareProgressReport(classID, teacher.ID, f)
+		if err != nil {
+			t.Fatalf("prepare report: %v", err)
+		}
+		rows := map[string]services.ProgressReportRow{}
+		if err := report.Each(func(row services.ProgressReportRow) error {
+			rows[row.Email] = row
+			return nil
+		}); err != nil {
+			t.Fatalf("report rows: %v", err)
+		}
+		return rows
+	}
+
+	rows := collect(filter)
+	if len(rows) != 2 {
+		t.Fatalf("report rows %+v", rows)
+	}
+	a := rows[ali.Email]
+	if a.TotalItems != 1 || a.Interval != 1 || a.AverageStability != 4 || a.GroupName != name {
+		t.Errorf("ali items %+v", a)
+	}
+	// The last day of the period has no review yet, so the streak counts
+	// back from today
+	if a.ReviewsDone != 4 || a.Streak != 3 {
+		t.Errorf("ali reviews %d, streak %d", a.ReviewsDone, a.Streak)
+	}
+	if a.AssignmentsDue != 1 || a.AssignmentsCompleted != 1 || a.AssignmentsIncomplete != 0 {
+		t.Errorf("ali assignments %+v", a)
+	}
+	u := rows[umar.Email]
+	if u.TotalItems != 1 || u.Menghafal != 1 || u.ReviewsDone != 0 || u.Streak != 0 || u.AssignmentsIncomplete != 1 {
+		t.Errorf("umar row %+v", u)
+	}
+
+	// A period before the assignment was due leaves it out
+	early := collect(services.ProgressReportFilter{From: today.AddDate(0, 0, -20), To: today.AddDate(0, 0, -8)})
+	if r := early[ali.Email]; r.ReviewsDone != 1 || r.Streak != 0 || r.AssignmentsDue != 0 {
+		t.Errorf("early period %+v", r)
+	}
+
+	filter.GroupID = group.ID.String()
+	if rows := collect(filter); len(rows) != 1 || rows[ali.Email].FullName != "Ali" {
+		t.Errorf("group report %+v", rows)
+	}
+}
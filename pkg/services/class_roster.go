@@ -0,0 +1,321 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/csv"
+	"errors"
+	"io"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"hifzhun-api/pkg/entities"
+	"hifzhun-api/pkg/repositories"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// MaxRosterRows caps the students of one roster import
+	MaxRosterRows = 500
+
+	// RosterEmailDomain is the placeholder email domain of students imported
+	// with a username only; they log in with the username.
+	RosterEmailDomain = "santri.hifzhun.local"
+)
+
+// Roster import row statuses
+const (
+	RosterStatusCreated       = "created"          // new account, enrolled
+	RosterStatusExisting      = "existing_account" // account exists, not enrolled; the student joins with the class code
+	RosterStatusAlreadyMember = "already_member"   // existing member, nothing changed
+	RosterStatusSkipped       = "skipped"          // see error
+)
+
+var rosterUsernamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{2,49}$`)
+
+// RosterRow is one student of a class roster. Login is an email or a username.
+type RosterRow struct {
+	Line     int
+	FullName string
+	Login    string
+	Guardian string
+}
+
+// RosterImportRow is the outcome of one roster row. Password is the one-time
+// password of a created account and is only returned once.
+type RosterImportRow struct {
+	Line     int        `json:"line"`
+	FullName string     `json:"full_name"`
+	Email    string     `json:"email,omitempty"`
+	Username string     `json:"username,omitempty"`
+	Guardian string     `json:"guardian,omitempty"`
+	UserID   *uuid.UUID `json:"user_id,omitempty"`
+	Status   string     `json:"status"`
+	Password string     `json:"password,omitempty"`
+	Error    string     `json:"error,omitempty"`
+}
+
+// RosterImportResult is the credentials sheet of a roster import
+type RosterImportResult struct {
+	ClassID       uuid.UUID         `json:"class_id"`
+	ClassName     string            `json:"class_name"`
+	ClassCode     string            `json:"class_code"`
+	Created       int               `json:"created"`
+	Existing      int               `json:"existing_account"`
+	AlreadyMember int               `json:"already_member"`
+	Skipped       int               `json:"skipped"`
+	Rows          []RosterImportRow `json:"rows"`
+}
+
+var rosterColumns = map[string]string{
+	"name": "name", "full_name": "name", "nama": "name", "nama_lengkap": "name",
+	"email": "login", "username": "login", "email_or_username": "login", "login": "login",
+	"guardian": "guardian", "guardian_name": "guardian", "wali": "guardian", "nama_wali": "guardian",
+}
+
+// ParseRosterCSV reads a roster with name, email or username and an optional
+// guardian column. A header row (name/nama, email/username, guardian/wali) may
+// name the columns; without one the columns are taken in that order.
+func ParseRosterCSV(r io.Reader) ([]RosterRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	var records [][]string
+	var lines []int
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.New("invalid CSV file")
+		}
+		line, _ := reader.FieldPos(0)
+		records = append(records, record)
+		lines = append(lines, line)
+	}
+
+	index := map[string]int{"name": 0, "login": 1, "guardian": 2}
+	start := 0
+	if len(records) > 0 {
+		header := map[string]int{}
+		for i, cell := range records[0] {
+			key := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(cell, "\ufeff")))
+			key = strings.ReplaceAll(key, " ", "_")
+			if column, ok := rosterColumns[key]; ok {
+				header[column] = i
+			}
+		}
+		if _, ok := header["name"]; ok {
+			if _, ok := header["login"]; !ok {
+				return nil, errors.New("roster needs an email or username column")
+			}
+			index, start = header, 1
+			if _, ok := index["guardian"]; !ok {
+				index["guardian"] = -1
+			}
+		}
+	}
+
+	cell := func(record []string, column string) string {
+		i := index[column]
+		if i < 0 || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	rows := make([]RosterRow, 0, len(records))
+	for i := start; i < len(records); i++ {
+		row := RosterRow{
+			Line:     lines[i],
+			FullName: cell(records[i], "name"),
+			Login:    cell(records[i], "login"),
+			Guardian: cell(records[i], "guardian"),
+		}
+		if row.FullName == "" && row.Login == "" && row.Guardian == "" {
+			continue
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("roster is empty")
+	}
+	if len(rows) > MaxRosterRows {
+		return nil, errors.New("roster has more than 500 students")
+	}
+	return rows, nil
+}
+
+// validRosterEmail accepts name@domain.tld, but not the placeholder domain
+// of username-only accounts.
+func validRosterEmail(email string) bool {
+	at := strings.Index(email, "@")
+	if at < 1 || at != strings.LastIndex(email, "@") || strings.HasSuffix(email, "@"+RosterEmailDomain) {
+		return false
+	}
+	domain := email[at+1:]
+	dot := strings.LastIndex(domain, ".")
+	return dot > 0 && dot < len(domain)-1
+}
+
+// generateOneTimePassword returns a 10-character password without look-alike
+// characters so it can be read off a printed sheet.
+func generateOneTimePassword() (string, error) {
+	const charset = "ABCDEFGHJKMNPQRSTUVWXYZabcdefghjkmnpqrstuvwxyz23456789"
+	password := make([]byte, 10)
+	for i := range password {
+		num, err := rand.Int(rand.Reader, big.NewInt(int64(len(charset))))
+		if err != nil {
+			return "", err
+		}
+		password[i] = charset[num.Int64()]
+	}
+	return string(password), nil
+}
+
+// ImportRoster creates active student accounts with one-time passwords for
+// the roster rows that have none and enrolls them in the class. Rows are
+// handled independently. Existing accounts (matched by email or username)
+// are only reported: their owners join with the class code, so the class's
+// join policy applies and nothing about the account is revealed.
+func (s *classService) ImportRoster(classID string, teacherID uuid.UUID, rows []RosterRow) (*RosterImportResult, error) {
+	if s.authSvc == nil {
+		return nil, errors.New("roster import not available")
+	}
+	class, err := s.memberClass(classID, teacherID)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("roster is empty")
+	}
+	if len(rows) > MaxRosterRows {
+		return nil, errors.New("roster has more than 500 students")
+	}
+
+	result := &RosterImportResult{
+		ClassID:   class.ID,
+		ClassName: class.Name,
+		ClassCode: class.ClassCode,
+		Rows:      make([]RosterImportRow, 0, len(rows)),
+	}
+	seen := make(map[string]int, len(rows))
+	for _, row := range rows {
+		out := s.importRosterRow(class, row, seen)
+		switch out.Status {
+		case RosterStatusCreated:
+			result.Created++
+		case RosterStatusExisting:
+			result.Existing++
+		case RosterStatusAlreadyMember:
+			result.AlreadyMember++
+		default:
+			result.Skipped++
+		}
+		result.Rows = append(result.Rows, out)
+	}
+	return result, nil
+}
+
+func (s *classService) importRosterRow(class *entities.Class, row RosterRow, seen map[string]int) RosterImportRow {
+	out := RosterImportRow{
+		Line:     row.Line,
+		FullName: strings.TrimSpace(row.FullName),
+		Guardian: strings.TrimSpace(row.Guardian),
+		Status:   RosterStatusSkipped,
+	}
+	login := strings.ToLower(strings.TrimSpace(row.Login))
+	isEmail := strings.Contains(login, "@")
+	if isEmail {
+		out.Email = login
+	} else {
+		out.Username = login
+	}
+
+	switch {
+	case out.FullName == "":
+		out.Error = "name is required"
+		return out
+	case login == "":
+		out.Error = "email or username is required"
+		return out
+	case isEmail && !validRosterEmail(login):
+		out.Error = "invalid email"
+		return out
+	case !isEmail && !rosterUsernamePattern.MatchString(login):
+		out.Error = "username must be 3-50 characters: letters, digits, dot, dash or underscore"
+		return out
+	}
+	if line, dup := seen[login]; dup {
+		out.Error = "duplicate of line " + strconv.Itoa(line) + " in the roster"
+		return out
+	}
+	seen[login] = row.Line
+
+	// Existing account: never enroll it or show who it belongs to
+	var existing *entities.User
+	var err error
+	if isEmail {
+		existing, err = s.userRepo.FindByEmail(login)
+	} else {
+		existing, err = s.userRepo.FindByUsername(login)
+	}
+	if err == nil {
+		if isMember, err := s.classMemberRepo.IsMember(class.ID.String(), existing.ID.String()); err == nil && isMember {
+			out.Status = RosterStatusAlreadyMember
+			return out
+		}
+		out.Status = RosterStatusExisting
+		out.Error = "an account with this email or username already exists; the student can join with the class code"
+		return out
+	}
+	if !errors.Is(err, repositories.ErrUserNotFound) {
+		out.Error = err.Error()
+		return out
+	}
+
+	password, err := generateOneTimePassword()
+	if err != nil {
+		out.Error = err.Error()
+		return out
+	}
+	hashed, err := s.authSvc.HashPassword(password)
+	if err != nil {
+		out.Error = err.Error()
+		return out
+	}
+	user := &entities.User{
+		Email:              out.Email,
+		Password:           hashed,
+		Role:               "student",
+		IsActive:           true,
+		MustChangePassword: true,
+		FullName:           out.FullName,
+		GuardianName:       out.Guardian,
+	}
+	if !isEmail {
+		username := login
+		user.Username = &username
+		user.Email = login + "@" + RosterEmailDomain
+	}
+	if err := s.userRepo.Create(user); err != nil {
+		out.Error = err.Error()
+		return out
+	}
+	out.UserID = &user.ID
+	out.Password = password
+	return s.enrollRosterStudent(class, user.ID, out)
+}
+
+// enrollRosterStudent enrolls a created account; one that could not be
+// enrolled keeps its credentials so the teacher can add it later.
+func (s *classService) enrollRosterStudent(class *entities.Class, userID uuid.UUID, out RosterImportRow) RosterImportRow {
+	out.Status = RosterStatusCreated
+	if err := s.addMember(class, userID); err != nil {
+		out.Error = err.Error()
+	}
+	return out
+}
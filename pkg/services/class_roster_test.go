@@ -0,0 +1,152 @@
+package services_test
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"hifzhun-api/pkg/entities"
+	"hifzhun-api/pkg/middlewares"
+	"hifzhun-api/pkg/services"
+	"hifzhun-api/pkg/usecases"
+)
+
+func TestClassRosterImport(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	env := newTestEnv(t)
+	userRepo := env.userRepo
+	memberRepo := env.memberRepo
+	authSvc := env.authSvc
+	svc := env.classSvc
+
+	teacher := &entities.User{Email: "ustadz@example.com", FullName: "Ustadz Ahmad", Role: "teacher"}
+	ali := &entities.User{Email: "ali@example.com", FullName: "Ali", Role: "student", IsActive: true}
+	for _, u := range []*entities.User{teacher, ali} {
+		if err := userRepo.Create(u); err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+	}
+	class, err := svc.CreateClass(teacher.ID, "Halaqah Maghrib", "", entities.ClassTypeBook, "")
+	if err != nil {
+		t.Fatalf("create class: %v", err)
+	}
+	classID := class.ID.String()
+
+	roster := "\ufeffNama,Wali,Email\n" +
+		"Umar Faruq,Bapak Faruq,umar@example.com\n" +
+		"Zaid,Ibu Aminah,Zaid.Kecil\n" +
+		"\n" +
+		"Ali,,ALI@example.com\n" +
+		"Umar Lagi,,umar@example.com\n" +
+		"Ustadz,,ustadz@example.com\n" +
+		",,tanpa.nama\n" +
+		"Bilal,,bilal@santri\n"
+	rows, err := services.ParseRosterCSV(strings.NewReader(roster))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(rows) != 7 || rows[0].FullName != "Umar Faruq" || rows[0].Guardian != "Bapak Faruq" || rows[2].Line != 5 {
+		t.Fatalf("rows = %+v", rows)
+	}
+	if _, err := services.ParseRosterCSV(strings.NewReader("Nama,Wali\nUmar,Bapak Faruq\n")); err == nil {
+		t.Error("parsed a roster without a login column")
+	}
+
+	if _, err := svc.ImportRoster(classID, ali.ID, rows); err == nil {
+		t.Error("a student imported a roster")
+	}
+	result, err := svc.ImportRoster(classID, teacher.ID, rows)
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if result.Created != 2 || result.Existing != 2 || result.Skipped != 3 || result.ClassCode != class.ClassCode {
+		t.Fatalf("result = %+v", result)
+	}
+	for i, want := range []string{"created", "created", "existing_account", "skipped", "existing_account", "skipped", "skipped"} {
+		if result.Rows[i].Status != want {
+			t.Errorf("row %d status = %q (%s), want %q", i, result.Rows[i].Status, result.Rows[i].Error, want)
+		}
+	}
+	if result.Rows[2].Password != "" || result.Rows[3].Error != "duplicate of line 2 in the roster" {
+		t.Errorf("rows = %+v", result.Rows)
+	}
+	// Existing accounts are neither enrolled nor described
+	for _, row := range []services.RosterImportRow{result.Rows[2], result.Rows[4]} {
+		if row.UserID != nil || row.Username != "" {
+			t.Errorf("existing account leaked: %+v", row)
+		}
+	}
+	if isMember, _ := memberRepo.IsMember(classID, ali.ID.String()); isMember {
+		t.Error("existing account was enrolled without joining")
+	}
+
+	// Created accounts are active students with a one-time password
+	umar, err := userRepo.FindByEmail("umar@example.com")
+	if err != nil {
+		t.Fatalf("find umar: %v", err)
+	}
+	if !umar.IsActive || !umar.MustChangePassword || umar.Role != "student" || umar.GuardianName != "Bapak Faruq" {
+		t.Errorf("umar = %+v", umar)
+	}
+	if err := authSvc.CheckPassword(umar.Password, result.Rows[0].Password); err != nil {
+		t.Error("one-time password does not match")
+	}
+	for _, id := range []string{umar.ID.String(), result.Rows[1].UserID.String()} {
+		if isMember, _ := memberRepo.IsMember(classID, id); !isMember {
+			t.Errorf("user %s not enrolled", id)
+		}
+	}
+
+	// Username-only students log in with the username, then change the password
+	auth := usecases.NewAuthUsecase(userRepo, authSvc)
+	zaid, token, err := auth.Login("zaid.kecil", result.Rows[1].Password)
+	if err != nil {
+		t.Fatalf("username login: %v", err)
+	}
+	if zaid.Email != "zaid.kecil@"+services.RosterEmailDomain || !zaid.MustChangePassword {
+		t.Errorf("zaid = %+v", zaid)
+	}
+
+	// Until then the token only works for changing the password
+	app := fiber.New()
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) }
+	app.Get("/profile", middlewares.JWTAuth(), ok)
+	app.Post("/change-password", middlewares.JWTAuthAllowingPasswordChange(), ok)
+	status := func(method, path, token string) int {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+		return resp.StatusCode
+	}
+	if code := status("GET", "/profile", token); code != fiber.StatusForbidden {
+		t.Errorf("profile with a one-time password token: %d", code)
+	}
+	if code := status("POST", "/change-password", token); code != fiber.StatusOK {
+		t.Errorf("change-password with a one-time password token: %d", code)
+	}
+
+	if _, err := auth.ChangePassword(zaid.ID.String(), result.Rows[1].Password, "short"); err == nil {
+		t.Error("accepted a short password")
+	}
+	token, err = auth.ChangePassword(zaid.ID.String(), result.Rows[1].Password, "hafalan-baru")
+	if err != nil {
+		t.Fatalf("change password: %v", err)
+	}
+	if code := status("GET", "/profile", token); code != fiber.StatusOK {
+		t.Errorf("profile after the change: %d", code)
+	}
+	if zaid, _, err = auth.Login("Zaid.Kecil", "hafalan-baru"); err != nil || zaid.MustChangePassword {
+		t.Errorf("login after change: %v", err)
+	}
+
+	// A second import leaves existing members untouched
+	again, err := svc.ImportRoster(classID, teacher.ID, rows[:1])
+	if err != nil || again.AlreadyMember != 1 || again.Rows[0].Password != "" {
+		t.Fatalf("reimport = %+v (%v)", again, err)
+	}
+}
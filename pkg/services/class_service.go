@@ -27,11 +27,16 @@ type ClassService interface {
 	CreateBookInClass(classID string, teacherID uuid.UUID, title, description, coverImage string, order int) (*entities.ClassBook, error)
 	AddBookToClass(classID string, teacherID uuid.UUID, bookID string, order int) (*entities.ClassBook, error)
 	RemoveBookFromClass(classID string, teacherID uuid.UUID, bookID string) error
-	GetStudentProgress(classID string, teacherID uuid.UUID) ([]StudentProgress, error)
-	GetClassBookStudentProgress(classID, bookID string, teacherID uuid.UUID) (*ClassBookStudentProgress, error)
-	GetPendingGraduations(classID string, teacherID uuid.UUID) ([]PendingGraduation, error)
-	ApproveGraduation(classID string, teacherID uuid.UUID, itemID string) error
-	RejectGraduation(classID string, teacherID uuid.UUID, itemID string) error
+	GetStudentProgress(classID string, teacherID uuid.UUID, groupID string) ([]StudentProgress, error)
+	GetClassBookStudentProgress(classID, bookID string, teacherID uuid.UUID, groupID string) (*ClassBookStudentProgress, error)
+	GetPendingGraduations(classID string, teacherID uuid.UUID, groupID string) ([]PendingGraduation, error)
+	ApproveGraduation(classID string, teacherID uuid.UUID, itemID string, comment string) error
+	RejectGraduation(classID string, teacherID uuid.UUID, itemID string, comment string, retestAt *time.Time) error
+	DecideGraduations(classID string, teacherID uuid.UUID, in GraduationBatchInput) (*GraduationBatchResult, error)
+	GetGraduationHistory(classID string, userID uuid.UUID, studentID string, page, perPage int) ([]GraduationDecision, int64, error)
+	GetGraduationPolicy(classID string, userID uuid.UUID) (*GraduationPolicy, error)
+	UpdateGraduationPolicy(classID string, teacherID uuid.UUID, in GraduationPolicyInput) (*GraduationPolicy, error)
+	ResetGraduationPolicy(classID string, teacherID uuid.UUID) (*GraduationPolicy, error)
 
 	// Student methods
 	JoinClass(userID uuid.UUID, classCode string) (*entities.Class, error)
@@ -39,6 +44,69 @@ type ClassService interface {
 	GetMyJoinedClasses(userID uuid.UUID) ([]entities.Class, error)
 	GetClassBooks(classID string, userID uuid.UUID) ([]entities.ClassBook, error)
 	GetClassMembers(classID string, userID uuid.UUID) ([]MemberInfo, error)
+
+	// Assignments
+	CreateAssignment(classID string, teacherID uuid.UUID, in AssignmentInput) (*entities.ClassAssignment, error)
+	UpdateAssignment(classID, assignmentID string, teacherID uuid.UUID, title, description *string, dueAt *time.Time) (*entities.ClassAssignment, error)
+	DeleteAssignment(classID, assignmentID string, teacherID uuid.UUID) error
+	SetAssignmentOverride(classID, assignmentID string, teacherID uuid.UUID, studentID string, dueAt *time.Time, excused bool, note string) (*entities.ClassAssignmentOverride, error)
+	RemoveAssignmentOverride(classID, assignmentID string, teacherID uuid.UUID, studentID string) error
+	GetClassAssignments(classID string, teacherID uuid.UUID) ([]AssignmentProgress, error)
+	GetAssignmentProgress(classID, assignmentID string, teacherID uuid.UUID) (*AssignmentProgress, error)
+	GetMyAssignments(classID string, userID uuid.UUID) ([]MyAssignment, error)
+
+	// Setoran
+	RecordSetoran(classID string, teacherID uuid.UUID, in SetoranInput, now time.Time) (*entities.Setoran, error)
+	GetStudentSetoran(classID string, userID uuid.UUID, studentID string, page, perPage int) ([]entities.Setoran, int64, error)
+	GetSetoranSheet(classID string, teacherID uuid.UUID, date time.Time) (*SetoranSheet, error)
+
+	// Staff
+	InviteStaff(classID string, ownerID uuid.UUID, email, role string) (*entities.ClassStaff, error)
+	GetClassStaff(classID string, userID uuid.UUID) ([]ClassStaffInfo, error)
+	UpdateStaffRole(classID string, ownerID uuid.UUID, staffUserID, role string) (*entities.ClassStaff, error)
+	RemoveStaff(classID string, ownerID uuid.UUID, staffUserID string) error
+	GetMyStaffInvitations(userID uuid.UUID) ([]ClassStaffInvitation, error)
+	RespondToStaffInvitation(invitationID string, userID uuid.UUID, accept bool) (*entities.ClassStaff, error)
+	TransferClassOwnership(classID string, ownerID uuid.UUID, newOwnerID string, leave bool) (*entities.Class, error)
+	LeaveClassStaff(classID string, userID uuid.UUID) error
+
+	// Membership
+	UpdateJoinSettings(classID string, teacherID uuid.UUID, in JoinSettingsInput) (*entities.Class, error)
+	RegenerateClassCode(classID string, teacherID uuid.UUID, expiresAt *time.Time) (*entities.Class, error)
+	GetJoinRequests(classID string, teacherID uuid.UUID) ([]JoinRequestInfo, error)
+	DecideJoinRequest(classID string, teacherID uuid.UUID, studentID string, approve bool) error
+	InviteStudent(classID string, teacherID uuid.UUID, email string) (*entities.ClassInvitation, error)
+	GetClassInvitations(classID string, teacherID uuid.UUID) ([]ClassInvitationInfo, error)
+	RevokeClassInvitation(classID string, teacherID uuid.UUID, invitationID string) error
+	GetMyClassInvitations(userID uuid.UUID) ([]ClassInvitationInfo, error)
+	RespondToClassInvitation(invitationID string, userID uuid.UUID, accept bool) (*MemberInfo, error)
+	RemoveMember(classID string, teacherID uuid.UUID, studentID string) error
+	BanMember(classID string, teacherID uuid.UUID, studentID, reason string) error
+	UnbanMember(classID string, teacherID uuid.UUID, studentID string) error
+	GetClassBans(classID string, teacherID uuid.UUID) ([]ClassBanInfo, error)
+	ImportRoster(classID string, teacherID uuid.UUID, rows []RosterRow) (*RosterImportResult, error)
+
+	// Groups
+	CreateClassGroup(classID string, teacherID uuid.UUID, in ClassGroupInput) (*ClassGroupInfo, error)
+	UpdateClassGroup(classID, groupID string, teacherID uuid.UUID, in ClassGroupInput) (*ClassGroupInfo, error)
+	DeleteClassGroup(classID, groupID string, teacherID uuid.UUID) error
+	GetClassGroups(classID string, userID uuid.UUID) ([]ClassGroupInfo, error)
+	GetClassGroup(classID, groupID string, userID uuid.UUID) (*ClassGroupInfo, error)
+	AddGroupMembers(classID, groupID string, teacherID uuid.UUID, studentIDs []string) (*ClassGroupInfo, error)
+	RemoveGroupMember(classID, groupID string, teacherID uuid.UUID, studentID string) error
+
+	// Announcements
+	CreateAnnouncement(classID string, authorID uuid.UUID, in AnnouncementInput) (*entities.ClassAnnouncement, error)
+	UpdateAnnouncement(classID, announcementID string, authorID uuid.UUID, in AnnouncementInput) (*entities.ClassAnnouncement, error)
+	DeleteAnnouncement(classID, announcementID string, authorID uuid.UUID) error
+	GetAnnouncements(classID string, userID uuid.UUID, page, perPage int) ([]entities.ClassAnnouncement, int64, error)
+
+	// Scheduled notifications
+	DeliverScheduledAnnouncements(now time.Time) (int, error)
+	SendAssignmentDueReminders(now time.Time, within time.Duration) (int, error)
+
+	// Reports
+	PrepareProgressReport(classID string, teacherID uuid.UUID, f ProgressReportFilter) (*ProgressReport, error)
 }
 
 // ItemDetail represents detailed information about a single class item
@@ -175,16 +243,35 @@ type PendingGraduation struct {
 }
 
 type classService struct {
-	classRepo       repositories.ClassRepository
-	classMemberRepo repositories.ClassMemberRepository
-	classBookRepo   repositories.ClassBookRepository
-	bookRepo        repositories.BookRepository
-	userRepo        repositories.UserRepository
-	itemRepo        *repositories.ItemRepository
-	juzRepo         *repositories.JuzRepository
-	juzItemRepo     *repositories.JuzItemRepository
-	dailyTaskRepo   repositories.DailyTaskRepository
-	dailyTaskSvc    DailyTaskService
+	classRepo        repositories.ClassRepository
+	classMemberRepo  repositories.ClassMemberRepository
+	classBookRepo    repositories.ClassBookRepository
+	bookRepo         repositories.BookRepository
+	userRepo         repositories.UserRepository
+	itemRepo         *repositories.ItemRepository
+	juzRepo          *repositories.JuzRepository
+	juzItemRepo      *repositories.JuzItemRepository
+	dailyTaskRepo    repositories.DailyTaskRepository
+	dailyTaskSvc     DailyTaskService
+	assignmentRepo   *repositories.ClassAssignmentRepository
+	bookModuleRepo   repositories.BookModuleRepository
+	bookItemRepo     repositories.BookItemRepository
+	quranValidator   *QuranValidator
+	bookSvc          BookService
+	setoranRepo      *repositories.SetoranRepository
+	reviewSvc        *ItemReviewService
+	graduationRepo   repositories.ItemGraduationRepository
+	policyRepo       *repositories.ClassGraduationPolicyRepository
+	staffRepo        *repositories.ClassStaffRepository
+	joinRequestRepo  *repositories.ClassJoinRequestRepository
+	invitationRepo   *repositories.ClassInvitationRepository
+	banRepo          *repositories.ClassBanRepository
+	authSvc          AuthService
+	groupRepo        *repositories.ClassGroupRepository
+	announcementRepo *repositories.ClassAnnouncementRepository
+	notifier         *NotificationService
+	reviewLogRepo    repositories.ReviewLogRepository
+	intervalLogRepo  *repositories.IntervalReviewLogRepository
 }
 
 // calculateItemStability returns the interval in days between last_review_at and next_review_at.
@@ -304,29 +391,71 @@ func (s *classService) enrichClassSummary(class *entities.Class) error {
 	return nil
 }
 
-func NewClassService(
-	classRepo repositories.ClassRepository,
-	classMemberRepo repositories.ClassMemberRepository,
-	classBookRepo repositories.ClassBookRepository,
-	bookRepo repositories.BookRepository,
-	userRepo repositories.UserRepository,
-	itemRepo *repositories.ItemRepository,
-	juzRepo *repositories.JuzRepository,
-	juzItemRepo *repositories.JuzItemRepository,
-	dailyTaskRepo repositories.DailyTaskRepository,
-	dailyTaskSvc DailyTaskService,
-) ClassService {
+// ClassServiceDeps holds the repositories and services of a ClassService.
+// Tests set only the ones they need.
+type ClassServiceDeps struct {
+	ClassRepo        repositories.ClassRepository
+	ClassMemberRepo  repositories.ClassMemberRepository
+	ClassBookRepo    repositories.ClassBookRepository
+	BookRepo         repositories.BookRepository
+	UserRepo         repositories.UserRepository
+	ItemRepo         *repositories.ItemRepository
+	JuzRepo          *repositories.JuzRepository
+	JuzItemRepo      *repositories.JuzItemRepository
+	DailyTaskRepo    repositories.DailyTaskRepository
+	DailyTaskSvc     DailyTaskService
+	AssignmentRepo   *repositories.ClassAssignmentRepository
+	BookModuleRepo   repositories.BookModuleRepository
+	BookItemRepo     repositories.BookItemRepository
+	QuranValidator   *QuranValidator
+	BookSvc          BookService
+	SetoranRepo      *repositories.SetoranRepository
+	ReviewSvc        *ItemReviewService
+	GraduationRepo   repositories.ItemGraduationRepository
+	PolicyRepo       *repositories.ClassGraduationPolicyRepository
+	StaffRepo        *repositories.ClassStaffRepository
+	JoinRequestRepo  *repositories.ClassJoinRequestRepository
+	InvitationRepo   *repositories.ClassInvitationRepository
+	BanRepo          *repositories.ClassBanRepository
+	AuthSvc          AuthService
+	GroupRepo        *repositories.ClassGroupRepository
+	AnnouncementRepo *repositories.ClassAnnouncementRepository
+	Notifier         *NotificationService
+	ReviewLogRepo    repositories.ReviewLogRepository
+	IntervalLogRepo  *repositories.IntervalReviewLogRepository
+}
+
+func NewClassService(deps ClassServiceDeps) ClassService {
 	return &classService{
-		classRepo:       classRepo,
-		classMemberRepo: classMemberRepo,
-		classBookRepo:   classBookRepo,
-		bookRepo:        bookRepo,
-		userRepo:        userRepo,
-		itemRepo:        itemRepo,
-		juzRepo:         juzRepo,
-		juzItemRepo:     juzItemRepo,
-		dailyTaskRepo:   dailyTaskRepo,
-		dailyTaskSvc:    dailyTaskSvc,
+		classRepo:        deps.ClassRepo,
+		classMemberRepo:  deps.ClassMemberRepo,
+		classBookRepo:    deps.ClassBookRepo,
+		bookRepo:         deps.BookRepo,
+		userRepo:         deps.UserRepo,
+		itemRepo:         deps.ItemRepo,
+		juzRepo:          deps.JuzRepo,
+		juzItemRepo:      deps.JuzItemRepo,
+		dailyTaskRepo:    deps.DailyTaskRepo,
+		dailyTaskSvc:     deps.DailyTaskSvc,
+		assignmentRepo:   deps.AssignmentRepo,
+		bookModuleRepo:   deps.BookModuleRepo,
+		bookItemRepo:     deps.BookItemRepo,
+		quranValidator:   deps.QuranValidator,
+		bookSvc:          deps.BookSvc,
+		setoranRepo:      deps.SetoranRepo,
+		reviewSvc:        deps.ReviewSvc,
+		graduationRepo:   deps.GraduationRepo,
+		policyRepo:       deps.PolicyRepo,
+		staffRepo:        deps.StaffRepo,
+		joinRequestRepo:  deps.JoinRequestRepo,
+		invitationRepo:   deps.InvitationRepo,
+		banRepo:          deps.BanRepo,
+		authSvc:          deps.AuthSvc,
+		groupRepo:        deps.GroupRepo,
+		announcementRepo: deps.AnnouncementRepo,
+		notifier:         deps.Notifier,
+		reviewLogRepo:    deps.ReviewLogRepo,
+		intervalLogRepo:  deps.IntervalLogRepo,
 	}
 }
 
@@ -395,11 +524,31 @@ func (s *classService) CreateClass(teacherID uuid.UUID, name, description, class
 	return class, nil
 }
 
+// GetMyClasses lists the classes the teacher owns followed by the classes
+// they teach as staff, each with the teacher's role.
 func (s *classService) GetMyClasses(teacherID uuid.UUID) ([]entities.Class, error) {
 	classes, err := s.classRepo.FindByTeacher(teacherID.String())
 	if err != nil {
 		return nil, err
 	}
+	for i := range classes {
+		classes[i].MyRole = entities.ClassStaffRoleOwner
+	}
+
+	if s.staffRepo != nil {
+		staffed, err := s.staffRepo.FindActiveByUser(teacherID.String())
+		if err != nil {
+			return nil, err
+		}
+		for _, staff := range staffed {
+			class, err := s.classRepo.FindByID(staff.ClassID.String())
+			if err != nil {
+				continue
+			}
+			class.MyRole = staff.Role
+			classes = append(classes, *class)
+		}
+	}
 
 	for i := range classes {
 		if err := s.enrichClassSummary(&classes[i]); err != nil {
@@ -416,8 +565,9 @@ func (s *classService) GetClassDetail(classID string, userID uuid.UUID) (*entiti
 		return nil, errors.New("class not found")
 	}
 
-	// Check if user is teacher or member
-	if class.GuruID != userID {
+	// Check if user is teacher, staff or member
+	class.MyRole = s.classRole(class, userID)
+	if class.MyRole == "" {
 		_, err := s.classMemberRepo.FindByClassAndUser(classID, userID.String())
 		if err != nil {
 			return nil, errors.New("you don't have access to this class")
@@ -471,13 +621,58 @@ func (s *classService) DeleteClass(classID string, teacherID uuid.UUID) error {
 		return errors.New("you don't have permission to delete this class")
 	}
 
-	// Delete all members and books
+	// Delete all members, staff and books
 	if err := s.classMemberRepo.DeleteByClassID(classID); err != nil {
 		return err
 	}
+	if s.staffRepo != nil {
+		if err := s.staffRepo.DeleteByClassID(classID); err != nil {
+			return err
+		}
+	}
+	if s.joinRequestRepo != nil {
+		if err := s.joinRequestRepo.DeleteByClassID(classID); err != nil {
+			return err
+		}
+	}
+	if s.invitationRepo != nil {
+		if err := s.invitationRepo.DeleteByClassID(classID); err != nil {
+			return err
+		}
+	}
+	if s.banRepo != nil {
+		if err := s.banRepo.DeleteByClassID(classID); err != nil {
+			return err
+		}
+	}
+	if s.groupRepo != nil {
+		if err := s.groupRepo.DeleteByClassID(classID); err != nil {
+			return err
+		}
+	}
+	if s.announcementRepo != nil {
+		if err := s.announcementRepo.DeleteByClassID(classID); err != nil {
+			return err
+		}
+	}
 	if err := s.classBookRepo.DeleteByClassID(classID); err != nil {
 		return err
 	}
+	if s.assignmentRepo != nil {
+		if err := s.assignmentRepo.DeleteByClassID(classID); err != nil {
+			return err
+		}
+	}
+	if s.setoranRepo != nil {
+		if err := s.setoranRepo.DeleteByClassID(classID); err != nil {
+			return err
+		}
+	}
+	if s.policyRepo != nil {
+		if err := s.policyRepo.DeleteByClassID(classID); err != nil {
+			return err
+		}
+	}
 
 	return s.classRepo.Delete(classID)
 }
@@ -488,7 +683,7 @@ func (s *classService) AddBookToClass(classID string, teacherID uuid.UUID, bookI
 		return nil, errors.New("class not found")
 	}
 
-	if class.GuruID != teacherID {
+	if !s.can(class, teacherID, ClassPermManageBooks) {
 		return nil, errors.New("you don't have permission to add book to this class")
 	}
 
@@ -535,7 +730,7 @@ func (s *classService) CreateBookInClass(classID string, teacherID uuid.UUID, ti
 		return nil, errors.New("class not found")
 	}
 
-	if class.GuruID != teacherID {
+	if !s.can(class, teacherID, ClassPermManageBooks) {
 		return nil, errors.New("you don't have permission to create a book in this class")
 	}
 
@@ -579,20 +774,22 @@ func (s *classService) RemoveBookFromClass(classID string, teacherID uuid.UUID,
 		return errors.New("class not found")
 	}
 
-	if class.GuruID != teacherID {
+	if !s.can(class, teacherID, ClassPermManageBooks) {
 		return errors.New("you don't have permission to remove book from this class")
 	}
 
 	return s.classBookRepo.DeleteByClassAndBook(classID, bookID)
 }
 
-func (s *classService) GetStudentProgress(classID string, teacherID uuid.UUID) ([]StudentProgress, error) {
+// GetStudentProgress returns the class progress of every student, or of the
+// students of one group when groupID is set.
+func (s *classService) GetStudentProgress(classID string, teacherID uuid.UUID, groupID string) ([]StudentProgress, error) {
 	class, err := s.classRepo.FindByID(classID)
 	if err != nil {
 		return nil, errors.New("class not found")
 	}
 
-	if class.GuruID != teacherID {
+	if !s.can(class, teacherID, ClassPermViewProgress) {
 		return nil, errors.New("you don't have permission to view this class progress")
 	}
 
@@ -604,7 +801,7 @@ func (s *classService) GetStudentProgress(classID string, teacherID uuid.UUID) (
 		}
 	}
 
-	members, err := s.classMemberRepo.FindByClassID(classID)
+	members, err := s.classMembers(class, groupID)
 	if err != nil {
 		return nil, err
 	}
@@ -616,12 +813,9 @@ func (s *classService) GetStudentProgress(classID string, teacherID uuid.UUID) (
 			continue
 		}
 
-		classQuranItemIDs := map[uuid.UUID]bool{}
-		if class.Type == entities.ClassTypeQuran {
-			classQuranItemIDs, err = s.classQuranItemIDSet(member.UserID, classID)
-			if err != nil {
-				continue
-			}
+		inClass, err := s.classItemFilter(class, classBooks, member.UserID)
+		if err != nil {
+			continue
 		}
 
 		// Get item stats for this user
@@ -639,11 +833,7 @@ func (s *classService) GetStudentProgress(classID string, teacherID uuid.UUID) (
 		}
 
 		for _, item := range items {
-			if class.Type == entities.ClassTypeQuran {
-				if !classQuranItemIDs[item.ID] {
-					continue
-				}
-			} else if !itemBelongsToClassBooks(item, classBooks) {
+			if !inClass(item) {
 				continue
 			}
 
@@ -692,15 +882,30 @@ func (s *classService) GetStudentProgress(classID string, teacherID uuid.UUID) (
 	return progressList, nil
 }
 
+// classItemFilter reports which of a student's Items belong to the class:
+// Items filed in the class juz for Quran classes, Items of the given class
+// books for book classes.
+func (s *classService) classItemFilter(class *entities.Class, classBooks []entities.ClassBook, userID uuid.UUID) (func(entities.Item) bool, error) {
+	if class.Type == entities.ClassTypeQuran {
+		ids, err := s.classQuranItemIDSet(userID, class.ID.String())
+		if err != nil {
+			return nil, err
+		}
+		return func(item entities.Item) bool { return ids[item.ID] }, nil
+	}
+	return func(item entities.Item) bool { return itemBelongsToClassBooks(item, classBooks) }, nil
+}
+
 // GetClassBookStudentProgress returns progress for every student in one book.
 // It deliberately scopes the report to a single class-book relation so a book
-// assigned to another class cannot be queried through this class.
-func (s *classService) GetClassBookStudentProgress(classID, bookID string, teacherID uuid.UUID) (*ClassBookStudentProgress, error) {
+// assigned to another class cannot be queried through this class. groupID,
+// when set, limits the report to the students of one group.
+func (s *classService) GetClassBookStudentProgress(classID, bookID string, teacherID uuid.UUID, groupID string) (*ClassBookStudentProgress, error) {
 	class, err := s.classRepo.FindByID(classID)
 	if err != nil {
 		return nil, errors.New("class not found")
 	}
-	if class.GuruID != teacherID {
+	if !s.can(class, teacherID, ClassPermViewProgress) {
 		return nil, errors.New("you don't have permission to view this class progress")
 	}
 	if class.Type != entities.ClassTypeBook {
@@ -725,7 +930,7 @@ func (s *classService) GetClassBookStudentProgress(classID, bookID string, teach
 		}
 	}
 
-	members, err := s.classMemberRepo.FindByClassID(classID)
+	members, err := s.classMembers(class, groupID)
 	if err != nil {
 		return nil, err
 	}
@@ -862,6 +1067,9 @@ func (s *classService) GetClassBookStudentProgress(classID, bookID string, teach
 
 // ==================== STUDENT METHODS ====================
 
+// JoinClass joins a class with its code. Open classes add the student right
+// away; approval classes queue a join request for the teacher (JoinStatus
+// pending); invite-only classes refuse the code.
 func (s *classService) JoinClass(userID uuid.UUID, classCode string) (*entities.Class, error) {
 	class, err := s.classRepo.FindByCode(classCode)
 	if err != nil {
@@ -872,6 +1080,10 @@ func (s *classService) JoinClass(userID uuid.UUID, classCode string) (*entities.
 		return nil, errors.New("class is not active")
 	}
 
+	if class.CodeExpiresAt != nil && !time.Now().In(config.AppLocation).Before(*class.CodeExpiresAt) {
+		return nil, errors.New("class code has expired")
+	}
+
 	// Check if already a member
 	_, err = s.classMemberRepo.FindByClassAndUser(class.ID.String(), userID.String())
 	if err == nil {
@@ -882,15 +1094,26 @@ func (s *classService) JoinClass(userID uuid.UUID, classCode string) (*entities.
 	if class.GuruID == userID {
 		return nil, errors.New("you cannot join your own class")
 	}
-
-	member := &entities.ClassMember{
-		ClassID:  class.ID,
-		UserID:   userID,
-		JoinedAt: time.Now().In(config.AppLocation),
+	if s.classRole(class, userID) != "" {
+		return nil, errors.New("class staff cannot join as a student")
+	}
+	if s.isBanned(class.ID.String(), userID.String()) {
+		return nil, errors.New("you are banned from this class")
 	}
 
-	if err := s.classMemberRepo.Create(member); err != nil {
-		return nil, err
+	switch class.JoinPolicy {
+	case entities.ClassJoinInviteOnly:
+		return nil, errors.New("this class is invite-only")
+	case entities.ClassJoinApproval:
+		if err := s.requestToJoin(class, userID); err != nil {
+			return nil, err
+		}
+		class.JoinStatus = "pending"
+	default:
+		if err := s.addMember(class, userID); err != nil {
+			return nil, err
+		}
+		class.JoinStatus = "joined"
 	}
 
 	if err := s.enrichClassSummary(class); err != nil {
@@ -905,6 +1128,9 @@ func (s *classService) LeaveClass(userID uuid.UUID, classID string) error {
 	if err != nil {
 		return errors.New("you are not a member of this class")
 	}
+	if err := s.leaveGroups(classID, userID.String()); err != nil {
+		return err
+	}
 
 	return s.classMemberRepo.DeleteByClassAndUser(classID, userID.String())
 }
@@ -936,7 +1162,7 @@ func (s *classService) GetClassBooks(classID string, userID uuid.UUID) ([]entiti
 	}
 
 	// Check access
-	if class.GuruID != userID {
+	if s.classRole(class, userID) == "" {
 		_, err := s.classMemberRepo.FindByClassAndUser(classID, userID.String())
 		if err != nil {
 			return nil, errors.New("you don't have access to this class")
@@ -971,8 +1197,8 @@ func (s *classService) GetClassMembers(classID string, userID uuid.UUID) ([]Memb
 		return nil, errors.New("class not found")
 	}
 
-	// Only teacher can see members
-	if class.GuruID != userID {
+	// Only teacher and staff can see members
+	if !s.can(class, userID, ClassPermViewProgress) {
 		return nil, errors.New("only teacher can view class members")
 	}
 
@@ -1001,13 +1227,15 @@ func (s *classService) GetClassMembers(classID string, userID uuid.UUID) ([]Memb
 
 // ==================== GRADUATION APPROVAL METHODS ====================
 
-func (s *classService) GetPendingGraduations(classID string, teacherID uuid.UUID) ([]PendingGraduation, error) {
+// GetPendingGraduations lists the items waiting for approval, optionally only
+// those of one group's students.
+func (s *classService) GetPendingGraduations(classID string, teacherID uuid.UUID, groupID string) ([]PendingGraduation, error) {
 	class, err := s.classRepo.FindByID(classID)
 	if err != nil {
 		return nil, errors.New("class not found")
 	}
 
-	if class.GuruID != teacherID {
+	if !s.can(class, teacherID, ClassPermViewProgress) {
 		return nil, errors.New("you don't have permission to view this class")
 	}
 
@@ -1015,8 +1243,8 @@ func (s *classService) GetPendingGraduations(classID string, teacherID uuid.UUID
 		return nil, errors.New("graduation approval only available for quran-type classes")
 	}
 
-	// Get all members
-	members, err := s.classMemberRepo.FindByClassID(classID)
+	// Get all members, or the members of one group
+	members, err := s.classMembers(class, groupID)
 	if err != nil {
 		return nil, err
 	}
@@ -1065,13 +1293,13 @@ func (s *classService) GetPendingGraduations(classID string, teacherID uuid.UUID
 	return pendingList, nil
 }
 
-func (s *classService) ApproveGraduation(classID string, teacherID uuid.UUID, itemID string) error {
+func (s *classService) ApproveGraduation(classID string, teacherID uuid.UUID, itemID string, comment string) error {
 	class, err := s.classRepo.FindByID(classID)
 	if err != nil {
 		return errors.New("class not found")
 	}
 
-	if class.GuruID != teacherID {
+	if !s.can(class, teacherID, ClassPermApproveGraduations) {
 		return errors.New("you don't have permission to approve graduations in this class")
 	}
 
@@ -1079,49 +1307,19 @@ func (s *classService) ApproveGraduation(classID string, teacherID uuid.UUID, it
 		return errors.New("graduation approval only available for quran-type classes")
 	}
 
-	// Get the item
-	itemUUID, err := uuid.Parse(itemID)
-	if err != nil {
-		return errors.New("invalid item ID")
-	}
-
-	item, err := s.itemRepo.GetByID(itemUUID)
-	if err != nil {
-		return errors.New("item not found")
-	}
-
-	// Verify item is pending graduate
-	if item.Status != entities.ItemStatusPendingGraduate {
-		return errors.New("item is not pending graduation")
-	}
-
-	// Verify item owner is a member of this class
-	isMember, err := s.classMemberRepo.IsMember(classID, item.OwnerID.String())
-	if err != nil || !isMember {
-		return errors.New("item owner is not a member of this class")
-	}
-
-	classQuranItemIDs, err := s.classQuranItemIDSet(item.OwnerID, classID)
-	if err != nil || !classQuranItemIDs[item.ID] {
-		return errors.New("item is not part of this class")
-	}
-
-	// Approve graduation
 	now := time.Now().In(config.AppLocation)
-	item.Status = entities.ItemStatusGraduate
-	item.ApprovedBy = &teacherID
-	item.ApprovedAt = &now
-
-	return s.itemRepo.Update(item)
+	return s.decideGraduation(class, teacherID, itemID, entities.GraduationActionApprove, comment, nil, now)
 }
 
-func (s *classService) RejectGraduation(classID string, teacherID uuid.UUID, itemID string) error {
+// RejectGraduation returns a pending item to fsrs_active. retestAt, when
+// set, forces the item into the student's daily tasks on that date.
+func (s *classService) RejectGraduation(classID string, teacherID uuid.UUID, itemID string, comment string, retestAt *time.Time) error {
 	class, err := s.classRepo.FindByID(classID)
 	if err != nil {
 		return errors.New("class not found")
 	}
 
-	if class.GuruID != teacherID {
+	if !s.can(class, teacherID, ClassPermApproveGraduations) {
 		return errors.New("you don't have permission to reject graduations in this class")
 	}
 
@@ -1129,35 +1327,6 @@ func (s *classService) RejectGraduation(classID string, teacherID uuid.UUID, ite
 		return errors.New("graduation rejection only available for quran-type classes")
 	}
 
-	// Get the item
-	itemUUID, err := uuid.Parse(itemID)
-	if err != nil {
-		return errors.New("invalid item ID")
-	}
-
-	item, err := s.itemRepo.GetByID(itemUUID)
-	if err != nil {
-		return errors.New("item not found")
-	}
-
-	// Verify item is pending graduate
-	if item.Status != entities.ItemStatusPendingGraduate {
-		return errors.New("item is not pending graduation")
-	}
-
-	// Verify item owner is a member of this class
-	isMember, err := s.classMemberRepo.IsMember(classID, item.OwnerID.String())
-	if err != nil || !isMember {
-		return errors.New("item owner is not a member of this class")
-	}
-
-	classQuranItemIDs, err := s.classQuranItemIDSet(item.OwnerID, classID)
-	if err != nil || !classQuranItemIDs[item.ID] {
-		return errors.New("item is not part of this class")
-	}
-
-	// Reject - return to fsrs_active
-	item.Status = entities.ItemStatusFSRSActive
-
-	return s.itemRepo.Update(item)
+	now := time.Now().In(config.AppLocation)
+	return s.decideGraduation(class, teacherID, itemID, entities.GraduationActionReject, comment, retestAt, now)
 }
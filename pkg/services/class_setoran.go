@@ -0,0 +1,209 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"hifzhun-api/pkg/config"
+	"hifzhun-api/pkg/entities"
+	"hifzhun-api/pkg/fsrs"
+
+	"github.com/google/uuid"
+)
+
+// SetoranInput is a setoran heard by the class teacher. Grade is the FSRS
+// rating: 1 = ulang, 2 = kurang lancar, 3 = lancar, 4 = mutqin.
+type SetoranInput struct {
+	StudentID uuid.UUID
+	ItemID    uuid.UUID
+	Grade     int
+	Mistakes  int
+	Notes     string
+}
+
+// SetoranSheetRow is one student on the daily setoran sheet
+type SetoranSheetRow struct {
+	UserID   uuid.UUID          `json:"user_id"`
+	Email    string             `json:"email"`
+	FullName string             `json:"full_name"`
+	Count    int                `json:"count"`
+	Mistakes int                `json:"mistakes"`
+	Sessions []entities.Setoran `json:"sessions"`
+}
+
+// SetoranSheet lists every student of a class with the setoran heard on one
+// day. Students without a setoran that day have an empty Sessions list.
+type SetoranSheet struct {
+	ClassID  uuid.UUID         `json:"class_id"`
+	Date     string            `json:"date"`
+	Heard    int               `json:"heard"`     // students with at least one setoran
+	NotHeard int               `json:"not_heard"` // students without
+	Total    int               `json:"total"`     // setoran sessions
+	Students []SetoranSheetRow `json:"students"`
+}
+
+// setoranClass loads a class and checks that teacherID holds perm in it
+func (s *classService) setoranClass(classID string, teacherID uuid.UUID, perm string) (*entities.Class, error) {
+	if s.setoranRepo == nil {
+		return nil, errors.New("setoran repository not available")
+	}
+	class, err := s.classRepo.FindByID(classID)
+	if err != nil {
+		return nil, errors.New("class not found")
+	}
+	if !s.can(class, teacherID, perm) {
+		return nil, errors.New("you don't have permission for setoran in this class")
+	}
+	return class, nil
+}
+
+// isClassItem reports whether item is one of the class-scoped items of its
+// owner: in a class juz for quran classes, of a class book for book classes.
+func (s *classService) isClassItem(class *entities.Class, item *entities.Item) (bool, error) {
+	if class.Type == entities.ClassTypeQuran {
+		ids, err := s.classQuranItemIDSet(item.OwnerID, class.ID.String())
+		if err != nil {
+			return false, err
+		}
+		return ids[item.ID], nil
+	}
+	classBooks, err := s.classBookRepo.FindByClassID(class.ID.String())
+	if err != nil {
+		return false, err
+	}
+	return itemBelongsToClassBooks(*item, classBooks), nil
+}
+
+// RecordSetoran records a setoran for a student's class item and feeds the
+// teacher's grade into the item's FSRS state.
+func (s *classService) RecordSetoran(classID string, teacherID uuid.UUID, in SetoranInput, now time.Time) (*entities.Setoran, error) {
+	class, err := s.setoranClass(classID, teacherID, ClassPermRecordSetoran)
+	if err != nil {
+		return nil, err
+	}
+	if s.reviewSvc == nil {
+		return nil, errors.New("review service not available")
+	}
+	if in.Grade < int(fsrs.Again) || in.Grade > int(fsrs.Easy) {
+		return nil, errors.New("grade must be between 1 and 4")
+	}
+	if in.Mistakes < 0 {
+		return nil, errors.New("mistakes cannot be negative")
+	}
+	if isMember, err := s.classMemberRepo.IsMember(classID, in.StudentID.String()); err != nil || !isMember {
+		return nil, errors.New("student is not a member of this class")
+	}
+
+	item, err := s.itemRepo.GetByID(in.ItemID)
+	if err != nil || item.OwnerID != in.StudentID {
+		return nil, errors.New("item not found")
+	}
+	inClass, err := s.isClassItem(class, item)
+	if err != nil {
+		return nil, err
+	}
+	if !inClass {
+		return nil, errors.New("item is not part of this class")
+	}
+
+	setoran := &entities.Setoran{
+		ClassID:    class.ID,
+		TeacherID:  teacherID,
+		StudentID:  in.StudentID,
+		ItemID:     item.ID,
+		ContentRef: item.ContentRef,
+		Grade:      in.Grade,
+		Mistakes:   in.Mistakes,
+		Notes:      strings.TrimSpace(in.Notes),
+		RecordedAt: now,
+	}
+	// The review and the setoran are saved together or not at all
+	save := func(reviewed *entities.Item, reviewLog *entities.ReviewLog) error {
+		setoran.ReviewLogID = &reviewLog.ID
+		setoran.StatusAfter = reviewed.Status
+		setoran.NextReviewAt = reviewed.NextReviewAt
+		return s.setoranRepo.CreateWithReview(setoran, reviewed, reviewLog)
+	}
+	if _, err := s.reviewSvc.ReviewAssessedItem(teacherID, in.StudentID, in.ItemID, fsrs.Rating(in.Grade), now, save); err != nil {
+		return nil, err
+	}
+	return setoran, nil
+}
+
+// GetStudentSetoran returns a student's setoran log in a class, most recent
+// first. The class teacher, staff and the student themselves can read it.
+func (s *classService) GetStudentSetoran(classID string, userID uuid.UUID, studentID string, page, perPage int) ([]entities.Setoran, int64, error) {
+	if s.setoranRepo == nil {
+		return nil, 0, errors.New("setoran repository not available")
+	}
+	class, err := s.classRepo.FindByID(classID)
+	if err != nil {
+		return nil, 0, errors.New("class not found")
+	}
+	if userID.String() != studentID && !s.can(class, userID, ClassPermViewProgress) {
+		return nil, 0, errors.New("you don't have access to this setoran log")
+	}
+	if isMember, err := s.classMemberRepo.IsMember(classID, studentID); err != nil || !isMember {
+		return nil, 0, errors.New("student is not a member of this class")
+	}
+
+	list, total, err := s.setoranRepo.FindByClassAndStudent(classID, studentID, perPage, (page-1)*perPage)
+	if err != nil {
+		return nil, 0, err
+	}
+	if list == nil {
+		list = []entities.Setoran{}
+	}
+	return list, total, nil
+}
+
+// GetSetoranSheet returns the class-wide setoran sheet of one day
+func (s *classService) GetSetoranSheet(classID string, teacherID uuid.UUID, date time.Time) (*SetoranSheet, error) {
+	class, err := s.setoranClass(classID, teacherID, ClassPermViewProgress)
+	if err != nil {
+		return nil, err
+	}
+	date = date.In(config.AppLocation)
+	from := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, config.AppLocation)
+	sessions, err := s.setoranRepo.FindByClassBetween(classID, from, from.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+	byStudent := make(map[uuid.UUID][]entities.Setoran)
+	for _, session := range sessions {
+		byStudent[session.StudentID] = append(byStudent[session.StudentID], session)
+	}
+
+	members, err := s.classMemberRepo.FindByClassID(classID)
+	if err != nil {
+		return nil, err
+	}
+	sheet := &SetoranSheet{
+		ClassID:  class.ID,
+		Date:     from.Format("2006-01-02"),
+		Total:    len(sessions),
+		Students: make([]SetoranSheetRow, 0, len(members)),
+	}
+	for _, member := range members {
+		row := SetoranSheetRow{UserID: member.UserID, Sessions: byStudent[member.UserID]}
+		if user, err := s.userRepo.FindByID(member.UserID.String()); err == nil {
+			row.Email = user.Email
+			row.FullName = user.FullName
+		}
+		if row.Sessions == nil {
+			row.Sessions = []entities.Setoran{}
+		}
+		row.Count = len(row.Sessions)
+		for _, session := range row.Sessions {
+			row.Mistakes += session.Mistakes
+		}
+		if row.Count > 0 {
+			sheet.Heard++
+		} else {
+			sheet.NotHeard++
+		}
+		sheet.Students = append(sheet.Students, row)
+	}
+	return sheet, nil
+}
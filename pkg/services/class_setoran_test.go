@@ -0,0 +1,166 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"hifzhun-api/pkg/config"
+	"hifzhun-api/pkg/entities"
+	"hifzhun-api/pkg/services"
+)
+
+func TestClassSetoran(t *testing.T) {
+	env := newTestEnv(t)
+	db := env.db
+	userRepo := env.userRepo
+	classRepo := env.classRepo
+	itemRepo := env.itemRepo
+	assignmentRepo := env.assignmentRepo
+	reviewSvc := env.reviewSvc
+	svc := env.classSvc
+
+	teacher := &entities.User{Email: "ustadz@example.com", FullName: "Ustadz Ahmad"}
+	ali := &entities.User{Email: "ali@example.com", FullName: "Ali"}
+	umar := &entities.User{Email: "umar@example.com", FullName: "Umar"}
+	for _, u := range []*entities.User{teacher, ali, umar} {
+		if err := userRepo.Create(u); err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+	}
+	class := &entities.Class{GuruID: teacher.ID, Name: "Tahfidz A", ClassCode: "TAHFIDZ", IsActive: true}
+	if err := classRepo.Create(class); err != nil {
+		t.Fatalf("create class: %v", err)
+	}
+	classID := class.ID.String()
+	for _, u := range []*entities.User{ali, umar} {
+		if _, err := svc.JoinClass(u.ID, "TAHFIDZ"); err != nil {
+			t.Fatalf("join: %v", err)
+		}
+	}
+
+	now := time.Now().In(config.AppLocation)
+	assignment, err := svc.CreateAssignment(classID, teacher.ID, services.AssignmentInput{Title: "An-Naba", ContentRef: "surah:78:1-20", DueAt: now.Add(48 * time.Hour)})
+	if err != nil {
+		t.Fatalf("create assignment: %v", err)
+	}
+	links, err := assignmentRepo.FindItemsByUser(assignment.ID.String(), ali.ID.String())
+	if err != nil || len(links) != 1 {
+		t.Fatalf("ali's assignment items: %v (%d)", err, len(links))
+	}
+
+	// Ali reviewed the item yesterday; his next review is days away
+	item, err := itemRepo.GetByID(links[0].ItemID)
+	if err != nil {
+		t.Fatalf("get item: %v", err)
+	}
+	yesterday, nextWeek := now.AddDate(0, 0, -1), now.AddDate(0, 0, 7)
+	item.Status = entities.ItemStatusFSRSActive
+	item.FSRSStartAt = &yesterday
+	item.LastReviewAt = &yesterday
+	item.NextReviewAt = &nextWeek
+	item.Stability, item.Difficulty, item.ReviewCount = 3, 5, 1
+	if err := itemRepo.Update(item); err != nil {
+		t.Fatalf("update item: %v", err)
+	}
+	if _, err := reviewSvc.ReviewItem(ali.ID, item.ID, 3, now); err == nil {
+		t.Error("self review allowed before the next review date")
+	}
+
+	in := services.SetoranInput{StudentID: ali.ID, ItemID: item.ID, Grade: 2, Mistakes: 3, Notes: " tertukar ayat 12 "}
+	if _, err := svc.RecordSetoran(classID, umar.ID, in, now); err == nil {
+		t.Error("a student recorded a setoran")
+	}
+	if _, err := svc.RecordSetoran(classID, teacher.ID, services.SetoranInput{StudentID: ali.ID, ItemID: item.ID, Grade: 5}, now); err == nil {
+		t.Error("accepted grade 5")
+	}
+	if _, err := svc.RecordSetoran(classID, teacher.ID, services.SetoranInput{StudentID: umar.ID, ItemID: item.ID, Grade: 3}, now); err == nil {
+		t.Error("recorded a setoran on another student's item")
+	}
+	outside := &entities.Item{OwnerID: ali.ID, SourceType: "quran", ContentRef: "surah:1:1-7", Status: entities.ItemStatusFSRSActive}
+	if err := itemRepo.Create(outside); err != nil {
+		t.Fatalf("create item: %v", err)
+	}
+	if _, err := svc.RecordSetoran(classID, teacher.ID, services.SetoranInput{StudentID: ali.ID, ItemID: outside.ID, Grade: 3}, now); err == nil {
+		t.Error("recorded a setoran on an item outside the class")
+	}
+
+	setoran, err := svc.RecordSetoran(classID, teacher.ID, in, now)
+	if err != nil {
+		t.Fatalf("record setoran: %v", err)
+	}
+	if setoran.Notes != "tertukar ayat 12" || setoran.ContentRef != "surah:78:1-20" || setoran.ReviewLogID == nil {
+		t.Errorf("setoran = %+v", setoran)
+	}
+
+	// The grade went through the FSRS review path, marked as teacher-assessed
+	updated, _ := itemRepo.GetByID(item.ID)
+	if updated.ReviewCount != 2 || !updated.LastReviewAt.Equal(now) {
+		t.Errorf("item not reviewed: count %d, last review %v", updated.ReviewCount, updated.LastReviewAt)
+	}
+	var reviewLog entities.ReviewLog
+	if err := db.First(&reviewLog, "id = ?", *setoran.ReviewLogID).Error; err != nil {
+		t.Fatalf("review log: %v", err)
+	}
+	if reviewLog.AssessedBy == nil || *reviewLog.AssessedBy != teacher.ID || reviewLog.UserID != ali.ID || reviewLog.Rating != 2 {
+		t.Errorf("review log = %+v", reviewLog)
+	}
+
+	log, total, err := svc.GetStudentSetoran(classID, ali.ID, ali.ID.String(), 1, 20)
+	if err != nil || total != 1 || len(log) != 1 {
+		t.Fatalf("ali's setoran log: %v (%d)", err, total)
+	}
+	if _, _, err := svc.GetStudentSetoran(classID, umar.ID, ali.ID.String(), 1, 20); err == nil {
+		t.Error("a classmate read another student's setoran log")
+	}
+
+	sheet, err := svc.GetSetoranSheet(classID, teacher.ID, now)
+	if err != nil {
+		t.Fatalf("sheet: %v", err)
+	}
+	if sheet.Heard != 1 || sheet.NotHeard != 1 || sheet.Total != 1 {
+		t.Errorf("sheet = heard %d, not heard %d, total %d", sheet.Heard, sheet.NotHeard, sheet.Total)
+	}
+	for _, row := range sheet.Students {
+		if row.UserID == ali.ID && (row.Count != 1 || row.Mistakes != 3) {
+			t.Errorf("ali's row = %+v", row)
+		}
+	}
+	if sheet, err := svc.GetSetoranSheet(classID, teacher.ID, now.AddDate(0, 0, -1)); err != nil || sheet.Heard != 0 {
+		t.Errorf("yesterday's sheet: %v", err)
+	}
+
+	// Umar's assignment item is untouched; his first setoran moves it into FSRS
+	umarLinks, err := assignmentRepo.FindItemsByUser(assignment.ID.String(), umar.ID.String())
+	if err != nil || len(umarLinks) != 1 {
+		t.Fatalf("umar's assignment items: %v (%d)", err, len(umarLinks))
+	}
+	if fresh, _ := itemRepo.GetByID(umarLinks[0].ItemID); fresh.Status != entities.ItemStatusMenghafal {
+		t.Fatalf("umar's item status = %s", fresh.Status)
+	}
+	first, err := svc.RecordSetoran(classID, teacher.ID, services.SetoranInput{StudentID: umar.ID, ItemID: umarLinks[0].ItemID, Grade: 3}, now)
+	if err != nil {
+		t.Fatalf("first setoran: %v", err)
+	}
+	if first.StatusAfter != entities.ItemStatusFSRSActive || first.NextReviewAt == nil {
+		t.Errorf("first setoran = %+v", first)
+	}
+	if fresh, _ := itemRepo.GetByID(umarLinks[0].ItemID); fresh.Status != entities.ItemStatusFSRSActive || fresh.FSRSStartAt == nil || fresh.ReviewCount != 1 {
+		t.Errorf("umar's item after setoran = %+v", fresh)
+	}
+
+	// A setoran that cannot be saved leaves the item and review log as they were
+	if err := db.Migrator().DropTable(&entities.Setoran{}); err != nil {
+		t.Fatalf("drop setoran table: %v", err)
+	}
+	if _, err := svc.RecordSetoran(classID, teacher.ID, in, now.Add(time.Hour)); err == nil {
+		t.Fatal("recorded a setoran without its table")
+	}
+	if after, _ := itemRepo.GetByID(item.ID); after.ReviewCount != 2 || !after.LastReviewAt.Equal(now) {
+		t.Errorf("failed setoran changed the item: count %d, last review %v", after.ReviewCount, after.LastReviewAt)
+	}
+	var logs int64
+	db.Model(&entities.ReviewLog{}).Where("item_id = ?", item.ID).Count(&logs)
+	if logs != 1 {
+		t.Errorf("review logs after a failed setoran = %d", logs)
+	}
+}
@@ -0,0 +1,413 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"hifzhun-api/pkg/config"
+	"hifzhun-api/pkg/entities"
+
+	"github.com/google/uuid"
+)
+
+// Class permissions granted by a staff role. The owner has all of them and
+// is the only one who can change the class itself, its staff and ownership.
+const (
+	ClassPermApproveGraduations = "approve_graduations"
+	ClassPermManageBooks        = "manage_books"
+	ClassPermViewProgress       = "view_progress"
+	ClassPermManageMembers      = "manage_members"
+	ClassPermRecordSetoran      = "record_setoran"
+	ClassPermPostAnnouncements  = "post_announcements"
+)
+
+var classRolePermissions = map[string][]string{
+	entities.ClassStaffRoleOwner: {
+		ClassPermApproveGraduations, ClassPermManageBooks, ClassPermViewProgress, ClassPermManageMembers, ClassPermRecordSetoran,
+		ClassPermPostAnnouncements,
+	},
+	entities.ClassStaffRoleCoTeacher: {
+		ClassPermApproveGraduations, ClassPermManageBooks, ClassPermViewProgress, ClassPermManageMembers, ClassPermRecordSetoran,
+		ClassPermPostAnnouncements,
+	},
+	entities.ClassStaffRoleAssistant: {
+		ClassPermViewProgress, ClassPermRecordSetoran, ClassPermPostAnnouncements,
+	},
+}
+
+// ClassRolePermissions returns the permissions of a class staff role
+func ClassRolePermissions(role string) []string {
+	perms := classRolePermissions[role]
+	if perms == nil {
+		return []string{}
+	}
+	return perms
+}
+
+// ClassStaffInfo is a class staff member or open invitation with the user
+type ClassStaffInfo struct {
+	ID          *uuid.UUID `json:"id,omitempty"` // empty for the owner
+	UserID      uuid.UUID  `json:"user_id"`
+	Email       string     `json:"email"`
+	FullName    string     `json:"full_name"`
+	Role        string     `json:"role"`
+	Status      string     `json:"status"`
+	Permissions []string   `json:"permissions"`
+	AcceptedAt  *time.Time `json:"accepted_at,omitempty"`
+}
+
+// ClassStaffInvitation is an open staff invitation shown to the invitee
+type ClassStaffInvitation struct {
+	ID          uuid.UUID `json:"id"`
+	ClassID     uuid.UUID `json:"class_id"`
+	ClassName   string    `json:"class_name"`
+	Role        string    `json:"role"`
+	InvitedBy   uuid.UUID `json:"invited_by"`
+	InviterName string    `json:"inviter_name,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func normalizeClassStaffRole(role string) (string, error) {
+	role = strings.ToLower(strings.TrimSpace(role))
+	switch role {
+	case "":
+		return entities.ClassStaffRoleAssistant, nil
+	case entities.ClassStaffRoleCoTeacher, entities.ClassStaffRoleAssistant:
+		return role, nil
+	}
+	return "", errors.New("role must be co_teacher or assistant")
+}
+
+// classRole returns the role of userID in the class: owner, an active staff
+// role, or "" for students and outsiders.
+func (s *classService) classRole(class *entities.Class, userID uuid.UUID) string {
+	if class.GuruID == userID {
+		return entities.ClassStaffRoleOwner
+	}
+	if s.staffRepo == nil {
+		return ""
+	}
+	staff, err := s.staffRepo.FindByClassAndUser(class.ID.String(), userID.String())
+	if err != nil || staff.Status != entities.ClassStaffStatusActive {
+		return ""
+	}
+	return staff.Role
+}
+
+// can reports whether userID holds perm in the class
+func (s *classService) can(class *entities.Class, userID uuid.UUID, perm string) bool {
+	for _, p := range classRolePermissions[s.classRole(class, userID)] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// staffClass loads a class and checks that ownerID owns it
+func (s *classService) staffClass(classID string, ownerID uuid.UUID) (*entities.Class, error) {
+	if s.staffRepo == nil {
+		return nil, errors.New("class staff repository not available")
+	}
+	class, err := s.classRepo.FindByID(classID)
+	if err != nil {
+		return nil, errors.New("class not found")
+	}
+	if class.GuruID != ownerID {
+		return nil, errors.New("only the class owner can manage staff")
+	}
+	return class, nil
+}
+
+// InviteStaff invites a teacher by email to teach the class as co-teacher or
+// assistant. Inviting the same teacher again updates the role.
+func (s *classService) InviteStaff(classID string, ownerID uuid.UUID, email, role string) (*entities.ClassStaff, error) {
+	class, err := s.staffClass(classID, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	role, err = normalizeClassStaffRole(role)
+	if err != nil {
+		return nil, err
+	}
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" || !strings.Contains(email, "@") {
+		return nil, errors.New("a valid email is required")
+	}
+	user, err := s.userRepo.FindByEmail(email)
+	if err != nil {
+		return nil, errors.New("no user with this email")
+	}
+	if user.ID == class.GuruID {
+		return nil, errors.New("you already own this class")
+	}
+	if user.Role != "teacher" && user.Role != "admin" {
+		return nil, errors.New("only teachers can be invited as class staff")
+	}
+	if isMember, err := s.classMemberRepo.IsMember(classID, user.ID.String()); err == nil && isMember {
+		return nil, errors.New("user is a student of this class")
+	}
+
+	if existing, err := s.staffRepo.FindByClassAndUser(classID, user.ID.String()); err == nil {
+		if existing.Role == role {
+			return existing, nil
+		}
+		existing.Role = role
+		if err := s.staffRepo.Update(existing); err != nil {
+			return nil, err
+		}
+		return existing, nil
+	}
+
+	invitation := &entities.ClassStaff{
+		ClassID:   class.ID,
+		UserID:    user.ID,
+		Role:      role,
+		Status:    entities.ClassStaffStatusPending,
+		InvitedBy: ownerID,
+	}
+	if err := s.staffRepo.Create(invitation); err != nil {
+		return nil, err
+	}
+	return invitation, nil
+}
+
+// GetClassStaff lists the owner, staff and open invitations of a class.
+// The owner and active staff can see the list.
+func (s *classService) GetClassStaff(classID string, userID uuid.UUID) ([]ClassStaffInfo, error) {
+	if s.staffRepo == nil {
+		return nil, errors.New("class staff repository not available")
+	}
+	class, err := s.classRepo.FindByID(classID)
+	if err != nil {
+		return nil, errors.New("class not found")
+	}
+	if s.classRole(class, userID) == "" {
+		return nil, errors.New("you don't have access to this class staff")
+	}
+
+	list, err := s.staffRepo.FindByClassID(classID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]ClassStaffInfo, 0, len(list)+1)
+	owner := ClassStaffInfo{
+		UserID:      class.GuruID,
+		Role:        entities.ClassStaffRoleOwner,
+		Status:      entities.ClassStaffStatusActive,
+		Permissions: ClassRolePermissions(entities.ClassStaffRoleOwner),
+	}
+	if user, err := s.userRepo.FindByID(class.GuruID.String()); err == nil {
+		owner.Email = user.Email
+		owner.FullName = user.FullName
+	}
+	result = append(result, owner)
+
+	for i := range list {
+		staff := list[i]
+		info := ClassStaffInfo{
+			ID:          &list[i].ID,
+			UserID:      staff.UserID,
+			Role:        staff.Role,
+			Status:      staff.Status,
+			Permissions: ClassRolePermissions(staff.Role),
+			AcceptedAt:  staff.AcceptedAt,
+		}
+		if staff.User != nil {
+			info.Email = staff.User.Email
+			info.FullName = staff.User.FullName
+		}
+		result = append(result, info)
+	}
+	return result, nil
+}
+
+// UpdateStaffRole changes the role of a staff member or invitation
+func (s *classService) UpdateStaffRole(classID string, ownerID uuid.UUID, staffUserID, role string) (*entities.ClassStaff, error) {
+	if _, err := s.staffClass(classID, ownerID); err != nil {
+		return nil, err
+	}
+	role, err := normalizeClassStaffRole(role)
+	if err != nil {
+		return nil, err
+	}
+	staff, err := s.staffRepo.FindByClassAndUser(classID, staffUserID)
+	if err != nil {
+		return nil, errors.New("staff not found")
+	}
+	if staff.Role == role {
+		return staff, nil
+	}
+	staff.Role = role
+	if err := s.staffRepo.Update(staff); err != nil {
+		return nil, err
+	}
+	return staff, nil
+}
+
+// RemoveStaff revokes an invitation or removes a staff member from the class
+func (s *classService) RemoveStaff(classID string, ownerID uuid.UUID, staffUserID string) error {
+	if _, err := s.staffClass(classID, ownerID); err != nil {
+		return err
+	}
+	staff, err := s.staffRepo.FindByClassAndUser(classID, staffUserID)
+	if err != nil {
+		return errors.New("staff not found")
+	}
+	if err := s.unassignGroupStaff(classID, staffUserID); err != nil {
+		return err
+	}
+	return s.staffRepo.Delete(staff.ID.String())
+}
+
+// GetMyStaffInvitations lists open staff invitations sent to the user
+func (s *classService) GetMyStaffInvitations(userID uuid.UUID) ([]ClassStaffInvitation, error) {
+	if s.staffRepo == nil {
+		return nil, errors.New("class staff repository not available")
+	}
+	pending, err := s.staffRepo.FindPendingByUser(userID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	inviterNames := make(map[uuid.UUID]string)
+	invitations := make([]ClassStaffInvitation, 0, len(pending))
+	for _, staff := range pending {
+		if staff.Class == nil {
+			continue
+		}
+		name, ok := inviterNames[staff.InvitedBy]
+		if !ok {
+			if inviter, err := s.userRepo.FindByID(staff.InvitedBy.String()); err == nil {
+				name = inviter.FullName
+			}
+			inviterNames[staff.InvitedBy] = name
+		}
+		invitations = append(invitations, ClassStaffInvitation{
+			ID:          staff.ID,
+			ClassID:     staff.ClassID,
+			ClassName:   staff.Class.Name,
+			Role:        staff.Role,
+			InvitedBy:   staff.InvitedBy,
+			InviterName: name,
+			CreatedAt:   staff.CreatedAt,
+		})
+	}
+	return invitations, nil
+}
+
+// RespondToStaffInvitation accepts or declines a staff invitation addressed
+// to the user. Declined invitations are deleted.
+func (s *classService) RespondToStaffInvitation(invitationID string, userID uuid.UUID, accept bool) (*entities.ClassStaff, error) {
+	if s.staffRepo == nil {
+		return nil, errors.New("class staff repository not available")
+	}
+	staff, err := s.staffRepo.FindByID(invitationID)
+	if err != nil || staff.UserID != userID {
+		return nil, errors.New("invitation not found")
+	}
+	if staff.Status != entities.ClassStaffStatusPending {
+		return nil, errors.New("invitation was already accepted")
+	}
+
+	if !accept {
+		return nil, s.staffRepo.Delete(invitationID)
+	}
+
+	now := time.Now().In(config.AppLocation)
+	staff.Status = entities.ClassStaffStatusActive
+	staff.AcceptedAt = &now
+	if err := s.staffRepo.Update(staff); err != nil {
+		return nil, err
+	}
+	return staff, nil
+}
+
+// TransferClassOwnership hands the class over to an active staff member. The
+// previous owner stays as co-teacher unless leave is set.
+func (s *classService) TransferClassOwnership(classID string, ownerID uuid.UUID, newOwnerID string, leave bool) (*entities.Class, error) {
+	class, err := s.staffClass(classID, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	staff, err := s.staffRepo.FindByClassAndUser(classID, newOwnerID)
+	if err != nil || staff.Status != entities.ClassStaffStatusActive {
+		return nil, errors.New("new owner must be an active staff member of this class")
+	}
+	if err := s.transferOwnership(class, staff, !leave); err != nil {
+		return nil, err
+	}
+	if err := s.enrichClassSummary(class); err != nil {
+		return nil, err
+	}
+	return class, nil
+}
+
+// transferOwnership makes staff the owner of the class. The staff row of the
+// new owner is removed; the previous owner keeps a co-teacher row if stay.
+// All of it is written in one transaction.
+func (s *classService) transferOwnership(class *entities.Class, staff *entities.ClassStaff, stay bool) error {
+	previousOwner := class.GuruID
+	var previousStaff *entities.ClassStaff
+	if stay {
+		now := time.Now().In(config.AppLocation)
+		previousStaff = &entities.ClassStaff{
+			ClassID:    class.ID,
+			UserID:     previousOwner,
+			Role:       entities.ClassStaffRoleCoTeacher,
+			Status:     entities.ClassStaffStatusActive,
+			InvitedBy:  staff.UserID,
+			AcceptedAt: &now,
+		}
+	}
+	class.GuruID = staff.UserID
+	if err := s.staffRepo.TransferOwnership(class, staff, previousOwner, previousStaff); err != nil {
+		class.GuruID = previousOwner
+		return err
+	}
+	return nil
+}
+
+// LeaveClassStaff removes the user from the staff of a class. When the owner
+// leaves, ownership passes to the longest-serving co-teacher; without one
+// the owner has to delete the class instead.
+func (s *classService) LeaveClassStaff(classID string, userID uuid.UUID) error {
+	if s.staffRepo == nil {
+		return errors.New("class staff repository not available")
+	}
+	class, err := s.classRepo.FindByID(classID)
+	if err != nil {
+		return errors.New("class not found")
+	}
+
+	if class.GuruID != userID {
+		staff, err := s.staffRepo.FindByClassAndUser(classID, userID.String())
+		if err != nil || staff.Status != entities.ClassStaffStatusActive {
+			return errors.New("you are not staff of this class")
+		}
+		if err := s.unassignGroupStaff(classID, userID.String()); err != nil {
+			return err
+		}
+		return s.staffRepo.Delete(staff.ID.String())
+	}
+
+	list, err := s.staffRepo.FindByClassID(classID)
+	if err != nil {
+		return err
+	}
+	var successor *entities.ClassStaff
+	for i := range list {
+		if list[i].Role != entities.ClassStaffRoleCoTeacher || list[i].Status != entities.ClassStaffStatusActive {
+			continue
+		}
+		if successor == nil || list[i].AcceptedAt != nil && successor.AcceptedAt != nil && list[i].AcceptedAt.Before(*successor.AcceptedAt) {
+			successor = &list[i]
+		}
+	}
+	if successor == nil {
+		return errors.New("the class has no co-teacher to take over; transfer ownership or delete the class")
+	}
+	return s.transferOwnership(class, successor, false)
+}
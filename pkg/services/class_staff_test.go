@@ -0,0 +1,135 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"hifzhun-api/pkg/config"
+	"hifzhun-api/pkg/entities"
+	"hifzhun-api/pkg/services"
+)
+
+func TestClassStaff(t *testing.T) {
+	env := newTestEnv(t)
+	userRepo := env.userRepo
+	classRepo := env.classRepo
+	svc := env.classSvc
+
+	head := &entities.User{Email: "ustadz@example.com", FullName: "Ustadz Ahmad", Role: "teacher"}
+	co := &entities.User{Email: "ustadz.hasan@example.com", FullName: "Ustadz Hasan", Role: "teacher"}
+	musyrif := &entities.User{Email: "musyrif@example.com", FullName: "Musyrif Bilal", Role: "teacher"}
+	ali := &entities.User{Email: "ali@example.com", FullName: "Ali", Role: "student"}
+	for _, u := range []*entities.User{head, co, musyrif, ali} {
+		if err := userRepo.Create(u); err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+	}
+	class := &entities.Class{GuruID: head.ID, Name: "Halaqah Subuh", ClassCode: "SUBUH", IsActive: true}
+	if err := classRepo.Create(class); err != nil {
+		t.Fatalf("create class: %v", err)
+	}
+	classID := class.ID.String()
+	if _, err := svc.JoinClass(ali.ID, "SUBUH"); err != nil {
+		t.Fatalf("join: %v", err)
+	}
+
+	if _, err := svc.InviteStaff(classID, head.ID, ali.Email, "assistant"); err == nil {
+		t.Error("invited a student as staff")
+	}
+	if _, err := svc.InviteStaff(classID, co.ID, musyrif.Email, "assistant"); err == nil {
+		t.Error("a non-owner invited staff")
+	}
+	if _, err := svc.InviteStaff(classID, head.ID, co.Email, "co_teacher"); err != nil {
+		t.Fatalf("invite co-teacher: %v", err)
+	}
+	if _, err := svc.InviteStaff(classID, head.ID, " Musyrif@Example.com ", ""); err != nil {
+		t.Fatalf("invite assistant: %v", err)
+	}
+
+	// A pending invitation grants nothing
+	if _, err := svc.GetClassMembers(classID, co.ID); err == nil {
+		t.Error("pending co-teacher viewed members")
+	}
+
+	invitations, err := svc.GetMyStaffInvitations(co.ID)
+	if err != nil || len(invitations) != 1 || invitations[0].ClassName != "Halaqah Subuh" || invitations[0].InviterName != "Ustadz Ahmad" {
+		t.Fatalf("co-teacher invitations = %+v (%v)", invitations, err)
+	}
+	if _, err := svc.RespondToStaffInvitation(invitations[0].ID.String(), musyrif.ID, true); err == nil {
+		t.Error("accepted someone else's invitation")
+	}
+	if _, err := svc.RespondToStaffInvitation(invitations[0].ID.String(), co.ID, true); err != nil {
+		t.Fatalf("accept: %v", err)
+	}
+	invitations, _ = svc.GetMyStaffInvitations(musyrif.ID)
+	if len(invitations) != 1 || invitations[0].Role != entities.ClassStaffRoleAssistant {
+		t.Fatalf("assistant invitations = %+v", invitations)
+	}
+	if _, err := svc.RespondToStaffInvitation(invitations[0].ID.String(), musyrif.ID, true); err != nil {
+		t.Fatalf("accept: %v", err)
+	}
+
+	// Assistants view progress but cannot manage the class
+	if _, err := svc.GetClassMembers(classID, musyrif.ID); err != nil {
+		t.Errorf("assistant members: %v", err)
+	}
+	due := time.Now().In(config.AppLocation).Add(48 * time.Hour)
+	assignment := services.AssignmentInput{Title: "An-Naba", ContentRef: "surah:78:1-20", DueAt: due}
+	if _, err := svc.CreateAssignment(classID, musyrif.ID, assignment); err == nil {
+		t.Error("assistant created an assignment")
+	}
+	if _, err := svc.UpdateGraduationPolicy(classID, musyrif.ID, services.GraduationPolicyInput{}); err == nil {
+		t.Error("assistant changed the graduation policy")
+	}
+	if _, err := svc.UpdateClass(classID, co.ID, "Renamed", "", "", nil); err == nil {
+		t.Error("co-teacher updated the class")
+	}
+	if _, err := svc.CreateAssignment(classID, co.ID, assignment); err != nil {
+		t.Errorf("co-teacher create assignment: %v", err)
+	}
+	minReviews := 7
+	if _, err := svc.UpdateGraduationPolicy(classID, co.ID, services.GraduationPolicyInput{MinReviews: &minReviews}); err != nil {
+		t.Errorf("co-teacher update policy: %v", err)
+	}
+	if _, err := svc.JoinClass(musyrif.ID, "SUBUH"); err == nil {
+		t.Error("staff joined as a student")
+	}
+
+	classes, err := svc.GetMyClasses(co.ID)
+	if err != nil || len(classes) != 1 || classes[0].MyRole != entities.ClassStaffRoleCoTeacher {
+		t.Fatalf("co-teacher classes = %+v (%v)", classes, err)
+	}
+
+	if _, err := svc.TransferClassOwnership(classID, head.ID, ali.ID.String(), false); err == nil {
+		t.Error("transferred ownership to a student")
+	}
+	transferred, err := svc.TransferClassOwnership(classID, head.ID, co.ID.String(), false)
+	if err != nil || transferred.GuruID != co.ID {
+		t.Fatalf("transfer: %v", err)
+	}
+	staff, err := svc.GetClassStaff(classID, head.ID)
+	if err != nil || len(staff) != 3 || staff[0].UserID != co.ID {
+		t.Fatalf("staff after transfer = %+v (%v)", staff, err)
+	}
+	if _, err := svc.InviteStaff(classID, head.ID, "other@example.com", ""); err == nil {
+		t.Error("previous owner still manages staff")
+	}
+
+	// The owner leaving hands the class to the co-teacher; with none left the owner must stay
+	if err := svc.LeaveClassStaff(classID, co.ID); err != nil {
+		t.Fatalf("owner leave: %v", err)
+	}
+	if reloaded, _ := classRepo.FindByID(classID); reloaded.GuruID != head.ID {
+		t.Errorf("ownership went to %s", reloaded.GuruID)
+	}
+	if err := svc.LeaveClassStaff(classID, head.ID); err == nil {
+		t.Error("owner left without a co-teacher to take over")
+	}
+
+	if err := svc.RemoveStaff(classID, head.ID, musyrif.ID.String()); err != nil {
+		t.Fatalf("remove staff: %v", err)
+	}
+	if _, err := svc.GetClassMembers(classID, musyrif.ID); err == nil {
+		t.Error("removed assistant still views members")
+	}
+}
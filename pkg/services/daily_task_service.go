@@ -34,6 +34,7 @@ type dailyTaskService struct {
 	classRepo       repositories.ClassRepository
 	juzRepo         *repositories.JuzRepository
 	juzItemRepo     *repositories.JuzItemRepository
+	policies        graduationPolicyResolver
 }
 
 func NewDailyTaskService(
@@ -44,6 +45,7 @@ func NewDailyTaskService(
 	classRepo repositories.ClassRepository,
 	juzRepo *repositories.JuzRepository,
 	juzItemRepo *repositories.JuzItemRepository,
+	policyRepo *repositories.ClassGraduationPolicyRepository,
 ) DailyTaskService {
 	return &dailyTaskService{
 		reviewStateRepo: reviewStateRepo,
@@ -53,29 +55,15 @@ func NewDailyTaskService(
 		classRepo:       classRepo,
 		juzRepo:         juzRepo,
 		juzItemRepo:     juzItemRepo,
+		policies: graduationPolicyResolver{
+			classRepo:       classRepo,
+			classMemberRepo: classMemberRepo,
+			juzItemRepo:     juzItemRepo,
+			policyRepo:      policyRepo,
+		},
 	}
 }
 
-func (s *dailyTaskService) isItemInActiveQuranClass(item *entities.Item, userID uuid.UUID) bool {
-	if item == nil || item.SourceType != "quran" || s.juzItemRepo == nil {
-		return false
-	}
-	infoByItemID, err := s.juzItemRepo.FindJuzInfoByItemIDs([]string{item.ID.String()})
-	if err != nil {
-		return false
-	}
-	info, exists := infoByItemID[item.ID.String()]
-	if !exists || info.ClassID == nil {
-		return false
-	}
-	class, err := s.classRepo.FindByID(*info.ClassID)
-	if err != nil || class.Type != entities.ClassTypeQuran || !class.IsActive {
-		return false
-	}
-	isMember, err := s.classMemberRepo.IsMember(class.ID.String(), userID.String())
-	return err == nil && isMember
-}
-
 func (s *dailyTaskService) GenerateToday(
 	ctx context.Context,
 	userID uuid.UUID,
@@ -92,45 +80,20 @@ func (s *dailyTaskService) GenerateToday(
 	// For now, book items stay in 'start' until user does first review
 
 	// ========== 0️⃣ Auto-graduation for FSRS items ==========
-	// Quran items: by days
-	eligibleItemsByDays, err := s.itemRepo.FindEligibleForGraduation(userID, entities.GraduationIntervalDays, now)
-	if err == nil {
-		for _, item := range eligibleItemsByDays {
-			// Skip book items - they have different graduation logic
-			if item.SourceType == "book" {
+	// Quran items: by days or stability, against the policy of their class
+	gradCandidates, err := s.itemRepo.FindGraduationCandidates(userID)
+	if err == nil && len(gradCandidates) > 0 {
+		graduations := s.policies.resolve(gradCandidates, userID)
+		for _, item := range gradCandidates {
+			graduation := graduations[item.ID]
+			if !graduation.Policy.Qualifies(&item, now) {
 				continue
 			}
-			if !qualifiesForGraduation(&item, now) {
-				continue
-			}
-			if s.isItemInActiveQuranClass(&item, userID) {
+			if graduation.NeedsApproval(&item) {
 				item.Status = entities.ItemStatusPendingGraduate
 			} else {
 				item.Status = entities.ItemStatusGraduate
-				nextRev := now.AddDate(0, 0, entities.GraduateReviewDays)
-				nextRev = time.Date(nextRev.Year(), nextRev.Month(), nextRev.Day(), 0, 0, 0, 0, nextRev.Location())
-				item.NextReviewAt = &nextRev
-			}
-			s.itemRepo.Update(&item)
-		}
-	}
-	// Quran items: by stability
-	eligibleItemsByStab, err := s.itemRepo.FindEligibleForGraduationByStability(userID, entities.GraduateStabilityThreshold)
-	if err == nil {
-		for _, item := range eligibleItemsByStab {
-			// Skip book items - they have different graduation logic
-			if item.SourceType == "book" {
-				continue
-			}
-			if !qualifiesForGraduation(&item, now) {
-				continue
-			}
-			if s.isItemInActiveQuranClass(&item, userID) {
-				item.Status = entities.ItemStatusPendingGraduate
-			} else {
-				item.Status = entities.ItemStatusGraduate
-				nextRev := now.AddDate(0, 0, entities.GraduateReviewDays)
-				nextRev = time.Date(nextRev.Year(), nextRev.Month(), nextRev.Day(), 0, 0, 0, 0, nextRev.Location())
+				nextRev := graduation.Policy.NextGraduateReview(now)
 				item.NextReviewAt = &nextRev
 			}
 			s.itemRepo.Update(&item)
@@ -227,6 +190,34 @@ func (s *dailyTaskService) GenerateToday(
 		})
 	}
 
+	// ========== 2.5️⃣ Retest yang dijadwalkan guru ==========
+	// Forced into today's tasks regardless of the FSRS schedule
+	retestItems, err := s.itemRepo.FindRetestDue(userID, now)
+	if err != nil {
+		return nil, err
+	}
+	if len(retestItems) > 0 {
+		scheduled := make(map[uuid.UUID]bool, len(tasks))
+		for _, t := range tasks {
+			scheduled[t.ItemID] = true
+		}
+		for _, item := range retestItems {
+			if scheduled[item.ID] {
+				continue
+			}
+			tasks = append(tasks, entities.DailyTask{
+				ID:        uuid.New(),
+				UserID:    userID,
+				ItemID:    item.ID,
+				CardID:    uuid.Nil,
+				TaskDate:  taskDate,
+				Source:    "retest",
+				State:     "pending",
+				CreatedAt: now,
+			})
+		}
+	}
+
 	// ========== 3️⃣ Cards dari FSRS Review State (existing logic) ==========
 	candidates, err := s.reviewStateRepo.FindDueByUser(
 		ctx,
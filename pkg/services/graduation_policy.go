@@ -4,14 +4,56 @@ import (
 	"time"
 
 	"hifzhun-api/pkg/entities"
+	"hifzhun-api/pkg/repositories"
+
+	"github.com/google/uuid"
 )
 
 // MinimumReviewsToGraduate is required regardless of whether graduation is
 // evaluated immediately after a review or while generating daily tasks.
 const MinimumReviewsToGraduate = 5
 
-func qualifiesForGraduation(item *entities.Item, now time.Time) bool {
-	if item == nil || item.Status != entities.ItemStatusFSRSActive || item.ReviewCount < MinimumReviewsToGraduate {
+// GraduationPolicy is the graduation standard items are evaluated against:
+// the policy of the class an item belongs to, or the default one.
+type GraduationPolicy struct {
+	MinReviews         int     `json:"min_reviews" example:"5"`
+	MinDays            int     `json:"min_days" example:"30"`
+	StabilityThreshold float64 `json:"stability_threshold" example:"30"`
+	RequireApproval    bool    `json:"require_approval" example:"true"`
+	GraduateReviewDays int     `json:"graduate_review_days" example:"20"`
+	IsDefault          bool    `json:"is_default"`
+}
+
+// DefaultGraduationPolicy is used for items outside a class and for classes
+// without their own policy.
+func DefaultGraduationPolicy() GraduationPolicy {
+	return GraduationPolicy{
+		MinReviews:         MinimumReviewsToGraduate,
+		MinDays:            entities.GraduationIntervalDays,
+		StabilityThreshold: entities.GraduateStabilityThreshold,
+		RequireApproval:    true,
+		GraduateReviewDays: entities.GraduateReviewDays,
+		IsDefault:          true,
+	}
+}
+
+func graduationPolicyFromEntity(p *entities.ClassGraduationPolicy) GraduationPolicy {
+	return GraduationPolicy{
+		MinReviews:         p.MinReviews,
+		MinDays:            p.MinDays,
+		StabilityThreshold: p.StabilityThreshold,
+		RequireApproval:    p.RequireApproval,
+		GraduateReviewDays: p.GraduateReviewDays,
+	}
+}
+
+// Qualifies reports whether an fsrs_active item meets the policy
+func (p GraduationPolicy) Qualifies(item *entities.Item, now time.Time) bool {
+	if item == nil || item.Status != entities.ItemStatusFSRSActive || item.ReviewCount < p.MinReviews {
+		return false
+	}
+	// A rejected graduation waits for the teacher's retest
+	if item.RetestAt != nil {
 		return false
 	}
 
@@ -22,6 +64,127 @@ func qualifiesForGraduation(item *entities.Item, now time.Time) bool {
 		daysInFSRSActive = int(now.Sub(*item.IntervalEndAt).Hours() / 24)
 	}
 
-	return daysInFSRSActive >= entities.GraduationIntervalDays ||
-		item.Stability >= entities.GraduateStabilityThreshold
+	return daysInFSRSActive >= p.MinDays ||
+		item.Stability >= p.StabilityThreshold
+}
+
+// NextGraduateReview is the next periodic review of a graduate Quran item
+func (p GraduationPolicy) NextGraduateReview(now time.Time) time.Time {
+	next := now.AddDate(0, 0, p.GraduateReviewDays)
+	return time.Date(next.Year(), next.Month(), next.Day(), 0, 0, 0, 0, next.Location())
+}
+
+// itemGraduation is the policy an item is evaluated against. InClass is set
+// when the item belongs to an active class its owner is a member of.
+type itemGraduation struct {
+	Policy  GraduationPolicy
+	InClass bool
+}
+
+// NeedsApproval reports whether a qualifying item waits for the teacher.
+// Only Quran classes have the approval queue.
+func (g itemGraduation) NeedsApproval(item *entities.Item) bool {
+	return g.InClass && item.SourceType == "quran" && g.Policy.RequireApproval
+}
+
+// graduationPolicyResolver finds the class of items (class juz for Quran
+// items, class book for book items) and its graduation policy.
+type graduationPolicyResolver struct {
+	classRepo       repositories.ClassRepository
+	classMemberRepo repositories.ClassMemberRepository
+	classBookRepo   repositories.ClassBookRepository
+	juzItemRepo     *repositories.JuzItemRepository
+	policyRepo      *repositories.ClassGraduationPolicyRepository
+}
+
+// resolve returns the graduation policy of each item owned by userID
+func (r graduationPolicyResolver) resolve(items []entities.Item, userID uuid.UUID) map[uuid.UUID]itemGraduation {
+	result := make(map[uuid.UUID]itemGraduation, len(items))
+	classByItem := make(map[uuid.UUID]string)
+
+	quranIDs := make([]string, 0, len(items))
+	for _, item := range items {
+		result[item.ID] = itemGraduation{Policy: DefaultGraduationPolicy()}
+		if item.SourceType == "quran" {
+			quranIDs = append(quranIDs, item.ID.String())
+		}
+	}
+	if r.classRepo == nil || r.classMemberRepo == nil {
+		return result
+	}
+
+	if len(quranIDs) > 0 && r.juzItemRepo != nil {
+		if classIDs, err := r.juzItemRepo.FindClassIDsByItemIDs(quranIDs); err == nil {
+			for itemID, classID := range classIDs {
+				if parsed, err := uuid.Parse(itemID); err == nil {
+					classByItem[parsed] = classID
+				}
+			}
+		}
+	}
+	if r.classBookRepo != nil {
+		classByBook := make(map[string][]string)
+		for _, item := range items {
+			if item.SourceType != "book" {
+				continue
+			}
+			bookID, ok := bookIDFromItemContentRef(item.ContentRef)
+			if !ok {
+				continue
+			}
+			classIDs, seen := classByBook[bookID]
+			if !seen {
+				classIDs, _ = r.classBookRepo.FindClassIDsByBookAndMember(bookID, userID.String())
+				classByBook[bookID] = classIDs
+			}
+			if len(classIDs) > 0 {
+				classByItem[item.ID] = classIDs[0]
+			}
+		}
+	}
+
+	// Only active classes the user is still a member of apply their policy
+	activeClasses := make(map[string]bool)
+	for _, classID := range classByItem {
+		if _, checked := activeClasses[classID]; checked {
+			continue
+		}
+		class, err := r.classRepo.FindByID(classID)
+		if err != nil || !class.IsActive {
+			activeClasses[classID] = false
+			continue
+		}
+		isMember, err := r.classMemberRepo.IsMember(classID, userID.String())
+		activeClasses[classID] = err == nil && isMember
+	}
+
+	policies := map[string]entities.ClassGraduationPolicy{}
+	if r.policyRepo != nil {
+		classIDs := make([]string, 0, len(activeClasses))
+		for classID, active := range activeClasses {
+			if active {
+				classIDs = append(classIDs, classID)
+			}
+		}
+		if found, err := r.policyRepo.FindByClassIDs(classIDs); err == nil {
+			policies = found
+		}
+	}
+
+	for itemID, classID := range classByItem {
+		if !activeClasses[classID] {
+			continue
+		}
+		graduation := itemGraduation{Policy: DefaultGraduationPolicy(), InClass: true}
+		if policy, ok := policies[classID]; ok {
+			graduation.Policy = graduationPolicyFromEntity(&policy)
+		}
+		result[itemID] = graduation
+	}
+	return result
+}
+
+// resolveOne returns the graduation policy of a single item
+func (r graduationPolicyResolver) resolveOne(item *entities.Item, userID uuid.UUID) itemGraduation {
+	return r.resolve([]entities.Item{*item}, userID)[item.ID]
 }
@@ -2,6 +2,7 @@ package services
 
 import (
 	"errors"
+	"strings"
 
 	"github.com/google/uuid"
 
@@ -104,8 +105,8 @@ func (s *HafalanService) AddItemToJuz(
 		}
 	}
 
-	// Validate content_ref against Quran data
-	if err := s.quranValidator.ValidateContentRef(mode, contentRef); err != nil {
+	// Validate content_ref against Quran data and the target juz
+	if err := s.quranValidator.ValidateContentRefInJuz(mode, contentRef, juz.Index); err != nil {
 		return nil, err
 	}
 
@@ -153,3 +154,72 @@ func (s *HafalanService) AddItemToJuz(
 		EstimatedReviewSeconds: item.EstimatedReviewSeconds,
 	}, nil
 }
+
+// ValidateItemContentRef checks that a new content_ref for an existing Quran
+// item still falls inside the juz the item is filed under.
+func (s *HafalanService) ValidateItemContentRef(itemID uuid.UUID, contentRef string) error {
+	juzIndex, err := s.juzItemRepo.FindJuzIndexByItemID(itemID.String())
+	if err != nil {
+		return err
+	}
+	if juzIndex == 0 {
+		return errors.New("item is not filed under any juz")
+	}
+
+	return s.quranValidator.ValidateContentRefInJuz(contentRefMode(contentRef), contentRef, juzIndex)
+}
+
+// MisfiledJuzItem describes a Quran item whose content_ref does not belong
+// to the juz it is filed under
+type MisfiledJuzItem struct {
+	ItemID      string       `json:"item_id"`
+	OwnerID     string       `json:"owner_id"`
+	JuzID       string       `json:"juz_id"`
+	JuzIndex    int          `json:"juz_index"`
+	ClassID     *string      `json:"class_id,omitempty"`
+	ContentRef  string       `json:"content_ref"`
+	ExpectedJuz []JuzSegment `json:"expected_juz,omitempty"`
+	Reason      string       `json:"reason"`
+}
+
+// FindMisfiledItems scans all Quran items and reports those whose surah ref
+// is outside (or crosses the boundary of) the juz they were added to.
+func (s *HafalanService) FindMisfiledItems() ([]MisfiledJuzItem, error) {
+	refs, err := s.juzItemRepo.FindAllQuranItemRefs()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]MisfiledJuzItem, 0)
+	for _, ref := range refs {
+		mode := contentRefMode(ref.ContentRef)
+		if mode != "surah" {
+			continue
+		}
+
+		err := s.quranValidator.ValidateContentRefInJuz(mode, ref.ContentRef, ref.JuzIndex)
+		if err == nil {
+			continue
+		}
+
+		segments, _ := s.quranValidator.SplitSurahRefByJuz(ref.ContentRef)
+		result = append(result, MisfiledJuzItem{
+			ItemID:      ref.ItemID,
+			OwnerID:     ref.OwnerID,
+			JuzID:       ref.JuzID,
+			JuzIndex:    ref.JuzIndex,
+			ClassID:     ref.ClassID,
+			ContentRef:  ref.ContentRef,
+			ExpectedJuz: segments,
+			Reason:      err.Error(),
+		})
+	}
+
+	return result, nil
+}
+
+// contentRefMode derives the add mode ("surah" | "page") from a content_ref
+func contentRefMode(contentRef string) string {
+	mode, _, _ := strings.Cut(contentRef, ":")
+	return mode
+}
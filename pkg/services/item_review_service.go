@@ -5,7 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 
@@ -20,8 +22,9 @@ type ItemReviewResult struct {
 	IntervalDays    int
 	NextReviewAt    *time.Time
 	Graduated       bool
-	PendingGraduate bool // true if waiting for teacher approval
-	ReviewCount     int  // total reviews for this item
+	PendingGraduate bool         // true if waiting for teacher approval
+	ReviewCount     int          // total reviews for this item
+	AnswerGrade     *AnswerGrade // set when the review came with a typed answer
 }
 
 type ItemReviewService struct {
@@ -32,6 +35,10 @@ type ItemReviewService struct {
 	classRepo           repositories.ClassRepository
 	classBookRepo       repositories.ClassBookRepository
 	juzItemRepo         *repositories.JuzItemRepository
+	bookItemRepo        repositories.BookItemRepository
+	overrideRepo        repositories.BookItemOverrideRepository
+	reviewLogRepo       repositories.ReviewLogRepository
+	policies            graduationPolicyResolver
 }
 
 func NewItemReviewService(
@@ -42,6 +49,10 @@ func NewItemReviewService(
 	classRepo repositories.ClassRepository,
 	classBookRepo repositories.ClassBookRepository,
 	juzItemRepo *repositories.JuzItemRepository,
+	bookItemRepo repositories.BookItemRepository,
+	overrideRepo repositories.BookItemOverrideRepository,
+	reviewLogRepo repositories.ReviewLogRepository,
+	policyRepo *repositories.ClassGraduationPolicyRepository,
 ) *ItemReviewService {
 	return &ItemReviewService{
 		itemRepo:            itemRepo,
@@ -51,31 +62,19 @@ func NewItemReviewService(
 		classRepo:           classRepo,
 		classBookRepo:       classBookRepo,
 		juzItemRepo:         juzItemRepo,
+		bookItemRepo:        bookItemRepo,
+		overrideRepo:        overrideRepo,
+		reviewLogRepo:       reviewLogRepo,
+		policies: graduationPolicyResolver{
+			classRepo:       classRepo,
+			classMemberRepo: classMemberRepo,
+			classBookRepo:   classBookRepo,
+			juzItemRepo:     juzItemRepo,
+			policyRepo:      policyRepo,
+		},
 	}
 }
 
-// isItemInActiveQuranClass only returns true for an item created in a specific,
-// active Quran class where the owner is still a member.
-func (s *ItemReviewService) isItemInActiveQuranClass(item *entities.Item, userID uuid.UUID) bool {
-	if item == nil || item.SourceType != "quran" || s.juzItemRepo == nil {
-		return false
-	}
-	infoByItemID, err := s.juzItemRepo.FindJuzInfoByItemIDs([]string{item.ID.String()})
-	if err != nil {
-		return false
-	}
-	info, exists := infoByItemID[item.ID.String()]
-	if !exists || info.ClassID == nil {
-		return false
-	}
-	class, err := s.classRepo.FindByID(*info.ClassID)
-	if err != nil || class.Type != entities.ClassTypeQuran || !class.IsActive {
-		return false
-	}
-	isMember, err := s.classMemberRepo.IsMember(class.ID.String(), userID.String())
-	return err == nil && isMember
-}
-
 func (s *ItemReviewService) canAccessBookItem(item *entities.Item, userID uuid.UUID) bool {
 	if item.SourceType != "book" {
 		return true
@@ -120,12 +119,88 @@ func (s *ItemReviewService) canAccessBookItem(item *entities.Item, userID uuid.U
 	return err == nil && allowedTeacher
 }
 
+// expectedAnswer is what a typed answer is compared with: the hidden spans
+// of the item's cloze for cloze items, otherwise the (override) answer.
+func expectedAnswer(bookItem *entities.BookItem, clozeIndex int) string {
+	if bookItem.Type == entities.BookItemTypeCloze {
+		if clozeIndex == 0 {
+			indices := ClozeIndices(bookItem.Content)
+			if len(indices) == 0 {
+				return ""
+			}
+			clozeIndex = indices[0]
+		}
+		_, hidden := RenderCloze(bookItem.Content, clozeIndex)
+		return strings.Join(hidden, " ")
+	}
+	return bookItem.Answer
+}
+
+// gradeItemAnswer compares typedAnswer with the answer the user sees for
+// the book item behind item.
+func (s *ItemReviewService) gradeItemAnswer(item *entities.Item, userID uuid.UUID, typedAnswer string) (*AnswerGrade, error) {
+	if item.SourceType != "book" || s.bookItemRepo == nil {
+		return nil, errors.New("typed answers are only supported for book items")
+	}
+	if strings.TrimSpace(typedAnswer) == "" {
+		return nil, errors.New("typed_answer is required")
+	}
+	if utf8.RuneCountInString(typedAnswer) > MaxTypedAnswerLength {
+		return nil, fmt.Errorf("typed_answer must be at most %d characters", MaxTypedAnswerLength)
+	}
+
+	parts := strings.Split(item.ContentRef, ":")
+	if len(parts) != 4 || parts[0] != "book" || parts[2] != "item" {
+		return nil, errors.New("book item not found")
+	}
+	bookItem, err := s.bookItemRepo.FindByID(parts[3])
+	if err != nil {
+		return nil, errors.New("book item not found")
+	}
+
+	resolved := ResolveBookItemContent(bookItem, &userID, s.overrideRepo)
+	expected := expectedAnswer(&resolved.BookItem, item.ClozeIndex)
+	if strings.TrimSpace(expected) == "" {
+		return nil, errors.New("this item has no answer to compare with")
+	}
+	return GradeTypedAnswer(expected, typedAnswer), nil
+}
+
+// CheckTypedAnswer grades a typed answer without reviewing the item, so the
+// client can show the diff before the user confirms a rating.
+func (s *ItemReviewService) CheckTypedAnswer(userID uuid.UUID, itemID uuid.UUID, typedAnswer string) (*AnswerGrade, error) {
+	item, err := s.itemRepo.GetByID(itemID)
+	if err != nil {
+		return nil, errors.New("item not found")
+	}
+	if item.OwnerID != userID {
+		return nil, errors.New("unauthorized")
+	}
+	if !s.canAccessBookItem(item, userID) {
+		return nil, errors.New("you don't have access to this book item")
+	}
+	return s.gradeItemAnswer(item, userID, typedAnswer)
+}
+
 func (s *ItemReviewService) ReviewItem(
 	userID uuid.UUID,
 	itemID uuid.UUID,
 	rating fsrs.Rating,
 	now time.Time,
 ) (*ItemReviewResult, error) {
+	return s.ReviewItemWithAnswer(userID, itemID, rating, "", now)
+}
+
+// ReviewItemWithAnswer reviews an item with an optional typed answer. The
+// answer is graded and stored in the review log; rating 0 takes the
+// suggested rating.
+func (s *ItemReviewService) ReviewItemWithAnswer(
+	userID uuid.UUID,
+	itemID uuid.UUID,
+	rating fsrs.Rating,
+	typedAnswer string,
+	now time.Time,
+) (*ItemReviewResult, error) {
 
 	// 1. Get item
 	item, err := s.itemRepo.GetByID(itemID)
@@ -141,10 +216,59 @@ func (s *ItemReviewService) ReviewItem(
 		return nil, errors.New("you don't have access to this book item")
 	}
 
-	// 3. Normalize legacy 'interval' status for quran items to 'fsrs_active'
-	if item.SourceType == "quran" && item.Status == entities.ItemStatusInterval {
+	return s.applyReview(item, userID, rating, typedAnswer, now, nil, nil)
+}
+
+// ReviewAssessedItem reviews a student's item with the grade a teacher gave
+// in a setoran. The caller checks that the teacher may assess the item.
+// save stores the reviewed item and its review log, so the caller can write
+// them in one transaction with the setoran.
+func (s *ItemReviewService) ReviewAssessedItem(
+	teacherID uuid.UUID,
+	studentID uuid.UUID,
+	itemID uuid.UUID,
+	rating fsrs.Rating,
+	now time.Time,
+	save func(item *entities.Item, reviewLog *entities.ReviewLog) error,
+) (*ItemReviewResult, error) {
+	item, err := s.itemRepo.GetByID(itemID)
+	if err != nil {
+		return nil, errors.New("item not found")
+	}
+	if item.OwnerID != studentID {
+		return nil, errors.New("item does not belong to this student")
+	}
+	if !s.canAccessBookItem(item, studentID) {
+		return nil, errors.New("the student doesn't have access to this book item")
+	}
+	return s.applyReview(item, studentID, rating, "", now, &teacherID, save)
+}
+
+// applyReview runs an FSRS review on an item the caller already checked.
+// assessedBy is the teacher for setoran reviews: the grade is the teacher's,
+// the item may be heard before its next review date, and a quran item still
+// being memorized enters FSRS. save replaces the default saving of the item
+// and its review log when set.
+func (s *ItemReviewService) applyReview(
+	item *entities.Item,
+	userID uuid.UUID,
+	rating fsrs.Rating,
+	typedAnswer string,
+	now time.Time,
+	assessedBy *uuid.UUID,
+	save func(item *entities.Item, reviewLog *entities.ReviewLog) error,
+) (*ItemReviewResult, error) {
+	itemID := item.ID
+	var err error
+
+	// 3. Normalize legacy 'interval' status for quran items to 'fsrs_active'.
+	// A teacher hearing a setoran also moves a quran item out of menghafal.
+	heardInMenghafal := assessedBy != nil && (item.Status == entities.ItemStatusStart || item.Status == entities.ItemStatusMenghafal)
+	if item.SourceType == "quran" && (item.Status == entities.ItemStatusInterval || heardInMenghafal) {
+		if item.Status == entities.ItemStatusInterval {
+			item.IntervalEndAt = &now
+		}
 		item.Status = entities.ItemStatusFSRSActive
-		item.IntervalEndAt = &now
 		if item.FSRSStartAt == nil {
 			if item.IntervalStartAt != nil {
 				item.FSRSStartAt = item.IntervalStartAt
@@ -162,7 +286,17 @@ func (s *ItemReviewService) ReviewItem(
 		}
 	}
 
-	// 4. Validate rating
+	// 4. Grade typed answer, then validate rating
+	var grade *AnswerGrade
+	if typedAnswer != "" {
+		grade, err = s.gradeItemAnswer(item, userID, typedAnswer)
+		if err != nil {
+			return nil, err
+		}
+		if rating == 0 {
+			rating = fsrs.Rating(grade.SuggestedRating)
+		}
+	}
 	if rating < fsrs.Again || rating > fsrs.Easy {
 		return nil, errors.New("invalid rating (1-4)")
 	}
@@ -170,8 +304,10 @@ func (s *ItemReviewService) ReviewItem(
 	// 5. Check if first review
 	isFirstReview := item.LastReviewAt == nil
 
-	// 6. Check if review is allowed (must be first review OR now >= next_review_at)
-	if !isFirstReview && item.NextReviewAt != nil {
+	// 6. Check if review is allowed (must be first review OR now >= next_review_at).
+	// A teacher can hear a setoran at any time, and a retest is due on its date.
+	retestDue := item.RetestAt != nil && !now.Before(*item.RetestAt)
+	if !isFirstReview && item.NextReviewAt != nil && assessedBy == nil && !retestDue {
 		if now.Before(*item.NextReviewAt) {
 			return nil, fmt.Errorf("review not allowed yet, next review at: %s", item.NextReviewAt.Format("2006-01-02 15:04"))
 		}
@@ -208,6 +344,7 @@ func (s *ItemReviewService) ReviewItem(
 
 	// 10. Run FSRS review
 	result := fsrs.Review(prevState, rating, now, weights)
+	stabilityBefore, difficultyBefore := item.Stability, item.Difficulty
 
 	// 11. Update item with new FSRS state
 	item.Stability = result.NewState.Stability
@@ -220,6 +357,9 @@ func (s *ItemReviewService) ReviewItem(
 	}
 	item.ReviewCount++
 	item.LastReviewAt = &now
+	if retestDue {
+		item.RetestAt = nil
+	}
 
 	intervalDays := int(result.Interval.Hours() / 24)
 	// Normalize next review time to 00:00:00
@@ -242,15 +382,12 @@ func (s *ItemReviewService) ReviewItem(
 		}
 	}
 
-	if qualifiesForGraduation(item, now) {
-		if item.SourceType == "quran" {
-			if s.isItemInActiveQuranClass(item, userID) {
-				item.Status = entities.ItemStatusPendingGraduate
-				pendingGraduate = true
-			} else {
-				item.Status = entities.ItemStatusGraduate
-				graduated = true
-			}
+	// Graduation follows the policy of the item's class
+	graduation := s.policies.resolveOne(item, userID)
+	if graduation.Policy.Qualifies(item, now) {
+		if graduation.NeedsApproval(item) {
+			item.Status = entities.ItemStatusPendingGraduate
+			pendingGraduate = true
 		} else {
 			item.Status = entities.ItemStatusGraduate
 			graduated = true
@@ -260,23 +397,51 @@ func (s *ItemReviewService) ReviewItem(
 	if item.Status == entities.ItemStatusGraduate {
 		if item.SourceType == "quran" {
 			// Quran: schedule periodic post-graduation review
-			graduateNextReview := now.AddDate(0, 0, entities.GraduateReviewDays)
-			graduateNextReview = time.Date(graduateNextReview.Year(), graduateNextReview.Month(), graduateNextReview.Day(), 0, 0, 0, 0, graduateNextReview.Location())
+			graduateNextReview := graduation.Policy.NextGraduateReview(now)
 			item.NextReviewAt = &graduateNextReview
 			nextReview = graduateNextReview
-			intervalDays = entities.GraduateReviewDays
+			intervalDays = graduation.Policy.GraduateReviewDays
 		} else {
 			// Book: no further review after graduation
 			item.NextReviewAt = nil
 		}
 	}
 
-	// 14. Save item
-	if err := s.itemRepo.Update(item); err != nil {
-		return nil, err
+	// 14. Review log
+	reviewLog := &entities.ReviewLog{
+		ID:               uuid.New(),
+		UserID:           userID,
+		ItemID:           item.ID,
+		ReviewedAt:       now,
+		Rating:           int(rating),
+		StabilityBefore:  stabilityBefore,
+		DifficultyBefore: difficultyBefore,
+		StabilityAfter:   item.Stability,
+		DifficultyAfter:  item.Difficulty,
+		IntervalDays:     intervalDays,
+		AssessedBy:       assessedBy,
+	}
+	if grade != nil {
+		reviewLog.TypedAnswer = typedAnswer
+		reviewLog.SuggestedRating = grade.SuggestedRating
+		reviewLog.AnswerSimilarity = grade.Similarity
+	}
+
+	// 15. Save item and review log (a failed review log does not undo the review)
+	if save != nil {
+		if err := save(item, reviewLog); err != nil {
+			return nil, err
+		}
+	} else {
+		if err := s.itemRepo.Update(item); err != nil {
+			return nil, err
+		}
+		if s.reviewLogRepo != nil {
+			_ = s.reviewLogRepo.Create(context.Background(), reviewLog)
+		}
 	}
 
-	// 15. Mark daily task as done (ignore error if not found)
+	// 16. Mark daily task as done (ignore error if not found)
 	taskDate := utils.NormalizeDate(now)
 	if s.dailyTaskActionRepo != nil {
 		_ = s.dailyTaskActionRepo.UpdateStateByItemID(
@@ -295,5 +460,6 @@ func (s *ItemReviewService) ReviewItem(
 		Graduated:       graduated,
 		PendingGraduate: pendingGraduate,
 		ReviewCount:     item.ReviewCount,
+		AnswerGrade:     grade,
 	}, nil
 }
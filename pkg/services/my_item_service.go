@@ -42,6 +42,7 @@ type QuranItemDetail struct {
 type BookItemDetail struct {
 	MyItemDetail
 	BookItemTitle string `json:"book_item_title,omitempty"`
+	ClozeIndex    int    `json:"cloze_index,omitempty"`
 }
 
 type QuranGroup struct {
@@ -272,6 +273,7 @@ func (s *MyItemService) GetMyBookItems(userID uuid.UUID) (*MyItemsBookResponse,
 				CreatedAt:    item.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 			},
 			BookItemTitle: resolvedTitle,
+			ClozeIndex:    item.ClozeIndex,
 		})
 	}
 
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"hifzhun-api/pkg/config"
+	"hifzhun-api/pkg/entities"
+	"hifzhun-api/pkg/repositories"
+
+	"github.com/google/uuid"
+)
+
+// NotificationService keeps the in-app notification inbox of each user
+type NotificationService struct {
+	repo *repositories.NotificationRepository
+}
+
+func NewNotificationService(repo *repositories.NotificationRepository) *NotificationService {
+	return &NotificationService{repo: repo}
+}
+
+// Notify sends a copy of n to each user
+func (s *NotificationService) Notify(userIDs []uuid.UUID, n entities.Notification) error {
+	now := time.Now().In(config.AppLocation)
+	list := make([]entities.Notification, 0, len(userIDs))
+	seen := make(map[uuid.UUID]bool, len(userIDs))
+	for _, id := range userIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		msg := n
+		msg.UserID = id
+		msg.ReadAt = nil
+		msg.CreatedAt = now
+		list = append(list, msg)
+	}
+	return s.repo.CreateBatch(list)
+}
+
+// notify sends a notification when the service is wired. A failed
+// notification is logged and never fails the action that caused it.
+func notify(svc *NotificationService, userIDs []uuid.UUID, n entities.Notification) {
+	if svc == nil || len(userIDs) == 0 {
+		return
+	}
+	if err := svc.Notify(userIDs, n); err != nil {
+		log.Printf("⚠️ Failed to send %s notification: %v", n.Type, err)
+	}
+}
+
+// GetNotifications lists a user's notifications, newest first
+func (s *NotificationService) GetNotifications(userID uuid.UUID, unreadOnly bool, page, perPage int) ([]entities.Notification, int64, error) {
+	return s.repo.FindByUser(userID.String(), unreadOnly, perPage, (page-1)*perPage)
+}
+
+// UnreadCount returns the number of unread notifications of a user
+func (s *NotificationService) UnreadCount(userID uuid.UUID) (int64, error) {
+	return s.repo.CountUnread(userID.String())
+}
+
+// MarkRead marks one of the user's notifications as read
+func (s *NotificationService) MarkRead(userID uuid.UUID, notificationID string) error {
+	if _, err := uuid.Parse(notificationID); err != nil {
+		return errors.New("invalid notification ID")
+	}
+	found, err := s.repo.MarkRead(notificationID, userID.String(), time.Now().In(config.AppLocation))
+	if err != nil {
+		return err
+	}
+	if !found {
+		return errors.New("notification not found")
+	}
+	return nil
+}
+
+// MarkAllRead marks every unread notification of the user as read and
+// returns how many there were.
+func (s *NotificationService) MarkAllRead(userID uuid.UUID) (int64, error) {
+	return s.repo.MarkAllRead(userID.String(), time.Now().In(config.AppLocation))
+}
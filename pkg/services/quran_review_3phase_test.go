@@ -122,6 +122,7 @@ func TestLegacyQuranPersonalIntervalReviewFlow(t *testing.T) {
 		nil,
 		juzRepo,
 		juzItemRepo,
+		nil,
 	)
 
 	tasks, err := dailyService.GenerateToday(context.Background(), userID, now, 0)
@@ -151,6 +152,10 @@ func TestLegacyQuranPersonalIntervalReviewFlow(t *testing.T) {
 		nil,
 		nil,
 		juzItemRepo,
+		nil,
+		nil,
+		nil,
+		nil,
 	)
 
 	reviewResult, err := reviewService.ReviewItem(userID, item.ID, fsrs.Good, now)
@@ -210,7 +215,7 @@ func TestNewQuranPersonalActivationAndReview(t *testing.T) {
 	}
 
 	// Review item
-	reviewService := services.NewItemReviewService(itemRepo, nil, nil, nil, nil, nil, juzItemRepo)
+	reviewService := services.NewItemReviewService(itemRepo, nil, nil, nil, nil, nil, juzItemRepo, nil, nil, nil, nil)
 	res, err := reviewService.ReviewItem(userID, item.ID, fsrs.Good, now.AddDate(0, 0, 1))
 	if err != nil {
 		t.Fatalf("ReviewItem error: %v", err)
@@ -11,14 +11,39 @@ import (
 
 // SurahInfo holds data from surah.json
 type SurahInfo struct {
+	Index string         `json:"index"`
+	Title string         `json:"title"`
+	Count int            `json:"count"`
+	Juz   []SurahJuzSpan `json:"juz"`
+}
+
+// SurahJuzSpan is one juz segment of a surah as listed in surah.json,
+// e.g. {"index": "02", "verse": {"start": "verse_142", "end": "verse_252"}}
+type SurahJuzSpan struct {
 	Index string `json:"index"`
-	Title string `json:"title"`
-	Count int    `json:"count"`
+	Verse struct {
+		Start string `json:"start"`
+		End   string `json:"end"`
+	} `json:"verse"`
+}
+
+// juzSpan is the parsed form of SurahJuzSpan
+type juzSpan struct {
+	juz   int
+	start int
+	end   int
+}
+
+// JuzSegment is the part of a content_ref that falls inside a single juz
+type JuzSegment struct {
+	JuzIndex   int    `json:"juz_index"`
+	ContentRef string `json:"content_ref"`
 }
 
 // QuranValidator validates content_ref against Quran data
 type QuranValidator struct {
-	surahs map[int]SurahInfo // key: surah number (1-114)
+	surahs   map[int]SurahInfo // key: surah number (1-114)
+	juzSpans map[int][]juzSpan // key: surah number, ordered by start verse
 }
 
 // NewQuranValidator loads surah data from JSON file
@@ -34,12 +59,23 @@ func NewQuranValidator(surahJSONPath string) (*QuranValidator, error) {
 	}
 
 	surahs := make(map[int]SurahInfo)
+	juzSpans := make(map[int][]juzSpan)
 	for _, s := range surahList {
 		idx, _ := strconv.Atoi(s.Index)
 		surahs[idx] = s
+
+		for _, j := range s.Juz {
+			juzIdx, err1 := strconv.Atoi(j.Index)
+			start, err2 := strconv.Atoi(strings.TrimPrefix(j.Verse.Start, "verse_"))
+			end, err3 := strconv.Atoi(strings.TrimPrefix(j.Verse.End, "verse_"))
+			if err1 != nil || err2 != nil || err3 != nil {
+				return nil, fmt.Errorf("invalid juz span for surah %s in surah.json", s.Index)
+			}
+			juzSpans[idx] = append(juzSpans[idx], juzSpan{juz: juzIdx, start: start, end: end})
+		}
 	}
 
-	return &QuranValidator{surahs: surahs}, nil
+	return &QuranValidator{surahs: surahs, juzSpans: juzSpans}, nil
 }
 
 // ValidateContentRef validates content_ref format: "surah:78:1-5"
@@ -56,58 +92,125 @@ func (v *QuranValidator) ValidateContentRef(mode, contentRef string) error {
 	return nil
 }
 
+// ValidateContentRefInJuz validates content_ref and makes sure it lies entirely
+// inside the given juz. Refs that cross a juz boundary are rejected with the
+// per-juz refs the user should add instead.
+func (v *QuranValidator) ValidateContentRefInJuz(mode, contentRef string, juzIndex int) error {
+	if err := v.ValidateContentRef(mode, contentRef); err != nil {
+		return err
+	}
+
+	// Page refs have no juz data yet; nothing more to check
+	if mode != "surah" {
+		return nil
+	}
+
+	segments, err := v.SplitSurahRefByJuz(contentRef)
+	if err != nil {
+		return err
+	}
+
+	if len(segments) == 1 {
+		if segments[0].JuzIndex != juzIndex {
+			return fmt.Errorf("%s belongs to juz %d, not juz %d", contentRef, segments[0].JuzIndex, juzIndex)
+		}
+		return nil
+	}
+
+	parts := make([]string, 0, len(segments))
+	for _, seg := range segments {
+		parts = append(parts, fmt.Sprintf("%s (juz %d)", seg.ContentRef, seg.JuzIndex))
+	}
+	return fmt.Errorf("%s crosses juz boundaries, add it as separate items: %s", contentRef, strings.Join(parts, ", "))
+}
+
+// SplitSurahRefByJuz splits a surah ref into one segment per juz it touches.
+// A ref that lies inside a single juz returns exactly one segment.
+func (v *QuranValidator) SplitSurahRefByJuz(contentRef string) ([]JuzSegment, error) {
+	surahNum, startVerse, endVerse, err := v.parseSurahRef(contentRef)
+	if err != nil {
+		return nil, err
+	}
+
+	spans := v.juzSpans[surahNum]
+	if len(spans) == 0 {
+		return nil, fmt.Errorf("juz data for surah %d not found", surahNum)
+	}
+
+	var segments []JuzSegment
+	for _, span := range spans {
+		from := max(startVerse, span.start)
+		to := min(endVerse, span.end)
+		if from > to {
+			continue
+		}
+		segments = append(segments, JuzSegment{
+			JuzIndex:   span.juz,
+			ContentRef: fmt.Sprintf("surah:%d:%d-%d", surahNum, from, to),
+		})
+	}
+
+	return segments, nil
+}
+
 // validateSurahRef validates format "surah:78:1-5"
 func (v *QuranValidator) validateSurahRef(contentRef string) error {
+	_, _, _, err := v.parseSurahRef(contentRef)
+	return err
+}
+
+// parseSurahRef parses and validates "surah:SURAH_NUM:START-END"
+func (v *QuranValidator) parseSurahRef(contentRef string) (int, int, int, error) {
 	// Expected format: surah:SURAH_NUM:START-END
 	parts := strings.Split(contentRef, ":")
 	if len(parts) != 3 {
-		return errors.New("invalid content_ref format, expected: surah:SURAH_NUM:START-END")
+		return 0, 0, 0, errors.New("invalid content_ref format, expected: surah:SURAH_NUM:START-END")
 	}
 
 	if parts[0] != "surah" {
-		return errors.New("content_ref must start with 'surah:'")
+		return 0, 0, 0, errors.New("content_ref must start with 'surah:'")
 	}
 
 	// Parse surah number
 	surahNum, err := strconv.Atoi(parts[1])
 	if err != nil {
-		return errors.New("invalid surah number")
+		return 0, 0, 0, errors.New("invalid surah number")
 	}
 
 	// Check if surah exists
 	surah, exists := v.surahs[surahNum]
 	if !exists {
-		return fmt.Errorf("surah %d not found (valid: 1-114)", surahNum)
+		return 0, 0, 0, fmt.Errorf("surah %d not found (valid: 1-114)", surahNum)
 	}
 
 	// Parse verse range (e.g., "1-5")
 	verseRange := strings.Split(parts[2], "-")
 	if len(verseRange) != 2 {
-		return errors.New("invalid verse range format, expected: START-END")
+		return 0, 0, 0, errors.New("invalid verse range format, expected: START-END")
 	}
 
 	startVerse, err := strconv.Atoi(verseRange[0])
 	if err != nil {
-		return errors.New("invalid start verse number")
+		return 0, 0, 0, errors.New("invalid start verse number")
 	}
 
 	endVerse, err := strconv.Atoi(verseRange[1])
 	if err != nil {
-		return errors.New("invalid end verse number")
+		return 0, 0, 0, errors.New("invalid end verse number")
 	}
 
 	// Validate verse range
 	if startVerse < 1 {
-		return errors.New("start verse must be at least 1")
+		return 0, 0, 0, errors.New("start verse must be at least 1")
 	}
 
 	if startVerse > endVerse {
-		return errors.New("start verse cannot be greater than end verse")
+		return 0, 0, 0, errors.New("start verse cannot be greater than end verse")
 	}
 
 	if endVerse > surah.Count {
-		return fmt.Errorf("verse %d exceeds surah %s verse count (%d)", endVerse, surah.Title, surah.Count)
+		return 0, 0, 0, fmt.Errorf("verse %d exceeds surah %s verse count (%d)", endVerse, surah.Title, surah.Count)
 	}
 
-	return nil
+	return surahNum, startVerse, endVerse, nil
 }
@@ -0,0 +1,53 @@
+package services_test
+
+import (
+	"strings"
+	"testing"
+
+	"hifzhun-api/pkg/services"
+)
+
+func TestQuranValidatorJuzPlacement(t *testing.T) {
+	v, err := services.NewQuranValidator("../../data/surah.json")
+	if err != nil {
+		t.Fatalf("failed to load surah.json: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		contentRef string
+		juzIndex   int
+		wantErr    string
+	}{
+		{name: "An-Naba inside juz 30", contentRef: "surah:78:1-5", juzIndex: 30},
+		{name: "Al-Baqarah start inside juz 1", contentRef: "surah:2:1-5", juzIndex: 1},
+		{name: "Al-Baqarah filed under juz 30", contentRef: "surah:2:1-5", juzIndex: 30, wantErr: "belongs to juz 1"},
+		{name: "Al-Baqarah across juz 1 and 2", contentRef: "surah:2:140-143", juzIndex: 1, wantErr: "crosses juz boundaries"},
+		{name: "verse out of range", contentRef: "surah:1:1-8", juzIndex: 1, wantErr: "exceeds"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.ValidateContentRefInJuz("surah", tt.contentRef, tt.juzIndex)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	segments, err := v.SplitSurahRefByJuz("surah:2:140-143")
+	if err != nil {
+		t.Fatalf("unexpected split error: %v", err)
+	}
+	if len(segments) != 2 ||
+		segments[0].JuzIndex != 1 || segments[0].ContentRef != "surah:2:140-141" ||
+		segments[1].JuzIndex != 2 || segments[1].ContentRef != "surah:2:142-143" {
+		t.Errorf("unexpected segments: %+v", segments)
+	}
+}
@@ -0,0 +1,172 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"hifzhun-api/pkg/config"
+	"hifzhun-api/pkg/repositories"
+	"hifzhun-api/pkg/services"
+)
+
+// setupTestSQLiteDB opens an in-memory sqlite database with every table of
+// config.Models. Unlike Postgres, sqlite ignores row locks.
+func setupTestSQLiteDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	config.InitAppLocation()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(config.Models...); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+// testEnv holds the repositories and services of a test database, wired the
+// way main.go wires them
+type testEnv struct {
+	db *gorm.DB
+
+	userRepo         repositories.UserRepository
+	classRepo        repositories.ClassRepository
+	memberRepo       repositories.ClassMemberRepository
+	classBookRepo    repositories.ClassBookRepository
+	itemRepo         *repositories.ItemRepository
+	juzRepo          *repositories.JuzRepository
+	juzItemRepo      *repositories.JuzItemRepository
+	reviewLogRepo    repositories.ReviewLogRepository
+	policyRepo       *repositories.ClassGraduationPolicyRepository
+	bookRepo         repositories.BookRepository
+	bookModuleRepo   repositories.BookModuleRepository
+	bookItemRepo     repositories.BookItemRepository
+	overrideRepo     repositories.BookItemOverrideRepository
+	revisionRepo     *repositories.BookRevisionRepository
+	purgeLogRepo     *repositories.BookPurgeLogRepository
+	collaboratorRepo *repositories.BookCollaboratorRepository
+	assignmentRepo   *repositories.ClassAssignmentRepository
+	setoranRepo      *repositories.SetoranRepository
+	announcementRepo *repositories.ClassAnnouncementRepository
+
+	authSvc       services.AuthService
+	notifications *services.NotificationService
+	dailyTaskSvc  services.DailyTaskService
+	reviewSvc     *services.ItemReviewService
+	bookSvc       services.BookService
+	classSvc      services.ClassService
+}
+
+// newTestEnv opens a fresh sqlite database and wires the services over it
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	db := setupTestSQLiteDB(t)
+	validator, err := services.NewQuranValidator("../../data/surah.json")
+	if err != nil {
+		t.Fatalf("validator: %v", err)
+	}
+
+	e := &testEnv{
+		db:               db,
+		userRepo:         repositories.NewUserRepository(db),
+		classRepo:        repositories.NewClassRepository(db),
+		memberRepo:       repositories.NewClassMemberRepository(db),
+		classBookRepo:    repositories.NewClassBookRepository(db),
+		itemRepo:         repositories.NewItemRepository(db),
+		juzRepo:          repositories.NewJuzRepository(db),
+		juzItemRepo:      repositories.NewJuzItemRepository(db),
+		reviewLogRepo:    repositories.NewReviewLogRepository(db),
+		policyRepo:       repositories.NewClassGraduationPolicyRepository(db),
+		bookRepo:         repositories.NewBookRepository(db),
+		bookModuleRepo:   repositories.NewBookModuleRepository(db),
+		bookItemRepo:     repositories.NewBookItemRepository(db),
+		overrideRepo:     repositories.NewBookItemOverrideRepository(db),
+		revisionRepo:     repositories.NewBookRevisionRepository(db),
+		purgeLogRepo:     repositories.NewBookPurgeLogRepository(db),
+		collaboratorRepo: repositories.NewBookCollaboratorRepository(db),
+		assignmentRepo:   repositories.NewClassAssignmentRepository(db),
+		setoranRepo:      repositories.NewSetoranRepository(db),
+		announcementRepo: repositories.NewClassAnnouncementRepository(db),
+		authSvc:          services.NewAuthService(),
+	}
+	e.notifications = services.NewNotificationService(repositories.NewNotificationRepository(db))
+
+	reviewStateRepo := repositories.NewReviewStateRepository(db)
+	dailyTaskRepo := repositories.NewDailyTaskRepository(db)
+	dailyTaskActionRepo := repositories.NewDailyTaskActionRepository(db)
+	e.dailyTaskSvc = services.NewDailyTaskService(
+		reviewStateRepo,
+		dailyTaskRepo,
+		e.itemRepo,
+		e.memberRepo,
+		e.classRepo,
+		e.juzRepo,
+		e.juzItemRepo,
+		e.policyRepo,
+	)
+	e.reviewSvc = services.NewItemReviewService(
+		e.itemRepo,
+		repositories.NewFSRSWeightsRepository(db),
+		dailyTaskActionRepo,
+		e.memberRepo,
+		e.classRepo,
+		e.classBookRepo,
+		e.juzItemRepo,
+		e.bookItemRepo,
+		e.overrideRepo,
+		e.reviewLogRepo,
+		e.policyRepo,
+	)
+	e.bookSvc = services.NewBookService(
+		e.bookRepo,
+		e.bookModuleRepo,
+		e.bookItemRepo,
+		e.classBookRepo,
+		e.itemRepo,
+		e.userRepo,
+		repositories.NewBookUpdateRequestRepository(db),
+		e.overrideRepo,
+		e.revisionRepo,
+		repositories.NewBookReviewRepository(db),
+		repositories.NewBookPublishRequestRepository(db),
+		e.purgeLogRepo,
+		e.collaboratorRepo,
+		repositories.NewBookActivityRepository(db),
+		e.notifications,
+	)
+	e.classSvc = services.NewClassService(services.ClassServiceDeps{
+		ClassRepo:        e.classRepo,
+		ClassMemberRepo:  e.memberRepo,
+		ClassBookRepo:    e.classBookRepo,
+		BookRepo:         e.bookRepo,
+		UserRepo:         e.userRepo,
+		ItemRepo:         e.itemRepo,
+		JuzRepo:          e.juzRepo,
+		JuzItemRepo:      e.juzItemRepo,
+		DailyTaskRepo:    dailyTaskRepo,
+		DailyTaskSvc:     e.dailyTaskSvc,
+		AssignmentRepo:   e.assignmentRepo,
+		BookModuleRepo:   e.bookModuleRepo,
+		BookItemRepo:     e.bookItemRepo,
+		QuranValidator:   validator,
+		BookSvc:          e.bookSvc,
+		SetoranRepo:      e.setoranRepo,
+		ReviewSvc:        e.reviewSvc,
+		GraduationRepo:   repositories.NewItemGraduationRepository(db),
+		PolicyRepo:       e.policyRepo,
+		StaffRepo:        repositories.NewClassStaffRepository(db),
+		JoinRequestRepo:  repositories.NewClassJoinRequestRepository(db),
+		InvitationRepo:   repositories.NewClassInvitationRepository(db),
+		BanRepo:          repositories.NewClassBanRepository(db),
+		AuthSvc:          e.authSvc,
+		GroupRepo:        repositories.NewClassGroupRepository(db),
+		AnnouncementRepo: e.announcementRepo,
+		Notifier:         e.notifications,
+		ReviewLogRepo:    e.reviewLogRepo,
+		IntervalLogRepo:  repositories.NewIntervalReviewLogRepository(db),
+	})
+	return e
+}
@@ -28,15 +28,18 @@ type TeacherRequestService interface {
 type teacherRequestService struct {
 	teacherReqRepo repositories.TeacherRequestRepository
 	userRepo       repositories.UserRepository
+	notifier       *NotificationService
 }
 
 func NewTeacherRequestService(
 	teacherReqRepo repositories.TeacherRequestRepository,
 	userRepo repositories.UserRepository,
+	notifier *NotificationService,
 ) TeacherRequestService {
 	return &teacherRequestService{
 		teacherReqRepo: teacherReqRepo,
 		userRepo:       userRepo,
+		notifier:       notifier,
 	}
 }
 
@@ -127,7 +130,17 @@ func (s *teacherRequestService) ApproveRequest(id string) error {
 		return err
 	}
 
-	return s.userRepo.ActivateUser(req.UserID.String())
+	if err := s.userRepo.ActivateUser(req.UserID.String()); err != nil {
+		return err
+	}
+
+	notify(s.notifier, []uuid.UUID{req.UserID}, entities.Notification{
+		Type:  entities.NotificationTypeTeacherRequestApproved,
+		Title: "Permintaan menjadi guru disetujui",
+		Body:  "Anda sekarang dapat membuat kelas dan buku.",
+		RefID: &req.ID,
+	})
+	return nil
 }
 
 func (s *teacherRequestService) RejectRequest(id string) error {
@@ -140,7 +153,16 @@ func (s *teacherRequestService) RejectRequest(id string) error {
 		return errors.New("request already processed")
 	}
 
-	return s.teacherReqRepo.UpdateStatus(id, "rejected")
+	if err := s.teacherReqRepo.UpdateStatus(id, "rejected"); err != nil {
+		return err
+	}
+
+	notify(s.notifier, []uuid.UUID{req.UserID}, entities.Notification{
+		Type:  entities.NotificationTypeTeacherRequestRejected,
+		Title: "Permintaan menjadi guru ditolak",
+		RefID: &req.ID,
+	})
+	return nil
 }
 
 func (s *teacherRequestService) GetStats() (*repositories.TeacherRequestStats, error) {
@@ -2,6 +2,8 @@ package usecases
 
 import (
 	"errors"
+	"strings"
+
 	"hifzhun-api/pkg/entities"
 	"hifzhun-api/pkg/repositories"
 	"hifzhun-api/pkg/services"
@@ -9,7 +11,8 @@ import (
 
 type AuthUsecase interface {
 	Register(user *entities.User) error
-	Login(email, password string) (*entities.User, string, error)
+	Login(login, password string) (*entities.User, string, error)
+	ChangePassword(userID, oldPassword, newPassword string) (string, error)
 }
 
 type authUsecase struct {
@@ -34,8 +37,16 @@ func (u *authUsecase) Register(user *entities.User) error {
 	return u.userRepo.Create(user)
 }
 
-func (u *authUsecase) Login(email, password string) (*entities.User, string, error) {
-	user, err := u.userRepo.FindByEmail(email)
+// Login accepts an email or, for roster-imported students, a username
+func (u *authUsecase) Login(login, password string) (*entities.User, string, error) {
+	login = strings.TrimSpace(login)
+	var user *entities.User
+	var err error
+	if strings.Contains(login, "@") {
+		user, err = u.userRepo.FindByEmail(login)
+	} else {
+		user, err = u.userRepo.FindByUsername(strings.ToLower(login))
+	}
 	if err != nil {
 		return nil, "", errors.New("Invalid email or password")
 	}
@@ -48,7 +59,7 @@ func (u *authUsecase) Login(email, password string) (*entities.User, string, err
 		return nil, "", errors.New("Invalid email or password")
 	}
 
-	token, err := u.authSvc.GenerateToken(user.ID, user.Email, user.Role)
+	token, err := u.authSvc.GenerateToken(user.ID, user.Email, user.Role, user.MustChangePassword)
 	if err != nil {
 		return nil, "", errors.New("failed to generate token")
 	}
@@ -56,3 +67,36 @@ func (u *authUsecase) Login(email, password string) (*entities.User, string, err
 	return user, token, nil
 }
 
+// ChangePassword replaces the password after checking the current one. It
+// also clears the one-time password flag of roster-imported accounts and
+// returns a fresh token, since a token issued while the flag was set only
+// works for changing the password.
+func (u *authUsecase) ChangePassword(userID, oldPassword, newPassword string) (string, error) {
+	if len(newPassword) < 8 {
+		return "", errors.New("new password must be at least 8 characters")
+	}
+	user, err := u.userRepo.FindByID(userID)
+	if err != nil {
+		return "", err
+	}
+	if err := u.authSvc.CheckPassword(user.Password, oldPassword); err != nil {
+		return "", errors.New("current password is incorrect")
+	}
+	if oldPassword == newPassword {
+		return "", errors.New("new password must differ from the current one")
+	}
+
+	hashed, err := u.authSvc.HashPassword(newPassword)
+	if err != nil {
+		return "", err
+	}
+	if err := u.userRepo.UpdatePassword(userID, hashed, false); err != nil {
+		return "", err
+	}
+
+	token, err := u.authSvc.GenerateToken(user.ID, user.Email, user.Role, false)
+	if err != nil {
+		return "", errors.New("failed to generate token")
+	}
+	return token, nil
+}
@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	MaxAttachmentSize   = 10 * 1024 * 1024 // 10 MB
+	AttachmentUploadDir = "uploads/attachments"
+
+	// attachmentObjectDir is the folder of attachments in the Supabase bucket
+	attachmentObjectDir = "attachments/"
+)
+
+// Allowed attachment extensions with their content type
+var attachmentContentTypes = map[string]string{
+	".pdf":  "application/pdf",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".png":  "image/png",
+	".webp": "image/webp",
+	".mp3":  "audio/mpeg",
+	".m4a":  "audio/mp4",
+}
+
+// SaveAttachment validates and saves an uploaded announcement attachment
+// (pdf, image or audio). Returns a public URL when Supabase Storage is
+// configured, otherwise a local relative file path.
+func SaveAttachment(file *multipart.FileHeader) (string, error) {
+	ext := strings.ToLower(filepath.Ext(file.Filename))
+	contentType, ok := attachmentContentTypes[ext]
+	if !ok {
+		return "", errors.New("only pdf, png, jpg, jpeg, webp, mp3 and m4a files are allowed")
+	}
+	if file.Size > MaxAttachmentSize {
+		return "", errors.New("attachment size must be 10MB or less")
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return "", fmt.Errorf("failed to open uploaded file: %w", err)
+	}
+	defer src.Close()
+
+	data, err := io.ReadAll(io.LimitReader(src, MaxAttachmentSize+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to read uploaded file: %w", err)
+	}
+	if len(data) > MaxAttachmentSize {
+		return "", errors.New("attachment size must be 10MB or less")
+	}
+
+	// Documents and images must really be what their extension says
+	sniffed := http.DetectContentType(data)
+	if (ext == ".pdf" || strings.HasPrefix(contentType, "image/")) && sniffed != contentType {
+		return "", errors.New("uploaded file content does not match its extension")
+	}
+
+	storedName := fmt.Sprintf("%s_%d%s", uuid.New().String(), time.Now().Unix(), ext)
+	if supabaseStorageConfigured() {
+		return uploadToSupabase(attachmentObjectDir+storedName, data, contentType)
+	}
+
+	if err := os.MkdirAll(AttachmentUploadDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create upload directory: %w", err)
+	}
+	destPath := filepath.Join(AttachmentUploadDir, storedName)
+	if err := os.WriteFile(destPath, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write output file: %w", err)
+	}
+	return "/" + destPath, nil
+}
+
+// IsAttachmentURL reports whether link points at an attachment stored by
+// SaveAttachment: a file in the Supabase bucket when Supabase Storage is
+// configured, otherwise a local upload.
+func IsAttachmentURL(link string) bool {
+	var prefix string
+	if supabaseStorageConfigured() {
+		prefix = fmt.Sprintf("%s/storage/v1/object/public/%s/%s", supabaseBaseURL(), url.PathEscape(os.Getenv("SUPABASE_BUCKET")), attachmentObjectDir)
+	} else {
+		prefix = "/" + AttachmentUploadDir + "/"
+	}
+	name, ok := strings.CutPrefix(link, prefix)
+	return ok && name != "" && !strings.ContainsAny(name, "/\\?#%")
+}
@@ -0,0 +1,26 @@
+package utils
+
+import "strings"
+
+// csvFormulaPrefixes are the first characters that make a spreadsheet read
+// a CSV cell as a formula
+const csvFormulaPrefixes = "=+-@\t\r"
+
+// CSVSafeCell prefixes text a spreadsheet would read as a formula with an
+// apostrophe, so cells like "=HYPERLINK(...)" stay plain text. Text that
+// already starts with an apostrophe gets another one so CSVUnescapeCell
+// restores it exactly.
+func CSVSafeCell(v string) string {
+	if v != "" && strings.ContainsRune(csvFormulaPrefixes+"'", rune(v[0])) {
+		return "'" + v
+	}
+	return v
+}
+
+// CSVUnescapeCell removes the apostrophe CSVSafeCell added
+func CSVUnescapeCell(v string) string {
+	if len(v) > 1 && v[0] == '\'' && strings.ContainsRune(csvFormulaPrefixes+"'", rune(v[1])) {
+		return v[1:]
+	}
+	return v
+}
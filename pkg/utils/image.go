@@ -55,6 +55,18 @@ func SaveCoverImage(file *multipart.FileHeader) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("failed to read uploaded file: %w", err)
 	}
+
+	return SaveImageBytes(file.Filename, data)
+}
+
+// SaveImageBytes validates and saves image data that did not come from a
+// multipart upload (e.g. media extracted from an imported deck). The file
+// extension of filename decides the allowed format.
+func SaveImageBytes(filename string, data []byte) (string, error) {
+	ext := strings.ToLower(filepath.Ext(filename))
+	if !allowedImageExts[ext] {
+		return "", errors.New("only png, jpg, jpeg, and webp files are allowed")
+	}
 	if int64(len(data)) > MaxImageSize {
 		return "", errors.New("image size must be 3MB or less")
 	}
@@ -69,8 +81,8 @@ func SaveCoverImage(file *multipart.FileHeader) (string, error) {
 	}
 
 	// Generate unique filename
-	filename := fmt.Sprintf("%s_%d%s", uuid.New().String(), time.Now().Unix(), ext)
-	objectPath := "covers/" + filename
+	storedName := fmt.Sprintf("%s_%d%s", uuid.New().String(), time.Now().Unix(), ext)
+	objectPath := "covers/" + storedName
 
 	var buf bytes.Buffer
 	// Encode based on format
@@ -96,10 +108,10 @@ func SaveCoverImage(file *multipart.FileHeader) (string, error) {
 	}
 
 	if supabaseStorageConfigured() {
-		return uploadCoverImageToSupabase(objectPath, buf.Bytes(), contentType)
+		return uploadToSupabase(objectPath, buf.Bytes(), contentType)
 	}
 
-	return saveCoverImageLocally(filename, buf.Bytes())
+	return saveCoverImageLocally(storedName, buf.Bytes())
 }
 
 func saveCoverImageLocally(filename string, data []byte) (string, error) {
@@ -121,7 +133,9 @@ func supabaseStorageConfigured() bool {
 		os.Getenv("SUPABASE_BUCKET") != ""
 }
 
-func uploadCoverImageToSupabase(objectPath string, data []byte, contentType string) (string, error) {
+// uploadToSupabase stores data at objectPath in the Supabase Storage bucket
+// and returns its public URL
+func uploadToSupabase(objectPath string, data []byte, contentType string) (string, error) {
 	baseURL := supabaseBaseURL()
 	apiKey := supabaseAPIKey()
 	bucket := os.Getenv("SUPABASE_BUCKET")
@@ -139,7 +153,7 @@ func uploadCoverImageToSupabase(objectPath string, data []byte, contentType stri
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to upload image to supabase: %w", err)
+		return "", fmt.Errorf("failed to upload file to supabase: %w", err)
 	}
 	defer resp.Body.Close()
 
@@ -10,6 +10,10 @@ type Meta struct {
 	Page    int `json:"page,omitempty"`
 	PerPage int `json:"per_page,omitempty"`
 	Total   int `json:"total,omitempty"`
+
+	// Cursor pagination: pass NextCursor as ?cursor= to get the next page
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more,omitempty"`
 }
 
 type SuccessResponse struct {
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// arabicFold maps hamza/alif variants to their bare letter so that
+// "أحمد", "احمد" and "إحمد" match each other.
+var arabicFold = map[rune]rune{
+	'أ': 'ا', 'إ': 'ا', 'آ': 'ا', 'ٱ': 'ا',
+	'ؤ': 'و',
+	'ئ': 'ي', 'ى': 'ي',
+	'ة': 'ه',
+}
+
+// isArabicMark reports harakat, Quranic annotation marks and tatweel.
+func isArabicMark(r rune) bool {
+	return (r >= 0x064B && r <= 0x065F) || // fathatan .. wavy hamza below
+		r == 0x0670 || // superscript alef
+		(r >= 0x06D6 && r <= 0x06ED) || // Quranic annotation signs
+		r == 0x0640 // tatweel
+}
+
+// NormalizeSearchText prepares text for catalog search: lowercase, Arabic
+// harakat removed, hamza forms folded, everything that is not a letter or
+// digit turned into a single space.
+func NormalizeSearchText(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	space := true
+	for _, r := range s {
+		if isArabicMark(r) || unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		if folded, ok := arabicFold[r]; ok {
+			r = folded
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(unicode.ToLower(r))
+			space = false
+			continue
+		}
+		if !space {
+			b.WriteByte(' ')
+			space = true
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// SearchPrefixQuery turns user input into a Postgres tsquery that matches
+// every word as a prefix ("kitab tauh" → "kitab:* & tauh:*"). Returns "" when
+// nothing searchable is left.
+func SearchPrefixQuery(s string) string {
+	words := strings.Fields(NormalizeSearchText(s))
+	if len(words) == 0 {
+		return ""
+	}
+	for i, w := range words {
+		words[i] = w + ":*"
+	}
+	return strings.Join(words, " & ")
+}
@@ -0,0 +1,163 @@
+// Package xlsx writes single-sheet Office Open XML spreadsheets (.xlsx).
+//
+// An .xlsx file is a zip archive of XML parts. The fixed parts (content
+// types, relationships, workbook) are written up front and the worksheet
+// is written last, one row at a time, so a large sheet is streamed to the
+// underlying writer instead of being built in memory. Strings are stored
+// inline, which avoids the shared strings table.
+package xlsx
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ContentType is the MIME type of an .xlsx file
+const ContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+const (
+	nsMain = "http://schemas.openxmlformats.org/spreadsheetml/2006/main"
+	nsRels = "http://schemas.openxmlformats.org/package/2006/relationships"
+	nsDoc  = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+	header = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n"
+)
+
+var fixedParts = []struct{ name, body string }{
+	{"[Content_Types].xml", header +
+		`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", header +
+		`<Relationships xmlns="` + nsRels + `">` +
+		`<Relationship Id="rId1" Type="` + nsDoc + `/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/_rels/workbook.xml.rels", header +
+		`<Relationships xmlns="` + nsRels + `">` +
+		`<Relationship Id="rId1" Type="` + nsDoc + `/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`},
+}
+
+// Writer streams the rows of one worksheet into an .xlsx file
+type Writer struct {
+	zw    *zip.Writer
+	sheet *bufio.Writer
+	rows  int
+	err   error
+}
+
+// NewWriter starts an .xlsx file with one sheet called sheetName. Sheet
+// names are cut to 31 characters and characters Excel rejects are replaced.
+func NewWriter(w io.Writer, sheetName string) (*Writer, error) {
+	zw := zip.NewWriter(w)
+	for _, part := range fixedParts {
+		if err := writePart(zw, part.name, part.body); err != nil {
+			return nil, err
+		}
+	}
+	var name strings.Builder
+	xml.EscapeText(&name, []byte(cleanSheetName(sheetName)))
+	workbook := header +
+		`<workbook xmlns="` + nsMain + `" xmlns:r="` + nsDoc + `">` +
+		`<sheets><sheet name="` + name.String() + `" sheetId="1" r:id="rId1"/></sheets>` +
+		`</workbook>`
+	if err := writePart(zw, "xl/workbook.xml", workbook); err != nil {
+		return nil, err
+	}
+
+	part, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	sheet := bufio.NewWriter(part)
+	sheet.WriteString(header + `<worksheet xmlns="` + nsMain + `"><sheetData>`)
+	return &Writer{zw: zw, sheet: sheet}, nil
+}
+
+func writePart(zw *zip.Writer, name, body string) error {
+	part, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(part, body)
+	return err
+}
+
+func cleanSheetName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '-'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if runes := []rune(name); len(runes) > 31 {
+		name = string(runes[:31])
+	}
+	if name == "" {
+		return "Sheet1"
+	}
+	return name
+}
+
+// WriteRow appends a row. Strings are written as text, integers and floats
+// as numbers and nil as an empty cell.
+func (w *Writer) WriteRow(cells ...any) error {
+	if w.err != nil {
+		return w.err
+	}
+	w.rows++
+	fmt.Fprintf(w.sheet, `<row r="%d">`, w.rows)
+	for i, cell := range cells {
+		ref := columnName(i) + strconv.Itoa(w.rows)
+		switch v := cell.(type) {
+		case nil:
+			continue
+		case string:
+			fmt.Fprintf(w.sheet, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+			xml.EscapeText(w.sheet, []byte(v))
+			w.sheet.WriteString(`</t></is></c>`)
+		case int:
+			fmt.Fprintf(w.sheet, `<c r="%s"><v>%d</v></c>`, ref, v)
+		case int64:
+			fmt.Fprintf(w.sheet, `<c r="%s"><v>%d</v></c>`, ref, v)
+		case float64:
+			fmt.Fprintf(w.sheet, `<c r="%s"><v>%s</v></c>`, ref, strconv.FormatFloat(v, 'f', -1, 64))
+		default:
+			w.err = fmt.Errorf("xlsx: unsupported cell type %T", cell)
+			return w.err
+		}
+	}
+	_, w.err = w.sheet.WriteString(`</row>`)
+	return w.err
+}
+
+// Close finishes the sheet and the zip archive. It does not close the
+// underlying writer.
+func (w *Writer) Close() error {
+	if w.err != nil {
+		return w.err
+	}
+	w.sheet.WriteString(`</sheetData></worksheet>`)
+	if err := w.sheet.Flush(); err != nil {
+		return err
+	}
+	w.err = errors.New("xlsx: writer is closed")
+	return w.zw.Close()
+}
+
+// columnName turns a zero-based column index into its letters (0 → A, 26 → AA)
+func columnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
@@ -0,0 +1,138 @@
+package xlsx_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"testing"
+
+	"hifzhun-api/pkg/xlsx"
+)
+
+type sheetXML struct {
+	Rows []struct {
+		R     int `xml:"r,attr"`
+		Cells []struct {
+			R      string `xml:"r,attr"`
+			T      string `xml:"t,attr"`
+			Value  string `xml:"v"`
+			Inline string `xml:"is>t"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+type workbookXML struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+func readPart(t *testing.T, zr *zip.Reader, name string, v any) {
+	t.Helper()
+	f, err := zr.Open(name)
+	if err != nil {
+		t.Fatalf("open %s: %v", name, err)
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatalf("read %s: %v", name, err)
+	}
+	if err := xml.Unmarshal(data, v); err != nil {
+		t.Fatalf("parse %s: %v", name, err)
+	}
+}
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := xlsx.NewWriter(&buf, "Progres <A&B>: kelas/1")
+	if err != nil {
+		t.Fatalf("new writer: %v", err)
+	}
+	if err := w.WriteRow("Nama", "Catatan", nil, 42, int64(7), 3.25); err != nil {
+		t.Fatalf("write row: %v", err)
+	}
+	// 28 cells reach column AB
+	wide := make([]any, 28)
+	for i := range wide {
+		wide[i] = i
+	}
+	wide[27] = `<b>"Ali" & 'Umar'</b>`
+	if err := w.WriteRow(wide...); err != nil {
+		t.Fatalf("write wide row: %v", err)
+	}
+	if err := w.WriteRow(struct{}{}); err == nil {
+		t.Error("accepted an unsupported cell type")
+	}
+	if err := w.Close(); err == nil {
+		t.Error("closed after a failed row")
+	}
+
+	// A writer that only got valid rows produces a readable file
+	buf.Reset()
+	w, _ = xlsx.NewWriter(&buf, "Progres <A&B>: kelas/1")
+	w.WriteRow("Nama", "Catatan", nil, 42, int64(7), 3.25)
+	w.WriteRow(wide...)
+	if err := w.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if err := w.WriteRow("late"); err == nil {
+		t.Error("wrote a row after close")
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("open zip: %v", err)
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/_rels/workbook.xml.rels"} {
+		var v struct{}
+		readPart(t, zr, name, &v)
+	}
+
+	var wb workbookXML
+	readPart(t, zr, "xl/workbook.xml", &wb)
+	if len(wb.Sheets) != 1 || wb.Sheets[0].Name != "Progres <A&B>- kelas-1" {
+		t.Errorf("sheets = %+v", wb.Sheets)
+	}
+
+	var sheet sheetXML
+	readPart(t, zr, "xl/worksheets/sheet1.xml", &sheet)
+	if len(sheet.Rows) != 2 || sheet.Rows[0].R != 1 || sheet.Rows[1].R != 2 {
+		t.Fatalf("rows = %+v", sheet.Rows)
+	}
+
+	first := sheet.Rows[0].Cells
+	if len(first) != 5 {
+		t.Fatalf("first row cells = %+v", first)
+	}
+	if c := first[0]; c.R != "A1" || c.T != "inlineStr" || c.Inline != "Nama" {
+		t.Errorf("A1 = %+v", c)
+	}
+	// The nil cell in C1 is skipped
+	if c := first[2]; c.R != "D1" || c.T != "" || c.Value != "42" {
+		t.Errorf("D1 = %+v", c)
+	}
+	if c := first[3]; c.R != "E1" || c.Value != "7" {
+		t.Errorf("E1 = %+v", c)
+	}
+	if c := first[4]; c.R != "F1" || c.Value != "3.25" {
+		t.Errorf("F1 = %+v", c)
+	}
+
+	second := sheet.Rows[1].Cells
+	if len(second) != 28 {
+		t.Fatalf("second row has %d cells", len(second))
+	}
+	for i, want := range map[int]string{0: "A2", 25: "Z2", 26: "AA2", 27: "AB2"} {
+		if second[i].R != want {
+			t.Errorf("cell %d ref = %s, want %s", i, second[i].R, want)
+		}
+	}
+	if c := second[26]; c.Value != "26" {
+		t.Errorf("AA2 = %+v", c)
+	}
+	if c := second[27]; c.T != "inlineStr" || c.Inline != `<b>"Ali" & 'Umar'</b>` {
+		t.Errorf("AB2 = %+v", c)
+	}
+}